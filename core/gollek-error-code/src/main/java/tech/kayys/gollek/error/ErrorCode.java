@@ -92,13 +92,16 @@ public enum ErrorCode {
     INIT_CONFIGURATION_INVALID(ErrorCategory.INIT, 500, "INIT_004", "Invalid configuration", false),
     INIT_DEPENDENCY_MISSING(ErrorCategory.INIT, 500, "INIT_005", "Required dependency missing", false),
 
-    // ===== Runtime Execution Errors (500, 504) =====
+    // ===== Runtime Execution Errors (500, 503, 504) =====
     RUNTIME_INFERENCE_FAILED(ErrorCategory.RUNTIME, 500, "RUNTIME_001", "Inference execution failed", true),
     RUNTIME_TIMEOUT(ErrorCategory.RUNTIME, 504, "RUNTIME_002", "Inference request timeout", true),
     RUNTIME_OUT_OF_MEMORY(ErrorCategory.RUNTIME, 500, "RUNTIME_003", "Out of memory during inference", true),
     RUNTIME_NATIVE_CRASH(ErrorCategory.RUNTIME, 500, "RUNTIME_004", "Native library crashed", true),
     RUNTIME_INVALID_STATE(ErrorCategory.RUNTIME, 500, "RUNTIME_005", "Invalid runner state", false),
     RUNTIME_BATCH_SIZE_EXCEEDED(ErrorCategory.RUNTIME, 400, "RUNTIME_006", "Batch size exceeds limit", false),
+    RUNTIME_QUEUE_FULL(ErrorCategory.RUNTIME, 503, "RUNTIME_007", "Runner request queue is full", true),
+    RUNTIME_QUEUE_TIMEOUT(ErrorCategory.RUNTIME, 503, "RUNTIME_008", "Request waited too long for a runner", true),
+    RUNTIME_PREFILL_TIMEOUT(ErrorCategory.RUNTIME, 504, "RUNTIME_009", "Prompt processing timeout", true),
 
     // ===== Storage Errors (500, 503) =====
     STORAGE_READ_FAILED(ErrorCategory.STORAGE, 500, "STORAGE_001", "Failed to read from storage", true),
@@ -265,11 +265,23 @@ public final class InferenceRequest {
     }
 
     // Parameter accessors
+
+    /**
+     * Sampling temperature. An explicit {@code 0} selects greedy (argmax) decoding;
+     * use {@link #hasParameter(String)} to tell an explicit value from the default.
+     */
     public double getTemperature() {
         Object val = parameters.get("temperature");
         return val instanceof Number n ? n.doubleValue() : 0.2;
     }
 
+    /**
+     * Whether the client supplied {@code key} explicitly (as opposed to relying on defaults).
+     */
+    public boolean hasParameter(String key) {
+        return parameters.get(key) != null;
+    }
+
     public double getTopP() {
         Object val = parameters.get("top_p");
         return val instanceof Number n ? n.doubleValue() : 0.9;
@@ -295,6 +307,15 @@ public final class InferenceRequest {
         return val instanceof Boolean b ? b : false;
     }
 
+    /**
+     * When true, a request that hits its deadline returns the tokens generated so far
+     * with {@link InferenceResponse.FinishReason#TIMEOUT} instead of failing.
+     */
+    public boolean isReturnPartialOnTimeout() {
+        Object val = parameters.get("return_partial_on_timeout");
+        return val instanceof Boolean b ? b : false;
+    }
+
     public int getMirostat() {
         Object val = parameters.get("mirostat");
         return val instanceof Number n ? n.intValue() : 0;
@@ -459,6 +480,11 @@ public final class InferenceRequest {
             return this;
         }
 
+        public Builder returnPartialOnTimeout(boolean returnPartial) {
+            this.parameters.put("return_partial_on_timeout", returnPartial);
+            return this;
+        }
+
         public Builder mirostat(int mirostat) {
             this.parameters.put("mirostat", mirostat);
             return this;
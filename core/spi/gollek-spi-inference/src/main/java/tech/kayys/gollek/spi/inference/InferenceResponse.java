@@ -38,6 +38,7 @@ public final class InferenceResponse implements InferenceResponseInterface {
     private final List<ToolCall> toolCalls;
     private final FinishReason finishReason;
     private final String sessionId;
+    private final List<String> warnings;
 
     /**
      * Reason for stopping generation.
@@ -46,6 +47,10 @@ public final class InferenceResponse implements InferenceResponseInterface {
         STOP, // Normal completion (EOS token)
         TOOL_CALLS, // Model wants to call tools
         LENGTH, // Hit max_tokens limit
+        TIMEOUT, // Deadline reached; content holds the partial output
+        TIME_LIMIT, // Client's max_time_ms reached; content holds the partial output
+        STALLED, // No token within the inter-token timeout; content holds the partial output
+        CANCELLED, // Client went away; content holds the partial output
         ERROR // Error during generation
     }
 
@@ -71,7 +76,8 @@ public final class InferenceResponse implements InferenceResponseInterface {
             @JsonProperty("metadata") Map<String, Object> metadata,
             @JsonProperty("toolCalls") List<ToolCall> toolCalls,
             @JsonProperty("finishReason") FinishReason finishReason,
-            @JsonProperty("sessionId") String sessionId) {
+            @JsonProperty("sessionId") String sessionId,
+            @JsonProperty("warnings") List<String> warnings) {
         this.requestId = Objects.requireNonNull(requestId, "requestId");
         this.content = Objects.requireNonNull(content, "content");
         this.model = model;
@@ -88,6 +94,16 @@ public final class InferenceResponse implements InferenceResponseInterface {
                 : Collections.emptyList();
         this.finishReason = finishReason != null ? finishReason : FinishReason.STOP;
         this.sessionId = sessionId;
+        this.warnings = warnings != null
+                ? Collections.unmodifiableList(new ArrayList<>(warnings))
+                : Collections.emptyList();
+    }
+
+    public InferenceResponse(String requestId, String content, String model, int tokensUsed, int inputTokens,
+            int outputTokens, long durationMs, Instant timestamp, Map<String, Object> metadata,
+            List<ToolCall> toolCalls, FinishReason finishReason, String sessionId) {
+        this(requestId, content, model, tokensUsed, inputTokens, outputTokens, durationMs, timestamp, metadata,
+                toolCalls, finishReason, sessionId, null);
     }
 
     // Getters
@@ -148,6 +164,14 @@ public final class InferenceResponse implements InferenceResponseInterface {
         return sessionId;
     }
 
+    /**
+     * Non-fatal notices about how the request was adjusted (clamped parameters,
+     * truncated prompt, ...). Empty when the request ran as given.
+     */
+    public List<String> getWarnings() {
+        return warnings;
+    }
+
     public boolean hasToolCalls() {
         return toolCalls != null && !toolCalls.isEmpty();
     }
@@ -171,7 +195,8 @@ public final class InferenceResponse implements InferenceResponseInterface {
                 .metadata(metadata)
                 .toolCalls(toolCalls)
                 .finishReason(finishReason)
-                .sessionId(sessionId);
+                .sessionId(sessionId)
+                .warnings(warnings);
     }
 
     public static Builder builder() {
@@ -191,6 +216,7 @@ public final class InferenceResponse implements InferenceResponseInterface {
         private final List<ToolCall> toolCalls = new ArrayList<>();
         private FinishReason finishReason = FinishReason.STOP;
         private String sessionId;
+        private final List<String> warnings = new ArrayList<>();
 
         public Builder requestId(String requestId) {
             this.requestId = requestId;
@@ -262,12 +288,22 @@ public final class InferenceResponse implements InferenceResponseInterface {
             return this;
         }
 
+        public Builder warning(String warning) {
+            this.warnings.add(warning);
+            return this;
+        }
+
+        public Builder warnings(List<String> warnings) {
+            this.warnings.addAll(warnings);
+            return this;
+        }
+
         public InferenceResponse build() {
             Objects.requireNonNull(requestId, "requestId is required");
             Objects.requireNonNull(content, "content is required");
             return new InferenceResponse(
                     requestId, content, model, tokensUsed, inputTokens, outputTokens,
-                    durationMs, timestamp, metadata, toolCalls, finishReason, sessionId);
+                    durationMs, timestamp, metadata, toolCalls, finishReason, sessionId, warnings);
         }
     }
 
@@ -0,0 +1,46 @@
+package tech.kayys.gollek.spi.inference;
+
+import java.util.Map;
+import java.util.concurrent.ConcurrentHashMap;
+import java.util.concurrent.TimeUnit;
+
+/**
+ * Process-wide cancellation signal keyed by request id. Transports (HTTP, SSE, gRPC)
+ * cancel a request when its client goes away; runners poll {@link #isCancelled} between
+ * tokens, stop generating, and {@link #clear} the id when done.
+ *
+ * <p>The signal is keyed by id rather than carried on the request object because
+ * requests are copied and rebuilt on their way through the SDK and providers.
+ */
+public final class RequestCancellation {
+
+    /** Cancellations for requests that never reach a runner are dropped after this. */
+    private static final long RETENTION_NANOS = TimeUnit.MINUTES.toNanos(10);
+
+    private static final Map<String, Long> CANCELLED = new ConcurrentHashMap<>();
+
+    private RequestCancellation() {
+    }
+
+    public static void cancel(String requestId) {
+        if (requestId == null) {
+            return;
+        }
+        long now = System.nanoTime();
+        CANCELLED.values().removeIf(at -> now - at > RETENTION_NANOS);
+        CANCELLED.put(requestId, now);
+    }
+
+    public static boolean isCancelled(String requestId) {
+        return requestId != null && CANCELLED.containsKey(requestId);
+    }
+
+    /**
+     * Forget a request once it has finished, cancelled or not.
+     */
+    public static void clear(String requestId) {
+        if (requestId != null) {
+            CANCELLED.remove(requestId);
+        }
+    }
+}
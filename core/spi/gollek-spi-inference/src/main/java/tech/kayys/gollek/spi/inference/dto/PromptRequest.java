@@ -18,7 +18,8 @@ public record PromptRequest(
 ) {
     public PromptRequest {
         if (maxTokens <= 0) maxTokens = 256;
-        if (temperature <= 0) temperature = 1.0;
+        // 0 is a valid value meaning greedy decoding; only negative values are invalid
+        if (temperature < 0) temperature = 1.0;
         if (topP <= 0 || topP > 1.0) topP = 1.0;
     }
 
@@ -0,0 +1,28 @@
+package tech.kayys.gollek.spi.inference;
+
+import org.junit.jupiter.api.Test;
+
+import static org.junit.jupiter.api.Assertions.*;
+
+public class RequestCancellationTest {
+
+    @Test
+    public void testCancelAndClear() {
+        String id = "req-cancel-1";
+        assertFalse(RequestCancellation.isCancelled(id));
+
+        RequestCancellation.cancel(id);
+        assertTrue(RequestCancellation.isCancelled(id));
+        assertFalse(RequestCancellation.isCancelled("req-cancel-2"));
+
+        RequestCancellation.clear(id);
+        assertFalse(RequestCancellation.isCancelled(id));
+    }
+
+    @Test
+    public void testNullIdsAreIgnored() {
+        RequestCancellation.cancel(null);
+        RequestCancellation.clear(null);
+        assertFalse(RequestCancellation.isCancelled(null));
+    }
+}
@@ -159,6 +159,14 @@ public final class ProviderRequest {
         return Optional.empty();
     }
 
+    /**
+     * Whether {@code key} was supplied explicitly. Use this (or {@link #getParameter})
+     * rather than the defaulting getters when a zero value must be honored.
+     */
+    public boolean hasParameter(String key) {
+        return parameters.get(key) != null;
+    }
+
     public double getTemperature() {
         return getParameter("temperature", Number.class)
                 .map(Number::doubleValue)
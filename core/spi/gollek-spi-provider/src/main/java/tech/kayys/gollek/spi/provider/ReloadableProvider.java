@@ -0,0 +1,57 @@
+package tech.kayys.gollek.spi.provider;
+
+import java.time.Duration;
+
+/**
+ * Extension for providers that can swap a model's weights in place, without a restart.
+ */
+public interface ReloadableProvider {
+
+    /**
+     * Outcome of a reload. {@code poolsSwapped} is zero when the model was not loaded;
+     * the new file is then used on first load. {@code gpuLayers} is the offload the new
+     * instances were asked for, or null when the reload did not change it.
+     */
+    record ModelReload(String modelId, String modelPath, int poolsSwapped, int sessionsRetired, long loadMillis,
+            Integer gpuLayers) {
+
+        public ModelReload(String modelId, String modelPath, int poolsSwapped, int sessionsRetired, long loadMillis) {
+            this(modelId, modelPath, poolsSwapped, sessionsRetired, loadMillis, null);
+        }
+    }
+
+    /**
+     * How to reload. {@code gpuLayers} changes how many layers are offloaded (-1 for all,
+     * 0 for none; null keeps the current value) and applies to later loads of the model
+     * too. With {@code drain}, new requests wait while in-flight ones finish and the old
+     * instances are unloaded before the new ones load, so the memory they held (VRAM,
+     * mostly) is free for them; {@code drainTimeout} bounds that wait.
+     */
+    record ReloadOptions(Integer gpuLayers, boolean drain, Duration drainTimeout) {
+
+        public static final ReloadOptions DEFAULT = new ReloadOptions(null, false, Duration.ZERO);
+    }
+
+    /**
+     * Load {@code modelPath} for {@code modelId}, warm it up, and swap it in for new
+     * requests; the replaced instances are freed once their in-flight requests finish.
+     * A null path reloads the model's current file.
+     *
+     * @throws IllegalArgumentException if the file does not exist
+     */
+    ModelReload reloadModel(String modelId, String modelPath);
+
+    /**
+     * As {@link #reloadModel(String, String)}, with {@code options}.
+     *
+     * @throws IllegalArgumentException if the file does not exist
+     * @throws IllegalStateException if draining did not finish within the timeout
+     * @throws UnsupportedOperationException if the provider cannot apply the options
+     */
+    default ModelReload reloadModel(String modelId, String modelPath, ReloadOptions options) {
+        if (options.gpuLayers() != null || options.drain()) {
+            throw new UnsupportedOperationException("GPU offload reloads are not supported by this provider");
+        }
+        return reloadModel(modelId, modelPath);
+    }
+}
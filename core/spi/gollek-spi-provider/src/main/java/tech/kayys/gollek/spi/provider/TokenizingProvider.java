@@ -0,0 +1,26 @@
+package tech.kayys.gollek.spi.provider;
+
+/**
+ * Extension for providers that can expose a model's tokenizer, so clients can budget the
+ * context window without bundling the model.
+ */
+public interface TokenizingProvider {
+
+    /**
+     * Token ids of {@code text}. {@code addSpecial} adds the model's BOS/EOS tokens as it
+     * would for a prompt; special-token text such as {@code <|im_start|>} is always parsed.
+     *
+     * @throws IllegalArgumentException if the model is unknown to this provider
+     */
+    int[] tokenize(String modelId, String text, boolean addSpecial);
+
+    /**
+     * Text of {@code tokens}, joined as the model decodes them.
+     */
+    String detokenize(String modelId, int[] tokens);
+
+    /**
+     * Text of a single token as the model's vocabulary spells it.
+     */
+    String tokenPiece(String modelId, int token);
+}
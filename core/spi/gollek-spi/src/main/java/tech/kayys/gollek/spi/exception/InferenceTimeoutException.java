@@ -0,0 +1,39 @@
+package tech.kayys.gollek.spi.exception;
+
+import tech.kayys.gollek.error.ErrorCode;
+
+/**
+ * A request ran out of one of its time budgets before producing any output. Which one is
+ * {@link #stage()}: waiting for the runner ({@code 503}), processing the prompt or the
+ * whole request ({@code 504}). A gap between tokens is not an error; generation stops
+ * with finish reason {@code stalled} and keeps what it produced.
+ */
+public class InferenceTimeoutException extends InferenceException {
+
+    public enum Stage {
+        QUEUE(ErrorCode.RUNTIME_QUEUE_TIMEOUT),
+        PREFILL(ErrorCode.RUNTIME_PREFILL_TIMEOUT),
+        TOTAL(ErrorCode.RUNTIME_TIMEOUT);
+
+        private final ErrorCode errorCode;
+
+        Stage(ErrorCode errorCode) {
+            this.errorCode = errorCode;
+        }
+
+        public ErrorCode errorCode() {
+            return errorCode;
+        }
+    }
+
+    private final Stage stage;
+
+    public InferenceTimeoutException(Stage stage, String message) {
+        super(stage.errorCode(), message);
+        this.stage = stage;
+    }
+
+    public Stage stage() {
+        return stage;
+    }
+}
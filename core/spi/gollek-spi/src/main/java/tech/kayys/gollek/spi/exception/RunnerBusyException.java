@@ -0,0 +1,22 @@
+package tech.kayys.gollek.spi.exception;
+
+import tech.kayys.gollek.error.ErrorCode;
+
+/**
+ * A runner turned a request away because its queue stayed full for the whole queue-wait
+ * budget. Servers answer it with 503 and a {@code Retry-After} of
+ * {@link #retryAfterSeconds()}, the runner's estimate of when a place frees up.
+ */
+public class RunnerBusyException extends InferenceException {
+
+    private final long retryAfterSeconds;
+
+    public RunnerBusyException(String message, long retryAfterSeconds) {
+        super(ErrorCode.RUNTIME_QUEUE_FULL, message);
+        this.retryAfterSeconds = Math.max(1, retryAfterSeconds);
+    }
+
+    public long retryAfterSeconds() {
+        return retryAfterSeconds;
+    }
+}
@@ -42,6 +42,12 @@ public enum DeviceType {
      */
     METAL("metal", "Apple Metal GPU", 9, true),
 
+    /**
+     * Vulkan GPU acceleration.
+     * Vendor-neutral compute on NVIDIA, AMD and Intel GPUs.
+     */
+    VULKAN("vulkan", "Vulkan GPU", 8, true),
+
     /**
      * Google Cloud TPU (Tensor Processing Unit).
      * Optimized for TensorFlow and JAX workloads.
@@ -128,7 +134,8 @@ public enum DeviceType {
      * Check if this device type is GPU-based.
      */
     public boolean isGpu() {
-        return this == CUDA || this == ROCM || this == INTEL_GPU || this == METAL || this == DIRECTML || this == WEBGPU;
+        return this == CUDA || this == ROCM || this == INTEL_GPU || this == METAL || this == VULKAN || this == DIRECTML
+                || this == WEBGPU;
     }
 
     /**
@@ -259,7 +259,7 @@ public final class GollekConfig {
             if (maxBatchSize <= 0) maxBatchSize = 128;
             if (storageMode == null) storageMode = "TURBOQUANT_3BIT";
             if (draftTokens <= 0) draftTokens = 5;
-            if (temperature <= 0) temperature = 1.0;
+            if (temperature < 0) temperature = 1.0;
             if (topP <= 0 || topP > 1.0) topP = 1.0;
         }
     }
@@ -14,6 +14,7 @@ import java.util.Map;
 import java.util.concurrent.ConcurrentHashMap;
 import java.util.concurrent.atomic.AtomicBoolean;
 import java.util.concurrent.atomic.AtomicInteger;
+import java.util.concurrent.atomic.LongAdder;
 
 /**
  * Default implementation of {@link HealthCheckService}.
@@ -103,6 +104,29 @@ public final class DefaultHealthCheckService implements HealthCheckService {
         }
     }
 
+    /**
+     * Marks a model as failed; liveness reports it until it is registered again.
+     */
+    public void markModelFailed(String modelId, String errorMessage) {
+        ModelHealthEntry info = models.get(modelId);
+        if (info != null) {
+            info.errorMessage = errorMessage != null ? errorMessage : "";
+            info.failed = true;
+            LOG.warnf("Model failed: %s (%s)", modelId, info.errorMessage);
+        }
+    }
+
+    /**
+     * Records a completed inference for the model's count and average latency.
+     */
+    public void recordInference(String modelId, long latencyMs) {
+        ModelHealthEntry info = models.get(modelId);
+        if (info != null) {
+            info.inferenceCount.increment();
+            info.totalLatencyMs.add(Math.max(0, latencyMs));
+        }
+    }
+
     /**
      * Registers a scheduler for health tracking.
      */
@@ -255,8 +279,8 @@ public final class DefaultHealthCheckService implements HealthCheckService {
                 info.warmed,
                 1,  // loadedVersions
                 info.loadedAt,
-                0,  // inferenceCount (would come from metrics)
-                0.0,  // avgLatencyMs
+                info.inferenceCount.sum(),
+                info.avgLatencyMs(),
                 status
             ));
         }
@@ -368,15 +392,22 @@ public final class DefaultHealthCheckService implements HealthCheckService {
      */
     private static final class ModelHealthEntry {
         final String modelId;
-        boolean loaded = false;
-        boolean warmed = false;
-        boolean failed = false;
-        String errorMessage = "";
-        LocalDateTime loadedAt = null;
+        volatile boolean loaded = false;
+        volatile boolean warmed = false;
+        volatile boolean failed = false;
+        volatile String errorMessage = "";
+        volatile LocalDateTime loadedAt = null;
+        final LongAdder inferenceCount = new LongAdder();
+        final LongAdder totalLatencyMs = new LongAdder();
 
         ModelHealthEntry(String modelId) {
             this.modelId = modelId;
         }
+
+        double avgLatencyMs() {
+            long count = inferenceCount.sum();
+            return count == 0 ? 0.0 : (double) totalLatencyMs.sum() / count;
+        }
     }
 
     /**
@@ -120,6 +120,16 @@ public interface HealthCheckService {
      */
     void markModelWarmed(String modelId);
 
+    /**
+     * Marks a model as failed; liveness reports it until it is registered again.
+     */
+    void markModelFailed(String modelId, String errorMessage);
+
+    /**
+     * Records a completed inference so model health reports real counts and latency.
+     */
+    void recordInference(String modelId, long latencyMs);
+
     /**
      * Creates a builder for configuring this service.
      */
@@ -402,6 +402,7 @@ public final class InferenceService {
         }
 
         gracefulShutdown.requestStarted();
+        Instant start = Instant.now();
 
         try {
             // 6. Submit to scheduler
@@ -411,6 +412,7 @@ public final class InferenceService {
             if (trace != null) {
                 trace.recordSuccess();
             }
+            healthCheck.recordInference(modelName, Duration.between(start, Instant.now()).toMillis());
 
             return response;
 
@@ -458,6 +460,7 @@ public final class InferenceService {
         }
 
         gracefulShutdown.requestStarted();
+        Instant start = Instant.now();
 
         try {
             executeStreamingInference(request, context, trace, tokenCallback);
@@ -465,6 +468,7 @@ public final class InferenceService {
             if (trace != null) {
                 trace.recordSuccess();
             }
+            healthCheck.recordInference(modelName, Duration.between(start, Instant.now()).toMillis());
         } catch (Exception e) {
             if (trace != null) {
                 trace.recordError(e);
@@ -0,0 +1,48 @@
+package tech.kayys.gollek.runtime.inference.health;
+
+import org.junit.jupiter.api.Test;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class DefaultHealthCheckServiceTest {
+
+    @Test
+    void modelHealthReportsRecordedInferencesAndAverageLatency() {
+        DefaultHealthCheckService health = DefaultHealthCheckService.builder().build();
+        health.registerModel("m");
+        health.markModelLoaded("m");
+
+        assertEquals(0, health.getHealthDetails().modelHealth().get("m").inferenceCount());
+        assertEquals(0.0, health.getHealthDetails().modelHealth().get("m").avgLatencyMs());
+
+        health.recordInference("m", 100);
+        health.recordInference("m", 300);
+        // unregistered models are ignored
+        health.recordInference("other", 50);
+
+        HealthCheckService.ModelHealth model = health.getHealthDetails().modelHealth().get("m");
+        assertEquals(2, model.inferenceCount());
+        assertEquals(200.0, model.avgLatencyMs());
+        assertFalse(health.getHealthDetails().modelHealth().containsKey("other"));
+    }
+
+    @Test
+    void failedModelsFailLivenessUntilRegisteredAgain() {
+        DefaultHealthCheckService health = DefaultHealthCheckService.builder().build();
+        health.registerModel("m");
+        health.markModelLoaded("m");
+        assertTrue(health.checkLiveness().healthy());
+
+        health.markModelFailed("m", "out of memory");
+
+        HealthCheckService.HealthResult liveness = health.checkLiveness();
+        assertFalse(liveness.healthy());
+        assertEquals("out of memory", liveness.details().get("error"));
+        assertEquals("failed", health.getHealthDetails().modelHealth().get("m").status());
+
+        health.registerModel("m");
+        assertTrue(health.checkLiveness().healthy());
+    }
+}
@@ -186,17 +186,15 @@ public class LlamaCppMultimodalProcessor implements MultimodalProcessor {
             }
 
             // 3. Build final prompt and generate
-            String response = generateMultimodalText(inputData.textPrompt, request.getOutputConfig(), itemEmbeddings);
+            Generation generation = generateMultimodalText(inputData.textPrompt, request.getOutputConfig(), itemEmbeddings);
 
             long durationMs = System.currentTimeMillis() - startTime;
 
             return MultimodalResponse.builder()
                     .requestId(request.getRequestId())
                     .model(request.getModel())
-                    .outputs(MultimodalContent.ofText(response))
-                    .usage(new MultimodalResponse.Usage(
-                            estimateTokens(inputData.textPrompt),
-                            estimateTokens(response)))
+                    .outputs(MultimodalContent.ofText(generation.text()))
+                    .usage(new MultimodalResponse.Usage(generation.promptTokens(), generation.completionTokens()))
                     .durationMs(durationMs)
                     .metadata(Map.of(
                             "processor", "gguf-multimodal",
@@ -213,7 +211,11 @@ public class LlamaCppMultimodalProcessor implements MultimodalProcessor {
         }
     }
 
-    private String generateMultimodalText(String textPrompt, MultimodalRequest.OutputConfig config,
+    /** Generated text with exact prompt (text tokens plus embedding positions) and completion token counts. */
+    private record Generation(String text, int promptTokens, int completionTokens) {
+    }
+
+    private Generation generateMultimodalText(String textPrompt, MultimodalRequest.OutputConfig config,
             List<MemorySegment> embeddings) throws InferenceException {
         try {
             int[] tokens = llamaCppBinding.tokenize(modelHandle, textPrompt, true, true);
@@ -225,6 +227,12 @@ public class LlamaCppMultimodalProcessor implements MultimodalProcessor {
             for (MemorySegment e : embeddings) {
                 totalSequenceLen += (int) (e.byteSize() / (nEmbed * 4L));
             }
+            int contextSize = providerConfig.maxContextTokens();
+            if (contextSize > 0 && totalSequenceLen > contextSize) {
+                throw new InferenceException(ErrorCode.VALIDATION_CONSTRAINT_VIOLATION,
+                        "Prompt is " + totalSequenceLen + " tokens, which exceeds the context window of "
+                                + contextSize + " tokens");
+            }
 
             MemorySegment batch = llamaCppBinding.batchInit(totalSequenceLen, 0, 1);
             int batchIdx = 0;
@@ -251,8 +259,10 @@ public class LlamaCppMultimodalProcessor implements MultimodalProcessor {
             }
 
             // 3. Generation loop
-            return runAutoregressiveLoop(config, pos);
+            return runAutoregressiveLoop(config, pos, totalSequenceLen);
 
+        } catch (InferenceException e) {
+            throw e;
         } catch (Throwable e) {
             log.errorf("GGUF generation failed: %s", e.getMessage());
             throw new InferenceException(
@@ -262,7 +272,8 @@ public class LlamaCppMultimodalProcessor implements MultimodalProcessor {
         }
     }
 
-    private String runAutoregressiveLoop(MultimodalRequest.OutputConfig config, int startPos) throws Exception {
+    private Generation runAutoregressiveLoop(MultimodalRequest.OutputConfig config, int startPos, int promptTokens)
+            throws Exception {
         MemorySegment sampler = llamaCppBinding.createSamplerChain();
         float temp = config != null ? (float) config.getTemperature() : 0.7f;
         if (temp <= 0) {
@@ -297,7 +308,7 @@ public class LlamaCppMultimodalProcessor implements MultimodalProcessor {
 
         llamaCppBinding.freeSampler(sampler);
         llamaCppBinding.batchFree(batch);
-        return sb.toString();
+        return new Generation(sb.toString(), promptTokens, nGenerated);
     }
 
     /**
@@ -392,13 +403,6 @@ public class LlamaCppMultimodalProcessor implements MultimodalProcessor {
         return MemorySegment.NULL;
     }
 
-    /**
-     * Estimate token count.
-     */
-    private int estimateTokens(String text) {
-        return Math.max(1, text.length() / 4);
-    }
-
     /**
      * Shutdown the processor.
      */
@@ -0,0 +1,132 @@
+# Windows counterpart of build-llama-cpp.sh, run by the Maven "windows" profile.
+# Builds llama.cpp as DLLs with CMake (MSVC by default) and the gollek shim, and copies
+# llama.dll, ggml*.dll and gollek_llama_shim.dll to target\llama-cpp\lib.
+#
+# GOLLEK_LLAMA_SOURCE_DIR  llama.cpp checkout (default %USERPROFILE%\.gollek\source\vendor\llama.cpp)
+# GOLLEK_GGML_CUDA         ON to build the CUDA backend (default: ON when CUDA_PATH is set)
+# GOLLEK_GGML_VULKAN       ON to build the Vulkan backend (default OFF)
+# GOLLEK_GGML_BACKEND_DL   ON to build backends as loadable modules, with all CPU variants
+$ErrorActionPreference = "Stop"
+
+$BaseDir = (Get-Location).Path
+$DefaultLlamaSource = Join-Path $env:USERPROFILE ".gollek\source\vendor\llama.cpp"
+$LlamaSourceDir = if ($env:GOLLEK_LLAMA_SOURCE_DIR) { $env:GOLLEK_LLAMA_SOURCE_DIR } else { $DefaultLlamaSource }
+
+# Primary build output goes to source directory
+$SourceBuildDir = Join-Path $LlamaSourceDir "build"
+$SourceOutputDir = Join-Path $SourceBuildDir "bin"
+
+# Secondary output for backward compatibility
+$OutputDir = Join-Path $BaseDir "target\llama-cpp\lib"
+
+Write-Host "Running build-llama-cpp.ps1..."
+Write-Host "Base Dir: $BaseDir"
+Write-Host "GOLLEK_LLAMA_SOURCE_DIR: $LlamaSourceDir"
+Write-Host "Source Output Dir: $SourceOutputDir"
+Write-Host "Secondary Output Dir: $OutputDir"
+
+$VendorDir = $LlamaSourceDir
+# Backward-compatible source-root normalization
+if (-not (Test-Path (Join-Path $VendorDir "CMakeLists.txt"))) {
+    if (Test-Path (Join-Path $VendorDir "llama.cpp\CMakeLists.txt")) {
+        $VendorDir = Join-Path $VendorDir "llama.cpp"
+    } elseif (Test-Path (Join-Path $VendorDir "llama-cpp\llama.cpp\CMakeLists.txt")) {
+        $VendorDir = Join-Path $VendorDir "llama-cpp\llama.cpp"
+    }
+}
+Write-Host "Resolved llama.cpp source: $VendorDir"
+
+New-Item -ItemType Directory -Force -Path $SourceOutputDir | Out-Null
+New-Item -ItemType Directory -Force -Path $OutputDir | Out-Null
+
+$ShimSrc = Join-Path $BaseDir "src\main\native\golek_llama_shim.c"
+
+function Build-Shim([string]$LibDir) {
+    if (-not (Test-Path $ShimSrc)) { return }
+    $ImportLib = Get-ChildItem -Path $SourceBuildDir -Recurse -Filter "llama.lib" -ErrorAction SilentlyContinue |
+        Select-Object -First 1
+    if ($null -eq $ImportLib) {
+        Write-Warning "llama.lib import library not found; skipping gollek llama shim"
+        return
+    }
+    $ShimOut = Join-Path $LibDir "gollek_llama_shim.dll"
+    if (Get-Command cl -ErrorAction SilentlyContinue) {
+        & cl /nologo /LD /O2 "/I$VendorDir\include" "/I$VendorDir\ggml\include" $ShimSrc `
+            /link $ImportLib.FullName "/OUT:$ShimOut" "/IMPLIB:$(Join-Path $SourceBuildDir 'gollek_llama_shim.lib')"
+    } elseif (Get-Command gcc -ErrorAction SilentlyContinue) {
+        & gcc -shared -O2 "-I$VendorDir\include" "-I$VendorDir\ggml\include" $ShimSrc `
+            "-L$LibDir" -lllama -o $ShimOut
+    } else {
+        Write-Warning "No C compiler (cl or gcc) on PATH; skipping gollek llama shim"
+        return
+    }
+    if ($LASTEXITCODE -ne 0) { Write-Warning "failed to build gollek llama shim" }
+}
+
+# Check if artifacts already exist to skip build (speed optimization).
+$LlamaOk = Test-Path (Join-Path $OutputDir "llama.dll")
+$ShimOk = -not (Test-Path $ShimSrc) -or (Test-Path (Join-Path $OutputDir "gollek_llama_shim.dll"))
+if ($LlamaOk -and $ShimOk) {
+    Write-Host "Native library already exists in $OutputDir. Skipping build."
+    exit 0
+}
+
+# Fast path: llama library exists, only shim is missing.
+if ($LlamaOk) {
+    Write-Host "llama library exists; building missing shim only..."
+    Build-Shim $OutputDir
+    exit 0
+}
+
+if (-not (Test-Path (Join-Path $VendorDir "CMakeLists.txt"))) {
+    Write-Warning "llama.cpp source or CMakeLists.txt not found at $VendorDir"
+    Write-Host "Set GOLLEK_LLAMA_SOURCE_DIR or place source at $DefaultLlamaSource"
+    Write-Host "Skipping native build; the runner will look for llama.dll at runtime."
+    exit 0
+}
+
+if (-not (Get-Command cmake -ErrorAction SilentlyContinue)) {
+    Write-Warning "cmake not found. Cannot build llama.cpp."
+    exit 0
+}
+
+$Cuda = if ($env:GOLLEK_GGML_CUDA) { $env:GOLLEK_GGML_CUDA } elseif ($env:CUDA_PATH) { "ON" } else { "OFF" }
+$Vulkan = if ($env:GOLLEK_GGML_VULKAN) { $env:GOLLEK_GGML_VULKAN } else { "OFF" }
+$BackendDlFlags = if ($env:GOLLEK_GGML_BACKEND_DL -eq "ON") {
+    @("-DGGML_BACKEND_DL=ON", "-DGGML_CPU_ALL_VARIANTS=ON", "-DGGML_NATIVE=OFF")
+} else { @() }
+
+Write-Host "Building llama.cpp from $VendorDir (CUDA=$Cuda, Vulkan=$Vulkan)..."
+New-Item -ItemType Directory -Force -Path $SourceBuildDir | Out-Null
+& cmake -S $VendorDir -B $SourceBuildDir `
+    -DBUILD_SHARED_LIBS=ON `
+    -DLLAMA_BUILD_TESTS=OFF `
+    -DLLAMA_BUILD_EXAMPLES=OFF `
+    -DLLAMA_BUILD_TOOLS=OFF `
+    -DLLAMA_BUILD_SERVER=OFF `
+    -DLLAMA_CURL=OFF `
+    "-DGGML_CUDA=$Cuda" `
+    "-DGGML_VULKAN=$Vulkan" `
+    @BackendDlFlags
+if ($LASTEXITCODE -ne 0) { throw "cmake configure failed" }
+& cmake --build $SourceBuildDir --config Release -j 4
+if ($LASTEXITCODE -ne 0) { throw "cmake build failed" }
+
+Write-Host "Copying artifacts to $SourceOutputDir and $OutputDir..."
+# Multi-config generators (Visual Studio) put DLLs under bin\Release
+Get-ChildItem -Path $SourceBuildDir -Recurse -Include "llama.dll", "ggml*.dll" |
+    Where-Object { $_.DirectoryName -ne $SourceOutputDir } |
+    ForEach-Object { Copy-Item $_.FullName -Destination $SourceOutputDir -Force }
+Get-ChildItem -Path (Join-Path $SourceOutputDir "*") -Include "llama.dll", "ggml*.dll" -File |
+    ForEach-Object { Copy-Item $_.FullName -Destination $OutputDir -Force -Verbose }
+
+# Build small ABI-stable shim to avoid problematic struct-return FFM calls in native image.
+Build-Shim $SourceOutputDir
+$ShimBuilt = Join-Path $SourceOutputDir "gollek_llama_shim.dll"
+if (Test-Path $ShimBuilt) {
+    Copy-Item $ShimBuilt -Destination $OutputDir -Force -Verbose
+}
+
+Write-Host "llama.cpp build complete."
+Write-Host "Primary output: $SourceOutputDir"
+Write-Host "Secondary output: $OutputDir"
@@ -125,13 +125,20 @@ if command -v cmake &> /dev/null; then
     else
         GGML_METAL_FLAG="${GOLLEK_GGML_METAL:-OFF}"
     fi
+    # GOLLEK_GGML_BACKEND_DL=ON builds every backend as a loadable module and one CPU
+    # module per instruction level; the runner picks the ones this host can run.
+    BACKEND_DL_FLAGS=()
+    if [ "${GOLLEK_GGML_BACKEND_DL:-OFF}" = "ON" ]; then
+        BACKEND_DL_FLAGS=(-DGGML_BACKEND_DL=ON -DGGML_CPU_ALL_VARIANTS=ON -DGGML_NATIVE=OFF)
+    fi
     cmake "$VENDOR_DIR" \
         -DBUILD_SHARED_LIBS=ON \
         -DLLAMA_BUILD_TESTS=OFF \
         -DLLAMA_BUILD_EXAMPLES=OFF \
         -DLLAMA_BUILD_TOOLS=OFF \
         -DLLAMA_BUILD_SERVER=OFF \
-        -DGGML_METAL="$GGML_METAL_FLAG"
+        -DGGML_METAL="$GGML_METAL_FLAG" \
+        "${BACKEND_DL_FLAGS[@]}"
     cmake --build . --config Release -j 4
 else
     echo "Error: cmake not found. Cannot build llama.cpp."
@@ -5,16 +5,22 @@ import tech.kayys.gollek.gguf.tokenizer.GGUFChatTemplateService;
 import org.jboss.logging.Logger;
 import tech.kayys.gollek.spi.inference.InferenceRequest;
 import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
 import tech.kayys.gollek.spi.model.ModelManifest;
 import tech.kayys.gollek.spi.Message;
 import java.lang.foreign.MemorySegment;
 import java.lang.foreign.ValueLayout;
 import java.lang.foreign.Arena;
 import java.time.Instant;
+import java.util.ArrayList;
+import java.util.HashMap;
 import java.util.List;
 import java.util.Map;
 import java.util.Random;
+import java.util.concurrent.CancellationException;
 import java.util.function.Consumer;
+import java.util.stream.Collectors;
+import java.util.stream.IntStream;
 
 /**
  * Inference logic executor that uses refactored components.
@@ -25,6 +31,14 @@ class InferenceLogicExecutor {
     private static final String CHAT_TOKEN = "\u7e26\u7e26";
     private static final String HEADER_START = "<|start_header_id|>";
     private static final String ASSISTANT = "<|assistant|>";
+    private static final float MAX_TEMPERATURE = 2.0f;
+    /** Response metadata key for the stop sequence that ended generation. */
+    static final String STOP_SEQUENCE = "stop_sequence";
+    /** Request flag: feed {@code prompt} to the model as-is, without template or BOS. */
+    static final String RAW = "raw";
+    /** Request flag: return the prompt's token ids in the {@value #PROMPT_TOKENS} metadata. */
+    static final String RETURN_TOKENS = "return_tokens";
+    static final String PROMPT_TOKENS = "prompt_tokens";
 
     // Simple multimodal data holder
     private static class MultimodalData {
@@ -47,6 +61,8 @@ class InferenceLogicExecutor {
     private final MemorySegment model, context;
     private final int contextSize, vocabSize, eosToken, bosToken, runtimeBatchSize;
     private final String chatTemplate;
+    private final LlamaCppTurnFormat turnFormat;
+    private final LlamaCppPromptCompressor promptCompressor;
     private final LlamaCppKVCacheManager kvCacheManager;
     private final LlamaCppTokenSampler tokenSampler;
     private final LlamaCppMetricsRecorder metricsRecorder;
@@ -62,6 +78,8 @@ class InferenceLogicExecutor {
         this.vocabSize = vocabSize; this.eosToken = eosToken; this.bosToken = bosToken;
         this.runtimeBatchSize = runtimeBatchSize; this.chatTemplate = chatTemplate;
         this.kvCacheManager = kvCacheManager; this.tokenSampler = tokenSampler; this.metricsRecorder = metricsRecorder; this.manifest = manifest;
+        this.turnFormat = LlamaCppTurnFormat.forModel(providerConfig, manifest != null ? manifest.modelId() : null);
+        this.promptCompressor = new LlamaCppPromptCompressor(providerConfig, token -> binding.tokenToPiece(model, token));
     }
 
     InferenceResponse execute(InferenceRequest request, Consumer<String> onTokenPiece) {
@@ -69,96 +87,327 @@ class InferenceLogicExecutor {
         if (prompt == null || prompt.isBlank()) return createEmptyResponse(request);
         long requestStart = System.nanoTime();
         kvCacheManager.loadSessionIfExists(context, request);
-        boolean hasChatSpecial = prompt.contains(CHAT_TOKEN) || prompt.contains(HEADER_START) || prompt.contains(ASSISTANT) || prompt.contains("<|im_start|>");
-        int[] promptTokens = kvCacheManager.tokenizeWithCache(model, prompt, !hasChatSpecial);
+        int[] promptTokens = tokenizePrompt(request, prompt);
+        int originalTokens = promptTokens.length;
+        promptTokens = promptCompressor.compress(request, promptTokens);
         int nTokens = promptTokens.length;
         if (nTokens == 0) return createEmptyResponse(request);
-        int maxContext = providerConfig.maxContextTokens();
-        if (maxContext > 0 && nTokens > maxContext) {
-            int[] truncated = new int[maxContext];
-            System.arraycopy(promptTokens, nTokens - maxContext, truncated, 0, maxContext);
-            promptTokens = truncated; nTokens = maxContext;
+        int compressedTokens = nTokens;
+        List<String> warnings = new ArrayList<>();
+        int limit = providerConfig.maxContextTokens() > 0 ? providerConfig.maxContextTokens() : Integer.MAX_VALUE;
+        if (contextSize > 0) limit = Math.min(limit, contextSize);
+        LlamaCppContextOverflow overflow = LlamaCppContextOverflow.forRequest(request.getParameters(), providerConfig);
+        int keep = overflow == LlamaCppContextOverflow.SHIFT ? keepTokens(request, promptTokens) : 0;
+        promptTokens = overflow.fit(promptTokens, limit, keep, warnings);
+        nTokens = promptTokens.length;
+        if (contextSize > 0) keep = Math.min(keep, Math.min(nTokens, contextSize / 2));
+        // a long system prompt is worth keeping on disk; restored here, stored once evaluated
+        MultimodalData multimodalData = extractMultimodalData(request);
+        int cachePrefix = providerConfig.promptCacheEnabled() && multimodalData == null
+                ? systemPrefixTokens(request, promptTokens) : 0;
+        int reusePrefix = kvCacheManager.reusePrefix(context, promptTokens, nTokens, cachePrefix);
+        boolean cachePrompt = reusePrefix < cachePrefix && cachePrefix < nTokens
+                && kvCacheManager.shouldCachePrompt(promptTokens, cachePrefix);
+        metricsRecorder.recordPrefixCache(reusePrefix, nTokens);
+        GenerationParams params = GenerationParams.of(request, warnings);
+        Random random = params.random();
+        int maxTokens = params.maxTokens();
+        if (contextSize > 0 && nTokens + maxTokens > contextSize && overflow != LlamaCppContextOverflow.SHIFT) {
+            int clamped = Math.max(0, contextSize - nTokens);
+            warnings.add("max_tokens reduced from " + maxTokens + " to " + clamped + " to fit the context window");
+            maxTokens = clamped;
         }
-        int reusePrefix = kvCacheManager.computeReusePrefix(promptTokens, nTokens);
-        if (reusePrefix == 0) kvCacheManager.resetKvCache(context);
-        float temperature = ((Number) request.getParameters().getOrDefault("temperature", 0.8f)).floatValue();
-        int topK = ((Number) request.getParameters().getOrDefault("top_k", 40)).intValue();
-        float topP = ((Number) request.getParameters().getOrDefault("top_p", 0.95f)).floatValue();
-        float minP = ((Number) request.getParameters().getOrDefault("min_p", 0.05f)).floatValue();
-        float repeatPenalty = ((Number) request.getParameters().getOrDefault("repeat_penalty", 1.1f)).floatValue();
-        float frequencyPenalty = ((Number) request.getParameters().getOrDefault("frequency_penalty", 0.0f)).floatValue();
-        float presencePenalty = ((Number) request.getParameters().getOrDefault("presence_penalty", 0.0f)).floatValue();
-        int repeatLastN = ((Number) request.getParameters().getOrDefault("repeat_last_n", 64)).intValue();
-        int seed = ((Number) request.getParameters().getOrDefault("seed", -1)).intValue();
-        Random random = seed == -1 ? java.util.concurrent.ThreadLocalRandom.current() : new Random(seed);
-        int maxTokens = ((Number) request.getParameters().getOrDefault("max_tokens", 128)).intValue();
-        long timeoutMs = Math.max(1000L, ((Number) request.getParameters().getOrDefault("inference_timeout_ms", 120000L)).longValue());
-        Instant deadline = Instant.now().plusMillis(timeoutMs);
-        List<String> stopSequences = resolveStopSequences(request);
-        int maxStopLength = maxStopSequenceLength(stopSequences);
-        boolean usePenalties = repeatPenalty > 1.0f || presencePenalty != 0.0f || frequencyPenalty != 0.0f;
-        int effectiveRepeatLastN = usePenalties ? Math.max(0, repeatLastN) : 0;
+        Instant deadline = Instant.now().plusMillis(params.timeoutMs());
+        LlamaCppTimeouts timeouts = LlamaCppTimeouts.of(request.getParameters());
+        boolean returnPartial = request.isReturnPartialOnTimeout();
+        InferenceResponse.FinishReason finishReason = InferenceResponse.FinishReason.STOP;
+        String stopSequence = null;
+        LlamaCppStopMatcher stopMatcher = new LlamaCppStopMatcher(stopSequences(request));
+        LlamaCppLogprobs logprobs = LlamaCppLogprobs.forRequest(request, token -> binding.tokenToPiece(model, token));
+        int[] outputTokens = LlamaCppVerbose.requested(request) ? new int[Math.max(0, maxTokens)] : null;
+        int effectiveRepeatLastN = params.effectiveRepeatLastN();
         int[] recentRing = effectiveRepeatLastN > 0 ? new int[effectiveRepeatLastN] : null;
         int recentRingSize = 0, recentRingIndex = 0;
         int[] recentTokenCounts = effectiveRepeatLastN > 0 ? new int[Math.max(1, vocabSize)] : null;
         int maxBatch = Math.max(1, runtimeBatchSize);
         MemorySegment batch = binding.batchInit(maxBatch, 0, 1);
         StringBuilder result = new StringBuilder();
-        int tokensGenerated = 0;
-        long promptStartNanos = requestStart, promptEndNanos = 0L, firstTokenNanos = 0L;
+        int tokensGenerated = 0, shifted = 0;
+        long promptStartNanos = requestStart, promptEndNanos = 0L, firstTokenNanos = 0L, lastTokenNanos = 0L;
+        LlamaCppSlotStats slotStats = kvCacheManager.slotStats();
+        slotStats.acquire(0, request.getRequestId());
         try {
-            // Check for multimodal data (images, etc.)
-            MultimodalData multimodalData = extractMultimodalData(request);
             int processed = reusePrefix;
             
             // If multimodal, use special batch setting with embeddings
             if (multimodalData != null && multimodalData.hasEmbeddings()) {
-                processed = processMultimodalBatch(batch, multimodalData, promptTokens, nTokens, reusePrefix, maxBatch, deadline);
+                processed = processMultimodalBatch(batch, multimodalData, promptTokens, nTokens, reusePrefix, maxBatch, deadline, timeouts, requestStart);
             } else {
                 // Text-only processing
                 while (processed < nTokens) {
-                    if (Instant.now().isAfter(deadline)) throw new RuntimeException("Prompt timed out");
+                    if (RequestCancellation.isCancelled(request.getRequestId())) {
+                        throw new CancellationException("Request " + request.getRequestId() + " cancelled");
+                    }
+                    if (Instant.now().isAfter(deadline)) {
+                        // nothing generated yet, but the caller still prefers an answer over an error
+                        if (params.timeLimited()) return createTimeoutResponse(request, nTokens, warnings, InferenceResponse.FinishReason.TIME_LIMIT);
+                        if (returnPartial) return createTimeoutResponse(request, nTokens, warnings, InferenceResponse.FinishReason.TIMEOUT);
+                        throw LlamaCppTimeouts.timedOut("Prompt timed out");
+                    }
+                    if (timeouts.prefillExpired(requestStart, System.nanoTime())) throw timeouts.prefillTimedOut();
                     int chunk = Math.min(maxBatch, nTokens - processed);
+                    // stop at the end of the cached prefix so the sequence holds exactly it
+                    if (cachePrompt && processed < cachePrefix) chunk = Math.min(chunk, cachePrefix - processed);
                     binding.setBatchSize(batch, chunk);
                     for (int i = 0; i < chunk; i++) binding.setBatchToken(batch, i, promptTokens[processed + i], processed + i, 0, i == chunk - 1);
                     if (binding.decode(context, batch) != 0) throw new RuntimeException("Prompt evaluation failed");
                     processed += chunk;
+                    // progress for the stall watchdog
+                    slotStats.setTokens(0, processed);
+                    if (cachePrompt && processed == cachePrefix) kvCacheManager.cachePrompt(context, promptTokens, cachePrefix);
                 }
             }
             promptEndNanos = System.nanoTime();
             kvCacheManager.updateAfterPrompt(promptTokens, nTokens);
             int currentPos = nTokens;
-            LlamaCppTokenSampler.SamplingConfig config = new LlamaCppTokenSampler.SamplingConfig(temperature, topK, topP, minP, repeatPenalty, frequencyPenalty, presencePenalty, recentTokenCounts);
-            while (tokensGenerated < maxTokens) {
-                if (Instant.now().isAfter(deadline)) throw new RuntimeException("Generation timed out");
+            LlamaCppTokenSampler.SamplingConfig config = params.samplingConfig(recentTokenCounts);
+            while (true) {
+                if (tokensGenerated >= maxTokens) {
+                    finishReason = InferenceResponse.FinishReason.LENGTH;
+                    break;
+                }
+                if (RequestCancellation.isCancelled(request.getRequestId())) {
+                    log.debugf("Request %s cancelled after %d tokens", request.getRequestId(), tokensGenerated);
+                    finishReason = InferenceResponse.FinishReason.CANCELLED;
+                    break;
+                }
+                if (Instant.now().isAfter(deadline)) {
+                    if (params.timeLimited()) {
+                        log.debugf("max_time_ms reached after %d tokens", tokensGenerated);
+                        finishReason = InferenceResponse.FinishReason.TIME_LIMIT;
+                        break;
+                    }
+                    if (!returnPartial) throw LlamaCppTimeouts.timedOut("Generation timed out");
+                    log.debugf("Deadline reached after %d tokens; returning partial result", tokensGenerated);
+                    finishReason = InferenceResponse.FinishReason.TIMEOUT;
+                    break;
+                }
+                long now = System.nanoTime();
+                if (tokensGenerated == 0 && timeouts.prefillExpired(requestStart, now)) throw timeouts.prefillTimedOut();
+                if (tokensGenerated > 0 && timeouts.stalled(lastTokenNanos, now)) {
+                    log.debugf("No token within %d ms after %d tokens; returning partial result", timeouts.interTokenMs(), tokensGenerated);
+                    finishReason = InferenceResponse.FinishReason.STALLED;
+                    break;
+                }
                 int newToken = tokenSampler.sampleNextToken(context, 0, config, random);
                 if (isEndToken(newToken)) break;
                 String piece = binding.tokenToPiece(model, newToken);
-                if (tokensGenerated == 0) firstTokenNanos = System.nanoTime();
-                result.append(piece);
-                if (onTokenPiece != null && piece != null) onTokenPiece.accept(piece);
+                lastTokenNanos = System.nanoTime();
+                if (tokensGenerated == 0) firstTokenNanos = lastTokenNanos;
+                if (logprobs != null) logprobs.record(tokenSampler.logits(context, 0), newToken, piece);
+                emit(result, stopMatcher.accept(piece), onTokenPiece, logprobs);
+                if (outputTokens != null) outputTokens[tokensGenerated] = newToken;
                 tokensGenerated++;
-                kvCacheManager.updateAfterGeneration(newToken);
-                if (!stopSequences.isEmpty() && maxStopLength > 0) {
-                    String matched = checkStopSequence(result.toString(), stopSequences, maxStopLength);
-                    if (matched != null) { int cut = result.indexOf(matched, Math.max(0, result.length() - maxStopLength)); if (cut >= 0) { result.setLength(cut); break; } }
-                }
+                if (stopMatcher.matched() != null) { stopSequence = stopMatcher.matched(); break; }
                 if (effectiveRepeatLastN > 0) { int[] state = kvCacheManager.pushRecentToken(newToken, recentRing, recentRingSize, recentRingIndex, recentTokenCounts, effectiveRepeatLastN); recentRingSize = state[0]; recentRingIndex = state[1]; }
+                if (overflow == LlamaCppContextOverflow.SHIFT && contextSize > 0 && currentPos >= contextSize) {
+                    // window full: drop the oldest tokens after the kept prefix and carry on
+                    int discard = LlamaCppContextOverflow.discard(currentPos, keep);
+                    if (!kvCacheManager.shiftContext(context, keep, discard)) {
+                        warnings.add("context window full and this model's KV cache cannot be shifted");
+                        finishReason = InferenceResponse.FinishReason.LENGTH;
+                        break;
+                    }
+                    currentPos -= discard;
+                    shifted += discard;
+                }
                 binding.setBatchSize(batch, 1);
                 binding.setBatchToken(batch, 0, newToken, currentPos++, 0, true);
-                if (binding.decode(context, batch) != 0) { log.error("Decode failed"); break; }
+                if (binding.decode(context, batch) != 0) { log.error("Decode failed"); finishReason = InferenceResponse.FinishReason.ERROR; break; }
+                // only now is the token in the KV cache; the history must not run ahead of it
+                kvCacheManager.updateAfterGeneration(newToken);
             }
+            emit(result, stopMatcher.flush(), onTokenPiece, logprobs);
+            if (shifted > 0) warnings.add("context shifted: " + shifted + " earlier tokens dropped during generation");
             kvCacheManager.saveSessionIfExists(context, request);
             metricsRecorder.recordInferenceMetrics(requestStart, promptStartNanos, promptEndNanos, promptEndNanos, firstTokenNanos, nTokens, tokensGenerated);
-            return InferenceResponse.builder().requestId(request.getRequestId()).model(manifest.modelId()).content(result.toString()).inputTokens(nTokens).outputTokens(tokensGenerated).tokensUsed(nTokens + tokensGenerated).build();
-        } finally { binding.batchFree(batch); }
+            InferenceResponse.Builder response = InferenceResponse.builder().requestId(request.getRequestId()).model(manifest.modelId()).content(result.toString()).inputTokens(nTokens).outputTokens(tokensGenerated).tokensUsed(nTokens + tokensGenerated).finishReason(finishReason).warnings(warnings);
+            if (stopSequence != null) response.metadata(STOP_SEQUENCE, stopSequence);
+            reportCompression(response, originalTokens, compressedTokens);
+            if (flag(request, RETURN_TOKENS)) response.metadata(PROMPT_TOKENS, IntStream.of(promptTokens).boxed().toList());
+            reportLogprobs(response, logprobs, result.length());
+            if (outputTokens != null) response.metadata(LlamaCppVerbose.METADATA, LlamaCppVerbose.report(params, maxTokens, outputTokens, tokensGenerated, nTokens, reusePrefix, requestStart, promptEndNanos, firstTokenNanos));
+            return response.build();
+        } finally { slotStats.idle(0); binding.batchFree(batch); }
+    }
+
+    /** Per-request sampling and limit parameters, shared with the batch scheduler. */
+    record GenerationParams(float temperature, int topK, float topP, float minP, float repeatPenalty,
+            float frequencyPenalty, float presencePenalty, int repeatLastN, int seed, int maxTokens, long timeoutMs,
+            boolean timeLimited, Map<Integer, Float> logitBias, float typicalP, int mirostat, float mirostatTau,
+            float mirostatEta) {
+
+        static final String LOGIT_BIAS = "logit_bias";
+
+        static GenerationParams of(InferenceRequest request, List<String> warnings) {
+            Map<String, Object> p = request.getParameters();
+            float temperature = ((Number) p.getOrDefault("temperature", 0.8f)).floatValue();
+            if (temperature > MAX_TEMPERATURE) {
+                warnings.add("temperature clamped to " + MAX_TEMPERATURE);
+                temperature = MAX_TEMPERATURE;
+            }
+            long timeoutMs = Math.max(1000L, ((Number) p.getOrDefault("inference_timeout_ms", 120000L)).longValue());
+            // a client's max_time_ms ends generation cleanly with partial output instead of failing
+            long maxTimeMs = p.get("max_time_ms") instanceof Number n ? n.longValue() : 0L;
+            boolean timeLimited = maxTimeMs > 0 && maxTimeMs <= timeoutMs;
+            return new GenerationParams(temperature,
+                    ((Number) p.getOrDefault("top_k", 40)).intValue(),
+                    ((Number) p.getOrDefault("top_p", 0.95f)).floatValue(),
+                    ((Number) p.getOrDefault("min_p", 0.05f)).floatValue(),
+                    ((Number) p.getOrDefault("repeat_penalty", 1.1f)).floatValue(),
+                    ((Number) p.getOrDefault("frequency_penalty", 0.0f)).floatValue(),
+                    ((Number) p.getOrDefault("presence_penalty", 0.0f)).floatValue(),
+                    ((Number) p.getOrDefault("repeat_last_n", 64)).intValue(),
+                    ((Number) p.getOrDefault("seed", -1)).intValue(),
+                    ((Number) p.getOrDefault("max_tokens", 128)).intValue(),
+                    timeLimited ? maxTimeMs : timeoutMs,
+                    timeLimited,
+                    logitBias(p.get(LOGIT_BIAS)),
+                    ((Number) p.getOrDefault("typical_p", 1.0f)).floatValue(),
+                    ((Number) p.getOrDefault("mirostat", 0)).intValue(),
+                    ((Number) p.getOrDefault("mirostat_tau", 5.0f)).floatValue(),
+                    ((Number) p.getOrDefault("mirostat_eta", 0.1f)).floatValue());
+        }
+
+        /** A fresh sampler config for one request; mirostat state is not shared between requests. */
+        LlamaCppTokenSampler.SamplingConfig samplingConfig(int[] recentTokenCounts) {
+            return new LlamaCppTokenSampler.SamplingConfig(temperature, topK, topP, minP, repeatPenalty,
+                    frequencyPenalty, presencePenalty, recentTokenCounts, logitBias, typicalP, mirostat, mirostatTau,
+                    mirostatEta);
+        }
+
+        /**
+         * {@code logit_bias} as token id to bias. Keys may be strings, as JSON object keys
+         * are; biases are clamped to {@code [-100, 100]} like the OpenAI API does.
+         *
+         * @throws IllegalArgumentException if a key is not a token id or a bias not a number
+         */
+        static Map<Integer, Float> logitBias(Object value) {
+            if (!(value instanceof Map<?, ?> entries) || entries.isEmpty()) {
+                return Map.of();
+            }
+            Map<Integer, Float> biases = new HashMap<>();
+            for (Map.Entry<?, ?> entry : entries.entrySet()) {
+                int token;
+                try {
+                    token = entry.getKey() instanceof Number n ? n.intValue()
+                            : Integer.parseInt(String.valueOf(entry.getKey()).trim());
+                } catch (NumberFormatException e) {
+                    throw new IllegalArgumentException("logit_bias keys must be token ids: " + entry.getKey());
+                }
+                float bias;
+                if (entry.getValue() instanceof Number n) {
+                    bias = n.floatValue();
+                } else {
+                    try {
+                        bias = Float.parseFloat(String.valueOf(entry.getValue()).trim());
+                    } catch (NumberFormatException e) {
+                        throw new IllegalArgumentException("logit_bias for token " + token + " must be a number: "
+                                + entry.getValue());
+                    }
+                }
+                biases.put(token, Math.max(-100f, Math.min(100f, bias)));
+            }
+            return Map.copyOf(biases);
+        }
+
+        Random random() { return seed == -1 ? java.util.concurrent.ThreadLocalRandom.current() : new Random(seed); }
+
+        int effectiveRepeatLastN() {
+            boolean usePenalties = repeatPenalty > 1.0f || presencePenalty != 0.0f || frequencyPenalty != 0.0f;
+            return usePenalties ? Math.max(0, repeatLastN) : 0;
+        }
+    }
+
+    /**
+     * Fits prompt tokens into {@code limit} with the request's {@link LlamaCppContextOverflow}
+     * strategy: an oversized prompt is rejected, its tail kept, or its oldest tokens after the
+     * system prompt dropped.
+     */
+    int[] fitPrompt(InferenceRequest request, int[] tokens, int limit, List<String> warnings) {
+        if (tokens.length <= limit) return tokens;
+        LlamaCppContextOverflow overflow = LlamaCppContextOverflow.forRequest(request.getParameters(), providerConfig);
+        int keep = overflow == LlamaCppContextOverflow.SHIFT ? keepTokens(request, tokens) : 0;
+        return overflow.fit(tokens, limit, keep, warnings);
+    }
+
+    /**
+     * Leading prompt tokens a context shift keeps: {@code n_keep} when given (negative for
+     * all of them), otherwise those the chat's leading system messages render to.
+     */
+    int keepTokens(InferenceRequest request, int[] tokens) {
+        if (request.getParameters().get(LlamaCppContextOverflow.KEEP) instanceof Number n) {
+            return n.intValue() < 0 ? tokens.length : Math.min(n.intValue(), tokens.length);
+        }
+        return systemPrefixTokens(request, tokens);
+    }
+
+    /** Leading prompt tokens that the chat's leading system messages render to. */
+    int systemPrefixTokens(InferenceRequest request, int[] tokens) {
+        List<Message> messages = request.getMessages();
+        if (flag(request, RAW) || messages == null) return 0;
+        List<Message> system = messages.stream().takeWhile(m -> m.getRole() == Message.Role.SYSTEM).toList();
+        if (system.isEmpty()) return 0;
+        String rendered = turnFormat != null ? turnFormat.render(system) : templateService.render(chatTemplate, system);
+        // the system-only render may end with a generation prompt; only the shared prefix counts
+        int[] prefix = tokenizePrompt(rendered);
+        int common = 0;
+        while (common < prefix.length && common < tokens.length && prefix[common] == tokens[common]) common++;
+        return common;
+    }
+
+    /** Tokenizes a rendered prompt; BOS is only added when the template did not emit special tokens. */
+    int[] tokenizePrompt(String prompt) {
+        boolean hasChatSpecial = prompt.contains(CHAT_TOKEN) || prompt.contains(HEADER_START) || prompt.contains(ASSISTANT) || prompt.contains("<|im_start|>");
+        return kvCacheManager.tokenizeWithCache(model, prompt, !hasChatSpecial);
+    }
+
+    /** As {@link #tokenizePrompt(String)}, except that raw prompts never get a BOS token. */
+    int[] tokenizePrompt(InferenceRequest request, String prompt) {
+        return flag(request, RAW) ? kvCacheManager.tokenizeWithCache(model, prompt, false) : tokenizePrompt(prompt);
     }
 
-    private String resolvePrompt(InferenceRequest request) {
+    /** Prompt tokens with low-information ones pruned, when compression applies to the request. */
+    int[] compressPrompt(InferenceRequest request, int[] tokens) {
+        return promptCompressor.compress(request, tokens);
+    }
+
+    static void reportCompression(InferenceResponse.Builder response, int originalTokens, int compressedTokens) {
+        if (compressedTokens < originalTokens) {
+            response.metadata(LlamaCppPromptCompressor.METADATA,
+                    Map.of("original_tokens", originalTokens, "compressed_tokens", compressedTokens));
+        }
+    }
+
+    static boolean flag(InferenceRequest request, String name) {
+        Object value = request.getParameters().get(name);
+        return value != null && Boolean.parseBoolean(String.valueOf(value));
+    }
+
+    String resolvePrompt(InferenceRequest request) {
+        if (flag(request, RAW)) {
+            // the prompt as sent, or the message contents back to back; never a template
+            if (request.getParameters().get("prompt") instanceof String raw) return raw;
+            if (request.getMessages() == null) return "";
+            return request.getMessages().stream().map(m -> m.getContent() == null ? "" : m.getContent())
+                    .collect(Collectors.joining());
+        }
         String prompt = (String) request.getParameters().getOrDefault("prompt", "");
         if (request.getMessages() == null || request.getMessages().isEmpty()) return prompt;
         
-        String rendered = templateService.render(chatTemplate, request.getMessages());
+        String rendered = turnFormat != null
+                ? turnFormat.render(request.getMessages())
+                : templateService.render(chatTemplate, request.getMessages());
         
         // Show prompt in log if verbose
         if (System.getProperty("quarkus.log.level", "INFO").equalsIgnoreCase("DEBUG")) {
@@ -172,16 +421,39 @@ class InferenceLogicExecutor {
         // Redundant, handled by GGUFChatTemplateService.fallbackRender
         return templateService.render(null, messages);
     }
-    private String checkStopSequence(String text, List<String> stops, int maxLen) { for (String stop : stops) if (text.contains(stop)) return stop; return null; }
-    private List<String> resolveStopSequences(InferenceRequest request) {
+    /**
+     * Appends text released by the stop matcher to the result and the stream; a
+     * {@link LlamaCppLogprobs.Sink} also gets the log probabilities of the tokens it covers.
+     */
+    static void emit(StringBuilder result, String text, Consumer<String> onTokenPiece, LlamaCppLogprobs logprobs) {
+        if (text.isEmpty()) return;
+        result.append(text);
+        if (onTokenPiece == null) return;
+        if (logprobs != null && onTokenPiece instanceof LlamaCppLogprobs.Sink sink) sink.accept(text, logprobs.release(result.length()));
+        else onTokenPiece.accept(text);
+    }
+    static void reportLogprobs(InferenceResponse.Builder response, LlamaCppLogprobs logprobs, int contentChars) {
+        if (logprobs != null) response.metadata(LlamaCppLogprobs.METADATA, LlamaCppLogprobs.toMetadata(logprobs.upTo(contentChars)));
+    }
+    static List<String> resolveStopSequences(InferenceRequest request) {
         Object stop = request.getParameters().get("stop");
         if (stop == null) return List.of();
         if (stop instanceof String s) return s.isBlank() ? List.of() : List.of(s);
         if (stop instanceof List<?> list) return list.stream().filter(o -> o != null && !o.toString().isBlank()).map(Object::toString).toList();
         return List.of();
     }
-    private int maxStopSequenceLength(List<String> stops) { if (stops.isEmpty()) return 0; return stops.stream().mapToInt(s -> s == null ? 0 : s.length()).max().orElse(0); }
-    private boolean isEndToken(int tokenId) {
+    /** The request's stop strings, plus the turn format's for chat requests to a base model. */
+    List<String> stopSequences(InferenceRequest request) {
+        List<String> stops = resolveStopSequences(request);
+        if (turnFormat == null || turnFormat.stops().isEmpty() || flag(request, RAW)
+                || request.getMessages() == null || request.getMessages().isEmpty()) {
+            return stops;
+        }
+        List<String> merged = new ArrayList<>(stops);
+        turnFormat.stops().stream().filter(stop -> !stop.isEmpty() && !merged.contains(stop)).forEach(merged::add);
+        return merged;
+    }
+    boolean isEndToken(int tokenId) {
         if (tokenId < 0) return true;
         try { if (binding.isEndOfGeneration(model, tokenId)) return true; } catch (RuntimeException e) { log.debug("EOG check failed: " + e.getMessage()); }
         return tokenId == eosToken;
@@ -190,6 +462,17 @@ class InferenceLogicExecutor {
         return InferenceResponse.builder().requestId(request.getRequestId()).model(manifest.modelId()).content("").tokensUsed(0).build();
     }
 
+    private InferenceResponse createTimeoutResponse(InferenceRequest request, int inputTokens, List<String> warnings,
+            InferenceResponse.FinishReason reason) {
+        return InferenceResponse.builder().requestId(request.getRequestId()).model(manifest.modelId()).content("")
+                .inputTokens(inputTokens).tokensUsed(inputTokens).finishReason(reason)
+                .warnings(warnings).build();
+    }
+
+    static boolean hasMultimodalData(InferenceRequest request) {
+        return request.getParameters().get("multimodal") instanceof MultimodalData;
+    }
+
     private MultimodalData extractMultimodalData(InferenceRequest request) {
         Object multimodal = request.getParameters().get("multimodal");
         if (multimodal instanceof MultimodalData) {
@@ -199,7 +482,8 @@ class InferenceLogicExecutor {
     }
 
     private int processMultimodalBatch(MemorySegment batch, MultimodalData multimodalData, 
-            int[] promptTokens, int nTokens, int reusePrefix, int maxBatch, Instant deadline) {
+            int[] promptTokens, int nTokens, int reusePrefix, int maxBatch, Instant deadline,
+            LlamaCppTimeouts timeouts, long requestStart) {
         int processed = reusePrefix;
         int embdIndex = 0;
         float[][] embeddings = multimodalData.getEmbeddings();
@@ -207,7 +491,8 @@ class InferenceLogicExecutor {
         
         // Process multimodal embeddings first
         while (embdIndex < embeddings.length && processed < nTokens) {
-            if (Instant.now().isAfter(deadline)) throw new RuntimeException("Multimodal prompt timed out");
+            if (Instant.now().isAfter(deadline)) throw LlamaCppTimeouts.timedOut("Multimodal prompt timed out");
+            if (timeouts.prefillExpired(requestStart, System.nanoTime())) throw timeouts.prefillTimedOut();
             
             // Check if current position matches embedding position
             if (embdPos != null && embdIndex < embdPos.length && processed == embdPos[embdIndex]) {
@@ -218,6 +503,7 @@ class InferenceLogicExecutor {
                 if (binding.decode(context, batch) != 0) throw new RuntimeException("Multimodal decode failed");
                 embdIndex++;
                 processed++;
+                kvCacheManager.slotStats().setTokens(0, processed);
             } else {
                 // Process regular token
                 int chunk = Math.min(maxBatch, nTokens - processed);
@@ -227,17 +513,20 @@ class InferenceLogicExecutor {
                 }
                 if (binding.decode(context, batch) != 0) throw new RuntimeException("Prompt evaluation failed");
                 processed += chunk;
+                kvCacheManager.slotStats().setTokens(0, processed);
             }
         }
         
         // Process remaining tokens
         while (processed < nTokens) {
-            if (Instant.now().isAfter(deadline)) throw new RuntimeException("Prompt timed out");
+            if (Instant.now().isAfter(deadline)) throw LlamaCppTimeouts.timedOut("Prompt timed out");
+            if (timeouts.prefillExpired(requestStart, System.nanoTime())) throw timeouts.prefillTimedOut();
             int chunk = Math.min(maxBatch, nTokens - processed);
             binding.setBatchSize(batch, chunk);
             for (int i = 0; i < chunk; i++) binding.setBatchToken(batch, i, promptTokens[processed + i], processed + i, 0, i == chunk - 1);
             if (binding.decode(context, batch) != 0) throw new RuntimeException("Prompt evaluation failed");
             processed += chunk;
+            kvCacheManager.slotStats().setTokens(0, processed);
         }
         
         return processed;
@@ -0,0 +1,230 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.lang.foreign.Arena;
+import java.lang.foreign.FunctionDescriptor;
+import java.lang.foreign.Linker;
+import java.lang.foreign.MemorySegment;
+import java.lang.foreign.SymbolLookup;
+import java.lang.foreign.ValueLayout;
+import java.lang.invoke.MethodHandle;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.ArrayList;
+import java.util.Comparator;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.OptionalInt;
+import java.util.function.ToIntFunction;
+import java.util.stream.Stream;
+
+/**
+ * Loads ggml backend modules for llama.cpp builds with {@code GGML_BACKEND_DL}, where
+ * {@code libllama} links no backend and each one (CPU, CUDA, Metal, Vulkan, ...) is a
+ * separate module registered at runtime. A single distribution can then ship all of them
+ * and let every host pick what it can run.
+ *
+ * <p>Modules are named {@code [lib]ggml-<backend>[-<variant>]}. One module per backend is
+ * loaded:
+ * <ul>
+ *   <li>CUDA variants {@code cu<major>[.<minor>]} (e.g. {@code libggml-cuda-cu12.4.so}):
+ *       the newest the installed driver supports ({@code cuDriverGetVersion}), falling back
+ *       to the unversioned module;</li>
+ *   <li>other variants (the {@code GGML_CPU_ALL_VARIANTS} CPU modules such as
+ *       {@code libggml-cpu-haswell.so}): the highest non-zero {@code ggml_backend_score},
+ *       falling back to the unversioned module.</li>
+ * </ul>
+ */
+final class LlamaCppBackendLoader {
+
+    private static final Logger log = Logger.getLogger(LlamaCppBackendLoader.class);
+
+    /** A backend module file: {@code backend} ({@code cpu}, {@code cuda}, ...) and its variant, or "". */
+    record Module(Path path, String backend, String variant) {
+
+        String label() {
+            return variant.isEmpty() ? backend : backend + " (" + variant + ")";
+        }
+    }
+
+    private LlamaCppBackendLoader() {
+    }
+
+    /**
+     * Loads the best module of each backend in {@code dir} when the library has no backend
+     * registered yet, i.e. was built with {@code GGML_BACKEND_DL}. Returns the labels of the
+     * loaded modules; empty when nothing had to (or could) be loaded.
+     */
+    static List<String> loadIfDynamic(LlamaCppBinding binding, Optional<Path> dir) {
+        if (binding.backendRegistryCount() != 0) {
+            // backends are linked in, or the library predates the registry API
+            return List.of();
+        }
+        if (dir.isEmpty() || !Files.isDirectory(dir.get())) {
+            log.warn("llama.cpp was built with GGML_BACKEND_DL but the backend module directory is unknown; "
+                    + "set gguf.provider.native.backend-dir");
+            return List.of();
+        }
+        List<String> loaded = new ArrayList<>();
+        for (Module module : select(discover(dir.get()), cudaDriverVersion(), LlamaCppBackendLoader::score)) {
+            if (binding.loadBackend(module.path())) {
+                loaded.add(module.label());
+            } else {
+                log.warnf("Failed to load ggml backend module %s", module.path());
+            }
+        }
+        if (loaded.isEmpty()) {
+            log.warnf("llama.cpp was built with GGML_BACKEND_DL but no backend module could be loaded from %s; "
+                    + "set gguf.provider.native.backend-dir", dir.get());
+        } else {
+            log.infof("Loaded ggml backends from %s: %s", dir.get(), loaded);
+        }
+        return loaded;
+    }
+
+    /** True for variant module file names, which only {@code GGML_BACKEND_DL} builds produce. */
+    static boolean isVariant(String fileName) {
+        return parse(Path.of(fileName)).map(m -> !m.variant().isEmpty()).orElse(false);
+    }
+
+    static Optional<Module> parse(Path file) {
+        String name = file.getFileName().toString();
+        String ext = LlamaNativeLoader.nativeLibExt();
+        if (!name.endsWith(ext)) {
+            return Optional.empty();
+        }
+        name = name.substring(0, name.length() - ext.length());
+        if (name.startsWith("lib")) {
+            name = name.substring(3);
+        }
+        if (!name.startsWith("ggml-")) {
+            return Optional.empty();
+        }
+        String rest = name.substring("ggml-".length());
+        int dash = rest.indexOf('-');
+        String backend = dash < 0 ? rest : rest.substring(0, dash);
+        String variant = dash < 0 ? "" : rest.substring(dash + 1);
+        // ggml-base is the shared core; libggml-cuda.0.dylib style names are soname copies
+        if (backend.isEmpty() || backend.equals("base") || backend.contains(".")) {
+            return Optional.empty();
+        }
+        return Optional.of(new Module(file, backend, variant));
+    }
+
+    static List<Module> discover(Path dir) {
+        try (Stream<Path> files = Files.list(dir)) {
+            return files.filter(Files::isRegularFile)
+                    .sorted()
+                    .map(LlamaCppBackendLoader::parse)
+                    .flatMap(Optional::stream)
+                    .toList();
+        } catch (IOException e) {
+            log.debugf("Failed to list ggml backend modules in %s: %s", dir, e.getMessage());
+            return List.of();
+        }
+    }
+
+    /** The module to load for each backend, in discovery order. */
+    static List<Module> select(List<Module> modules, OptionalInt cudaDriver, ToIntFunction<Path> score) {
+        Map<String, List<Module>> byBackend = new LinkedHashMap<>();
+        modules.forEach(m -> byBackend.computeIfAbsent(m.backend(), k -> new ArrayList<>()).add(m));
+        List<Module> chosen = new ArrayList<>();
+        byBackend.forEach((backend, candidates) -> {
+            Module best = backend.equals("cuda") ? selectCuda(candidates, cudaDriver) : selectScored(candidates, score);
+            if (best != null) {
+                chosen.add(best);
+            }
+        });
+        return chosen;
+    }
+
+    private static Module selectCuda(List<Module> candidates, OptionalInt driver) {
+        if (driver.isPresent()) {
+            Optional<Module> newest = candidates.stream()
+                    .filter(m -> cudaVersion(m.variant()) > 0 && cudaVersion(m.variant()) <= driver.getAsInt())
+                    .max(Comparator.comparingInt(m -> cudaVersion(m.variant())));
+            if (newest.isPresent()) {
+                return newest.get();
+            }
+        }
+        return unversioned(candidates);
+    }
+
+    private static Module selectScored(List<Module> candidates, ToIntFunction<Path> score) {
+        Module best = null;
+        int bestScore = 0;
+        for (Module module : candidates) {
+            if (module.variant().isEmpty()) {
+                continue;
+            }
+            int s = score.applyAsInt(module.path());
+            if (s > bestScore) {
+                best = module;
+                bestScore = s;
+            }
+        }
+        return best != null ? best : unversioned(candidates);
+    }
+
+    private static Module unversioned(List<Module> candidates) {
+        return candidates.stream().filter(m -> m.variant().isEmpty()).findFirst().orElse(null);
+    }
+
+    /**
+     * {@code cu12.4} / {@code cu12} as a CUDA version number in {@code cuDriverGetVersion}
+     * form (12040 / 12000), or -1.
+     */
+    static int cudaVersion(String variant) {
+        if (!variant.startsWith("cu")) {
+            return -1;
+        }
+        String[] parts = variant.substring(2).split("\\.");
+        try {
+            int major = Integer.parseInt(parts[0]);
+            int minor = parts.length > 1 ? Integer.parseInt(parts[1]) : 0;
+            return major * 1000 + minor * 10;
+        } catch (NumberFormatException e) {
+            return -1;
+        }
+    }
+
+    /** The module's {@code ggml_backend_score} (0 = unsupported on this host, or no score). */
+    static int score(Path module) {
+        try (Arena arena = Arena.ofConfined()) {
+            Optional<MemorySegment> fn = SymbolLookup.libraryLookup(module, arena).find("ggml_backend_score");
+            if (fn.isEmpty()) {
+                return 0;
+            }
+            MethodHandle scoreFn = Linker.nativeLinker().downcallHandle(fn.get(),
+                    FunctionDescriptor.of(ValueLayout.JAVA_INT));
+            return (int) scoreFn.invokeExact();
+        } catch (Throwable e) {
+            log.debugf("Failed to score ggml backend %s: %s", module, e.getMessage());
+            return 0;
+        }
+    }
+
+    /** Version of the installed CUDA driver, e.g. 12040 for 12.4; empty without a driver. */
+    static OptionalInt cudaDriverVersion() {
+        if (LlamaNativeLoader.isMacOS()) {
+            return OptionalInt.empty();
+        }
+        String library = LlamaNativeLoader.isWindows() ? "nvcuda" : "libcuda.so.1";
+        try (Arena arena = Arena.ofConfined()) {
+            MemorySegment fn = SymbolLookup.libraryLookup(library, arena).find("cuDriverGetVersion").orElseThrow();
+            MethodHandle getVersion = Linker.nativeLinker().downcallHandle(fn,
+                    FunctionDescriptor.of(ValueLayout.JAVA_INT, ValueLayout.ADDRESS));
+            MemorySegment version = arena.allocate(ValueLayout.JAVA_INT);
+            if ((int) getVersion.invokeExact(version) == 0) {
+                return OptionalInt.of(version.get(ValueLayout.JAVA_INT, 0));
+            }
+        } catch (Throwable e) {
+            log.debugf("No CUDA driver: %s", e.getMessage());
+        }
+        return OptionalInt.empty();
+    }
+}
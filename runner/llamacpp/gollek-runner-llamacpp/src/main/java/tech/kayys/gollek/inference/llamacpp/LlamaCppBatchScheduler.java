@@ -0,0 +1,895 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.jboss.logging.Logger;
+import tech.kayys.gollek.spi.exception.RunnerBusyException;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.Priority;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+
+import java.lang.foreign.MemorySegment;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.ArrayDeque;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.Iterator;
+import java.util.List;
+import java.util.Random;
+import java.util.concurrent.CancellationException;
+import java.util.concurrent.CompletableFuture;
+import java.util.concurrent.PriorityBlockingQueue;
+import java.util.concurrent.TimeUnit;
+import java.util.concurrent.atomic.AtomicLong;
+import java.util.function.Consumer;
+
+/**
+ * Continuous batching scheduler. A single worker thread owns the llama context and
+ * interleaves up to {@code n_seq_max} requests in one batch per decode step: generating
+ * sequences contribute their next token, sequences still in prefill contribute prompt
+ * chunks, and sequences join or leave between steps as requests arrive and finish.
+ *
+ * <p>Requests that need the whole context (session persistence, multimodal embeddings)
+ * are run exclusively through the single-sequence executor once active sequences drain.
+ *
+ * <p>Waiting requests start in {@link Priority} order (the {@value #PRIORITY} parameter,
+ * else the request's priority), first come first served within a priority, or with
+ * {@code shortest-job-first} by arrival time pushed back in proportion to the output
+ * length {@link LlamaCppOutputLengthPredictor} expects. With the
+ * {@code evict} preemption policy a waiting request also takes the sequence of a running
+ * one it outranks; the evicted request waits at the head of its priority and resumes by
+ * re-prefilling its prompt and the output it had produced.
+ */
+public class LlamaCppBatchScheduler {
+
+    private static final Logger log = Logger.getLogger(LlamaCppBatchScheduler.class);
+    private static final long IDLE_POLL_MS = 50;
+    private static final double COMPLETION_EWMA_ALPHA = 0.3;
+    static final String PRIORITY = "priority";
+
+    /**
+     * Where a queued request stands: {@code position} is 1 for the next request to start;
+     * {@code estimatedWaitMs} is null until a request has completed and the throughput is
+     * known.
+     */
+    public record QueueStatus(int position, int queued, Long estimatedWaitMs) {
+    }
+
+    /**
+     * Runs a request alone on the context, bypassing batching.
+     */
+    @FunctionalInterface
+    public interface ExclusiveExecutor {
+        InferenceResponse execute(InferenceRequest request, Consumer<String> onTokenPiece);
+    }
+
+    private final LlamaCppBinding binding;
+    private final LlamaCppMetricsRecorder metricsRecorder;
+    private final LlamaCppKVCacheManager kvCacheManager;
+    private final LlamaCppSlotStats slotStats;
+    private final LlamaCppTokenSampler tokenSampler;
+    private final InferenceLogicExecutor promptExecutor;
+    private final ExclusiveExecutor exclusiveExecutor;
+    private final MemorySegment model;
+    private final MemorySegment context;
+    private final String modelId;
+    private final int vocabSize;
+    private final int maxSequences;
+    private final int maxBatch;
+    private final int sequenceContext;
+    private final int maxContextTokens;
+
+    private final PriorityBlockingQueue<Task> queue = new PriorityBlockingQueue<>();
+    private final int maxQueue;
+    private final long queueWaitNanos;
+    private final boolean evict;
+    private final boolean shortestJobFirst;
+    private final int sjfMsPerToken;
+    private final LlamaCppOutputLengthPredictor lengthPredictor = new LlamaCppOutputLengthPredictor();
+    private final AtomicLong submitted = new AtomicLong();
+    private final ArrayDeque<Integer> freeSequences = new ArrayDeque<>();
+    private final List<Slot> active = new ArrayList<>();
+    private Task waiting;
+    private boolean contextDirty;
+    private boolean seqRmSupported = true;
+    private long lastCompletionNanos;
+    private double completionIntervalNanos;
+    /** Submitters waiting for room in a full queue; changed only while holding {@code queue}. */
+    private volatile int blocked;
+
+    private volatile boolean shutdown;
+    private Thread worker;
+
+    public LlamaCppBatchScheduler(
+            LlamaCppBinding binding,
+            LlamaCppProviderConfig providerConfig,
+            LlamaCppMetricsRecorder metricsRecorder,
+            LlamaCppKVCacheManager kvCacheManager,
+            LlamaCppTokenSampler tokenSampler,
+            InferenceLogicExecutor promptExecutor,
+            ExclusiveExecutor exclusiveExecutor,
+            MemorySegment model,
+            MemorySegment context,
+            String modelId,
+            int contextSize,
+            int vocabSize,
+            int runtimeBatchSize) {
+
+        this.binding = binding;
+        this.metricsRecorder = metricsRecorder;
+        this.kvCacheManager = kvCacheManager;
+        this.slotStats = kvCacheManager.slotStats();
+        this.tokenSampler = tokenSampler;
+        this.promptExecutor = promptExecutor;
+        this.exclusiveExecutor = exclusiveExecutor;
+        this.model = model;
+        this.context = context;
+        this.modelId = modelId;
+        this.vocabSize = vocabSize;
+        this.maxSequences = Math.max(1, providerConfig.continuousBatchingMaxSequences());
+        this.maxBatch = Math.max(maxSequences, runtimeBatchSize);
+        this.sequenceContext = contextSize > 0 ? Math.max(1, contextSize / Math.max(maxSequences, providerConfig.sequenceSlots())) : 0;
+        this.maxContextTokens = providerConfig.maxContextTokens();
+        this.maxQueue = Math.max(1, providerConfig.continuousBatchingMaxQueue());
+        Duration queueWait = providerConfig.continuousBatchingQueueWait();
+        this.queueWaitNanos = queueWait == null ? 0L : Math.max(0L, queueWait.toNanos());
+        this.evict = "evict".equalsIgnoreCase(providerConfig.continuousBatchingPreemption());
+        this.shortestJobFirst = providerConfig.continuousBatchingShortestJobFirst();
+        this.sjfMsPerToken = Math.max(0, providerConfig.continuousBatchingSjfMsPerToken());
+        for (int seq = 0; seq < maxSequences; seq++) {
+            freeSequences.add(seq);
+        }
+    }
+
+    /**
+     * Start the scheduler worker thread.
+     */
+    public void start() {
+        if (worker != null) {
+            return;
+        }
+        worker = new Thread(this::run, "gguf-batch-scheduler");
+        worker.setDaemon(true);
+        worker.start();
+    }
+
+    /**
+     * Submit a request and block until it completes. Tokens are streamed to
+     * {@code onTokenPiece} from the worker thread as they are sampled.
+     */
+    public InferenceResponse submit(InferenceRequest request, Consumer<String> onTokenPiece) {
+        return submit(request, onTokenPiece, null);
+    }
+
+    /**
+     * As {@link #submit(InferenceRequest, Consumer)}, additionally reporting the request's
+     * queue position to {@code onQueued} (from the worker thread) whenever it changes
+     * while the request waits for a sequence.
+     *
+     * @throws RunnerBusyException when the queue stays full for the {@code queue-wait} budget
+     */
+    public InferenceResponse submit(InferenceRequest request, Consumer<String> onTokenPiece,
+            Consumer<QueueStatus> onQueued) {
+        if (shutdown) {
+            throw new RuntimeException("Runner closed");
+        }
+        long sequence = submitted.incrementAndGet();
+        Task task = new Task(request, onTokenPiece, onQueued, isExclusive(request), priorityOf(request), sequence,
+                rank(request, sequence));
+        synchronized (queue) {
+            awaitRoom();
+            queue.add(task);
+        }
+        try {
+            return task.future.get();
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+            throw new RuntimeException("Interrupted", e);
+        } catch (Exception e) {
+            Throwable cause = e.getCause() == null ? e : e.getCause();
+            if (cause instanceof RuntimeException re) {
+                throw re;
+            }
+            throw new RuntimeException("Batched inference failed", cause);
+        }
+    }
+
+    /**
+     * Block, holding the {@code queue} monitor, until the queue has room or the queue-wait
+     * budget runs out. The worker wakes waiters as it takes requests off the queue.
+     */
+    private void awaitRoom() {
+        long deadline = System.nanoTime() + queueWaitNanos;
+        while (queue.size() >= maxQueue) {
+            long remaining = deadline - System.nanoTime();
+            if (shutdown) {
+                throw new RuntimeException("Runner closed");
+            }
+            if (remaining <= 0) {
+                metricsRecorder.recordRejection();
+                throw new RunnerBusyException("Runner busy: " + queue.size() + " requests queued",
+                        retryAfterSeconds());
+            }
+            blocked++;
+            try {
+                TimeUnit.NANOSECONDS.timedWait(queue, remaining);
+            } catch (InterruptedException e) {
+                Thread.currentThread().interrupt();
+                throw new RuntimeException("Interrupted", e);
+            } finally {
+                blocked--;
+            }
+        }
+    }
+
+    /**
+     * Wake submitters waiting for room once the worker has taken requests off the queue.
+     */
+    private void signalRoom() {
+        if (blocked == 0) {
+            return;
+        }
+        synchronized (queue) {
+            if (queue.size() < maxQueue || shutdown) {
+                queue.notifyAll();
+            }
+        }
+    }
+
+    /**
+     * Seconds until a full queue should have room again: the time to the next completion
+     * at the recent rate, or one second before any request has completed.
+     */
+    long retryAfterSeconds() {
+        Long waitMs = estimateWaitMs(1);
+        return waitMs == null ? 1 : Math.max(1, (waitMs + 999) / 1000);
+    }
+
+    /**
+     * Stop the worker and fail queued and in-flight requests.
+     */
+    public void shutdown() {
+        shutdown = true;
+        signalRoom();
+        if (worker != null) {
+            worker.interrupt();
+            try {
+                worker.join(TimeUnit.SECONDS.toMillis(5));
+            } catch (InterruptedException e) {
+                Thread.currentThread().interrupt();
+            }
+        }
+        Task pending;
+        while ((pending = queue.poll()) != null) {
+            pending.future.completeExceptionally(new RuntimeException("Runner closed"));
+        }
+    }
+
+    static boolean isExclusive(InferenceRequest request) {
+        Object persist = request.getParameters().getOrDefault("gguf.session.persist", "false");
+        return Boolean.parseBoolean(String.valueOf(persist)) || InferenceLogicExecutor.hasMultimodalData(request);
+    }
+
+    /**
+     * The {@value #PRIORITY} parameter (a name such as {@code high}, or a level where 0 is
+     * critical), else the request's own priority.
+     */
+    static Priority priorityOf(InferenceRequest request) {
+        Object value = request.getParameters().get(PRIORITY);
+        if (value instanceof Priority priority) {
+            return priority;
+        }
+        if (value instanceof Number number) {
+            int level = Math.max(Priority.CRITICAL.level(), Math.min(Priority.LOW.level(), number.intValue()));
+            return Arrays.stream(Priority.values()).filter(p -> p.level() == level).findFirst()
+                    .orElse(Priority.NORMAL);
+        }
+        if (value != null) {
+            return Priority.fromString(value.toString());
+        }
+        return request.getPriority();
+    }
+
+    private void run() {
+        MemorySegment batch = binding.batchInit(maxBatch, 0, maxSequences);
+        try {
+            while (!shutdown) {
+                try {
+                    expireQueued();
+                    preempt();
+                    admit();
+                    signalRoom();
+                    reportQueue();
+                    recordQueueDepths();
+                    if (active.isEmpty()) {
+                        if (waiting == null) {
+                            waiting = queue.poll(IDLE_POLL_MS, TimeUnit.MILLISECONDS);
+                        }
+                        continue;
+                    }
+                    step(batch);
+                } catch (InterruptedException e) {
+                    Thread.currentThread().interrupt();
+                    break;
+                } catch (Exception e) {
+                    log.error("Batch scheduler step failed", e);
+                    failActive(e);
+                }
+            }
+        } finally {
+            failActive(new RuntimeException("Runner closed"));
+            if (waiting != null) {
+                waiting.future.completeExceptionally(new RuntimeException("Runner closed"));
+                waiting = null;
+            }
+            binding.batchFree(batch);
+        }
+    }
+
+    private void admit() {
+        while (!freeSequences.isEmpty()) {
+            Task task = waiting != null ? waiting : queue.poll();
+            waiting = null;
+            if (task == null) {
+                return;
+            }
+            if (RequestCancellation.isCancelled(task.request.getRequestId())) {
+                if (task.parked != null) {
+                    finish(task.parked, InferenceResponse.FinishReason.CANCELLED);
+                    recordCompletion();
+                    continue;
+                }
+                // the client left while queued; never occupy a sequence for it
+                task.future.completeExceptionally(new CancellationException(
+                        "Request " + task.request.getRequestId() + " cancelled"));
+                continue;
+            }
+            if (task.parked != null) {
+                resume(task);
+                continue;
+            }
+            if (task.exclusive || !seqRmSupported) {
+                if (!active.isEmpty()) {
+                    // hold the task (and everything behind it) until the context drains
+                    waiting = task;
+                    return;
+                }
+                if (task.exclusive) {
+                    runExclusive(task);
+                    continue;
+                }
+            }
+            startSlot(task);
+        }
+    }
+
+    /**
+     * Fail requests that waited longer than their {@code queue_timeout_ms} for a sequence.
+     * Evicted requests have started, so the budget no longer applies to them.
+     */
+    private void expireQueued() {
+        long now = System.nanoTime();
+        if (waiting != null && waiting.queueExpired(now)) {
+            waiting.future.completeExceptionally(waiting.timeouts.queueTimedOut());
+            waiting = null;
+        }
+        queue.removeIf(task -> {
+            if (!task.queueExpired(now)) {
+                return false;
+            }
+            task.future.completeExceptionally(task.timeouts.queueTimedOut());
+            return true;
+        });
+    }
+
+    /**
+     * Under the {@code evict} policy, free a sequence for the head of the queue by parking
+     * the lowest-priority, most recently submitted running request it outranks.
+     */
+    private void preempt() {
+        if (!evict || !freeSequences.isEmpty() || waiting != null || !seqRmSupported) {
+            return;
+        }
+        Task head = queue.peek();
+        if (head == null || head.exclusive) {
+            return;
+        }
+        Slot victim = null;
+        for (Slot slot : active) {
+            if (!slot.completed && (victim == null || slot.task.compareTo(victim.task) > 0)) {
+                victim = slot;
+            }
+        }
+        if (victim == null || victim.task.priority.level() <= head.priority.level()) {
+            return;
+        }
+        active.remove(victim);
+        release(victim);
+        victim.park();
+        victim.task.parked = victim;
+        queue.add(victim.task);
+        metricsRecorder.recordPreemption();
+        metricsRecorder.recordActiveSequences(active.size());
+        log.debugf("Preempted %s (%s) for %s (%s) after %d tokens", victim.task.request.getRequestId(),
+                victim.task.priority, head.request.getRequestId(), head.priority, victim.generated);
+    }
+
+    private void resume(Task task) {
+        Slot slot = task.parked;
+        task.parked = null;
+        slot.seqId = freeSequences.poll();
+        // time spent parked is the scheduler's doing, not a stalled sequence
+        slot.lastTokenNanos = System.nanoTime();
+        slotStats.acquire(slot.seqId, task.request.getRequestId());
+        active.add(slot);
+        metricsRecorder.recordActiveSequences(active.size());
+    }
+
+    private void runExclusive(Task task) {
+        if (contextDirty) {
+            kvCacheManager.resetKvCache(context);
+            contextDirty = false;
+        }
+        try {
+            task.future.complete(exclusiveExecutor.execute(task.request, task.onTokenPiece));
+        } catch (Exception e) {
+            task.future.completeExceptionally(e);
+        }
+        recordCompletion();
+    }
+
+    /**
+     * Tell queued requests that asked for it where they stand. The held task (if any) is
+     * next, then the queue in priority order.
+     */
+    private void reportQueue() {
+        if (waiting == null && queue.isEmpty()) {
+            return;
+        }
+        int queued = queue.size() + (waiting != null ? 1 : 0);
+        int position = 0;
+        if (waiting != null) {
+            notifyQueued(waiting, ++position, queued);
+        }
+        for (Task task : queue.stream().sorted().toList()) {
+            notifyQueued(task, ++position, queued);
+        }
+    }
+
+    private void notifyQueued(Task task, int position, int queued) {
+        // evicted requests are already streaming; queue events are only sent before the first token
+        if (task.onQueued == null || task.parked != null || task.lastPosition == position) {
+            return;
+        }
+        task.lastPosition = position;
+        try {
+            task.onQueued.accept(new QueueStatus(position, queued, estimateWaitMs(position)));
+        } catch (Exception e) {
+            log.debugf("Queue status listener failed for %s: %s", task.request.getRequestId(), e.getMessage());
+        }
+    }
+
+    /**
+     * Queue order within a priority: arrival order, or under shortest-job-first the arrival
+     * time in milliseconds plus the predicted output length weighted by {@code sjf-ms-per-token}.
+     */
+    long rank(InferenceRequest request, long sequence) {
+        if (!shortestJobFirst) {
+            return sequence;
+        }
+        return System.nanoTime() / 1_000_000 + (long) lengthPredictor.predict(request) * sjfMsPerToken;
+    }
+
+    LlamaCppOutputLengthPredictor lengthPredictor() {
+        return lengthPredictor;
+    }
+
+    /**
+     * The queue as a request arriving now would see it: behind every waiting request, with
+     * the wait predicted from recent completions. Read without locking, so approximate.
+     */
+    public QueueStatus queueStatus() {
+        int queued = queue.size() + (waiting != null ? 1 : 0);
+        return new QueueStatus(queued + 1, queued, estimateWaitMs(queued + 1));
+    }
+
+    /**
+     * A request at {@code position} starts after about that many completions, spaced by the
+     * recent average interval between completions.
+     */
+    Long estimateWaitMs(int position) {
+        if (completionIntervalNanos <= 0) {
+            return null;
+        }
+        return Math.round(position * completionIntervalNanos / 1_000_000d);
+    }
+
+    private void recordQueueDepths() {
+        int[] depths = new int[Priority.values().length];
+        if (waiting != null) {
+            depths[waiting.priority.ordinal()]++;
+        }
+        for (Task task : queue) {
+            depths[task.priority.ordinal()]++;
+        }
+        metricsRecorder.recordQueueDepths(depths);
+    }
+
+    void recordCompletion() {
+        long now = System.nanoTime();
+        if (lastCompletionNanos != 0L) {
+            long interval = now - lastCompletionNanos;
+            completionIntervalNanos = completionIntervalNanos <= 0
+                    ? interval
+                    : COMPLETION_EWMA_ALPHA * interval + (1 - COMPLETION_EWMA_ALPHA) * completionIntervalNanos;
+        }
+        lastCompletionNanos = now;
+    }
+
+    private void startSlot(Task task) {
+        if (!contextDirty) {
+            // the single-sequence prefix history no longer matches once other sequences share the cache
+            kvCacheManager.resetKvCache(context);
+            contextDirty = true;
+        }
+        Slot slot;
+        try {
+            slot = newSlot(task);
+        } catch (Exception e) {
+            task.future.completeExceptionally(e);
+            return;
+        }
+        if (slot == null) {
+            task.future.complete(InferenceResponse.builder().requestId(task.request.getRequestId())
+                    .model(modelId).content("").tokensUsed(0).build());
+            return;
+        }
+        slot.seqId = freeSequences.poll();
+        slotStats.acquire(slot.seqId, task.request.getRequestId());
+        active.add(slot);
+        metricsRecorder.recordActiveSequences(active.size());
+    }
+
+    private Slot newSlot(Task task) {
+        InferenceRequest request = task.request;
+        long requestStart = System.nanoTime();
+        String prompt = promptExecutor.resolvePrompt(request);
+        if (prompt == null || prompt.isBlank()) {
+            return null;
+        }
+        int[] tokens = promptExecutor.tokenizePrompt(request, prompt);
+        int originalTokens = tokens.length;
+        tokens = promptExecutor.compressPrompt(request, tokens);
+        if (tokens.length == 0) {
+            return null;
+        }
+        int compressedTokens = tokens.length;
+        List<String> warnings = new ArrayList<>();
+        int limit = sequenceContext > 0 ? sequenceContext : Integer.MAX_VALUE;
+        if (maxContextTokens > 0) {
+            limit = Math.min(limit, maxContextTokens);
+        }
+        tokens = promptExecutor.fitPrompt(request, tokens, limit, warnings);
+        InferenceLogicExecutor.GenerationParams params = InferenceLogicExecutor.GenerationParams.of(request, warnings);
+        int maxTokens = params.maxTokens();
+        if (sequenceContext > 0 && tokens.length + maxTokens > sequenceContext) {
+            int clamped = Math.max(0, sequenceContext - tokens.length);
+            warnings.add("max_tokens reduced from " + maxTokens + " to " + clamped + " to fit the per-sequence context window");
+            maxTokens = clamped;
+        }
+        Slot slot = new Slot(task, tokens, params, maxTokens, promptExecutor.stopSequences(request), warnings,
+                requestStart, vocabSize);
+        slot.originalTokens = originalTokens;
+        slot.compressedTokens = compressedTokens;
+        slot.logprobs = LlamaCppLogprobs.forRequest(request, t -> binding.tokenToPiece(model, t));
+        return slot;
+    }
+
+    private void step(MemorySegment batch) {
+        int n = 0;
+        for (Slot slot : active) {
+            slot.logitIndex = -1;
+            if (slot.prefilled == slot.tokens.length && slot.pendingToken >= 0) {
+                binding.setBatchToken(batch, n, slot.pendingToken, slot.pos++, slot.seqId, true);
+                slotStats.setTokens(slot.seqId, slot.pos);
+                slot.pendingToken = -1;
+                slot.logitIndex = n++;
+            }
+        }
+        for (Slot slot : active) {
+            if (n >= maxBatch) {
+                break;
+            }
+            if (slot.prefilled >= slot.tokens.length) {
+                continue;
+            }
+            int chunk = Math.min(maxBatch - n, slot.tokens.length - slot.prefilled);
+            for (int i = 0; i < chunk; i++) {
+                int pos = slot.prefilled + i;
+                binding.setBatchToken(batch, n + i, slot.tokens[pos], pos, slot.seqId, pos == slot.tokens.length - 1);
+            }
+            slot.prefilled += chunk;
+            n += chunk;
+            slotStats.setTokens(slot.seqId, slot.prefilled);
+            if (slot.prefilled == slot.tokens.length) {
+                slot.logitIndex = n - 1;
+                slot.pos = slot.tokens.length;
+                if (slot.promptEndNanos == 0L) {
+                    slot.promptEndNanos = System.nanoTime();
+                }
+            }
+        }
+        if (n == 0) {
+            return;
+        }
+        binding.setBatchSize(batch, n);
+        if (binding.decode(context, batch) != 0) {
+            throw new RuntimeException("Batched decode failed");
+        }
+        metricsRecorder.recordBatchStep(active.size(), n);
+
+        Instant now = Instant.now();
+        Iterator<Slot> it = active.iterator();
+        while (it.hasNext()) {
+            Slot slot = it.next();
+            boolean done;
+            try {
+                done = advance(slot, now);
+            } catch (Exception e) {
+                slot.task.future.completeExceptionally(e);
+                done = true;
+                slot.completed = true;
+            }
+            if (done) {
+                it.remove();
+                release(slot);
+                recordCompletion();
+            }
+        }
+        metricsRecorder.recordActiveSequences(active.size());
+    }
+
+    /**
+     * Sample the next token for a slot whose logits were produced this step.
+     * Returns true when the slot has finished.
+     */
+    private boolean advance(Slot slot, Instant now) {
+        if (now.isAfter(slot.deadline)) {
+            if (slot.timeLimited) {
+                return finish(slot, InferenceResponse.FinishReason.TIME_LIMIT);
+            }
+            if (!slot.task.request.isReturnPartialOnTimeout()) {
+                throw LlamaCppTimeouts.timedOut(slot.promptEndNanos == 0L ? "Prompt timed out" : "Generation timed out");
+            }
+            return finish(slot, InferenceResponse.FinishReason.TIMEOUT);
+        }
+        if (RequestCancellation.isCancelled(slot.task.request.getRequestId())) {
+            return finish(slot, InferenceResponse.FinishReason.CANCELLED);
+        }
+        long nowNanos = System.nanoTime();
+        if (slot.generated == 0 && slot.task.timeouts.prefillExpired(slot.requestStart, nowNanos)) {
+            throw slot.task.timeouts.prefillTimedOut();
+        }
+        if (slot.generated > 0 && slot.task.timeouts.stalled(slot.lastTokenNanos, nowNanos)) {
+            return finish(slot, InferenceResponse.FinishReason.STALLED);
+        }
+        if (slot.logitIndex < 0) {
+            return false;
+        }
+        if (slot.generated >= slot.maxTokens) {
+            return finish(slot, InferenceResponse.FinishReason.LENGTH);
+        }
+        int token = tokenSampler.sampleNextToken(context, slot.logitIndex, slot.config, slot.random);
+        if (promptExecutor.isEndToken(token)) {
+            return finish(slot, InferenceResponse.FinishReason.STOP);
+        }
+        String piece = binding.tokenToPiece(model, token);
+        slot.lastTokenNanos = System.nanoTime();
+        if (slot.generated == 0) {
+            slot.firstTokenNanos = slot.lastTokenNanos;
+        }
+        if (slot.logprobs != null) {
+            slot.logprobs.record(tokenSampler.logits(context, slot.logitIndex), token, piece);
+        }
+        InferenceLogicExecutor.emit(slot.result, slot.stopMatcher.accept(piece), slot.task.onTokenPiece,
+                slot.logprobs);
+        slot.output[slot.generated++] = token;
+        if (slot.stopMatcher.matched() != null) {
+            slot.stopSequence = slot.stopMatcher.matched();
+            return finish(slot, InferenceResponse.FinishReason.STOP);
+        }
+        if (slot.repeatLastN > 0) {
+            int[] state = kvCacheManager.pushRecentToken(token, slot.recentRing, slot.recentRingSize,
+                    slot.recentRingIndex, slot.config.recentTokenCounts, slot.repeatLastN);
+            slot.recentRingSize = state[0];
+            slot.recentRingIndex = state[1];
+        }
+        if (slot.generated >= slot.maxTokens) {
+            return finish(slot, InferenceResponse.FinishReason.LENGTH);
+        }
+        slot.pendingToken = token;
+        return false;
+    }
+
+    private boolean finish(Slot slot, InferenceResponse.FinishReason reason) {
+        InferenceLogicExecutor.emit(slot.result, slot.stopMatcher.flush(), slot.task.onTokenPiece, slot.logprobs);
+        int inputTokens = slot.promptTokens;
+        long promptEnd = slot.promptEndNanos;
+        metricsRecorder.recordInferenceMetrics(slot.requestStart, slot.requestStart, promptEnd, promptEnd,
+                slot.firstTokenNanos, inputTokens, slot.generated);
+        InferenceResponse.Builder response = InferenceResponse.builder()
+                .requestId(slot.task.request.getRequestId())
+                .model(modelId)
+                .content(slot.result.toString())
+                .inputTokens(inputTokens)
+                .outputTokens(slot.generated)
+                .tokensUsed(inputTokens + slot.generated)
+                .finishReason(reason)
+                .warnings(slot.warnings);
+        if (slot.stopSequence != null) {
+            response.metadata(InferenceLogicExecutor.STOP_SEQUENCE, slot.stopSequence);
+        }
+        InferenceLogicExecutor.reportCompression(response, slot.originalTokens, slot.compressedTokens);
+        if (InferenceLogicExecutor.flag(slot.task.request, InferenceLogicExecutor.RETURN_TOKENS)) {
+            response.metadata(InferenceLogicExecutor.PROMPT_TOKENS,
+                    Arrays.stream(slot.tokens, 0, slot.promptTokens).boxed().toList());
+        }
+        InferenceLogicExecutor.reportLogprobs(response, slot.logprobs, slot.result.length());
+        if (LlamaCppVerbose.requested(slot.task.request)) {
+            // sequences prefill from scratch, so nothing of the prompt is reused
+            response.metadata(LlamaCppVerbose.METADATA, LlamaCppVerbose.report(slot.params, slot.maxTokens,
+                    slot.output, slot.generated, inputTokens, 0, slot.requestStart, promptEnd, slot.firstTokenNanos));
+        }
+        if (reason == InferenceResponse.FinishReason.STOP || reason == InferenceResponse.FinishReason.LENGTH) {
+            lengthPredictor.record(slot.task.request, slot.generated);
+        }
+        slot.task.future.complete(response.build());
+        slot.completed = true;
+        return true;
+    }
+
+    private void release(Slot slot) {
+        if (seqRmSupported && !binding.memorySeqRm(context, slot.seqId, -1, -1)) {
+            log.warn("llama_memory_seq_rm unavailable; sequences will only be refilled after the batch drains");
+            seqRmSupported = false;
+        }
+        if (!seqRmSupported && active.isEmpty()) {
+            kvCacheManager.resetKvCache(context);
+        }
+        slotStats.recordEviction(slot.seqId, slotStats.tokens(slot.seqId));
+        slotStats.idle(slot.seqId);
+        freeSequences.add(slot.seqId);
+    }
+
+    private void failActive(Exception cause) {
+        for (Slot slot : active) {
+            if (!slot.completed) {
+                slot.task.future.completeExceptionally(cause);
+            }
+            slotStats.recordEviction(slot.seqId, slotStats.tokens(slot.seqId));
+            slotStats.idle(slot.seqId);
+            freeSequences.add(slot.seqId);
+        }
+        active.clear();
+        if (context != null) {
+            kvCacheManager.resetKvCache(context);
+        }
+        metricsRecorder.recordActiveSequences(0);
+    }
+
+    private static final class Task implements Comparable<Task> {
+        final InferenceRequest request;
+        final Consumer<String> onTokenPiece;
+        final Consumer<QueueStatus> onQueued;
+        final boolean exclusive;
+        final Priority priority;
+        final long sequence;
+        final long rank;
+        final LlamaCppTimeouts timeouts;
+        final long submittedNanos = System.nanoTime();
+        final CompletableFuture<InferenceResponse> future = new CompletableFuture<>();
+        int lastPosition;
+        /** Generation state of an evicted request, resumed instead of started. */
+        Slot parked;
+
+        Task(InferenceRequest request, Consumer<String> onTokenPiece, Consumer<QueueStatus> onQueued,
+                boolean exclusive, Priority priority, long sequence, long rank) {
+            this.request = request;
+            this.onTokenPiece = onTokenPiece;
+            this.onQueued = onQueued;
+            this.exclusive = exclusive;
+            this.priority = priority;
+            this.sequence = sequence;
+            this.rank = rank;
+            this.timeouts = LlamaCppTimeouts.of(request.getParameters());
+        }
+
+        /** Whether the request has waited too long to start; never once it has started. */
+        boolean queueExpired(long nowNanos) {
+            return parked == null && timeouts.queueExpired(submittedNanos, nowNanos);
+        }
+
+        @Override
+        public int compareTo(Task other) {
+            int byPriority = Integer.compare(priority.level(), other.priority.level());
+            if (byPriority != 0) {
+                return byPriority;
+            }
+            int byRank = Long.compare(rank, other.rank);
+            return byRank != 0 ? byRank : Long.compare(sequence, other.sequence);
+        }
+    }
+
+    /**
+     * Per-sequence generation state.
+     */
+    private static final class Slot {
+        final Task task;
+        final int promptTokens;
+        final int[] output;
+        final int maxTokens;
+        final List<String> warnings;
+        final long requestStart;
+        final Instant deadline;
+        final boolean timeLimited;
+        final InferenceLogicExecutor.GenerationParams params;
+        final LlamaCppTokenSampler.SamplingConfig config;
+        final Random random;
+        final LlamaCppStopMatcher stopMatcher;
+        final int repeatLastN;
+        final int[] recentRing;
+        final StringBuilder result = new StringBuilder();
+
+        int[] tokens;
+        int seqId;
+        int prefilled;
+        int pos;
+        int pendingToken = -1;
+        int logitIndex = -1;
+        int generated;
+        int recentRingSize;
+        int recentRingIndex;
+        long promptEndNanos;
+        long firstTokenNanos;
+        long lastTokenNanos;
+        int originalTokens;
+        int compressedTokens;
+        String stopSequence;
+        LlamaCppLogprobs logprobs;
+        boolean completed;
+
+        Slot(Task task, int[] tokens, InferenceLogicExecutor.GenerationParams params, int maxTokens,
+                List<String> stopSequences, List<String> warnings, long requestStart, int vocabSize) {
+            this.task = task;
+            this.tokens = tokens;
+            this.promptTokens = tokens.length;
+            this.output = new int[Math.max(0, maxTokens)];
+            this.maxTokens = maxTokens;
+            this.warnings = warnings;
+            this.requestStart = requestStart;
+            this.deadline = Instant.now().plusMillis(params.timeoutMs());
+            this.timeLimited = params.timeLimited();
+            this.params = params;
+            this.repeatLastN = params.effectiveRepeatLastN();
+            this.recentRing = repeatLastN > 0 ? new int[repeatLastN] : null;
+            int[] recentTokenCounts = repeatLastN > 0 ? new int[Math.max(1, vocabSize)] : null;
+            this.config = params.samplingConfig(recentTokenCounts);
+            // seeded requests get their own generator; ThreadLocalRandom would be the worker's
+            this.random = params.seed() == -1 ? new Random() : new Random(params.seed());
+            this.stopMatcher = new LlamaCppStopMatcher(stopSequences);
+        }
+
+        /**
+         * Drop the sequence: the output so far joins the prompt, so a resumed slot
+         * re-prefills it (including the last sampled, not yet decoded token) and samples on.
+         */
+        void park() {
+            tokens = Arrays.copyOf(tokens, promptTokens + generated);
+            System.arraycopy(output, 0, tokens, promptTokens, generated);
+            prefilled = 0;
+            pos = 0;
+            pendingToken = -1;
+            logitIndex = -1;
+        }
+    }
+}
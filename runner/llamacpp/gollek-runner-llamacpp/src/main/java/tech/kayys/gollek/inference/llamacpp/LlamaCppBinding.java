@@ -3,8 +3,15 @@ package tech.kayys.gollek.inference.llamacpp;
 import org.jboss.logging.Logger;
 
 import java.lang.foreign.*;
+import java.lang.invoke.MethodHandles;
+import java.lang.invoke.MethodType;
 import java.nio.file.Path;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
 import java.util.Optional;
+import java.util.Set;
+import java.util.concurrent.ConcurrentHashMap;
 import java.util.concurrent.atomic.AtomicBoolean;
 
 /**
@@ -57,20 +64,24 @@ public class LlamaCppBinding {
         boolean verbose = config != null && config.verboseLogging();
         Optional<String> libPath = config == null ? Optional.empty() : config.nativeLibraryPath();
         Optional<String> libDir  = config == null ? Optional.empty() : config.nativeLibraryDir();
-        return doLoad(verbose, libPath, libDir);
+        Optional<String> backendDir = config == null ? Optional.empty() : config.nativeBackendDir();
+        return doLoad(verbose, libPath, libDir, backendDir);
     }
 
     /** Loads with explicit verbosity flag. */
     public static LlamaCppBinding load(boolean verbose) {
-        return doLoad(verbose, Optional.empty(), Optional.empty());
+        return doLoad(verbose, Optional.empty(), Optional.empty(), Optional.empty());
     }
 
-    private static LlamaCppBinding doLoad(boolean verbose, Optional<String> libPath, Optional<String> libDir) {
+    private static LlamaCppBinding doLoad(boolean verbose, Optional<String> libPath, Optional<String> libDir,
+            Optional<String> backendDir) {
         SymbolLookup lookup = LlamaNativeLoader.load(verbose, libPath, libDir);
         LlamaHandles handles = new LlamaHandles(lookup);
         handles.verbose = verbose;
         LlamaCppBinding binding = new LlamaCppBinding(handles);
         binding.backendInit();
+        LlamaCppBackendLoader.loadIfDynamic(binding,
+                backendDir.filter(s -> !s.isBlank()).map(Path::of).or(LlamaNativeLoader::libraryDir));
         return binding;
     }
 
@@ -107,6 +118,172 @@ public class LlamaCppBinding {
         LlamaNativeLoader.suppressNativeLogs(SymbolLookup.loaderLookup());
     }
 
+    // ── Devices ───────────────────────────────────────────────────────────────
+
+    /**
+     * A ggml compute device: the registry that owns it ({@code CPU}, {@code CUDA},
+     * {@code ROCm}, {@code Metal}, {@code Vulkan}, ...), its device name ({@code CUDA0})
+     * and its {@code ggml_backend_dev_type} (0 CPU, 1 GPU, 2 accelerator, 3 integrated GPU).
+     */
+    public record BackendDevice(MemorySegment handle, String registry, String name, int type) {
+        public boolean isGpu() { return type == 1 || type == 3; }
+    }
+
+    /** Devices registered by the loaded ggml backends; empty if the library cannot list them. */
+    public List<BackendDevice> backendDevices() {
+        if (h.backendDevCount == null || h.backendDevGet == null || h.backendDevBackendReg == null
+                || h.backendRegName == null) {
+            return List.of();
+        }
+        try {
+            long count = (long) h.backendDevCount.invoke();
+            List<BackendDevice> devices = new ArrayList<>();
+            for (long i = 0; i < count; i++) {
+                MemorySegment dev = (MemorySegment) h.backendDevGet.invoke(i);
+                MemorySegment reg = (MemorySegment) h.backendDevBackendReg.invoke(dev);
+                String registry = cString((MemorySegment) h.backendRegName.invoke(reg));
+                String name = h.backendDevName == null ? registry
+                        : cString((MemorySegment) h.backendDevName.invoke(dev));
+                int type = h.backendDevType == null ? -1 : (int) h.backendDevType.invoke(dev);
+                devices.add(new BackendDevice(dev, registry, name, type));
+            }
+            return List.copyOf(devices);
+        } catch (Throwable e) {
+            log.debugf("Failed to enumerate ggml devices: %s", e.getMessage());
+            return List.of();
+        }
+    }
+
+    /** Free memory on {@code device} in bytes, or -1 if the library cannot tell. */
+    public long deviceFreeMemory(BackendDevice device) {
+        if (h.backendDevMemory == null) {
+            return -1;
+        }
+        try (Arena local = Arena.ofConfined()) {
+            MemorySegment free = local.allocate(ValueLayout.JAVA_LONG);
+            MemorySegment total = local.allocate(ValueLayout.JAVA_LONG);
+            h.backendDevMemory.invoke(device.handle(), free, total);
+            return free.get(ValueLayout.JAVA_LONG, 0);
+        } catch (Throwable e) {
+            log.debugf("Failed to query memory of %s: %s", device.name(), e.getMessage());
+            return -1;
+        }
+    }
+
+    /** Number of registered ggml backends, or -1 if the library cannot tell. */
+    public long backendRegistryCount() {
+        if (h.backendRegCount == null) {
+            return -1;
+        }
+        try {
+            return (long) h.backendRegCount.invoke();
+        } catch (Throwable e) {
+            log.debugf("Failed to count ggml backends: %s", e.getMessage());
+            return -1;
+        }
+    }
+
+    /** Names of the registered ggml backends ({@code CPU}, {@code CUDA}, {@code BLAS}, ...); empty if unknown. */
+    public List<String> backendRegistries() {
+        if (h.backendRegCount == null || h.backendRegGet == null || h.backendRegName == null) {
+            return List.of();
+        }
+        try {
+            long count = (long) h.backendRegCount.invoke();
+            List<String> names = new ArrayList<>();
+            for (long i = 0; i < count; i++) {
+                names.add(cString((MemorySegment) h.backendRegName.invoke((MemorySegment) h.backendRegGet.invoke(i))));
+            }
+            return List.copyOf(names);
+        } catch (Throwable e) {
+            log.debugf("Failed to list ggml backends: %s", e.getMessage());
+            return List.of();
+        }
+    }
+
+    /** {@code ggml_version}, e.g. {@code 0.9.4}; empty for libraries built before ggml exported it. */
+    public String ggmlVersion() {
+        return constString(h.ggmlVersion);
+    }
+
+    /** {@code ggml_commit}: the short commit the library was built from; empty if unavailable. */
+    public String ggmlCommit() {
+        return constString(h.ggmlCommit);
+    }
+
+    /** Whether the gollek C shim was found next to the library. */
+    public boolean hasShim() {
+        return h.gollekModelDefaultParamsInto != null || h.gollekLogDisable != null;
+    }
+
+    private String constString(java.lang.invoke.MethodHandle handle) {
+        if (handle == null) {
+            return "";
+        }
+        try {
+            return cString((MemorySegment) handle.invoke());
+        } catch (Throwable e) {
+            log.debugf("Failed to read llama.cpp build string: %s", e.getMessage());
+            return "";
+        }
+    }
+
+    /**
+     * Loads and registers a ggml backend module ({@code ggml_backend_load}), for libraries
+     * built with {@code GGML_BACKEND_DL}. Returns false if the module could not be loaded.
+     */
+    public boolean loadBackend(Path module) {
+        if (h.backendLoad == null) {
+            return false;
+        }
+        try (Arena call = Arena.ofConfined()) {
+            MemorySegment reg = (MemorySegment) h.backendLoad.invoke(
+                    call.allocateFrom(module.toAbsolutePath().toString()));
+            return !reg.equals(MemorySegment.NULL);
+        } catch (Throwable e) {
+            log.debugf("Failed to load ggml backend %s: %s", module, e.getMessage());
+            return false;
+        }
+    }
+
+    /**
+     * Restricts a model to {@code devices} by setting the NULL-terminated
+     * {@code llama_model_params.devices} list; an empty list keeps llama.cpp's default of
+     * every GPU device.
+     */
+    public void setModelDevices(MemorySegment params, List<BackendDevice> devices) {
+        if (devices.isEmpty()) {
+            setModelParam(params, "devices", MemorySegment.NULL);
+            return;
+        }
+        MemorySegment list = arena.allocate(ValueLayout.ADDRESS, devices.size() + 1L);
+        for (int i = 0; i < devices.size(); i++) {
+            list.setAtIndex(ValueLayout.ADDRESS, i, devices.get(i).handle());
+        }
+        list.setAtIndex(ValueLayout.ADDRESS, devices.size(), MemorySegment.NULL);
+        setModelParam(params, "devices", list);
+    }
+
+    /**
+     * {@code llama_print_system_info}: the features each loaded backend was compiled with,
+     * e.g. {@code CPU : SSE3 = 1 | AVX = 1 | AVX2 = 1 | ...}; empty if unavailable.
+     */
+    public String systemInfo() {
+        if (h.printSystemInfo == null) {
+            return "";
+        }
+        try {
+            return cString((MemorySegment) h.printSystemInfo.invoke());
+        } catch (Throwable e) {
+            log.debugf("Failed to read llama.cpp system info: %s", e.getMessage());
+            return "";
+        }
+    }
+
+    private static String cString(MemorySegment ptr) {
+        return ptr == null || ptr.equals(MemorySegment.NULL) ? "" : ptr.reinterpret(Long.MAX_VALUE).getString(0L);
+    }
+
     // ── Default params ────────────────────────────────────────────────────────
 
     /**
@@ -202,9 +379,59 @@ public class LlamaCppBinding {
     }
 
     public void freeContext(MemorySegment context) {
+        clearAbort(context);
         try { h.freeContext.invoke(context); } catch (Throwable e) { log.error("Failed to free context", e); }
     }
 
+    // ── Abort ────────────────────────────────────────────────────────────────
+
+    // contexts whose running decode should give up; polled by llama.cpp between graph nodes
+    private static final Set<Long> ABORTING = ConcurrentHashMap.newKeySet();
+    private static MemorySegment abortStub;
+
+    public boolean supportsAbort() {
+        return h.setAbortCallback != null;
+    }
+
+    /**
+     * Lets {@link #abort} interrupt decodes on {@code context}. Returns false when the
+     * library lacks {@code llama_set_abort_callback}.
+     */
+    public boolean installAbortCallback(MemorySegment context) {
+        if (h.setAbortCallback == null) return false;
+        try {
+            h.setAbortCallback.invoke(context, abortStub(), context);
+            return true;
+        } catch (Throwable e) { throw new RuntimeException("Failed to install abort callback", e); }
+    }
+
+    /**
+     * Makes the decode running on {@code context}, and every later one, fail until
+     * {@link #clearAbort}; a no-op without {@link #installAbortCallback}.
+     */
+    public void abort(MemorySegment context) {
+        ABORTING.add(context.address());
+    }
+
+    public void clearAbort(MemorySegment context) {
+        ABORTING.remove(context.address());
+    }
+
+    private static synchronized MemorySegment abortStub() throws ReflectiveOperationException {
+        if (abortStub == null) {
+            var callback = MethodHandles.lookup().findStatic(LlamaCppBinding.class, "abortRequested",
+                    MethodType.methodType(boolean.class, MemorySegment.class));
+            abortStub = Linker.nativeLinker().upcallStub(callback,
+                    FunctionDescriptor.of(ValueLayout.JAVA_BOOLEAN, ValueLayout.ADDRESS), Arena.global());
+        }
+        return abortStub;
+    }
+
+    @SuppressWarnings("unused")
+    private static boolean abortRequested(MemorySegment context) {
+        return ABORTING.contains(context.address());
+    }
+
     // ── Vocab / metadata ─────────────────────────────────────────────────────
 
     public MemorySegment getVocab(MemorySegment model) {
@@ -308,6 +535,33 @@ public class LlamaCppBinding {
         }
     }
 
+    /**
+     * Text of a token sequence ({@code llama_detokenize}). Decoding the sequence at once keeps
+     * characters that span several tokens intact; special tokens are rendered as text.
+     */
+    public String detokenize(MemorySegment model, int[] tokens) {
+        if (tokens.length == 0) return "";
+        try (Arena local = Arena.ofConfined()) {
+            MemorySegment vocab = getVocab(model);
+            MemorySegment tokenSeg = local.allocateFrom(ValueLayout.JAVA_INT, tokens);
+            int capacity = Math.max(256, tokens.length * 8);
+            MemorySegment buf = local.allocate(ValueLayout.JAVA_BYTE, capacity);
+            int length = (int) h.detokenize.invoke(vocab, tokenSeg, tokens.length, buf, capacity, false, true);
+            if (length < 0) {
+                capacity = -length;
+                buf = local.allocate(ValueLayout.JAVA_BYTE, capacity);
+                length = (int) h.detokenize.invoke(vocab, tokenSeg, tokens.length, buf, capacity, false, true);
+            }
+            if (length <= 0) return "";
+            byte[] bytes = new byte[length];
+            MemorySegment.copy(buf, 0, MemorySegment.ofArray(bytes), 0, length);
+            String decoded = decodeUtf8Safe(bytes);
+            return decoded != null ? decoded : "";
+        } catch (Throwable e) {
+            throw new RuntimeException("Failed to detokenize: " + e.getMessage(), e);
+        }
+    }
+
     /**
      * Safely decode UTF-8 bytes, replacing invalid sequences with replacement character.
      * This prevents garbled output from corrupted token streams.
@@ -392,6 +646,45 @@ public class LlamaCppBinding {
         } catch (Throwable e) { throw new RuntimeException("Failed to clear KV cache", e); }
     }
 
+    /**
+     * Removes positions {@code [p0, p1)} of a sequence from the KV cache ({@code p1 < 0} means
+     * to the end). Returns false when the library lacks the call or the cache cannot drop a
+     * partial range (e.g. recurrent models); callers then fall back to a full clear.
+     */
+    public boolean memorySeqRm(MemorySegment context, int seqId, int p0, int p1) {
+        if (h.memorySeqRm == null) return false;
+        try {
+            MemorySegment memory = (MemorySegment) h.getMemory.invoke(context);
+            return (boolean) h.memorySeqRm.invoke(memory, seqId, p0, p1);
+        } catch (Throwable e) { throw new RuntimeException("Failed to trim KV cache", e); }
+    }
+
+    /**
+     * Whether the KV cache can drop a middle range and move later positions down, which a
+     * context shift needs; false for recurrent models and libraries without the calls.
+     */
+    public boolean canShiftMemory(MemorySegment context) {
+        if (h.memorySeqRm == null || h.memorySeqAdd == null) return false;
+        if (h.memoryCanShift == null) return true;
+        try {
+            MemorySegment memory = (MemorySegment) h.getMemory.invoke(context);
+            return (boolean) h.memoryCanShift.invoke(memory);
+        } catch (Throwable e) { throw new RuntimeException("Failed to query KV cache shifting", e); }
+    }
+
+    /**
+     * Adds {@code delta} to positions {@code [p0, p1)} of a sequence in the KV cache
+     * ({@code p1 < 0} means to the end). Returns false when the library lacks the call.
+     */
+    public boolean memorySeqAdd(MemorySegment context, int seqId, int p0, int p1, int delta) {
+        if (h.memorySeqAdd == null) return false;
+        try {
+            MemorySegment memory = (MemorySegment) h.getMemory.invoke(context);
+            h.memorySeqAdd.invoke(memory, seqId, p0, p1, delta);
+            return true;
+        } catch (Throwable e) { throw new RuntimeException("Failed to shift KV cache", e); }
+    }
+
     public boolean saveSession(MemorySegment context, Path sessionPath, int[] tokens, int count) {
         if (sessionPath == null || tokens == null || count <= 0) return false;
         try (Arena local = Arena.ofConfined()) {
@@ -418,6 +711,102 @@ public class LlamaCppBinding {
         } catch (Throwable e) { throw new RuntimeException("Failed to load session: " + sessionPath, e); }
     }
 
+    // ── State snapshots ───────────────────────────────────────────────────────
+
+    /** Whether the loaded library exports the in-memory and per-sequence state API. */
+    public boolean supportsStateSnapshots() {
+        return h.stateGetData != null && h.stateSetData != null && h.stateSeqSaveFile != null
+                && h.stateSeqLoadFile != null;
+    }
+
+    /** Bytes needed for a full snapshot of the context (KV cache, logits, RNG). */
+    public long stateSize(MemorySegment context) {
+        h.require(h.stateGetSize, "llama_state_get_size");
+        try { return (long) h.stateGetSize.invoke(context); }
+        catch (Throwable e) { throw new RuntimeException("Failed to size context state", e); }
+    }
+
+    /** A full snapshot of the context, restorable with {@link #stateSet}. */
+    public byte[] stateGet(MemorySegment context) {
+        h.require(h.stateGetData, "llama_state_get_data");
+        try (Arena local = Arena.ofConfined()) {
+            long size = stateSize(context);
+            MemorySegment dst = local.allocate(size);
+            long written = (long) h.stateGetData.invoke(context, dst, size);
+            return dst.asSlice(0, written).toArray(ValueLayout.JAVA_BYTE);
+        } catch (RuntimeException e) { throw e; }
+        catch (Throwable e) { throw new RuntimeException("Failed to read context state", e); }
+    }
+
+    /** Restores a snapshot from {@link #stateGet}; false if llama.cpp rejected it. */
+    public boolean stateSet(MemorySegment context, byte[] state) {
+        h.require(h.stateSetData, "llama_state_set_data");
+        if (state == null || state.length == 0) return false;
+        try (Arena local = Arena.ofConfined()) {
+            MemorySegment src = local.allocateFrom(ValueLayout.JAVA_BYTE, state);
+            return (long) h.stateSetData.invoke(context, src, (long) state.length) > 0;
+        } catch (Throwable e) { throw new RuntimeException("Failed to restore context state", e); }
+    }
+
+    /** Bytes needed for a snapshot of one sequence's KV cells. */
+    public long seqStateSize(MemorySegment context, int seqId) {
+        h.require(h.stateSeqGetSize, "llama_state_seq_get_size");
+        try { return (long) h.stateSeqGetSize.invoke(context, seqId); }
+        catch (Throwable e) { throw new RuntimeException("Failed to size sequence state", e); }
+    }
+
+    /** A snapshot of sequence {@code seqId}, restorable into any sequence with {@link #seqStateSet}. */
+    public byte[] seqStateGet(MemorySegment context, int seqId) {
+        h.require(h.stateSeqGetData, "llama_state_seq_get_data");
+        try (Arena local = Arena.ofConfined()) {
+            long size = seqStateSize(context, seqId);
+            MemorySegment dst = local.allocate(Math.max(1, size));
+            long written = (long) h.stateSeqGetData.invoke(context, dst, size, seqId);
+            return dst.asSlice(0, written).toArray(ValueLayout.JAVA_BYTE);
+        } catch (RuntimeException e) { throw e; }
+        catch (Throwable e) { throw new RuntimeException("Failed to read sequence state", e); }
+    }
+
+    /** Loads a {@link #seqStateGet} snapshot into sequence {@code seqId}; false if rejected. */
+    public boolean seqStateSet(MemorySegment context, int seqId, byte[] state) {
+        h.require(h.stateSeqSetData, "llama_state_seq_set_data");
+        if (state == null || state.length == 0) return false;
+        try (Arena local = Arena.ofConfined()) {
+            MemorySegment src = local.allocateFrom(ValueLayout.JAVA_BYTE, state);
+            return (long) h.stateSeqSetData.invoke(context, src, (long) state.length, seqId) > 0;
+        } catch (Throwable e) { throw new RuntimeException("Failed to restore sequence state", e); }
+    }
+
+    /** Writes sequence {@code seqId}'s KV cells and the tokens they hold to {@code path}. */
+    public boolean saveSeqState(MemorySegment context, int seqId, Path path, int[] tokens, int count) {
+        h.require(h.stateSeqSaveFile, "llama_state_seq_save_file");
+        if (path == null || tokens == null || count <= 0) return false;
+        try (Arena local = Arena.ofConfined()) {
+            MemorySegment file = local.allocateFrom(path.toString());
+            MemorySegment tokenSeg = local.allocate(ValueLayout.JAVA_INT, count);
+            MemorySegment.copy(tokens, 0, tokenSeg, ValueLayout.JAVA_INT, 0, count);
+            return (long) h.stateSeqSaveFile.invoke(context, file, seqId, tokenSeg, (long) count) > 0;
+        } catch (Throwable e) { throw new RuntimeException("Failed to save sequence state: " + path, e); }
+    }
+
+    /**
+     * Reads a {@link #saveSeqState} file into sequence {@code seqId} and returns the tokens
+     * it holds, or an empty array if the file does not fit this context or model.
+     */
+    public int[] loadSeqState(MemorySegment context, int seqId, Path path, int maxTokens) {
+        h.require(h.stateSeqLoadFile, "llama_state_seq_load_file");
+        if (path == null || maxTokens <= 0) return new int[0];
+        try (Arena local = Arena.ofConfined()) {
+            MemorySegment file = local.allocateFrom(path.toString());
+            MemorySegment tokenSeg = local.allocate(ValueLayout.JAVA_INT, maxTokens);
+            MemorySegment countOut = local.allocate(ValueLayout.JAVA_LONG, 1);
+            long read = (long) h.stateSeqLoadFile.invoke(context, file, seqId, tokenSeg, (long) maxTokens, countOut);
+            if (read == 0) return new int[0];
+            int size = (int) Math.min(countOut.get(ValueLayout.JAVA_LONG, 0), maxTokens);
+            return tokenSeg.asSlice(0, (long) size * Integer.BYTES).toArray(ValueLayout.JAVA_INT);
+        } catch (Throwable e) { throw new RuntimeException("Failed to load sequence state: " + path, e); }
+    }
+
     // ── Batch (delegated to LlamaBatchOps) ───────────────────────────────────
 
     public MemorySegment batchInit(int nTokens, int embd, int nSeqMax) { return batch.init(nTokens, embd, nSeqMax); }
@@ -446,6 +835,7 @@ public class LlamaCppBinding {
     public void addMirostatV2Sampler(MemorySegment chain, int seed, float tau, float eta) { sampler.addMirostatV2(chain, seed, tau, eta); }
     public void addGrammarSampler(MemorySegment chain, MemorySegment model, String grammarStr, String grammarRoot) { sampler.addGrammar(chain, getVocab(model), grammarStr, grammarRoot); }
     public void addTypicalSampler(MemorySegment chain, float p, long minKeep) { sampler.addTypical(chain, p, minKeep); }
+    public void addLogitBiasSampler(MemorySegment chain, int nVocab, Map<Integer, Float> biases) { sampler.addLogitBias(chain, nVocab, biases); }
     public int sample(MemorySegment chain, MemorySegment context, int index) { return sampler.sample(chain, context, index); }
     public void freeSampler(MemorySegment chain) { sampler.freeChain(chain); }
 
@@ -454,6 +844,9 @@ public class LlamaCppBinding {
     public int nEmbd(MemorySegment model) throws Throwable { return (int) h.nEmbd.invoke(model); }
     public MemorySegment getEmbeddings(MemorySegment ctx) throws Throwable { return (MemorySegment) h.getEmbeddings.invoke(ctx); }
     public MemorySegment getEmbeddingsIth(MemorySegment ctx, int i) throws Throwable { return (MemorySegment) h.getEmbeddingsIth.invoke(ctx, i); }
+    public MemorySegment getEmbeddingsSeq(MemorySegment ctx, int seqId) throws Throwable { h.require(h.getEmbeddingsSeq, "llama_get_embeddings_seq"); return (MemorySegment) h.getEmbeddingsSeq.invoke(ctx, seqId); }
+    /** Pooling type the context was created with (llama_pooling_type); -1 when the library does not expose it. */
+    public int poolingType(MemorySegment ctx) throws Throwable { return h.poolingType == null ? -1 : (int) h.poolingType.invoke(ctx); }
 
     // ── LoRA adapters ─────────────────────────────────────────────────────────
 
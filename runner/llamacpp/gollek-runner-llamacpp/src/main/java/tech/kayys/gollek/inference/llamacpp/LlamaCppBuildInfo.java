@@ -0,0 +1,101 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.jboss.logging.Logger;
+
+import java.lang.foreign.Arena;
+import java.lang.foreign.FunctionDescriptor;
+import java.lang.foreign.Linker;
+import java.lang.foreign.MemorySegment;
+import java.lang.foreign.SymbolLookup;
+import java.lang.foreign.ValueLayout;
+import java.lang.invoke.MethodHandle;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+
+/**
+ * What the loaded llama.cpp library is, for {@code GET /v1/system} and bug reports: the
+ * ggml version and commit, the backends compiled in, the devices they found, the
+ * {@code llama_print_system_info} feature line and the GPU runtime versions on the host.
+ * Collected once at provider initialization; anything the library does not export is
+ * left out rather than guessed.
+ */
+final class LlamaCppBuildInfo {
+
+    private static final Logger log = Logger.getLogger(LlamaCppBuildInfo.class);
+
+    private LlamaCppBuildInfo() {
+    }
+
+    static Map<String, Object> describe(LlamaCppBinding binding) {
+        Map<String, Object> info = new LinkedHashMap<>();
+        if (binding == null) {
+            return info;
+        }
+        putIfPresent(info, "ggml_version", binding.ggmlVersion());
+        putIfPresent(info, "ggml_commit", binding.ggmlCommit());
+        List<String> registries = binding.backendRegistries();
+        info.put("ggml_backends", registries);
+        info.put("devices", binding.backendDevices().stream()
+                .map(d -> Map.<String, Object>of("backend", d.registry(), "name", d.name(), "gpu", d.isGpu()))
+                .toList());
+        putIfPresent(info, "system_info", binding.systemInfo().strip());
+        Map<String, Object> bindingInfo = new LinkedHashMap<>();
+        bindingInfo.put("kind", "ffm");
+        bindingInfo.put("java", Runtime.version().toString());
+        bindingInfo.put("shim", binding.hasShim());
+        LlamaNativeLoader.libraryDir().ifPresent(dir -> bindingInfo.put("library_dir", dir.toString()));
+        info.put("binding", bindingInfo);
+        if (compiledIn(registries, LlamaCppDeviceSupport.Backend.CUDA)) {
+            cudaDriverVersion().ifPresent(v -> info.put("cuda_driver", v));
+        }
+        if (compiledIn(registries, LlamaCppDeviceSupport.Backend.METAL)) {
+            // Metal ships with the OS, so its version is the macOS one
+            info.put("metal", "macOS " + System.getProperty("os.version", ""));
+        }
+        return info;
+    }
+
+    /**
+     * {@code cuDriverGetVersion} from the driver library, formatted {@code major.minor}; the
+     * runtime llama.cpp was built against is in {@code system_info}.
+     */
+    static Optional<String> cudaDriverVersion() {
+        String library = System.getProperty("os.name", "").toLowerCase(Locale.ROOT).contains("win")
+                ? "nvcuda.dll" : "libcuda.so.1";
+        try (Arena arena = Arena.ofConfined()) {
+            SymbolLookup lookup = SymbolLookup.libraryLookup(library, arena);
+            Optional<MemorySegment> symbol = lookup.find("cuDriverGetVersion");
+            if (symbol.isEmpty()) {
+                return Optional.empty();
+            }
+            MethodHandle handle = Linker.nativeLinker().downcallHandle(symbol.get(),
+                    FunctionDescriptor.of(ValueLayout.JAVA_INT, ValueLayout.ADDRESS));
+            MemorySegment out = arena.allocate(ValueLayout.JAVA_INT);
+            if ((int) handle.invoke(out) != 0) {
+                return Optional.empty();
+            }
+            return Optional.of(formatCudaVersion(out.get(ValueLayout.JAVA_INT, 0)));
+        } catch (Throwable e) {
+            log.debugf("CUDA driver version unavailable: %s", e.getMessage());
+            return Optional.empty();
+        }
+    }
+
+    /** CUDA packs versions as {@code 1000 * major + 10 * minor}: 12040 is 12.4. */
+    static String formatCudaVersion(int version) {
+        return (version / 1000) + "." + (version % 1000) / 10;
+    }
+
+    private static void putIfPresent(Map<String, Object> info, String key, String value) {
+        if (value != null && !value.isBlank()) {
+            info.put(key, value);
+        }
+    }
+
+    private static boolean compiledIn(List<String> registries, LlamaCppDeviceSupport.Backend backend) {
+        return registries.stream().anyMatch(r -> LlamaCppDeviceSupport.Backend.ofRegistry(r) == backend);
+    }
+}
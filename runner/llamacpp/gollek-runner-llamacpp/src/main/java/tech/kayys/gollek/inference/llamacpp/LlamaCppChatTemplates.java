@@ -0,0 +1,102 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import java.util.LinkedHashMap;
+import java.util.Map;
+
+/**
+ * Which chat template a model renders chat requests with. By default that is the Jinja
+ * template embedded in the GGUF ({@code tokenizer.chat_template}). A per-model override,
+ * {@code gguf.provider.chat-template."<model-id>"}, replaces it with a named template or
+ * with a template given inline. Models with neither fall back to ChatML.
+ */
+final class LlamaCppChatTemplates {
+
+    static final String CHATML = "{% for m in messages %}<|im_start|>{{ m.role }}\n{{ m.content }}<|im_end|>\n"
+            + "{% endfor %}{% if add_generation_prompt %}<|im_start|>assistant\n{% endif %}";
+
+    /** Named templates for the common families; the names are also what detection reports. */
+    static final Map<String, String> NAMED = Map.of(
+            "chatml", CHATML,
+            "llama3", "{% for m in messages %}<|start_header_id|>{{ m.role }}<|end_header_id|>\n\n"
+                    + "{{ m.content | trim }}<|eot_id|>{% endfor %}{% if add_generation_prompt %}"
+                    + "<|start_header_id|>assistant<|end_header_id|>\n\n{% endif %}",
+            "mistral", "{% for m in messages %}{% if m.role == 'assistant' %}{{ m.content }}</s>"
+                    + "{% else %}[INST] {{ m.content }} [/INST]{% endif %}{% endfor %}",
+            "gemma", "{% for m in messages %}<start_of_turn>{% if m.role == 'assistant' %}model{% else %}user"
+                    + "{% endif %}\n{{ m.content | trim }}<end_of_turn>\n{% endfor %}"
+                    + "{% if add_generation_prompt %}<start_of_turn>model\n{% endif %}",
+            "phi3", "{% for m in messages %}<|{{ m.role }}|>\n{{ m.content }}<|end|>\n{% endfor %}"
+                    + "{% if add_generation_prompt %}<|assistant|>\n{% endif %}",
+            "zephyr", "{% for m in messages %}<|{{ m.role }}|>\n{{ m.content }}</s>\n{% endfor %}"
+                    + "{% if add_generation_prompt %}<|assistant|>\n{% endif %}");
+
+    /**
+     * The template in effect: {@code source} is {@code embedded}, {@code named},
+     * {@code custom} or {@code fallback}; {@code name} is the template family when known.
+     */
+    record Active(String template, String source, String name) {
+
+        Map<String, Object> describe() {
+            Map<String, Object> out = new LinkedHashMap<>();
+            out.put("source", source);
+            if (name != null) {
+                out.put("name", name);
+            }
+            return out;
+        }
+    }
+
+    private LlamaCppChatTemplates() {
+    }
+
+    /**
+     * Resolves the template for {@code modelId}.
+     *
+     * @throws IllegalArgumentException if the override names an unknown template
+     */
+    static Active resolve(LlamaCppProviderConfig config, String modelId, String embedded) {
+        Map<String, String> overrides = config.chatTemplates();
+        String override = modelId == null || overrides == null ? null : overrides.get(modelId);
+        if (override != null && !override.isBlank()) {
+            if (isInline(override)) {
+                return new Active(override, "custom", detect(override));
+            }
+            String name = override.strip().toLowerCase();
+            String template = NAMED.get(name);
+            if (template == null) {
+                throw new IllegalArgumentException("Unknown chat template for " + modelId + ": " + override
+                        + " (expected one of " + NAMED.keySet() + " or a Jinja template)");
+            }
+            return new Active(template, "named", name);
+        }
+        if (embedded != null && !embedded.isBlank()) {
+            return new Active(embedded, "embedded", detect(embedded));
+        }
+        return new Active(CHATML, "fallback", "chatml");
+    }
+
+    /** A value with Jinja markup is a template; anything else is a name. */
+    static boolean isInline(String value) {
+        return value.contains("{{") || value.contains("{%");
+    }
+
+    /** The template family from its turn markers, or null for one this does not know. */
+    static String detect(String template) {
+        if (template.contains("<|im_start|>")) {
+            return "chatml";
+        }
+        if (template.contains("<|start_header_id|>")) {
+            return "llama3";
+        }
+        if (template.contains("<start_of_turn>")) {
+            return "gemma";
+        }
+        if (template.contains("<|assistant|>")) {
+            return template.contains("<|end|>") ? "phi3" : "zephyr";
+        }
+        if (template.contains("[INST]")) {
+            return "mistral";
+        }
+        return null;
+    }
+}
@@ -0,0 +1,87 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+
+/**
+ * What to do when a prompt, or a prompt plus its generation, does not fit the context
+ * window. Chosen per request with {@value #PARAMETER}; the default comes from
+ * {@code context.overflow}, or from {@code context.truncate-prompt} when that is unset.
+ *
+ * <ul>
+ * <li>{@code error}: reject an oversized prompt and clamp {@code max_tokens} to the space left.</li>
+ * <li>{@code truncate}: keep the tail of an oversized prompt and clamp {@code max_tokens}.</li>
+ * <li>{@code shift}: keep the first {@value #KEEP} tokens (by default the system prompt) and
+ * drop the oldest tokens after them, both from an oversized prompt and, whenever generation
+ * reaches the end of the window, from the KV cache, so generation can run past it.</li>
+ * </ul>
+ */
+enum LlamaCppContextOverflow {
+    ERROR, TRUNCATE, SHIFT;
+
+    /** Request parameter selecting the strategy. */
+    static final String PARAMETER = "context_overflow";
+    /** Request parameter: leading prompt tokens that {@code shift} never drops. */
+    static final String KEEP = "n_keep";
+
+    String id() {
+        return name().toLowerCase(Locale.ROOT);
+    }
+
+    /**
+     * @throws IllegalArgumentException if {@code value} names no strategy
+     */
+    static LlamaCppContextOverflow parse(String value) {
+        for (LlamaCppContextOverflow strategy : values()) {
+            if (strategy.id().equalsIgnoreCase(value.strip())) {
+                return strategy;
+            }
+        }
+        throw new IllegalArgumentException(PARAMETER + " must be one of error, truncate or shift: " + value);
+    }
+
+    /** The request's strategy, or the configured default. */
+    static LlamaCppContextOverflow forRequest(Map<String, Object> parameters, LlamaCppProviderConfig config) {
+        Object requested = parameters.get(PARAMETER);
+        if (requested != null && !String.valueOf(requested).isBlank()) {
+            return parse(String.valueOf(requested));
+        }
+        return config.contextOverflow().filter(v -> !v.isBlank()).map(LlamaCppContextOverflow::parse)
+                .orElse(config.contextTruncatePrompt() ? TRUNCATE : ERROR);
+    }
+
+    /**
+     * Fits prompt tokens into {@code limit}. {@code keep} leading tokens survive a shift,
+     * capped at half the window so there is always room for recent text.
+     *
+     * @throws IllegalArgumentException for {@code error} when the prompt does not fit
+     */
+    int[] fit(int[] tokens, int limit, int keep, List<String> warnings) {
+        if (tokens.length <= limit) {
+            return tokens;
+        }
+        if (this == ERROR) {
+            throw new IllegalArgumentException("Prompt is " + tokens.length
+                    + " tokens, which exceeds the context window of " + limit + " tokens");
+        }
+        int head = this == SHIFT ? Math.max(0, Math.min(keep, limit / 2)) : 0;
+        int[] fitted = new int[limit];
+        System.arraycopy(tokens, 0, fitted, 0, head);
+        System.arraycopy(tokens, tokens.length - (limit - head), fitted, head, limit - head);
+        int dropped = tokens.length - limit;
+        warnings.add(head > 0
+                ? "prompt shifted: " + dropped + " tokens after the first " + head + " dropped"
+                : "prompt truncated by " + dropped + " tokens");
+        return fitted;
+    }
+
+    /**
+     * Tokens to drop after the first {@code keep} when {@code past} positions fill the window:
+     * half of the droppable ones, as llama.cpp's own context shift does. Zero when nothing
+     * can be dropped.
+     */
+    static int discard(int past, int keep) {
+        return Math.max(0, (past - keep) / 2);
+    }
+}
@@ -0,0 +1,268 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.lang.foreign.Arena;
+import java.lang.foreign.FunctionDescriptor;
+import java.lang.foreign.Linker;
+import java.lang.foreign.SymbolLookup;
+import java.lang.foreign.ValueLayout;
+import java.lang.invoke.MethodHandle;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.Arrays;
+import java.util.HashSet;
+import java.util.LinkedHashMap;
+import java.util.LinkedHashSet;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+import java.util.concurrent.TimeUnit;
+
+/**
+ * Startup check that the CPU backend of the loaded llama.cpp library only uses instructions
+ * this host has. A library built with {@code GGML_NATIVE} on a newer machine (AVX512 on an
+ * AVX2 server, SVE on a NEON-only ARM box) loads fine and then kills the JVM with SIGILL in
+ * the first matmul; comparing {@code llama_print_system_info} with the host's CPU flags turns
+ * that into an error at provider initialization.
+ *
+ * <p>Builds with {@code GGML_BACKEND_DL} and {@code GGML_CPU_ALL_VARIANTS} pick a CPU variant
+ * at load time and report that variant's features, so they pass on any host.
+ */
+final class LlamaCppCpuFeatures {
+
+    private static final Logger log = Logger.getLogger(LlamaCppCpuFeatures.class);
+
+    /**
+     * CPU features reported by {@code llama_print_system_info} that fault when missing,
+     * with the lower-cased names hosts use for them: Linux {@code /proc/cpuinfo} flags,
+     * macOS {@code machdep.cpu.*} words and {@code hw.optional.*} keys.
+     */
+    static final Map<String, List<String>> HOST_NAMES = new LinkedHashMap<>();
+
+    static {
+        HOST_NAMES.put("SSE3", List.of("pni", "sse3"));
+        HOST_NAMES.put("SSSE3", List.of("ssse3"));
+        HOST_NAMES.put("AVX", List.of("avx", "avx1.0"));
+        HOST_NAMES.put("AVX2", List.of("avx2"));
+        HOST_NAMES.put("AVX_VNNI", List.of("avx_vnni", "avxvnni"));
+        HOST_NAMES.put("F16C", List.of("f16c"));
+        HOST_NAMES.put("FMA", List.of("fma"));
+        HOST_NAMES.put("BMI2", List.of("bmi2"));
+        HOST_NAMES.put("AVX512", List.of("avx512f"));
+        HOST_NAMES.put("AVX512_VBMI", List.of("avx512vbmi", "avx512_vbmi"));
+        HOST_NAMES.put("AVX512_VNNI", List.of("avx512_vnni", "avx512vnni"));
+        HOST_NAMES.put("AVX512_BF16", List.of("avx512_bf16", "avx512bf16"));
+        HOST_NAMES.put("AMX_INT8", List.of("amx_int8", "amxint8"));
+        HOST_NAMES.put("NEON", List.of("asimd", "neon"));
+        HOST_NAMES.put("ARM_FMA", List.of("asimd", "neon"));
+        HOST_NAMES.put("FP16_VA", List.of("asimdhp", "feat_fp16"));
+        HOST_NAMES.put("DOTPROD", List.of("asimddp", "feat_dotprod"));
+        HOST_NAMES.put("MATMUL_INT8", List.of("i8mm", "feat_i8mm"));
+        HOST_NAMES.put("SVE", List.of("sve"));
+        HOST_NAMES.put("SME", List.of("sme", "feat_sme"));
+    }
+
+    /** Windows {@code IsProcessorFeaturePresent} constants for the features it can answer. */
+    private static final Map<String, Integer> WINDOWS_FEATURES = Map.of(
+            "SSE3", 13,
+            "SSSE3", 36,
+            "AVX", 39,
+            "AVX2", 40,
+            "AVX512", 41,
+            "NEON", 19,
+            "DOTPROD", 43);
+
+    /**
+     * CPU features of this host: {@code supported} among the {@code checked} ones. Features
+     * outside {@code checked} (e.g. FMA on Windows) cannot be verified and are assumed present.
+     */
+    record Host(String source, Set<String> supported, Set<String> checked) {
+
+        /** Host described by a set of lower-cased CPU flags that lists every feature it has. */
+        static Host ofFlags(String source, Set<String> flags) {
+            Set<String> supported = new LinkedHashSet<>();
+            HOST_NAMES.forEach((feature, names) -> {
+                if (names.stream().anyMatch(flags::contains)) {
+                    supported.add(feature);
+                }
+            });
+            return new Host(source, supported, HOST_NAMES.keySet());
+        }
+    }
+
+    private LlamaCppCpuFeatures() {
+    }
+
+    /**
+     * Compares the library's CPU features with the host's. On a mismatch this logs an error
+     * and throws {@link IllegalStateException} when {@code enforce} is set, otherwise it only
+     * warns. Does nothing if either side cannot be determined.
+     */
+    static void verify(LlamaCppBinding binding, boolean enforce) {
+        Set<String> built = libraryFeatures(binding.systemInfo());
+        if (built.isEmpty()) {
+            log.debug("llama.cpp did not report CPU features; skipping CPU compatibility check");
+            return;
+        }
+        Optional<Host> host = detectHost();
+        if (host.isEmpty()) {
+            log.debugf("Could not read host CPU features; llama.cpp CPU backend built for %s", built);
+            return;
+        }
+        log.infof("llama.cpp CPU backend built for %s; host supports %s (%s)",
+                built, host.get().supported(), host.get().source());
+        List<String> missing = missing(built, host.get());
+        if (missing.isEmpty()) {
+            return;
+        }
+        String message = mismatchMessage(missing);
+        if (!enforce) {
+            log.warn(message);
+            return;
+        }
+        log.error(message);
+        throw new IllegalStateException(message);
+    }
+
+    /**
+     * Features set to 1 in the CPU section of {@code llama_print_system_info}, e.g.
+     * {@code CPU : SSE3 = 1 | AVX = 1 | AVX2 = 1 | AVX512 = 0 | ... | Metal : ...}. Older
+     * builds print no section names; their whole string describes the CPU.
+     */
+    static Set<String> libraryFeatures(String systemInfo) {
+        Set<String> features = new LinkedHashSet<>();
+        if (systemInfo == null) {
+            return features;
+        }
+        String section = "CPU";
+        for (String part : systemInfo.split("\\|")) {
+            String item = part.trim();
+            int colon = item.indexOf(" : ");
+            if (colon >= 0) {
+                section = item.substring(0, colon).trim();
+                item = item.substring(colon + 3).trim();
+            }
+            int eq = item.indexOf('=');
+            if (eq < 0 || !section.equalsIgnoreCase("CPU")) {
+                continue;
+            }
+            String name = item.substring(0, eq).trim();
+            if (item.substring(eq + 1).trim().equals("1") && HOST_NAMES.containsKey(name)) {
+                features.add(name);
+            }
+        }
+        return features;
+    }
+
+    /** Library features the host was checked for and lacks, in report order. */
+    static List<String> missing(Set<String> built, Host host) {
+        return built.stream()
+                .filter(f -> host.checked().contains(f) && !host.supported().contains(f))
+                .toList();
+    }
+
+    static String mismatchMessage(List<String> missing) {
+        return "The llama.cpp library was compiled for CPU instructions this host does not support: "
+                + String.join(", ", missing) + ". Running a model would crash the JVM with SIGILL. "
+                + "Rebuild llama.cpp without them (-DGGML_NATIVE=OFF and -DGGML_<FEATURE>=OFF), or with "
+                + "-DGGML_BACKEND_DL=ON -DGGML_CPU_ALL_VARIANTS=ON so a compatible CPU variant is picked "
+                + "at runtime, or point gguf.provider.native.library-dir (GOLLEK_LLAMA_LIB_DIR) at a build "
+                + "for this CPU. Set gguf.provider.native.cpu-check=false to start anyway.";
+    }
+
+    /** Lower-cased flags of the first {@code flags} (x86) or {@code Features} (ARM) line. */
+    static Set<String> parseCpuinfo(String cpuinfo) {
+        for (String line : cpuinfo.split("\n")) {
+            int colon = line.indexOf(':');
+            if (colon < 0) {
+                continue;
+            }
+            String key = line.substring(0, colon).trim();
+            if (key.equals("flags") || key.equals("Features")) {
+                return words(line.substring(colon + 1));
+            }
+        }
+        return Set.of();
+    }
+
+    /**
+     * Flags from {@code sysctl machdep.cpu hw.optional} output: the words of the
+     * {@code machdep.cpu.*features} lines and the last segment of each enabled
+     * {@code hw.optional.*} key.
+     */
+    static Set<String> parseSysctl(String output) {
+        Set<String> flags = new HashSet<>();
+        for (String line : output.split("\n")) {
+            int colon = line.indexOf(':');
+            if (colon < 0) {
+                continue;
+            }
+            String key = line.substring(0, colon).trim();
+            String value = line.substring(colon + 1).trim();
+            if (key.startsWith("machdep.cpu.") && key.endsWith("features")) {
+                flags.addAll(words(value));
+            } else if (key.startsWith("hw.optional.") && value.equals("1")) {
+                flags.add(key.substring(key.lastIndexOf('.') + 1).toLowerCase(Locale.ROOT));
+            }
+        }
+        return flags;
+    }
+
+    private static Set<String> words(String value) {
+        Set<String> words = new HashSet<>();
+        Arrays.stream(value.trim().split("\\s+"))
+                .filter(w -> !w.isEmpty())
+                .map(w -> w.toLowerCase(Locale.ROOT))
+                .forEach(words::add);
+        return words;
+    }
+
+    static Optional<Host> detectHost() {
+        String os = System.getProperty("os.name", "").toLowerCase(Locale.ROOT);
+        try {
+            if (os.contains("linux")) {
+                Set<String> flags = parseCpuinfo(Files.readString(Path.of("/proc/cpuinfo")));
+                return flags.isEmpty() ? Optional.empty() : Optional.of(Host.ofFlags("/proc/cpuinfo", flags));
+            }
+            if (os.contains("mac")) {
+                Set<String> flags = parseSysctl(sysctl());
+                return flags.isEmpty() ? Optional.empty() : Optional.of(Host.ofFlags("sysctl", flags));
+            }
+            if (os.contains("windows")) {
+                return Optional.of(windowsHost());
+            }
+        } catch (Throwable e) {
+            log.debugf("Failed to detect host CPU features: %s", e.getMessage());
+        }
+        return Optional.empty();
+    }
+
+    private static String sysctl() throws IOException, InterruptedException {
+        // sysctl exits non-zero when one of the names is unknown but still prints the others
+        Process process = new ProcessBuilder("sysctl", "machdep.cpu", "hw.optional")
+                .redirectError(ProcessBuilder.Redirect.DISCARD)
+                .start();
+        String output = new String(process.getInputStream().readAllBytes(), StandardCharsets.UTF_8);
+        process.waitFor(5, TimeUnit.SECONDS);
+        return output;
+    }
+
+    private static Host windowsHost() throws Throwable {
+        MethodHandle isPresent = Linker.nativeLinker().downcallHandle(
+                SymbolLookup.libraryLookup("kernel32", Arena.global())
+                        .find("IsProcessorFeaturePresent").orElseThrow(),
+                FunctionDescriptor.of(ValueLayout.JAVA_INT, ValueLayout.JAVA_INT));
+        Set<String> supported = new LinkedHashSet<>();
+        for (Map.Entry<String, Integer> feature : WINDOWS_FEATURES.entrySet()) {
+            if ((int) isPresent.invokeExact((int) feature.getValue()) != 0) {
+                supported.add(feature.getKey());
+            }
+        }
+        return new Host("IsProcessorFeaturePresent", supported, WINDOWS_FEATURES.keySet());
+    }
+}
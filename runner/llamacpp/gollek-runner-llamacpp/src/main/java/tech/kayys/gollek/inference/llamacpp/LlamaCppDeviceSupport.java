@@ -1,18 +1,120 @@
 package tech.kayys.gollek.inference.llamacpp;
 
+import org.jboss.logging.Logger;
 import tech.kayys.gollek.spi.model.DeviceType;
 
 import java.nio.file.Files;
 import java.nio.file.Path;
+import java.util.Arrays;
+import java.util.Collections;
+import java.util.EnumSet;
 import java.util.LinkedHashSet;
+import java.util.List;
 import java.util.Locale;
 import java.util.Set;
 
 final class LlamaCppDeviceSupport {
 
+    private static final Logger log = Logger.getLogger(LlamaCppDeviceSupport.class);
+
+    /** llama.cpp compute backends selectable with {@code gguf.provider.gpu.backend}. */
+    enum Backend {
+        CPU(DeviceType.CPU),
+        CUDA(DeviceType.CUDA),
+        ROCM(DeviceType.ROCM),
+        METAL(DeviceType.METAL),
+        VULKAN(DeviceType.VULKAN);
+
+        /** GPU backends in the order {@code auto} prefers them. */
+        static final List<Backend> GPU_PREFERENCE = List.of(CUDA, ROCM, METAL, VULKAN);
+
+        final DeviceType deviceType;
+
+        Backend(DeviceType deviceType) {
+            this.deviceType = deviceType;
+        }
+
+        String id() {
+            return name().toLowerCase(Locale.ROOT);
+        }
+
+        /** The backend of a ggml registry name, or null for ones the runner does not select (BLAS, SYCL, ...). */
+        static Backend ofRegistry(String registry) {
+            return switch (registry == null ? "" : registry.toLowerCase(Locale.ROOT)) {
+                case "cpu" -> CPU;
+                case "cuda" -> CUDA;
+                case "rocm", "hip" -> ROCM;
+                case "metal", "mtl" -> METAL;
+                case "vulkan" -> VULKAN;
+                default -> null;
+            };
+        }
+    }
+
     private LlamaCppDeviceSupport() {
     }
 
+    /** Backends the loaded library has at least one device for; empty if it cannot list devices. */
+    static Set<Backend> detectBackends(LlamaCppBinding binding) {
+        if (binding == null) {
+            return Set.of();
+        }
+        EnumSet<Backend> found = EnumSet.noneOf(Backend.class);
+        for (LlamaCppBinding.BackendDevice device : binding.backendDevices()) {
+            Backend backend = Backend.ofRegistry(device.registry());
+            if (backend != null) {
+                found.add(backend);
+            }
+        }
+        return Collections.unmodifiableSet(found);
+    }
+
+    /**
+     * The GPU backend to offload to, or {@link Backend#CPU} when offloading is off or the
+     * requested backend has no device. When the library cannot list its devices the
+     * request is trusted, and {@code auto} keeps the old assumption of Metal on Apple
+     * Silicon and CUDA elsewhere.
+     *
+     * @throws IllegalArgumentException if {@code gpu.backend} is not a known backend
+     */
+    static Backend selectBackend(LlamaCppProviderConfig config, Set<Backend> available) {
+        String requested = config.gpuBackend() == null || config.gpuBackend().isBlank()
+                ? "auto" : config.gpuBackend().trim().toLowerCase(Locale.ROOT);
+        Backend explicit = null;
+        if (!requested.equals("auto")) {
+            explicit = Arrays.stream(Backend.values()).filter(b -> b.id().equals(requested)).findFirst()
+                    .orElseThrow(() -> new IllegalArgumentException("Unknown gguf.provider.gpu.backend '"
+                            + requested + "'; expected auto, cpu, cuda, rocm, metal or vulkan"));
+        }
+        if (explicit == Backend.CPU || !effectiveGpuEnabled(config)) {
+            return Backend.CPU;
+        }
+        if (explicit != null) {
+            if (available.isEmpty() || available.contains(explicit)) {
+                return explicit;
+            }
+            log.warnf("gguf.provider.gpu.backend=%s but the llama.cpp library has no %s device (found %s); using CPU",
+                    explicit.id(), explicit.id(), available);
+            return Backend.CPU;
+        }
+        for (Backend backend : Backend.GPU_PREFERENCE) {
+            if (available.contains(backend)) {
+                return backend;
+            }
+        }
+        if (available.isEmpty()) {
+            return isAppleSilicon() ? Backend.METAL : Backend.CUDA;
+        }
+        return Backend.CPU;
+    }
+
+    /** GPU devices of {@code backend} in registry order, for {@code llama_model_params.devices}. */
+    static List<LlamaCppBinding.BackendDevice> devicesOf(LlamaCppBinding binding, Backend backend) {
+        return binding.backendDevices().stream()
+                .filter(d -> d.isGpu() && Backend.ofRegistry(d.registry()) == backend)
+                .toList();
+    }
+
     static boolean isAppleSilicon() {
         String osName = System.getProperty("os.name", "").toLowerCase(Locale.ROOT);
         if (!osName.contains("mac")) {
@@ -54,15 +156,10 @@ final class LlamaCppDeviceSupport {
         return 0;
     }
 
-    static Set<DeviceType> supportedDevices(LlamaCppProviderConfig config) {
+    static Set<DeviceType> supportedDevices(LlamaCppProviderConfig config, Set<Backend> available) {
         LinkedHashSet<DeviceType> devices = new LinkedHashSet<>();
         devices.add(DeviceType.CPU);
-        if (config.gpuEnabled()) {
-            devices.add(DeviceType.CUDA);
-        }
-        if (shouldAutoMetal(config)) {
-            devices.add(DeviceType.METAL);
-        }
+        devices.add(selectBackend(config, available).deviceType);
         return Set.copyOf(devices);
     }
 
@@ -0,0 +1,194 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.jboss.logging.Logger;
+
+import java.lang.foreign.MemorySegment;
+import java.lang.foreign.ValueLayout;
+import java.util.ArrayList;
+import java.util.List;
+
+/**
+ * Embeddings component: owns a second llama.cpp context created with
+ * {@code embeddings = true} and the configured pooling type, so embedding calls never
+ * disturb the generation context's KV cache.
+ *
+ * <p>The context is created lazily on first use and shares the already-loaded model
+ * weights. Calls are serialized on this instance because the context is single-sequence.
+ * Its size comes from {@code embedding.context-size}, not the generation context: the
+ * whole input is decoded in one batch, so the batch buffers grow with it.
+ */
+class LlamaCppEmbeddingEngine {
+    private static final Logger log = Logger.getLogger(LlamaCppEmbeddingEngine.class);
+
+    // llama_pooling_type
+    static final int POOLING_NONE = 0;
+    static final int POOLING_MEAN = 1;
+    static final int POOLING_CLS = 2;
+    static final int POOLING_LAST = 3;
+    static final int POOLING_RANK = 4;
+
+    private final LlamaCppBinding binding;
+    private final LlamaCppProviderConfig providerConfig;
+    private final MemorySegment model;
+    private final int contextSize;
+    private MemorySegment context;
+    private int pooling;
+
+    LlamaCppEmbeddingEngine(LlamaCppBinding binding, LlamaCppProviderConfig providerConfig,
+            MemorySegment model, int contextSize) {
+        this.binding = binding;
+        this.providerConfig = providerConfig;
+        this.model = model;
+        this.contextSize = contextSize(providerConfig.embeddingContextSize(), contextSize);
+    }
+
+    /** The embedding context size: {@code configured}, or the generation size when 0 or less. */
+    static int contextSize(int configured, int generationContextSize) {
+        return configured > 0 ? configured : generationContextSize;
+    }
+
+    static int parsePooling(String value) {
+        return switch (value == null ? "mean" : value.trim().toLowerCase()) {
+            case "none" -> POOLING_NONE;
+            case "cls" -> POOLING_CLS;
+            case "last" -> POOLING_LAST;
+            case "rank" -> POOLING_RANK;
+            default -> POOLING_MEAN;
+        };
+    }
+
+    int dimension() {
+        try {
+            return binding.nEmbd(model);
+        } catch (Throwable t) {
+            throw new RuntimeException("Failed to get embedding dimension", t);
+        }
+    }
+
+    synchronized List<float[]> embed(List<String> inputs) {
+        ensureContext();
+        int nEmbd = dimension();
+        List<float[]> out = new ArrayList<>(inputs.size());
+        for (String input : inputs) {
+            out.add(embedOne(input, nEmbd));
+        }
+        return out;
+    }
+
+    private float[] embedOne(String input, int nEmbd) {
+        int[] tokens = binding.tokenize(model, input == null ? "" : input, true, false);
+        if (tokens.length == 0) {
+            return new float[nEmbd];
+        }
+        if (tokens.length > contextSize) {
+            // non-causal models need the whole sequence in one ubatch; keep the head
+            int[] truncated = new int[contextSize];
+            System.arraycopy(tokens, 0, truncated, 0, contextSize);
+            tokens = truncated;
+        }
+        binding.kvCacheClear(context);
+        MemorySegment batch = binding.batchInit(tokens.length, 0, 1);
+        try {
+            binding.setBatchSize(batch, tokens.length);
+            for (int i = 0; i < tokens.length; i++) {
+                binding.setBatchToken(batch, i, tokens[i], i, 0, true);
+            }
+            if (binding.decode(context, batch) != 0) {
+                throw new RuntimeException("Embedding decode failed");
+            }
+            float[] vec = pooling == POOLING_NONE ? meanOfTokens(tokens.length, nEmbd) : pooled(nEmbd);
+            if (providerConfig.embeddingNormalize()) {
+                normalize(vec);
+            }
+            return vec;
+        } finally {
+            binding.batchFree(batch);
+        }
+    }
+
+    private float[] pooled(int nEmbd) {
+        try {
+            MemorySegment seg = binding.getEmbeddingsSeq(context, 0);
+            if (seg == null || seg.address() == 0) {
+                throw new RuntimeException("No pooled embedding available (model may not support pooling)");
+            }
+            int n = pooling == POOLING_RANK ? 1 : nEmbd;
+            float[] vec = new float[n];
+            MemorySegment view = seg.reinterpret((long) n * Float.BYTES);
+            for (int i = 0; i < n; i++) {
+                vec[i] = view.getAtIndex(ValueLayout.JAVA_FLOAT, i);
+            }
+            return vec;
+        } catch (RuntimeException e) {
+            throw e;
+        } catch (Throwable t) {
+            throw new RuntimeException("Failed to read pooled embedding", t);
+        }
+    }
+
+    private float[] meanOfTokens(int nTokens, int nEmbd) {
+        float[] vec = new float[nEmbd];
+        try {
+            for (int t = 0; t < nTokens; t++) {
+                MemorySegment row = binding.getEmbeddingsIth(context, t).reinterpret((long) nEmbd * Float.BYTES);
+                for (int i = 0; i < nEmbd; i++) {
+                    vec[i] += row.getAtIndex(ValueLayout.JAVA_FLOAT, i);
+                }
+            }
+        } catch (Throwable t) {
+            throw new RuntimeException("Failed to read token embeddings", t);
+        }
+        for (int i = 0; i < nEmbd; i++) {
+            vec[i] /= nTokens;
+        }
+        return vec;
+    }
+
+    static void normalize(float[] vec) {
+        double sum = 0.0;
+        for (float v : vec) {
+            sum += (double) v * v;
+        }
+        if (sum <= 0.0) {
+            return;
+        }
+        float inv = (float) (1.0 / Math.sqrt(sum));
+        for (int i = 0; i < vec.length; i++) {
+            vec[i] *= inv;
+        }
+    }
+
+    private void ensureContext() {
+        if (context != null) {
+            return;
+        }
+        int requested = parsePooling(providerConfig.embeddingPooling());
+        int threads = Math.max(1, Runtime.getRuntime().availableProcessors() / 2);
+        MemorySegment params = binding.getDefaultContextParams();
+        binding.setContextParam(params, "n_ctx", contextSize);
+        binding.setContextParam(params, "n_batch", contextSize);
+        binding.setContextParam(params, "n_ubatch", contextSize);
+        binding.setContextParam(params, "n_seq_max", 1);
+        binding.setContextParam(params, "n_threads", threads);
+        binding.setContextParam(params, "n_threads_batch", threads);
+        binding.setContextParam(params, "embeddings", true);
+        binding.setContextParam(params, "pooling_type", requested);
+        binding.setContextParam(params, "samplers", MemorySegment.NULL);
+        binding.setContextParam(params, "n_samplers", 0L);
+        this.context = binding.createContext(model, params);
+        try {
+            int actual = binding.poolingType(context);
+            this.pooling = actual >= 0 ? actual : requested;
+        } catch (Throwable t) {
+            this.pooling = requested;
+        }
+        log.debugf("Embedding context ready (pooling=%d, n_ctx=%d)", pooling, contextSize);
+    }
+
+    synchronized void close() {
+        if (context != null) {
+            binding.freeContext(context);
+            context = null;
+        }
+    }
+}
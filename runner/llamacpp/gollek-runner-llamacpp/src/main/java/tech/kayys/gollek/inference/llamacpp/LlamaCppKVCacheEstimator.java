@@ -0,0 +1,97 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import java.util.function.Function;
+
+/**
+ * Estimates KV cache memory from GGUF hyper-parameters, mirroring llama.cpp's layout:
+ * per layer and token, K holds {@code key_length * head_count_kv} and V holds
+ * {@code value_length * head_count_kv} elements, stored as f16 by default.
+ */
+public final class LlamaCppKVCacheEstimator {
+
+    /** Bytes per element of the default f16 KV cache. */
+    static final int F16_BYTES = 2;
+    /** Auto-sized contexts are rounded down to this granularity. */
+    static final int CONTEXT_GRANULARITY = 256;
+    static final int MIN_CONTEXT = 512;
+
+    private LlamaCppKVCacheEstimator() {
+    }
+
+    /**
+     * KV cache estimate for a context. {@code bytesPerToken} is zero when the model does
+     * not expose the hyper-parameters needed.
+     */
+    public record Estimate(String architecture, int layers, int kvHeads, int keyLength, int valueLength,
+            long bytesPerToken, int contextSize) {
+
+        public long totalBytes() {
+            return bytesPerToken * contextSize;
+        }
+
+        public boolean known() {
+            return bytesPerToken > 0;
+        }
+
+        public Estimate withContextSize(int newContextSize) {
+            return new Estimate(architecture, layers, kvHeads, keyLength, valueLength, bytesPerToken, newContextSize);
+        }
+    }
+
+    /**
+     * Estimate from model metadata; {@code metadata} maps a GGUF key to its value or null.
+     */
+    public static Estimate estimate(Function<String, String> metadata, int contextSize) {
+        String arch = metadata.apply("general.architecture");
+        if (arch == null || arch.isBlank()) {
+            return new Estimate(null, 0, 0, 0, 0, 0, contextSize);
+        }
+        int layers = parse(metadata.apply(arch + ".block_count"));
+        int embd = parse(metadata.apply(arch + ".embedding_length"));
+        int heads = parse(metadata.apply(arch + ".attention.head_count"));
+        int kvHeads = parse(metadata.apply(arch + ".attention.head_count_kv"));
+        if (kvHeads <= 0) {
+            kvHeads = heads;
+        }
+        int headDim = heads > 0 ? embd / heads : 0;
+        int keyLength = parse(metadata.apply(arch + ".attention.key_length"));
+        int valueLength = parse(metadata.apply(arch + ".attention.value_length"));
+        if (keyLength <= 0) {
+            keyLength = headDim;
+        }
+        if (valueLength <= 0) {
+            valueLength = headDim;
+        }
+        long perToken = (long) layers * kvHeads * (keyLength + valueLength) * F16_BYTES;
+        return new Estimate(arch, layers, kvHeads, keyLength, valueLength, Math.max(0, perToken), contextSize);
+    }
+
+    /**
+     * Largest context (rounded down to {@link #CONTEXT_GRANULARITY}, at least
+     * {@link #MIN_CONTEXT}) whose KV cache fits in {@code budgetBytes}; never above
+     * {@code requested}.
+     */
+    public static int fitContext(long bytesPerToken, long budgetBytes, int requested) {
+        if (bytesPerToken <= 0 || budgetBytes <= 0) {
+            return requested;
+        }
+        long fits = budgetBytes / bytesPerToken;
+        if (fits >= requested) {
+            return requested;
+        }
+        int rounded = (int) (fits / CONTEXT_GRANULARITY * CONTEXT_GRANULARITY);
+        return Math.min(requested, Math.max(MIN_CONTEXT, rounded));
+    }
+
+    private static int parse(String value) {
+        if (value == null) {
+            return 0;
+        }
+        try {
+            return (int) Double.parseDouble(value.trim());
+        } catch (NumberFormatException e) {
+            // per-layer arrays and other non-scalar values are not supported
+            return 0;
+        }
+    }
+}
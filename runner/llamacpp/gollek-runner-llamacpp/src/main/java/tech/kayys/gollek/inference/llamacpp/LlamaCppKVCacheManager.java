@@ -21,6 +21,8 @@ public class LlamaCppKVCacheManager {
     private final LlamaCppBinding binding;
     private final LlamaCppProviderConfig providerConfig;
     private final ModelManifest manifest;
+    private final LlamaCppSlotStats slotStats;
+    private final LlamaCppPromptCache promptCache;
 
     private int[] kvTokenHistory = new int[0];
     private int kvTokenCount = 0;
@@ -34,31 +36,136 @@ public class LlamaCppKVCacheManager {
             });
 
     public LlamaCppKVCacheManager(LlamaCppBinding binding, LlamaCppProviderConfig providerConfig, ModelManifest manifest) {
+        this(binding, providerConfig, manifest, new LlamaCppSlotStats(1, 0));
+    }
+
+    public LlamaCppKVCacheManager(LlamaCppBinding binding, LlamaCppProviderConfig providerConfig, ModelManifest manifest,
+            LlamaCppSlotStats slotStats) {
         this.binding = binding;
         this.providerConfig = providerConfig;
         this.manifest = manifest;
+        this.slotStats = slotStats;
+        this.promptCache = LlamaCppPromptCache.forModel(binding, providerConfig, manifest);
+    }
+
+    /**
+     * Slot occupancy shared with the batch scheduler; this manager maintains slot 0.
+     */
+    public LlamaCppSlotStats slotStats() {
+        return slotStats;
+    }
+
+    /**
+     * Prepare the KV cache for a new prompt and return how many leading prompt tokens are
+     * already cached. Keeps the longest prefix shared with the cached history and removes
+     * the rest of sequence 0, so a common system prompt is evaluated only once.
+     */
+    public int reusePrefix(MemorySegment context, int[] promptTokens, int nTokens) {
+        return reusePrefix(context, promptTokens, nTokens, 0);
     }
 
     /**
-     * Compute how many tokens from the current prompt can reuse existing KV cache.
+     * As {@link #reusePrefix(MemorySegment, int[], int)}, first restoring the prompt cache's
+     * state for the first {@code cachePrefix} tokens (typically the system prompt) when the
+     * cache holds them and memory holds less.
      */
-    public int computeReusePrefix(int[] promptTokens, int nTokens) {
-        if (kvTokenCount == 0 || kvTokenHistory.length == 0) {
+    public int reusePrefix(MemorySegment context, int[] promptTokens, int nTokens, int cachePrefix) {
+        if (!providerConfig.prefixCacheEnabled()) {
+            resetKvCache(context);
             return 0;
         }
-        int minLen = Math.min(kvTokenCount, nTokens);
+        int reused = matchPrefix(context, promptTokens, nTokens);
+        if (reused < cachePrefix && cachePrefix < nTokens && restoreCachedPrompt(context, promptTokens, cachePrefix)) {
+            reused = matchPrefix(context, promptTokens, nTokens);
+        }
+        slotStats.recordPrefixLookup(0, reused);
+        return reused;
+    }
+
+    private boolean restoreCachedPrompt(MemorySegment context, int[] promptTokens, int length) {
+        if (promptCache == null || !promptCache.worthCaching(length) || !binding.supportsStateSnapshots()
+                || !promptCache.contains(promptTokens, length)) {
+            return false;
+        }
+        resetKvCache(context);
+        try {
+            int[] tokens = promptCache.load(context, promptTokens, length);
+            if (tokens.length == 0) {
+                resetKvCache(context);
+                return false;
+            }
+            kvTokenHistory = tokens;
+            kvTokenCount = tokens.length;
+            slotStats.setTokens(0, kvTokenCount);
+            log.debugf("Restored %d prompt tokens from the prompt cache", kvTokenCount);
+            return true;
+        } catch (RuntimeException e) {
+            log.warnf("Failed to restore cached prompt state: %s", e.getMessage());
+            resetKvCache(context);
+            return false;
+        }
+    }
+
+    /**
+     * Whether the first {@code cachePrefix} prompt tokens should be written to the prompt
+     * cache once evaluated: they are long enough and not cached yet.
+     */
+    public boolean shouldCachePrompt(int[] promptTokens, int cachePrefix) {
+        return promptCache != null && promptCache.worthCaching(cachePrefix) && binding.supportsStateSnapshots()
+                && !promptCache.contains(promptTokens, cachePrefix);
+    }
+
+    /**
+     * Write sequence 0 to the prompt cache as the state of the first {@code cachePrefix}
+     * prompt tokens; call it when exactly those have been evaluated.
+     */
+    public void cachePrompt(MemorySegment context, int[] promptTokens, int cachePrefix) {
+        if (promptCache.store(context, promptTokens, cachePrefix)) {
+            log.debugf("Cached %d prompt tokens on disk", cachePrefix);
+        }
+    }
+
+    private int matchPrefix(MemorySegment context, int[] promptTokens, int nTokens) {
         int common = 0;
-        for (int i = 0; i < minLen; i++) {
-            if (kvTokenHistory[i] == promptTokens[i]) {
-                common++;
-            } else {
-                break;
+        int minLen = Math.min(kvTokenCount, nTokens);
+        while (common < minLen && kvTokenHistory[common] == promptTokens[common]) {
+            common++;
+        }
+        // the last prompt token is always decoded again so fresh logits exist for sampling
+        common = Math.min(common, nTokens - 1);
+        if (common <= 0 || common < providerConfig.prefixCacheMinTokens()) {
+            resetKvCache(context);
+            return 0;
+        }
+        if (common < kvTokenCount) {
+            if (!binding.memorySeqRm(context, 0, common, -1)) {
+                resetKvCache(context);
+                return 0;
             }
+            slotStats.recordEviction(0, kvTokenCount - common);
+            kvTokenCount = common;
         }
-        if (common == kvTokenCount && nTokens > kvTokenCount) {
-            return common;
+        return common;
+    }
+
+    /**
+     * Context shift on sequence 0: drop {@code discard} cached tokens after the first
+     * {@code keep} and move the later ones down so generation can continue. Returns false,
+     * leaving the cache untouched, when the model's cache cannot shift.
+     */
+    public boolean shiftContext(MemorySegment context, int keep, int discard) {
+        if (discard <= 0 || !binding.canShiftMemory(context)
+                || !binding.memorySeqRm(context, 0, keep, keep + discard)) {
+            return false;
         }
-        return 0;
+        binding.memorySeqAdd(context, 0, keep + discard, -1, -discard);
+        int[] shifted = Arrays.copyOf(kvTokenHistory, kvTokenHistory.length);
+        System.arraycopy(kvTokenHistory, keep + discard, shifted, keep, kvTokenCount - keep - discard);
+        kvTokenHistory = shifted;
+        kvTokenCount -= discard;
+        slotStats.recordEviction(0, discard);
+        slotStats.setTokens(0, kvTokenCount);
+        return true;
     }
 
     /**
@@ -68,6 +175,7 @@ public class LlamaCppKVCacheManager {
         if (context != null && !context.equals(MemorySegment.NULL)) {
             binding.kvCacheClear(context);
         }
+        slotStats.recordEviction(0, kvTokenCount);
         kvTokenHistory = new int[0];
         kvTokenCount = 0;
     }
@@ -129,6 +237,7 @@ public class LlamaCppKVCacheManager {
             if (loadedTokens.length > 0) {
                 kvTokenHistory = loadedTokens;
                 kvTokenCount = loadedTokens.length;
+                slotStats.setTokens(0, kvTokenCount);
                 log.debugf("Loaded session from %s with %d tokens", sessionPath, kvTokenCount);
             }
         } catch (Exception e) {
@@ -160,12 +269,60 @@ public class LlamaCppKVCacheManager {
         }
     }
 
+    /**
+     * Snapshot sequence 0 and its token history to {@code path}, so a later process can
+     * skip prefilling the same prefix. Returns false if nothing is cached or the library
+     * lacks the sequence state API.
+     */
+    public boolean saveSequenceState(MemorySegment context, Path path) {
+        if (kvTokenCount == 0 || !binding.supportsStateSnapshots()) {
+            return false;
+        }
+        try {
+            if (path.getParent() != null) {
+                Files.createDirectories(path.getParent());
+            }
+            return binding.saveSeqState(context, 0, path, kvTokenHistory, kvTokenCount);
+        } catch (IOException | RuntimeException e) {
+            log.warnf("Failed to save KV state to %s: %s", path, e.getMessage());
+            return false;
+        }
+    }
+
+    /**
+     * Replace sequence 0 with a {@link #saveSequenceState} snapshot and return the number of
+     * tokens restored; {@link #reusePrefix} then treats them as cached. A snapshot that does
+     * not fit this model or context leaves an empty cache and returns 0.
+     */
+    public int restoreSequenceState(MemorySegment context, Path path) {
+        if (path == null || !Files.exists(path) || !binding.supportsStateSnapshots()) {
+            return 0;
+        }
+        resetKvCache(context);
+        try {
+            int[] tokens = binding.loadSeqState(context, 0, path, providerConfig.maxContextTokens());
+            if (tokens.length == 0) {
+                return 0;
+            }
+            kvTokenHistory = tokens;
+            kvTokenCount = tokens.length;
+            slotStats.setTokens(0, kvTokenCount);
+            log.debugf("Restored KV state from %s with %d tokens", path, kvTokenCount);
+            return kvTokenCount;
+        } catch (RuntimeException e) {
+            log.warnf("Failed to restore KV state from %s: %s", path, e.getMessage());
+            resetKvCache(context);
+            return 0;
+        }
+    }
+
     /**
      * Update token history with prompt tokens after prompt evaluation.
      */
     public void updateAfterPrompt(int[] promptTokens, int nTokens) {
         kvTokenHistory = Arrays.copyOf(promptTokens, nTokens);
         kvTokenCount = nTokens;
+        slotStats.setTokens(0, nTokens);
     }
 
     /**
@@ -174,6 +331,7 @@ public class LlamaCppKVCacheManager {
     public void updateAfterGeneration(int tokenId) {
         if (tokenId >= 0) {
             kvTokenHistory = appendToken(kvTokenHistory, kvTokenCount++, tokenId);
+            slotStats.setTokens(0, kvTokenCount);
         }
     }
 
@@ -0,0 +1,198 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.lang.foreign.MemorySegment;
+import java.lang.foreign.ValueLayout;
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.PriorityQueue;
+import java.util.function.Consumer;
+import java.util.function.IntFunction;
+
+/**
+ * Per-token log probabilities for one generation, requested with {@code "logprobs": N}.
+ * Each sampled token is scored against the model's raw distribution (the logits from
+ * {@code llama_get_logits_ith}, log-softmaxed), so values do not depend on temperature,
+ * penalties or other sampler settings. Up to {@value #MAX_TOP} most likely alternatives are
+ * kept per token.
+ *
+ * <p>Entries are handed out as the text they cover is released by the stop matcher, so a
+ * stream never reports tokens of a stop sequence. Not thread-safe; each generation owns one.
+ */
+final class LlamaCppLogprobs {
+
+    /** Request parameter: number of alternatives per token, or {@code true} for none. */
+    static final String PARAMETER = "logprobs";
+    /** Alternatives per token when {@value #PARAMETER} is {@code true}, OpenAI chat style. */
+    static final String TOP_PARAMETER = "top_logprobs";
+    /** Response and chunk metadata key holding the entries. */
+    static final String METADATA = "logprobs";
+    static final int MAX_TOP = 20;
+
+    /** One of the most likely tokens at a position. */
+    record Alternative(int token, String text, double logprob) {
+    }
+
+    /** A sampled token, its log probability and the most likely tokens at its position. */
+    record Entry(int token, String text, double logprob, List<Alternative> top) {
+    }
+
+    /** A stream consumer that also receives the entries covered by each piece of text. */
+    interface Sink extends Consumer<String> {
+
+        void accept(String text, List<Entry> logprobs);
+
+        @Override
+        default void accept(String text) {
+            accept(text, List.of());
+        }
+    }
+
+    private final int topN;
+    private final IntFunction<String> pieceOf;
+    private final List<Entry> entries = new ArrayList<>();
+    /** Text offset where each entry's piece ends. */
+    private final List<Integer> ends = new ArrayList<>();
+    private int generatedChars;
+    private int released;
+
+    LlamaCppLogprobs(int topN, IntFunction<String> pieceOf) {
+        this.topN = topN;
+        this.pieceOf = pieceOf;
+    }
+
+    /** Tracker for {@code request}, or null when it did not ask for log probabilities. */
+    static LlamaCppLogprobs forRequest(InferenceRequest request, IntFunction<String> pieceOf) {
+        int topN = requested(request.getParameters());
+        return topN < 0 ? null : new LlamaCppLogprobs(topN, pieceOf);
+    }
+
+    /**
+     * Alternatives per token asked for by {@code parameters}, capped at {@value #MAX_TOP};
+     * -1 when log probabilities are off.
+     *
+     * @throws IllegalArgumentException if the count is negative or not a number
+     */
+    static int requested(Map<String, Object> parameters) {
+        Object value = parameters.get(PARAMETER);
+        int topN;
+        if (value == null || Boolean.FALSE.equals(value)) {
+            return -1;
+        } else if (Boolean.TRUE.equals(value)) {
+            Object top = parameters.get(TOP_PARAMETER);
+            topN = top instanceof Number n ? n.intValue() : 0;
+        } else if (value instanceof Number n) {
+            topN = n.intValue();
+        } else {
+            try {
+                topN = Integer.parseInt(String.valueOf(value).trim());
+            } catch (NumberFormatException e) {
+                throw new IllegalArgumentException("logprobs must be a number of alternatives: " + value);
+            }
+        }
+        if (topN < 0) {
+            throw new IllegalArgumentException("logprobs must not be negative: " + topN);
+        }
+        return Math.min(topN, MAX_TOP);
+    }
+
+    /**
+     * Scores {@code token}, sampled from {@code logits} (one float per vocabulary entry),
+     * whose text is {@code piece}. Must run before the next decode overwrites the logits.
+     */
+    void record(MemorySegment logits, int token, String piece) {
+        int vocab = (int) (logits.byteSize() / Float.BYTES);
+        float max = Float.NEGATIVE_INFINITY;
+        for (int i = 0; i < vocab; i++) {
+            max = Math.max(max, logits.getAtIndex(ValueLayout.JAVA_FLOAT, i));
+        }
+        double sum = 0;
+        for (int i = 0; i < vocab; i++) {
+            sum += Math.exp(logits.getAtIndex(ValueLayout.JAVA_FLOAT, i) - max);
+        }
+        double logSumExp = max + Math.log(sum);
+
+        List<Alternative> top = new ArrayList<>(topN);
+        if (topN > 0) {
+            // min-heap of the topN highest logits seen so far
+            PriorityQueue<Integer> heap = new PriorityQueue<>(topN + 1,
+                    (a, b) -> Float.compare(logits.getAtIndex(ValueLayout.JAVA_FLOAT, a),
+                            logits.getAtIndex(ValueLayout.JAVA_FLOAT, b)));
+            for (int i = 0; i < vocab; i++) {
+                if (heap.size() < topN) {
+                    heap.add(i);
+                } else if (logits.getAtIndex(ValueLayout.JAVA_FLOAT, i)
+                        > logits.getAtIndex(ValueLayout.JAVA_FLOAT, heap.peek())) {
+                    heap.poll();
+                    heap.add(i);
+                }
+            }
+            while (!heap.isEmpty()) {
+                int id = heap.poll();
+                top.add(0, new Alternative(id, pieceOf.apply(id),
+                        logits.getAtIndex(ValueLayout.JAVA_FLOAT, id) - logSumExp));
+            }
+        }
+        entries.add(new Entry(token, piece, logits.getAtIndex(ValueLayout.JAVA_FLOAT, token) - logSumExp,
+                List.copyOf(top)));
+        generatedChars += piece.length();
+        ends.add(generatedChars);
+    }
+
+    /**
+     * Entries not handed out yet whose text lies within the first {@code releasedChars}
+     * characters of output.
+     */
+    List<Entry> release(int releasedChars) {
+        int from = released;
+        while (released < entries.size() && ends.get(released) <= releasedChars) {
+            released++;
+        }
+        return List.copyOf(entries.subList(from, released));
+    }
+
+    /** All entries whose text lies within the first {@code contentChars} characters of output. */
+    List<Entry> upTo(int contentChars) {
+        int count = 0;
+        while (count < entries.size() && ends.get(count) <= contentChars) {
+            count++;
+        }
+        return List.copyOf(entries.subList(0, count));
+    }
+
+    /**
+     * Entries in the OpenAI {@code logprobs.content} shape: {@code token}, {@code logprob},
+     * {@code bytes} and {@code top_logprobs}.
+     */
+    static List<Map<String, Object>> toMetadata(List<Entry> entries) {
+        List<Map<String, Object>> content = new ArrayList<>(entries.size());
+        for (Entry entry : entries) {
+            List<Map<String, Object>> top = new ArrayList<>(entry.top().size());
+            for (Alternative alternative : entry.top()) {
+                top.add(describe(alternative.token(), alternative.text(), alternative.logprob()));
+            }
+            Map<String, Object> item = describe(entry.token(), entry.text(), entry.logprob());
+            item.put("top_logprobs", top);
+            content.add(item);
+        }
+        return content;
+    }
+
+    private static Map<String, Object> describe(int token, String text, double logprob) {
+        Map<String, Object> item = new LinkedHashMap<>();
+        item.put("token", text);
+        item.put("id", token);
+        item.put("logprob", logprob);
+        byte[] utf8 = text.getBytes(StandardCharsets.UTF_8);
+        List<Integer> bytes = new ArrayList<>(utf8.length);
+        for (byte b : utf8) {
+            bytes.add(b & 0xff);
+        }
+        item.put("bytes", bytes);
+        return item;
+    }
+}
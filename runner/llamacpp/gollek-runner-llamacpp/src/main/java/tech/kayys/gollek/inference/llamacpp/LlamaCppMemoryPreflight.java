@@ -0,0 +1,210 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.gguf.loader.GGUFParser;
+import tech.kayys.gollek.gguf.loader.GGUFReader;
+
+import java.io.IOException;
+import java.lang.foreign.Arena;
+import java.lang.management.ManagementFactory;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Checks, before llama.cpp is asked to load a model, that its weights, KV cache and
+ * compute buffers fit in the memory that is free. A load that does not fit is refused
+ * with the settings that would fit, rather than left to exhaust the host.
+ *
+ * <p>Weights are split between host and device in proportion to the offloaded layers
+ * (plus one for the output layer), and so is the KV cache, which llama.cpp keeps next
+ * to each layer. Compute buffers are a flat allowance on whichever side runs the graph.
+ */
+final class LlamaCppMemoryPreflight {
+
+    /** Compute buffers and scratch: this much, or a twentieth of the weights if larger. */
+    static final long MIN_OVERHEAD_BYTES = 256L * 1024 * 1024;
+
+    private static final double GIB = 1024.0 * 1024.0 * 1024.0;
+
+    private LlamaCppMemoryPreflight() {
+    }
+
+    /** Estimated memory for one load, split into host RAM and device memory. */
+    record Requirement(long weightsBytes, long kvBytes, long overheadBytes, long hostBytes, long deviceBytes) {
+    }
+
+    /**
+     * Outcome of the check. {@code available*} is -1 where it could not be measured, and
+     * such a side is never reported as short. {@code suggestedContext} and
+     * {@code suggestedGpuLayers} are -1 when no value of that setting would help.
+     */
+    record Verdict(Requirement required, long availableHost, long availableDevice,
+            int suggestedContext, int suggestedGpuLayers) {
+
+        boolean hostShort() {
+            return availableHost >= 0 && required.hostBytes() > availableHost;
+        }
+
+        boolean deviceShort() {
+            return availableDevice >= 0 && required.deviceBytes() > availableDevice;
+        }
+
+        boolean fits() {
+            return !hostShort() && !deviceShort();
+        }
+
+        /** Why the load is refused and what to change; only meaningful when it does not fit. */
+        String message(String model, int contextSize, int gpuLayers) {
+            StringBuilder msg = new StringBuilder("Not enough memory to load ").append(model).append(": ");
+            List<String> shortfalls = new ArrayList<>();
+            if (hostShort()) {
+                shortfalls.add(String.format("needs ~%.1f GiB RAM but %.1f GiB is available",
+                        required.hostBytes() / GIB, availableHost / GIB));
+            }
+            if (deviceShort()) {
+                shortfalls.add(String.format("needs ~%.1f GiB VRAM but %.1f GiB is free",
+                        required.deviceBytes() / GIB, availableDevice / GIB));
+            }
+            msg.append(String.join(" and ", shortfalls));
+            msg.append(String.format(" (weights %.1f GiB, KV cache %.1f GiB for n_ctx=%d, overhead %.1f GiB).",
+                    required.weightsBytes() / GIB, required.kvBytes() / GIB, contextSize,
+                    required.overheadBytes() / GIB));
+            List<String> tries = new ArrayList<>();
+            if (suggestedContext > 0 && suggestedContext < contextSize) {
+                tries.add("n_ctx=" + suggestedContext + " (gguf.provider.max-context-tokens)");
+            }
+            if (suggestedGpuLayers >= 0 && suggestedGpuLayers != gpuLayers) {
+                tries.add("gpu_layers=" + suggestedGpuLayers + " (gguf.provider.gpu.layers)");
+            }
+            tries.add("a smaller quantization");
+            msg.append(" Try ").append(String.join(", ", tries))
+                    .append(", or set gguf.provider.memory.preflight.enabled=false to load anyway.");
+            return msg.toString();
+        }
+    }
+
+    /**
+     * Memory needed to load {@code modelBytes} of weights with {@code gpuLayers} offloaded
+     * (-1 for all) and a KV cache of {@code kv}; {@code kv.layers()} of zero means the
+     * layer count is unknown and any offload is treated as full.
+     */
+    static Requirement require(long modelBytes, LlamaCppKVCacheEstimator.Estimate kv, int gpuLayers) {
+        long weights = Math.max(0, modelBytes);
+        long kvBytes = kv.known() ? kv.totalBytes() : 0;
+        long overhead = Math.max(MIN_OVERHEAD_BYTES, weights / 20);
+        double share = offloadedShare(gpuLayers, kv.layers());
+        long deviceWeights = (long) (weights * share);
+        long deviceKv = (long) (kvBytes * share);
+        long host = weights - deviceWeights + kvBytes - deviceKv + (gpuLayers == 0 ? overhead : 0);
+        long device = gpuLayers == 0 ? 0 : deviceWeights + deviceKv + overhead;
+        return new Requirement(weights, kvBytes, overhead, host, device);
+    }
+
+    /**
+     * Checks {@code required} against the memory available on each side, less
+     * {@code headroom}, and works out the context and offload that would fit.
+     */
+    static Verdict check(long modelBytes, LlamaCppKVCacheEstimator.Estimate kv, int gpuLayers,
+            long availableHost, long availableDevice, long headroom) {
+        Requirement required = require(modelBytes, kv, gpuLayers);
+        long host = availableHost < 0 ? -1 : Math.max(0, availableHost - headroom);
+        long device = availableDevice < 0 || gpuLayers == 0 ? -1 : Math.max(0, availableDevice - headroom);
+        Verdict verdict = new Verdict(required, host, device, -1, -1);
+        if (verdict.fits()) {
+            return verdict;
+        }
+        return new Verdict(required, host, device,
+                fittingContext(modelBytes, kv, gpuLayers, host, device),
+                verdict.deviceShort() ? fittingGpuLayers(modelBytes, kv, gpuLayers, device) : -1);
+    }
+
+    /** Largest context that fits on both sides with the weights as they are, or -1. */
+    static int fittingContext(long modelBytes, LlamaCppKVCacheEstimator.Estimate kv, int gpuLayers,
+            long availableHost, long availableDevice) {
+        if (!kv.known()) {
+            return -1;
+        }
+        int step = LlamaCppKVCacheEstimator.CONTEXT_GRANULARITY;
+        for (int context = kv.contextSize() / step * step; context >= LlamaCppKVCacheEstimator.MIN_CONTEXT;
+                context -= step) {
+            Verdict v = new Verdict(require(modelBytes, kv.withContextSize(context), gpuLayers),
+                    availableHost, availableDevice, -1, -1);
+            if (v.fits()) {
+                return context;
+            }
+        }
+        return -1;
+    }
+
+    /** Most layers that fit in free device memory at the current context, or -1 if not measurable. */
+    static int fittingGpuLayers(long modelBytes, LlamaCppKVCacheEstimator.Estimate kv, int gpuLayers,
+            long availableDevice) {
+        if (gpuLayers == 0 || availableDevice < 0 || kv.layers() <= 0) {
+            return -1;
+        }
+        int max = gpuLayers < 0 ? kv.layers() + 1 : Math.min(gpuLayers, kv.layers() + 1);
+        for (int layers = max; layers > 0; layers--) {
+            if (require(modelBytes, kv, layers).deviceBytes() <= availableDevice) {
+                return layers;
+            }
+        }
+        return 0;
+    }
+
+    private static double offloadedShare(int gpuLayers, int blockCount) {
+        if (gpuLayers == 0) {
+            return 0;
+        }
+        if (gpuLayers < 0 || blockCount <= 0) {
+            return 1;
+        }
+        return Math.min(1.0, gpuLayers / (double) (blockCount + 1));
+    }
+
+    /**
+     * Metadata from the GGUF header, read without llama.cpp so it is available before
+     * the load.
+     */
+    static Map<String, Object> readMetadata(Path modelPath) throws IOException {
+        try (Arena arena = Arena.ofConfined(); GGUFReader reader = new GGUFReader(modelPath, arena)) {
+            return Map.copyOf(new GGUFParser().parse(reader.segment(), arena).metadata());
+        } catch (RuntimeException e) {
+            throw new IOException("Unreadable GGUF header in " + modelPath + ": " + e.getMessage(), e);
+        }
+    }
+
+    /**
+     * Host memory available to a new model: {@code memory.max-bytes} when set, otherwise
+     * {@code MemAvailable} on Linux (free RAM plus reclaimable cache), otherwise free RAM.
+     */
+    static long availableHostMemory(long configuredMax) {
+        if (configuredMax > 0) {
+            return configuredMax;
+        }
+        long memAvailable = memAvailable(Path.of("/proc/meminfo"));
+        if (memAvailable >= 0) {
+            return memAvailable;
+        }
+        if (ManagementFactory.getOperatingSystemMXBean() instanceof com.sun.management.OperatingSystemMXBean os) {
+            return os.getFreeMemorySize();
+        }
+        return -1;
+    }
+
+    /** {@code MemAvailable} from a {@code /proc/meminfo} file in bytes, or -1. */
+    static long memAvailable(Path meminfo) {
+        try {
+            for (String line : Files.readAllLines(meminfo)) {
+                if (line.startsWith("MemAvailable:")) {
+                    String[] parts = line.substring("MemAvailable:".length()).trim().split("\\s+");
+                    return Long.parseLong(parts[0]) * 1024;
+                }
+            }
+        } catch (IOException | RuntimeException ignored) {
+            // not Linux, or an unexpected format
+        }
+        return -1;
+    }
+}
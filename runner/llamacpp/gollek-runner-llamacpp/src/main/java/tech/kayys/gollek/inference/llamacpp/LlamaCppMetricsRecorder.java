@@ -6,8 +6,11 @@ import io.micrometer.core.instrument.Gauge;
 import io.micrometer.core.instrument.Timer;
 import io.micrometer.core.instrument.Counter;
 
+import tech.kayys.gollek.spi.inference.Priority;
+
 import java.time.Duration;
 import java.util.concurrent.atomic.AtomicLong;
+import java.util.concurrent.atomic.AtomicLongArray;
 
 /**
  * Handles metrics collection and reporting for GGUF inference operations.
@@ -26,6 +29,18 @@ public class LlamaCppMetricsRecorder {
     private final AtomicLong coalesceBatchMax = new AtomicLong();
     private final AtomicLong coalesceSeqMaxObserved = new AtomicLong();
     private final AtomicLong coalesceSeqTotal = new AtomicLong();
+    private final AtomicLong batchingActiveSequences = new AtomicLong();
+    private final AtomicLong batchingSteps = new AtomicLong();
+    private final AtomicLong batchingStepTokens = new AtomicLong();
+    private final AtomicLongArray batchingQueueDepths = new AtomicLongArray(Priority.values().length);
+    private final AtomicLong batchingPreemptions = new AtomicLong();
+    private final AtomicLong rejections = new AtomicLong();
+    private final AtomicLong kvCacheEstimatedBytes = new AtomicLong();
+    private final AtomicLong prefixCacheHits = new AtomicLong();
+    private final AtomicLong prefixCacheMisses = new AtomicLong();
+    private final AtomicLong prefixCacheReusedTokens = new AtomicLong();
+    private final AtomicLong prefixCachePromptTokens = new AtomicLong();
+    private volatile LlamaCppSlotStats slotStats;
 
     public LlamaCppMetricsRecorder() {
         this.coalesceMetricsRegistered = false;
@@ -56,6 +71,28 @@ public class LlamaCppMetricsRecorder {
         registry.gauge("gollek.gguf.coalesce.seq.max", tags, coalesceSeqMaxObserved, AtomicLong::get);
         registry.gauge("gollek.gguf.coalesce.seq.total", tags, coalesceSeqTotal, AtomicLong::get);
 
+        registry.gauge("gollek.gguf.kv_cache.estimated_bytes", tags, kvCacheEstimatedBytes, AtomicLong::get);
+        registry.gauge("gollek.gguf.batching.active_sequences", tags, batchingActiveSequences, AtomicLong::get);
+        registry.gauge("gollek.gguf.batching.steps", tags, batchingSteps, AtomicLong::get);
+        registry.gauge("gollek.gguf.batching.preemptions", tags, batchingPreemptions, AtomicLong::get);
+        registry.gauge("gollek.gguf.requests.rejected", tags, rejections, AtomicLong::get);
+        for (Priority priority : Priority.values()) {
+            Gauge.builder("gollek.gguf.batching.queue.depth", () -> batchingQueueDepths.get(priority.ordinal()))
+                    .tags(tags.and("priority", priority.name().toLowerCase())).register(registry);
+        }
+        Gauge.builder("gollek.gguf.batching.tokens_per_step", () -> {
+            long steps = batchingSteps.get();
+            return (steps == 0) ? 0.0 : (double) batchingStepTokens.get() / steps;
+        }).tags(tags).register(registry);
+        registry.gauge("gollek.gguf.prefix_cache.hits", tags, prefixCacheHits, AtomicLong::get);
+        registry.gauge("gollek.gguf.prefix_cache.misses", tags, prefixCacheMisses, AtomicLong::get);
+        registry.gauge("gollek.gguf.prefix_cache.reused_tokens", tags, prefixCacheReusedTokens, AtomicLong::get);
+        Gauge.builder("gollek.gguf.prefix_cache.reuse_ratio", () -> {
+            long prompt = prefixCachePromptTokens.get();
+            return (prompt == 0) ? 0.0 : (double) prefixCacheReusedTokens.get() / prompt;
+        }).tags(tags).register(registry);
+        registerSlotGauges(registry, tags);
+
         Gauge.builder("gollek.gguf.coalesce.batch.avg", () -> {
             long count = coalesceBatches.get();
             return (count == 0) ? 0.0 : (double) coalesceBatchTotal.get() / count;
@@ -157,6 +194,109 @@ public class LlamaCppMetricsRecorder {
         } while (!coalesceSeqMaxObserved.compareAndSet(previous, seqCount));
     }
 
+    /**
+     * Attach per-slot KV occupancy; call before {@link #registerMetrics} so the slot
+     * gauges are registered with the rest.
+     */
+    public void setSlotStats(LlamaCppSlotStats slotStats) {
+        this.slotStats = slotStats;
+    }
+
+    public LlamaCppSlotStats getSlotStats() {
+        return slotStats;
+    }
+
+    private void registerSlotGauges(MeterRegistry registry, Tags tags) {
+        LlamaCppSlotStats stats = slotStats;
+        if (stats == null) {
+            return;
+        }
+        Gauge.builder("gollek.gguf.kv_cache.used_tokens", stats, LlamaCppSlotStats::totalTokens)
+                .tags(tags).register(registry);
+        Gauge.builder("gollek.gguf.kv_cache.evictions", stats, LlamaCppSlotStats::totalEvictions)
+                .tags(tags).register(registry);
+        Gauge.builder("gollek.gguf.kv_cache.evicted_tokens", stats, LlamaCppSlotStats::totalEvictedTokens)
+                .tags(tags).register(registry);
+        for (int i = 0; i < stats.size(); i++) {
+            int slot = i;
+            Gauge.builder("gollek.gguf.slot.tokens", stats, s -> s.tokens(slot))
+                    .tags(tags.and("slot", String.valueOf(slot))).register(registry);
+        }
+    }
+
+    /**
+     * Record the load-time KV cache estimate.
+     */
+    public void recordKvCacheEstimate(LlamaCppKVCacheEstimator.Estimate estimate) {
+        kvCacheEstimatedBytes.set(estimate == null ? 0 : estimate.totalBytes());
+    }
+
+    /**
+     * Record one continuous-batching decode step.
+     */
+    public void recordBatchStep(int activeSequences, int tokens) {
+        batchingActiveSequences.set(activeSequences);
+        batchingSteps.incrementAndGet();
+        batchingStepTokens.addAndGet(tokens);
+    }
+
+    public void recordActiveSequences(int activeSequences) {
+        batchingActiveSequences.set(activeSequences);
+    }
+
+    /**
+     * Record requests waiting for a sequence, indexed by {@link Priority#ordinal()}.
+     */
+    public void recordQueueDepths(int[] depths) {
+        for (int i = 0; i < depths.length && i < batchingQueueDepths.length(); i++) {
+            batchingQueueDepths.set(i, depths[i]);
+        }
+    }
+
+    public long getQueueDepth(Priority priority) {
+        return batchingQueueDepths.get(priority.ordinal());
+    }
+
+    public void recordPreemption() {
+        batchingPreemptions.incrementAndGet();
+    }
+
+    public AtomicLong getPreemptions() {
+        return batchingPreemptions;
+    }
+
+    /**
+     * Record a request turned away because the runner had no room for it.
+     */
+    public void recordRejection() {
+        rejections.incrementAndGet();
+    }
+
+    public AtomicLong getRejections() {
+        return rejections;
+    }
+
+    /**
+     * Record how many prompt tokens were served from the KV prefix cache.
+     */
+    public void recordPrefixCache(int reusedTokens, int promptTokens) {
+        if (reusedTokens > 0) {
+            prefixCacheHits.incrementAndGet();
+            prefixCacheReusedTokens.addAndGet(reusedTokens);
+        } else {
+            prefixCacheMisses.incrementAndGet();
+        }
+        prefixCachePromptTokens.addAndGet(promptTokens);
+    }
+
+    public AtomicLong getPrefixCacheHits() {
+        return prefixCacheHits;
+    }
+
+    public AtomicLong getPrefixCacheReusedTokens() {
+        return prefixCacheReusedTokens;
+    }
+
     /**
      * Record a dropped coalescing request.
      */
@@ -234,6 +374,20 @@ public class LlamaCppMetricsRecorder {
         coalesceBatchMax.set(0);
         coalesceSeqMaxObserved.set(0);
         coalesceSeqTotal.set(0);
+        kvCacheEstimatedBytes.set(0);
+        batchingActiveSequences.set(0);
+        batchingSteps.set(0);
+        batchingStepTokens.set(0);
+        batchingPreemptions.set(0);
+        rejections.set(0);
+        for (int i = 0; i < batchingQueueDepths.length(); i++) {
+            batchingQueueDepths.set(i, 0);
+        }
+        prefixCacheHits.set(0);
+        prefixCacheMisses.set(0);
+        prefixCacheReusedTokens.set(0);
+        prefixCachePromptTokens.set(0);
+        slotStats = null;
         coalesceMetricsRegistered = false;
         meterRegistry = null;
         runnerTags = null;
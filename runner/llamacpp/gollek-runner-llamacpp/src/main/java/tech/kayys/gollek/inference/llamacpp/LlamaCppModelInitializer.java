@@ -5,6 +5,7 @@ import tech.kayys.gollek.spi.model.ModelManifest;
 import tech.kayys.gollek.spi.model.ModelFormat;
 
 import java.lang.foreign.MemorySegment;
+import java.lang.management.ManagementFactory;
 import java.nio.file.Path;
 import java.nio.file.Files;
 import java.util.Map;
@@ -21,6 +22,7 @@ public class LlamaCppModelInitializer {
 
     private final LlamaCppBinding binding;
     private final LlamaCppProviderConfig providerConfig;
+    private LlamaCppDeviceSupport.Backend backend = LlamaCppDeviceSupport.Backend.CPU;
 
     public LlamaCppModelInitializer(LlamaCppBinding binding, LlamaCppProviderConfig providerConfig) {
         this.binding = binding;
@@ -41,10 +43,14 @@ public class LlamaCppModelInitializer {
         public final String chatTemplate;
         public final int runtimeBatchSize;
         public final int activeGpuLayers;
+        public final LlamaCppKVCacheEstimator.Estimate kvCacheEstimate;
+        /** Backend the model's layers were offloaded to; CPU when none were. */
+        public final LlamaCppDeviceSupport.Backend backend;
 
         public InitializationResult(MemorySegment model, MemorySegment context, int contextSize,
                 int vocabSize, int eosToken, int bosToken, String chatTemplate,
-                int runtimeBatchSize, int activeGpuLayers) {
+                int runtimeBatchSize, int activeGpuLayers, LlamaCppKVCacheEstimator.Estimate kvCacheEstimate,
+                LlamaCppDeviceSupport.Backend backend) {
             this.model = model;
             this.context = context;
             this.contextSize = contextSize;
@@ -54,6 +60,8 @@ public class LlamaCppModelInitializer {
             this.chatTemplate = chatTemplate;
             this.runtimeBatchSize = runtimeBatchSize;
             this.activeGpuLayers = activeGpuLayers;
+            this.kvCacheEstimate = kvCacheEstimate;
+            this.backend = backend;
         }
     }
 
@@ -70,13 +78,18 @@ public class LlamaCppModelInitializer {
 
             suppressNativeLogsIfNeeded();
 
-            ModelConfig config = buildModelConfig(runnerConfig, modelPath);
+            ModelConfig config = selectBackend(buildModelConfig(runnerConfig, modelPath));
+            config = preflight(manifest.modelId(), modelPath, config);
 
             log.infof("Loading GGUF model from: %s", modelPath.toAbsolutePath());
             MemorySegment model = loadModel(modelPath, config);
+            LlamaCppKVCacheEstimator.Estimate kvEstimate = LlamaCppKVCacheEstimator.estimate(
+                    key -> binding.getModelMetadata(model, key), config.contextSize);
+            config = sizeContext(config, kvEstimate, safeFileSize(modelPath));
             MemorySegment context = createContext(modelPath, model, config);
 
-            return buildInitializationResult(model, context, config);
+            return buildInitializationResult(model, context, config,
+                    kvEstimate.withContextSize(config.contextSize));
         } catch (Exception e) {
             log.errorf("GGUF initialization error: %s", e.getMessage());
             throw new RuntimeException("Failed to initialize GGUF runner: " + e.getMessage(), e);
@@ -148,6 +161,97 @@ public class LlamaCppModelInitializer {
                 useMlock);
     }
 
+    /**
+     * Compare the KV cache estimate with the memory budget; warn, or shrink {@code n_ctx}
+     * when {@code context.auto-size} is enabled.
+     */
+    private ModelConfig sizeContext(ModelConfig config, LlamaCppKVCacheEstimator.Estimate estimate, long modelBytes) {
+        if (!estimate.known()) {
+            log.debugf("KV cache estimate unavailable for this model; keeping n_ctx=%d", config.contextSize);
+            return config;
+        }
+        long budget = kvCacheBudget(config, modelBytes);
+        log.infof("Estimated KV cache: %.1f MiB for n_ctx=%d (%d bytes/token), budget %s",
+                estimate.totalBytes() / (1024.0 * 1024.0), config.contextSize, estimate.bytesPerToken(),
+                budget > 0 ? String.format("%.1f MiB", budget / (1024.0 * 1024.0)) : "unknown");
+        if (budget <= 0 || estimate.totalBytes() <= budget) {
+            return config;
+        }
+        int fitted = LlamaCppKVCacheEstimator.fitContext(estimate.bytesPerToken(), budget, config.contextSize);
+        if (!providerConfig.contextAutoSize()) {
+            log.warnf("KV cache for n_ctx=%d may not fit in available memory; n_ctx=%d would fit. "
+                    + "Set gguf.provider.context.auto-size=true to reduce it automatically.",
+                    config.contextSize, fitted);
+            return config;
+        }
+        log.warnf("Reducing n_ctx from %d to %d so the KV cache fits in available memory", config.contextSize, fitted);
+        return new ModelConfig(config.gpuLayers, config.threads, fitted, config.batchSize,
+                config.useMmap, config.useMlock);
+    }
+
+    /**
+     * Refuse the load when the weights, KV cache and compute buffers would not fit in free
+     * memory. With {@code context.auto-size} a smaller context that fits is used instead.
+     */
+    private ModelConfig preflight(String modelId, Path modelPath, ModelConfig config) {
+        if (!providerConfig.memoryPreflightEnabled()) {
+            return config;
+        }
+        Map<String, Object> metadata;
+        try {
+            metadata = LlamaCppMemoryPreflight.readMetadata(modelPath);
+        } catch (Exception e) {
+            log.debugf("Skipping memory preflight for %s: %s", modelPath, e.getMessage());
+            return config;
+        }
+        LlamaCppKVCacheEstimator.Estimate kv = LlamaCppKVCacheEstimator.estimate(
+                key -> metadata.get(key) == null ? null : String.valueOf(metadata.get(key)), config.contextSize);
+        long freeDevice = backend == LlamaCppDeviceSupport.Backend.CPU ? -1 : freeDeviceMemory();
+        LlamaCppMemoryPreflight.Verdict verdict = LlamaCppMemoryPreflight.check(safeFileSize(modelPath), kv,
+                config.gpuLayers, LlamaCppMemoryPreflight.availableHostMemory(providerConfig.maxMemoryBytes()),
+                freeDevice, providerConfig.memoryPreflightHeadroomBytes());
+        if (verdict.fits()) {
+            return config;
+        }
+        if (providerConfig.contextAutoSize() && verdict.suggestedContext() > 0) {
+            log.warnf("Reducing n_ctx from %d to %d so %s fits in available memory",
+                    config.contextSize, verdict.suggestedContext(), modelId);
+            return new ModelConfig(config.gpuLayers, config.threads, verdict.suggestedContext(), config.batchSize,
+                    config.useMmap, config.useMlock);
+        }
+        throw new IllegalStateException(verdict.message(modelId, config.contextSize, config.gpuLayers));
+    }
+
+    /** Free memory summed over the selected backend's devices, or -1 if none can report it. */
+    private long freeDeviceMemory() {
+        long total = -1;
+        for (LlamaCppBinding.BackendDevice device : LlamaCppDeviceSupport.devicesOf(binding, backend)) {
+            long free = binding.deviceFreeMemory(device);
+            if (free >= 0) {
+                total = Math.max(0, total) + free;
+            }
+        }
+        return total;
+    }
+
+    /**
+     * Memory available for the KV cache: the configured limit minus the weights, or free
+     * RAM on CPU. VRAM cannot be queried, so GPU offload without a limit is unbounded.
+     */
+    private long kvCacheBudget(ModelConfig config, long modelBytes) {
+        long max = providerConfig.maxMemoryBytes();
+        if (max > 0) {
+            return Math.max(1, max - Math.max(0, modelBytes));
+        }
+        if (config.gpuLayers != 0) {
+            return 0;
+        }
+        if (ManagementFactory.getOperatingSystemMXBean() instanceof com.sun.management.OperatingSystemMXBean os) {
+            return os.getFreeMemorySize();
+        }
+        return 0;
+    }
+
     private int adjustGpuLayersForLargeModel(int configuredGpuLayers, long modelSizeBytes) {
         boolean forceGpuForLargeModel = Boolean.parseBoolean(
                 System.getProperty(
@@ -164,10 +268,33 @@ public class LlamaCppModelInitializer {
         return configuredGpuLayers;
     }
 
+    /**
+     * Pick the offload backend from {@code gpu.backend} and the devices the library
+     * registered; a CPU choice drops any requested GPU layers.
+     */
+    private ModelConfig selectBackend(ModelConfig config) {
+        if (config.gpuLayers == 0) {
+            backend = LlamaCppDeviceSupport.Backend.CPU;
+            return config;
+        }
+        var available = LlamaCppDeviceSupport.detectBackends(binding);
+        backend = LlamaCppDeviceSupport.selectBackend(providerConfig, available);
+        log.infof("llama.cpp backends available: %s; offloading to %s",
+                available.isEmpty() ? "unknown" : available, backend);
+        if (backend != LlamaCppDeviceSupport.Backend.CPU) {
+            return config;
+        }
+        return new ModelConfig(0, config.threads, config.contextSize, config.batchSize,
+                config.useMmap, config.useMlock);
+    }
+
     private MemorySegment loadModel(Path modelPath, ModelConfig config) {
         MemorySegment modelParams = binding.getDefaultModelParams();
         binding.setModelParam(modelParams, "n_gpu_layers", config.gpuLayers);
         binding.setModelParam(modelParams, "main_gpu", providerConfig.gpuDeviceId());
+        if (backend != LlamaCppDeviceSupport.Backend.CPU) {
+            binding.setModelDevices(modelParams, LlamaCppDeviceSupport.devicesOf(binding, backend));
+        }
         binding.setModelParam(modelParams, "use_mmap", config.useMmap);
         binding.setModelParam(modelParams, "use_direct_io", false);
         binding.setModelParam(modelParams, "use_mlock", config.useMlock);
@@ -184,7 +311,7 @@ public class LlamaCppModelInitializer {
         binding.setContextParam(contextParams, "n_ctx", config.contextSize);
         binding.setContextParam(contextParams, "n_batch", config.batchSize);
         binding.setContextParam(contextParams, "n_ubatch", config.batchSize);
-        binding.setContextParam(contextParams, "n_seq_max", Math.max(1, providerConfig.coalesceSeqMax()));
+        binding.setContextParam(contextParams, "n_seq_max", Math.max(1, providerConfig.sequenceSlots()));
         binding.setContextParam(contextParams, "n_threads", config.threads);
         binding.setContextParam(contextParams, "n_threads_batch", config.threads);
         binding.setContextParam(contextParams, "offload_kqv", config.gpuLayers != 0);
@@ -208,6 +335,7 @@ public class LlamaCppModelInitializer {
     private MemorySegment createContextOnCpu(Path modelPath, MemorySegment model, ModelConfig config,
             RuntimeException gpuError) {
         binding.freeModel(model);
+        backend = LlamaCppDeviceSupport.Backend.CPU;
 
         MemorySegment cpuModelParams = binding.getDefaultModelParams();
         binding.setModelParam(cpuModelParams, "n_gpu_layers", 0);
@@ -228,7 +356,7 @@ public class LlamaCppModelInitializer {
         binding.setContextParam(contextParams, "n_ctx", config.contextSize);
         binding.setContextParam(contextParams, "n_batch", config.batchSize);
         binding.setContextParam(contextParams, "n_ubatch", config.batchSize);
-        binding.setContextParam(contextParams, "n_seq_max", Math.max(1, providerConfig.coalesceSeqMax()));
+        binding.setContextParam(contextParams, "n_seq_max", Math.max(1, providerConfig.sequenceSlots()));
         binding.setContextParam(contextParams, "n_threads", config.threads);
         binding.setContextParam(contextParams, "n_threads_batch", config.threads);
         binding.setContextParam(contextParams, "offload_kqv", false);
@@ -240,7 +368,7 @@ public class LlamaCppModelInitializer {
     }
 
     private InitializationResult buildInitializationResult(MemorySegment model, MemorySegment context,
-            ModelConfig config) {
+            ModelConfig config, LlamaCppKVCacheEstimator.Estimate kvEstimate) {
         int contextSize = binding.getContextSize(context);
         int vocabSize = binding.getVocabSize(model);
         int eosToken = binding.getEosToken(model);
@@ -249,8 +377,8 @@ public class LlamaCppModelInitializer {
 
         log.debugf("Loaded chat template: %s", chatTemplate != null ? "Yes" : "No");
         log.debugf("Model initialized: ctx=%d vocab=%d eos=%d bos=%d", contextSize, vocabSize, eosToken, bosToken);
-        log.infof("GGUF runtime config: gpu_layers=%d, n_ctx=%d, n_batch=%d, threads=%d",
-                config.gpuLayers, config.contextSize, config.batchSize, config.threads);
+        log.infof("GGUF runtime config: backend=%s, gpu_layers=%d, n_ctx=%d, n_batch=%d, threads=%d",
+                backend.id(), config.gpuLayers, config.contextSize, config.batchSize, config.threads);
 
         return new InitializationResult(
                 model,
@@ -261,7 +389,9 @@ public class LlamaCppModelInitializer {
                 bosToken,
                 chatTemplate,
                 config.batchSize,
-                config.gpuLayers);
+                backend == LlamaCppDeviceSupport.Backend.CPU ? 0 : config.gpuLayers,
+                kvEstimate,
+                backend);
     }
 
     private boolean hasGgufHeader(Path path) {
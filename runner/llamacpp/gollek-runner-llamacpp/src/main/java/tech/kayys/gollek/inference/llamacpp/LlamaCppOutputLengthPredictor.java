@@ -0,0 +1,68 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.Map;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Online estimate of how many tokens a request will generate, for shortest-job-first
+ * ordering of the batch queue. Requests are bucketed by prompt size (powers of two of its
+ * characters) and by whether the output is constrained (JSON mode or a grammar); each
+ * bucket keeps an exponentially weighted mean of what its completed requests produced.
+ * A bucket without history falls back to the mean over all requests, and with no history
+ * at all the request is assumed to use its whole {@code max_tokens}. Estimates never
+ * exceed {@code max_tokens}.
+ *
+ * <p>Completions are recorded from the scheduler worker; estimates are read from
+ * submitting threads.
+ */
+final class LlamaCppOutputLengthPredictor {
+
+    private static final double ALPHA = 0.2;
+    /** The runner's own default when a request sets no limit. */
+    private static final int DEFAULT_MAX_TOKENS = 128;
+
+    record Features(int promptBucket, boolean structured) {
+
+        static Features of(InferenceRequest request) {
+            long chars = 0;
+            Object raw = request.getParameters().get("prompt");
+            if (InferenceLogicExecutor.flag(request, InferenceLogicExecutor.RAW) && raw != null) {
+                chars = raw.toString().length();
+            } else {
+                for (Message message : request.getMessages()) {
+                    chars += message.getContent() == null ? 0 : message.getContent().length();
+                }
+            }
+            int bucket = 64 - Long.numberOfLeadingZeros(chars);
+            boolean structured = request.isJsonMode() || request.getGrammar() != null;
+            return new Features(bucket, structured);
+        }
+    }
+
+    private final Map<Features, Double> means = new ConcurrentHashMap<>();
+    private volatile double overall = -1;
+
+    /** Expected output tokens of {@code request}, at least 1. */
+    int predict(InferenceRequest request) {
+        int maxTokens = maxTokens(request);
+        Double mean = means.get(Features.of(request));
+        double expected = mean != null ? mean : overall >= 0 ? overall : maxTokens;
+        return (int) Math.max(1, Math.min(maxTokens, Math.round(expected)));
+    }
+
+    /** Folds the length of a completed request into its bucket and the overall mean. */
+    void record(InferenceRequest request, int generated) {
+        means.merge(Features.of(request), (double) generated, (old, now) -> old + ALPHA * (now - old));
+        double current = overall;
+        overall = current < 0 ? generated : current + ALPHA * (generated - current);
+    }
+
+    private static int maxTokens(InferenceRequest request) {
+        Object value = request.getParameters().get("max_tokens");
+        int maxTokens = value instanceof Number n ? n.intValue() : DEFAULT_MAX_TOKENS;
+        return Math.max(1, maxTokens);
+    }
+}
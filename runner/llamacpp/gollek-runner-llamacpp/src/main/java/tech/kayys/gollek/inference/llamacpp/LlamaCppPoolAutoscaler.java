@@ -0,0 +1,63 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+/**
+ * Decides when a session pool loads another runner or unloads one. A pool scales up
+ * while requests wait beyond {@code target-queue} per runner or the predicted wait
+ * exceeds {@code target-wait}, and scales down one runner at a time once nothing has
+ * waited for {@code scale-down-after}. Loading a runner takes seconds, so the quiet
+ * period keeps a pool from unloading one it is about to need again.
+ *
+ * <p>Thread-safety: {@link #decide} is synchronized; one instance per pool.
+ */
+final class LlamaCppPoolAutoscaler {
+
+    enum Step {
+        UP, DOWN, HOLD
+    }
+
+    /**
+     * Load of one pool: {@code waiting} counts requests not yet generating, whether
+     * queued on a runner or blocked on the pool's concurrency limit.
+     */
+    record Load(int runners, int waiting, long maxWaitMs) {
+    }
+
+    private final int minRunners;
+    private final int maxRunners;
+    private final int targetQueue;
+    private final long targetWaitMs;
+    private final long scaleDownAfterMs;
+    private long quietSince = -1;
+
+    LlamaCppPoolAutoscaler(LlamaCppProviderConfig config) {
+        this.minRunners = Math.max(1, config.sessionPoolMinSize());
+        this.maxRunners = Math.max(minRunners, config.sessionPoolAutoscaleMaxRunners());
+        this.targetQueue = Math.max(1, config.sessionPoolAutoscaleTargetQueue());
+        this.targetWaitMs = config.sessionPoolAutoscaleTargetWait().toMillis();
+        this.scaleDownAfterMs = config.sessionPoolAutoscaleScaleDownAfter().toMillis();
+    }
+
+    synchronized Step decide(Load load, long nowMs) {
+        if (load.runners() < minRunners) {
+            return Step.UP;
+        }
+        boolean busy = load.waiting() > (long) targetQueue * load.runners() || load.maxWaitMs() > targetWaitMs;
+        if (busy) {
+            quietSince = -1;
+            return load.runners() < maxRunners ? Step.UP : Step.HOLD;
+        }
+        if (load.waiting() > 0) {
+            quietSince = -1;
+            return Step.HOLD;
+        }
+        if (quietSince < 0) {
+            quietSince = nowMs;
+        }
+        if (load.runners() > minRunners && nowMs - quietSince >= scaleDownAfterMs) {
+            // the next runner down has to be quiet for a full period too
+            quietSince = nowMs;
+            return Step.DOWN;
+        }
+        return Step.HOLD;
+    }
+}
@@ -0,0 +1,284 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.function.Consumer;
+import java.util.regex.Matcher;
+import java.util.regex.Pattern;
+
+/**
+ * Clean-up of generated text, for chat models that leak template residue into their
+ * output and for products that render it into web pages. Configured per model
+ * ({@code gguf.provider.post-process}) and adjusted per request with a {@value #PARAMETER}
+ * map of the same switches in snake case, plus {@code replace: [{pattern, replacement}]}
+ * which is appended to the model's list.
+ */
+record LlamaCppPostProcessor(boolean removeArtifacts, List<Replacement> replacements, boolean trimToSentence,
+        Sanitize sanitize, boolean stripLeadingWhitespace) {
+
+    static final String PARAMETER = "post_process";
+
+    static final LlamaCppPostProcessor NONE = new LlamaCppPostProcessor(false, List.of(), false, Sanitize.NONE,
+            false);
+
+    /** End-of-turn markers and role headers of the common chat templates. */
+    private static final Pattern ARTIFACTS = Pattern.compile(
+            "^\\s*(<\\|im_start\\|>assistant\\n?|<\\|start_header_id\\|>assistant<\\|end_header_id\\|>\\s*"
+                    + "|<start_of_turn>model\\n?)"
+                    + "|<\\|im_end\\|>|<\\|im_start\\|>|<\\|eot_id\\|>|<\\|end_of_text\\|>|<\\|endoftext\\|>"
+                    + "|<\\|end\\|>|<end_of_turn>|</s>");
+
+    /** A sentence end, with any closing quotes or brackets that belong to it. */
+    private static final Pattern SENTENCE_END = Pattern.compile("[.!?。！？][\"'”’)\\]]*(?=\\s|$)");
+
+    /** Elements whose content is code, dropped whole; an unclosed one runs to the end. */
+    private static final Pattern ACTIVE_BLOCK = Pattern.compile(
+            "<(script|style|iframe|object)\\b[^>]*>.*?(</\\1\\s*>|\\z)", Pattern.CASE_INSENSITIVE | Pattern.DOTALL);
+    private static final Pattern ACTIVE_OPEN = Pattern.compile("<(script|style|iframe|object)\\b",
+            Pattern.CASE_INSENSITIVE);
+    private static final Pattern TAG = Pattern.compile("<[a-zA-Z/!][^>]*>");
+    /** Markdown link and image targets a renderer would execute. */
+    private static final Pattern SCRIPT_LINK = Pattern.compile(
+            "\\]\\(\\s*(javascript|vbscript|data):([^()]|\\([^()]*\\))*\\)",
+            Pattern.CASE_INSENSITIVE);
+
+    record Replacement(Pattern pattern, String replacement) {
+    }
+
+    enum Sanitize {
+        NONE, STRIP, ESCAPE;
+
+        static Sanitize of(String value) {
+            try {
+                return value == null ? NONE : valueOf(value.trim().toUpperCase(Locale.ROOT));
+            } catch (IllegalArgumentException e) {
+                throw new IllegalArgumentException("Unknown sanitize mode: " + value + " (expected none, strip or escape)");
+            }
+        }
+    }
+
+    /** The transforms configured for {@code modelId}, or {@link #NONE}. */
+    static LlamaCppPostProcessor forModel(LlamaCppProviderConfig config, String modelId) {
+        Map<String, LlamaCppProviderConfig.PostProcess> models = config.postProcess();
+        if (modelId == null || models == null || !models.containsKey(modelId)) {
+            return NONE;
+        }
+        LlamaCppProviderConfig.PostProcess model = models.get(modelId);
+        List<Replacement> replacements = new ArrayList<>();
+        for (LlamaCppProviderConfig.Replacement replacement : model.replacements().orElse(List.of())) {
+            replacements.add(new Replacement(Pattern.compile(replacement.pattern()),
+                    replacement.replacement().orElse("")));
+        }
+        return new LlamaCppPostProcessor(model.removeArtifacts(), List.copyOf(replacements), model.trimToSentence(),
+                Sanitize.of(model.sanitize()), model.stripLeadingWhitespace());
+    }
+
+    /**
+     * These transforms with the request's {@value #PARAMETER} overrides applied.
+     *
+     * @throws IllegalArgumentException if a requested pattern does not compile or the
+     *                                  sanitize mode is unknown
+     */
+    LlamaCppPostProcessor forRequest(InferenceRequest request) {
+        if (!(request.getParameters().get(PARAMETER) instanceof Map<?, ?> overrides)) {
+            return this;
+        }
+        List<Replacement> merged = new ArrayList<>(replacements);
+        if (overrides.get("replace") instanceof List<?> requested) {
+            for (Object entry : requested) {
+                if (entry instanceof Map<?, ?> replacement && replacement.get("pattern") != null) {
+                    Object with = replacement.get("replacement");
+                    merged.add(new Replacement(Pattern.compile(String.valueOf(replacement.get("pattern"))),
+                            with == null ? "" : String.valueOf(with)));
+                }
+            }
+        }
+        return new LlamaCppPostProcessor(
+                flag(overrides, "remove_artifacts", removeArtifacts),
+                List.copyOf(merged),
+                flag(overrides, "trim_to_sentence", trimToSentence),
+                overrides.get("sanitize") == null ? sanitize : Sanitize.of(String.valueOf(overrides.get("sanitize"))),
+                flag(overrides, "strip_leading_whitespace", stripLeadingWhitespace));
+    }
+
+    private static boolean flag(Map<?, ?> overrides, String name, boolean fallback) {
+        Object value = overrides.get(name);
+        return value == null ? fallback : Boolean.parseBoolean(String.valueOf(value));
+    }
+
+    boolean isEmpty() {
+        return !removeArtifacts && replacements.isEmpty() && !trimToSentence && sanitize == Sanitize.NONE
+                && !stripLeadingWhitespace;
+    }
+
+    /** {@code response} with its content transformed; token counts are left as generated. */
+    InferenceResponse apply(InferenceResponse response) {
+        if (response == null || response.getContent() == null || isEmpty()) {
+            return response;
+        }
+        String content = apply(response.getContent(), response.getFinishReason());
+        return content.equals(response.getContent()) ? response : response.toBuilder().content(content).build();
+    }
+
+    String apply(String text, InferenceResponse.FinishReason finishReason) {
+        if (removeArtifacts) {
+            text = ARTIFACTS.matcher(text).replaceAll("");
+        }
+        for (Replacement replacement : replacements) {
+            text = replacement.pattern().matcher(text).replaceAll(replacement.replacement());
+        }
+        // only cut text the model did not get to finish; a natural stop is left alone
+        if (trimToSentence && (finishReason == InferenceResponse.FinishReason.LENGTH
+                || finishReason == InferenceResponse.FinishReason.TIME_LIMIT)) {
+            Matcher matcher = SENTENCE_END.matcher(text);
+            int end = -1;
+            while (matcher.find()) {
+                end = matcher.end();
+            }
+            if (end > 0) {
+                text = text.substring(0, end);
+            }
+        }
+        text = sanitize(text);
+        return stripLeadingWhitespace ? text.stripLeading() : text;
+    }
+
+    String sanitize(String text) {
+        if (sanitize == Sanitize.NONE) {
+            return text;
+        }
+        text = SCRIPT_LINK.matcher(text).replaceAll("](#)");
+        if (sanitize == Sanitize.STRIP) {
+            return TAG.matcher(ACTIVE_BLOCK.matcher(text).replaceAll("")).replaceAll("");
+        }
+        StringBuilder sb = new StringBuilder(text.length());
+        for (int i = 0; i < text.length(); i++) {
+            char c = text.charAt(i);
+            switch (c) {
+                case '<' -> sb.append("&lt;");
+                case '>' -> sb.append("&gt;");
+                case '&' -> sb.append("&amp;");
+                case '"' -> sb.append("&quot;");
+                case '\'' -> sb.append("&#39;");
+                default -> sb.append(c);
+            }
+        }
+        return sb.toString();
+    }
+
+    /**
+     * A token consumer that applies the transforms that work on partial output (sanitizing
+     * and leading whitespace) before passing text on. Text that may still turn out to be
+     * a tag or link is held back until it is complete or {@link StreamFilter#finish()}.
+     * Log probabilities travel with the next text passed on when {@code downstream} is a
+     * {@link LlamaCppLogprobs.Sink}.
+     */
+    StreamFilter stream(Consumer<String> downstream) {
+        return new StreamFilter(downstream);
+    }
+
+    final class StreamFilter implements LlamaCppLogprobs.Sink {
+
+        private final Consumer<String> downstream;
+        private final StringBuilder raw = new StringBuilder();
+        private final List<LlamaCppLogprobs.Entry> logprobs = new ArrayList<>();
+        private int emitted;
+
+        private StreamFilter(Consumer<String> downstream) {
+            this.downstream = downstream;
+        }
+
+        @Override
+        public void accept(String piece, List<LlamaCppLogprobs.Entry> pieceLogprobs) {
+            logprobs.addAll(pieceLogprobs);
+            accept(piece);
+        }
+
+        @Override
+        public void accept(String piece) {
+            if (stripLeadingWhitespace && raw.isEmpty()) {
+                piece = piece.stripLeading();
+            }
+            if (piece.isEmpty()) {
+                return;
+            }
+            if (sanitize == Sanitize.NONE) {
+                raw.append(piece);
+                forward(piece);
+                return;
+            }
+            raw.append(piece);
+            emit(safeEnd());
+        }
+
+        /** Passes on whatever was held back. */
+        void finish() {
+            if (sanitize != Sanitize.NONE) {
+                emit(raw.length());
+            }
+            if (!logprobs.isEmpty() && downstream instanceof LlamaCppLogprobs.Sink) {
+                forward("");
+            }
+        }
+
+        private int safeEnd() {
+            int end = raw.length();
+            int link = raw.lastIndexOf("](");
+            if (link >= 0 && !closes(link + 1)) {
+                end = link;
+            }
+            if (sanitize == Sanitize.STRIP) {
+                int open = raw.lastIndexOf("<");
+                if (open >= 0 && raw.indexOf(">", open) < 0) {
+                    end = Math.min(end, open);
+                }
+                Matcher active = ACTIVE_OPEN.matcher(raw);
+                while (active.find()) {
+                    String name = active.group(1).toLowerCase(Locale.ROOT);
+                    if (raw.toString().toLowerCase(Locale.ROOT).indexOf("</" + name, active.end()) < 0) {
+                        end = Math.min(end, active.start());
+                        break;
+                    }
+                }
+            }
+            return end;
+        }
+
+        /** Whether the bracket at {@code open} has its match, allowing nested pairs. */
+        private boolean closes(int open) {
+            int depth = 0;
+            for (int i = open; i < raw.length(); i++) {
+                char c = raw.charAt(i);
+                if (c == '(') {
+                    depth++;
+                } else if (c == ')' && --depth == 0) {
+                    return true;
+                }
+            }
+            return false;
+        }
+
+        private void forward(String text) {
+            if (logprobs.isEmpty() || !(downstream instanceof LlamaCppLogprobs.Sink sink)) {
+                downstream.accept(text);
+                return;
+            }
+            List<LlamaCppLogprobs.Entry> covered = List.copyOf(logprobs);
+            logprobs.clear();
+            sink.accept(text, covered);
+        }
+
+        private void emit(int end) {
+            String clean = sanitize(raw.substring(0, end));
+            if (clean.length() > emitted) {
+                forward(clean.substring(emitted));
+                emitted = clean.length();
+            }
+        }
+    }
+}
@@ -0,0 +1,199 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.jboss.logging.Logger;
+import tech.kayys.gollek.spi.model.ModelManifest;
+
+import java.io.IOException;
+import java.lang.foreign.MemorySegment;
+import java.nio.ByteBuffer;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.StandardCopyOption;
+import java.nio.file.attribute.FileTime;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.Comparator;
+import java.util.HexFormat;
+import java.util.List;
+import java.util.stream.Stream;
+
+/**
+ * Sequence state of prompt prefixes on disk, one file per prefix named by the SHA-256 of
+ * its tokens, so warm system prompts survive a restart. A file's modification time is
+ * its last use: hits touch it, and once the directory holds more than
+ * {@code prompt-cache.max-entries} files or {@code prompt-cache.max-bytes}, the least
+ * recently used go.
+ */
+final class LlamaCppPromptCache {
+
+    private static final Logger log = Logger.getLogger(LlamaCppPromptCache.class);
+
+    static final String SUFFIX = ".kv";
+
+    private final LlamaCppBinding binding;
+    private final Path dir;
+    private final long maxBytes;
+    private final int maxEntries;
+    private final int minTokens;
+
+    LlamaCppPromptCache(LlamaCppBinding binding, Path dir, long maxBytes, int maxEntries, int minTokens) {
+        this.binding = binding;
+        this.dir = dir;
+        this.maxBytes = maxBytes;
+        this.maxEntries = maxEntries;
+        this.minTokens = Math.max(1, minTokens);
+    }
+
+    /** The cache for {@code manifest}'s model, or null when disabled. */
+    static LlamaCppPromptCache forModel(LlamaCppBinding binding, LlamaCppProviderConfig config,
+            ModelManifest manifest) {
+        if (!config.promptCacheEnabled() || !config.prefixCacheEnabled()) {
+            return null;
+        }
+        String base = config.promptCacheDir().filter(d -> !d.isBlank())
+                .orElse(System.getProperty("user.home") + "/.gollek/cache/gguf/prompts");
+        String safeModel = manifest == null ? "unknown" : manifest.modelId().replace('/', '_');
+        return new LlamaCppPromptCache(binding, Path.of(base, safeModel), config.promptCacheMaxBytes(),
+                config.promptCacheMaxEntries(), config.promptCacheMinTokens());
+    }
+
+    /** Whether a prefix of {@code length} tokens is long enough to cache. */
+    boolean worthCaching(int length) {
+        return length >= minTokens;
+    }
+
+    /** Hex SHA-256 of the first {@code length} tokens. */
+    static String key(int[] tokens, int length) {
+        try {
+            MessageDigest digest = MessageDigest.getInstance("SHA-256");
+            ByteBuffer buffer = ByteBuffer.allocate(length * Integer.BYTES);
+            buffer.asIntBuffer().put(tokens, 0, length);
+            return HexFormat.of().formatHex(digest.digest(buffer.array()));
+        } catch (NoSuchAlgorithmException e) {
+            throw new IllegalStateException("SHA-256 unavailable", e);
+        }
+    }
+
+    Path path(int[] tokens, int length) {
+        return dir.resolve(key(tokens, length) + SUFFIX);
+    }
+
+    boolean contains(int[] tokens, int length) {
+        return Files.isRegularFile(path(tokens, length));
+    }
+
+    /**
+     * Loads the cached state of the first {@code length} tokens into sequence 0, which the
+     * caller has cleared. Returns the restored tokens, or an empty array when there is no
+     * entry or it does not load as exactly these tokens, for instance after the model file
+     * changed; such an entry is removed and the caller clears the sequence again.
+     */
+    int[] load(MemorySegment context, int[] tokens, int length) {
+        Path file = path(tokens, length);
+        if (!Files.isRegularFile(file)) {
+            return new int[0];
+        }
+        int[] restored = binding.loadSeqState(context, 0, file, length);
+        if (!Arrays.equals(restored, 0, restored.length, tokens, 0, length)) {
+            log.warnf("Prompt cache entry %s does not fit this model and prompt; removing it", file.getFileName());
+            delete(file);
+            return new int[0];
+        }
+        touch(file);
+        return restored;
+    }
+
+    /**
+     * Writes sequence 0, which holds exactly the first {@code length} tokens, as their
+     * entry, then evicts down to the limits. Returns false if the state could not be saved.
+     */
+    boolean store(MemorySegment context, int[] tokens, int length) {
+        Path file = path(tokens, length);
+        Path partial = file.resolveSibling(file.getFileName() + ".tmp");
+        try {
+            Files.createDirectories(dir);
+            if (!binding.saveSeqState(context, 0, partial, tokens, length)) {
+                delete(partial);
+                return false;
+            }
+            // readers never see a half-written entry
+            Files.move(partial, file, StandardCopyOption.REPLACE_EXISTING, StandardCopyOption.ATOMIC_MOVE);
+            touch(file);
+        } catch (IOException | RuntimeException e) {
+            log.warnf("Failed to cache prompt state in %s: %s", dir, e.getMessage());
+            delete(partial);
+            return false;
+        }
+        evict();
+        return true;
+    }
+
+    /** Removes least recently used entries until both limits hold. */
+    void evict() {
+        List<Path> entries = entries();
+        long total = 0;
+        for (Path entry : entries) {
+            total += size(entry);
+        }
+        int count = entries.size();
+        for (Path entry : entries) {
+            if (count <= maxEntries && total <= maxBytes) {
+                break;
+            }
+            total -= size(entry);
+            count--;
+            delete(entry);
+            log.debugf("Evicted prompt cache entry %s", entry.getFileName());
+        }
+    }
+
+    /** Entries, least recently used first. */
+    List<Path> entries() {
+        if (!Files.isDirectory(dir)) {
+            return List.of();
+        }
+        try (Stream<Path> files = Files.list(dir)) {
+            List<Path> entries = new ArrayList<>(files.filter(f -> f.getFileName().toString().endsWith(SUFFIX))
+                    .toList());
+            entries.sort(Comparator.comparing(LlamaCppPromptCache::lastUsed));
+            return entries;
+        } catch (IOException e) {
+            log.warnf("Failed to list prompt cache %s: %s", dir, e.getMessage());
+            return List.of();
+        }
+    }
+
+    private static FileTime lastUsed(Path file) {
+        try {
+            return Files.getLastModifiedTime(file);
+        } catch (IOException e) {
+            return FileTime.fromMillis(0);
+        }
+    }
+
+    private static long size(Path file) {
+        try {
+            return Files.size(file);
+        } catch (IOException e) {
+            return 0;
+        }
+    }
+
+    private static void touch(Path file) {
+        try {
+            Files.setLastModifiedTime(file, FileTime.fromMillis(System.currentTimeMillis()));
+        } catch (IOException ignored) {
+            // only the eviction order suffers
+        }
+    }
+
+    private static void delete(Path file) {
+        try {
+            Files.deleteIfExists(file);
+        } catch (IOException ignored) {
+            // evicted again on the next store
+        }
+    }
+}
@@ -0,0 +1,125 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.Arrays;
+import java.util.HashMap;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Set;
+import java.util.function.IntFunction;
+
+/**
+ * Heuristic prompt compression in the spirit of LLMLingua, without a second model: the
+ * tokens that carry least information are pruned from the middle of a long prompt until
+ * it is down to the target ratio. Runs of whitespace and repeated punctuation go first,
+ * then common function words. The head (system prompt) and tail (question and open
+ * assistant turn) are kept whole, as are special tokens.
+ *
+ * <p>{@value #PARAMETER} on a request overrides the configured switch: {@code true},
+ * {@code false}, or a keep ratio such as {@code 0.5}.
+ */
+final class LlamaCppPromptCompressor {
+
+    static final String PARAMETER = "compress_prompt";
+    /** Response metadata with {@code original_tokens} and {@code compressed_tokens}. */
+    static final String METADATA = "prompt_compression";
+
+    private static final Set<String> FILLER = Set.of(
+            "the", "a", "an", "of", "to", "is", "are", "was", "were", "be", "been", "that", "this", "these",
+            "those", "very", "really", "just", "quite", "actually", "basically", "also", "so", "then", "which",
+            "and", "or", "in", "on", "at", "for", "with", "as", "by", "it", "its");
+
+    private final LlamaCppProviderConfig config;
+    private final IntFunction<String> pieces;
+
+    LlamaCppPromptCompressor(LlamaCppProviderConfig config, IntFunction<String> pieces) {
+        this.config = config;
+        this.pieces = pieces;
+    }
+
+    /** {@code tokens}, pruned if compression applies to the request; otherwise the same array. */
+    int[] compress(InferenceRequest request, int[] tokens) {
+        float ratio = ratio(request);
+        if (ratio <= 0f || ratio >= 1f || tokens.length < Math.max(1, config.promptCompressionMinTokens())
+                || InferenceLogicExecutor.flag(request, InferenceLogicExecutor.RAW)) {
+            return tokens;
+        }
+        int head = Math.max(0, config.promptCompressionKeepHead());
+        int tail = Math.max(0, config.promptCompressionKeepTail());
+        if (head + tail >= tokens.length) {
+            return tokens;
+        }
+        int excess = tokens.length - (int) Math.ceil(tokens.length * ratio);
+        Map<Integer, String> cache = new HashMap<>();
+        IntFunction<String> piece = token -> cache.computeIfAbsent(token, t -> {
+            String p = pieces.apply(t);
+            return p == null ? "" : p;
+        });
+        boolean[] drop = new boolean[tokens.length];
+        int end = tokens.length - tail;
+
+        // first pass: a whitespace or punctuation token repeating the one before it adds nothing
+        for (int i = Math.max(head, 1); i < end && excess > 0; i++) {
+            String p = piece.apply(tokens[i]);
+            if (!special(p) && isBlankOrPunctuation(p) && (tokens[i] == tokens[i - 1]
+                    || (p.isBlank() && piece.apply(tokens[i - 1]).isBlank()))) {
+                drop[i] = true;
+                excess--;
+            }
+        }
+        // second pass: function words, which the model can infer back from context
+        for (int i = head; i < end && excess > 0; i++) {
+            if (!drop[i] && FILLER.contains(piece.apply(tokens[i]).strip().toLowerCase(Locale.ROOT))) {
+                drop[i] = true;
+                excess--;
+            }
+        }
+        int kept = 0;
+        int[] out = new int[tokens.length];
+        for (int i = 0; i < tokens.length; i++) {
+            if (!drop[i]) {
+                out[kept++] = tokens[i];
+            }
+        }
+        return kept == tokens.length ? tokens : Arrays.copyOf(out, kept);
+    }
+
+    private float ratio(InferenceRequest request) {
+        Object value = request.getParameters().get(PARAMETER);
+        float configured = config.promptCompressionTargetRatio();
+        if (value == null) {
+            return config.promptCompressionEnabled() ? configured : 0f;
+        }
+        if (value instanceof Number n) {
+            return n.floatValue();
+        }
+        String text = String.valueOf(value).trim();
+        if (text.equalsIgnoreCase("true")) {
+            return configured;
+        }
+        if (text.equalsIgnoreCase("false")) {
+            return 0f;
+        }
+        try {
+            return Float.parseFloat(text);
+        } catch (NumberFormatException e) {
+            return 0f;
+        }
+    }
+
+    /** Control tokens spell as nothing or as a bracketed marker. */
+    private static boolean special(String piece) {
+        return piece == null || piece.isEmpty() || (piece.startsWith("<") && piece.endsWith(">") && piece.length() > 2)
+                || (piece.startsWith("[") && piece.endsWith("]") && piece.length() > 2);
+    }
+
+    private static boolean isBlankOrPunctuation(String piece) {
+        for (int i = 0; i < piece.length(); i++) {
+            if (Character.isLetterOrDigit(piece.charAt(i))) {
+                return false;
+            }
+        }
+        return true;
+    }
+}
@@ -30,6 +30,8 @@ import tech.kayys.gollek.spi.provider.ProviderHealth;
 import tech.kayys.gollek.spi.provider.ProviderMetadata;
 import tech.kayys.gollek.spi.provider.ProviderMetrics;
 import tech.kayys.gollek.spi.provider.ProviderRequest;
+import tech.kayys.gollek.spi.provider.ReloadableProvider;
+import tech.kayys.gollek.spi.provider.TokenizingProvider;
 import tech.kayys.gollek.spi.inference.InferenceRequest;
 import tech.kayys.gollek.spi.inference.InferenceResponse;
 import tech.kayys.gollek.spi.Message;
@@ -70,7 +72,7 @@ import java.util.concurrent.atomic.AtomicBoolean;
  * @see LoraAdapterManager
  */
 @ApplicationScoped
-public class LlamaCppProvider implements StreamingProvider {
+public class LlamaCppProvider implements StreamingProvider, ReloadableProvider, TokenizingProvider {
 
     private static final Logger log = Logger.getLogger(LlamaCppProvider.class);
     private static final String PROVIDER_ID = "gguf";
@@ -135,6 +137,8 @@ public class LlamaCppProvider implements StreamingProvider {
 
     private ProviderMetadata metadata;
     private ProviderCapabilities capabilities;
+    private volatile Set<LlamaCppDeviceSupport.Backend> availableBackends = Set.of();
+    private volatile Map<String, Object> buildInfo = Map.of();
     private static final String ADAPTER_PROVIDER_TAG = "gguf";
 
     void onStart(@Observes StartupEvent event) {
@@ -197,7 +201,10 @@ public class LlamaCppProvider implements StreamingProvider {
         }
 
         if (this.capabilities == null) {
-            boolean gpuActive = LlamaCppDeviceSupport.effectiveGpuEnabled(config);
+            availableBackends = LlamaCppDeviceSupport.detectBackends(binding);
+            buildInfo = LlamaCppBuildInfo.describe(binding);
+            boolean gpuActive = LlamaCppDeviceSupport.selectBackend(config, availableBackends)
+                    != LlamaCppDeviceSupport.Backend.CPU;
             var features = new java.util.LinkedHashSet<>(Set.of(
                     "local_inference",
                     "cpu_inference",
@@ -221,7 +228,7 @@ public class LlamaCppProvider implements StreamingProvider {
                     .maxContextTokens(config.maxContextTokens())
                     .maxOutputTokens(config.maxContextTokens() / 2)
                     .supportedFormats(Set.of(ModelFormat.GGUF))
-                    .supportedDevices(LlamaCppDeviceSupport.supportedDevices(config))
+                    .supportedDevices(LlamaCppDeviceSupport.supportedDevices(config, availableBackends))
                     .supportedLanguages(java.util.List.of("en"))
                     .features(Set.copyOf(features))
                     .build();
@@ -420,16 +427,6 @@ public class LlamaCppProvider implements StreamingProvider {
                 AdapterSpec adapterSpec = resolveAdapterSpec(request, tenantId);
                 InferenceRequest inferenceRequest = convertToInferenceRequest(request, adapterSpec);
                 
-                // Determine hardware acceleration info
-                boolean gpuActive = LlamaCppDeviceSupport.effectiveGpuEnabled(config);
-                String hardware = gpuActive ? "GPU/Metal" : "CPU";
-                int gpuLayers = LlamaCppDeviceSupport.resolveGpuLayers(config);
-                if (gpuActive && gpuLayers != 0) {
-                    hardware += " (layers: " + (gpuLayers == -1 ? "all" : gpuLayers) + ")";
-                }
-                
-                final Map<String, Object> metadata = Map.of("hardware", hardware);
-
                 Instant adapterAcquireStart = Instant.now();
 
                 sessionContext = sessionManager.getSession(
@@ -448,12 +445,18 @@ public class LlamaCppProvider implements StreamingProvider {
 
                 // Final session context for closure
                 final LlamaCppSessionManager.SessionContext finalSession = sessionContext;
+                final Map<String, Object> metadata = Map.of("hardware", describeHardware(sessionContext.runner()));
 
                 AtomicBoolean first = new AtomicBoolean(true);
                 return sessionContext.runner().inferStream(
                         inferenceRequest)
                         .map(chunk -> {
                             if (first.compareAndSet(true, false)) {
+                                Map<String, Object> merged = metadata;
+                                if (chunk.metadata() != null && !chunk.metadata().isEmpty()) {
+                                    merged = new java.util.HashMap<>(chunk.metadata());
+                                    merged.putAll(metadata);
+                                }
                                 return new StreamingInferenceChunk(
                                     chunk.requestId(),
                                     chunk.index(),
@@ -464,7 +467,7 @@ public class LlamaCppProvider implements StreamingProvider {
                                     chunk.finishReason(),
                                     chunk.usage(),
                                     chunk.emittedAt(),
-                                    metadata
+                                    merged
                                 );
                             }
                             return chunk;
@@ -515,8 +518,15 @@ public class LlamaCppProvider implements StreamingProvider {
                     details.put("version", metadata.getVersion());
                 }
 
+                details.put("backends", availableBackends.stream().map(LlamaCppDeviceSupport.Backend::id).toList());
+                if (!buildInfo.isEmpty()) {
+                    details.put("engine", buildInfo);
+                }
                 if (sessionManager != null) {
                     details.put("active_sessions", sessionManager.getActiveSessionCount());
+                    details.put("slots", sessionManager.describeSlots());
+                    details.put("models", sessionManager.describeBackends());
+                    details.put("stalls", sessionManager.stallStats());
                     if (!sessionManager.isHealthy()) {
                         status = ProviderHealth.Status.DEGRADED;
                         details.put("session_manager", "degraded");
@@ -558,6 +568,52 @@ public class LlamaCppProvider implements StreamingProvider {
         });
     }
 
+    @Override
+    public ModelReload reloadModel(String modelId, String modelPath) {
+        ensureInitialized();
+        return sessionManager.reloadModel(modelId, modelPath);
+    }
+
+    @Override
+    public ModelReload reloadModel(String modelId, String modelPath, ReloadOptions options) {
+        ensureInitialized();
+        return sessionManager.reloadModel(modelId, modelPath, options);
+    }
+
+    @Override
+    public int[] tokenize(String modelId, String text, boolean addSpecial) {
+        return withRunner(modelId, runner -> runner.tokenize(text, addSpecial));
+    }
+
+    @Override
+    public String detokenize(String modelId, int[] tokens) {
+        return withRunner(modelId, runner -> runner.detokenize(tokens));
+    }
+
+    @Override
+    public String tokenPiece(String modelId, int token) {
+        return withRunner(modelId, runner -> runner.tokenPiece(token));
+    }
+
+    /**
+     * Run {@code action} on a session of {@code modelId}, loading the model if needed.
+     */
+    private <T> T withRunner(String modelId, java.util.function.Function<LlamaCppRunner, T> action) {
+        ensureInitialized();
+        if (!supports(modelId, null)) {
+            throw new IllegalArgumentException("Unknown GGUF model: " + modelId);
+        }
+        var session = sessionManager.getSession("tokenizer", modelId, config);
+        if (session == null) {
+            throw new IllegalStateException("Failed to acquire session context for model: " + modelId);
+        }
+        try {
+            return action.apply(session.runner());
+        } finally {
+            sessionManager.releaseSession("tokenizer", modelId, session);
+        }
+    }
+
     @Override
     public Optional<ProviderMetrics> metrics() {
         return Optional.of(metrics);
@@ -595,15 +651,26 @@ public class LlamaCppProvider implements StreamingProvider {
         return System.getProperty("org.graalvm.nativeimage.imagecode") != null;
     }
 
+    /** The runner's backend for response metadata, e.g. {@code CUDA (layers: all)}. */
+    private static String describeHardware(LlamaCppRunner runner) {
+        LlamaCppDeviceSupport.Backend backend = runner.getBackend();
+        if (backend == LlamaCppDeviceSupport.Backend.CPU) {
+            return "CPU";
+        }
+        int layers = runner.getGpuLayers();
+        return backend.deviceType.getDisplayName() + " (layers: " + (layers == -1 ? "all" : layers) + ")";
+    }
+
     private Set<DeviceType> buildSupportedDevices() {
-        return LlamaCppDeviceSupport.supportedDevices(config);
+        return LlamaCppDeviceSupport.supportedDevices(config, availableBackends);
     }
 
     private void prewarmModels(java.util.List<String> modelIds) {
         modelIds.forEach(modelId -> {
             if (supports(modelId, null)) {
                 try {
-                    sessionManager.getSession("system", modelId, config);
+                    var session = sessionManager.getSession("system", modelId, config);
+                    sessionManager.releaseSession("system", modelId, session);
                 } catch (Exception e) {
                     log.warn("Prewarm failed", e);
                 }
@@ -632,27 +699,50 @@ public class LlamaCppProvider implements StreamingProvider {
         return ManifestStore.getModelsRoot().resolve("blobs").resolve(normalizedId).toAbsolutePath().toString();
     }
 
-    private InferenceRequest convertToInferenceRequest(ProviderRequest request, AdapterSpec adapterSpec) {
+    private static float floatParam(ProviderRequest request, String key, float fallback) {
+        return request.getParameter(key, Number.class).map(Number::floatValue).orElse(fallback);
+    }
+
+    InferenceRequest convertToInferenceRequest(ProviderRequest request, AdapterSpec adapterSpec) {
         // Apply Chat Template (Defaulting to ChatML for now as Qwen uses it)
         // TODO: detect template type from model metadata or config
         String prompt = applyChatMLTemplate(request.getMessages());
+        boolean raw = request.getParameter(InferenceLogicExecutor.RAW, Boolean.class).orElse(false);
+        if (raw) {
+            // raw prompts reach the model untouched; the runner falls back to the bare message text
+            prompt = request.getParameter("prompt", String.class).orElse(null);
+        }
 
         var builder = InferenceRequest.builder()
+                // keep the caller's id so transport cancellation reaches the runner
+                .requestId(request.getRequestId())
                 .model(request.getModel())
                 .messages(request.getMessages())
-                .parameter("prompt", prompt)
-                .parameter("max_tokens", Math.max(16, request.getMaxTokens()))
-                .parameter("temperature", request.getTemperature())
-                .parameter("top_p", request.getTopP())
-                .parameter("top_k", request.getParameter("top_k", Integer.class).orElse(config.defaultTopK()))
-                .parameter("stop", List.of("<|im_end|>", "<|endoftext|>", "</s>"))
+                .parameter("max_tokens", request.getParameter("max_tokens", Number.class)
+                        .map(Number::intValue).orElse(Math.max(16, request.getMaxTokens())))
+                // Explicit values (including 0, or a neutral repeat_penalty of 1.0) are honored as-is;
+                // configured defaults apply only when the field is absent. temperature 0 is greedy.
+                .parameter("temperature", floatParam(request, "temperature", config.defaultTemperature()))
+                .parameter("top_p", floatParam(request, "top_p", config.defaultTopP()))
+                .parameter("top_k", request.getParameter("top_k", Number.class)
+                        .map(Number::intValue).orElse(config.defaultTopK()))
+                .parameter("repeat_penalty", request.getParameter("repeat_penalty", Number.class)
+                        .or(() -> request.getParameter("repetition_penalty", Number.class))
+                        .map(Number::floatValue).orElse(config.defaultRepeatPenalty()))
+                .parameter("repeat_last_n", request.getParameter("repeat_last_n", Number.class)
+                        .map(Number::intValue).orElse(config.defaultRepeatLastN()))
+                .parameter("stop", raw ? List.of() : List.of("<|im_end|>", "<|endoftext|>", "</s>"))
                 .parameter("json_mode",
                         request.getParameter("json_mode", Boolean.class).orElse(config.defaultJsonMode()));
 
+        if (prompt != null) {
+            builder.parameter("prompt", prompt);
+        }
+
         // Add additional sampling parameters from provider request
         request.getParameters().forEach((k, v) -> {
             if (!k.equals("prompt") && !k.equals("max_tokens") && !k.equals("temperature") && !k.equals("top_p")
-                    && !k.equals("top_k")) {
+                    && !k.equals("top_k") && !k.equals("repeat_penalty") && !k.equals("repeat_last_n")) {
                 builder.parameter(k, v);
             }
         });
@@ -754,6 +844,7 @@ public class LlamaCppProvider implements StreamingProvider {
             if (binding != null) {
                 binding.backendInit();
                 log.info("llama.cpp native library initialized");
+                LlamaCppCpuFeatures.verify(binding, config.nativeCpuCheck());
             } else {
                 log.warn("GGUF native binding is not available; llama.cpp backend disabled.");
                 throw new IllegalStateException("Native binding not available");
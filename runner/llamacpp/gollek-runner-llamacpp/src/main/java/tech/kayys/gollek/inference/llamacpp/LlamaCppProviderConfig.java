@@ -6,6 +6,7 @@ import io.smallrye.config.WithName;
 
 import java.time.Duration;
 import java.util.List;
+import java.util.Map;
 import java.util.Optional;
 
 /**
@@ -55,6 +56,22 @@ public interface LlamaCppProviderConfig {
     @WithName("native.library-dir")
     Optional<String> nativeLibraryDir();
 
+    /**
+     * Directory with the ggml backend modules of a llama.cpp built with
+     * {@code GGML_BACKEND_DL}; defaults to the directory the llama library was loaded from.
+     */
+    @WithName("native.backend-dir")
+    Optional<String> nativeBackendDir();
+
+    /**
+     * Refuse to start when the native library was compiled for CPU instructions (AVX2,
+     * AVX512, SVE, ...) this host lacks, instead of dying with SIGILL on the first request.
+     * When false the mismatch is only logged.
+     */
+    @WithName("native.cpu-check")
+    @WithDefault("true")
+    boolean nativeCpuCheck();
+
     /**
      * Base directory for GGUF model files
      */
@@ -69,6 +86,31 @@ public interface LlamaCppProviderConfig {
     @WithDefault("4096")
     int maxContextTokens();
 
+    /**
+     * Reduce the context window at load time when the estimated KV cache does not fit in
+     * available memory ({@code memory.max-bytes} or free RAM). When false only a warning is logged.
+     */
+    @WithName("context.auto-size")
+    @WithDefault("false")
+    boolean contextAutoSize();
+
+    /**
+     * Keep the tail of a prompt that does not fit the context window instead of rejecting the
+     * request. Truncated requests carry a warning.
+     */
+    @WithName("context.truncate-prompt")
+    @WithDefault("false")
+    boolean contextTruncatePrompt();
+
+    /**
+     * Default strategy for prompts and generations that overflow the context window:
+     * {@code error}, {@code truncate} or {@code shift}. Requests may choose their own with
+     * {@code context_overflow}. When unset, {@code context.truncate-prompt} picks
+     * {@code truncate} or {@code error}.
+     */
+    @WithName("context.overflow")
+    Optional<String> contextOverflow();
+
     /**
      * Enable GPU acceleration
      */
@@ -104,6 +146,15 @@ public interface LlamaCppProviderConfig {
     @WithDefault("0")
     int gpuDeviceId();
 
+    /**
+     * Backend to offload to: auto, cuda, rocm, metal, vulkan or cpu. {@code auto} picks the
+     * first of CUDA, ROCm, Metal and Vulkan that the loaded library has a device for;
+     * offloading itself is still governed by {@code gpu.enabled} and {@code gpu.layers}.
+     */
+    @WithName("gpu.backend")
+    @WithDefault("auto")
+    String gpuBackend();
+
     /**
      * Number of threads for CPU inference
      */
@@ -132,6 +183,57 @@ public interface LlamaCppProviderConfig {
     @WithDefault("false")
     boolean mlockEnabled();
 
+    /**
+     * Reuse the KV cache for the longest token prefix shared with the previous request,
+     * trimming only the diverging tail instead of re-evaluating the whole prompt.
+     */
+    @WithName("prefix-cache.enabled")
+    @WithDefault("true")
+    boolean prefixCacheEnabled();
+
+    /**
+     * Shared prefixes shorter than this are re-evaluated from scratch
+     */
+    @WithName("prefix-cache.min-tokens")
+    @WithDefault("16")
+    int prefixCacheMinTokens();
+
+    /**
+     * Keep the KV state of common system prompts on disk, keyed by a hash of their tokens,
+     * so a restart does not have to prefill them again. Needs the prefix cache.
+     */
+    @WithName("prompt-cache.enabled")
+    @WithDefault("false")
+    boolean promptCacheEnabled();
+
+    /**
+     * Directory of the prompt cache; one subdirectory per model. Defaults to
+     * {@code ~/.gollek/cache/gguf/prompts}.
+     */
+    @WithName("prompt-cache.dir")
+    Optional<String> promptCacheDir();
+
+    /**
+     * Disk the prompt cache may use per model; least recently used entries go first
+     */
+    @WithName("prompt-cache.max-bytes")
+    @WithDefault("2147483648")
+    long promptCacheMaxBytes();
+
+    /**
+     * Entries the prompt cache keeps per model
+     */
+    @WithName("prompt-cache.max-entries")
+    @WithDefault("32")
+    int promptCacheMaxEntries();
+
+    /**
+     * System prompts shorter than this are not worth a cache file
+     */
+    @WithName("prompt-cache.min-tokens")
+    @WithDefault("64")
+    int promptCacheMinTokens();
+
     /**
      * Session pool minimum size per tenant/model combination
      */
@@ -153,6 +255,59 @@ public interface LlamaCppProviderConfig {
     @WithDefault("PT5M")
     Duration sessionPoolIdleTimeout();
 
+    /**
+     * Grow and shrink the number of runners (loaded model instances) in each session
+     * pool with load, between {@code session.pool.min-size} and
+     * {@code session.pool.autoscale.max-runners}. Off: a pool keeps one runner.
+     */
+    @WithName("session.pool.autoscale.enabled")
+    @WithDefault("false")
+    boolean sessionPoolAutoscaleEnabled();
+
+    /**
+     * Most runners a pool scales to; each one holds its own context and KV cache.
+     */
+    @WithName("session.pool.autoscale.max-runners")
+    @WithDefault("2")
+    int sessionPoolAutoscaleMaxRunners();
+
+    /**
+     * Waiting requests per runner above which another runner is loaded.
+     */
+    @WithName("session.pool.autoscale.target-queue")
+    @WithDefault("4")
+    int sessionPoolAutoscaleTargetQueue();
+
+    /**
+     * Predicted queue wait above which another runner is loaded.
+     */
+    @WithName("session.pool.autoscale.target-wait")
+    @WithDefault("PT5S")
+    Duration sessionPoolAutoscaleTargetWait();
+
+    /**
+     * How long nothing may wait in a pool before one of its runners is unloaded.
+     */
+    @WithName("session.pool.autoscale.scale-down-after")
+    @WithDefault("PT2M")
+    Duration sessionPoolAutoscaleScaleDownAfter();
+
+    /**
+     * How long an in-flight request may go without a prompt chunk or token before its
+     * runner is taken out of rotation, its decode aborted and a canary run; a failed
+     * canary replaces the runner. PT0S disables the watchdog.
+     */
+    @WithName("stall.timeout")
+    @WithDefault("PT0S")
+    Duration stallTimeout();
+
+    /**
+     * How long a suspect runner gets to finish its aborted work and answer the canary.
+     */
+    @WithName("stall.canary-timeout")
+    @WithDefault("PT30S")
+    Duration stallCanaryTimeout();
+
     /**
      * Convenience: session timeout in minutes
      */
@@ -209,6 +364,106 @@ public interface LlamaCppProviderConfig {
     @WithDefault("1")
     int coalesceSeqMax();
 
+    /**
+     * Continuous batching: interleave concurrent requests in one llama batch, each on its
+     * own sequence, admitting and retiring sequences between decode steps.
+     */
+    @WithName("continuous-batching.enabled")
+    @WithDefault("false")
+    boolean continuousBatchingEnabled();
+
+    /**
+     * Maximum sequences decoded together; the context window is split evenly between them.
+     */
+    @WithName("continuous-batching.max-sequences")
+    @WithDefault("4")
+    int continuousBatchingMaxSequences();
+
+    /**
+     * Requests waiting for a free sequence before new ones are rejected as busy.
+     */
+    @WithName("continuous-batching.max-queue")
+    @WithDefault("64")
+    int continuousBatchingMaxQueue();
+
+    /**
+     * How long a request may wait for room in a full queue before it is rejected as busy;
+     * zero rejects it at once.
+     */
+    @WithName("continuous-batching.queue-wait")
+    @WithDefault("PT0S")
+    Duration continuousBatchingQueueWait();
+
+    /**
+     * What a queued request may do to running ones when no sequence is free. Queued
+     * requests always start in priority order; with {@code evict}, one that outranks a
+     * running request also takes its sequence, and the evicted request resumes (its
+     * prompt and output so far re-prefilled) once a sequence frees up.
+     */
+    @WithName("continuous-batching.preemption")
+    @WithDefault("none")
+    String continuousBatchingPreemption();
+
+    /**
+     * Start waiting requests of equal priority shortest-job-first, by an output length
+     * predicted from completed requests with similar prompts, instead of in arrival order.
+     */
+    @WithName("continuous-batching.shortest-job-first")
+    @WithDefault("false")
+    boolean continuousBatchingShortestJobFirst();
+
+    /**
+     * Under shortest-job-first, how far (in milliseconds of arrival time) each predicted
+     * output token moves a request back. This bounds how long a long request can be
+     * overtaken by shorter ones that arrive after it.
+     */
+    @WithName("continuous-batching.sjf-ms-per-token")
+    @WithDefault("20")
+    int continuousBatchingSjfMsPerToken();
+
+    /**
+     * Prompt compression: prune low-information tokens (filler words, whitespace and
+     * punctuation runs) from the middle of long prompts before prefill. Requests may opt
+     * in or out with {@code compress_prompt}.
+     */
+    @WithName("prompt-compression.enabled")
+    @WithDefault("false")
+    boolean promptCompressionEnabled();
+
+    /**
+     * Prompts shorter than this many tokens are left alone.
+     */
+    @WithName("prompt-compression.min-tokens")
+    @WithDefault("1024")
+    int promptCompressionMinTokens();
+
+    /**
+     * Fraction of the prompt's tokens to keep, at most; pruning stops once reached.
+     */
+    @WithName("prompt-compression.target-ratio")
+    @WithDefault("0.7")
+    float promptCompressionTargetRatio();
+
+    /**
+     * Tokens at the start (system prompt) and end (question and assistant header) of the
+     * prompt that are never pruned.
+     */
+    @WithName("prompt-compression.keep-head")
+    @WithDefault("64")
+    int promptCompressionKeepHead();
+
+    @WithName("prompt-compression.keep-tail")
+    @WithDefault("256")
+    int promptCompressionKeepTail();
+
+    /**
+     * Convenience: number of sequences (n_seq_max) the context must be created with.
+     */
+    default int sequenceSlots() {
+        int slots = Math.max(1, coalesceSeqMax());
+        return continuousBatchingEnabled() ? Math.max(slots, continuousBatchingMaxSequences()) : slots;
+    }
+
     /**
      * Default inference timeout
      */
@@ -257,6 +512,29 @@ public interface LlamaCppProviderConfig {
     @WithName("prewarm.models")
     Optional<List<String>> prewarmModels();
 
+    /**
+     * Pooling for /v1/embeddings: none, mean, cls, last or rank. With {@code none}
+     * the runner mean-pools the per-token embeddings itself.
+     */
+    @WithName("embedding.pooling")
+    @WithDefault("mean")
+    String embeddingPooling();
+
+    /**
+     * L2-normalize embedding vectors before returning them
+     */
+    @WithName("embedding.normalize")
+    @WithDefault("true")
+    boolean embeddingNormalize();
+
+    /**
+     * Context (and batch) size of the embedding context, the longest input embedded in
+     * full; longer inputs are truncated. 0 uses the generation context size.
+     */
+    @WithName("embedding.context-size")
+    @WithDefault("512")
+    int embeddingContextSize();
+
     /**
      * Default temperature for sampling
      */
@@ -320,6 +598,21 @@ public interface LlamaCppProviderConfig {
     @WithDefault("0")
     long maxMemoryBytes();
 
+    /**
+     * Refuse to load a model whose estimated weights, KV cache and compute buffers exceed
+     * the free RAM (or {@code memory.max-bytes}) and, when offloading, free VRAM.
+     */
+    @WithName("memory.preflight.enabled")
+    @WithDefault("true")
+    boolean memoryPreflightEnabled();
+
+    /**
+     * Memory the preflight check leaves free on each side for the OS and other processes.
+     */
+    @WithName("memory.preflight.headroom-bytes")
+    @WithDefault("536870912")
+    long memoryPreflightHeadroomBytes();
+
     /**
      * Enable metrics collection
      */
@@ -387,4 +680,114 @@ public interface LlamaCppProviderConfig {
      */
     @WithName("lora.rollout.blocked-path-prefixes")
     Optional<List<String>> loraRolloutBlockedPathPrefixes();
+
+    /**
+     * Chat template overrides keyed by model id, e.g.
+     * {@code gguf.provider.chat-template."qwen2-7b"=chatml}: a named template (chatml,
+     * llama3, mistral, gemma, phi3, zephyr) or a Jinja template inline. Replaces the
+     * template embedded in the GGUF.
+     */
+    @WithName("chat-template")
+    Map<String, String> chatTemplates();
+
+    /**
+     * Turn formats for models without a chat template (base models), keyed by model id,
+     * e.g. {@code gguf.provider.turn-format."llama-2-7b".preset=plain}. A configured format
+     * replaces the model's own template for chat requests.
+     */
+    @WithName("turn-format")
+    Map<String, TurnFormat> turnFormats();
+
+    /**
+     * How chat messages are laid out for a base model. Unset prefixes and suffixes come
+     * from the preset ({@code plain}, {@code alpaca} or {@code chatml}).
+     */
+    interface TurnFormat {
+
+        @WithName("preset")
+        @WithDefault("plain")
+        String preset();
+
+        @WithName("system-prefix")
+        Optional<String> systemPrefix();
+
+        @WithName("system-suffix")
+        Optional<String> systemSuffix();
+
+        @WithName("user-prefix")
+        Optional<String> userPrefix();
+
+        @WithName("user-suffix")
+        Optional<String> userSuffix();
+
+        @WithName("assistant-prefix")
+        Optional<String> assistantPrefix();
+
+        @WithName("assistant-suffix")
+        Optional<String> assistantSuffix();
+
+        /**
+         * Stop strings added to every chat request, so the model does not go on to
+         * write the next user turn itself.
+         */
+        @WithName("stop")
+        Optional<List<String>> stop();
+    }
+
+    /**
+     * Output clean-up keyed by model id, e.g.
+     * {@code gguf.provider.post-process."qwen2-7b".remove-artifacts=true}. Requests may
+     * adjust it with the {@code post_process} parameter.
+     */
+    @WithName("post-process")
+    Map<String, PostProcess> postProcess();
+
+    /**
+     * Transforms applied to generated text, in order: artifact removal, replacements,
+     * sentence trimming, sanitizing, leading whitespace.
+     */
+    interface PostProcess {
+
+        /**
+         * Drop template residue such as {@code <|im_end|>} or a leading role header.
+         */
+        @WithName("remove-artifacts")
+        @WithDefault("false")
+        boolean removeArtifacts();
+
+        @WithName("replace")
+        Optional<List<Replacement>> replacements();
+
+        /**
+         * Cut output that hit {@code max_tokens} back to its last complete sentence.
+         */
+        @WithName("trim-to-sentence")
+        @WithDefault("false")
+        boolean trimToSentence();
+
+        /**
+         * {@code strip} removes HTML tags (with script and style bodies) and
+         * {@code escape} HTML-escapes them, for output rendered into web pages; both
+         * neutralise {@code javascript:} links. {@code none} by default.
+         */
+        @WithName("sanitize")
+        @WithDefault("none")
+        String sanitize();
+
+        @WithName("strip-leading-whitespace")
+        @WithDefault("false")
+        boolean stripLeadingWhitespace();
+    }
+
+    /**
+     * A regex replacement, e.g. {@code replace[0].pattern=^Answer:\\s*}.
+     */
+    interface Replacement {
+
+        @WithName("pattern")
+        String pattern();
+
+        @WithName("replacement")
+        Optional<String> replacement();
+    }
 }
@@ -3,21 +3,30 @@ package tech.kayys.gollek.inference.llamacpp;
 import tech.kayys.gollek.gguf.tokenizer.GGUFChatTemplateService;
 
 import org.jboss.logging.Logger;
+import tech.kayys.gollek.spi.exception.RunnerBusyException;
 import tech.kayys.gollek.spi.inference.InferenceRequest;
 import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
 import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
 import tech.kayys.gollek.spi.embedding.EmbeddingRequest;
 import tech.kayys.gollek.spi.embedding.EmbeddingResponse;
 import tech.kayys.gollek.spi.model.ModelManifest;
+import tech.kayys.gollek.spi.model.ModelFormat;
+import tech.kayys.gollek.spi.model.ModalityType;
 import io.smallrye.mutiny.Multi;
 import io.smallrye.mutiny.Uni;
+import io.smallrye.mutiny.subscription.MultiEmitter;
 import io.micrometer.core.instrument.MeterRegistry;
+import java.time.Duration;
 import java.util.List;
 import java.util.Map;
+import java.util.concurrent.CompletableFuture;
+import java.util.concurrent.ExecutionException;
 import java.util.concurrent.ExecutorService;
 import java.util.concurrent.Executors;
 import java.util.concurrent.Semaphore;
 import java.util.concurrent.TimeUnit;
+import java.util.concurrent.TimeoutException;
 import java.util.function.Consumer;
 
 /**
@@ -31,6 +40,7 @@ import java.util.function.Consumer;
  * - LlamaCppMetricsRecorder: Metrics collection
  * - LlamaCppAdapterManager: LoRA adapter lifecycle
  * - LlamaCppCoalescer: Request batching (optional)
+ * - LlamaCppBatchScheduler: Continuous batching across sequences (optional)
  */
 public class LlamaCppRunner {
 
@@ -48,12 +58,19 @@ public class LlamaCppRunner {
     private LlamaCppKVCacheManager kvCacheManager;
     private LlamaCppTokenSampler tokenSampler;
     private LlamaCppCoalescer coalescer;
+    private LlamaCppBatchScheduler batchScheduler;
+    private LlamaCppEmbeddingEngine embeddingEngine;
+    private LlamaCppPostProcessor postProcessor = LlamaCppPostProcessor.NONE;
 
     // State from initialization
     private java.lang.foreign.MemorySegment model;
     private java.lang.foreign.MemorySegment context;
     private int contextSize, vocabSize, eosToken, bosToken, runtimeBatchSize;
     private String chatTemplate;
+    private LlamaCppChatTemplates.Active activeTemplate;
+    private LlamaCppKVCacheEstimator.Estimate kvCacheEstimate;
+    private LlamaCppDeviceSupport.Backend backend = LlamaCppDeviceSupport.Backend.CPU;
+    private int gpuLayers;
 
     private final ExecutorService executorService = Executors.newCachedThreadPool();
     private final Semaphore concurrencyLimit;
@@ -86,18 +103,42 @@ public class LlamaCppRunner {
             this.vocabSize = result.vocabSize;
             this.eosToken = result.eosToken;
             this.bosToken = result.bosToken;
-            this.chatTemplate = result.chatTemplate;
+            this.activeTemplate = LlamaCppChatTemplates.resolve(providerConfig, manifest.modelId(), result.chatTemplate);
+            this.chatTemplate = activeTemplate.template();
             this.runtimeBatchSize = result.runtimeBatchSize;
+            this.kvCacheEstimate = result.kvCacheEstimate;
+            this.backend = result.backend;
+            this.gpuLayers = result.activeGpuLayers;
+            metricsRecorder.recordKvCacheEstimate(kvCacheEstimate);
+            binding.installAbortCallback(context);
 
             // 3. Initialize remaining components
-            this.kvCacheManager = new LlamaCppKVCacheManager(binding, providerConfig, manifest);
+            this.kvCacheManager = new LlamaCppKVCacheManager(binding, providerConfig, manifest, newSlotStats());
+            metricsRecorder.setSlotStats(kvCacheManager.slotStats());
             this.tokenSampler = new LlamaCppTokenSampler(binding, vocabSize);
+            this.postProcessor = LlamaCppPostProcessor.forModel(providerConfig, manifest.modelId());
 
             // 4. Configure adapter using AdapterManager component
             adapterManager.configureAdapter(model, context, runnerConfig);
 
-            // 5. Start coalescer if enabled using Coalescer component
-            if (providerConfig.coalesceEnabled()) {
+            // 5. Start the continuous batching scheduler, or the coalescer, if enabled
+            if (providerConfig.continuousBatchingEnabled()) {
+                this.batchScheduler = new LlamaCppBatchScheduler(
+                        binding,
+                        providerConfig,
+                        metricsRecorder,
+                        kvCacheManager,
+                        tokenSampler,
+                        newInferenceExecutor(),
+                        this::executeInference,
+                        model,
+                        context,
+                        manifest.modelId(),
+                        contextSize,
+                        vocabSize,
+                        runtimeBatchSize);
+                batchScheduler.start();
+            } else if (providerConfig.coalesceEnabled()) {
                 this.coalescer = new LlamaCppCoalescer(
                         binding,
                         providerConfig,
@@ -124,39 +165,150 @@ public class LlamaCppRunner {
 
     public InferenceResponse infer(InferenceRequest request) {
         checkInitialized();
-        if (coalescer != null) {
-            return coalescer.submit(request, null, () -> {
-                executeWithComponents(request, null);
-                return null;
-            });
+        try {
+            LlamaCppPostProcessor output = postProcessor.forRequest(request);
+            if (batchScheduler != null) {
+                return output.apply(batchScheduler.submit(request, null));
+            }
+            if (coalescer != null) {
+                return output.apply(coalescer.submit(request, null, () -> {
+                    executeWithComponents(request, null);
+                    return null;
+                }));
+            }
+            return output.apply(executeWithComponents(request, null));
+        } finally {
+            RequestCancellation.clear(request.getRequestId());
         }
-        return executeWithComponents(request, null);
     }
 
     public Multi<StreamingInferenceChunk> inferStream(InferenceRequest request) {
         checkInitialized();
-        return Multi.createFrom().emitter(emitter -> executorService.execute(() -> {
-            try {
-                int[] counter = { 0 };
-                Consumer<String> onToken = piece -> {
-                    if (!emitter.isCancelled()) {
-                        emitter.emit(StreamingInferenceChunk.of(request.getRequestId(), counter[0]++, piece));
-                    }
-                };
-                if (coalescer != null) {
-                    coalescer.submit(request, onToken, () -> {
-                        executeWithComponents(request, onToken);
-                        return null;
-                    });
-                } else {
-                    executeWithComponents(request, onToken);
+        return Multi.createFrom().emitter(emitter -> {
+            // a dropped subscriber stops generation instead of just muting it
+            emitter.onTermination(() -> {
+                if (emitter.isCancelled()) {
+                    RequestCancellation.cancel(request.getRequestId());
                 }
-                emitter.complete();
-            } catch (Exception e) {
+            });
+            executorService.execute(() -> streamTo(emitter, request));
+        });
+    }
+
+    private void streamTo(MultiEmitter<? super StreamingInferenceChunk> emitter, InferenceRequest request) {
+        try {
+            int[] counter = { 0 };
+            InferenceResponse[] result = new InferenceResponse[1];
+            // only the transforms that work on partial output apply to streams
+            LlamaCppPostProcessor.StreamFilter onToken = postProcessor.forRequest(request).stream(
+                    (LlamaCppLogprobs.Sink) (piece, logprobs) -> {
+                        if (emitter.isCancelled()) {
+                            return;
+                        }
+                        emitter.emit(logprobs.isEmpty()
+                                ? StreamingInferenceChunk.of(request.getRequestId(), counter[0]++, piece)
+                                : StreamingInferenceChunk.withMetadata(request.getRequestId(), counter[0]++, piece,
+                                        Map.of(LlamaCppLogprobs.METADATA, LlamaCppLogprobs.toMetadata(logprobs))));
+                    });
+            if (batchScheduler != null) {
+                result[0] = batchScheduler.submit(request, onToken, wantsQueueEvents(request)
+                        ? status -> {
+                            if (!emitter.isCancelled()) {
+                                emitter.emit(queuedChunk(request, counter[0]++, status));
+                            }
+                        }
+                        : null);
+            } else if (coalescer != null) {
+                coalescer.submit(request, onToken, () -> {
+                    result[0] = executeWithComponents(request, onToken);
+                    return null;
+                });
+            } else {
+                result[0] = executeWithComponents(request, onToken);
+            }
+            onToken.finish();
+            if (!emitter.isCancelled()) {
+                emitter.emit(finalChunk(request, counter[0], result[0]));
+            }
+            emitter.complete();
+        } catch (Exception e) {
+            if (emitter.isCancelled()) {
+                log.debugf("Streaming request %s ended after cancellation: %s", request.getRequestId(), e.getMessage());
+            } else {
                 log.error("Streaming failed", e);
                 emitter.fail(e);
             }
-        }));
+        } finally {
+            RequestCancellation.clear(request.getRequestId());
+        }
+    }
+
+    /**
+     * Streams opt in to queue status chunks with the {@code queue_events} parameter.
+     */
+    static boolean wantsQueueEvents(InferenceRequest request) {
+        return Boolean.parseBoolean(String.valueOf(request.getParameters().getOrDefault("queue_events", "false")));
+    }
+
+    /**
+     * An empty-delta chunk whose {@code queue} metadata carries the position, queue length
+     * and estimated start while the request waits for a sequence.
+     */
+    private static StreamingInferenceChunk queuedChunk(InferenceRequest request, int index,
+            LlamaCppBatchScheduler.QueueStatus status) {
+        Map<String, Object> queue = new java.util.LinkedHashMap<>();
+        queue.put("position", status.position());
+        queue.put("queued", status.queued());
+        if (status.estimatedWaitMs() != null) {
+            queue.put("estimated_wait_ms", status.estimatedWaitMs());
+            queue.put("estimated_start", java.time.Instant.now().plusMillis(status.estimatedWaitMs()).toString());
+        }
+        return new StreamingInferenceChunk(request.getRequestId(), index, ModalityType.TEXT,
+                "", null, false, null, null, java.time.Instant.now(), Map.of("queue", queue));
+    }
+
+    private static StreamingInferenceChunk finalChunk(InferenceRequest request, int index, InferenceResponse response) {
+        String reason = response != null && response.getFinishReason() != null
+                ? response.getFinishReason().name().toLowerCase()
+                : "stop";
+        StreamingInferenceChunk.ChunkUsage usage = response == null ? null
+                : new StreamingInferenceChunk.ChunkUsage(response.getInputTokens(), response.getOutputTokens(),
+                        response.getDurationMs());
+        Map<String, Object> metadata = new java.util.LinkedHashMap<>();
+        if (response != null && !response.getWarnings().isEmpty()) {
+            metadata.put("warnings", response.getWarnings());
+        }
+        if (response != null) {
+            for (String key : List.of(InferenceLogicExecutor.STOP_SEQUENCE, InferenceLogicExecutor.PROMPT_TOKENS,
+                    LlamaCppPromptCompressor.METADATA, LlamaCppVerbose.METADATA)) {
+                if (response.getMetadata().get(key) != null) {
+                    metadata.put(key, response.getMetadata().get(key));
+                }
+            }
+        }
+        return new StreamingInferenceChunk(request.getRequestId(), index, ModalityType.TEXT,
+                "", null, true, reason, usage, java.time.Instant.now(), metadata.isEmpty() ? null : metadata);
+    }
+
+    /**
+     * Token ids of {@code text} using the model's vocabulary; special-token text is parsed.
+     */
+    public int[] tokenize(String text, boolean addSpecial) {
+        checkInitialized();
+        return binding.tokenize(model, text, addSpecial, true);
+    }
+
+    public String detokenize(int[] tokens) {
+        checkInitialized();
+        return binding.detokenize(model, tokens);
+    }
+
+    public String tokenPiece(int token) {
+        checkInitialized();
+        if (token < 0 || (vocabSize > 0 && token >= vocabSize)) {
+            throw new IllegalArgumentException("Token " + token + " is outside the vocabulary (size " + vocabSize + ")");
+        }
+        return binding.tokenToPiece(model, token);
     }
 
     public Uni<EmbeddingResponse> embed(EmbeddingRequest request) {
@@ -164,6 +316,73 @@ public class LlamaCppRunner {
         return Uni.createFrom().item(() -> executeEmbedding(request));
     }
 
+    /**
+     * KV cache size estimated at load time for the context actually created, or null
+     * before initialization.
+     */
+    public LlamaCppKVCacheEstimator.Estimate getKvCacheEstimate() {
+        return kvCacheEstimate;
+    }
+
+    /**
+     * Backend the model was offloaded to (CPU when no layers were), for health and model listings.
+     */
+    public LlamaCppDeviceSupport.Backend getBackend() {
+        return backend;
+    }
+
+    /**
+     * Where the chat template comes from ({@code source}) and its family ({@code name}); a
+     * configured turn format takes precedence over any template.
+     */
+    public Map<String, Object> getChatTemplateInfo() {
+        if (manifest != null && LlamaCppTurnFormat.forModel(providerConfig, manifest.modelId()) != null) {
+            return Map.of("source", "turn-format");
+        }
+        return activeTemplate == null ? Map.of() : activeTemplate.describe();
+    }
+
+    /**
+     * The GGUF file this runner loaded, or null before initialization.
+     */
+    public String getModelPath() {
+        if (manifest == null || manifest.artifacts().get(ModelFormat.GGUF) == null) {
+            return null;
+        }
+        return manifest.artifacts().get(ModelFormat.GGUF).uri();
+    }
+
+    /**
+     * Layers offloaded to {@link #getBackend()}; -1 means all, 0 none.
+     */
+    public int getGpuLayers() {
+        return gpuLayers;
+    }
+
+    /**
+     * Per-sequence KV cache occupancy, or an empty list before initialization.
+     */
+    public List<LlamaCppSlotStats.Slot> getSlots() {
+        return kvCacheManager == null ? List.of() : kvCacheManager.slotStats().snapshot();
+    }
+
+    /**
+     * The batch queue a new request would join, or null without continuous batching.
+     */
+    public LlamaCppBatchScheduler.QueueStatus getQueueStatus() {
+        return batchScheduler == null ? null : batchScheduler.queueStatus();
+    }
+
+    private LlamaCppSlotStats newSlotStats() {
+        if (!providerConfig.continuousBatchingEnabled()) {
+            return new LlamaCppSlotStats(1, contextSize);
+        }
+        int sequences = Math.max(1, providerConfig.continuousBatchingMaxSequences());
+        // same split as the batch scheduler's per-sequence window
+        int perSequence = contextSize > 0 ? contextSize / Math.max(sequences, providerConfig.sequenceSlots()) : 0;
+        return new LlamaCppSlotStats(sequences, perSequence);
+    }
+
     public void registerMetrics(MeterRegistry registry, String tenantId, String modelId) {
         if (metricsRecorder != null) {
             metricsRecorder.registerMetrics(registry, tenantId, modelId, providerConfig.coalesceMaxQueue());
@@ -173,6 +392,8 @@ public class LlamaCppRunner {
     public void close() {
         if (!initialized)
             return;
+        if (batchScheduler != null)
+            batchScheduler.shutdown();
         cleanup();
         executorService.shutdownNow();
         if (coalescer != null)
@@ -180,6 +401,72 @@ public class LlamaCppRunner {
         initialized = false;
     }
 
+    /**
+     * Requests whose slot has made no progress for more than {@code threshold}.
+     */
+    public List<String> stalledRequests(Duration threshold) {
+        if (!initialized) {
+            return List.of();
+        }
+        return kvCacheManager.slotStats().stalledRequests(System.nanoTime(), threshold.toNanos());
+    }
+
+    /**
+     * Make the decode in progress, and any started before {@link #canary}, fail so their
+     * requests end with an error. Returns false when llama.cpp cannot abort a decode.
+     */
+    public boolean abortInFlight() {
+        if (!initialized || !binding.supportsAbort()) {
+            return false;
+        }
+        binding.abort(context);
+        return true;
+    }
+
+    /**
+     * Whether the runner still generates after a stall: waits for its requests to leave,
+     * lifts the abort and asks for one token, all within {@code timeout}.
+     */
+    public boolean canary(Duration timeout) {
+        if (!initialized) {
+            return false;
+        }
+        long deadline = System.nanoTime() + timeout.toNanos();
+        try {
+            while (kvCacheManager.slotStats().activeSlots() > 0) {
+                if (System.nanoTime() > deadline) {
+                    log.warnf("Runner for %s still busy %d ms after the abort", manifest.modelId(), timeout.toMillis());
+                    return false;
+                }
+                Thread.sleep(50);
+            }
+            binding.clearAbort(context);
+            InferenceRequest probe = InferenceRequest.builder()
+                    .model(manifest.modelId())
+                    .message(tech.kayys.gollek.spi.Message.user("ping"))
+                    .parameter("max_tokens", 1)
+                    .build();
+            // a hung decode keeps its thread, so it must not be a pooled one
+            CompletableFuture<InferenceResponse> result = new CompletableFuture<>();
+            Thread.ofPlatform().daemon().name("gollek-gguf-canary").start(() -> {
+                try {
+                    result.complete(infer(probe));
+                } catch (Throwable e) {
+                    result.completeExceptionally(e);
+                }
+            });
+            InferenceResponse response = result.get(Math.max(1, deadline - System.nanoTime()), TimeUnit.NANOSECONDS);
+            return response.getFinishReason() != InferenceResponse.FinishReason.ERROR;
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+            return false;
+        } catch (ExecutionException | TimeoutException e) {
+            log.warnf("Canary for %s failed: %s", manifest.modelId(),
+                    e instanceof TimeoutException ? "no answer within " + timeout.toMillis() + " ms" : e.getCause());
+            return false;
+        }
+    }
+
     public List<InferenceRequest> createDefaultWarmupRequests() {
         return List.of(InferenceRequest.builder()
                 .model(manifest != null ? manifest.modelId() : "unknown")
@@ -231,15 +518,20 @@ public class LlamaCppRunner {
     }
 
     private InferenceResponse executeWithComponents(InferenceRequest request, Consumer<String> onTokenPiece) {
+        LlamaCppTimeouts timeouts = LlamaCppTimeouts.of(request.getParameters());
+        long waitMs = providerConfig.defaultTimeout().toMillis();
+        boolean queueBound = timeouts.queueMs() > 0 && timeouts.queueMs() < waitMs;
         boolean permit = false;
         try {
-            permit = concurrencyLimit.tryAcquire(providerConfig.defaultTimeout().toMillis(), TimeUnit.MILLISECONDS);
+            permit = concurrencyLimit.tryAcquire(queueBound ? timeouts.queueMs() : waitMs, TimeUnit.MILLISECONDS);
         } catch (InterruptedException e) {
             Thread.currentThread().interrupt();
             throw new RuntimeException("Interrupted", e);
         }
+        if (!permit && queueBound)
+            throw timeouts.queueTimedOut();
         if (!permit)
-            throw new RuntimeException("Runner busy");
+            throw busy();
         try {
             return executeInference(request, onTokenPiece);
         } finally {
@@ -248,12 +540,26 @@ public class LlamaCppRunner {
         }
     }
 
+    /**
+     * Every permit stayed taken for the default timeout; the runner has no completion rate
+     * outside continuous batching, so clients are told to retry shortly.
+     */
+    private RunnerBusyException busy() {
+        metricsRecorder.recordRejection();
+        return new RunnerBusyException("Runner busy: all " + providerConfig.maxConcurrentRequests()
+                + " concurrent requests in use", 1);
+    }
+
     private InferenceResponse executeInference(InferenceRequest request, Consumer<String> onTokenPiece) {
         // Delegate to inference logic that uses all components
+        return newInferenceExecutor().execute(request, onTokenPiece);
+    }
+
+    private InferenceLogicExecutor newInferenceExecutor() {
         return new InferenceLogicExecutor(
                 binding, providerConfig, templateService,
                 model, context, contextSize, vocabSize, eosToken, bosToken, runtimeBatchSize, chatTemplate,
-                kvCacheManager, tokenSampler, metricsRecorder, manifest).execute(request, onTokenPiece);
+                kvCacheManager, tokenSampler, metricsRecorder, manifest);
     }
 
     private EmbeddingResponse executeEmbedding(EmbeddingRequest request) {
@@ -261,23 +567,13 @@ public class LlamaCppRunner {
             boolean permit = concurrencyLimit.tryAcquire(providerConfig.defaultTimeout().toMillis(),
                     TimeUnit.MILLISECONDS);
             if (!permit)
-                throw new RuntimeException("Runner busy");
+                throw busy();
             try {
-                // Use kvCacheManager for tokenization
-                String input = request.inputs().get(0);
-                int[] tokens = kvCacheManager.tokenizeWithCache(model, input, true);
-                if (tokens.length == 0) {
-                    return new EmbeddingResponse(request.requestId(), manifest.modelId(), List.of(new float[0]), 0,
-                            Map.of());
-                }
-                int nEmbd;
-                try {
-                    nEmbd = binding.nEmbd(model);
-                } catch (Throwable t) {
-                    throw new RuntimeException("Failed to get embedding dimension", t);
-                }
-                return new EmbeddingResponse(request.requestId(), manifest.modelId(), List.of(new float[nEmbd]), nEmbd,
-                        Map.of());
+                LlamaCppEmbeddingEngine engine = embeddingEngine();
+                List<float[]> vectors = engine.embed(request.inputs());
+                int dim = vectors.isEmpty() ? engine.dimension() : vectors.get(0).length;
+                return new EmbeddingResponse(request.requestId(), manifest.modelId(), vectors, dim,
+                        Map.of("pooling", providerConfig.embeddingPooling()));
             } finally {
                 concurrencyLimit.release();
             }
@@ -286,7 +582,18 @@ public class LlamaCppRunner {
         }
     }
 
+    private synchronized LlamaCppEmbeddingEngine embeddingEngine() {
+        if (embeddingEngine == null) {
+            embeddingEngine = new LlamaCppEmbeddingEngine(binding, providerConfig, model, contextSize);
+        }
+        return embeddingEngine;
+    }
+
     private void cleanup() {
+        if (embeddingEngine != null) {
+            embeddingEngine.close();
+            embeddingEngine = null;
+        }
         if (adapterManager != null) {
             adapterManager.removeAdapter(context);
             adapterManager.cleanup();
@@ -3,6 +3,7 @@ package tech.kayys.gollek.inference.llamacpp;
 import tech.kayys.gollek.gguf.tokenizer.GGUFChatTemplateService;
 
 import io.micrometer.core.instrument.Counter;
+import io.micrometer.core.instrument.FunctionCounter;
 import io.micrometer.core.instrument.Gauge;
 import io.micrometer.core.instrument.MeterRegistry;
 import jakarta.annotation.PreDestroy;
@@ -15,11 +16,17 @@ import tech.kayys.gollek.provider.core.session.EwmaAdaptiveSessionEvictionPolicy
 import tech.kayys.gollek.spi.observability.AdapterMetricSchema;
 import tech.kayys.gollek.spi.observability.AdapterMetricsRecorder;
 import tech.kayys.gollek.spi.observability.AdapterSpec;
+import tech.kayys.gollek.spi.provider.ReloadableProvider;
 
+import java.nio.file.Files;
+import java.nio.file.Path;
 import java.time.Duration;
 import java.time.Instant;
+import java.util.ArrayList;
+import java.util.List;
 import java.util.Map;
 import java.util.concurrent.ConcurrentHashMap;
+import java.util.concurrent.ExecutorService;
 import java.util.concurrent.Executors;
 import java.util.concurrent.Semaphore;
 import java.util.concurrent.ScheduledExecutorService;
@@ -33,6 +40,9 @@ import java.util.concurrent.atomic.AtomicLong;
  * Features:
  * - Per-tenant/model session pooling
  * - Configurable pool sizes (min/max)
+ * - Optional runner autoscaling per pool ({@link LlamaCppPoolAutoscaler})
+ * - Optional stall watchdog: a runner whose request stops making progress is taken
+ *   out of rotation, aborted and canaried, and replaced if the canary fails
  * - Idle timeout and cleanup
  * - Resource limits enforcement
  * - Thread-safe concurrent access
@@ -45,6 +55,8 @@ public class LlamaCppSessionManager {
     private static final Logger log = Logger.getLogger(LlamaCppSessionManager.class);
     private static final Duration DEFAULT_IDLE_TIMEOUT = Duration.ofMinutes(5);
     private static final long MIN_CLEANUP_INTERVAL_SECONDS = 10L;
+    private static final long AUTOSCALE_INTERVAL_SECONDS = 2L;
+    private static final long STALL_CHECK_INTERVAL_SECONDS = 1L;
 
     private final LlamaCppBinding binding;
     private final GGUFChatTemplateService templateService;
@@ -66,6 +78,9 @@ public class LlamaCppSessionManager {
     }
 
     private final Map<String, SessionPool> pools = new ConcurrentHashMap<>();
+    private final Map<String, String> modelPathOverrides = new ConcurrentHashMap<>();
+    // gpu_layers set by an offload reload; they win over gguf.provider.gpu.layers
+    private final Map<String, Integer> gpuLayerOverrides = new ConcurrentHashMap<>();
     private final AtomicInteger totalActiveSessions = new AtomicInteger(0);
     private final AdaptiveSessionEvictionState adaptiveEvictionState = new AdaptiveSessionEvictionState();
     private final AtomicLong adaptiveIdleTimeoutSeconds = new AtomicLong(300);
@@ -73,6 +88,12 @@ public class LlamaCppSessionManager {
     private volatile Counter evictionReclaimedCounter;
     private volatile boolean adaptiveMetricsRegistered;
     private volatile ScheduledExecutorService cleanupExecutor;
+    private volatile ScheduledExecutorService autoscaleExecutor;
+    private volatile ScheduledExecutorService stallExecutor;
+    private volatile ExecutorService stallRecoveryExecutor;
+    private final AtomicLong stallEvents = new AtomicLong();
+    private final AtomicLong canaryFailures = new AtomicLong();
+    private final AtomicLong runnerRestarts = new AtomicLong();
     private volatile boolean initialized = false;
     private volatile boolean shutdown = false;
 
@@ -104,7 +125,15 @@ public class LlamaCppSessionManager {
         private final AdapterSpec adapterSpec;
         private final LlamaCppProviderConfig config;
         private final Map<String, SessionContext> sessions = new ConcurrentHashMap<>();
+        // replaced by a reload; closed once their last in-flight request is released
+        private final Map<String, SessionContext> retired = new ConcurrentHashMap<>();
+        // stalled; out of rotation until their canary passes
+        private final Map<String, Suspect> suspects = new ConcurrentHashMap<>();
+        private final Map<String, Integer> inFlight = new java.util.HashMap<>();
         private final Semaphore permits;
+        // null unless autoscaling is enabled
+        private final LlamaCppPoolAutoscaler autoscaler;
+        private int generation;
 
         SessionPool(String poolKey, String requestId, String modelId, AdapterSpec adapterSpec,
                 LlamaCppProviderConfig config) {
@@ -114,26 +143,36 @@ public class LlamaCppSessionManager {
             this.adapterSpec = adapterSpec;
             this.config = config;
             this.permits = new Semaphore(config.sessionPoolMaxSize(), true);
+            this.autoscaler = config.sessionPoolAutoscaleEnabled() ? new LlamaCppPoolAutoscaler(config) : null;
         }
 
         SessionContext acquire() throws InterruptedException {
             permits.acquire();
 
             try {
-                // Try to find an idle session
-                SessionContext session = findIdleSession(resolveAdaptiveIdleTimeout(config));
-                if (session != null) {
-                    log.debugf("Reusing session %s for pool %s", session.sessionId(), poolKey);
-                    return session.touch();
+                // Reuse the least busy live session
+                int createdIn;
+                synchronized (this) {
+                    SessionContext session = leastBusySession(resolveAdaptiveIdleTimeout(config));
+                    if (session != null) {
+                        log.debugf("Reusing session %s for pool %s", session.sessionId(), poolKey);
+                        inFlight.merge(session.sessionId(), 1, Integer::sum);
+                        return session.touch();
+                    }
+                    createdIn = generation;
                 }
 
                 // Create new session
                 if (currentUtilization(config) >= 0.75d) {
                     recordAdaptiveTelemetry(true, 0);
                 }
-                session = createSession();
-                sessions.put(session.sessionId(), session);
+                SessionContext session = createSession(false);
                 totalActiveSessions.incrementAndGet();
+                synchronized (this) {
+                    inFlight.merge(session.sessionId(), 1, Integer::sum);
+                    // a reload raced with the load; serve this request, then let it drain
+                    (createdIn == generation ? sessions : retired).put(session.sessionId(), session);
+                }
 
                 log.debugf(" new session %s for pool %s (total active: %d)",
                         session.sessionId(), poolKey, totalActiveSessions.get());
@@ -147,19 +186,275 @@ public class LlamaCppSessionManager {
         }
 
         void release(SessionContext session) {
-            // Update last used timestamp
-            sessions.put(session.sessionId(), session.touch());
+            SessionContext drained = null;
+            synchronized (this) {
+                int remaining = inFlight.merge(session.sessionId(), -1, Integer::sum);
+                if (remaining <= 0) {
+                    inFlight.remove(session.sessionId());
+                }
+                if (retired.containsKey(session.sessionId())) {
+                    if (remaining <= 0) {
+                        drained = retired.remove(session.sessionId());
+                    }
+                } else if (!suspects.containsKey(session.sessionId())) {
+                    // Update last used timestamp
+                    sessions.put(session.sessionId(), session.touch());
+                }
+            }
             permits.release();
+            if (drained != null) {
+                closeRetired(drained);
+            }
+        }
+
+        /**
+         * Make {@code fresh} the only session for new requests and retire the current
+         * ones; returns how many were retired.
+         */
+        int swap(SessionContext fresh) {
+            List<SessionContext> drained = new ArrayList<>();
+            int count;
+            synchronized (this) {
+                generation++;
+                count = sessions.size();
+                for (SessionContext old : sessions.values()) {
+                    if (inFlight.getOrDefault(old.sessionId(), 0) > 0) {
+                        retired.put(old.sessionId(), old);
+                    } else {
+                        drained.add(old);
+                    }
+                }
+                sessions.clear();
+                sessions.put(fresh.sessionId(), fresh);
+            }
+            drained.forEach(this::closeRetired);
+            return count;
+        }
+
+        /**
+         * Stop handing out sessions and wait until none is in flight. Returns false, with
+         * nothing held, if that takes longer than {@code timeout}.
+         */
+        boolean drain(Duration timeout) throws InterruptedException {
+            return permits.tryAcquire(config.sessionPoolMaxSize(), timeout.toMillis(), TimeUnit.MILLISECONDS);
+        }
+
+        /** Hand out sessions again after {@link #drain}. */
+        void resume() {
+            permits.release(config.sessionPoolMaxSize());
+        }
+
+        /** Close every session of a drained pool so their memory is free; returns how many. */
+        int closeAll() {
+            List<SessionContext> closing;
+            synchronized (this) {
+                generation++;
+                closing = new ArrayList<>(sessions.values());
+                closing.addAll(retired.values());
+                sessions.clear();
+                retired.clear();
+            }
+            closing.forEach(this::closeRetired);
+            return closing.size();
+        }
+
+        private void closeRetired(SessionContext session) {
+            log.infof("Closing replaced session %s of pool %s", session.sessionId(), poolKey);
+            try {
+                session.runner().close();
+            } catch (Exception e) {
+                log.warnf(e, "Error closing session %s", session.sessionId());
+            } finally {
+                totalActiveSessions.decrementAndGet();
+            }
         }
 
-        private SessionContext findIdleSession(Duration timeout) {
+        /**
+         * Take runners with a request that has made no progress for {@code stall.timeout}
+         * out of rotation; returns them for {@link #recover}.
+         */
+        List<SessionContext> markStalled() {
+            Duration threshold = config.stallTimeout();
+            if (threshold.isZero() || threshold.isNegative()) {
+                return List.of();
+            }
+            List<SessionContext> marked = new ArrayList<>();
+            synchronized (this) {
+                for (SessionContext session : List.copyOf(sessions.values())) {
+                    List<String> stalled = session.runner().stalledRequests(threshold);
+                    if (stalled.isEmpty()) {
+                        continue;
+                    }
+                    log.warnf("Session %s of pool %s made no progress on %s for %d ms; marking it suspect",
+                            session.sessionId(), poolKey, stalled, threshold.toMillis());
+                    sessions.remove(session.sessionId());
+                    suspects.put(session.sessionId(), new Suspect(session, generation));
+                    marked.add(session);
+                }
+            }
+            return marked;
+        }
+
+        /**
+         * Abort a suspect runner's work and run its canary. A runner that answers goes back
+         * into rotation; one that does not is replaced by a freshly loaded runner and closed
+         * once nothing is in flight on it.
+         */
+        void recover(SessionContext session) {
+            LlamaCppRunner runner = session.runner();
+            if (!runner.abortInFlight()) {
+                log.warnf("llama.cpp cannot abort decodes; waiting for session %s to finish its requests",
+                        session.sessionId());
+            }
+            boolean healthy = runner.canary(config.stallCanaryTimeout());
+            boolean idle;
+            int markedIn;
+            synchronized (this) {
+                Suspect suspect = suspects.remove(session.sessionId());
+                if (suspect == null) {
+                    // closed by shutdown meanwhile
+                    return;
+                }
+                markedIn = suspect.generation();
+                boolean current = markedIn == generation && !shutdown;
+                if (healthy && current) {
+                    sessions.put(session.sessionId(), session.touch());
+                    log.infof("Session %s of pool %s passed its canary; back in rotation", session.sessionId(),
+                            poolKey);
+                    return;
+                }
+                idle = inFlight.getOrDefault(session.sessionId(), 0) <= 0;
+                if (!idle) {
+                    retired.put(session.sessionId(), session);
+                }
+            }
+            if (healthy) {
+                // a reload replaced the pool's runners while this one was suspect
+                if (idle) {
+                    closeRetired(session);
+                }
+                return;
+            }
+            canaryFailures.incrementAndGet();
+            log.errorf("Session %s of pool %s failed its canary; restarting its runner", session.sessionId(),
+                    poolKey);
+            if (idle) {
+                closeRetired(session);
+            }
+            restart(markedIn);
+        }
+
+        private void restart(int markedIn) {
+            SessionContext fresh;
+            try {
+                fresh = createSession(true);
+            } catch (Exception e) {
+                log.warnf("Could not restart a runner for pool %s: %s", poolKey, e.getMessage());
+                return;
+            }
+            totalActiveSessions.incrementAndGet();
+            synchronized (this) {
+                if (markedIn == generation && !shutdown) {
+                    sessions.put(fresh.sessionId(), fresh);
+                    runnerRestarts.incrementAndGet();
+                    log.infof("Restarted runner %s for pool %s", fresh.sessionId(), poolKey);
+                    return;
+                }
+            }
+            closeRetired(fresh);
+        }
+
+        private SessionContext leastBusySession(Duration timeout) {
             return sessions.values().stream()
                     .filter(s -> !s.isIdle(timeout))
-                    .findFirst()
+                    .min(java.util.Comparator.comparingInt(s -> inFlight.getOrDefault(s.sessionId(), 0)))
                     .orElse(null);
         }
 
-        private SessionContext createSession() {
+        /**
+         * Load another runner or unload one, as the autoscaler decides for the pool's
+         * current load. A new runner is loaded and warmed up before it takes requests; an
+         * unloaded one stops taking them at once and is closed when its last in-flight
+         * request is released.
+         */
+        void autoscale(long nowMs) {
+            if (autoscaler == null) {
+                return;
+            }
+            LlamaCppPoolAutoscaler.Load load = load();
+            switch (autoscaler.decide(load, nowMs)) {
+                case UP -> scaleUp(load.runners());
+                case DOWN -> scaleDown();
+                case HOLD -> {
+                }
+            }
+        }
+
+        private synchronized LlamaCppPoolAutoscaler.Load load() {
+            int waiting = permits.getQueueLength();
+            long maxWait = 0;
+            for (SessionContext session : sessions.values()) {
+                var queue = session.runner().getQueueStatus();
+                if (queue != null) {
+                    waiting += queue.queued();
+                    if (queue.estimatedWaitMs() != null) {
+                        maxWait = Math.max(maxWait, queue.estimatedWaitMs());
+                    }
+                } else {
+                    // without continuous batching a runner serves one request at a time
+                    waiting += Math.max(0, inFlight.getOrDefault(session.sessionId(), 0) - 1);
+                }
+            }
+            return new LlamaCppPoolAutoscaler.Load(sessions.size(), waiting, maxWait);
+        }
+
+        private void scaleUp(int runners) {
+            int createdIn;
+            synchronized (this) {
+                createdIn = generation;
+            }
+            SessionContext session;
+            try {
+                session = createSession(true);
+            } catch (Exception e) {
+                log.warnf("Could not add a runner to pool %s: %s", poolKey, e.getMessage());
+                return;
+            }
+            totalActiveSessions.incrementAndGet();
+            synchronized (this) {
+                if (createdIn == generation && !shutdown) {
+                    sessions.put(session.sessionId(), session);
+                    log.infof("Scaled pool %s up to %d runners", poolKey, runners + 1);
+                    return;
+                }
+            }
+            // a reload swapped the pool's runners while this one was loading
+            closeRetired(session);
+        }
+
+        private void scaleDown() {
+            SessionContext victim;
+            boolean busy;
+            synchronized (this) {
+                if (sessions.size() <= 1) {
+                    return;
+                }
+                victim = sessions.values().stream()
+                        .min(java.util.Comparator.comparingInt(s -> inFlight.getOrDefault(s.sessionId(), 0)))
+                        .orElseThrow();
+                sessions.remove(victim.sessionId());
+                busy = inFlight.getOrDefault(victim.sessionId(), 0) > 0;
+                if (busy) {
+                    retired.put(victim.sessionId(), victim);
+                }
+                log.infof("Scaling pool %s down to %d runners", poolKey, sessions.size());
+            }
+            if (!busy) {
+                closeRetired(victim);
+            }
+        }
+
+        private SessionContext createSession(boolean warmup) {
             String sessionId = java.util.UUID.randomUUID().toString();
 
             // Create artifact location
@@ -186,7 +481,8 @@ public class LlamaCppSessionManager {
                     .build();
 
             // Create runner configuration
-            int effectiveGpuLayers = LlamaCppDeviceSupport.resolveGpuLayers(config);
+            int effectiveGpuLayers = gpuLayerOverrides.getOrDefault(modelId,
+                    LlamaCppDeviceSupport.resolveGpuLayers(config));
             Map<String, Object> runnerConfig = Map.of(
                     "nGpuLayers", effectiveGpuLayers,
                     "nThreads", config.threads(),
@@ -217,7 +513,7 @@ public class LlamaCppSessionManager {
                 }
 
                 // Warmup runner if configured
-                if (config.prewarmEnabled()) {
+                if (warmup || config.prewarmEnabled()) {
                     runner.warmup(runner.createDefaultWarmupRequests());
                 }
                 if (meterRegistry != null) {
@@ -269,6 +565,10 @@ public class LlamaCppSessionManager {
             log.debugf("Shutting down session pool %s with %d sessions",
                     poolKey, sessions.size());
 
+            sessions.putAll(retired);
+            retired.clear();
+            suspects.values().forEach(suspect -> sessions.put(suspect.session().sessionId(), suspect.session()));
+            suspects.clear();
             sessions.values().forEach(session -> {
                 try {
                     session.runner().close();
@@ -282,10 +582,14 @@ public class LlamaCppSessionManager {
         }
 
         int size() {
-            return sessions.size();
+            return sessions.size() + retired.size() + suspects.size();
         }
     }
 
+    /** A session out of rotation since generation {@code generation} of its pool. */
+    private record Suspect(SessionContext session, int generation) {
+    }
+
     /**
      * Initialize session manager
      */
@@ -299,6 +603,8 @@ public class LlamaCppSessionManager {
 
         // Start cleanup task
         startCleanupTask();
+        startAutoscaleTask();
+        startStallWatch();
 
         initialized = true;
         log.info("GGUF Session Manager initialized");
@@ -361,6 +667,142 @@ public class LlamaCppSessionManager {
         return totalActiveSessions.get();
     }
 
+    /**
+     * Load {@code modelPath} for every pool serving {@code modelId}, warm the new runners
+     * up, then swap them in. New requests go to the new runners; the old ones are closed
+     * as soon as their in-flight requests are released. If any load fails nothing is
+     * swapped. A null path reloads the model's current file.
+     */
+    public ReloadableProvider.ModelReload reloadModel(String modelId, String modelPath) {
+        return reloadModel(modelId, modelPath, ReloadableProvider.ReloadOptions.DEFAULT);
+    }
+
+    /**
+     * As {@link #reloadModel(String, String)}, optionally with a new {@code gpu_layers}.
+     * With {@code drain} each pool first stops taking requests (they queue), waits for the
+     * in-flight ones and closes its runners, so the VRAM they held is free for the new
+     * load. If a drained load fails the previous settings are restored and the pools load
+     * them again on their next request.
+     */
+    public ReloadableProvider.ModelReload reloadModel(String modelId, String modelPath,
+            ReloadableProvider.ReloadOptions options) {
+        ensureInitialized();
+        if (modelId == null || modelId.isBlank()) {
+            throw new IllegalArgumentException("model is required");
+        }
+        if (modelPath != null && !Files.isRegularFile(Path.of(modelPath))) {
+            throw new IllegalArgumentException("Model file not found: " + modelPath);
+        }
+        if (options.gpuLayers() != null && options.gpuLayers() < -1) {
+            throw new IllegalArgumentException("gpu_layers must be -1 (all), 0 (none) or a layer count: "
+                    + options.gpuLayers());
+        }
+        long start = System.nanoTime();
+        synchronized (modelPathOverrides) {
+            String previous = modelPath == null
+                    ? modelPathOverrides.remove(modelId)
+                    : modelPathOverrides.put(modelId, modelPath);
+            Integer previousLayers = options.gpuLayers() == null ? gpuLayerOverrides.get(modelId)
+                    : gpuLayerOverrides.put(modelId, options.gpuLayers());
+            List<SessionPool> targets = pools.values().stream()
+                    .filter(pool -> modelId.equals(pool.modelId))
+                    .toList();
+            List<SessionPool> drained = new ArrayList<>();
+            Map<SessionPool, SessionContext> fresh = new java.util.LinkedHashMap<>();
+            int retiredCount = 0;
+            try {
+                if (options.drain()) {
+                    for (SessionPool pool : targets) {
+                        if (!pool.drain(options.drainTimeout())) {
+                            throw new IllegalStateException("Timed out after " + options.drainTimeout().toMillis()
+                                    + " ms waiting for in-flight requests of " + modelId + " to finish");
+                        }
+                        drained.add(pool);
+                    }
+                    for (SessionPool pool : targets) {
+                        retiredCount += pool.closeAll();
+                    }
+                }
+                for (SessionPool pool : targets) {
+                    fresh.put(pool, pool.createSession(true));
+                    totalActiveSessions.incrementAndGet();
+                }
+            } catch (RuntimeException | InterruptedException e) {
+                fresh.values().forEach(session -> {
+                    session.runner().close();
+                    totalActiveSessions.decrementAndGet();
+                });
+                restore(modelPathOverrides, modelId, previous);
+                restore(gpuLayerOverrides, modelId, previousLayers);
+                drained.forEach(SessionPool::resume);
+                if (e instanceof InterruptedException) {
+                    Thread.currentThread().interrupt();
+                    throw new IllegalStateException("Interrupted while draining " + modelId, e);
+                }
+                throw (RuntimeException) e;
+            }
+            for (var entry : fresh.entrySet()) {
+                retiredCount += entry.getKey().swap(entry.getValue());
+            }
+            drained.forEach(SessionPool::resume);
+            long loadMillis = TimeUnit.NANOSECONDS.toMillis(System.nanoTime() - start);
+            String effectivePath = modelPath != null ? modelPath : resolveModelPath(modelId, null);
+            log.infof("Reloaded model %s from %s%s (%d pools swapped, %d sessions retired, %d ms)",
+                    modelId, effectivePath,
+                    options.gpuLayers() == null ? "" : " with gpu_layers=" + options.gpuLayers(),
+                    fresh.size(), retiredCount, loadMillis);
+            return new ReloadableProvider.ModelReload(modelId, effectivePath, fresh.size(), retiredCount, loadMillis,
+                    options.gpuLayers());
+        }
+    }
+
+    private static <V> void restore(Map<String, V> overrides, String modelId, V previous) {
+        if (previous == null) {
+            overrides.remove(modelId);
+        } else {
+            overrides.put(modelId, previous);
+        }
+    }
+
+    /**
+     * Per-slot KV cache occupancy of every pooled runner, plus its batch queue depth and
+     * predicted wait, as plain maps so it can be carried in provider health details.
+     */
+    public java.util.List<Map<String, Object>> describeSlots() {
+        java.util.List<Map<String, Object>> out = new java.util.ArrayList<>();
+        pools.values().forEach(pool -> pool.sessions.values().forEach(session -> {
+            Map<String, Object> entry = new java.util.LinkedHashMap<>();
+            entry.put("model", pool.modelId);
+            entry.put("session_id", session.sessionId());
+            entry.put("slots", session.runner().getSlots().stream().map(LlamaCppSlotStats.Slot::toMap).toList());
+            LlamaCppBatchScheduler.QueueStatus queue = session.runner().getQueueStatus();
+            if (queue != null) {
+                entry.put("queued", queue.queued());
+                entry.put("estimated_wait_ms", queue.estimatedWaitMs());
+            }
+            out.add(entry);
+        }));
+        return out;
+    }
+
+    /**
+     * The compute backend, chat template and file of each loaded model, one entry per model.
+     */
+    public java.util.List<Map<String, Object>> describeBackends() {
+        Map<String, Map<String, Object>> byModel = new java.util.LinkedHashMap<>();
+        pools.values().forEach(pool -> pool.sessions.values().stream().findFirst().ifPresent(session ->
+                byModel.computeIfAbsent(pool.modelId, model -> {
+                    Map<String, Object> entry = new java.util.LinkedHashMap<>();
+                    entry.put("model", model);
+                    entry.put("backend", session.runner().getBackend().id());
+                    entry.put("gpu_layers", session.runner().getGpuLayers());
+                    entry.put("chat_template", session.runner().getChatTemplateInfo());
+                    entry.put("path", session.runner().getModelPath());
+                    return entry;
+                })));
+        return new java.util.ArrayList<>(byModel.values());
+    }
+
     /**
      * Check if session manager is healthy
      */
@@ -384,6 +826,15 @@ public class LlamaCppSessionManager {
         if (cleanupExecutor != null) {
             cleanupExecutor.shutdownNow();
         }
+        if (autoscaleExecutor != null) {
+            autoscaleExecutor.shutdownNow();
+        }
+        if (stallExecutor != null) {
+            stallExecutor.shutdownNow();
+        }
+        if (stallRecoveryExecutor != null) {
+            stallRecoveryExecutor.shutdownNow();
+        }
         pools.values().forEach(SessionPool::shutdown);
         pools.clear();
 
@@ -402,8 +853,19 @@ public class LlamaCppSessionManager {
 
     private String resolveModelPath(String modelId, LlamaCppProviderConfig config) {
         if (modelId == null) return null;
+        String reloaded = modelPathOverrides.get(modelId);
+        if (reloaded != null) return reloaded;
         if (modelId.startsWith("/")) return modelId;
 
+        // 0. Registry name of a model pulled into the base path (`gollek models pull`)
+        if (config != null && config.modelBasePath() != null && !modelId.contains("/")) {
+            Path named = Path.of(config.modelBasePath(),
+                    modelId.toLowerCase(java.util.Locale.ROOT) + ".gguf");
+            if (Files.isRegularFile(named)) {
+                return named.toAbsolutePath().toString();
+            }
+        }
+
         // 1. Try manifest resolution
         var manifest = manifestStore.findByModelId(modelId, "gguf");
         if (manifest.isPresent()) {
@@ -441,6 +903,89 @@ public class LlamaCppSessionManager {
         log.infof("Started GGUF session cleanup task (interval=%ds)", intervalSeconds);
     }
 
+    /**
+     * Runs on its own thread: loading a runner takes seconds and must not hold up idle
+     * cleanup. Pools without autoscaling skip the tick.
+     */
+    private void startAutoscaleTask() {
+        if (autoscaleExecutor != null) {
+            return;
+        }
+        autoscaleExecutor = Executors.newSingleThreadScheduledExecutor(r -> {
+            Thread t = new Thread(r, "gollek-gguf-pool-autoscale");
+            t.setDaemon(true);
+            return t;
+        });
+        autoscaleExecutor.scheduleWithFixedDelay(this::autoscalePools, AUTOSCALE_INTERVAL_SECONDS,
+                AUTOSCALE_INTERVAL_SECONDS, TimeUnit.SECONDS);
+    }
+
+    void autoscalePools() {
+        if (shutdown) {
+            return;
+        }
+        long now = System.currentTimeMillis();
+        pools.values().forEach(pool -> {
+            try {
+                pool.autoscale(now);
+            } catch (Exception e) {
+                log.warnf(e, "Autoscaling pool %s failed", pool.poolKey);
+            }
+        });
+    }
+
+    /**
+     * Checks every pool for stalled runners each second. Recoveries run on their own
+     * threads, since a canary can wait for {@code stall.canary-timeout}; pools without
+     * {@code stall.timeout} skip the tick.
+     */
+    private void startStallWatch() {
+        if (stallExecutor != null) {
+            return;
+        }
+        stallExecutor = Executors.newSingleThreadScheduledExecutor(r -> {
+            Thread t = new Thread(r, "gollek-gguf-stall-watch");
+            t.setDaemon(true);
+            return t;
+        });
+        stallRecoveryExecutor = Executors.newCachedThreadPool(r -> {
+            Thread t = new Thread(r, "gollek-gguf-stall-recovery");
+            t.setDaemon(true);
+            return t;
+        });
+        stallExecutor.scheduleWithFixedDelay(this::checkStalls, STALL_CHECK_INTERVAL_SECONDS,
+                STALL_CHECK_INTERVAL_SECONDS, TimeUnit.SECONDS);
+    }
+
+    void checkStalls() {
+        if (shutdown) {
+            return;
+        }
+        pools.values().forEach(pool -> {
+            try {
+                for (SessionContext session : pool.markStalled()) {
+                    stallEvents.incrementAndGet();
+                    stallRecoveryExecutor.execute(() -> {
+                        try {
+                            pool.recover(session);
+                        } catch (Exception e) {
+                            log.warnf(e, "Recovering session %s failed", session.sessionId());
+                        }
+                    });
+                }
+            } catch (Exception e) {
+                log.warnf(e, "Stall check of pool %s failed", pool.poolKey);
+            }
+        });
+    }
+
+    /** Stall events, failed canaries and restarted runners since startup. */
+    public Map<String, Long> stallStats() {
+        return Map.of("stall_events", stallEvents.get(),
+                "canary_failures", canaryFailures.get(),
+                "runner_restarts", runnerRestarts.get());
+    }
+
     /**
      * Manual cleanup trigger (can be called by scheduler)
      */
@@ -559,6 +1104,18 @@ public class LlamaCppSessionManager {
                     .tag("provider", "gguf")
                     .description("Total sessions reclaimed by idle-eviction loops")
                     .register(meterRegistry);
+            FunctionCounter.builder("gollek.session.stall.events_total", stallEvents, AtomicLong::get)
+                    .tag("provider", "gguf")
+                    .description("Runners taken out of rotation because a request stopped making progress")
+                    .register(meterRegistry);
+            FunctionCounter.builder("gollek.session.stall.canary_failures_total", canaryFailures, AtomicLong::get)
+                    .tag("provider", "gguf")
+                    .description("Stalled runners that failed their canary")
+                    .register(meterRegistry);
+            FunctionCounter.builder("gollek.session.stall.restarts_total", runnerRestarts, AtomicLong::get)
+                    .tag("provider", "gguf")
+                    .description("Runners loaded to replace one that failed its canary")
+                    .register(meterRegistry);
             adaptiveMetricsRegistered = true;
         }
     }
@@ -0,0 +1,200 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Per-sequence KV cache occupancy for one runner. A slot is a llama.cpp sequence id:
+ * the single-sequence executor always uses slot 0, the continuous batching scheduler
+ * assigns one slot per in-flight request.
+ *
+ * <p>An eviction is any removal of cached tokens from a slot, whether a prefix mismatch
+ * trims the history, the cache is cleared, or a finished sequence is released.
+ *
+ * <p>An active slot's progress is the last time it was acquired or its token count
+ * changed, which prompt chunks and sampled tokens both do; {@link #stalledRequests}
+ * finds the ones that have gone quiet.
+ */
+public class LlamaCppSlotStats {
+
+    /**
+     * Point-in-time view of one slot.
+     */
+    public record Slot(int id, boolean active, String requestId, int tokens, int capacity,
+            long prefixHits, long prefixMisses, long reusedTokens, long evictions, long evictedTokens) {
+
+        public Map<String, Object> toMap() {
+            Map<String, Object> map = new LinkedHashMap<>();
+            map.put("id", id);
+            map.put("active", active);
+            map.put("request_id", requestId);
+            map.put("tokens", tokens);
+            map.put("capacity", capacity);
+            map.put("prefix_hits", prefixHits);
+            map.put("prefix_misses", prefixMisses);
+            map.put("reused_tokens", reusedTokens);
+            map.put("evictions", evictions);
+            map.put("evicted_tokens", evictedTokens);
+            return map;
+        }
+    }
+
+    private static final class State {
+        boolean active;
+        String requestId;
+        int tokens;
+        long lastProgressNanos;
+        long prefixHits;
+        long prefixMisses;
+        long reusedTokens;
+        long evictions;
+        long evictedTokens;
+    }
+
+    private final State[] slots;
+    private final int capacity;
+
+    public LlamaCppSlotStats(int slots, int capacity) {
+        this.slots = new State[Math.max(1, slots)];
+        for (int i = 0; i < this.slots.length; i++) {
+            this.slots[i] = new State();
+        }
+        this.capacity = Math.max(0, capacity);
+    }
+
+    public int size() {
+        return slots.length;
+    }
+
+    /**
+     * Tokens each slot can hold; zero when unknown.
+     */
+    public int capacity() {
+        return capacity;
+    }
+
+    public synchronized void acquire(int slot, String requestId) {
+        State s = state(slot);
+        if (s != null) {
+            s.active = true;
+            s.requestId = requestId;
+            s.lastProgressNanos = System.nanoTime();
+        }
+    }
+
+    /**
+     * Mark a slot idle. Cached tokens stay counted until they are evicted.
+     */
+    public synchronized void idle(int slot) {
+        State s = state(slot);
+        if (s != null) {
+            s.active = false;
+            s.requestId = null;
+        }
+    }
+
+    public synchronized void setTokens(int slot, int tokens) {
+        State s = state(slot);
+        if (s != null) {
+            s.tokens = Math.max(0, tokens);
+            s.lastProgressNanos = System.nanoTime();
+        }
+    }
+
+    /**
+     * Requests of active slots without progress for more than {@code thresholdNanos}.
+     */
+    public synchronized List<String> stalledRequests(long nowNanos, long thresholdNanos) {
+        List<String> stalled = new ArrayList<>();
+        for (State s : slots) {
+            if (s.active && nowNanos - s.lastProgressNanos > thresholdNanos) {
+                stalled.add(s.requestId);
+            }
+        }
+        return stalled;
+    }
+
+    public synchronized int activeSlots() {
+        int active = 0;
+        for (State s : slots) {
+            if (s.active) {
+                active++;
+            }
+        }
+        return active;
+    }
+
+    /**
+     * Record a prefix cache lookup; {@code reusedTokens == 0} is a miss.
+     */
+    public synchronized void recordPrefixLookup(int slot, int reusedTokens) {
+        State s = state(slot);
+        if (s == null) {
+            return;
+        }
+        if (reusedTokens > 0) {
+            s.prefixHits++;
+            s.reusedTokens += reusedTokens;
+        } else {
+            s.prefixMisses++;
+        }
+    }
+
+    /**
+     * Record that {@code tokens} cached tokens were dropped from a slot.
+     */
+    public synchronized void recordEviction(int slot, int tokens) {
+        State s = state(slot);
+        if (s == null || tokens <= 0) {
+            return;
+        }
+        s.evictions++;
+        s.evictedTokens += tokens;
+        s.tokens = Math.max(0, s.tokens - tokens);
+    }
+
+    public synchronized List<Slot> snapshot() {
+        List<Slot> out = new ArrayList<>(slots.length);
+        for (int i = 0; i < slots.length; i++) {
+            State s = slots[i];
+            out.add(new Slot(i, s.active, s.requestId, s.tokens, capacity, s.prefixHits, s.prefixMisses,
+                    s.reusedTokens, s.evictions, s.evictedTokens));
+        }
+        return out;
+    }
+
+    public synchronized int tokens(int slot) {
+        State s = state(slot);
+        return s == null ? 0 : s.tokens;
+    }
+
+    public synchronized long totalTokens() {
+        long total = 0;
+        for (State s : slots) {
+            total += s.tokens;
+        }
+        return total;
+    }
+
+    public synchronized long totalEvictions() {
+        long total = 0;
+        for (State s : slots) {
+            total += s.evictions;
+        }
+        return total;
+    }
+
+    public synchronized long totalEvictedTokens() {
+        long total = 0;
+        for (State s : slots) {
+            total += s.evictedTokens;
+        }
+        return total;
+    }
+
+    private State state(int slot) {
+        return slot >= 0 && slot < slots.length ? slots[slot] : null;
+    }
+}
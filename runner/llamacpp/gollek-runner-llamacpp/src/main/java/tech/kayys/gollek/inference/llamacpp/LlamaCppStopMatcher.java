@@ -0,0 +1,83 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import java.util.List;
+
+/**
+ * Rolling stop-sequence matcher for one generation. Pieces are fed as tokens are sampled;
+ * text that could still be the start of a stop sequence (e.g. {@code "\n"} or
+ * {@code "\nHum"} while waiting for {@code "\nHuman:"}) is held back, and everything else is
+ * released for output. A stop that spans several tokens is therefore found and trimmed
+ * before any of it reaches a stream.
+ *
+ * <p>Not thread-safe; each generation loop owns its matcher.
+ */
+final class LlamaCppStopMatcher {
+
+    private final List<String> stops;
+    private final StringBuilder pending = new StringBuilder();
+    private String matched;
+
+    LlamaCppStopMatcher(List<String> stops) {
+        this.stops = stops.stream().filter(s -> s != null && !s.isEmpty()).toList();
+    }
+
+    /**
+     * Appends {@code piece} and returns the text that can no longer be part of a stop, possibly
+     * empty. When a stop completes, returns the text before it and {@link #matched()} is set;
+     * further pieces are ignored.
+     */
+    String accept(String piece) {
+        if (matched != null || piece == null || piece.isEmpty()) {
+            return "";
+        }
+        pending.append(piece);
+        if (stops.isEmpty()) {
+            return drain(pending.length());
+        }
+        int earliest = -1;
+        for (String stop : stops) {
+            int at = pending.indexOf(stop);
+            if (at >= 0 && (earliest < 0 || at < earliest)) {
+                earliest = at;
+                matched = stop;
+            }
+        }
+        if (matched != null) {
+            String released = pending.substring(0, earliest);
+            pending.setLength(0);
+            return released;
+        }
+        return drain(pending.length() - heldBack());
+    }
+
+    /** The held-back text, once generation ended without reaching a stop. */
+    String flush() {
+        return drain(pending.length());
+    }
+
+    /** The stop sequence that ended generation, or null. */
+    String matched() {
+        return matched;
+    }
+
+    /** Length of the longest suffix of the pending text that a stop starts with. */
+    private int heldBack() {
+        String text = pending.toString();
+        int held = 0;
+        for (String stop : stops) {
+            for (int len = Math.min(stop.length() - 1, text.length()); len > held; len--) {
+                if (text.regionMatches(text.length() - len, stop, 0, len)) {
+                    held = len;
+                    break;
+                }
+            }
+        }
+        return held;
+    }
+
+    private String drain(int length) {
+        String released = pending.substring(0, length);
+        pending.delete(0, length);
+        return released;
+    }
+}
@@ -0,0 +1,68 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.spi.exception.InferenceTimeoutException;
+
+import java.util.Map;
+
+/**
+ * A request's stage budgets in milliseconds, each 0 when unset, next to the total
+ * {@code inference_timeout_ms}:
+ *
+ * <ul>
+ * <li>{@value #QUEUE}: from submission until the runner starts the request.</li>
+ * <li>{@value #PREFILL}: from the start until the first token is sampled.</li>
+ * <li>{@value #INTER_TOKEN}: the longest gap between two sampled tokens.</li>
+ * </ul>
+ *
+ * Queue and prefill overruns fail the request, as nothing has been generated; a gap
+ * between tokens ends it with {@code STALLED} and the output so far.
+ */
+record LlamaCppTimeouts(long queueMs, long prefillMs, long interTokenMs) {
+
+    static final String QUEUE = "queue_timeout_ms";
+    static final String PREFILL = "prefill_timeout_ms";
+    static final String INTER_TOKEN = "inter_token_timeout_ms";
+
+    static LlamaCppTimeouts of(Map<String, Object> parameters) {
+        return new LlamaCppTimeouts(millis(parameters, QUEUE), millis(parameters, PREFILL),
+                millis(parameters, INTER_TOKEN));
+    }
+
+    private static long millis(Map<String, Object> parameters, String name) {
+        return parameters.get(name) instanceof Number n && n.longValue() > 0 ? n.longValue() : 0L;
+    }
+
+    /** Whether a request submitted at {@code submittedNanos} has waited too long to start. */
+    boolean queueExpired(long submittedNanos, long nowNanos) {
+        return expired(queueMs, submittedNanos, nowNanos);
+    }
+
+    /** Whether a request started at {@code startNanos} is still without its first token too long. */
+    boolean prefillExpired(long startNanos, long nowNanos) {
+        return expired(prefillMs, startNanos, nowNanos);
+    }
+
+    /** Whether the gap since the token sampled at {@code lastTokenNanos} is too long. */
+    boolean stalled(long lastTokenNanos, long nowNanos) {
+        return expired(interTokenMs, lastTokenNanos, nowNanos);
+    }
+
+    private static boolean expired(long budgetMs, long sinceNanos, long nowNanos) {
+        return budgetMs > 0 && nowNanos - sinceNanos > budgetMs * 1_000_000L;
+    }
+
+    InferenceTimeoutException queueTimedOut() {
+        return new InferenceTimeoutException(InferenceTimeoutException.Stage.QUEUE,
+                "Queue wait timed out (" + QUEUE + "=" + queueMs + ")");
+    }
+
+    InferenceTimeoutException prefillTimedOut() {
+        return new InferenceTimeoutException(InferenceTimeoutException.Stage.PREFILL,
+                "Prompt processing timed out (" + PREFILL + "=" + prefillMs + ")");
+    }
+
+    /** The whole-request deadline passed with nothing to return. */
+    static InferenceTimeoutException timedOut(String message) {
+        return new InferenceTimeoutException(InferenceTimeoutException.Stage.TOTAL, message);
+    }
+}
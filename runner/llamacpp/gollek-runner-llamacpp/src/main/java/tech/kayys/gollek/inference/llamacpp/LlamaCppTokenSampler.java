@@ -2,14 +2,21 @@ package tech.kayys.gollek.inference.llamacpp;
 
 import java.lang.foreign.MemorySegment;
 import java.lang.foreign.ValueLayout;
+import java.util.Map;
 import java.util.Random;
 
 /**
- * Handles token sampling strategies including temperature scaling, top-k, top-p,
- * min-p filtering, and penalty application (repeat, frequency, presence).
+ * Handles token sampling strategies including temperature scaling, top-k, locally typical,
+ * top-p and min-p filtering, mirostat (v1 and v2), logit bias, and penalty application
+ * (repeat, frequency, presence).
  */
 public class LlamaCppTokenSampler {
 
+    /** A bias this low bans the token, as {@code -100} does in the OpenAI API. */
+    static final float BAN = -100.0f;
+    /** Tokens used to estimate the distribution's Zipf exponent in mirostat v1, as llama.cpp does. */
+    private static final int MIROSTAT_M = 100;
+
     private final LlamaCppBinding binding;
     private final int vocabSize;
     private final ThreadLocal<TokenProb[]> tokenBufferLocal;
@@ -38,7 +45,7 @@ public class LlamaCppTokenSampler {
         logits = logits.reinterpret((long) effectiveVocab * Float.BYTES);
 
         if (config.temperature <= 0.0f) {
-            return argMaxToken(logits, effectiveVocab);
+            return argMaxToken(logits, effectiveVocab, config.logitBias);
         }
 
         TokenProb[] tokenBuffer = getTokenBuffer(effectiveVocab);
@@ -48,17 +55,32 @@ public class LlamaCppTokenSampler {
                 effectiveVocab,
                 config);
 
+        // mirostat sets its own cutoff and replaces the top-k / typical / top-p / min-p filters
+        if (config.mirostat == 1 || config.mirostat == 2) {
+            return sampleMirostat(tokenBuffer, candidateCount, config, random);
+        }
+
         if (config.topK == 1) {
             return argMaxToken(tokenBuffer, candidateCount);
         }
 
-        if ((config.topK <= 0) && (config.topP <= 0.0f || config.topP >= 1.0f) && config.minP <= 0.0f) {
+        if ((config.topK <= 0) && (config.topP <= 0.0f || config.topP >= 1.0f) && config.minP <= 0.0f
+                && (config.typicalP <= 0.0f || config.typicalP >= 1.0f)) {
             return sampleFromUnsorted(tokenBuffer, candidateCount, random);
         }
 
         return applyFilteringAndSample(tokenBuffer, candidateCount, config, random);
     }
 
+    /**
+     * Raw logits at {@code batchIndex}, one float per vocabulary entry; valid until the
+     * next decode. Sampling does not modify them.
+     */
+    MemorySegment logits(MemorySegment context, int batchIndex) {
+        int effectiveVocab = vocabSize > 0 ? vocabSize : 32768;
+        return getLogits(context, batchIndex).reinterpret((long) effectiveVocab * Float.BYTES);
+    }
+
     private MemorySegment getLogits(MemorySegment context, int batchIndex) {
         try {
             return binding.getLogitsIth(context, batchIndex);
@@ -92,6 +114,12 @@ public class LlamaCppTokenSampler {
             buffer[i].logit = value * invTemp;
             buffer[i].tokenId = i;
         }
+        for (Map.Entry<Integer, Float> bias : config.logitBias.entrySet()) {
+            int token = bias.getKey();
+            if (token >= 0 && token < effectiveVocab) {
+                buffer[token].logit = biased(buffer[token].logit, bias.getValue(), invTemp);
+            }
+        }
 
         return effectiveVocab;
     }
@@ -122,6 +150,10 @@ public class LlamaCppTokenSampler {
             buffer[i].prob /= sum;
         }
 
+        if (config.typicalP > 0.0f && config.typicalP < 1.0f) {
+            size = applyTypicalSampling(buffer, size, config.typicalP);
+        }
+
         if (config.topP > 0.0f && config.topP < 1.0f) {
             size = applyNucleusSampling(buffer, size, config.topP);
         }
@@ -155,6 +187,110 @@ public class LlamaCppTokenSampler {
         return size;
     }
 
+    /**
+     * Locally typical sampling: keep the tokens whose surprise is closest to the
+     * distribution's entropy until their mass reaches {@code typicalP}. Expects {@code buffer}
+     * sorted by logit with normalized probabilities; leaves it re-sorted by logit.
+     */
+    private int applyTypicalSampling(TokenProb[] buffer, int size, float typicalP) {
+        double entropy = 0.0;
+        for (int i = 0; i < size; i++) {
+            double p = buffer[i].prob;
+            if (p > 0.0) {
+                entropy -= p * Math.log(p);
+            }
+        }
+        final double h = entropy;
+        java.util.Arrays.sort(buffer, 0, size, (a, b) -> Double.compare(deviation(a, h), deviation(b, h)));
+        double cumulative = 0.0;
+        int kept = 0;
+        while (kept < size) {
+            cumulative += buffer[kept++].prob;
+            if (cumulative >= typicalP) {
+                break;
+            }
+        }
+        java.util.Arrays.sort(buffer, 0, kept, (a, b) -> Float.compare(b.logit, a.logit));
+        if (kept < size) {
+            normalizeProbabilities(buffer, kept);
+        }
+        return kept;
+    }
+
+    private static double deviation(TokenProb token, double entropy) {
+        return token.prob > 0.0 ? Math.abs(-Math.log(token.prob) - entropy) : Double.MAX_VALUE;
+    }
+
+    /**
+     * Mirostat (v1 with {@code mirostat=1}, v2 with {@code 2}): truncate so the expected
+     * surprise tracks {@code tau}, adapting {@code mu} in the config after every token, as
+     * llama.cpp's samplers do.
+     */
+    private int sampleMirostat(TokenProb[] buffer, int size, SamplingConfig config, Random random) {
+        partialSelectTopK(buffer, size, size);
+        softmaxSorted(buffer, size);
+        int kept;
+        if (config.mirostat == 1) {
+            // estimate the Zipf exponent from the head of the distribution to size the top-k
+            int m = Math.min(MIROSTAT_M, size - 1);
+            double sumTiBi = 0.0, sumTi2 = 0.0;
+            for (int i = 0; i < m; i++) {
+                if (buffer[i + 1].prob <= 0.0) {
+                    break;
+                }
+                double ti = Math.log((i + 2) / (double) (i + 1));
+                double bi = Math.log(buffer[i].prob / buffer[i + 1].prob);
+                sumTiBi += ti * bi;
+                sumTi2 += ti * ti;
+            }
+            double sHat = sumTi2 > 0.0 ? sumTiBi / sumTi2 : 1.0;
+            double epsilonHat = sHat - 1.0;
+            double k = epsilonHat == 0.0 ? size
+                    : Math.pow(epsilonHat * Math.pow(2.0, config.mirostatMu)
+                            / (1.0 - Math.pow(size, -epsilonHat)), 1.0 / sHat);
+            kept = (int) Math.max(1, Math.min(size, Double.isFinite(k) ? Math.round(k) : size));
+        } else {
+            kept = 0;
+            while (kept < size && -log2(buffer[kept].prob) <= config.mirostatMu) {
+                kept++;
+            }
+            kept = Math.max(1, kept);
+        }
+        normalizeProbabilities(buffer, kept);
+        int index = sampleIndex(buffer, kept, random);
+        double surprise = -log2(buffer[index].prob);
+        config.mirostatMu -= (float) (config.mirostatEta * (surprise - config.mirostatTau));
+        return buffer[index].tokenId;
+    }
+
+    private static double log2(double p) {
+        return Math.log(p) / Math.log(2.0);
+    }
+
+    private static void softmaxSorted(TokenProb[] buffer, int size) {
+        float maxLogit = buffer[0].logit;
+        double sum = 0.0;
+        for (int i = 0; i < size; i++) {
+            buffer[i].prob = Math.exp(buffer[i].logit - maxLogit);
+            sum += buffer[i].prob;
+        }
+        for (int i = 0; i < size && sum > 0.0; i++) {
+            buffer[i].prob /= sum;
+        }
+    }
+
+    private static int sampleIndex(TokenProb[] candidates, int size, Random random) {
+        double r = random.nextDouble();
+        double acc = 0.0;
+        for (int i = 0; i < size; i++) {
+            acc += candidates[i].prob;
+            if (r <= acc) {
+                return i;
+            }
+        }
+        return size - 1;
+    }
+
     private int applyMinPFiltering(TokenProb[] buffer, int size, float minP) {
         double best = buffer[0].prob;
         double threshold = best * minP;
@@ -218,11 +354,23 @@ public class LlamaCppTokenSampler {
         return candidates[size - 1].tokenId;
     }
 
-    private int argMaxToken(MemorySegment logits, int effectiveVocab) {
+    /**
+     * {@code logit} (already divided by the temperature) shifted by {@code bias} at the same
+     * scale; {@link #BAN} or below removes the token outright.
+     */
+    private static float biased(float logit, float bias, float invTemp) {
+        return bias <= BAN ? Float.NEGATIVE_INFINITY : logit + bias * invTemp;
+    }
+
+    private int argMaxToken(MemorySegment logits, int effectiveVocab, Map<Integer, Float> logitBias) {
         int bestId = 0;
         float best = Float.NEGATIVE_INFINITY;
         for (int i = 0; i < effectiveVocab; i++) {
             float value = logits.getAtIndex(ValueLayout.JAVA_FLOAT, i);
+            Float bias = logitBias.isEmpty() ? null : logitBias.get(i);
+            if (bias != null) {
+                value = biased(value, bias, 1.0f);
+            }
             if (value > best) {
                 best = value;
                 bestId = i;
@@ -310,10 +458,35 @@ public class LlamaCppTokenSampler {
         public final float frequencyPenalty;
         public final float presencePenalty;
         public final int[] recentTokenCounts;
+        /** Token id to additive logit bias, OpenAI {@code logit_bias} semantics. */
+        public final Map<Integer, Float> logitBias;
+        /** Locally typical sampling mass; 1.0 disables it. */
+        public final float typicalP;
+        /** 0 off, 1 mirostat, 2 mirostat v2. */
+        public final int mirostat;
+        public final float mirostatTau;
+        public final float mirostatEta;
+        /** Mirostat's running maximum surprise, starting at {@code 2 * tau}; updated per token. */
+        float mirostatMu;
 
         public SamplingConfig(float temperature, int topK, float topP, float minP,
                              float repeatPenalty, float frequencyPenalty, float presencePenalty,
                              int[] recentTokenCounts) {
+            this(temperature, topK, topP, minP, repeatPenalty, frequencyPenalty, presencePenalty, recentTokenCounts,
+                    Map.of());
+        }
+
+        public SamplingConfig(float temperature, int topK, float topP, float minP,
+                             float repeatPenalty, float frequencyPenalty, float presencePenalty,
+                             int[] recentTokenCounts, Map<Integer, Float> logitBias) {
+            this(temperature, topK, topP, minP, repeatPenalty, frequencyPenalty, presencePenalty, recentTokenCounts,
+                    logitBias, 1.0f, 0, 5.0f, 0.1f);
+        }
+
+        public SamplingConfig(float temperature, int topK, float topP, float minP,
+                             float repeatPenalty, float frequencyPenalty, float presencePenalty,
+                             int[] recentTokenCounts, Map<Integer, Float> logitBias,
+                             float typicalP, int mirostat, float mirostatTau, float mirostatEta) {
             this.temperature = temperature;
             this.topK = topK;
             this.topP = topP;
@@ -322,6 +495,12 @@ public class LlamaCppTokenSampler {
             this.frequencyPenalty = frequencyPenalty;
             this.presencePenalty = presencePenalty;
             this.recentTokenCounts = recentTokenCounts;
+            this.logitBias = logitBias == null ? Map.of() : logitBias;
+            this.typicalP = typicalP;
+            this.mirostat = mirostat;
+            this.mirostatTau = mirostatTau;
+            this.mirostatEta = mirostatEta;
+            this.mirostatMu = 2.0f * mirostatTau;
         }
     }
 
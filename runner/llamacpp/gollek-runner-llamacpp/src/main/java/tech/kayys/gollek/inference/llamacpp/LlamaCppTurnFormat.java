@@ -0,0 +1,70 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.spi.Message;
+
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Prompt layout for chat requests against base models, which have no chat template to
+ * render with. Each message becomes {@code prefix + content + suffix} for its role, and
+ * the prompt ends with an open assistant turn. Tool and function results are laid out
+ * as user turns.
+ */
+record LlamaCppTurnFormat(String systemPrefix, String systemSuffix, String userPrefix, String userSuffix,
+        String assistantPrefix, String assistantSuffix, List<String> stops) {
+
+    static final LlamaCppTurnFormat PLAIN = new LlamaCppTurnFormat("", "\n\n", "User: ", "\n\n",
+            "Assistant: ", "\n\n", List.of("\nUser:"));
+
+    static final LlamaCppTurnFormat ALPACA = new LlamaCppTurnFormat("", "\n\n", "### Instruction:\n", "\n\n",
+            "### Response:\n", "\n\n", List.of("### Instruction:"));
+
+    static final LlamaCppTurnFormat CHATML = new LlamaCppTurnFormat("<|im_start|>system\n", "<|im_end|>\n",
+            "<|im_start|>user\n", "<|im_end|>\n", "<|im_start|>assistant\n", "<|im_end|>\n", List.of("<|im_end|>"));
+
+    private static final Map<String, LlamaCppTurnFormat> PRESETS = Map.of(
+            "plain", PLAIN, "alpaca", ALPACA, "chatml", CHATML);
+
+    /**
+     * The format configured for {@code modelId}, or {@code null} to use the model's chat template.
+     *
+     * @throws IllegalArgumentException if the configured preset is unknown
+     */
+    static LlamaCppTurnFormat forModel(LlamaCppProviderConfig config, String modelId) {
+        Map<String, LlamaCppProviderConfig.TurnFormat> formats = config.turnFormats();
+        if (modelId == null || formats == null || !formats.containsKey(modelId)) {
+            return null;
+        }
+        LlamaCppProviderConfig.TurnFormat format = formats.get(modelId);
+        LlamaCppTurnFormat preset = PRESETS.get(format.preset().toLowerCase());
+        if (preset == null) {
+            throw new IllegalArgumentException("Unknown turn-format preset for " + modelId + ": " + format.preset()
+                    + " (expected one of " + PRESETS.keySet() + ")");
+        }
+        return new LlamaCppTurnFormat(
+                format.systemPrefix().orElse(preset.systemPrefix),
+                format.systemSuffix().orElse(preset.systemSuffix),
+                format.userPrefix().orElse(preset.userPrefix),
+                format.userSuffix().orElse(preset.userSuffix),
+                format.assistantPrefix().orElse(preset.assistantPrefix),
+                format.assistantSuffix().orElse(preset.assistantSuffix),
+                // a custom user prefix marks the next turn; stop there unless told otherwise
+                format.stop().orElseGet(() -> format.userPrefix().filter(p -> !p.isBlank())
+                        .map(p -> List.of("\n" + p.strip())).orElse(preset.stops)));
+    }
+
+    String render(List<Message> messages) {
+        StringBuilder sb = new StringBuilder();
+        for (Message message : messages) {
+            String content = message.getContent() == null ? "" : message.getContent();
+            switch (message.getRole()) {
+                case SYSTEM -> sb.append(systemPrefix).append(content).append(systemSuffix);
+                case ASSISTANT -> sb.append(assistantPrefix).append(content).append(assistantSuffix);
+                default -> sb.append(userPrefix).append(content).append(userSuffix);
+            }
+        }
+        // a trailing space would be tokenized on its own; the model's first token carries it instead
+        return sb.append(assistantPrefix.replaceAll(" +$", "")).toString();
+    }
+}
@@ -0,0 +1,85 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.Arrays;
+import java.util.LinkedHashMap;
+import java.util.Map;
+
+/**
+ * The debugging report a request asks for with {@code "verbose": true}, returned in the
+ * {@value #METADATA} metadata: the sampled token ids, the sampler settings in effect after
+ * defaults and clamping, how much of the prompt came from the KV cache, and timings. The
+ * server turns it on for {@code response_format: verbose_json}.
+ */
+final class LlamaCppVerbose {
+
+    /** Request flag. */
+    static final String PARAMETER = "verbose";
+    /** Response and final chunk metadata key. */
+    static final String METADATA = "verbose";
+
+    private LlamaCppVerbose() {
+    }
+
+    static boolean requested(InferenceRequest request) {
+        return InferenceLogicExecutor.flag(request, PARAMETER);
+    }
+
+    /**
+     * @param output       sampled token ids; the first {@code generated} are reported
+     * @param reusedTokens prompt tokens whose KV entries were reused rather than evaluated
+     * @param promptEnd    {@link System#nanoTime()} when prefill finished, 0 if it did not
+     * @param firstToken   {@link System#nanoTime()} of the first sampled token, 0 if none
+     */
+    static Map<String, Object> report(InferenceLogicExecutor.GenerationParams params, int maxTokens, int[] output,
+            int generated, int promptTokens, int reusedTokens, long requestStart, long promptEnd, long firstToken) {
+        long end = System.nanoTime();
+        Map<String, Object> sampler = new LinkedHashMap<>();
+        sampler.put("temperature", params.temperature());
+        sampler.put("top_k", params.topK());
+        sampler.put("top_p", params.topP());
+        sampler.put("min_p", params.minP());
+        sampler.put("typical_p", params.typicalP());
+        sampler.put("repeat_penalty", params.repeatPenalty());
+        sampler.put("frequency_penalty", params.frequencyPenalty());
+        sampler.put("presence_penalty", params.presencePenalty());
+        sampler.put("repeat_last_n", params.effectiveRepeatLastN());
+        sampler.put("mirostat", params.mirostat());
+        sampler.put("mirostat_tau", params.mirostatTau());
+        sampler.put("mirostat_eta", params.mirostatEta());
+        sampler.put("seed", params.seed());
+        sampler.put("max_tokens", maxTokens);
+        if (!params.logitBias().isEmpty()) {
+            sampler.put("logit_bias", params.logitBias());
+        }
+
+        Map<String, Object> cache = new LinkedHashMap<>();
+        cache.put("prompt_tokens", promptTokens);
+        cache.put("reused_tokens", reusedTokens);
+        cache.put("evaluated_tokens", promptTokens - reusedTokens);
+
+        long decodeStart = promptEnd > 0 ? promptEnd : end;
+        Map<String, Object> timings = new LinkedHashMap<>();
+        timings.put("prompt_ms", millis(decodeStart - requestStart));
+        timings.put("decode_ms", millis(end - decodeStart));
+        if (firstToken > 0) {
+            timings.put("ttft_ms", millis(firstToken - requestStart));
+        }
+        timings.put("total_ms", millis(end - requestStart));
+        if (generated > 0 && end > decodeStart) {
+            timings.put("tokens_per_second", Math.round(generated * 1e10 / (end - decodeStart)) / 10.0);
+        }
+
+        Map<String, Object> report = new LinkedHashMap<>();
+        report.put("token_ids", Arrays.stream(output, 0, generated).boxed().toList());
+        report.put("sampler", sampler);
+        report.put("cache", cache);
+        report.put("timings", timings);
+        return report;
+    }
+
+    private static double millis(long nanos) {
+        return Math.max(0L, nanos) / 1_000L / 1000.0;
+    }
+}
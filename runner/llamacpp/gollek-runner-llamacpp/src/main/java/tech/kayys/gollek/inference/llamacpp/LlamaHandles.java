@@ -35,10 +35,24 @@ final class LlamaHandles {
     final MethodHandle initFromModel;
     final MethodHandle freeModel;
     final MethodHandle freeContext;
+    final MethodHandle setAbortCallback;          // optional
 
     // ── KV cache ─────────────────────────────────────────────────────────────
     final MethodHandle getMemory;
     final MethodHandle memoryClear;
+    final MethodHandle memorySeqRm;               // optional
+    final MethodHandle memorySeqAdd;              // optional
+    final MethodHandle memoryCanShift;            // optional
+
+    // ── State snapshots (all optional) ───────────────────────────────────────
+    final MethodHandle stateGetSize;
+    final MethodHandle stateGetData;
+    final MethodHandle stateSetData;
+    final MethodHandle stateSeqGetSize;
+    final MethodHandle stateSeqGetData;
+    final MethodHandle stateSeqSetData;
+    final MethodHandle stateSeqSaveFile;
+    final MethodHandle stateSeqLoadFile;
 
     // ── Vocab / metadata ─────────────────────────────────────────────────────
     final MethodHandle modelGetVocab;
@@ -80,11 +94,14 @@ final class LlamaHandles {
     final MethodHandle samplerInitMirostatV2;
     final MethodHandle samplerInitGrammar;
     final MethodHandle samplerInitTypical;        // optional
+    final MethodHandle samplerInitLogitBias;      // optional
 
     // ── Embeddings ───────────────────────────────────────────────────────────
     final MethodHandle nEmbd;
     final MethodHandle getEmbeddings;
     final MethodHandle getEmbeddingsIth;
+    final MethodHandle getEmbeddingsSeq;          // optional
+    final MethodHandle poolingType;               // optional
 
     // ── LoRA adapters (all optional) ─────────────────────────────────────────
     final MethodHandle adapterLoraInit;
@@ -93,6 +110,21 @@ final class LlamaHandles {
     final MethodHandle clearAdapterLora;
     final MethodHandle adapterLoraFree;
 
+    // ── ggml device registry (all optional) ──────────────────────────────────
+    final MethodHandle backendDevCount;
+    final MethodHandle backendDevGet;
+    final MethodHandle backendDevName;
+    final MethodHandle backendDevType;
+    final MethodHandle backendDevBackendReg;
+    final MethodHandle backendDevMemory;
+    final MethodHandle backendRegName;
+    final MethodHandle printSystemInfo;           // optional
+    final MethodHandle backendRegCount;           // optional
+    final MethodHandle backendLoad;               // optional, GGML_BACKEND_DL builds
+    final MethodHandle backendRegGet;             // optional
+    final MethodHandle ggmlVersion;               // optional, newer ggml
+    final MethodHandle ggmlCommit;                // optional, newer ggml
+
     // ── Verbosity flag (read from system properties or set after construction) ────────────────
     boolean verbose = false;
 
@@ -118,11 +150,44 @@ final class LlamaHandles {
                 FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS, LlamaStructLayouts.CONTEXT_PARAMS));
         freeModel   = link(linker, lookup, "llama_free_model",   FunctionDescriptor.ofVoid(ValueLayout.ADDRESS));
         freeContext = link(linker, lookup, "llama_free",         FunctionDescriptor.ofVoid(ValueLayout.ADDRESS));
+        setAbortCallback = linkOpt(linker, lookup, "llama_set_abort_callback",
+                FunctionDescriptor.ofVoid(ValueLayout.ADDRESS, ValueLayout.ADDRESS, ValueLayout.ADDRESS));
 
         getMemory    = link(linker, lookup, "llama_get_memory",
                 FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS));
         memoryClear  = link(linker, lookup, "llama_memory_clear",
                 FunctionDescriptor.ofVoid(ValueLayout.ADDRESS, ValueLayout.JAVA_BOOLEAN));
+        memorySeqRm  = linkOpt(linker, lookup, "llama_memory_seq_rm",
+                FunctionDescriptor.of(ValueLayout.JAVA_BOOLEAN, ValueLayout.ADDRESS, ValueLayout.JAVA_INT,
+                        ValueLayout.JAVA_INT, ValueLayout.JAVA_INT));
+        memorySeqAdd = linkOpt(linker, lookup, "llama_memory_seq_add",
+                FunctionDescriptor.ofVoid(ValueLayout.ADDRESS, ValueLayout.JAVA_INT, ValueLayout.JAVA_INT,
+                        ValueLayout.JAVA_INT, ValueLayout.JAVA_INT));
+        memoryCanShift = linkOpt(linker, lookup, "llama_memory_can_shift",
+                FunctionDescriptor.of(ValueLayout.JAVA_BOOLEAN, ValueLayout.ADDRESS));
+
+        stateGetSize     = linkOpt(linker, lookup, "llama_state_get_size",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG, ValueLayout.ADDRESS));
+        stateGetData     = linkOpt(linker, lookup, "llama_state_get_data",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG, ValueLayout.ADDRESS, ValueLayout.ADDRESS,
+                        ValueLayout.JAVA_LONG));
+        stateSetData     = linkOpt(linker, lookup, "llama_state_set_data",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG, ValueLayout.ADDRESS, ValueLayout.ADDRESS,
+                        ValueLayout.JAVA_LONG));
+        stateSeqGetSize  = linkOpt(linker, lookup, "llama_state_seq_get_size",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG, ValueLayout.ADDRESS, ValueLayout.JAVA_INT));
+        stateSeqGetData  = linkOpt(linker, lookup, "llama_state_seq_get_data",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG, ValueLayout.ADDRESS, ValueLayout.ADDRESS,
+                        ValueLayout.JAVA_LONG, ValueLayout.JAVA_INT));
+        stateSeqSetData  = linkOpt(linker, lookup, "llama_state_seq_set_data",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG, ValueLayout.ADDRESS, ValueLayout.ADDRESS,
+                        ValueLayout.JAVA_LONG, ValueLayout.JAVA_INT));
+        stateSeqSaveFile = linkOpt(linker, lookup, "llama_state_seq_save_file",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG, ValueLayout.ADDRESS, ValueLayout.ADDRESS,
+                        ValueLayout.JAVA_INT, ValueLayout.ADDRESS, ValueLayout.JAVA_LONG));
+        stateSeqLoadFile = linkOpt(linker, lookup, "llama_state_seq_load_file",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG, ValueLayout.ADDRESS, ValueLayout.ADDRESS,
+                        ValueLayout.JAVA_INT, ValueLayout.ADDRESS, ValueLayout.JAVA_LONG, ValueLayout.ADDRESS));
 
         modelGetVocab    = link(linker, lookup, "llama_model_get_vocab",
                 FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS));
@@ -202,6 +267,9 @@ final class LlamaHandles {
                         ValueLayout.ADDRESS, ValueLayout.ADDRESS));
         samplerInitTypical = linkOpt(linker, lookup, "llama_sampler_init_typical",
                 FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.JAVA_FLOAT, ValueLayout.JAVA_LONG));
+        samplerInitLogitBias = linkOpt(linker, lookup, "llama_sampler_init_logit_bias",
+                FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.JAVA_INT, ValueLayout.JAVA_INT,
+                        ValueLayout.ADDRESS));
 
         nEmbd           = link(linker, lookup, "llama_n_embd",
                 FunctionDescriptor.of(ValueLayout.JAVA_INT, ValueLayout.ADDRESS));
@@ -209,6 +277,10 @@ final class LlamaHandles {
                 FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS));
         getEmbeddingsIth = link(linker, lookup, "llama_get_embeddings_ith",
                 FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS, ValueLayout.JAVA_INT));
+        getEmbeddingsSeq = linkOpt(linker, lookup, "llama_get_embeddings_seq",
+                FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS, ValueLayout.JAVA_INT));
+        poolingType      = linkOpt(linker, lookup, "llama_pooling_type",
+                FunctionDescriptor.of(ValueLayout.JAVA_INT, ValueLayout.ADDRESS));
 
         adapterLoraInit  = linkOpt(linker, lookup, "llama_adapter_lora_init",
                 FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS, ValueLayout.ADDRESS));
@@ -221,6 +293,33 @@ final class LlamaHandles {
                 FunctionDescriptor.ofVoid(ValueLayout.ADDRESS));
         adapterLoraFree  = linkOpt(linker, lookup, "llama_adapter_lora_free",
                 FunctionDescriptor.ofVoid(ValueLayout.ADDRESS));
+
+        backendDevCount      = linkOpt(linker, lookup, "ggml_backend_dev_count",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG));
+        backendDevGet        = linkOpt(linker, lookup, "ggml_backend_dev_get",
+                FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.JAVA_LONG));
+        backendDevName       = linkOpt(linker, lookup, "ggml_backend_dev_name",
+                FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS));
+        backendDevType       = linkOpt(linker, lookup, "ggml_backend_dev_type",
+                FunctionDescriptor.of(ValueLayout.JAVA_INT, ValueLayout.ADDRESS));
+        backendDevBackendReg = linkOpt(linker, lookup, "ggml_backend_dev_backend_reg",
+                FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS));
+        backendDevMemory     = linkOpt(linker, lookup, "ggml_backend_dev_memory",
+                FunctionDescriptor.ofVoid(ValueLayout.ADDRESS, ValueLayout.ADDRESS, ValueLayout.ADDRESS));
+        backendRegName       = linkOpt(linker, lookup, "ggml_backend_reg_name",
+                FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS));
+        printSystemInfo      = linkOpt(linker, lookup, "llama_print_system_info",
+                FunctionDescriptor.of(ValueLayout.ADDRESS));
+        backendRegCount      = linkOpt(linker, lookup, "ggml_backend_reg_count",
+                FunctionDescriptor.of(ValueLayout.JAVA_LONG));
+        backendLoad          = linkOpt(linker, lookup, "ggml_backend_load",
+                FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.ADDRESS));
+        backendRegGet        = linkOpt(linker, lookup, "ggml_backend_reg_get",
+                FunctionDescriptor.of(ValueLayout.ADDRESS, ValueLayout.JAVA_LONG));
+        ggmlVersion          = linkOpt(linker, lookup, "ggml_version",
+                FunctionDescriptor.of(ValueLayout.ADDRESS));
+        ggmlCommit           = linkOpt(linker, lookup, "ggml_commit",
+                FunctionDescriptor.of(ValueLayout.ADDRESS));
     }
 
     // ── Linking helpers ───────────────────────────────────────────────────────
@@ -8,6 +8,7 @@ import java.lang.foreign.*;
 import java.lang.invoke.MethodHandle;
 import java.lang.invoke.MethodHandles;
 import java.lang.invoke.MethodType;
+import java.nio.charset.StandardCharsets;
 import java.nio.file.Files;
 import java.nio.file.Path;
 import java.nio.file.StandardCopyOption;
@@ -36,8 +37,16 @@ final class LlamaNativeLoader {
     private static final Logger log = Logger.getLogger(LlamaNativeLoader.class);
     private static final String LIB_BASE_NAME = "llama";
 
+    /** Directory the llama library was loaded from; null until loaded or when unknown. */
+    private static volatile Path loadedLibraryDir;
+
     private LlamaNativeLoader() {}
 
+    /** Directory of the loaded llama library, where ggml backend modules are looked up. */
+    static Optional<Path> libraryDir() {
+        return Optional.ofNullable(loadedLibraryDir);
+    }
+
     /**
      * Loads the native library and returns a {@link SymbolLookup} for it.
      *
@@ -54,6 +63,9 @@ final class LlamaNativeLoader {
         }
         try {
             Path loadedPath = loadNativeLibrary(explicitLibPath, explicitLibDir, verbose);
+            if (loadedPath != null) {
+                loadedLibraryDir = loadedPath.toAbsolutePath().getParent();
+            }
             SymbolLookup loaderLookup = SymbolLookup.loaderLookup();
             
             if (loadedPath != null && Files.exists(loadedPath)) {
@@ -165,24 +177,28 @@ final class LlamaNativeLoader {
 
         if (isMacOS()) {
             fixMacOSLibraryPaths(libraryDir, verbose);
-        }
 
-        // Load ggml base libraries first (strict dependency order)
-        String[] baseLibs = {
-                "libggml-base.dylib", "libggml-base.0.dylib", "libggml-base.0.9.5.dylib",
-                "libggml-cpu.dylib",  "libggml-cpu.0.dylib",  "libggml-cpu.0.9.5.dylib",
-                "libggml-blas.dylib", "libggml-blas.0.dylib", "libggml-blas.0.9.5.dylib",
-                "libggml-metal.dylib","libggml-metal.0.dylib","libggml-metal.0.9.5.dylib",
-                "libggml.dylib",      "libggml.0.dylib",      "libggml.0.9.5.dylib"
-        };
-        for (String lib : baseLibs) {
-            Path p = libraryDir.resolve(lib);
-            if (Files.exists(p)) {
-                try { System.load(p.toAbsolutePath().toString()); }
-                catch (UnsatisfiedLinkError ignored) {}
+            // Load ggml base libraries first (strict dependency order)
+            String[] baseLibs = {
+                    "libggml-base.dylib", "libggml-base.0.dylib", "libggml-base.0.9.5.dylib",
+                    "libggml-cpu.dylib",  "libggml-cpu.0.dylib",  "libggml-cpu.0.9.5.dylib",
+                    "libggml-blas.dylib", "libggml-blas.0.dylib", "libggml-blas.0.9.5.dylib",
+                    "libggml-metal.dylib","libggml-metal.0.dylib","libggml-metal.0.9.5.dylib",
+                    "libggml.dylib",      "libggml.0.dylib",      "libggml.0.9.5.dylib"
+            };
+            for (String lib : baseLibs) {
+                Path p = libraryDir.resolve(lib);
+                if (Files.exists(p)) {
+                    try { System.load(p.toAbsolutePath().toString()); }
+                    catch (UnsatisfiedLinkError ignored) {}
+                }
             }
         }
 
+        if (isWindows()) {
+            prepareWindowsDllSearch(libraryDir, verbose);
+        }
+
         // Load remaining dependencies
         for (String dep : dependencyLoadOrder(libraryDir)) {
             if (dep.equals(mainLibName) || dep.startsWith("lib" + LIB_BASE_NAME)
@@ -228,6 +244,55 @@ final class LlamaNativeLoader {
         }
     }
 
+    /**
+     * Windows resolves a DLL's imports from the application directory, the system
+     * directories and {@code PATH}, not from the directory of the DLL being loaded, and a
+     * service usually runs with a minimal {@code PATH}. The library directory is added to
+     * the search order so the ggml backends llama.dll loads at runtime find their siblings,
+     * and the CUDA runtime is preloaded from {@code %CUDA_PATH%\bin} for {@code ggml-cuda.dll}.
+     */
+    private static void prepareWindowsDllSearch(Path libraryDir, boolean verbose) {
+        try {
+            MethodHandle setDllDirectory = Linker.nativeLinker().downcallHandle(
+                    SymbolLookup.libraryLookup("kernel32", Arena.global())
+                            .find("SetDllDirectoryW").orElseThrow(),
+                    FunctionDescriptor.of(ValueLayout.JAVA_INT, ValueLayout.ADDRESS));
+            try (Arena arena = Arena.ofConfined()) {
+                MemorySegment dir = arena.allocateFrom(libraryDir.toAbsolutePath().toString(),
+                        StandardCharsets.UTF_16LE);
+                if ((int) setDllDirectory.invokeExact(dir) == 0 && verbose) {
+                    log.warnf("SetDllDirectoryW failed for %s", libraryDir);
+                }
+            }
+        } catch (Throwable e) {
+            if (verbose) log.warnf("Failed to add %s to the DLL search path: %s", libraryDir, e.getMessage());
+        }
+
+        Optional<Path> cudaBin = optionalEnv("CUDA_PATH").map(cuda -> Path.of(cuda, "bin"))
+                .filter(Files::isDirectory);
+        if (cudaBin.isEmpty()) return;
+        try (var stream = Files.list(cudaBin.get())) {
+            for (String n : cudaRuntimeDlls(stream.map(p -> p.getFileName().toString()).toList())) {
+                try { System.load(cudaBin.get().resolve(n).toString()); }
+                catch (UnsatisfiedLinkError e) {
+                    if (verbose) log.warnf("Failed to preload %s: %s", n, e.getMessage());
+                }
+            }
+        } catch (IOException e) {
+            if (verbose) log.warnf("Failed to list %s: %s", cudaBin.get(), e.getMessage());
+        }
+    }
+
+    /** The CUDA runtime DLLs among {@code fileNames}, in the order they must be loaded. */
+    static List<String> cudaRuntimeDlls(List<String> fileNames) {
+        return fileNames.stream()
+                .filter(n -> n.endsWith(".dll") && (n.startsWith("cudart64_")
+                        || n.startsWith("cublasLt64_") || n.startsWith("cublas64_")))
+                // cublas imports cublasLt, so load it first
+                .sorted((x, y) -> Boolean.compare(!x.startsWith("cublasLt"), !y.startsWith("cublasLt")))
+                .toList();
+    }
+
     // ── Resource extraction ───────────────────────────────────────────────────
 
     private static Path extractAndLoadFromResources(boolean verbose) throws Exception {
@@ -364,20 +429,23 @@ final class LlamaNativeLoader {
             paths.filter(Files::isRegularFile)
                     .map(p -> p.getFileName().toString())
                     .filter(n -> n.endsWith(nativeLibExt()) && (n.startsWith("libggml") || n.startsWith("ggml")))
+                    // GGML_BACKEND_DL variants are loaded by LlamaCppBackendLoader, never preloaded
+                    .filter(n -> !LlamaCppBackendLoader.isVariant(n))
                     .sorted()
                     .forEach(names::add);
         } catch (IOException ignored) {}
         return new ArrayList<>(names);
     }
 
-    private static List<String> nativeDependencyFileNames() {
+    static List<String> nativeDependencyFileNames() {
         String ext = nativeLibExt();
         List<String> names = new ArrayList<>();
         if (isWindows()) {
             names.addAll(List.of("ggml-base" + ext, "ggml-cpu" + ext, "ggml-blas" + ext,
-                    "ggml-cuda" + ext, "ggml-metal" + ext, "ggml" + ext, "llama" + ext,
+                    "ggml-cuda" + ext, "ggml-vulkan" + ext, "ggml" + ext, "llama" + ext,
                     "libggml-base" + ext, "libggml-cpu" + ext, "libggml-blas" + ext,
-                    "libggml-cuda" + ext, "libggml-metal" + ext, "libggml" + ext, "libllama" + ext));
+                    "libggml-cuda" + ext, "libggml-vulkan" + ext, "libggml" + ext, "libllama" + ext,
+                    shimLibraryFileName()));
         } else {
             names.addAll(List.of(
                     "libggml-base" + ext, "libggml-cpu" + ext, "libggml-blas" + ext,
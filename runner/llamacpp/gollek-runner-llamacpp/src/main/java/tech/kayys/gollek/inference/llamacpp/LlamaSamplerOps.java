@@ -1,6 +1,7 @@
 package tech.kayys.gollek.inference.llamacpp;
 
 import java.lang.foreign.*;
+import java.util.Map;
 
 /**
  * Wraps all llama.cpp sampler-chain operations.
@@ -9,12 +10,15 @@ import java.lang.foreign.*;
  * one or more sampler stages. The chain is passed to {@link #sample} to draw the
  * next token, and freed with {@link #freeChain} when done.
  *
- * <p>Optional samplers (top-p, min-p, Mirostat, grammar, typical) are only
+ * <p>Optional samplers (top-p, min-p, Mirostat, grammar, typical, logit bias) are only
  * available when the native library exports the corresponding symbol; calling
  * them on an unsupported build throws {@link IllegalStateException}.
  */
 final class LlamaSamplerOps {
 
+    private static final StructLayout LOGIT_BIAS = MemoryLayout.structLayout(
+            ValueLayout.JAVA_INT.withName("token"), ValueLayout.JAVA_FLOAT.withName("bias"));
+
     private final LlamaHandles h;
     private final Arena arena;
 
@@ -210,6 +214,34 @@ final class LlamaSamplerOps {
         }
     }
 
+    /**
+     * Adds a logit-bias sampler to the chain. It should come first, so the biases are
+     * applied to the raw logits before penalties and temperature.
+     *
+     * @param nVocab vocabulary size
+     * @param biases token id to additive bias; {@code -inf} bans the token
+     */
+    void addLogitBias(MemorySegment chain, int nVocab, Map<Integer, Float> biases) {
+        if (biases == null || biases.isEmpty()) {
+            return;
+        }
+        try (Arena local = Arena.ofConfined()) {
+            h.require(h.samplerInitLogitBias, "llama_sampler_init_logit_bias");
+            // llama_logit_bias { llama_token token; float bias; }, copied by the sampler
+            MemorySegment entries = local.allocate(LOGIT_BIAS, biases.size());
+            long offset = 0;
+            for (Map.Entry<Integer, Float> bias : biases.entrySet()) {
+                entries.set(ValueLayout.JAVA_INT, offset, bias.getKey());
+                entries.set(ValueLayout.JAVA_FLOAT, offset + Integer.BYTES, bias.getValue());
+                offset += LOGIT_BIAS.byteSize();
+            }
+            h.samplerChainAdd.invoke(chain,
+                    (MemorySegment) h.samplerInitLogitBias.invoke(nVocab, biases.size(), entries));
+        } catch (Throwable e) {
+            throw new RuntimeException("Failed to add logit-bias sampler", e);
+        }
+    }
+
     /**
      * Samples the next token from the logits at the given batch index.
      *
@@ -1,23 +1,29 @@
 #include "llama.h"
 
+#if defined(_WIN32)
+#define GOLLEK_SHIM_API __declspec(dllexport)
+#else
+#define GOLLEK_SHIM_API
+#endif
+
 static void gollek_llama_noop_log_callback(enum ggml_log_level level, const char * text, void * user_data) {
     (void) level;
     (void) text;
     (void) user_data;
 }
 
-void gollek_llama_log_disable(void) {
+GOLLEK_SHIM_API void gollek_llama_log_disable(void) {
     llama_log_set(gollek_llama_noop_log_callback, NULL);
 }
 
-void gollek_llama_model_default_params_into(struct llama_model_params *out) {
+GOLLEK_SHIM_API void gollek_llama_model_default_params_into(struct llama_model_params *out) {
     if (!out) {
         return;
     }
     *out = llama_model_default_params();
 }
 
-void gollek_llama_context_default_params_into(struct llama_context_params *out) {
+GOLLEK_SHIM_API void gollek_llama_context_default_params_into(struct llama_context_params *out) {
     if (!out) {
         return;
     }
@@ -227,6 +227,62 @@ class LlamaCppProviderTest {
         assertThat(provider.metrics().get().getFailedRequests()).isGreaterThanOrEqualTo(0);
     }
 
+    @Test
+    @DisplayName("Temperature 0 reaches the runner as greedy decoding; the default applies only when unset")
+    void testZeroTemperatureIsKept() {
+        when(config.defaultTemperature()).thenReturn(0.8f);
+
+        ProviderRequest greedy = ProviderRequest.builder()
+                .model("model.gguf")
+                .message(Message.user("Hello"))
+                .parameter("temperature", 0)
+                .build();
+        ProviderRequest unset = ProviderRequest.builder()
+                .model("model.gguf")
+                .message(Message.user("Hello"))
+                .build();
+
+        assertThat(provider.convertToInferenceRequest(greedy, null).getParameters().get("temperature"))
+                .isEqualTo(0.0f);
+        assertThat(provider.convertToInferenceRequest(unset, null).getParameters().get("temperature"))
+                .isEqualTo(0.8f);
+    }
+
+    @Test
+    @DisplayName("Explicit zero or neutral sampling values are honored instead of the configured defaults")
+    void testExplicitSamplingValuesAreHonored() {
+        when(config.defaultTopP()).thenReturn(0.9f);
+        when(config.defaultTopK()).thenReturn(40);
+        when(config.defaultRepeatPenalty()).thenReturn(1.1f);
+        when(config.defaultRepeatLastN()).thenReturn(64);
+
+        ProviderRequest explicit = ProviderRequest.builder()
+                .model("model.gguf")
+                .message(Message.user("Hello"))
+                .parameter("top_p", 0)
+                .parameter("top_k", 0)
+                .parameter("repetition_penalty", 1.0)
+                .parameter("repeat_last_n", 0)
+                .parameter("max_tokens", 4)
+                .build();
+        ProviderRequest unset = ProviderRequest.builder()
+                .model("model.gguf")
+                .message(Message.user("Hello"))
+                .build();
+
+        assertThat(provider.convertToInferenceRequest(explicit, null).getParameters())
+                .containsEntry("top_p", 0.0f)
+                .containsEntry("top_k", 0)
+                .containsEntry("repeat_penalty", 1.0f)
+                .containsEntry("repeat_last_n", 0)
+                .containsEntry("max_tokens", 4);
+        assertThat(provider.convertToInferenceRequest(unset, null).getParameters())
+                .containsEntry("top_p", 0.9f)
+                .containsEntry("top_k", 40)
+                .containsEntry("repeat_penalty", 1.1f)
+                .containsEntry("repeat_last_n", 64);
+    }
+
     // Helper methods
 
     private void initializeProvider() {
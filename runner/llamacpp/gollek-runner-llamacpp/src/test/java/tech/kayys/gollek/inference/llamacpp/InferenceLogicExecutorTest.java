@@ -0,0 +1,122 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.gguf.tokenizer.GGUFChatTemplateService;
+
+import org.junit.jupiter.api.Test;
+import org.mockito.Mockito;
+import tech.kayys.gollek.spi.exception.InferenceTimeoutException;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.model.ArtifactLocation;
+import tech.kayys.gollek.spi.model.ModelFormat;
+import tech.kayys.gollek.spi.model.ModelManifest;
+
+import java.lang.foreign.Arena;
+import java.lang.foreign.MemorySegment;
+import java.lang.foreign.ValueLayout;
+import java.time.Instant;
+import java.util.Collections;
+import java.util.Map;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.mockito.ArgumentMatchers.*;
+import static org.mockito.Mockito.when;
+
+class InferenceLogicExecutorTest {
+
+    private static InferenceLogicExecutor executor(int contextSize, long decodeMillis) {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.maxContextTokens()).thenReturn(128);
+
+        LlamaCppBinding binding = Mockito.mock(LlamaCppBinding.class);
+        GGUFChatTemplateService templateService = Mockito.mock(GGUFChatTemplateService.class);
+        when(templateService.render(any(), any())).thenReturn("hello");
+
+        float[] logitsArray = new float[] { 0.1f, 0.2f, 0.3f, 0.4f };
+        MemorySegment logits = Arena.ofAuto().allocate(ValueLayout.JAVA_FLOAT, logitsArray.length);
+        MemorySegment.copy(MemorySegment.ofArray(logitsArray), 0, logits, 0,
+                logitsArray.length * ValueLayout.JAVA_FLOAT.byteSize());
+
+        when(binding.tokenize(any(), anyString(), anyBoolean(), anyBoolean())).thenReturn(new int[] { 1, 2 });
+        when(binding.batchInit(anyInt(), anyInt(), anyInt())).thenReturn(MemorySegment.NULL);
+        when(binding.decode(any(), any())).thenAnswer(invocation -> {
+            Thread.sleep(decodeMillis);
+            return 0;
+        });
+        when(binding.getLogitsIth(any(), anyInt())).thenReturn(logits);
+        when(binding.tokenToPiece(any(), anyInt())).thenReturn("x");
+        when(binding.isEndOfGeneration(any(), anyInt())).thenReturn(false);
+
+        ArtifactLocation location = new ArtifactLocation("/models/test.gguf", null, null, null);
+        ModelManifest manifest = ModelManifest.builder()
+                .modelId("test-model")
+                .name("test-model")
+                .version("1.0")
+                .path(location.uri())
+                .apiKey(tech.kayys.gollek.spi.auth.ApiKeyConstants.COMMUNITY_API_KEY)
+                .requestId("tenant1")
+                .artifacts(Map.of(ModelFormat.GGUF, location))
+                .supportedDevices(Collections.emptyList())
+                .resourceRequirements(null)
+                .metadata(Collections.emptyMap())
+                .createdAt(Instant.now())
+                .updatedAt(Instant.now())
+                .build();
+
+        LlamaCppKVCacheManager kvCache = new LlamaCppKVCacheManager(binding, config, null);
+        return new InferenceLogicExecutor(binding, config, templateService, MemorySegment.NULL, MemorySegment.NULL,
+                contextSize, 4, -1, 1, 8, null, kvCache, new LlamaCppTokenSampler(binding, 4),
+                new LlamaCppMetricsRecorder(), manifest);
+    }
+
+    private static InferenceRequest.Builder request(int maxTokens) {
+        return InferenceRequest.builder()
+                .model("test-model")
+                .message(tech.kayys.gollek.spi.Message.user("hello"))
+                .parameter("temperature", 0.0f)
+                .parameter("max_tokens", maxTokens);
+    }
+
+    @Test
+    void deadlineReturnsThePartialOutputWhenAsked() {
+        // the shortest deadline is a second; each token takes 200 ms
+        InferenceRequest request = request(100)
+                .parameter("inference_timeout_ms", 1000L)
+                .returnPartialOnTimeout(true)
+                .build();
+
+        InferenceResponse response = executor(128, 200).execute(request, null);
+
+        assertThat(response.getFinishReason()).isEqualTo(InferenceResponse.FinishReason.TIMEOUT);
+        assertThat(response.getOutputTokens()).isBetween(1, 99);
+        assertThat(response.getContent()).isEqualTo("x".repeat(response.getOutputTokens()));
+    }
+
+    @Test
+    void deadlineFailsTheRequestByDefault() {
+        InferenceRequest request = request(100)
+                .parameter("inference_timeout_ms", 1000L)
+                .build();
+
+        assertThatThrownBy(() -> executor(128, 200).execute(request, null))
+                .isInstanceOf(InferenceTimeoutException.class)
+                .hasMessageContaining("timed out");
+    }
+
+    @Test
+    void silentAdjustmentsAreReportedAsWarnings() {
+        InferenceRequest request = request(20)
+                .parameter("temperature", 5.0f)
+                .build();
+
+        // a 2-token prompt leaves 6 tokens of an 8-token context
+        InferenceResponse response = executor(8, 0).execute(request, null);
+
+        assertThat(response.getOutputTokens()).isEqualTo(6);
+        assertThat(response.getFinishReason()).isEqualTo(InferenceResponse.FinishReason.LENGTH);
+        assertThat(response.getWarnings()).containsExactlyInAnyOrder(
+                "temperature clamped to 2.0",
+                "max_tokens reduced from 20 to 6 to fit the context window");
+    }
+}
@@ -0,0 +1,70 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import tech.kayys.gollek.inference.llamacpp.LlamaCppBackendLoader.Module;
+
+import java.nio.file.Path;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.OptionalInt;
+import java.util.stream.Stream;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppBackendLoaderTest {
+
+    private static final String EXT = LlamaNativeLoader.nativeLibExt();
+
+    private static List<Module> modules(String... names) {
+        return Stream.of(names)
+                .map(n -> LlamaCppBackendLoader.parse(Path.of("lib" + n + EXT)))
+                .flatMap(Optional::stream)
+                .toList();
+    }
+
+    @Test
+    void parsesBackendAndVariantFromFileNames() {
+        assertThat(LlamaCppBackendLoader.parse(Path.of("libggml-cpu-haswell" + EXT)))
+                .contains(new Module(Path.of("libggml-cpu-haswell" + EXT), "cpu", "haswell"));
+        assertThat(LlamaCppBackendLoader.parse(Path.of("ggml-cuda-cu12.4" + EXT)).map(Module::variant))
+                .contains("cu12.4");
+        assertThat(LlamaCppBackendLoader.parse(Path.of("libggml-base" + EXT))).isEmpty();
+        assertThat(LlamaCppBackendLoader.parse(Path.of("libggml-cuda.0" + EXT))).isEmpty();
+        assertThat(LlamaCppBackendLoader.parse(Path.of("libllama" + EXT))).isEmpty();
+        assertThat(LlamaCppBackendLoader.isVariant("libggml-cpu-skylakex" + EXT)).isTrue();
+        assertThat(LlamaCppBackendLoader.isVariant("libggml-cpu" + EXT)).isFalse();
+    }
+
+    @Test
+    void picksTheHighestScoringCpuVariant() {
+        Map<String, Integer> scores = Map.of("libggml-cpu-sandybridge" + EXT, 20, "libggml-cpu-haswell" + EXT, 40,
+                "libggml-cpu-skylakex" + EXT, 0);
+
+        List<Module> chosen = LlamaCppBackendLoader.select(
+                modules("ggml-cpu-sandybridge", "ggml-cpu-haswell", "ggml-cpu-skylakex", "ggml-vulkan"),
+                OptionalInt.empty(), p -> scores.getOrDefault(p.getFileName().toString(), 0));
+
+        assertThat(chosen).extracting(Module::label).containsExactly("cpu (haswell)", "vulkan");
+    }
+
+    @Test
+    void picksTheNewestCudaBuildTheDriverSupports() {
+        List<Module> cuda = modules("ggml-cuda-cu11.8", "ggml-cuda-cu12.4", "ggml-cuda-cu12.8", "ggml-cuda");
+
+        assertThat(LlamaCppBackendLoader.select(cuda, OptionalInt.of(12060), p -> 0))
+                .extracting(Module::variant).containsExactly("cu12.4");
+        assertThat(LlamaCppBackendLoader.select(cuda, OptionalInt.of(11040), p -> 0))
+                .extracting(Module::variant).containsExactly("");
+        // without a driver only the unversioned module is tried
+        assertThat(LlamaCppBackendLoader.select(cuda, OptionalInt.empty(), p -> 0))
+                .extracting(Module::variant).containsExactly("");
+    }
+
+    @Test
+    void parsesCudaVersions() {
+        assertThat(LlamaCppBackendLoader.cudaVersion("cu12.4")).isEqualTo(12040);
+        assertThat(LlamaCppBackendLoader.cudaVersion("cu11")).isEqualTo(11000);
+        assertThat(LlamaCppBackendLoader.cudaVersion("haswell")).isEqualTo(-1);
+    }
+}
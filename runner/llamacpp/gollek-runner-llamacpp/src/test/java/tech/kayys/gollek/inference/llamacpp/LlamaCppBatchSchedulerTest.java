@@ -0,0 +1,480 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.gguf.tokenizer.GGUFChatTemplateService;
+
+import org.junit.jupiter.api.Test;
+import org.mockito.Mockito;
+import tech.kayys.gollek.spi.exception.InferenceTimeoutException;
+import tech.kayys.gollek.spi.exception.RunnerBusyException;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.Priority;
+
+import java.lang.foreign.Arena;
+import java.lang.foreign.MemorySegment;
+import java.lang.foreign.ValueLayout;
+import java.time.Duration;
+import java.util.List;
+import java.util.concurrent.CompletableFuture;
+import java.util.concurrent.CopyOnWriteArrayList;
+import java.util.concurrent.CountDownLatch;
+import java.util.concurrent.TimeUnit;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.mockito.ArgumentMatchers.*;
+import static org.mockito.Mockito.atLeastOnce;
+import static org.mockito.Mockito.never;
+import static org.mockito.Mockito.verify;
+import static org.mockito.Mockito.when;
+
+class LlamaCppBatchSchedulerTest {
+
+    @Test
+    void interleavesConcurrentRequestsOnSeparateSequences() throws Exception {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.maxContextTokens()).thenReturn(128);
+        when(config.continuousBatchingEnabled()).thenReturn(true);
+        when(config.continuousBatchingMaxSequences()).thenReturn(2);
+        when(config.continuousBatchingMaxQueue()).thenReturn(8);
+        when(config.sequenceSlots()).thenReturn(2);
+
+        LlamaCppBinding binding = Mockito.mock(LlamaCppBinding.class);
+        GGUFChatTemplateService templateService = Mockito.mock(GGUFChatTemplateService.class);
+        when(templateService.render(any(), any())).thenReturn("hello");
+
+        float[] logitsArray = new float[] { 0.1f, 0.2f, 0.3f, 0.4f };
+        MemorySegment logits = Arena.ofAuto().allocate(ValueLayout.JAVA_FLOAT, logitsArray.length);
+        MemorySegment.copy(MemorySegment.ofArray(logitsArray), 0, logits, 0,
+                logitsArray.length * ValueLayout.JAVA_FLOAT.byteSize());
+
+        // hold the first decode until both requests are queued so they share a batch
+        CountDownLatch bothQueued = new CountDownLatch(1);
+        when(binding.tokenize(any(), anyString(), anyBoolean(), anyBoolean())).thenReturn(new int[] { 1, 2 });
+        when(binding.batchInit(anyInt(), anyInt(), anyInt())).thenReturn(MemorySegment.NULL);
+        when(binding.decode(any(), any())).thenAnswer(invocation -> {
+            bothQueued.await(5, TimeUnit.SECONDS);
+            return 0;
+        });
+        when(binding.getLogitsIth(any(), anyInt())).thenReturn(logits);
+        when(binding.tokenToPiece(any(), anyInt())).thenReturn("x");
+        when(binding.isEndOfGeneration(any(), anyInt())).thenReturn(false);
+        when(binding.memorySeqRm(any(), anyInt(), anyInt(), anyInt())).thenReturn(true);
+
+        LlamaCppMetricsRecorder metrics = new LlamaCppMetricsRecorder();
+        LlamaCppKVCacheManager kvCache = new LlamaCppKVCacheManager(binding, config, null);
+        LlamaCppTokenSampler sampler = new LlamaCppTokenSampler(binding, 4);
+        InferenceLogicExecutor executor = new InferenceLogicExecutor(binding, config, templateService,
+                MemorySegment.NULL, MemorySegment.NULL, 128, 4, -1, 1, 8, null, kvCache, sampler, metrics, null);
+        LlamaCppBatchScheduler scheduler = new LlamaCppBatchScheduler(binding, config, metrics, kvCache, sampler,
+                executor, (request, onToken) -> { throw new AssertionError("unexpected exclusive run"); },
+                MemorySegment.NULL, MemorySegment.NULL, "test-model", 128, 4, 8);
+        scheduler.start();
+        try {
+            CompletableFuture<InferenceResponse> one = CompletableFuture.supplyAsync(() -> scheduler.submit(request(2), null));
+            CompletableFuture<InferenceResponse> two = CompletableFuture.supplyAsync(() -> scheduler.submit(request(3), null));
+            Thread.sleep(100);
+            bothQueued.countDown();
+
+            assertThat(one.get(5, TimeUnit.SECONDS).getContent()).isEqualTo("xx");
+            assertThat(two.get(5, TimeUnit.SECONDS).getContent()).isEqualTo("xxx");
+            assertThat(two.get().getOutputTokens()).isEqualTo(3);
+            // neither hit an end token, so both ran out of max_tokens
+            assertThat(one.get().getFinishReason()).isEqualTo(InferenceResponse.FinishReason.LENGTH);
+            assertThat(two.get().getFinishReason()).isEqualTo(InferenceResponse.FinishReason.LENGTH);
+        } finally {
+            scheduler.shutdown();
+        }
+
+        verify(binding, atLeastOnce()).setBatchToken(any(), anyInt(), anyInt(), anyInt(), eq(0), anyBoolean());
+        verify(binding, atLeastOnce()).setBatchToken(any(), anyInt(), anyInt(), anyInt(), eq(1), anyBoolean());
+        verify(binding, atLeastOnce()).memorySeqRm(any(), eq(1), eq(-1), eq(-1));
+        verify(binding, never()).setBatchToken(any(), anyInt(), anyInt(), anyInt(), eq(2), anyBoolean());
+    }
+
+    @Test
+    void queuedRequestsAreToldTheirPosition() throws Exception {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.maxContextTokens()).thenReturn(128);
+        when(config.continuousBatchingEnabled()).thenReturn(true);
+        when(config.continuousBatchingMaxSequences()).thenReturn(1);
+        when(config.continuousBatchingMaxQueue()).thenReturn(8);
+        when(config.sequenceSlots()).thenReturn(1);
+
+        LlamaCppBinding binding = Mockito.mock(LlamaCppBinding.class);
+        GGUFChatTemplateService templateService = Mockito.mock(GGUFChatTemplateService.class);
+        when(templateService.render(any(), any())).thenReturn("hello");
+
+        float[] logitsArray = new float[] { 0.1f, 0.2f, 0.3f, 0.4f };
+        MemorySegment logits = Arena.ofAuto().allocate(ValueLayout.JAVA_FLOAT, logitsArray.length);
+        MemorySegment.copy(MemorySegment.ofArray(logitsArray), 0, logits, 0,
+                logitsArray.length * ValueLayout.JAVA_FLOAT.byteSize());
+
+        // slow decode keeps the only sequence busy while the second request waits
+        when(binding.tokenize(any(), anyString(), anyBoolean(), anyBoolean())).thenReturn(new int[] { 1, 2 });
+        when(binding.batchInit(anyInt(), anyInt(), anyInt())).thenReturn(MemorySegment.NULL);
+        when(binding.decode(any(), any())).thenAnswer(invocation -> {
+            Thread.sleep(10);
+            return 0;
+        });
+        when(binding.getLogitsIth(any(), anyInt())).thenReturn(logits);
+        when(binding.tokenToPiece(any(), anyInt())).thenReturn("x");
+        when(binding.isEndOfGeneration(any(), anyInt())).thenReturn(false);
+        when(binding.memorySeqRm(any(), anyInt(), anyInt(), anyInt())).thenReturn(true);
+
+        LlamaCppMetricsRecorder metrics = new LlamaCppMetricsRecorder();
+        LlamaCppKVCacheManager kvCache = new LlamaCppKVCacheManager(binding, config, null);
+        LlamaCppTokenSampler sampler = new LlamaCppTokenSampler(binding, 4);
+        InferenceLogicExecutor executor = new InferenceLogicExecutor(binding, config, templateService,
+                MemorySegment.NULL, MemorySegment.NULL, 128, 4, -1, 1, 8, null, kvCache, sampler, metrics, null);
+        LlamaCppBatchScheduler scheduler = new LlamaCppBatchScheduler(binding, config, metrics, kvCache, sampler,
+                executor, (request, onToken) -> { throw new AssertionError("unexpected exclusive run"); },
+                MemorySegment.NULL, MemorySegment.NULL, "test-model", 128, 4, 8);
+        java.util.List<LlamaCppBatchScheduler.QueueStatus> statuses = new java.util.concurrent.CopyOnWriteArrayList<>();
+        scheduler.start();
+        try {
+            CompletableFuture<InferenceResponse> one = CompletableFuture.supplyAsync(() -> scheduler.submit(request(20), null));
+            Thread.sleep(50);
+            CompletableFuture<InferenceResponse> two = CompletableFuture.supplyAsync(
+                    () -> scheduler.submit(request(2), null, statuses::add));
+
+            assertThat(one.get(5, TimeUnit.SECONDS).getOutputTokens()).isEqualTo(20);
+            assertThat(two.get(5, TimeUnit.SECONDS).getContent()).isEqualTo("xx");
+        } finally {
+            scheduler.shutdown();
+        }
+
+        assertThat(statuses).hasSize(1);
+        assertThat(statuses.get(0).position()).isEqualTo(1);
+        assertThat(statuses.get(0).queued()).isEqualTo(1);
+        // nothing had completed yet, so there is no throughput to estimate from
+        assertThat(statuses.get(0).estimatedWaitMs()).isNull();
+    }
+
+    @Test
+    void higherPriorityRequestsStartFirst() throws Exception {
+        List<String> finished = new CopyOnWriteArrayList<>();
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler(null, new LlamaCppMetricsRecorder());
+        scheduler.start();
+        try {
+            CompletableFuture<InferenceResponse> running = CompletableFuture.supplyAsync(
+                    () -> scheduler.submit(request(10, "low"), null));
+            Thread.sleep(30);
+            CompletableFuture<Void> normal = CompletableFuture.runAsync(
+                    () -> finished.add(scheduler.submit(request(2, "normal"), null).getRequestId()));
+            Thread.sleep(20);
+            CompletableFuture<Void> high = CompletableFuture.runAsync(
+                    () -> finished.add(scheduler.submit(request(2, "high"), null).getRequestId()));
+
+            CompletableFuture.allOf(running, normal, high).get(5, TimeUnit.SECONDS);
+        } finally {
+            scheduler.shutdown();
+        }
+
+        assertThat(finished).containsExactly("high", "normal");
+    }
+
+    @Test
+    void shortestJobFirstStartsShorterPredictedRequestsFirst() throws Exception {
+        LlamaCppProviderConfig config = singleSequenceConfig(null);
+        when(config.continuousBatchingShortestJobFirst()).thenReturn(true);
+        when(config.continuousBatchingSjfMsPerToken()).thenReturn(100);
+        List<String> finished = new CopyOnWriteArrayList<>();
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler(Mockito.mock(LlamaCppBinding.class), config,
+                new LlamaCppMetricsRecorder());
+        scheduler.start();
+        try {
+            CompletableFuture<InferenceResponse> running = CompletableFuture.supplyAsync(
+                    () -> scheduler.submit(request(10), null));
+            Thread.sleep(30);
+            // with no history yet each request is expected to use all of its max_tokens
+            CompletableFuture<Void> longer = CompletableFuture.runAsync(
+                    () -> finished.add(scheduler.submit(named(request(8), "long"), null).getRequestId()));
+            Thread.sleep(20);
+            CompletableFuture<Void> shorter = CompletableFuture.runAsync(
+                    () -> finished.add(scheduler.submit(named(request(2), "short"), null).getRequestId()));
+
+            CompletableFuture.allOf(running, longer, shorter).get(5, TimeUnit.SECONDS);
+        } finally {
+            scheduler.shutdown();
+        }
+
+        assertThat(finished).containsExactly("short", "long");
+    }
+
+    @Test
+    void evictionParksAndResumesTheOutrankedRequest() throws Exception {
+        LlamaCppMetricsRecorder metrics = new LlamaCppMetricsRecorder();
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler("evict", metrics);
+        StringBuilder streamed = new StringBuilder();
+        scheduler.start();
+        try {
+            CompletableFuture<InferenceResponse> low = CompletableFuture.supplyAsync(
+                    () -> scheduler.submit(request(20, "low"), streamed::append));
+            Thread.sleep(50);
+            InferenceResponse high = scheduler.submit(request(2, "high"), null);
+
+            assertThat(high.getContent()).isEqualTo("xx");
+            assertThat(low.isDone()).isFalse();
+            InferenceResponse resumed = low.get(5, TimeUnit.SECONDS);
+            assertThat(resumed.getOutputTokens()).isEqualTo(20);
+            assertThat(resumed.getInputTokens()).isEqualTo(2);
+            assertThat(streamed.toString()).isEqualTo("x".repeat(20));
+        } finally {
+            scheduler.shutdown();
+        }
+        assertThat(metrics.getPreemptions().get()).isEqualTo(1);
+    }
+
+    @Test
+    void maxTimeEndsGenerationWithPartialOutput() {
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler(null, new LlamaCppMetricsRecorder());
+        scheduler.start();
+        try {
+            InferenceResponse response = scheduler.submit(request(100).toBuilder()
+                    .parameter("max_time_ms", 100L)
+                    .build(), null);
+
+            assertThat(response.getFinishReason()).isEqualTo(InferenceResponse.FinishReason.TIME_LIMIT);
+            assertThat(response.getOutputTokens()).isBetween(1, 99);
+            assertThat(response.getContent()).hasSize(response.getOutputTokens());
+        } finally {
+            scheduler.shutdown();
+        }
+    }
+
+    @Test
+    void interTokenGapEndsGenerationAsStalled() {
+        // every decode takes 10 ms, longer than the allowed gap
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler(null, new LlamaCppMetricsRecorder());
+        scheduler.start();
+        try {
+            InferenceResponse response = scheduler.submit(request(100).toBuilder()
+                    .parameter(LlamaCppTimeouts.INTER_TOKEN, 5L)
+                    .build(), null);
+
+            assertThat(response.getFinishReason()).isEqualTo(InferenceResponse.FinishReason.STALLED);
+            assertThat(response.getOutputTokens()).isEqualTo(1);
+            assertThat(response.getContent()).isEqualTo("x");
+        } finally {
+            scheduler.shutdown();
+        }
+    }
+
+    @Test
+    void queuedRequestsFailOnceTheirQueueTimeoutRunsOut() throws Exception {
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler(null, new LlamaCppMetricsRecorder());
+        scheduler.start();
+        try {
+            CompletableFuture<InferenceResponse> running = CompletableFuture.supplyAsync(
+                    () -> scheduler.submit(request(100), null));
+            Thread.sleep(30);
+
+            assertThatThrownBy(() -> scheduler.submit(request(2).toBuilder()
+                    .parameter(LlamaCppTimeouts.QUEUE, 50L)
+                    .build(), null))
+                    .isInstanceOfSatisfying(InferenceTimeoutException.class,
+                            e -> assertThat(e.stage()).isEqualTo(InferenceTimeoutException.Stage.QUEUE));
+            assertThat(running).isNotDone();
+        } finally {
+            scheduler.shutdown();
+        }
+    }
+
+    @Test
+    void rawPromptsSkipBosAndReturnTheirTokens() {
+        LlamaCppBinding binding = Mockito.mock(LlamaCppBinding.class);
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler(binding, null, new LlamaCppMetricsRecorder());
+        scheduler.start();
+        try {
+            InferenceResponse response = scheduler.submit(request(2).toBuilder()
+                    .parameter("raw", true)
+                    .parameter("prompt", "<s>[INST] hi [/INST]")
+                    .parameter("return_tokens", true)
+                    .build(), null);
+
+            assertThat(response.getContent()).isEqualTo("xx");
+            assertThat(response.getMetadata().get("prompt_tokens")).isEqualTo(List.of(1, 2));
+        } finally {
+            scheduler.shutdown();
+        }
+        verify(binding).tokenize(any(), eq("<s>[INST] hi [/INST]"), eq(false), anyBoolean());
+        verify(binding, never()).tokenize(any(), anyString(), eq(true), anyBoolean());
+    }
+
+    @Test
+    void priorityComesFromTheParameterOrTheRequest() {
+        assertThat(LlamaCppBatchScheduler.priorityOf(request(1, "HIGH"))).isEqualTo(Priority.HIGH);
+        assertThat(LlamaCppBatchScheduler.priorityOf(request(1))).isEqualTo(Priority.NORMAL);
+        assertThat(LlamaCppBatchScheduler.priorityOf(request(1).toBuilder().priority(Priority.LOW).build()))
+                .isEqualTo(Priority.LOW);
+    }
+
+    @Test
+    void rejectsPromptsLongerThanTheSequenceContext() {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.maxContextTokens()).thenReturn(128);
+        when(config.continuousBatchingEnabled()).thenReturn(true);
+        when(config.continuousBatchingMaxSequences()).thenReturn(2);
+        when(config.continuousBatchingMaxQueue()).thenReturn(8);
+        when(config.sequenceSlots()).thenReturn(2);
+
+        LlamaCppBinding binding = Mockito.mock(LlamaCppBinding.class);
+        GGUFChatTemplateService templateService = Mockito.mock(GGUFChatTemplateService.class);
+        when(templateService.render(any(), any())).thenReturn("hello");
+        when(binding.tokenize(any(), anyString(), anyBoolean(), anyBoolean())).thenReturn(new int[80]);
+        when(binding.batchInit(anyInt(), anyInt(), anyInt())).thenReturn(MemorySegment.NULL);
+
+        LlamaCppMetricsRecorder metrics = new LlamaCppMetricsRecorder();
+        LlamaCppKVCacheManager kvCache = new LlamaCppKVCacheManager(binding, config, null);
+        LlamaCppTokenSampler sampler = new LlamaCppTokenSampler(binding, 4);
+        InferenceLogicExecutor executor = new InferenceLogicExecutor(binding, config, templateService,
+                MemorySegment.NULL, MemorySegment.NULL, 128, 4, -1, 1, 8, null, kvCache, sampler, metrics, null);
+        // two sequences share a 128-token context, so each gets 64
+        LlamaCppBatchScheduler scheduler = new LlamaCppBatchScheduler(binding, config, metrics, kvCache, sampler,
+                executor, (request, onToken) -> { throw new AssertionError("unexpected exclusive run"); },
+                MemorySegment.NULL, MemorySegment.NULL, "test-model", 128, 4, 8);
+        scheduler.start();
+        try {
+            assertThatThrownBy(() -> scheduler.submit(request(4), null))
+                    .isInstanceOf(IllegalArgumentException.class)
+                    .hasMessageContaining("80 tokens")
+                    .hasMessageContaining("64 tokens");
+        } finally {
+            scheduler.shutdown();
+        }
+        verify(binding, never()).decode(any(), any());
+    }
+
+    @Test
+    void fullQueueRejectsWithRetryAfterOnceTheQueueWaitRunsOut() throws Exception {
+        LlamaCppProviderConfig config = singleSequenceConfig(null);
+        when(config.continuousBatchingMaxQueue()).thenReturn(1);
+        when(config.continuousBatchingQueueWait()).thenReturn(Duration.ofMillis(100));
+        LlamaCppMetricsRecorder metrics = new LlamaCppMetricsRecorder();
+        // never started, so the first request holds the only place in the queue
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler(Mockito.mock(LlamaCppBinding.class), config, metrics);
+        try {
+            CompletableFuture<InferenceResponse> queued = CompletableFuture.supplyAsync(
+                    () -> scheduler.submit(request(2), null));
+            Thread.sleep(30);
+
+            long start = System.nanoTime();
+            assertThatThrownBy(() -> scheduler.submit(request(2), null))
+                    .isInstanceOfSatisfying(RunnerBusyException.class,
+                            e -> assertThat(e.retryAfterSeconds()).isEqualTo(1));
+            assertThat(TimeUnit.NANOSECONDS.toMillis(System.nanoTime() - start)).isGreaterThanOrEqualTo(100);
+            assertThat(metrics.getRejections().get()).isEqualTo(1);
+            assertThat(queued).isNotDone();
+        } finally {
+            scheduler.shutdown();
+        }
+    }
+
+    @Test
+    void queueWaitAdmitsRequestsOnceTheWorkerMakesRoom() throws Exception {
+        LlamaCppProviderConfig config = singleSequenceConfig(null);
+        when(config.continuousBatchingMaxQueue()).thenReturn(1);
+        when(config.continuousBatchingQueueWait()).thenReturn(Duration.ofSeconds(5));
+        LlamaCppMetricsRecorder metrics = new LlamaCppMetricsRecorder();
+        LlamaCppBatchScheduler scheduler = singleSequenceScheduler(Mockito.mock(LlamaCppBinding.class), config, metrics);
+        scheduler.start();
+        try {
+            List<CompletableFuture<InferenceResponse>> requests = new java.util.ArrayList<>();
+            for (int i = 0; i < 3; i++) {
+                requests.add(CompletableFuture.supplyAsync(() -> scheduler.submit(request(3), null)));
+                Thread.sleep(10);
+            }
+
+            CompletableFuture.allOf(requests.toArray(CompletableFuture[]::new)).get(5, TimeUnit.SECONDS);
+            assertThat(requests).allSatisfy(r -> assertThat(r.get().getContent()).isEqualTo("xxx"));
+            assertThat(metrics.getRejections().get()).isZero();
+        } finally {
+            scheduler.shutdown();
+        }
+    }
+
+    @Test
+    void sessionPersistRequestsRunExclusively() {
+        InferenceRequest request = InferenceRequest.builder()
+                .model("test-model")
+                .message(tech.kayys.gollek.spi.Message.user("hello"))
+                .parameter("gguf.session.persist", true)
+                .build();
+
+        assertThat(LlamaCppBatchScheduler.isExclusive(request)).isTrue();
+        assertThat(LlamaCppBatchScheduler.isExclusive(request(1))).isFalse();
+    }
+
+    /**
+     * One sequence, a 10ms decode step and a model that never emits an end token.
+     */
+    private static LlamaCppBatchScheduler singleSequenceScheduler(String preemption, LlamaCppMetricsRecorder metrics) {
+        return singleSequenceScheduler(Mockito.mock(LlamaCppBinding.class), preemption, metrics);
+    }
+
+    private static LlamaCppBatchScheduler singleSequenceScheduler(LlamaCppBinding binding, String preemption,
+            LlamaCppMetricsRecorder metrics) {
+        return singleSequenceScheduler(binding, singleSequenceConfig(preemption), metrics);
+    }
+
+    private static LlamaCppProviderConfig singleSequenceConfig(String preemption) {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.maxContextTokens()).thenReturn(128);
+        when(config.continuousBatchingEnabled()).thenReturn(true);
+        when(config.continuousBatchingMaxSequences()).thenReturn(1);
+        when(config.continuousBatchingMaxQueue()).thenReturn(8);
+        when(config.continuousBatchingPreemption()).thenReturn(preemption);
+        when(config.sequenceSlots()).thenReturn(1);
+        return config;
+    }
+
+    private static LlamaCppBatchScheduler singleSequenceScheduler(LlamaCppBinding binding,
+            LlamaCppProviderConfig config, LlamaCppMetricsRecorder metrics) {
+        GGUFChatTemplateService templateService = Mockito.mock(GGUFChatTemplateService.class);
+        when(templateService.render(any(), any())).thenReturn("hello");
+
+        float[] logitsArray = new float[] { 0.1f, 0.2f, 0.3f, 0.4f };
+        MemorySegment logits = Arena.ofAuto().allocate(ValueLayout.JAVA_FLOAT, logitsArray.length);
+        MemorySegment.copy(MemorySegment.ofArray(logitsArray), 0, logits, 0,
+                logitsArray.length * ValueLayout.JAVA_FLOAT.byteSize());
+        when(binding.tokenize(any(), anyString(), anyBoolean(), anyBoolean())).thenReturn(new int[] { 1, 2 });
+        when(binding.batchInit(anyInt(), anyInt(), anyInt())).thenReturn(MemorySegment.NULL);
+        when(binding.decode(any(), any())).thenAnswer(invocation -> {
+            Thread.sleep(10);
+            return 0;
+        });
+        when(binding.getLogitsIth(any(), anyInt())).thenReturn(logits);
+        when(binding.tokenToPiece(any(), anyInt())).thenReturn("x");
+        when(binding.isEndOfGeneration(any(), anyInt())).thenReturn(false);
+        when(binding.memorySeqRm(any(), anyInt(), anyInt(), anyInt())).thenReturn(true);
+
+        LlamaCppKVCacheManager kvCache = new LlamaCppKVCacheManager(binding, config, null);
+        LlamaCppTokenSampler sampler = new LlamaCppTokenSampler(binding, 4);
+        InferenceLogicExecutor executor = new InferenceLogicExecutor(binding, config, templateService,
+                MemorySegment.NULL, MemorySegment.NULL, 128, 4, -1, 1, 8, null, kvCache, sampler, metrics, null);
+        return new LlamaCppBatchScheduler(binding, config, metrics, kvCache, sampler,
+                executor, (request, onToken) -> { throw new AssertionError("unexpected exclusive run"); },
+                MemorySegment.NULL, MemorySegment.NULL, "test-model", 128, 4, 8);
+    }
+
+    private static InferenceRequest request(int maxTokens, String priority) {
+        return request(maxTokens).toBuilder()
+                .requestId(priority)
+                .parameter("priority", priority)
+                .build();
+    }
+
+    private static InferenceRequest named(InferenceRequest request, String requestId) {
+        return request.toBuilder().requestId(requestId).build();
+    }
+
+    private static InferenceRequest request(int maxTokens) {
+        return InferenceRequest.builder()
+                .model("test-model")
+                .message(tech.kayys.gollek.spi.Message.user("hello"))
+                .parameter("temperature", 0.0f)
+                .parameter("max_tokens", maxTokens)
+                .build();
+    }
+}
@@ -0,0 +1,20 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppBuildInfoTest {
+
+    @Test
+    void formatsPackedCudaVersions() {
+        assertThat(LlamaCppBuildInfo.formatCudaVersion(12040)).isEqualTo("12.4");
+        assertThat(LlamaCppBuildInfo.formatCudaVersion(11080)).isEqualTo("11.8");
+        assertThat(LlamaCppBuildInfo.formatCudaVersion(13000)).isEqualTo("13.0");
+    }
+
+    @Test
+    void describesNothingWithoutALibrary() {
+        assertThat(LlamaCppBuildInfo.describe(null)).isEmpty();
+    }
+}
@@ -0,0 +1,63 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import org.mockito.Mockito;
+import tech.kayys.gollek.gguf.tokenizer.GGUFChatTemplateService;
+import tech.kayys.gollek.spi.Message;
+
+import java.util.List;
+import java.util.Map;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.mockito.Mockito.when;
+
+class LlamaCppChatTemplatesTest {
+
+    private static final String QWEN = "{% for message in messages %}<|im_start|>{{ message.role }}\n"
+            + "{{ message.content }}<|im_end|>\n{% endfor %}<|im_start|>assistant\n";
+
+    private static LlamaCppProviderConfig config(Map<String, String> overrides) {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.chatTemplates()).thenReturn(overrides);
+        return config;
+    }
+
+    @Test
+    void usesTheEmbeddedTemplateAndDetectsItsFamily() {
+        var active = LlamaCppChatTemplates.resolve(config(Map.of()), "qwen", QWEN);
+
+        assertThat(active.template()).isEqualTo(QWEN);
+        assertThat(active.describe()).isEqualTo(Map.of("source", "embedded", "name", "chatml"));
+        assertThat(LlamaCppChatTemplates.resolve(config(Map.of()), "base", null).describe())
+                .isEqualTo(Map.of("source", "fallback", "name", "chatml"));
+    }
+
+    @Test
+    void overridesByNameOrInlineTemplate() {
+        var named = LlamaCppChatTemplates.resolve(config(Map.of("qwen", "Llama3")), "qwen", QWEN);
+        var custom = LlamaCppChatTemplates.resolve(config(Map.of("qwen", "{{ messages[0].content }}")), "qwen", QWEN);
+
+        assertThat(named.describe()).isEqualTo(Map.of("source", "named", "name", "llama3"));
+        assertThat(named.template()).contains("<|start_header_id|>");
+        assertThat(custom.describe()).isEqualTo(Map.of("source", "custom"));
+        assertThat(LlamaCppChatTemplates.resolve(config(Map.of("other", "gemma")), "qwen", QWEN).source())
+                .isEqualTo("embedded");
+        assertThatThrownBy(() -> LlamaCppChatTemplates.resolve(config(Map.of("qwen", "vicuna")), "qwen", QWEN))
+                .isInstanceOf(IllegalArgumentException.class)
+                .hasMessageContaining("vicuna");
+    }
+
+    @Test
+    void namedTemplatesRenderAndAreDetectedAsThemselves() {
+        GGUFChatTemplateService service = new GGUFChatTemplateService();
+        List<Message> messages = List.of(Message.system("Be brief."), Message.user("Hi"));
+
+        LlamaCppChatTemplates.NAMED.forEach((name, template) -> {
+            assertThat(LlamaCppChatTemplates.detect(template)).as(name).isEqualTo(name);
+            assertThat(service.render(template, messages)).as(name).contains("Hi").doesNotContain("{%");
+        });
+        assertThat(service.render(LlamaCppChatTemplates.NAMED.get("gemma"), messages))
+                .endsWith("<start_of_turn>model\n");
+    }
+}
@@ -0,0 +1,57 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.mockito.Mockito.mock;
+import static org.mockito.Mockito.when;
+
+class LlamaCppContextOverflowTest {
+
+    private static final int[] PROMPT = { 1, 2, 3, 4, 5, 6, 7, 8, 9, 10 };
+
+    @Test
+    void requestChoiceBeatsConfiguredDefault() {
+        LlamaCppProviderConfig config = mock(LlamaCppProviderConfig.class);
+        when(config.contextOverflow()).thenReturn(Optional.empty());
+        assertThat(LlamaCppContextOverflow.forRequest(Map.of(), config)).isEqualTo(LlamaCppContextOverflow.ERROR);
+
+        when(config.contextTruncatePrompt()).thenReturn(true);
+        assertThat(LlamaCppContextOverflow.forRequest(Map.of(), config)).isEqualTo(LlamaCppContextOverflow.TRUNCATE);
+
+        when(config.contextOverflow()).thenReturn(Optional.of("shift"));
+        assertThat(LlamaCppContextOverflow.forRequest(Map.of(), config)).isEqualTo(LlamaCppContextOverflow.SHIFT);
+        assertThat(LlamaCppContextOverflow.forRequest(Map.of("context_overflow", "error"), config))
+                .isEqualTo(LlamaCppContextOverflow.ERROR);
+        assertThatThrownBy(() -> LlamaCppContextOverflow.forRequest(Map.of("context_overflow", "wrap"), config))
+                .isInstanceOf(IllegalArgumentException.class);
+    }
+
+    @Test
+    void fitsOversizedPromptsByStrategy() {
+        List<String> warnings = new ArrayList<>();
+
+        assertThatThrownBy(() -> LlamaCppContextOverflow.ERROR.fit(PROMPT, 6, 0, warnings))
+                .hasMessageContaining("exceeds the context window of 6 tokens");
+        assertThat(LlamaCppContextOverflow.TRUNCATE.fit(PROMPT, 6, 2, warnings)).containsExactly(5, 6, 7, 8, 9, 10);
+        assertThat(LlamaCppContextOverflow.SHIFT.fit(PROMPT, 6, 2, warnings)).containsExactly(1, 2, 7, 8, 9, 10);
+        // the kept prefix never takes more than half the window
+        assertThat(LlamaCppContextOverflow.SHIFT.fit(PROMPT, 6, 5, warnings)).containsExactly(1, 2, 3, 8, 9, 10);
+        assertThat(LlamaCppContextOverflow.SHIFT.fit(PROMPT, 10, 2, warnings)).isSameAs(PROMPT);
+        assertThat(warnings).containsExactly("prompt truncated by 4 tokens",
+                "prompt shifted: 4 tokens after the first 2 dropped",
+                "prompt shifted: 4 tokens after the first 3 dropped");
+    }
+
+    @Test
+    void discardsHalfOfTheShiftableTokens() {
+        assertThat(LlamaCppContextOverflow.discard(4096, 96)).isEqualTo(2000);
+        assertThat(LlamaCppContextOverflow.discard(8, 8)).isZero();
+    }
+}
@@ -0,0 +1,72 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import tech.kayys.gollek.inference.llamacpp.LlamaCppCpuFeatures.Host;
+
+import java.util.List;
+import java.util.Set;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppCpuFeaturesTest {
+
+    private static final String AVX512_BUILD = "CPU : SSE3 = 1 | SSSE3 = 1 | AVX = 1 | AVX2 = 1 | F16C = 1 | FMA = 1 "
+            + "| BMI2 = 1 | AVX512 = 1 | AVX512_VNNI = 1 | AVX512_BF16 = 0 | LLAMAFILE = 1 | OPENMP = 1 | REPACK = 1 | "
+            + "CUDA : ARCHS = 890 | USE_GRAPHS = 1 | ";
+
+    private static final String AVX2_CPUINFO = """
+            processor	: 0
+            vendor_id	: AuthenticAMD
+            flags		: fpu sse2 pni ssse3 fma sse4_1 sse4_2 avx f16c avx2 bmi2
+            bogomips	: 7186.00
+            """;
+
+    @Test
+    void readsOnlyEnabledFeaturesOfTheCpuSection() {
+        assertThat(LlamaCppCpuFeatures.libraryFeatures(AVX512_BUILD))
+                .containsExactly("SSE3", "SSSE3", "AVX", "AVX2", "F16C", "FMA", "BMI2", "AVX512", "AVX512_VNNI");
+        // builds before per-backend sections
+        assertThat(LlamaCppCpuFeatures.libraryFeatures("AVX = 1 | AVX2 = 0 | NEON = 0 | "))
+                .containsExactly("AVX");
+        assertThat(LlamaCppCpuFeatures.libraryFeatures("Metal : EMBED_LIBRARY = 1 | "))
+                .isEmpty();
+    }
+
+    @Test
+    void reportsFeaturesTheHostLacks() {
+        Host host = Host.ofFlags("/proc/cpuinfo", LlamaCppCpuFeatures.parseCpuinfo(AVX2_CPUINFO));
+
+        assertThat(host.supported()).contains("SSE3", "AVX2", "FMA").doesNotContain("AVX512");
+        assertThat(LlamaCppCpuFeatures.missing(LlamaCppCpuFeatures.libraryFeatures(AVX512_BUILD), host))
+                .containsExactly("AVX512", "AVX512_VNNI");
+    }
+
+    @Test
+    void featuresTheHostCannotReportAreNotFlagged() {
+        Host windows = new Host("IsProcessorFeaturePresent", Set.of("AVX", "AVX2"), Set.of("AVX", "AVX2", "AVX512"));
+
+        assertThat(LlamaCppCpuFeatures.missing(Set.of("AVX2", "FMA", "F16C"), windows)).isEmpty();
+        assertThat(LlamaCppCpuFeatures.missing(Set.of("AVX2", "AVX512"), windows)).containsExactly("AVX512");
+    }
+
+    @Test
+    void parsesMacSysctlFlags() {
+        String sysctl = """
+                hw.optional.arm.FEAT_DotProd: 1
+                hw.optional.arm.FEAT_I8MM: 0
+                hw.optional.neon: 1
+                """;
+        Host host = Host.ofFlags("sysctl", LlamaCppCpuFeatures.parseSysctl(sysctl));
+
+        assertThat(host.supported()).contains("NEON", "ARM_FMA", "DOTPROD").doesNotContain("MATMUL_INT8", "SVE");
+    }
+
+    @Test
+    void mismatchMessageNamesFeaturesAndRemedies() {
+        assertThat(LlamaCppCpuFeatures.mismatchMessage(List.of("AVX512", "AVX512_VBMI")))
+                .contains("AVX512, AVX512_VBMI")
+                .contains("GGML_CPU_ALL_VARIANTS")
+                .contains("GOLLEK_LLAMA_LIB_DIR")
+                .contains("gguf.provider.native.cpu-check=false");
+    }
+}
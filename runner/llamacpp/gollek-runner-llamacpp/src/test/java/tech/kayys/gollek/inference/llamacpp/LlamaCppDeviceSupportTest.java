@@ -0,0 +1,77 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import tech.kayys.gollek.inference.llamacpp.LlamaCppDeviceSupport.Backend;
+import tech.kayys.gollek.spi.model.DeviceType;
+
+import java.lang.foreign.MemorySegment;
+import java.util.EnumSet;
+import java.util.List;
+import java.util.Set;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.mockito.Mockito.mock;
+import static org.mockito.Mockito.when;
+
+class LlamaCppDeviceSupportTest {
+
+    private static LlamaCppProviderConfig config(boolean gpuEnabled, String backend) {
+        LlamaCppProviderConfig config = mock(LlamaCppProviderConfig.class);
+        when(config.gpuEnabled()).thenReturn(gpuEnabled);
+        when(config.gpuBackend()).thenReturn(backend);
+        return config;
+    }
+
+    @Test
+    void autoPrefersCudaThenRocmThenMetalThenVulkan() {
+        LlamaCppProviderConfig config = config(true, "auto");
+
+        assertThat(LlamaCppDeviceSupport.selectBackend(config, EnumSet.of(Backend.CPU, Backend.VULKAN, Backend.CUDA)))
+                .isEqualTo(Backend.CUDA);
+        assertThat(LlamaCppDeviceSupport.selectBackend(config, EnumSet.of(Backend.CPU, Backend.VULKAN, Backend.ROCM)))
+                .isEqualTo(Backend.ROCM);
+        assertThat(LlamaCppDeviceSupport.selectBackend(config, EnumSet.of(Backend.CPU, Backend.VULKAN)))
+                .isEqualTo(Backend.VULKAN);
+        assertThat(LlamaCppDeviceSupport.selectBackend(config, EnumSet.of(Backend.CPU)))
+                .isEqualTo(Backend.CPU);
+    }
+
+    @Test
+    void explicitBackendIsUsedOnlyWhenItHasADevice() {
+        Set<Backend> available = EnumSet.of(Backend.CPU, Backend.CUDA, Backend.VULKAN);
+
+        assertThat(LlamaCppDeviceSupport.selectBackend(config(true, "Vulkan"), available)).isEqualTo(Backend.VULKAN);
+        assertThat(LlamaCppDeviceSupport.selectBackend(config(true, "rocm"), available)).isEqualTo(Backend.CPU);
+        assertThat(LlamaCppDeviceSupport.selectBackend(config(true, "cpu"), available)).isEqualTo(Backend.CPU);
+        // the library could not list its devices: trust the configuration
+        assertThat(LlamaCppDeviceSupport.selectBackend(config(true, "rocm"), Set.of())).isEqualTo(Backend.ROCM);
+    }
+
+    @Test
+    void gpuOffloadDisabledAlwaysSelectsCpu() {
+        assertThat(LlamaCppDeviceSupport.selectBackend(config(false, "cuda"), EnumSet.of(Backend.CUDA)))
+                .isEqualTo(Backend.CPU);
+        assertThat(LlamaCppDeviceSupport.supportedDevices(config(false, "auto"), EnumSet.of(Backend.CUDA)))
+                .containsExactly(DeviceType.CPU);
+    }
+
+    @Test
+    void unknownBackendIsRejected() {
+        assertThatThrownBy(() -> LlamaCppDeviceSupport.selectBackend(config(true, "opencl"), Set.of()))
+                .isInstanceOf(IllegalArgumentException.class)
+                .hasMessageContaining("opencl");
+    }
+
+    @Test
+    void detectsBackendsFromRegisteredDevices() {
+        LlamaCppBinding binding = mock(LlamaCppBinding.class);
+        LlamaCppBinding.BackendDevice cpu = new LlamaCppBinding.BackendDevice(MemorySegment.NULL, "CPU", "CPU", 0);
+        LlamaCppBinding.BackendDevice blas = new LlamaCppBinding.BackendDevice(MemorySegment.NULL, "BLAS", "BLAS", 2);
+        LlamaCppBinding.BackendDevice rocm = new LlamaCppBinding.BackendDevice(MemorySegment.NULL, "ROCm", "ROCm0", 1);
+        when(binding.backendDevices()).thenReturn(List.of(cpu, blas, rocm));
+
+        assertThat(LlamaCppDeviceSupport.detectBackends(binding)).containsExactly(Backend.CPU, Backend.ROCM);
+        assertThat(LlamaCppDeviceSupport.devicesOf(binding, Backend.ROCM)).containsExactly(rocm);
+    }
+}
@@ -0,0 +1,59 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+
+import java.lang.foreign.MemorySegment;
+import java.util.List;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.mockito.ArgumentMatchers.any;
+import static org.mockito.ArgumentMatchers.anyBoolean;
+import static org.mockito.ArgumentMatchers.anyString;
+import static org.mockito.ArgumentMatchers.eq;
+import static org.mockito.Mockito.mock;
+import static org.mockito.Mockito.verify;
+import static org.mockito.Mockito.when;
+
+class LlamaCppEmbeddingEngineTest {
+
+        private final MemorySegment model = MemorySegment.ofAddress(1);
+        private final MemorySegment params = MemorySegment.ofAddress(2);
+        private final MemorySegment context = MemorySegment.ofAddress(3);
+
+        @Test
+        @DisplayName("Zero falls back to the generation context size")
+        void contextSizeFallsBackToGeneration() {
+                assertThat(LlamaCppEmbeddingEngine.contextSize(512, 8192)).isEqualTo(512);
+                assertThat(LlamaCppEmbeddingEngine.contextSize(0, 8192)).isEqualTo(8192);
+        }
+
+        @Test
+        @DisplayName("Embedding context is sized by its own setting, not the generation context")
+        void usesEmbeddingContextSize() throws Throwable {
+                LlamaCppBinding binding = mock(LlamaCppBinding.class);
+                LlamaCppProviderConfig config = mock(LlamaCppProviderConfig.class);
+                when(config.embeddingContextSize()).thenReturn(256);
+                when(config.embeddingPooling()).thenReturn("mean");
+                when(binding.getDefaultContextParams()).thenReturn(params);
+                when(binding.createContext(model, params)).thenReturn(context);
+                when(binding.nEmbd(model)).thenReturn(4);
+                when(binding.tokenize(eq(model), anyString(), anyBoolean(), anyBoolean()))
+                                .thenReturn(new int[300]);
+                when(binding.batchInit(256, 0, 1)).thenReturn(MemorySegment.ofAddress(4));
+                when(binding.decode(any(), any())).thenReturn(1);
+
+                var engine = new LlamaCppEmbeddingEngine(binding, config, model, 32768);
+                try {
+                        engine.embed(List.of("long input"));
+                } catch (RuntimeException expected) {
+                        // decode is stubbed to fail; only the context set-up matters here
+                }
+
+                verify(binding).setContextParam(params, "n_ctx", 256);
+                verify(binding).setContextParam(params, "n_batch", 256);
+                verify(binding).setContextParam(params, "n_ubatch", 256);
+                // inputs longer than the context are cut to fit one batch
+                verify(binding).batchInit(256, 0, 1);
+        }
+}
@@ -0,0 +1,39 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+
+import java.util.Map;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppKVCacheEstimatorTest {
+
+    @Test
+    void estimatesGroupedQueryAttentionCache() {
+        // Llama-3-8B: 32 layers, 4096 embd, 32 heads, 8 kv heads -> 128 KiB per token
+        Map<String, String> meta = Map.of(
+                "general.architecture", "llama",
+                "llama.block_count", "32",
+                "llama.embedding_length", "4096",
+                "llama.attention.head_count", "32",
+                "llama.attention.head_count_kv", "8");
+
+        LlamaCppKVCacheEstimator.Estimate estimate = LlamaCppKVCacheEstimator.estimate(meta::get, 8192);
+
+        assertThat(estimate.bytesPerToken()).isEqualTo(32L * 8 * (128 + 128) * 2);
+        assertThat(estimate.totalBytes()).isEqualTo(1024L * 1024 * 1024);
+    }
+
+    @Test
+    void unknownArchitectureYieldsNoEstimate() {
+        assertThat(LlamaCppKVCacheEstimator.estimate(key -> null, 4096).known()).isFalse();
+    }
+
+    @Test
+    void fitContextRoundsDownWithinBudget() {
+        long perToken = 128 * 1024;
+        assertThat(LlamaCppKVCacheEstimator.fitContext(perToken, perToken * 3000, 8192)).isEqualTo(2816);
+        assertThat(LlamaCppKVCacheEstimator.fitContext(perToken, perToken * 10, 8192)).isEqualTo(512);
+        assertThat(LlamaCppKVCacheEstimator.fitContext(perToken, perToken * 9000, 8192)).isEqualTo(8192);
+    }
+}
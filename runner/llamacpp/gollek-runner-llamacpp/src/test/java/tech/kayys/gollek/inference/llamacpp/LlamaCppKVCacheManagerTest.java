@@ -0,0 +1,184 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.BeforeEach;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import java.lang.foreign.MemorySegment;
+import java.nio.file.Files;
+import java.nio.file.Path;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.mockito.ArgumentMatchers.any;
+import static org.mockito.ArgumentMatchers.anyInt;
+import static org.mockito.ArgumentMatchers.eq;
+import static org.mockito.Mockito.mock;
+import static org.mockito.Mockito.never;
+import static org.mockito.Mockito.verify;
+import static org.mockito.Mockito.when;
+
+class LlamaCppKVCacheManagerTest {
+
+        private final MemorySegment context = MemorySegment.ofAddress(1);
+        private LlamaCppBinding binding;
+        private LlamaCppProviderConfig config;
+        private LlamaCppKVCacheManager manager;
+
+        @BeforeEach
+        void setUp() {
+                binding = mock(LlamaCppBinding.class);
+                config = mock(LlamaCppProviderConfig.class);
+                when(config.prefixCacheEnabled()).thenReturn(true);
+                when(config.prefixCacheMinTokens()).thenReturn(2);
+                manager = new LlamaCppKVCacheManager(binding, config, null);
+        }
+
+        @Test
+        @DisplayName("Diverging tail is trimmed and the shared prefix reused")
+        void trimsDivergingTail() {
+                manager.updateAfterPrompt(new int[] { 1, 2, 3, 4, 5 }, 5);
+                when(binding.memorySeqRm(context, 0, 3, -1)).thenReturn(true);
+
+                int reused = manager.reusePrefix(context, new int[] { 1, 2, 3, 9, 9 }, 5);
+
+                assertThat(reused).isEqualTo(3);
+                assertThat(manager.getTokenCount()).isEqualTo(3);
+                verify(binding, never()).kvCacheClear(any());
+        }
+
+        @Test
+        @DisplayName("Identical prompt still re-decodes its last token")
+        void identicalPromptKeepsLastTokenForLogits() {
+                manager.updateAfterPrompt(new int[] { 1, 2, 3, 4 }, 4);
+                when(binding.memorySeqRm(context, 0, 3, -1)).thenReturn(true);
+
+                assertThat(manager.reusePrefix(context, new int[] { 1, 2, 3, 4 }, 4)).isEqualTo(3);
+        }
+
+        @Test
+        @DisplayName("Falls back to a full clear when partial removal is unsupported")
+        void clearsWhenSeqRmUnsupported() {
+                manager.updateAfterPrompt(new int[] { 1, 2, 3, 4 }, 4);
+                when(binding.memorySeqRm(eq(context), anyInt(), anyInt(), anyInt())).thenReturn(false);
+
+                assertThat(manager.reusePrefix(context, new int[] { 1, 2, 3, 7 }, 4)).isZero();
+                verify(binding).kvCacheClear(context);
+        }
+
+        @Test
+        @DisplayName("Short shared prefixes are not worth reusing")
+        void shortPrefixResets() {
+                manager.updateAfterPrompt(new int[] { 1, 5 }, 2);
+
+                assertThat(manager.reusePrefix(context, new int[] { 1, 6, 7 }, 3)).isZero();
+                verify(binding).kvCacheClear(context);
+        }
+
+        @Test
+        @DisplayName("Slot 0 tracks occupancy, prefix hits and evicted tokens")
+        void tracksSlotOccupancy() {
+                manager.updateAfterPrompt(new int[] { 1, 2, 3, 4, 5 }, 5);
+                when(binding.memorySeqRm(context, 0, 3, -1)).thenReturn(true);
+                manager.reusePrefix(context, new int[] { 1, 2, 3, 9, 9 }, 5);
+                manager.reusePrefix(context, new int[] { 7, 8, 9 }, 3);
+
+                LlamaCppSlotStats.Slot slot = manager.slotStats().snapshot().get(0);
+                assertThat(slot.prefixHits()).isEqualTo(1);
+                assertThat(slot.prefixMisses()).isEqualTo(1);
+                assertThat(slot.reusedTokens()).isEqualTo(3);
+                assertThat(slot.evictions()).isEqualTo(2);
+                assertThat(slot.evictedTokens()).isEqualTo(5);
+                assertThat(slot.tokens()).isZero();
+        }
+
+        @Test
+        @DisplayName("Active slots without progress are reported as stalled")
+        void reportsStalledSlots() {
+                LlamaCppSlotStats stats = new LlamaCppSlotStats(2, 100);
+                stats.acquire(0, "quiet");
+                stats.acquire(1, "busy");
+                long later = System.nanoTime() + 5_000_000_000L;
+
+                assertThat(stats.stalledRequests(later, 1_000_000_000L)).containsExactly("quiet", "busy");
+                assertThat(stats.stalledRequests(System.nanoTime(), 1_000_000_000L)).isEmpty();
+
+                stats.idle(1);
+                assertThat(stats.stalledRequests(later, 1_000_000_000L)).containsExactly("quiet");
+                assertThat(stats.activeSlots()).isEqualTo(1);
+        }
+
+        @Test
+        @DisplayName("A saved sequence snapshot restores as a reusable prefix")
+        void restoredSnapshotIsReused(@TempDir Path dir) throws Exception {
+                Path file = dir.resolve("system.kv");
+                when(binding.supportsStateSnapshots()).thenReturn(true);
+                when(config.maxContextTokens()).thenReturn(4096);
+                manager.updateAfterPrompt(new int[] { 1, 2, 3, 4 }, 4);
+                when(binding.saveSeqState(eq(context), eq(0), eq(file), any(), eq(4))).thenReturn(true);
+
+                assertThat(manager.saveSequenceState(context, file)).isTrue();
+
+                Files.writeString(file, "state");
+                manager.clear();
+                when(binding.loadSeqState(context, 0, file, 4096)).thenReturn(new int[] { 1, 2, 3, 4 });
+
+                assertThat(manager.restoreSequenceState(context, file)).isEqualTo(4);
+                assertThat(manager.reusePrefix(context, new int[] { 1, 2, 3, 4, 8 }, 5)).isEqualTo(4);
+        }
+
+        @Test
+        @DisplayName("A system prompt cached on disk survives a fresh cache manager")
+        void promptCacheRestoresTheSystemPrefix(@TempDir Path dir) {
+                when(config.promptCacheEnabled()).thenReturn(true);
+                when(config.promptCacheDir()).thenReturn(java.util.Optional.of(dir.toString()));
+                when(config.promptCacheMaxBytes()).thenReturn(1L << 20);
+                when(config.promptCacheMaxEntries()).thenReturn(8);
+                when(config.promptCacheMinTokens()).thenReturn(3);
+                when(binding.supportsStateSnapshots()).thenReturn(true);
+                when(binding.saveSeqState(eq(context), eq(0), any(), any(), eq(3))).thenAnswer(invocation -> {
+                        Files.writeString(invocation.getArgument(2), "state");
+                        return true;
+                });
+                int[] first = { 1, 2, 3, 4, 5 };
+                manager = new LlamaCppKVCacheManager(binding, config, null);
+
+                assertThat(manager.shouldCachePrompt(first, 3)).isTrue();
+                manager.cachePrompt(context, first, 3);
+                assertThat(manager.shouldCachePrompt(first, 3)).isFalse();
+
+                // as after a restart: nothing in memory
+                manager = new LlamaCppKVCacheManager(binding, config, null);
+                when(binding.loadSeqState(eq(context), eq(0), any(), eq(3))).thenReturn(new int[] { 1, 2, 3 });
+
+                assertThat(manager.reusePrefix(context, new int[] { 1, 2, 3, 7, 8 }, 5, 3)).isEqualTo(3);
+                assertThat(manager.getTokenHistory()).containsExactly(1, 2, 3);
+        }
+
+        @Test
+        @DisplayName("Snapshots are skipped without the native state API")
+        void snapshotsNeedStateApi() {
+                manager.updateAfterPrompt(new int[] { 1, 2, 3 }, 3);
+
+                assertThat(manager.saveSequenceState(context, Path.of("unused.kv"))).isFalse();
+                verify(binding, never()).saveSeqState(any(), anyInt(), any(), any(), anyInt());
+        }
+
+        @Test
+        @DisplayName("Context shift drops tokens after the kept prefix and moves the rest down")
+        void shiftsContextPastTheKeptPrefix() {
+                manager.updateAfterPrompt(new int[] { 1, 2, 3, 4, 5, 6, 7, 8 }, 8);
+                when(binding.canShiftMemory(context)).thenReturn(true);
+                when(binding.memorySeqRm(context, 0, 2, 5)).thenReturn(true);
+
+                assertThat(manager.shiftContext(context, 2, 3)).isTrue();
+
+                verify(binding).memorySeqAdd(context, 0, 5, -1, -3);
+                assertThat(manager.getTokenCount()).isEqualTo(5);
+                assertThat(manager.getTokenHistory()).containsExactly(1, 2, 6, 7, 8);
+
+                when(binding.canShiftMemory(context)).thenReturn(false);
+                assertThat(manager.shiftContext(context, 2, 1)).isFalse();
+                assertThat(manager.getTokenCount()).isEqualTo(5);
+        }
+}
@@ -0,0 +1,88 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+
+import java.lang.foreign.MemorySegment;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.assertj.core.api.Assertions.within;
+
+class LlamaCppLogprobsTest {
+
+    private static final List<String> VOCAB = List.of("a", "b", "c", "d");
+
+    @Test
+    void logprobsAreTheLogSoftmaxOfTheRawLogits() {
+        LlamaCppLogprobs logprobs = new LlamaCppLogprobs(2, VOCAB::get);
+
+        logprobs.record(MemorySegment.ofArray(new float[] { 1f, 3f, 2f, 0f }), 2, "c");
+
+        double logSumExp = Math.log(Math.exp(1) + Math.exp(3) + Math.exp(2) + Math.exp(0));
+        LlamaCppLogprobs.Entry entry = logprobs.upTo(1).get(0);
+        assertThat(entry.token()).isEqualTo(2);
+        assertThat(entry.logprob()).isCloseTo(2 - logSumExp, within(1e-6));
+        assertThat(entry.top()).extracting(LlamaCppLogprobs.Alternative::text).containsExactly("b", "c");
+        assertThat(entry.top().get(0).logprob()).isCloseTo(3 - logSumExp, within(1e-6));
+    }
+
+    @Test
+    void entriesAreReleasedOnlyOnceTheirTextIs() {
+        LlamaCppLogprobs logprobs = new LlamaCppLogprobs(0, VOCAB::get);
+        float[] logits = { 0f, 0f, 0f, 0f };
+        logprobs.record(MemorySegment.ofArray(logits), 0, "He");
+        logprobs.record(MemorySegment.ofArray(logits), 1, "llo");
+        logprobs.record(MemorySegment.ofArray(logits), 2, "\n");
+
+        assertThat(logprobs.release(2)).extracting(LlamaCppLogprobs.Entry::text).containsExactly("He");
+        // "\n" is held back as a possible stop prefix
+        assertThat(logprobs.release(5)).extracting(LlamaCppLogprobs.Entry::text).containsExactly("llo");
+        assertThat(logprobs.release(5)).isEmpty();
+        assertThat(logprobs.upTo(5)).hasSize(2);
+        assertThat(logprobs.upTo(5).get(0).top()).isEmpty();
+    }
+
+    @Test
+    void requestedAcceptsCountsAndOpenAiChatFlags() {
+        assertThat(LlamaCppLogprobs.requested(Map.of())).isEqualTo(-1);
+        assertThat(LlamaCppLogprobs.requested(Map.of("logprobs", false))).isEqualTo(-1);
+        assertThat(LlamaCppLogprobs.requested(Map.of("logprobs", 3))).isEqualTo(3);
+        assertThat(LlamaCppLogprobs.requested(Map.of("logprobs", 50))).isEqualTo(LlamaCppLogprobs.MAX_TOP);
+        assertThat(LlamaCppLogprobs.requested(Map.of("logprobs", true))).isZero();
+        assertThat(LlamaCppLogprobs.requested(Map.of("logprobs", true, "top_logprobs", 5))).isEqualTo(5);
+        assertThatThrownBy(() -> LlamaCppLogprobs.requested(Map.of("logprobs", -1)))
+                .isInstanceOf(IllegalArgumentException.class);
+    }
+
+    @Test
+    void metadataFollowsTheOpenAiShape() {
+        LlamaCppLogprobs logprobs = new LlamaCppLogprobs(1, VOCAB::get);
+        logprobs.record(MemorySegment.ofArray(new float[] { 5f, 0f, 0f, 0f }), 0, "a");
+
+        Map<String, Object> item = LlamaCppLogprobs.toMetadata(logprobs.upTo(1)).get(0);
+
+        assertThat(item).containsEntry("token", "a").containsEntry("id", 0).containsEntry("bytes", List.of(97));
+        assertThat((List<?>) item.get("top_logprobs")).hasSize(1);
+    }
+
+    @Test
+    void streamFilterForwardsLogprobsWithTheTextTheyCover() {
+        List<String> texts = new ArrayList<>();
+        List<Integer> counts = new ArrayList<>();
+        LlamaCppPostProcessor.StreamFilter filter = LlamaCppPostProcessor.NONE.stream(
+                (LlamaCppLogprobs.Sink) (text, entries) -> {
+                    texts.add(text);
+                    counts.add(entries.size());
+                });
+        LlamaCppLogprobs.Entry entry = new LlamaCppLogprobs.Entry(0, "a", -0.5, List.of());
+
+        filter.accept("a", List.of(entry));
+        filter.finish();
+
+        assertThat(texts).containsExactly("a");
+        assertThat(counts).containsExactly(1);
+    }
+}
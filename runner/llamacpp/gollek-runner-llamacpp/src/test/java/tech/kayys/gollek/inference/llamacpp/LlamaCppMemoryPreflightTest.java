@@ -0,0 +1,65 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import java.nio.file.Files;
+import java.nio.file.Path;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppMemoryPreflightTest {
+
+    private static final long GIB = 1024L * 1024 * 1024;
+
+    @TempDir
+    Path dir;
+
+    /** Llama-3-8B shaped: 32 layers, 128 KiB of KV cache per token. */
+    private static LlamaCppKVCacheEstimator.Estimate llama3(int contextSize) {
+        return new LlamaCppKVCacheEstimator.Estimate("llama", 32, 8, 128, 128, 128 * 1024, contextSize);
+    }
+
+    @Test
+    void refusesCpuLoadAndSuggestsAContextThatFits() {
+        LlamaCppMemoryPreflight.Verdict verdict = LlamaCppMemoryPreflight.check(
+                4 * GIB, llama3(32768), 0, 6 * GIB, -1, 0);
+
+        assertThat(verdict.fits()).isFalse();
+        assertThat(verdict.hostShort()).isTrue();
+        assertThat(verdict.required().hostBytes()).isEqualTo(8 * GIB + LlamaCppMemoryPreflight.MIN_OVERHEAD_BYTES);
+        assertThat(verdict.suggestedContext()).isEqualTo(14336);
+        assertThat(verdict.suggestedGpuLayers()).isEqualTo(-1);
+        assertThat(verdict.message("llama3", 32768, 0))
+                .contains("needs ~8.3 GiB RAM but 6.0 GiB is available")
+                .contains("n_ctx=14336")
+                .contains("memory.preflight.enabled=false");
+    }
+
+    @Test
+    void suggestsFewerGpuLayersWhenVramIsShort() {
+        LlamaCppMemoryPreflight.Verdict verdict = LlamaCppMemoryPreflight.check(
+                4 * GIB, llama3(8192), -1, -1, 3 * GIB, 0);
+
+        assertThat(verdict.deviceShort()).isTrue();
+        assertThat(verdict.suggestedGpuLayers()).isEqualTo(18);
+        assertThat(verdict.message("llama3", 8192, -1)).contains("VRAM").contains("gpu_layers=18");
+    }
+
+    @Test
+    void fitsWhenMemoryCannotBeMeasuredOrIsPlentiful() {
+        assertThat(LlamaCppMemoryPreflight.check(4 * GIB, llama3(8192), -1, -1, -1, 0).fits()).isTrue();
+        assertThat(LlamaCppMemoryPreflight.check(4 * GIB, llama3(8192), 0, 16 * GIB, -1, GIB).fits()).isTrue();
+        assertThat(LlamaCppMemoryPreflight.check(4 * GIB, llama3(8192), 0, 5 * GIB, -1, GIB).fits()).isFalse();
+    }
+
+    @Test
+    void readsMemAvailableFromProcMeminfo() throws Exception {
+        Path meminfo = Files.writeString(dir.resolve("meminfo"),
+                "MemTotal:       16318412 kB\nMemFree:          512000 kB\nMemAvailable:    8192000 kB\n");
+
+        assertThat(LlamaCppMemoryPreflight.memAvailable(meminfo)).isEqualTo(8192000L * 1024);
+        assertThat(LlamaCppMemoryPreflight.memAvailable(dir.resolve("missing"))).isEqualTo(-1);
+        assertThat(LlamaCppMemoryPreflight.availableHostMemory(3 * GIB)).isEqualTo(3 * GIB);
+    }
+}
@@ -0,0 +1,49 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppOutputLengthPredictorTest {
+
+    private static InferenceRequest request(String prompt, int maxTokens) {
+        return InferenceRequest.builder()
+                .model("m")
+                .message(Message.user(prompt))
+                .parameter("max_tokens", maxTokens)
+                .build();
+    }
+
+    @Test
+    void withoutHistoryRequestsUseTheirWholeLimit() {
+        assertThat(new LlamaCppOutputLengthPredictor().predict(request("hi", 300))).isEqualTo(300);
+    }
+
+    @Test
+    void learnsPerPromptSizeAndFallsBackToTheOverallMean() {
+        LlamaCppOutputLengthPredictor predictor = new LlamaCppOutputLengthPredictor();
+        for (int i = 0; i < 20; i++) {
+            predictor.record(request("short question", 500), 10);
+            predictor.record(request("x".repeat(4000), 500), 400);
+        }
+
+        assertThat(predictor.predict(request("other words", 500))).isEqualTo(10);
+        assertThat(predictor.predict(request("y".repeat(4000), 500))).isEqualTo(400);
+        // no history for this size yet: somewhere between the two, from the overall mean
+        assertThat(predictor.predict(request("z".repeat(200), 500))).isBetween(10, 400);
+        assertThat(predictor.predict(request("y".repeat(4000), 50))).isEqualTo(50);
+    }
+
+    @Test
+    void constrainedOutputIsItsOwnBucket() {
+        LlamaCppOutputLengthPredictor predictor = new LlamaCppOutputLengthPredictor();
+        predictor.record(request("question", 500), 100);
+        InferenceRequest json = request("question", 500).toBuilder().parameter("json_mode", true).build();
+        predictor.record(json, 20);
+
+        assertThat(predictor.predict(json)).isEqualTo(20);
+        assertThat(predictor.predict(request("question", 500))).isEqualTo(100);
+    }
+}
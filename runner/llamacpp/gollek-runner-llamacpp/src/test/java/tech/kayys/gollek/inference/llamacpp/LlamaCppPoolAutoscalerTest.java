@@ -0,0 +1,56 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import org.mockito.Mockito;
+
+import java.time.Duration;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppPoolAutoscalerTest {
+
+    private static LlamaCppPoolAutoscaler autoscaler() {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        Mockito.when(config.sessionPoolMinSize()).thenReturn(1);
+        Mockito.when(config.sessionPoolAutoscaleMaxRunners()).thenReturn(3);
+        Mockito.when(config.sessionPoolAutoscaleTargetQueue()).thenReturn(2);
+        Mockito.when(config.sessionPoolAutoscaleTargetWait()).thenReturn(Duration.ofSeconds(5));
+        Mockito.when(config.sessionPoolAutoscaleScaleDownAfter()).thenReturn(Duration.ofSeconds(60));
+        return new LlamaCppPoolAutoscaler(config);
+    }
+
+    @Test
+    void scalesUpOnQueueDepthOrWaitUpToMax() {
+        var autoscaler = autoscaler();
+
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(1, 2, 0), 0))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.HOLD);
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(1, 3, 0), 0))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.UP);
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(2, 1, 6_000), 0))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.UP);
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(3, 20, 10_000), 0))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.HOLD);
+    }
+
+    @Test
+    void scalesDownOneRunnerPerQuietPeriodToMin() {
+        var autoscaler = autoscaler();
+        var quiet = new LlamaCppPoolAutoscaler.Load(3, 0, 0);
+
+        assertThat(autoscaler.decide(quiet, 0)).isEqualTo(LlamaCppPoolAutoscaler.Step.HOLD);
+        assertThat(autoscaler.decide(quiet, 59_000)).isEqualTo(LlamaCppPoolAutoscaler.Step.HOLD);
+        assertThat(autoscaler.decide(quiet, 60_000)).isEqualTo(LlamaCppPoolAutoscaler.Step.DOWN);
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(2, 0, 0), 61_000))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.HOLD);
+        // a waiting request restarts the quiet period
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(2, 1, 0), 100_000))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.HOLD);
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(2, 0, 0), 130_000))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.HOLD);
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(2, 0, 0), 190_000))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.DOWN);
+        assertThat(autoscaler.decide(new LlamaCppPoolAutoscaler.Load(1, 0, 0), 500_000))
+                .isEqualTo(LlamaCppPoolAutoscaler.Step.HOLD);
+    }
+}
@@ -0,0 +1,116 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import org.mockito.Mockito;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse.FinishReason;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.mockito.Mockito.when;
+
+class LlamaCppPostProcessorTest {
+
+    private static LlamaCppPostProcessor forModel(boolean removeArtifacts, boolean trimToSentence) {
+        LlamaCppProviderConfig.Replacement replacement = Mockito.mock(LlamaCppProviderConfig.Replacement.class);
+        when(replacement.pattern()).thenReturn("^Answer:\\s*");
+        when(replacement.replacement()).thenReturn(Optional.empty());
+        LlamaCppProviderConfig.PostProcess postProcess = Mockito.mock(LlamaCppProviderConfig.PostProcess.class);
+        when(postProcess.removeArtifacts()).thenReturn(removeArtifacts);
+        when(postProcess.replacements()).thenReturn(Optional.of(List.of(replacement)));
+        when(postProcess.trimToSentence()).thenReturn(trimToSentence);
+        when(postProcess.stripLeadingWhitespace()).thenReturn(true);
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.postProcess()).thenReturn(Map.of("chat", postProcess));
+        return LlamaCppPostProcessor.forModel(config, "chat");
+    }
+
+    private static InferenceRequest request(Object postProcess) {
+        return InferenceRequest.builder()
+                .model("chat")
+                .message(Message.user("hello"))
+                .parameter(LlamaCppPostProcessor.PARAMETER, postProcess)
+                .build();
+    }
+
+    @Test
+    void unconfiguredModelsAreLeftAlone() {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.postProcess()).thenReturn(Map.of());
+
+        assertThat(LlamaCppPostProcessor.forModel(config, "chat").isEmpty()).isTrue();
+    }
+
+    @Test
+    void removesTemplateResidueThenAppliesReplacements() {
+        LlamaCppPostProcessor output = forModel(true, false);
+
+        assertThat(output.apply("\n<|im_start|>assistant\nAnswer: Paris.<|im_end|>", FinishReason.STOP))
+                .isEqualTo("Paris.");
+    }
+
+    @Test
+    void trimsOnlyTruncatedOutputToTheLastSentence() {
+        LlamaCppPostProcessor output = forModel(false, true);
+
+        assertThat(output.apply("It is \"done.\" Then it went", FinishReason.LENGTH)).isEqualTo("It is \"done.\"");
+        assertThat(output.apply("It is done. Then it went", FinishReason.STOP)).isEqualTo("It is done. Then it went");
+        assertThat(output.apply("no sentence end", FinishReason.LENGTH)).isEqualTo("no sentence end");
+    }
+
+    @Test
+    void requestsOverrideSwitchesAndAddReplacements() {
+        LlamaCppPostProcessor output = forModel(true, false).forRequest(request(Map.of(
+                "remove_artifacts", false,
+                "replace", List.of(Map.of("pattern", "colour", "replacement", "color")))));
+
+        assertThat(output.removeArtifacts()).isFalse();
+        assertThat(output.apply("Answer: the colour</s>", FinishReason.STOP)).isEqualTo("the color</s>");
+    }
+
+    @Test
+    void stripsOrEscapesHtml() {
+        LlamaCppPostProcessor strip = LlamaCppPostProcessor.NONE.forRequest(request(Map.of("sanitize", "strip")));
+        LlamaCppPostProcessor escape = LlamaCppPostProcessor.NONE.forRequest(request(Map.of("sanitize", "escape")));
+        String text = "Hi <b>there</b><script>alert(1)</script> [x](javascript:alert(1)) & bye";
+
+        assertThat(strip.apply(text, FinishReason.STOP)).isEqualTo("Hi there [x](#) & bye");
+        assertThat(escape.apply(text, FinishReason.STOP)).isEqualTo(
+                "Hi &lt;b&gt;there&lt;/b&gt;&lt;script&gt;alert(1)&lt;/script&gt; [x](#) &amp; bye");
+        assertThatThrownBy(() -> LlamaCppPostProcessor.NONE.forRequest(request(Map.of("sanitize", "bleach"))))
+                .isInstanceOf(IllegalArgumentException.class)
+                .hasMessageContaining("bleach");
+    }
+
+    @Test
+    void streamsHoldBackTagsUntilTheyClose() {
+        LlamaCppPostProcessor strip = LlamaCppPostProcessor.NONE.forRequest(request(Map.of("sanitize", "strip")));
+        List<String> out = new ArrayList<>();
+        LlamaCppPostProcessor.StreamFilter stream = strip.stream(out::add);
+
+        for (String piece : List.of("a <", "b>bold</b> <scr", "ipt>x", "(1)</scr")) {
+            stream.accept(piece);
+        }
+        assertThat(String.join("", out)).isEqualTo("a bold ");
+        stream.accept("ipt> [y](javascript:f(1)");
+        assertThat(String.join("", out)).isEqualTo("a bold  [y");
+        stream.accept(") end <");
+        assertThat(String.join("", out)).isEqualTo("a bold  [y](#) end ");
+        stream.finish();
+
+        assertThat(String.join("", out)).isEqualTo("a bold  [y](#) end <");
+    }
+
+    @Test
+    void rejectsInvalidRequestPatterns() {
+        assertThatThrownBy(() -> LlamaCppPostProcessor.NONE.forRequest(request(Map.of(
+                "replace", List.of(Map.of("pattern", "(unclosed"))))))
+                .isInstanceOf(IllegalArgumentException.class);
+    }
+}
@@ -0,0 +1,89 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import java.lang.foreign.MemorySegment;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.attribute.FileTime;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.mockito.ArgumentMatchers.any;
+import static org.mockito.ArgumentMatchers.anyInt;
+import static org.mockito.ArgumentMatchers.eq;
+import static org.mockito.Mockito.mock;
+import static org.mockito.Mockito.when;
+
+class LlamaCppPromptCacheTest {
+
+    private static final int[] SYSTEM = { 1, 2, 3, 4 };
+    private static final int[] PROMPT = { 1, 2, 3, 4, 5, 6 };
+
+    @TempDir
+    Path dir;
+
+    private final LlamaCppBinding binding = mock(LlamaCppBinding.class);
+
+    private LlamaCppPromptCache cache(long maxBytes, int maxEntries) {
+        // every entry is 100 bytes
+        when(binding.saveSeqState(any(), eq(0), any(), any(), anyInt())).thenAnswer(invocation -> {
+            Files.write(invocation.getArgument(2), new byte[100]);
+            return true;
+        });
+        return new LlamaCppPromptCache(binding, dir, maxBytes, maxEntries, 4);
+    }
+
+    @Test
+    void keysOnlyTheCachedPrefix() {
+        assertThat(LlamaCppPromptCache.key(PROMPT, 4)).isEqualTo(LlamaCppPromptCache.key(SYSTEM, 4))
+                .hasSize(64);
+        assertThat(LlamaCppPromptCache.key(PROMPT, 5)).isNotEqualTo(LlamaCppPromptCache.key(PROMPT, 4));
+    }
+
+    @Test
+    void storesAndRestoresAPrefix() {
+        LlamaCppPromptCache cache = cache(1000, 8);
+        MemorySegment context = MemorySegment.NULL;
+
+        assertThat(cache.worthCaching(3)).isFalse();
+        assertThat(cache.store(context, PROMPT, 4)).isTrue();
+        assertThat(cache.contains(SYSTEM, 4)).isTrue();
+        assertThat(dir).isDirectoryNotContaining("glob:**.tmp");
+
+        when(binding.loadSeqState(context, 0, cache.path(PROMPT, 4), 4)).thenReturn(SYSTEM);
+        assertThat(cache.load(context, PROMPT, 4)).containsExactly(SYSTEM);
+    }
+
+    @Test
+    void dropsEntriesThatNoLongerLoad() {
+        LlamaCppPromptCache cache = cache(1000, 8);
+        cache.store(MemorySegment.NULL, PROMPT, 4);
+        // e.g. written for another build of the model
+        when(binding.loadSeqState(any(), eq(0), any(), anyInt())).thenReturn(new int[0]);
+
+        assertThat(cache.load(MemorySegment.NULL, PROMPT, 4)).isEmpty();
+        assertThat(cache.contains(PROMPT, 4)).isFalse();
+    }
+
+    @Test
+    void evictsLeastRecentlyUsedEntriesBeyondTheLimits() throws Exception {
+        LlamaCppPromptCache cache = cache(250, 8);
+        int[] a = { 10, 11, 12, 13 };
+        int[] b = { 20, 21, 22, 23 };
+        int[] c = { 30, 31, 32, 33 };
+        cache.store(MemorySegment.NULL, a, 4);
+        cache.store(MemorySegment.NULL, b, 4);
+        Files.setLastModifiedTime(cache.path(a, 4), FileTime.fromMillis(2_000));
+        Files.setLastModifiedTime(cache.path(b, 4), FileTime.fromMillis(1_000));
+
+        cache.store(MemorySegment.NULL, c, 4);
+
+        assertThat(cache.contains(b, 4)).isFalse();
+        assertThat(cache.contains(a, 4)).isTrue();
+        assertThat(cache.contains(c, 4)).isTrue();
+
+        new LlamaCppPromptCache(binding, dir, 1000, 1, 4).evict();
+        assertThat(cache.entries()).containsExactly(cache.path(c, 4));
+    }
+}
@@ -0,0 +1,62 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import org.mockito.Mockito;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.List;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.mockito.Mockito.when;
+
+class LlamaCppPromptCompressorTest {
+
+    /** Token i spells VOCAB[i]. */
+    private static final List<String> VOCAB = List.of("<|im_start|>", "user", "\n", " the", " cat", " sat",
+            " on", " mat", ".", " very", " big");
+
+    private static LlamaCppPromptCompressor compressor(boolean enabled) {
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.promptCompressionEnabled()).thenReturn(enabled);
+        when(config.promptCompressionMinTokens()).thenReturn(8);
+        when(config.promptCompressionTargetRatio()).thenReturn(0.7f);
+        when(config.promptCompressionKeepHead()).thenReturn(2);
+        when(config.promptCompressionKeepTail()).thenReturn(2);
+        return new LlamaCppPromptCompressor(config, VOCAB::get);
+    }
+
+    private static InferenceRequest request(Object compress) {
+        InferenceRequest.Builder builder = InferenceRequest.builder().model("m").message(Message.user("hi"));
+        return compress == null ? builder.build()
+                : builder.parameter(LlamaCppPromptCompressor.PARAMETER, compress).build();
+    }
+
+    // <|im_start|>user\n\n the cat sat on the very big mat. the cat
+    private static final int[] PROMPT = { 0, 1, 2, 2, 3, 4, 5, 6, 3, 9, 10, 7, 8, 3, 4 };
+
+    @Test
+    void prunesRepeatsThenFillerFromTheMiddleUpToTheTarget() {
+        int[] compressed = compressor(true).compress(request(null), PROMPT);
+
+        // 15 tokens at 0.7 keeps 11: the repeated newline, then the first three filler words go
+        assertThat(compressed).containsExactly(0, 1, 2, 4, 5, 9, 10, 7, 8, 3, 4);
+    }
+
+    @Test
+    void requestsOptInOrOut() {
+        assertThat(compressor(false).compress(request(null), PROMPT)).isSameAs(PROMPT);
+        assertThat(compressor(true).compress(request(false), PROMPT)).isSameAs(PROMPT);
+        assertThat(compressor(false).compress(request(true), PROMPT)).hasSize(11);
+        assertThat(compressor(false).compress(request(0.9), PROMPT)).hasSize(14);
+    }
+
+    @Test
+    void shortAndRawPromptsAreLeftAlone() {
+        int[] shortPrompt = { 0, 1, 3, 4, 3 };
+        assertThat(compressor(true).compress(request(null), shortPrompt)).isSameAs(shortPrompt);
+
+        InferenceRequest raw = request(null).toBuilder().parameter(InferenceLogicExecutor.RAW, true).build();
+        assertThat(compressor(true).compress(raw, PROMPT)).isSameAs(PROMPT);
+    }
+}
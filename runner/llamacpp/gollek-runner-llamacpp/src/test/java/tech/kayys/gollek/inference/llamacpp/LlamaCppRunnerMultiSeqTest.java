@@ -96,6 +96,7 @@ class LlamaCppRunnerMultiSeqTest {
         LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
         when(config.maxConcurrentRequests()).thenReturn(8);
         when(config.maxContextTokens()).thenReturn(2);
+        when(config.contextTruncatePrompt()).thenReturn(true);
         when(config.coalesceEnabled()).thenReturn(true);
         when(config.coalesceWindowMs()).thenReturn(1);
         when(config.coalesceMaxBatch()).thenReturn(4);
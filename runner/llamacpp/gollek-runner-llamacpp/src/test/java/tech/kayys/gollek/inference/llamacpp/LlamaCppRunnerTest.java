@@ -162,6 +162,7 @@ class LlamaCppRunnerTest {
                 org.mockito.Mockito.when(localConfig.gpuEnabled()).thenReturn(false);
                 org.mockito.Mockito.when(localConfig.mmapEnabled()).thenReturn(true);
                 org.mockito.Mockito.when(localConfig.mlockEnabled()).thenReturn(false);
+                org.mockito.Mockito.when(localConfig.prefixCacheEnabled()).thenReturn(true);
 
                 LlamaCppBinding localBinding = org.mockito.Mockito.mock(LlamaCppBinding.class);
                 GGUFChatTemplateService localTemplate = org.mockito.Mockito.mock(GGUFChatTemplateService.class);
@@ -2,6 +2,7 @@ package tech.kayys.gollek.inference.llamacpp;
 
 import tech.kayys.gollek.gguf.tokenizer.GGUFChatTemplateService;
 import tech.kayys.gollek.model.repo.local.ManifestStore;
+import tech.kayys.gollek.spi.provider.ReloadableProvider;
 
 import io.quarkus.test.junit.QuarkusTest;
 import jakarta.inject.Inject;
@@ -270,4 +271,39 @@ class LlamaCppSessionManagerTest {
         assertThat(sessionManager.adaptivePressureScoreForTest()).isLessThan(0.35d);
     }
 
+    @Test
+    @DisplayName("Reload rejects a missing model file")
+    void testReloadRejectsMissingFile() {
+        assertThatThrownBy(() -> sessionManager.reloadModel("m", "/nonexistent/model.gguf"))
+                .isInstanceOf(IllegalArgumentException.class);
+    }
+
+    @Test
+    @DisplayName("Reload of a model that is not loaded swaps nothing")
+    void testReloadWithoutLoadedPools() throws Exception {
+        var file = java.nio.file.Files.createTempFile("reload", ".gguf");
+        try {
+            var reload = sessionManager.reloadModel("m", file.toString());
+
+            assertThat(reload.poolsSwapped()).isZero();
+            assertThat(reload.sessionsRetired()).isZero();
+            assertThat(reload.modelPath()).isEqualTo(file.toString());
+        } finally {
+            java.nio.file.Files.deleteIfExists(file);
+        }
+    }
+
+    @Test
+    @DisplayName("Offload reload validates gpu_layers and reports the new value")
+    void testOffloadReloadWithoutLoadedPools() {
+        var options = new ReloadableProvider.ReloadOptions(12, true, Duration.ofSeconds(1));
+
+        var reload = sessionManager.reloadModel("m", null, options);
+
+        assertThat(reload.gpuLayers()).isEqualTo(12);
+        assertThat(reload.poolsSwapped()).isZero();
+        assertThatThrownBy(() -> sessionManager.reloadModel("m", null,
+                new ReloadableProvider.ReloadOptions(-2, true, Duration.ofSeconds(1))))
+                .isInstanceOf(IllegalArgumentException.class);
+    }
 }
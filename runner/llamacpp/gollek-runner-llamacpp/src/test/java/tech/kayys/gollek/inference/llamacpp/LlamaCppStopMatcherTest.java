@@ -0,0 +1,55 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+
+import java.util.List;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppStopMatcherTest {
+
+    @Test
+    void stopSplitAcrossTokensIsTrimmedAndNeverReleased() {
+        LlamaCppStopMatcher matcher = new LlamaCppStopMatcher(List.of("\nHuman:"));
+        StringBuilder out = new StringBuilder();
+
+        for (String piece : List.of("Sure", ".", "\n", "Human", ":", " next")) {
+            out.append(matcher.accept(piece));
+        }
+
+        assertThat(matcher.matched()).isEqualTo("\nHuman:");
+        assertThat(out).hasToString("Sure.");
+        assertThat(matcher.flush()).isEmpty();
+    }
+
+    @Test
+    void heldBackTextIsReleasedOnceTheStopIsRuledOut() {
+        LlamaCppStopMatcher matcher = new LlamaCppStopMatcher(List.of("\nHuman:"));
+
+        assertThat(matcher.accept("Hello")).isEqualTo("Hello");
+        assertThat(matcher.accept("\nHu")).isEmpty();
+        assertThat(matcher.accept("go")).isEqualTo("\nHugo");
+        assertThat(matcher.accept("\n")).isEmpty();
+        // generation ended without reaching the stop
+        assertThat(matcher.flush()).isEqualTo("\n");
+        assertThat(matcher.matched()).isNull();
+    }
+
+    @Test
+    void earliestStopWinsWhenSeveralMatch() {
+        LlamaCppStopMatcher matcher = new LlamaCppStopMatcher(List.of("</s>", "\n\n"));
+
+        assertThat(matcher.accept("a\n\nb</s>")).isEqualTo("a");
+        assertThat(matcher.matched()).isEqualTo("\n\n");
+        assertThat(matcher.accept("more")).isEmpty();
+    }
+
+    @Test
+    void withoutStopsEverythingIsReleasedImmediately() {
+        LlamaCppStopMatcher matcher = new LlamaCppStopMatcher(List.of());
+
+        assertThat(matcher.accept("\nHum")).isEqualTo("\nHum");
+        assertThat(matcher.accept(null)).isEmpty();
+        assertThat(matcher.flush()).isEmpty();
+    }
+}
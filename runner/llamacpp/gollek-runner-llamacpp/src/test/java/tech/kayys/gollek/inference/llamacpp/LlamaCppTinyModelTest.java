@@ -0,0 +1,139 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import tech.kayys.gollek.gguf.tokenizer.GGUFChatTemplateService;
+
+import io.quarkus.test.junit.QuarkusTest;
+import jakarta.inject.Inject;
+import org.junit.jupiter.api.AfterEach;
+import org.junit.jupiter.api.Assumptions;
+import org.junit.jupiter.api.BeforeEach;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.mockito.Mockito;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+import tech.kayys.gollek.spi.model.ArtifactLocation;
+import tech.kayys.gollek.spi.model.ModelFormat;
+import tech.kayys.gollek.spi.model.ModelManifest;
+
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.Collections;
+import java.util.List;
+import java.util.Map;
+import java.util.UUID;
+import java.util.stream.Collectors;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+/**
+ * Runs the real decode loop (tokenize, batch decode, sampler chain, stop matching, token
+ * callback) against a tiny GGUF model, e.g. llama.cpp's stories260K.gguf. Skipped unless
+ * the model is at {@code -Dgollek.test.tiny-model} (default
+ * ~/.gollek/models/stories260K.gguf) and the native library loads.
+ */
+@QuarkusTest
+class LlamaCppTinyModelTest {
+
+        private static final String PROMPT = "Once upon a time";
+
+        @Inject
+        LlamaCppProviderConfig config;
+
+        private LlamaCppRunner runner;
+
+        @BeforeEach
+        void setUp() {
+                Path model = Path.of(System.getProperty("gollek.test.tiny-model",
+                                Path.of(System.getProperty("user.home"), ".gollek", "models", "stories260K.gguf")
+                                                .toString()));
+                Assumptions.assumeTrue(Files.exists(model), "tiny model not found, skipping test");
+                LlamaCppBinding binding;
+                try {
+                        binding = LlamaCppBinding.load(config);
+                } catch (Throwable e) {
+                        Assumptions.abort("llama.cpp native library not available: " + e.getMessage());
+                        return;
+                }
+
+                ArtifactLocation location = new ArtifactLocation(model.toString(), null, null, null);
+                ModelManifest manifest = ModelManifest.builder()
+                                .modelId("tiny")
+                                .name("tiny")
+                                .version("1.0")
+                                .path(location.uri())
+                                .apiKey(tech.kayys.gollek.spi.auth.ApiKeyConstants.COMMUNITY_API_KEY)
+                                .requestId("tenant1")
+                                .artifacts(Map.of(ModelFormat.GGUF, location))
+                                .supportedDevices(Collections.emptyList())
+                                .resourceRequirements(null)
+                                .metadata(Collections.emptyMap())
+                                .createdAt(Instant.now())
+                                .updatedAt(Instant.now())
+                                .build();
+
+                runner = new LlamaCppRunner(binding, config, Mockito.mock(GGUFChatTemplateService.class));
+                runner.initialize(manifest, Map.of("nCtx", 256, "nGpuLayers", 0));
+        }
+
+        @AfterEach
+        void tearDown() {
+                if (runner != null) {
+                        runner.close();
+                }
+        }
+
+        private static InferenceRequest.Builder greedy(int maxTokens) {
+                return InferenceRequest.builder()
+                                .requestId(UUID.randomUUID().toString())
+                                .model("tiny")
+                                .prompt(PROMPT)
+                                .parameter(InferenceLogicExecutor.RAW, true)
+                                .temperature(0.0)
+                                .maxTokens(maxTokens);
+        }
+
+        @Test
+        @DisplayName("Greedy decoding produces the same tokens every time, up to max_tokens")
+        void testGreedyDecode() {
+                InferenceResponse first = runner.infer(greedy(16).build());
+                InferenceResponse second = runner.infer(greedy(16).build());
+
+                assertThat(first.getContent()).isNotEmpty();
+                assertThat(first.getInputTokens()).isPositive();
+                assertThat(first.getOutputTokens()).isBetween(1, 16);
+                assertThat(second.getContent()).isEqualTo(first.getContent());
+        }
+
+        @Test
+        @DisplayName("Stop sequences end generation before the match")
+        void testStopSequence() {
+                String full = runner.infer(greedy(32).build()).getContent();
+                Assumptions.assumeTrue(full.strip().length() >= 8, "model output too short to pick a stop");
+                String stop = full.substring(full.length() / 2, full.length() / 2 + 2);
+
+                InferenceResponse stopped = runner.infer(greedy(32).parameter("stop", List.of(stop)).build());
+
+                assertThat(stopped.getContent()).isEqualTo(full.substring(0, full.indexOf(stop)));
+                assertThat(stopped.getFinishReason()).isEqualTo(InferenceResponse.FinishReason.STOP);
+                assertThat(stopped.getMetadata()).containsEntry(InferenceLogicExecutor.STOP_SEQUENCE, stop);
+        }
+
+        @Test
+        @DisplayName("Streaming emits every piece to the token callback, then a final chunk")
+        void testStreamingMatchesBlocking() {
+                String blocking = runner.infer(greedy(16).build()).getContent();
+
+                List<StreamingInferenceChunk> chunks = runner.inferStream(greedy(16).build())
+                                .collect().asList().await().atMost(Duration.ofSeconds(60));
+
+                StreamingInferenceChunk last = chunks.get(chunks.size() - 1);
+                assertThat(last.finished()).isTrue();
+                assertThat(last.usage().outputTokens()).isPositive();
+                assertThat(chunks.stream().map(StreamingInferenceChunk::delta).collect(Collectors.joining()))
+                                .isEqualTo(blocking);
+        }
+}
@@ -0,0 +1,107 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+
+import java.lang.foreign.Arena;
+import java.lang.foreign.MemorySegment;
+import java.lang.foreign.ValueLayout;
+import java.util.Map;
+import java.util.Random;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.mockito.Mockito.mock;
+import static org.mockito.Mockito.when;
+
+class LlamaCppTokenSamplerTest {
+
+    private final MemorySegment context = MemorySegment.ofAddress(1);
+
+    private LlamaCppTokenSampler sampler(float... logits) {
+        MemorySegment segment = Arena.ofAuto().allocateFrom(ValueLayout.JAVA_FLOAT, logits);
+        LlamaCppBinding binding = mock(LlamaCppBinding.class);
+        when(binding.getLogitsIth(context, 0)).thenReturn(segment);
+        return new LlamaCppTokenSampler(binding, logits.length);
+    }
+
+    private static LlamaCppTokenSampler.SamplingConfig config(float temperature, Map<Integer, Float> logitBias) {
+        return new LlamaCppTokenSampler.SamplingConfig(temperature, 0, 1.0f, 0.0f, 1.0f, 0.0f, 0.0f, null,
+                logitBias);
+    }
+
+    @Test
+    void biasShiftsTheGreedyChoice() {
+        LlamaCppTokenSampler sampler = sampler(1f, 5f, 3f);
+
+        assertThat(sampler.sampleNextToken(context, 0, config(0f, Map.of()), new Random(1))).isEqualTo(1);
+        assertThat(sampler.sampleNextToken(context, 0, config(0f, Map.of(2, 4f)), new Random(1))).isEqualTo(2);
+    }
+
+    @Test
+    void minusOneHundredBansTheTokenAtAnyTemperature() {
+        LlamaCppTokenSampler sampler = sampler(1f, 50f, 1f);
+        Random random = new Random(7);
+
+        for (int i = 0; i < 200; i++) {
+            assertThat(sampler.sampleNextToken(context, 0, config(2.0f, Map.of(1, -100f)), random)).isNotEqualTo(1);
+        }
+    }
+
+    @Test
+    void parsesOpenAiStyleBiases() {
+        assertThat(InferenceLogicExecutor.GenerationParams.logitBias(Map.of("15", 250, " 7 ", "-3.5")))
+                .containsEntry(15, 100f)
+                .containsEntry(7, -3.5f);
+        assertThat(InferenceLogicExecutor.GenerationParams.logitBias(null)).isEmpty();
+        assertThatThrownBy(() -> InferenceLogicExecutor.GenerationParams.logitBias(Map.of("hello", 1)))
+                .isInstanceOf(IllegalArgumentException.class)
+                .hasMessageContaining("hello");
+    }
+
+    private static LlamaCppTokenSampler.SamplingConfig config(float typicalP, int mirostat, float tau) {
+        return new LlamaCppTokenSampler.SamplingConfig(1.0f, 0, 1.0f, 0.0f, 1.0f, 0.0f, 0.0f, null, Map.of(),
+                typicalP, mirostat, tau, 0.1f);
+    }
+
+    @Test
+    void typicalSamplingDropsAnAtypicallyLikelyToken() {
+        // one confident token among nine equal ones: the entropy sits near the nine
+        LlamaCppTokenSampler sampler = sampler(2f, 0f, 0f, 0f, 0f, 0f, 0f, 0f, 0f, 0f);
+        Random random = new Random(3);
+
+        for (int i = 0; i < 200; i++) {
+            assertThat(sampler.sampleNextToken(context, 0, config(0.5f, 0, 5f), random)).isNotZero();
+        }
+    }
+
+    @Test
+    void mirostatV2TruncatesToTheSurpriseBudgetAndAdaptsMu() {
+        LlamaCppTokenSampler sampler = sampler(2f, 0f, 0f, 0f, 0f, 0f, 0f, 0f, 0f, 0f);
+        Random random = new Random(5);
+        LlamaCppTokenSampler.SamplingConfig strict = config(1.0f, 2, 0.5f);
+
+        for (int i = 0; i < 20; i++) {
+            assertThat(sampler.sampleNextToken(context, 0, strict, random)).isZero();
+        }
+        // every pick was unsurprising, so the budget grew
+        assertThat(strict.mirostatMu).isGreaterThan(1.0f);
+
+        LlamaCppTokenSampler.SamplingConfig loose = config(1.0f, 2, 10f);
+        boolean other = false;
+        for (int i = 0; i < 200 && !other; i++) {
+            other = sampler.sampleNextToken(context, 0, loose, random) != 0;
+        }
+        assertThat(other).isTrue();
+    }
+
+    @Test
+    void mirostatV1SamplesFromTheEstimatedTopK() {
+        LlamaCppTokenSampler sampler = sampler(4f, 3f, 2f, 1f, 0f, -1f, -2f, -3f);
+        LlamaCppTokenSampler.SamplingConfig config = config(1.0f, 1, 0.1f);
+
+        int token = sampler.sampleNextToken(context, 0, config, new Random(9));
+
+        assertThat(token).isBetween(0, 7);
+        assertThat(config.mirostatMu).isNotEqualTo(0.2f);
+    }
+}
@@ -0,0 +1,54 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import org.mockito.Mockito;
+import tech.kayys.gollek.spi.Message;
+
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+import static org.mockito.Mockito.when;
+
+class LlamaCppTurnFormatTest {
+
+    private static LlamaCppProviderConfig config(String modelId, String preset) {
+        LlamaCppProviderConfig.TurnFormat format = Mockito.mock(LlamaCppProviderConfig.TurnFormat.class);
+        when(format.preset()).thenReturn(preset);
+        when(format.systemPrefix()).thenReturn(Optional.empty());
+        when(format.systemSuffix()).thenReturn(Optional.empty());
+        when(format.userPrefix()).thenReturn(Optional.of("Q: "));
+        when(format.userSuffix()).thenReturn(Optional.empty());
+        when(format.assistantPrefix()).thenReturn(Optional.of("A: "));
+        when(format.assistantSuffix()).thenReturn(Optional.empty());
+        when(format.stop()).thenReturn(Optional.empty());
+        LlamaCppProviderConfig config = Mockito.mock(LlamaCppProviderConfig.class);
+        when(config.turnFormats()).thenReturn(Map.of(modelId, format));
+        return config;
+    }
+
+    @Test
+    void unconfiguredModelsKeepTheirChatTemplate() {
+        assertThat(LlamaCppTurnFormat.forModel(config("base", "plain"), "instruct")).isNull();
+    }
+
+    @Test
+    void rendersTurnsWithPresetDefaultsAndOverrides() {
+        LlamaCppTurnFormat format = LlamaCppTurnFormat.forModel(config("base", "plain"), "base");
+
+        String prompt = format.render(List.of(Message.system("Be brief."), Message.user("Hi"),
+                Message.assistant("Hello."), Message.user("Why?")));
+
+        assertThat(prompt).isEqualTo("Be brief.\n\nQ: Hi\n\nA: Hello.\n\nQ: Why?\n\nA:");
+        assertThat(format.stops()).containsExactly("\nQ:");
+    }
+
+    @Test
+    void rejectsUnknownPresets() {
+        assertThatThrownBy(() -> LlamaCppTurnFormat.forModel(config("base", "vicuna"), "base"))
+                .isInstanceOf(IllegalArgumentException.class)
+                .hasMessageContaining("vicuna");
+    }
+}
@@ -0,0 +1,42 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaCppVerboseTest {
+
+    @Test
+    @SuppressWarnings("unchecked")
+    void reportsSettingsAfterClampingWithCacheAndTimings() {
+        InferenceRequest request = InferenceRequest.builder()
+                .model("test-model")
+                .message(tech.kayys.gollek.spi.Message.user("hello"))
+                .parameter("temperature", 3.0f)
+                .parameter("seed", 7)
+                .parameter("verbose", true)
+                .build();
+        InferenceLogicExecutor.GenerationParams params =
+                InferenceLogicExecutor.GenerationParams.of(request, new ArrayList<>());
+        long start = System.nanoTime() - 5_000_000;
+
+        Map<String, Object> report = LlamaCppVerbose.report(params, 16, new int[] { 11, 12, 13, 0 }, 3, 10, 4,
+                start, start + 2_000_000, start + 3_000_000);
+
+        assertThat(LlamaCppVerbose.requested(request)).isTrue();
+        assertThat(report.get("token_ids")).isEqualTo(List.of(11, 12, 13));
+        Map<String, Object> sampler = (Map<String, Object>) report.get("sampler");
+        assertThat(sampler).containsEntry("temperature", 2.0f).containsEntry("seed", 7)
+                .containsEntry("max_tokens", 16).containsEntry("top_k", 40);
+        assertThat((Map<String, Object>) report.get("cache")).containsEntry("prompt_tokens", 10)
+                .containsEntry("reused_tokens", 4).containsEntry("evaluated_tokens", 6);
+        Map<String, Object> timings = (Map<String, Object>) report.get("timings");
+        assertThat(timings).containsEntry("prompt_ms", 2.0).containsEntry("ttft_ms", 3.0)
+                .containsKeys("decode_ms", "total_ms", "tokens_per_second");
+    }
+}
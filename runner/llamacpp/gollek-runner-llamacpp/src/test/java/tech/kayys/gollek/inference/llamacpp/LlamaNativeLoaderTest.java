@@ -0,0 +1,32 @@
+package tech.kayys.gollek.inference.llamacpp;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.condition.EnabledOnOs;
+import org.junit.jupiter.api.condition.OS;
+
+import java.util.List;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+class LlamaNativeLoaderTest {
+
+    @Test
+    void preloadsOnlyTheCudaRuntimeWithCublasLtFirst() {
+        List<String> bin = List.of("cublas64_12.dll", "cudart64_12.dll", "nvrtc64_120_0.dll",
+                "cublasLt64_12.dll", "cublas64_12.lib", "cufft64_11.dll");
+
+        assertThat(LlamaNativeLoader.cudaRuntimeDlls(bin))
+                .containsExactly("cublasLt64_12.dll", "cublas64_12.dll", "cudart64_12.dll");
+        assertThat(LlamaNativeLoader.cudaRuntimeDlls(List.of("nvcc.exe"))).isEmpty();
+    }
+
+    @Test
+    @EnabledOnOs(OS.WINDOWS)
+    void windowsDependenciesUseDllNamesWithoutTheLibPrefix() {
+        assertThat(LlamaNativeLoader.shimLibraryFileName()).isEqualTo("gollek_llama_shim.dll");
+        assertThat(LlamaNativeLoader.nativeDependencyFileNames())
+                .contains("ggml-base.dll", "ggml-cuda.dll", "ggml-vulkan.dll", "llama.dll",
+                        "gollek_llama_shim.dll")
+                .allMatch(n -> n.endsWith(".dll"));
+    }
+}
@@ -0,0 +1,205 @@
+package tech.kayys.gollek.sdk.modelstore;
+
+import tech.kayys.gollek.sdk.model.PullProgress;
+
+import java.io.IOException;
+import java.io.InputStream;
+import java.io.OutputStream;
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.StandardCopyOption;
+import java.nio.file.StandardOpenOption;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.util.HexFormat;
+import java.util.function.Consumer;
+
+/**
+ * Resumable HTTP download into a {@code .part} file next to the target. An interrupted
+ * download continues from the bytes already on disk with a {@code Range} request; the
+ * finished file is checked against the expected sha256 before it is moved into place.
+ *
+ * <p>The token is only sent to the host of {@code tokenEndpoint}. Redirects are followed
+ * here rather than by the client so the token is dropped as soon as one leaves that host,
+ * e.g. from the Hugging Face Hub to its CDN, or when a plain URL points elsewhere.
+ */
+public final class ModelDownloader {
+
+    static final String PART_SUFFIX = ".part";
+
+    private static final int BUFFER_SIZE = 1 << 16;
+    private static final long PROGRESS_STEP_BYTES = 1L << 20;
+    private static final int MAX_REDIRECTS = 10;
+
+    private final HttpClient http;
+    private final String token;
+    private final String tokenHost;
+
+    public ModelDownloader(HttpClient http, String token, String tokenEndpoint) {
+        this.http = withoutRedirects(http);
+        this.token = token;
+        this.tokenHost = tokenEndpoint == null ? null : URI.create(tokenEndpoint).getHost();
+    }
+
+    /**
+     * Downloads {@code source} to {@code target}, resuming a previous partial download.
+     *
+     * @return the sha256 of the downloaded file
+     * @throws IOException on transfer errors or a checksum mismatch; the partial file is
+     *                     kept for resume in the first case and deleted in the second
+     */
+    public String download(ModelSource source, Path target, Consumer<PullProgress> progress) throws IOException {
+        Files.createDirectories(target.toAbsolutePath().getParent());
+        Path part = target.resolveSibling(target.getFileName() + PART_SUFFIX);
+        long offset = Files.exists(part) ? Files.size(part) : 0L;
+        if (source.size() > 0 && offset > source.size()) {
+            Files.delete(part);
+            offset = 0L;
+        }
+
+        if (source.size() <= 0 || offset < source.size()) {
+            offset = transfer(source, part, offset, progress);
+        }
+
+        emit(progress, "verifying", source, offset, offset);
+        String actual = sha256(part);
+        if (source.sha256() != null && !source.sha256().equalsIgnoreCase(actual)) {
+            Files.deleteIfExists(part);
+            throw new IOException("checksum mismatch for " + source.fileName()
+                    + ": expected " + source.sha256() + ", got " + actual);
+        }
+        Files.move(part, target, StandardCopyOption.REPLACE_EXISTING, StandardCopyOption.ATOMIC_MOVE);
+        emit(progress, "success", source, offset, offset);
+        return actual;
+    }
+
+    private long transfer(ModelSource source, Path part, long offset, Consumer<PullProgress> progress)
+            throws IOException {
+        HttpResponse<InputStream> response = send(source, offset);
+        int status = response.statusCode();
+        boolean append;
+        if (status == 206) {
+            append = true;
+        } else if (status == 200) {
+            // server ignored the range: start over
+            append = false;
+            offset = 0L;
+        } else if (status == 416 && offset > 0) {
+            // nothing left past what we already have
+            response.body().close();
+            return offset;
+        } else {
+            response.body().close();
+            throw new IOException("download of " + source.fileName() + " failed: HTTP " + status);
+        }
+
+        long total = source.size();
+        if (total <= 0) {
+            long length = response.headers().firstValueAsLong("Content-Length").orElse(-1L);
+            total = length < 0 ? -1L : length + offset;
+        }
+        String verb = append ? "resuming" : "downloading";
+        long written = offset;
+        long lastReport = -PROGRESS_STEP_BYTES;
+        try (InputStream in = response.body();
+                OutputStream out = Files.newOutputStream(part, StandardOpenOption.CREATE, StandardOpenOption.WRITE,
+                        append ? StandardOpenOption.APPEND : StandardOpenOption.TRUNCATE_EXISTING)) {
+            byte[] buffer = new byte[BUFFER_SIZE];
+            int n;
+            while ((n = in.read(buffer)) != -1) {
+                out.write(buffer, 0, n);
+                written += n;
+                if (written - lastReport >= PROGRESS_STEP_BYTES) {
+                    emit(progress, verb, source, total, written);
+                    lastReport = written;
+                }
+            }
+        }
+        emit(progress, verb, source, total, written);
+        if (total > 0 && written < total) {
+            throw new IOException("download of " + source.fileName() + " ended early at " + written + " of "
+                    + total + " bytes; run the pull again to resume");
+        }
+        return written;
+    }
+
+    private HttpResponse<InputStream> send(ModelSource source, long offset) throws IOException {
+        URI uri = URI.create(source.url());
+        boolean authorize = token != null && !token.isBlank();
+        for (int hops = 0; ; hops++) {
+            authorize = authorize && sendsToken(uri);
+            HttpRequest.Builder request = HttpRequest.newBuilder(uri).GET();
+            if (offset > 0) {
+                request.header("Range", "bytes=" + offset + "-");
+            }
+            if (authorize) {
+                request.header("Authorization", "Bearer " + token);
+            }
+            HttpResponse<InputStream> response;
+            try {
+                response = http.send(request.build(), HttpResponse.BodyHandlers.ofInputStream());
+            } catch (InterruptedException e) {
+                Thread.currentThread().interrupt();
+                throw new IOException("interrupted downloading " + source.fileName(), e);
+            }
+            int status = response.statusCode();
+            var location = response.headers().firstValue("Location");
+            if (status / 100 != 3 || status == 304 || location.isEmpty()) {
+                return response;
+            }
+            response.body().close();
+            if (hops >= MAX_REDIRECTS) {
+                throw new IOException("download of " + source.fileName() + " failed: too many redirects");
+            }
+            uri = uri.resolve(location.get());
+        }
+    }
+
+    /** Whether the token may go to {@code uri}, which must be on the token endpoint's host. */
+    boolean sendsToken(URI uri) {
+        return tokenHost != null && tokenHost.equalsIgnoreCase(uri.getHost());
+    }
+
+    private static HttpClient withoutRedirects(HttpClient http) {
+        if (http.followRedirects() == HttpClient.Redirect.NEVER) {
+            return http;
+        }
+        HttpClient.Builder builder = HttpClient.newBuilder()
+                .followRedirects(HttpClient.Redirect.NEVER)
+                .sslContext(http.sslContext())
+                .version(http.version());
+        http.connectTimeout().ifPresent(builder::connectTimeout);
+        http.proxy().ifPresent(builder::proxy);
+        http.authenticator().ifPresent(builder::authenticator);
+        http.executor().ifPresent(builder::executor);
+        return builder.build();
+    }
+
+    static String sha256(Path file) throws IOException {
+        MessageDigest digest;
+        try {
+            digest = MessageDigest.getInstance("SHA-256");
+        } catch (NoSuchAlgorithmException e) {
+            throw new IllegalStateException(e);
+        }
+        try (InputStream in = Files.newInputStream(file)) {
+            byte[] buffer = new byte[BUFFER_SIZE];
+            int n;
+            while ((n = in.read(buffer)) != -1) {
+                digest.update(buffer, 0, n);
+            }
+        }
+        return HexFormat.of().formatHex(digest.digest());
+    }
+
+    private static void emit(Consumer<PullProgress> progress, String status, ModelSource source, long total,
+            long completed) {
+        if (progress != null) {
+            progress.accept(PullProgress.of(status, source.sha256(), Math.max(total, 0L), completed));
+        }
+    }
+}
@@ -0,0 +1,134 @@
+package tech.kayys.gollek.sdk.modelstore;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import java.io.IOException;
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Locale;
+
+/**
+ * A single downloadable GGUF file. {@code sha256} and {@code size} are null/-1 when the
+ * origin does not publish them (plain URLs without {@code --sha256}).
+ *
+ * <p>Accepted specs:
+ * <ul>
+ * <li>{@code hf:owner/repo} – the repo's only GGUF file, or the one matching {@code quant}</li>
+ * <li>{@code hf:owner/repo/path/file.gguf} – an explicit file in the repo</li>
+ * <li>{@code https://host/path/file.gguf} – any URL</li>
+ * </ul>
+ */
+public record ModelSource(String url, String fileName, String sha256, long size) {
+
+    static final String HF_PREFIX = "hf:";
+    static final String HF_ENDPOINT = "https://huggingface.co";
+
+    private static final ObjectMapper JSON = new ObjectMapper();
+
+    public static boolean isUrl(String spec) {
+        String lower = spec.toLowerCase(Locale.ROOT);
+        return lower.startsWith("https://") || lower.startsWith("http://");
+    }
+
+    /**
+     * Resolves {@code spec} to a concrete file. Hugging Face repos are listed through the
+     * Hub API, which also supplies the LFS sha256 and size used to verify the download.
+     */
+    public static ModelSource resolve(HttpClient http, String endpoint, String spec, String revision,
+            String quant, String token) throws IOException {
+        if (spec == null || spec.isBlank()) {
+            throw new IllegalArgumentException("model spec required");
+        }
+        String trimmed = spec.trim();
+        if (isUrl(trimmed)) {
+            String path = URI.create(trimmed).getPath();
+            String name = path == null ? "" : path.substring(path.lastIndexOf('/') + 1);
+            if (name.isBlank()) {
+                throw new IllegalArgumentException("URL has no file name: " + trimmed);
+            }
+            return new ModelSource(trimmed, name, null, -1);
+        }
+        if (!trimmed.startsWith(HF_PREFIX)) {
+            throw new IllegalArgumentException("expected hf:owner/repo[/file.gguf] or an http(s) URL: " + trimmed);
+        }
+        String[] parts = trimmed.substring(HF_PREFIX.length()).split("/", 3);
+        if (parts.length < 2 || parts[0].isBlank() || parts[1].isBlank()) {
+            throw new IllegalArgumentException("expected hf:owner/repo[/file.gguf]: " + trimmed);
+        }
+        String repo = parts[0] + "/" + parts[1];
+        String file = parts.length == 3 ? parts[2] : null;
+        String rev = revision == null || revision.isBlank() ? "main" : revision;
+        String base = endpoint == null ? HF_ENDPOINT : endpoint;
+
+        List<JsonNode> ggufs = listGgufFiles(http, base, repo, rev, token);
+        JsonNode chosen = file != null ? findFile(ggufs, file) : pick(ggufs, quant, repo);
+        if (chosen == null) {
+            throw new IllegalArgumentException("file not found in " + repo + "@" + rev + ": " + file);
+        }
+        String path = chosen.path("path").asText();
+        JsonNode lfs = chosen.path("lfs");
+        String sha = lfs.hasNonNull("oid") ? lfs.get("oid").asText() : null;
+        long size = lfs.hasNonNull("size") ? lfs.get("size").asLong() : chosen.path("size").asLong(-1);
+        String url = base + "/" + repo + "/resolve/" + rev + "/" + path;
+        return new ModelSource(url, path.substring(path.lastIndexOf('/') + 1), sha, size);
+    }
+
+    private static List<JsonNode> listGgufFiles(HttpClient http, String base, String repo, String rev,
+            String token) throws IOException {
+        HttpRequest.Builder request = HttpRequest.newBuilder(
+                URI.create(base + "/api/models/" + repo + "/tree/" + rev + "?recursive=true")).GET();
+        if (token != null && !token.isBlank()) {
+            request.header("Authorization", "Bearer " + token);
+        }
+        HttpResponse<String> response;
+        try {
+            response = http.send(request.build(), HttpResponse.BodyHandlers.ofString());
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+            throw new IOException("interrupted listing " + repo, e);
+        }
+        if (response.statusCode() == 404) {
+            throw new IllegalArgumentException("repository or revision not found: " + repo + "@" + rev);
+        }
+        if (response.statusCode() / 100 != 2) {
+            throw new IOException("listing " + repo + " failed: HTTP " + response.statusCode());
+        }
+        List<JsonNode> out = new ArrayList<>();
+        for (JsonNode entry : JSON.readTree(response.body())) {
+            if ("file".equals(entry.path("type").asText())
+                    && entry.path("path").asText().toLowerCase(Locale.ROOT).endsWith(".gguf")) {
+                out.add(entry);
+            }
+        }
+        return out;
+    }
+
+    private static JsonNode findFile(List<JsonNode> files, String file) {
+        return files.stream().filter(f -> f.path("path").asText().equals(file)).findFirst().orElse(null);
+    }
+
+    static JsonNode pick(List<JsonNode> files, String quant, String repo) {
+        List<JsonNode> candidates = files;
+        if (quant != null && !quant.isBlank()) {
+            String needle = quant.toLowerCase(Locale.ROOT);
+            candidates = files.stream()
+                    .filter(f -> f.path("path").asText().toLowerCase(Locale.ROOT).contains(needle))
+                    .toList();
+        }
+        if (candidates.size() == 1) {
+            return candidates.get(0);
+        }
+        List<String> names = candidates.stream().map(f -> f.path("path").asText()).toList();
+        if (names.isEmpty()) {
+            throw new IllegalArgumentException("no GGUF file" + (quant == null ? "" : " matching " + quant)
+                    + " in " + repo);
+        }
+        throw new IllegalArgumentException("several GGUF files in " + repo
+                + ", pass --quant or the file path: " + String.join(", ", names));
+    }
+}
@@ -0,0 +1,179 @@
+package tech.kayys.gollek.sdk.modelstore;
+
+import com.fasterxml.jackson.core.type.TypeReference;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.SerializationFeature;
+
+import tech.kayys.gollek.sdk.model.PullProgress;
+import tech.kayys.gollek.sdk.util.GollekHome;
+
+import java.io.IOException;
+import java.net.http.HttpClient;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.StandardCopyOption;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.function.Consumer;
+
+/**
+ * Directory of pulled GGUF files plus a {@code registry.json} that maps registry names to
+ * their origin and checksum. A model named {@code qwen2.5-7b} is stored as
+ * {@code qwen2.5-7b.gguf}, so pointing {@code gguf.provider.model.base-path} at the store
+ * lets configuration and requests refer to it by name.
+ */
+public final class ModelStore {
+
+    public static final String REGISTRY_FILE = "registry.json";
+
+    private static final ObjectMapper JSON = new ObjectMapper().enable(SerializationFeature.INDENT_OUTPUT);
+
+    /**
+     * A pulled model. {@code source} is the spec it was pulled from, {@code url} the file
+     * actually downloaded.
+     */
+    public record Entry(String name, String path, String source, String url, String sha256, long sizeBytes,
+            String pulledAt) {
+    }
+
+    /**
+     * {@code revision} and {@code quant} only apply to Hugging Face specs; {@code sha256}
+     * overrides the expected checksum (required to verify plain URLs).
+     */
+    public record PullOptions(String name, String revision, String quant, String sha256, boolean force) {
+    }
+
+    private final Path root;
+    private final HttpClient http;
+    private final String endpoint;
+    private final String token;
+
+    public ModelStore(Path root, HttpClient http, String endpoint, String token) {
+        this.root = root;
+        this.http = http;
+        this.endpoint = endpoint;
+        this.token = token;
+    }
+
+    /**
+     * Store under {@code <gollek home>/models/gguf} using {@code HF_TOKEN} when set.
+     */
+    public static ModelStore defaults() {
+        HttpClient http = HttpClient.newBuilder()
+                .followRedirects(HttpClient.Redirect.NORMAL)
+                .connectTimeout(Duration.ofSeconds(30))
+                .build();
+        String token = System.getenv("HF_TOKEN");
+        if (token == null || token.isBlank()) {
+            token = System.getenv("HUGGING_FACE_HUB_TOKEN");
+        }
+        return new ModelStore(GollekHome.path("models", "gguf"), http, null, token);
+    }
+
+    public Path root() {
+        return root;
+    }
+
+    /**
+     * Downloads {@code spec} into the store and registers it. An existing entry with the
+     * same name is kept unless {@code force} is set; an interrupted pull resumes.
+     */
+    public synchronized Entry pull(String spec, PullOptions options, Consumer<PullProgress> progress)
+            throws IOException {
+        PullOptions opts = options != null ? options : new PullOptions(null, null, null, null, false);
+        ModelSource resolved = ModelSource.resolve(http, endpoint, spec, opts.revision(), opts.quant(), token);
+        ModelSource source = opts.sha256() == null || opts.sha256().isBlank()
+                ? resolved
+                : new ModelSource(resolved.url(), resolved.fileName(), opts.sha256().trim(), resolved.size());
+
+        String name = normalizeName(opts.name() != null && !opts.name().isBlank()
+                ? opts.name()
+                : defaultName(source.fileName()));
+        Map<String, Entry> registry = readRegistry();
+        Entry existing = registry.get(name);
+        if (existing != null && !opts.force() && Files.exists(Path.of(existing.path()))) {
+            if (progress != null) {
+                progress.accept(PullProgress.of("already present", existing.sha256(), existing.sizeBytes(),
+                        existing.sizeBytes()));
+            }
+            return existing;
+        }
+
+        Path target = root.resolve(name + ".gguf");
+        String sha = new ModelDownloader(http, token, endpoint == null ? ModelSource.HF_ENDPOINT : endpoint).download(source, target, progress);
+        Entry entry = new Entry(name, target.toAbsolutePath().toString(), spec.trim(), source.url(), sha,
+                Files.size(target), Instant.now().toString());
+        registry.put(name, entry);
+        writeRegistry(registry);
+        return entry;
+    }
+
+    public synchronized List<Entry> list() throws IOException {
+        return new ArrayList<>(readRegistry().values());
+    }
+
+    public synchronized Optional<Entry> find(String name) throws IOException {
+        return name == null ? Optional.empty() : Optional.ofNullable(readRegistry().get(normalizeName(name)));
+    }
+
+    /**
+     * Removes the entry and its file; returns false if the name is unknown.
+     */
+    public synchronized boolean remove(String name) throws IOException {
+        Map<String, Entry> registry = readRegistry();
+        Entry entry = registry.remove(normalizeName(name));
+        if (entry == null) {
+            return false;
+        }
+        Files.deleteIfExists(Path.of(entry.path()));
+        Files.deleteIfExists(Path.of(entry.path() + ModelDownloader.PART_SUFFIX));
+        writeRegistry(registry);
+        return true;
+    }
+
+    /**
+     * File stem without the {@code .gguf} extension, lower-cased.
+     */
+    static String defaultName(String fileName) {
+        String stem = fileName;
+        if (stem.toLowerCase(Locale.ROOT).endsWith(".gguf")) {
+            stem = stem.substring(0, stem.length() - ".gguf".length());
+        }
+        return stem.toLowerCase(Locale.ROOT);
+    }
+
+    /**
+     * Registry names double as file names, so anything outside {@code [a-z0-9._-]} becomes
+     * {@code -}.
+     */
+    static String normalizeName(String name) {
+        String normalized = name.trim().toLowerCase(Locale.ROOT).replaceAll("[^a-z0-9._-]+", "-");
+        if (normalized.isEmpty() || normalized.startsWith(".")) {
+            throw new IllegalArgumentException("invalid model name: " + name);
+        }
+        return normalized;
+    }
+
+    private Map<String, Entry> readRegistry() throws IOException {
+        Path file = root.resolve(REGISTRY_FILE);
+        if (!Files.exists(file)) {
+            return new LinkedHashMap<>();
+        }
+        return JSON.readValue(file.toFile(), new TypeReference<LinkedHashMap<String, Entry>>() {
+        });
+    }
+
+    private void writeRegistry(Map<String, Entry> registry) throws IOException {
+        Files.createDirectories(root);
+        Path file = root.resolve(REGISTRY_FILE);
+        Path tmp = root.resolve(REGISTRY_FILE + ".tmp");
+        JSON.writeValue(tmp.toFile(), registry);
+        Files.move(tmp, file, StandardCopyOption.REPLACE_EXISTING, StandardCopyOption.ATOMIC_MOVE);
+    }
+}
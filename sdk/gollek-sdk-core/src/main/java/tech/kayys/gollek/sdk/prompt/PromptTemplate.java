@@ -0,0 +1,176 @@
+package tech.kayys.gollek.sdk.prompt;
+
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.regex.Matcher;
+import java.util.regex.Pattern;
+
+/**
+ * A parsed prompt template in a small Jinja subset: {@code {{ name }}},
+ * {@code {{ name | default("text") }}}, {@code {% if name %}...{% else %}...{% endif %}}
+ * and {@code {# comments #}}. A leading comment is the prompt's description. Arguments
+ * are the names the template uses; one is required unless it has a default or only
+ * appears in an {@code if} condition or inside an {@code if} block.
+ *
+ * <p>The server renders MCP prompts with it and {@code gollek template render} checks
+ * templates offline, so both agree on what a template means.
+ */
+public final class PromptTemplate {
+
+    private static final Pattern TAG = Pattern.compile("\\{\\{(.*?)}}|\\{%(.*?)%}|\\{#(.*?)#}", Pattern.DOTALL);
+    private static final Pattern NAME = Pattern.compile("[A-Za-z_][A-Za-z0-9_]*");
+    private static final Pattern DEFAULT = Pattern.compile(
+            "([A-Za-z_][A-Za-z0-9_]*)\\s*\\|\\s*default\\(\\s*(?:\"((?:[^\"\\\\]|\\\\.)*)\"|'((?:[^'\\\\]|\\\\.)*)')\\s*\\)");
+
+    public record Argument(String name, boolean required) {
+    }
+
+    private sealed interface Node permits Text, Variable, If {
+    }
+
+    private record Text(String text) implements Node {
+    }
+
+    private record Variable(String name, String fallback) implements Node {
+    }
+
+    private record If(String name, List<Node> then, List<Node> otherwise) implements Node {
+    }
+
+    private final String description;
+    private final List<Node> nodes;
+    private final Map<String, Boolean> arguments = new LinkedHashMap<>();
+
+    private PromptTemplate(String description, List<Node> nodes) {
+        this.description = description;
+        this.nodes = nodes;
+        collect(nodes, false);
+    }
+
+    /**
+     * @throws IllegalArgumentException on malformed tags or unbalanced {@code if} blocks
+     */
+    public static PromptTemplate parse(String source) {
+        String description = null;
+        String body = source;
+        Matcher leading = Pattern.compile("\\A\\s*\\{#(.*?)#}\\s*", Pattern.DOTALL).matcher(source);
+        if (leading.find()) {
+            description = leading.group(1).strip();
+            body = source.substring(leading.end());
+        }
+        List<List<Node>> stack = new ArrayList<>();
+        List<If> open = new ArrayList<>();
+        List<Node> current = new ArrayList<>();
+        Matcher m = TAG.matcher(body);
+        int at = 0;
+        while (m.find()) {
+            if (m.start() > at) {
+                current.add(new Text(body.substring(at, m.start())));
+            }
+            at = m.end();
+            if (m.group(1) != null) {
+                current.add(variable(m.group(1).strip()));
+            } else if (m.group(2) != null) {
+                String[] words = m.group(2).strip().split("\\s+", 2);
+                switch (words[0]) {
+                    case "if" -> {
+                        if (words.length < 2 || !NAME.matcher(words[1]).matches()) {
+                            throw new IllegalArgumentException("expected {% if name %}, got {%" + m.group(2) + "%}");
+                        }
+                        If node = new If(words[1], new ArrayList<>(), new ArrayList<>());
+                        current.add(node);
+                        stack.add(current);
+                        open.add(node);
+                        current = node.then();
+                    }
+                    case "else" -> {
+                        if (open.isEmpty() || current != open.get(open.size() - 1).then()) {
+                            throw new IllegalArgumentException("{% else %} without {% if %}");
+                        }
+                        current = open.get(open.size() - 1).otherwise();
+                    }
+                    case "endif" -> {
+                        if (open.isEmpty()) {
+                            throw new IllegalArgumentException("{% endif %} without {% if %}");
+                        }
+                        open.remove(open.size() - 1);
+                        current = stack.remove(stack.size() - 1);
+                    }
+                    default -> throw new IllegalArgumentException("unsupported tag {%" + m.group(2) + "%}");
+                }
+            }
+        }
+        if (!open.isEmpty()) {
+            throw new IllegalArgumentException("{% if " + open.get(open.size() - 1).name() + " %} is not closed");
+        }
+        if (at < body.length()) {
+            current.add(new Text(body.substring(at)));
+        }
+        return new PromptTemplate(description, current);
+    }
+
+    public String description() {
+        return description;
+    }
+
+    public List<Argument> arguments() {
+        return arguments.entrySet().stream().map(e -> new Argument(e.getKey(), e.getValue())).toList();
+    }
+
+    /**
+     * @throws IllegalArgumentException when a required argument is missing
+     */
+    public String render(Map<String, String> values) {
+        for (Argument argument : arguments()) {
+            if (argument.required() && isBlank(values.get(argument.name()))) {
+                throw new IllegalArgumentException("missing required argument: " + argument.name());
+            }
+        }
+        StringBuilder out = new StringBuilder();
+        render(nodes, values, out);
+        return out.toString();
+    }
+
+    private static void render(List<Node> nodes, Map<String, String> values, StringBuilder out) {
+        for (Node node : nodes) {
+            switch (node) {
+                case Text t -> out.append(t.text());
+                case Variable v -> {
+                    String value = values.get(v.name());
+                    out.append(isBlank(value) && v.fallback() != null ? v.fallback() : value == null ? "" : value);
+                }
+                case If i -> render(isBlank(values.get(i.name())) ? i.otherwise() : i.then(), values, out);
+            }
+        }
+    }
+
+    private void collect(List<Node> nodes, boolean conditional) {
+        for (Node node : nodes) {
+            if (node instanceof Variable v) {
+                arguments.merge(v.name(), !conditional && v.fallback() == null, Boolean::logicalOr);
+            } else if (node instanceof If i) {
+                arguments.putIfAbsent(i.name(), false);
+                collect(i.then(), true);
+                collect(i.otherwise(), true);
+            }
+        }
+    }
+
+    private static Variable variable(String expression) {
+        Matcher withDefault = DEFAULT.matcher(expression);
+        if (withDefault.matches()) {
+            String fallback = withDefault.group(2) != null ? withDefault.group(2) : withDefault.group(3);
+            return new Variable(withDefault.group(1), fallback.replaceAll("\\\\(.)", "$1"));
+        }
+        if (!NAME.matcher(expression).matches()) {
+            throw new IllegalArgumentException("unsupported expression {{ " + expression + " }}");
+        }
+        return new Variable(expression, null);
+    }
+
+    private static boolean isBlank(String value) {
+        return value == null || value.isEmpty();
+    }
+}
@@ -0,0 +1,134 @@
+package tech.kayys.gollek.sdk.modelstore;
+
+import com.sun.net.httpserver.HttpServer;
+import org.junit.jupiter.api.AfterEach;
+import org.junit.jupiter.api.BeforeEach;
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import java.io.IOException;
+import java.io.OutputStream;
+import java.net.InetSocketAddress;
+import java.net.http.HttpClient;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.Arrays;
+import java.util.concurrent.atomic.AtomicReference;
+
+import static org.junit.jupiter.api.Assertions.*;
+
+class ModelStoreTest {
+
+    private static final byte[] BODY = "GGUF-fake-model-bytes-0123456789".repeat(64).getBytes(StandardCharsets.US_ASCII);
+
+    @TempDir
+    Path dir;
+
+    private HttpServer server;
+    private final AtomicReference<String> lastRange = new AtomicReference<>();
+    private final AtomicReference<String> fileAuthorization = new AtomicReference<>();
+    private final AtomicReference<String> redirectAuthorization = new AtomicReference<>();
+
+    @BeforeEach
+    void startServer() throws IOException {
+        server = HttpServer.create(new InetSocketAddress("127.0.0.1", 0), 0);
+        server.createContext("/files/model.gguf", exchange -> {
+            String range = exchange.getRequestHeaders().getFirst("Range");
+            lastRange.set(range);
+            fileAuthorization.set(exchange.getRequestHeaders().getFirst("Authorization"));
+            int from = range == null ? 0 : Integer.parseInt(range.substring("bytes=".length(), range.length() - 1));
+            byte[] slice = Arrays.copyOfRange(BODY, from, BODY.length);
+            exchange.sendResponseHeaders(range == null ? 200 : 206, slice.length);
+            try (OutputStream out = exchange.getResponseBody()) {
+                out.write(slice);
+            }
+        });
+        // same server under another host name, as the Hub redirects to its CDN
+        server.createContext("/redirect/model.gguf", exchange -> {
+            redirectAuthorization.set(exchange.getRequestHeaders().getFirst("Authorization"));
+            exchange.getResponseHeaders().set("Location",
+                    "http://localhost:" + server.getAddress().getPort() + "/files/model.gguf");
+            exchange.sendResponseHeaders(302, -1);
+            exchange.close();
+        });
+        server.start();
+    }
+
+    @AfterEach
+    void stopServer() {
+        server.stop(0);
+    }
+
+    private String url() {
+        return "http://127.0.0.1:" + server.getAddress().getPort() + "/files/model.gguf";
+    }
+
+    private ModelStore store() {
+        return new ModelStore(dir, HttpClient.newHttpClient(), null, null);
+    }
+
+    @Test
+    void pullsAndRegistersByName() throws Exception {
+        Path partial = dir.resolve("model.gguf" + ModelDownloader.PART_SUFFIX);
+        String sha = sha256(BODY);
+
+        ModelStore.Entry entry = store().pull(url(), new ModelStore.PullOptions(null, null, null, sha, false), null);
+
+        assertEquals("model", entry.name());
+        assertEquals(sha, entry.sha256());
+        assertArrayEquals(BODY, Files.readAllBytes(Path.of(entry.path())));
+        assertFalse(Files.exists(partial));
+        assertTrue(store().find("MODEL").isPresent());
+    }
+
+    @Test
+    void resumesPartialDownload() throws Exception {
+        Files.write(dir.resolve("tiny.gguf" + ModelDownloader.PART_SUFFIX), Arrays.copyOf(BODY, 100));
+
+        ModelStore.Entry entry = store().pull(url(), new ModelStore.PullOptions("tiny", null, null, null, false), null);
+
+        assertEquals("bytes=100-", lastRange.get());
+        assertArrayEquals(BODY, Files.readAllBytes(Path.of(entry.path())));
+    }
+
+    @Test
+    void checksumMismatchDiscardsDownload() {
+        ModelStore store = store();
+        IOException error = assertThrows(IOException.class,
+                () -> store.pull(url(), new ModelStore.PullOptions("bad", null, null, "00", false), null));
+
+        assertTrue(error.getMessage().contains("checksum mismatch"));
+        assertFalse(Files.exists(dir.resolve("bad.gguf")));
+        assertFalse(Files.exists(dir.resolve("bad.gguf" + ModelDownloader.PART_SUFFIX)));
+    }
+
+    @Test
+    void sendsTheTokenOnlyToTheTokenEndpointHost() throws Exception {
+        String endpoint = "http://127.0.0.1:" + server.getAddress().getPort();
+        ModelDownloader downloader = new ModelDownloader(
+                HttpClient.newBuilder().followRedirects(HttpClient.Redirect.NORMAL).build(), "secret", endpoint);
+
+        downloader.download(new ModelSource(endpoint + "/redirect/model.gguf", "model.gguf", null, -1),
+                dir.resolve("redirected.gguf"), null);
+
+        assertEquals("Bearer secret", redirectAuthorization.get());
+        assertNull(fileAuthorization.get());
+        assertArrayEquals(BODY, Files.readAllBytes(dir.resolve("redirected.gguf")));
+
+        new ModelDownloader(HttpClient.newHttpClient(), "secret", "https://huggingface.co")
+                .download(new ModelSource(url(), "model.gguf", null, -1), dir.resolve("plain.gguf"), null);
+        assertNull(fileAuthorization.get());
+    }
+
+    @Test
+    void normalizesNames() {
+        assertEquals("llama-3-8b-instruct.q4_k_m", ModelStore.defaultName("Llama-3-8B-Instruct.Q4_K_M.gguf"));
+        assertEquals("owner-model", ModelStore.normalizeName("owner/model"));
+        assertThrows(IllegalArgumentException.class, () -> ModelStore.normalizeName("../"));
+    }
+
+    private static String sha256(byte[] bytes) throws Exception {
+        return java.util.HexFormat.of().formatHex(java.security.MessageDigest.getInstance("SHA-256").digest(bytes));
+    }
+}
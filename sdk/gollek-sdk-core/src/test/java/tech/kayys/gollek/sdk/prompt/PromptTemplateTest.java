@@ -0,0 +1,48 @@
+package tech.kayys.gollek.sdk.prompt;
+
+import org.junit.jupiter.api.Test;
+
+import java.util.List;
+import java.util.Map;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+
+class PromptTemplateTest {
+
+    @Test
+    void rendersVariablesDefaultsAndConditionals() {
+        var template = PromptTemplate.parse("""
+                {# Summarize a document #}
+                Summarize in {{ style | default("bullet points") }}:
+                {{ text }}{% if audience %}
+                Audience: {{ audience }}{% else %}
+                Audience: general{% endif %}""");
+
+        assertEquals("Summarize a document", template.description());
+        assertEquals(List.of(
+                new PromptTemplate.Argument("style", false),
+                new PromptTemplate.Argument("text", true),
+                new PromptTemplate.Argument("audience", false)), template.arguments());
+        assertEquals("Summarize in bullet points:\nhello\nAudience: general",
+                template.render(Map.of("text", "hello")));
+        assertEquals("Summarize in prose:\nhello\nAudience: kids",
+                template.render(Map.of("text", "hello", "style", "prose", "audience", "kids")));
+    }
+
+    @Test
+    void missingRequiredArgumentIsRejected() {
+        var template = PromptTemplate.parse("Translate {{ text }} to {{ language | default('English') }}");
+
+        assertThrows(IllegalArgumentException.class, () -> template.render(Map.of()));
+        assertEquals("Translate hi to English", template.render(Map.of("text", "hi")));
+    }
+
+    @Test
+    void malformedTemplatesFailToParse() {
+        assertThrows(IllegalArgumentException.class, () -> PromptTemplate.parse("{% if x %}open"));
+        assertThrows(IllegalArgumentException.class, () -> PromptTemplate.parse("{% endif %}"));
+        assertThrows(IllegalArgumentException.class, () -> PromptTemplate.parse("{{ a + b }}"));
+        assertThrows(IllegalArgumentException.class, () -> PromptTemplate.parse("{% for x in y %}"));
+    }
+}
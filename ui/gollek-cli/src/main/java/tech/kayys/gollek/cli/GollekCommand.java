@@ -11,9 +11,11 @@ import picocli.CommandLine.Command;
 import tech.kayys.gollek.cli.commands.ChatCommand;
 import tech.kayys.gollek.cli.commands.PrepareCommand;
 import tech.kayys.gollek.cli.commands.DeleteCommand;
+import tech.kayys.gollek.cli.commands.DemoCommand;
 import tech.kayys.gollek.cli.commands.ExtensionsCommand;
 import tech.kayys.gollek.cli.commands.InfoCommand;
 import tech.kayys.gollek.cli.commands.ListCommand;
+import tech.kayys.gollek.cli.commands.ModelsCommand;
 import tech.kayys.gollek.cli.commands.McpCommand;
 import tech.kayys.gollek.cli.commands.ProvidersCommand;
 import tech.kayys.gollek.cli.commands.PullCommand;
@@ -30,6 +32,9 @@ import tech.kayys.gollek.cli.commands.MultimodalCommand;
 import tech.kayys.gollek.cli.commands.LiteRTCommand;
 import tech.kayys.gollek.cli.commands.OnnxCommand;
 import tech.kayys.gollek.cli.commands.QuantizeCommand;
+import tech.kayys.gollek.cli.commands.LoadTestCommand;
+import tech.kayys.gollek.cli.commands.TemplateCommand;
+import tech.kayys.gollek.cli.commands.ConfigCommand;
 import tech.kayys.gollek.sdk.util.GollekHome;
 
 import picocli.CommandLine;
@@ -42,6 +47,7 @@ import picocli.CommandLine.Option;
         RunCommand.class,
         ChatCommand.class,
         PullCommand.class,
+        ModelsCommand.class,
         PrepareCommand.class,
         ListCommand.class,
         McpCommand.class,
@@ -60,7 +66,11 @@ import picocli.CommandLine.Option;
         MultimodalCommand.class,
         LiteRTCommand.class,
         OnnxCommand.class,
-        QuantizeCommand.class
+        QuantizeCommand.class,
+        LoadTestCommand.class,
+        TemplateCommand.class,
+        ConfigCommand.class,
+        DemoCommand.class
 })
 
 public class GollekCommand implements Runnable {
@@ -0,0 +1,349 @@
+package tech.kayys.gollek.cli.commands;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.SerializationFeature;
+import io.quarkus.arc.Unremovable;
+import jakarta.enterprise.context.Dependent;
+import picocli.CommandLine.Command;
+import picocli.CommandLine.Option;
+import picocli.CommandLine.Parameters;
+
+import java.io.BufferedInputStream;
+import java.io.DataInputStream;
+import java.io.EOFException;
+import java.io.IOException;
+import java.io.InputStream;
+import java.io.Reader;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.time.Duration;
+import java.time.format.DateTimeParseException;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Properties;
+import java.util.Set;
+import java.util.concurrent.Callable;
+import java.util.regex.Matcher;
+import java.util.regex.Pattern;
+
+/**
+ * Offline checks for a server config file, meant for CI: value types, model files that
+ * do not exist, thread counts beyond the CPUs and context sizes beyond what the model was
+ * trained on. Every problem is reported in one run; exits non-zero on any error.
+ *
+ * Usage:
+ *   gollek config validate config/application.properties --format json --cpus 16
+ */
+@Dependent
+@Unremovable
+@Command(name = "config", description = "Check server config files", subcommands = {
+        ConfigCommand.Validate.class
+})
+public class ConfigCommand implements Runnable {
+
+    static final String MODEL_BASE_PATH = "gguf.provider.model.base-path";
+    static final String DEFAULT_MODEL_BASE_PATH = "${user.home}/.gollek/models/gguf";
+    static final String THREADS = "gguf.provider.threads";
+    static final String MAX_CONTEXT = "gguf.provider.max-context-tokens";
+
+    /** Keys naming models that must be present on disk. */
+    static final List<String> MODEL_LISTS = List.of(
+            "gguf.provider.prewarm.models",
+            "gollek.server.ready.models",
+            "gollek.server.model-cache.pinned");
+
+    private static final Pattern INTEGER_KEY = Pattern.compile(
+            "gguf\\.provider\\.(threads|batch-size|max-context-tokens|gpu\\.layers|gpu\\.device-id"
+                    + "|max-concurrent-requests|session\\.pool\\.(min|max)-size|prefix-cache\\.min-tokens)"
+                    + "|gollek\\.server\\.(request-timeout-ms|model-cache\\.max-size)|quarkus\\.http\\.port");
+    private static final Pattern DURATION_KEY = Pattern.compile(
+            "gguf\\.provider\\.session\\.pool\\.(idle-timeout|autoscale\\.(target-wait|scale-down-after))");
+    private static final Pattern EXPRESSION = Pattern.compile("\\$\\{([^:}]+)(?::([^}]*))?}");
+    private static final Set<String> BOOLEANS = Set.of("true", "false");
+
+    @Override
+    public void run() {
+        System.out.println("Use: gollek config validate <file> [--format text|json] [--cpus <n>]");
+    }
+
+    enum Severity {
+        error, warning
+    }
+
+    /** One finding, tied to the key it is about. */
+    record Problem(Severity severity, String key, String message) {
+    }
+
+    /** Everything found in one file. */
+    record Report(String file, List<Problem> problems) {
+
+        boolean valid() {
+            return problems.stream().noneMatch(p -> p.severity() == Severity.error);
+        }
+    }
+
+    @Command(name = "validate", description = "Validate a config file and list every problem")
+    public static class Validate implements Callable<Integer> {
+
+        @Parameters(index = "0", paramLabel = "<file>", description = "Config file (application.properties)")
+        Path file;
+
+        @Option(names = { "-f", "--format" }, description = "Output format: text, json", defaultValue = "text")
+        String format;
+
+        @Option(names = { "--cpus" }, description = "CPUs of the target machine (default: this machine's)")
+        Integer cpus;
+
+        @Option(names = { "--strict" }, description = "Fail on warnings too")
+        boolean strict;
+
+        @Override
+        public Integer call() {
+            Report report;
+            try (Reader reader = Files.newBufferedReader(file)) {
+                Properties props = new Properties();
+                props.load(reader);
+                report = new Report(file.toString(),
+                        validate(props, cpus != null ? cpus : Runtime.getRuntime().availableProcessors()));
+            } catch (IOException | IllegalArgumentException e) {
+                report = new Report(file.toString(),
+                        List.of(new Problem(Severity.error, "", "cannot read file: " + e.getMessage())));
+            }
+            if ("json".equalsIgnoreCase(format)) {
+                try {
+                    Map<String, Object> out = new LinkedHashMap<>();
+                    out.put("file", report.file());
+                    out.put("valid", report.valid());
+                    out.put("problems", report.problems());
+                    System.out.println(new ObjectMapper().enable(SerializationFeature.INDENT_OUTPUT)
+                            .writeValueAsString(out));
+                } catch (IOException e) {
+                    System.err.println("Failed to write report: " + e.getMessage());
+                    return 2;
+                }
+            } else {
+                for (Problem p : report.problems()) {
+                    System.out.printf("%s: %s%s%n", p.severity(), p.key().isEmpty() ? "" : p.key() + ": ",
+                            p.message());
+                }
+                System.out.printf("%s: %d error(s), %d warning(s)%n", report.file(),
+                        count(report, Severity.error), count(report, Severity.warning));
+            }
+            boolean failed = !report.valid() || (strict && !report.problems().isEmpty());
+            return failed ? 1 : 0;
+        }
+
+        private static long count(Report report, Severity severity) {
+            return report.problems().stream().filter(p -> p.severity() == severity).count();
+        }
+    }
+
+    /**
+     * All problems in {@code raw}. Profile-prefixed keys ({@code %prod.gguf.provider.threads})
+     * are checked like the plain key; {@code ${...}} expressions are expanded from system
+     * properties, the environment and the file itself.
+     */
+    static List<Problem> validate(Properties raw, int cpus) {
+        List<Problem> problems = new ArrayList<>();
+        Map<String, String> props = new LinkedHashMap<>();
+        for (String name : raw.stringPropertyNames()) {
+            props.put(name, expand(raw.getProperty(name).trim(), raw));
+        }
+        props.forEach((name, value) -> {
+            String key = unprofiled(name);
+            if (key.endsWith(".enabled") && !BOOLEANS.contains(value.toLowerCase())) {
+                problems.add(new Problem(Severity.error, name, "expected true or false, got '" + value + "'"));
+            } else if (INTEGER_KEY.matcher(key).matches() && parseInt(value).isEmpty()) {
+                problems.add(new Problem(Severity.error, name, "expected an integer, got '" + value + "'"));
+            } else if (DURATION_KEY.matcher(key).matches() && !isDuration(value)) {
+                problems.add(new Problem(Severity.error, name,
+                        "expected a duration such as PT5M or 30s, got '" + value + "'"));
+            }
+        });
+
+        for (var entry : withKey(props, THREADS).entrySet()) {
+            parseInt(entry.getValue()).ifPresent(threads -> {
+                if (threads < 1) {
+                    problems.add(new Problem(Severity.error, entry.getKey(), "must be at least 1"));
+                } else if (threads > cpus) {
+                    problems.add(new Problem(Severity.warning, entry.getKey(),
+                            threads + " threads on " + cpus + " CPUs oversubscribes and slows decoding"));
+                }
+            });
+        }
+
+        Map<String, String> bases = withKey(props, MODEL_BASE_PATH);
+        String base = bases.getOrDefault(MODEL_BASE_PATH, expand(DEFAULT_MODEL_BASE_PATH, raw));
+        bases.forEach((name, dir) -> {
+            if (!Files.isDirectory(Path.of(dir))) {
+                problems.add(new Problem(Severity.error, name, "model directory does not exist: " + dir));
+            }
+        });
+
+        Integer context = props.containsKey(MAX_CONTEXT) ? parseInt(props.get(MAX_CONTEXT)).orElse(null) : 4096;
+        for (String listKey : MODEL_LISTS) {
+            for (var entry : withKey(props, listKey).entrySet()) {
+                for (String model : entry.getValue().split(",")) {
+                    if (model.isBlank()) {
+                        continue;
+                    }
+                    Optional<Path> path = resolveModel(model.trim(), Path.of(base));
+                    if (path.isEmpty()) {
+                        problems.add(new Problem(Severity.error, entry.getKey(),
+                                "model file not found: " + model.trim() + " (looked in " + base + ")"));
+                        continue;
+                    }
+                    if (context == null || !path.get().toString().endsWith(".gguf")) {
+                        continue;
+                    }
+                    try {
+                        Long trained = trainingContext(path.get());
+                        if (trained != null && context > trained) {
+                            problems.add(new Problem(Severity.warning, MAX_CONTEXT, context
+                                    + " exceeds the " + trained + " tokens " + model.trim()
+                                    + " was trained on; output degrades past that"));
+                        }
+                    } catch (IOException e) {
+                        problems.add(new Problem(Severity.error, entry.getKey(),
+                                "cannot read " + path.get() + ": " + e.getMessage()));
+                    }
+                }
+            }
+        }
+        return problems;
+    }
+
+    /** {@code key} and its profile variants with their values. */
+    private static Map<String, String> withKey(Map<String, String> props, String key) {
+        Map<String, String> matches = new LinkedHashMap<>();
+        props.forEach((name, value) -> {
+            if (unprofiled(name).equals(key)) {
+                matches.put(name, value);
+            }
+        });
+        return matches;
+    }
+
+    static String unprofiled(String name) {
+        if (name.startsWith("%")) {
+            int dot = name.indexOf('.');
+            return dot > 0 ? name.substring(dot + 1) : name;
+        }
+        return name;
+    }
+
+    /** {@code ${name}} and {@code ${name:default}} from system properties, env, then the file. */
+    static String expand(String value, Properties file) {
+        Matcher m = EXPRESSION.matcher(value);
+        StringBuilder out = new StringBuilder();
+        while (m.find()) {
+            String name = m.group(1);
+            String resolved = System.getProperty(name);
+            if (resolved == null) {
+                resolved = System.getenv(name.replaceAll("[^A-Za-z0-9]", "_").toUpperCase());
+            }
+            if (resolved == null) {
+                resolved = file.getProperty(name);
+            }
+            if (resolved == null) {
+                resolved = m.group(2) != null ? m.group(2) : m.group();
+            }
+            m.appendReplacement(out, Matcher.quoteReplacement(resolved));
+        }
+        m.appendTail(out);
+        return out.toString();
+    }
+
+    /** A model named by path, file name, or file name without {@code .gguf}, under {@code base}. */
+    static Optional<Path> resolveModel(String model, Path base) {
+        for (Path candidate : List.of(Path.of(model), base.resolve(model), base.resolve(model + ".gguf"))) {
+            if (Files.isRegularFile(candidate)) {
+                return Optional.of(candidate);
+            }
+        }
+        return Optional.empty();
+    }
+
+    private static Optional<Integer> parseInt(String value) {
+        try {
+            return Optional.of(Integer.parseInt(value.trim()));
+        } catch (NumberFormatException e) {
+            return Optional.empty();
+        }
+    }
+
+    private static boolean isDuration(String value) {
+        if (value.matches("\\d+(ms|s|m|h|d)?")) {
+            return true;
+        }
+        try {
+            Duration.parse(value);
+            return true;
+        } catch (DateTimeParseException e) {
+            return false;
+        }
+    }
+
+    /**
+     * {@code <arch>.context_length} from a GGUF header, or null when the file does not set
+     * it. Reads only the metadata, never the tensors.
+     *
+     * @throws IOException if the file is not GGUF or is truncated
+     */
+    static Long trainingContext(Path gguf) throws IOException {
+        try (InputStream in = new BufferedInputStream(Files.newInputStream(gguf), 1 << 16)) {
+            DataInputStream data = new DataInputStream(in);
+            if (Integer.reverseBytes(data.readInt()) != 0x46554747) {
+                throw new IOException("not a GGUF file");
+            }
+            int version = Integer.reverseBytes(data.readInt());
+            if (version < 2) {
+                throw new IOException("GGUF version " + version + " is not supported");
+            }
+            data.readLong(); // tensor count
+            long kvCount = Long.reverseBytes(data.readLong());
+            for (long i = 0; i < kvCount; i++) {
+                String key = readString(data);
+                int type = Integer.reverseBytes(data.readInt());
+                if (key.endsWith(".context_length") && (type == 4 || type == 5 || type == 10 || type == 11)) {
+                    return type >= 10 ? Long.reverseBytes(data.readLong())
+                            : Integer.toUnsignedLong(Integer.reverseBytes(data.readInt()));
+                }
+                skipValue(data, type);
+            }
+            return null;
+        } catch (EOFException e) {
+            throw new IOException("truncated GGUF header");
+        }
+    }
+
+    private static String readString(DataInputStream data) throws IOException {
+        long length = Long.reverseBytes(data.readLong());
+        if (length < 0 || length > 1 << 20) {
+            throw new IOException("corrupt GGUF string length " + length);
+        }
+        return new String(data.readNBytes((int) length), StandardCharsets.UTF_8);
+    }
+
+    private static void skipValue(DataInputStream data, int type) throws IOException {
+        switch (type) {
+            case 0, 1, 7 -> data.skipNBytes(1);
+            case 2, 3 -> data.skipNBytes(2);
+            case 4, 5, 6 -> data.skipNBytes(4);
+            case 10, 11, 12 -> data.skipNBytes(8);
+            case 8 -> data.skipNBytes(Long.reverseBytes(data.readLong()));
+            case 9 -> {
+                int elementType = Integer.reverseBytes(data.readInt());
+                long count = Long.reverseBytes(data.readLong());
+                for (long i = 0; i < count; i++) {
+                    skipValue(data, elementType);
+                }
+            }
+            default -> throw new IOException("unknown GGUF value type " + type);
+        }
+    }
+}
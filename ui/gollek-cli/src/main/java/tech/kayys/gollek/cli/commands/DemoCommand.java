@@ -0,0 +1,205 @@
+package tech.kayys.gollek.cli.commands;
+
+import io.quarkus.arc.Unremovable;
+import jakarta.enterprise.context.Dependent;
+import picocli.CommandLine.Command;
+import picocli.CommandLine.Option;
+import tech.kayys.gollek.sdk.modelstore.ModelStore;
+import tech.kayys.gollek.sdk.util.GollekHome;
+
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Optional;
+
+/**
+ * Zero-config demo: pulls a small quantized model into the model store (once), starts the
+ * API server on it and prints the endpoint and playground URL.
+ * Usage: gollek demo [--port 8080] [--model hf:owner/repo --quant Q4_K_M]
+ */
+@Dependent
+@Unremovable
+@Command(name = "demo", description = "Download a tiny model and start the API server with the playground")
+public class DemoCommand implements Runnable {
+
+    static final String DEFAULT_MODEL = "hf:Qwen/Qwen2.5-0.5B-Instruct-GGUF";
+    static final String DEFAULT_QUANT = "Q4_K_M";
+    static final String DEMO_NAME = "gollek-demo";
+    static final String DEMO_API_KEY = "community";
+
+    @Option(names = { "--model" }, description = "Model to serve (hf:owner/repo[/file.gguf] or URL)",
+            defaultValue = DEFAULT_MODEL)
+    String model;
+
+    @Option(names = { "--quant" }, description = "Quantization to pick from the repo", defaultValue = DEFAULT_QUANT)
+    String quant;
+
+    @Option(names = { "--port" }, description = "HTTP port", defaultValue = "8080")
+    int port;
+
+    @Option(names = { "--server-jar" }, description = "Server quarkus-run.jar (default: $GOLLEK_SERVER_JAR, "
+            + "<gollek home>/server, or a local build)")
+    Path serverJar;
+
+    @Option(names = { "--startup-timeout" }, description = "Seconds to wait for the server to come up",
+            defaultValue = "180")
+    int startupTimeoutSeconds;
+
+    @Override
+    public void run() {
+        ModelStore store = ModelStore.defaults();
+        ModelStore.Entry entry;
+        try {
+            entry = pullDemoModel(store);
+        } catch (Exception e) {
+            System.err.println("\nFailed to download the demo model: " + e.getMessage());
+            return;
+        }
+
+        Optional<Path> jar = locateServerJar();
+        if (jar.isEmpty()) {
+            System.err.println("Gollek server not found. Build it with 'mvn -f ui/gollek-server-api package', "
+                    + "or set GOLLEK_SERVER_JAR / --server-jar to its quarkus-run.jar.");
+            return;
+        }
+
+        Process server;
+        try {
+            server = new ProcessBuilder(serverCommand(jar.get(), store.root(), entry.name()))
+                    .inheritIO()
+                    .start();
+        } catch (Exception e) {
+            System.err.println("Failed to start the server: " + e.getMessage());
+            return;
+        }
+        Runtime.getRuntime().addShutdownHook(new Thread(server::destroy));
+
+        String base = "http://localhost:" + port;
+        if (awaitHealthy(server, base)) {
+            printBanner(base, entry.name());
+        } else if (server.isAlive()) {
+            System.err.println("Server did not report healthy within " + startupTimeoutSeconds + "s; still waiting.");
+        }
+        try {
+            int exit = server.waitFor();
+            if (exit != 0) {
+                System.err.println("Server exited with code " + exit);
+            }
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+            server.destroy();
+        }
+    }
+
+    private ModelStore.Entry pullDemoModel(ModelStore store) throws Exception {
+        Optional<ModelStore.Entry> existing = store.find(DEMO_NAME);
+        if (existing.isPresent() && existing.get().source().equals(model)
+                && Files.exists(Path.of(existing.get().path()))) {
+            return existing.get();
+        }
+        System.out.println("Downloading demo model " + model + " (" + quant + ")...");
+        boolean replace = existing.isPresent();
+        ModelStore.Entry entry = store.pull(model, new ModelStore.PullOptions(DEMO_NAME, "main", quant, null, replace),
+                progress -> {
+                    if (progress.getTotal() > 0) {
+                        System.out.printf("\r%s [%s] %3d%% (%d/%d MB)", progress.getStatus(),
+                                progress.getProgressBar(30), progress.getPercentComplete(),
+                                progress.getCompleted() / 1024 / 1024, progress.getTotal() / 1024 / 1024);
+                    }
+                });
+        System.out.println();
+        return entry;
+    }
+
+    /** Server command line: demo defaults passed as system properties, no config file needed. */
+    List<String> serverCommand(Path jar, Path modelDir, String modelName) {
+        List<String> command = new ArrayList<>();
+        command.add(javaExecutable());
+        command.add("--enable-native-access=ALL-UNNAMED");
+        command.add("-Dquarkus.http.port=" + port);
+        command.add("-Dgollek.server.allowed-api-keys=" + DEMO_API_KEY);
+        command.add("-Dgguf.provider.model.base-path=" + modelDir.toAbsolutePath());
+        command.add("-Dgguf.provider.prewarm.enabled=true");
+        command.add("-Dgguf.provider.prewarm.models=" + modelName);
+        command.add("-jar");
+        command.add(jar.toAbsolutePath().toString());
+        return command;
+    }
+
+    private Optional<Path> locateServerJar() {
+        List<Path> candidates = new ArrayList<>();
+        if (serverJar != null) {
+            candidates.add(serverJar);
+        }
+        String env = System.getenv("GOLLEK_SERVER_JAR");
+        if (env != null && !env.isBlank()) {
+            candidates.add(Path.of(env.trim()));
+        }
+        candidates.add(GollekHome.path("server", "quarkus-app", "quarkus-run.jar"));
+        Path dir = Path.of(System.getProperty("user.dir")).toAbsolutePath();
+        for (int i = 0; i < 4 && dir != null; i++) {
+            candidates.add(dir.resolve("ui/gollek-server-api/target/quarkus-app/quarkus-run.jar"));
+            dir = dir.getParent();
+        }
+        return candidates.stream().filter(Files::isRegularFile).findFirst();
+    }
+
+    private static String javaExecutable() {
+        String javaHome = System.getProperty("java.home");
+        if (javaHome != null) {
+            Path java = Path.of(javaHome, "bin", isWindows() ? "java.exe" : "java");
+            if (Files.isExecutable(java)) {
+                return java.toString();
+            }
+        }
+        // native CLI build: use java from PATH
+        return "java";
+    }
+
+    private static boolean isWindows() {
+        return System.getProperty("os.name", "").toLowerCase().contains("win");
+    }
+
+    private boolean awaitHealthy(Process server, String base) {
+        HttpClient client = HttpClient.newBuilder().connectTimeout(Duration.ofSeconds(2)).build();
+        HttpRequest health = HttpRequest.newBuilder(URI.create(base + "/health"))
+                .timeout(Duration.ofSeconds(5))
+                .GET()
+                .build();
+        long deadline = System.nanoTime() + Duration.ofSeconds(startupTimeoutSeconds).toNanos();
+        while (server.isAlive() && System.nanoTime() < deadline) {
+            try {
+                if (client.send(health, HttpResponse.BodyHandlers.discarding()).statusCode() == 200) {
+                    return true;
+                }
+            } catch (Exception ignored) {
+                // not listening yet
+            }
+            try {
+                Thread.sleep(500);
+            } catch (InterruptedException e) {
+                Thread.currentThread().interrupt();
+                return false;
+            }
+        }
+        return false;
+    }
+
+    private void printBanner(String base, String modelName) {
+        System.out.println();
+        System.out.println("Gollek demo is running (Ctrl+C to stop)");
+        System.out.println("  Playground: " + base + "/playground/?model=" + modelName);
+        System.out.println("  API:        " + base + "/v1/chat/completions (X-API-Key: " + DEMO_API_KEY + ")");
+        System.out.println();
+        System.out.println("  curl " + base + "/v1/chat/completions -H 'Content-Type: application/json' \\");
+        System.out.println("    -H 'X-API-Key: " + DEMO_API_KEY + "' \\");
+        System.out.println("    -d '{\"model\":\"" + modelName + "\",\"messages\":[{\"role\":\"user\",\"content\":\"Hello!\"}]}'");
+        System.out.println();
+    }
+}
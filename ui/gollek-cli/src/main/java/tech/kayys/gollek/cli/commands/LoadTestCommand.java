@@ -0,0 +1,212 @@
+package tech.kayys.gollek.cli.commands;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import io.quarkus.arc.Unremovable;
+import jakarta.enterprise.context.Dependent;
+import picocli.CommandLine.Command;
+import picocli.CommandLine.Option;
+
+import java.io.BufferedReader;
+import java.io.InputStream;
+import java.io.InputStreamReader;
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.List;
+import java.util.Map;
+import java.util.UUID;
+import java.util.concurrent.CountDownLatch;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.concurrent.atomic.AtomicInteger;
+
+/**
+ * Drives concurrent streaming traffic against a running Gollek server and reports
+ * time-to-first-token, end-to-end latency percentiles and throughput.
+ */
+@Dependent
+@Unremovable
+@Command(name = "loadtest", description = "Run a concurrent streaming load test against a Gollek server")
+public class LoadTestCommand implements Runnable {
+
+    @Option(names = { "--url" }, description = "Server base URL", defaultValue = "http://localhost:8080")
+    public String url;
+
+    @Option(names = { "-m", "--model" }, description = "Model ID to request")
+    public String model;
+
+    @Option(names = { "-c", "--concurrency" }, description = "Concurrent streams", defaultValue = "4")
+    public int concurrency;
+
+    @Option(names = { "-n", "--requests" }, description = "Total requests to send", defaultValue = "32")
+    public int requests;
+
+    @Option(names = { "--prompt-file" }, description = "File with one prompt per line (cycled)")
+    public Path promptFile;
+
+    @Option(names = { "-p", "--prompt" }, description = "Prompt to use when no prompt file is given",
+            defaultValue = "Write a short paragraph about the ocean.")
+    public String prompt;
+
+    @Option(names = { "--max-tokens" }, description = "max_tokens per request", defaultValue = "128")
+    public int maxTokens;
+
+    @Option(names = { "--api-key" }, description = "X-API-Key header", defaultValue = "community")
+    public String apiKey;
+
+    @Option(names = { "--timeout" }, description = "Per-request timeout in seconds", defaultValue = "120")
+    public int timeoutSeconds;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    record Sample(long ttftMs, long latencyMs, int chunks, boolean ok, String error) {
+    }
+
+    @Override
+    public void run() {
+        List<String> prompts;
+        try {
+            prompts = loadPrompts();
+        } catch (Exception e) {
+            System.err.println("Failed to read prompts: " + e.getMessage());
+            return;
+        }
+        if (prompts.isEmpty()) {
+            System.err.println("No prompts to send.");
+            return;
+        }
+
+        HttpClient client = HttpClient.newBuilder().connectTimeout(Duration.ofSeconds(10)).build();
+        String endpoint = url.replaceAll("/+$", "") + "/v1/completions/stream";
+        List<Sample> samples = Collections.synchronizedList(new ArrayList<>());
+        AtomicInteger next = new AtomicInteger();
+        int workers = Math.max(1, Math.min(concurrency, requests));
+        CountDownLatch done = new CountDownLatch(workers);
+        ExecutorService pool = Executors.newFixedThreadPool(workers);
+
+        System.out.printf("Load test: %d requests, concurrency %d -> %s%n", requests, workers, endpoint);
+        long started = System.nanoTime();
+        for (int w = 0; w < workers; w++) {
+            pool.submit(() -> {
+                try {
+                    int i;
+                    while ((i = next.getAndIncrement()) < requests) {
+                        samples.add(send(client, endpoint, prompts.get(i % prompts.size())));
+                    }
+                } finally {
+                    done.countDown();
+                }
+            });
+        }
+        try {
+            done.await();
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+        } finally {
+            pool.shutdownNow();
+        }
+        double wallSeconds = (System.nanoTime() - started) / 1e9;
+        report(new ArrayList<>(samples), wallSeconds);
+    }
+
+    private List<String> loadPrompts() throws Exception {
+        if (promptFile == null) {
+            return List.of(prompt);
+        }
+        return Files.readAllLines(promptFile, StandardCharsets.UTF_8).stream()
+                .map(String::strip)
+                .filter(l -> !l.isEmpty())
+                .toList();
+    }
+
+    private Sample send(HttpClient client, String endpoint, String text) {
+        long start = System.nanoTime();
+        try {
+            var body = new java.util.LinkedHashMap<String, Object>();
+            body.put("requestId", UUID.randomUUID().toString());
+            if (model != null) {
+                body.put("model", model);
+            }
+            body.put("messages", List.of(Map.of("role", "USER", "content", text)));
+            body.put("parameters", Map.of("max_tokens", maxTokens));
+            body.put("streaming", true);
+
+            HttpRequest req = HttpRequest.newBuilder(URI.create(endpoint))
+                    .timeout(Duration.ofSeconds(timeoutSeconds))
+                    .header("Content-Type", "application/json")
+                    .header("Accept", "text/event-stream")
+                    .header("X-API-Key", apiKey)
+                    .POST(HttpRequest.BodyPublishers.ofString(mapper.writeValueAsString(body)))
+                    .build();
+            HttpResponse<InputStream> resp = client.send(req, HttpResponse.BodyHandlers.ofInputStream());
+            if (resp.statusCode() / 100 != 2) {
+                resp.body().close();
+                return new Sample(-1, elapsedMs(start), 0, false, "HTTP " + resp.statusCode());
+            }
+            long ttft = -1;
+            int chunks = 0;
+            try (BufferedReader reader = new BufferedReader(new InputStreamReader(resp.body(), StandardCharsets.UTF_8))) {
+                String line;
+                while ((line = reader.readLine()) != null) {
+                    if (!line.startsWith("data:")) {
+                        continue;
+                    }
+                    JsonNode chunk = mapper.readTree(line.substring(5).strip());
+                    if ("error".equals(chunk.path("finishReason").asText())) {
+                        return new Sample(ttft, elapsedMs(start), chunks, false, chunk.path("delta").asText("error"));
+                    }
+                    if (!chunk.path("delta").asText("").isEmpty()) {
+                        if (ttft < 0) {
+                            ttft = elapsedMs(start);
+                        }
+                        chunks++;
+                    }
+                }
+            }
+            return new Sample(ttft, elapsedMs(start), chunks, true, null);
+        } catch (Exception e) {
+            return new Sample(-1, elapsedMs(start), 0, false, e.getClass().getSimpleName() + ": " + e.getMessage());
+        }
+    }
+
+    private static long elapsedMs(long startNanos) {
+        return (System.nanoTime() - startNanos) / 1_000_000;
+    }
+
+    private void report(List<Sample> samples, double wallSeconds) {
+        List<Sample> ok = samples.stream().filter(Sample::ok).toList();
+        long[] ttft = ok.stream().mapToLong(Sample::ttftMs).filter(v -> v >= 0).sorted().toArray();
+        long[] latency = ok.stream().mapToLong(Sample::latencyMs).sorted().toArray();
+        long totalChunks = ok.stream().mapToLong(Sample::chunks).sum();
+
+        System.out.println();
+        System.out.printf("Requests:   %d ok, %d failed in %.2fs%n", ok.size(), samples.size() - ok.size(), wallSeconds);
+        System.out.printf("Throughput: %.2f req/s, %.1f tokens/s%n", ok.size() / wallSeconds, totalChunks / wallSeconds);
+        printPercentiles("TTFT (ms)", ttft);
+        printPercentiles("Latency (ms)", latency);
+        samples.stream().filter(s -> !s.ok()).map(Sample::error).distinct().limit(5)
+                .forEach(err -> System.out.println("  error: " + err));
+    }
+
+    private static void printPercentiles(String label, long[] sorted) {
+        if (sorted.length == 0) {
+            System.out.printf("%-13s n/a%n", label + ":");
+            return;
+        }
+        System.out.printf("%-13s p50=%d p90=%d p99=%d max=%d%n", label + ":",
+                percentile(sorted, 50), percentile(sorted, 90), percentile(sorted, 99), sorted[sorted.length - 1]);
+    }
+
+    static long percentile(long[] sorted, int p) {
+        int idx = (int) Math.ceil(p / 100.0 * sorted.length) - 1;
+        return sorted[Math.max(0, Math.min(sorted.length - 1, idx))];
+    }
+}
@@ -0,0 +1,105 @@
+package tech.kayys.gollek.cli.commands;
+
+import io.quarkus.arc.Unremovable;
+import jakarta.enterprise.context.Dependent;
+import picocli.CommandLine.Command;
+import picocli.CommandLine.Option;
+import picocli.CommandLine.Parameters;
+import tech.kayys.gollek.sdk.modelstore.ModelSource;
+import tech.kayys.gollek.sdk.modelstore.ModelStore;
+
+import java.util.List;
+
+/**
+ * Model store: GGUF files pulled by registry name.
+ * Usage: gollek models pull hf:owner/repo --quant Q4_K_M --name my-model
+ */
+@Dependent
+@Unremovable
+@Command(name = "models", description = "Download and list GGUF models in the local model store", subcommands = {
+        ModelsCommand.PullSubcommand.class,
+        ModelsCommand.ListSubcommand.class
+})
+public class ModelsCommand implements Runnable {
+
+    @Override
+    public void run() {
+        System.out.println("Use a subcommand: pull or list. Run 'gollek models --help' for details.");
+    }
+
+    @Command(name = "pull", description = "Download a GGUF file from Hugging Face or a URL (resumes if interrupted)")
+    public static class PullSubcommand implements Runnable {
+
+        @Parameters(index = "0", description = "hf:owner/repo, hf:owner/repo/file.gguf or an http(s) URL")
+        String spec;
+
+        @Option(names = { "--name" }, description = "Registry name (default: file name without .gguf)")
+        String name;
+
+        @Option(names = { "--quant" }, description = "Pick the repo file whose name contains this (e.g. Q4_K_M)")
+        String quant;
+
+        @Option(names = { "--revision" }, description = "Hugging Face branch, tag or commit", defaultValue = "main")
+        String revision;
+
+        @Option(names = { "--sha256" }, description = "Expected sha256 (Hugging Face LFS checksums are used otherwise)")
+        String sha256;
+
+        @Option(names = { "--force" }, description = "Download again even if the name is already registered")
+        boolean force;
+
+        @Override
+        public void run() {
+            ModelStore store = ModelStore.defaults();
+            try {
+                System.out.println("Pulling model: " + spec);
+                if (ModelSource.isUrl(spec) && (sha256 == null || sha256.isBlank())) {
+                    System.err.println("Warning: no --sha256 given, the downloaded file will not be verified");
+                }
+                ModelStore.Entry entry = store.pull(spec,
+                        new ModelStore.PullOptions(name, revision, quant, sha256, force),
+                        progress -> {
+                            if (progress.getTotal() > 0) {
+                                System.out.printf("\r%s [%s] %3d%% (%d/%d MB)",
+                                        progress.getStatus(),
+                                        progress.getProgressBar(30),
+                                        progress.getPercentComplete(),
+                                        progress.getCompleted() / 1024 / 1024,
+                                        progress.getTotal() / 1024 / 1024);
+                            } else {
+                                System.out.printf("\r%s... %d MB", progress.getStatus(),
+                                        progress.getCompleted() / 1024 / 1024);
+                            }
+                        });
+                System.out.println();
+                System.out.println("Pulled " + entry.name() + " -> " + entry.path());
+                System.out.println("sha256: " + entry.sha256());
+            } catch (Exception e) {
+                System.err.println("\nFailed to pull model: " + e.getMessage());
+            }
+        }
+    }
+
+    @Command(name = "list", description = "List models in the local model store")
+    public static class ListSubcommand implements Runnable {
+
+        @Override
+        public void run() {
+            ModelStore store = ModelStore.defaults();
+            try {
+                List<ModelStore.Entry> entries = store.list();
+                if (entries.isEmpty()) {
+                    System.out.println("No models in " + store.root());
+                    return;
+                }
+                System.out.printf("%-40s %10s  %s%n", "NAME", "SIZE", "SOURCE");
+                for (ModelStore.Entry entry : entries) {
+                    System.out.printf("%-40s %7d MB  %s%n", entry.name(), entry.sizeBytes() / 1024 / 1024,
+                            entry.source());
+                }
+            } catch (Exception e) {
+                System.err.println("Failed to read model store: " + e.getMessage());
+            }
+        }
+    }
+}
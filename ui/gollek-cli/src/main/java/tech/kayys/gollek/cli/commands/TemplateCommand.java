@@ -0,0 +1,230 @@
+package tech.kayys.gollek.cli.commands;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import io.quarkus.arc.Unremovable;
+import jakarta.enterprise.context.Dependent;
+import jakarta.inject.Inject;
+import picocli.CommandLine.Command;
+import picocli.CommandLine.Option;
+
+import tech.kayys.gollek.sdk.prompt.PromptTemplate;
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.ProviderHealth;
+import tech.kayys.gollek.spi.provider.ProviderRegistry;
+import tech.kayys.gollek.spi.provider.TokenizingProvider;
+
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Set;
+import java.util.concurrent.Callable;
+import java.util.stream.Collectors;
+
+/**
+ * Offline checks for the server's prompt templates ({@code gollek.server.mcp.prompts-dir}),
+ * meant for CI. Exits non-zero when a template does not parse, uses a required variable
+ * the vars file does not set, or does not fit the model's context.
+ *
+ * Usage:
+ *   gollek template render --template t.tmpl --vars vars.json --model m.gguf
+ */
+@Dependent
+@Unremovable
+@Command(name = "template", description = "Lint and dry-render prompt templates", subcommands = {
+        TemplateCommand.Render.class
+})
+public class TemplateCommand implements Runnable {
+
+    @Override
+    public void run() {
+        System.out.println("Use: gollek template render --template <file> [--vars <json>] [--model <model>]");
+    }
+
+    /** Outcome of checking a template against a set of variables. */
+    record Check(List<String> errors, List<String> warnings) {
+
+        boolean ok() {
+            return errors.isEmpty();
+        }
+    }
+
+    @Command(name = "render", description = "Render a template, flag undefined variables and check context fit")
+    public static class Render implements Callable<Integer> {
+
+        private static final Duration HEALTH_TIMEOUT = Duration.ofSeconds(5);
+
+        @Inject
+        ProviderRegistry providerRegistry;
+
+        @Option(names = { "-t", "--template" }, description = "Template file", required = true)
+        Path template;
+
+        @Option(names = { "--vars" }, description = "JSON object of variable values")
+        Path vars;
+
+        @Option(names = { "-m", "--model" }, description = "Model (ID or .gguf path) to tokenize with")
+        String model;
+
+        @Option(names = { "--context" }, description = "Context size in tokens (default: the loaded model's)")
+        Integer context;
+
+        @Option(names = { "--max-tokens" }, description = "Tokens to reserve for the completion", defaultValue = "0")
+        int maxTokens;
+
+        @Option(names = { "-q", "--quiet" }, description = "Do not print the rendered prompt")
+        boolean quiet;
+
+        private final ObjectMapper mapper = new ObjectMapper();
+
+        @Override
+        public Integer call() {
+            PromptTemplate parsed;
+            Map<String, String> values;
+            try {
+                parsed = PromptTemplate.parse(Files.readString(template));
+            } catch (IllegalArgumentException e) {
+                System.err.println(template + ": " + e.getMessage());
+                return 1;
+            } catch (Exception e) {
+                System.err.println("Failed to read template: " + e.getMessage());
+                return 1;
+            }
+            try {
+                values = vars == null ? Map.of() : values(mapper.readTree(vars.toFile()));
+            } catch (Exception e) {
+                System.err.println("Failed to read vars: " + e.getMessage());
+                return 1;
+            }
+
+            Check check = check(parsed, values);
+            check.warnings().forEach(w -> System.err.println("warning: " + w));
+            check.errors().forEach(e -> System.err.println("error: " + e));
+            if (!check.ok()) {
+                return 1;
+            }
+            String rendered = parsed.render(values);
+            if (!quiet) {
+                System.out.println(rendered);
+            }
+            if (model == null || model.isBlank()) {
+                return 0;
+            }
+            return checkContext(rendered);
+        }
+
+        private int checkContext(String rendered) {
+            String modelId = modelId(model);
+            for (LLMProvider provider : providerRegistry.getAllProviders()) {
+                if (!(provider instanceof TokenizingProvider tokenizer) || !provider.supports(modelId, null)) {
+                    continue;
+                }
+                int tokens;
+                try {
+                    tokens = tokenizer.tokenize(modelId, rendered, true).length;
+                } catch (Exception e) {
+                    System.err.println("Failed to tokenize with " + provider.id() + ": " + e.getMessage());
+                    return 1;
+                }
+                Integer window = context != null ? context : contextOf(provider, modelId);
+                if (window == null) {
+                    System.err.printf("%d tokens; context size unknown, pass --context to check the fit%n", tokens);
+                    return 0;
+                }
+                boolean fits = tokens + maxTokens <= window;
+                System.err.printf("%d tokens + %d reserved of %d context: %s%n", tokens, maxTokens, window,
+                        fits ? "fits" : "does not fit");
+                return fits ? 0 : 1;
+            }
+            System.err.println("No provider can tokenize model: " + model);
+            return 1;
+        }
+
+        private Integer contextOf(LLMProvider provider, String modelId) {
+            try {
+                return slotCapacity(provider.health().await().atMost(HEALTH_TIMEOUT), modelId);
+            } catch (Exception e) {
+                return null;
+            }
+        }
+    }
+
+    /**
+     * Required variables without a value are errors; optional ones only warn, since the
+     * template falls back to a default or an {@code else} branch. Values the template
+     * never uses are warned about too, as they are usually misspellings.
+     */
+    static Check check(PromptTemplate template, Map<String, String> values) {
+        List<String> errors = new ArrayList<>();
+        List<String> warnings = new ArrayList<>();
+        for (PromptTemplate.Argument argument : template.arguments()) {
+            String value = values.get(argument.name());
+            if (value != null && !value.isEmpty()) {
+                continue;
+            }
+            if (argument.required()) {
+                errors.add("undefined variable: " + argument.name());
+            } else {
+                warnings.add("variable not set, using its default: " + argument.name());
+            }
+        }
+        Set<String> used = template.arguments().stream().map(PromptTemplate.Argument::name)
+                .collect(Collectors.toSet());
+        values.keySet().stream().filter(name -> !used.contains(name)).sorted()
+                .forEach(name -> warnings.add("unused variable: " + name));
+        return new Check(errors, warnings);
+    }
+
+    /**
+     * Template values from a JSON object: strings as they are, other values as JSON text,
+     * nulls left unset.
+     *
+     * @throws IllegalArgumentException if {@code json} is not an object
+     */
+    static Map<String, String> values(JsonNode json) {
+        if (!json.isObject()) {
+            throw new IllegalArgumentException("expected a JSON object of variables");
+        }
+        Map<String, String> values = new LinkedHashMap<>();
+        json.properties().forEach(e -> {
+            JsonNode value = e.getValue();
+            if (!value.isNull()) {
+                values.put(e.getKey(), value.isTextual() ? value.asText() : value.toString());
+            }
+        });
+        return values;
+    }
+
+    /**
+     * Tokens one request can use on a loaded model: the largest sequence slot the provider
+     * reports for it, or null when it reports none.
+     */
+    static Integer slotCapacity(ProviderHealth health, String modelId) {
+        if (health == null || !(health.details().get("slots") instanceof List<?> runners)) {
+            return null;
+        }
+        Integer capacity = null;
+        for (Object entry : runners) {
+            if (!(entry instanceof Map<?, ?> runner) || !modelId.equals(runner.get("model"))
+                    || !(runner.get("slots") instanceof List<?> slots)) {
+                continue;
+            }
+            for (Object s : slots) {
+                if (s instanceof Map<?, ?> slot && slot.get("capacity") instanceof Number n) {
+                    capacity = capacity == null ? n.intValue() : Math.max(capacity, n.intValue());
+                }
+            }
+        }
+        return capacity;
+    }
+
+    /** GGUF paths are passed to providers as absolute paths; anything else is a model ID. */
+    static String modelId(String model) {
+        Path path = Path.of(model);
+        return Files.isRegularFile(path) ? path.toAbsolutePath().toString() : model;
+    }
+}
@@ -0,0 +1,102 @@
+package tech.kayys.gollek.cli.commands;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import java.io.ByteArrayOutputStream;
+import java.io.IOException;
+import java.nio.ByteBuffer;
+import java.nio.ByteOrder;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.List;
+import java.util.Properties;
+import java.util.Set;
+import java.util.stream.Collectors;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+public class ConfigCommandTest {
+
+    @TempDir
+    Path dir;
+
+    private static Properties props(String... pairs) {
+        Properties props = new Properties();
+        for (int i = 0; i < pairs.length; i += 2) {
+            props.setProperty(pairs[i], pairs[i + 1]);
+        }
+        return props;
+    }
+
+    /** A GGUF header with a token array before {@code llama.context_length}. */
+    private static byte[] gguf(int contextLength) {
+        ByteArrayOutputStream out = new ByteArrayOutputStream();
+        ByteBuffer b = ByteBuffer.allocate(4096).order(ByteOrder.LITTLE_ENDIAN);
+        b.putInt(0x46554747).putInt(3).putLong(0).putLong(3);
+        string(b, "general.architecture").putInt(8);
+        string(b, "llama");
+        string(b, "tokenizer.ggml.tokens").putInt(9).putInt(8).putLong(2);
+        string(b, "a");
+        string(b, "b");
+        string(b, "llama.context_length").putInt(4).putInt(contextLength);
+        out.write(b.array(), 0, b.position());
+        return out.toByteArray();
+    }
+
+    private static ByteBuffer string(ByteBuffer b, String s) {
+        byte[] bytes = s.getBytes(StandardCharsets.UTF_8);
+        return b.putLong(bytes.length).put(bytes);
+    }
+
+    @Test
+    public void testReportsEveryProblemAtOnce() throws Exception {
+        Files.write(dir.resolve("small.gguf"), gguf(2048));
+
+        List<ConfigCommand.Problem> problems = ConfigCommand.validate(props(
+                "gguf.provider.model.base-path", dir.toString(),
+                "gguf.provider.prewarm.models", "small,missing",
+                "gguf.provider.max-context-tokens", "8192",
+                "gguf.provider.threads", "32",
+                "%prod.gguf.provider.threads", "0",
+                "gguf.provider.gpu.enabled", "yes",
+                "gguf.provider.session.pool.idle-timeout", "five minutes"), 8);
+
+        assertEquals(Set.of(
+                "error gguf.provider.gpu.enabled",
+                "error gguf.provider.session.pool.idle-timeout",
+                "warning gguf.provider.threads",
+                "error %prod.gguf.provider.threads",
+                "error gguf.provider.prewarm.models",
+                "warning gguf.provider.max-context-tokens"),
+                problems.stream().map(p -> p.severity() + " " + p.key()).collect(Collectors.toSet()));
+        assertEquals(6, problems.size());
+        assertTrue(problems.stream().anyMatch(p -> p.message().contains("missing")));
+        assertTrue(problems.stream().anyMatch(p -> p.message().contains("2048")));
+    }
+
+    @Test
+    public void testValidConfigHasNoProblems() throws Exception {
+        Files.write(dir.resolve("m.gguf"), gguf(32768));
+
+        assertEquals(List.of(), ConfigCommand.validate(props(
+                "gguf.provider.model.base-path", "${models:" + dir + "}",
+                "gollek.server.ready.models", "m.gguf",
+                "gguf.provider.threads", "4",
+                "gguf.provider.max-context-tokens", "8192",
+                "gguf.provider.session.pool.idle-timeout", "PT5M"), 8));
+    }
+
+    @Test
+    public void testReadsTrainingContextFromTheGgufHeader() throws Exception {
+        Path model = Files.write(dir.resolve("m.gguf"), gguf(4096));
+        Path notGguf = Files.writeString(dir.resolve("x.gguf"), "not a model");
+
+        assertEquals(4096L, ConfigCommand.trainingContext(model));
+        assertThrows(IOException.class, () -> ConfigCommand.trainingContext(notGguf));
+        assertEquals("prod.key", ConfigCommand.unprofiled("%test.prod.key"));
+    }
+}
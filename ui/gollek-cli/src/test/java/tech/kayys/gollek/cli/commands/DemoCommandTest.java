@@ -0,0 +1,28 @@
+package tech.kayys.gollek.cli.commands;
+
+import org.junit.jupiter.api.Test;
+
+import java.nio.file.Path;
+import java.util.List;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+public class DemoCommandTest {
+
+    @Test
+    public void testServerCommandCarriesDemoDefaults() {
+        DemoCommand demo = new DemoCommand();
+        demo.port = 9090;
+
+        List<String> command = demo.serverCommand(Path.of("/opt/gollek/quarkus-run.jar"), Path.of("/models"),
+                DemoCommand.DEMO_NAME);
+
+        assertTrue(command.contains("-Dquarkus.http.port=9090"));
+        assertTrue(command.contains("-Dgguf.provider.prewarm.models=gollek-demo"));
+        assertTrue(command.contains("-Dgollek.server.allowed-api-keys=community"));
+        assertEquals(Path.of("/opt/gollek/quarkus-run.jar").toAbsolutePath().toString(),
+                command.get(command.size() - 1));
+        assertEquals("-jar", command.get(command.size() - 2));
+    }
+}
@@ -0,0 +1,56 @@
+package tech.kayys.gollek.cli.commands;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.sdk.prompt.PromptTemplate;
+import tech.kayys.gollek.spi.provider.ProviderHealth;
+
+import java.util.List;
+import java.util.Map;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+public class TemplateCommandTest {
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    @Test
+    public void testUndefinedRequiredVariableIsAnError() {
+        var template = PromptTemplate.parse("Summarize {{ text }} for {{ audience | default(\"everyone\") }}");
+
+        var check = TemplateCommand.check(template, Map.of("txet", "typo"));
+
+        assertFalse(check.ok());
+        assertEquals(List.of("undefined variable: text"), check.errors());
+        assertEquals(List.of("variable not set, using its default: audience", "unused variable: txet"),
+                check.warnings());
+        assertTrue(TemplateCommand.check(template, Map.of("text", "t")).ok());
+    }
+
+    @Test
+    public void testVarsFileValues() throws Exception {
+        var values = TemplateCommand.values(mapper.readTree("{\"a\":\"x\",\"n\":3,\"list\":[1],\"gone\":null}"));
+
+        assertEquals(Map.of("a", "x", "n", "3", "list", "[1]"), values);
+        assertThrows(IllegalArgumentException.class, () -> TemplateCommand.values(mapper.readTree("[]")));
+    }
+
+    @Test
+    public void testSlotCapacityOfTheModel() {
+        var health = ProviderHealth.builder()
+                .status(ProviderHealth.Status.HEALTHY)
+                .detail("slots", List.of(
+                        Map.of("model", "/m/a.gguf", "slots", List.of(Map.of("capacity", 2048), Map.of("capacity", 4096))),
+                        Map.of("model", "/m/b.gguf", "slots", List.of(Map.of("capacity", 8192)))))
+                .build();
+
+        assertEquals(4096, TemplateCommand.slotCapacity(health, "/m/a.gguf"));
+        assertNull(TemplateCommand.slotCapacity(health, "/m/c.gguf"));
+        assertNull(TemplateCommand.slotCapacity(ProviderHealth.healthy(), "/m/a.gguf"));
+    }
+}
@@ -0,0 +1,53 @@
+package tech.kayys.gollek.server;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import org.eclipse.microprofile.metrics.MetricRegistry;
+import org.eclipse.microprofile.metrics.Tag;
+
+import tech.kayys.gollek.spi.exception.RunnerBusyException;
+
+import java.util.Map;
+
+/**
+ * Answers requests a runner turned away with 503 and the runner's {@code Retry-After}
+ * instead of a 500, counting them in {@code gollek.requests.rejected} by endpoint. How
+ * long a request waits for room before that happens is the runner's queue-wait budget.
+ */
+@ApplicationScoped
+public class Backpressure {
+
+    @Inject
+    MetricRegistry registry;
+
+    /** The {@link RunnerBusyException} anywhere in {@code e}'s causes, or null. */
+    public static RunnerBusyException busy(Throwable e) {
+        for (Throwable cause = e; cause != null; cause = cause.getCause() == cause ? null : cause.getCause()) {
+            if (cause instanceof RunnerBusyException busy) {
+                return busy;
+            }
+        }
+        return null;
+    }
+
+    /**
+     * The 503 for a request a runner turned away on {@code endpoint}, or null when
+     * {@code e} is some other failure.
+     */
+    public Response reject(Throwable e, String endpoint) {
+        RunnerBusyException busy = busy(e);
+        if (busy == null) {
+            return null;
+        }
+        if (registry != null) {
+            registry.counter("gollek.requests.rejected", new Tag("endpoint", endpoint)).inc();
+        }
+        return Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                .header("Retry-After", busy.retryAfterSeconds())
+                .type(MediaType.APPLICATION_JSON)
+                .entity(Map.of("error", String.valueOf(busy.getMessage()))).build();
+    }
+}
@@ -0,0 +1,53 @@
+package tech.kayys.gollek.server;
+
+import io.grpc.Context;
+import io.vertx.core.http.HttpServerRequest;
+
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+
+/**
+ * Ties a request's generation to its client's lifetime: whichever transport the client
+ * used, a disconnect cancels the request through {@link RequestCancellation} so the
+ * runner stops and frees its worker.
+ */
+public final class ClientCancellation {
+
+    private ClientCancellation() {
+    }
+
+    /**
+     * Cancel {@code requestIds} if the HTTP connection closes before the response is
+     * complete. The connection has one close handler, so pass every request the response
+     * depends on in one call.
+     */
+    public static void onDisconnect(HttpServerRequest http, String... requestIds) {
+        if (http == null || requestIds == null || requestIds.length == 0) {
+            return;
+        }
+        http.response().closeHandler(v -> {
+            if (!http.response().ended()) {
+                for (String requestId : requestIds) {
+                    if (requestId != null) {
+                        RequestCancellation.cancel(requestId);
+                    }
+                }
+            }
+        });
+    }
+
+    /**
+     * Cancel {@code requestId} when a gRPC call is cancelled or its deadline expires.
+     * Capture {@code context} with {@link Context#current()} on the call's thread.
+     */
+    public static void onGrpcCancel(Context context, String requestId) {
+        if (requestId == null || context == null || context == Context.ROOT) {
+            return;
+        }
+        context.addListener(ctx -> {
+            // normal completion also cancels the call context, but without a cause
+            if (ctx.cancellationCause() != null) {
+                RequestCancellation.cancel(requestId);
+            }
+        }, Runnable::run);
+    }
+}
@@ -0,0 +1,16 @@
+package tech.kayys.gollek.server;
+
+import java.util.Map;
+import java.util.Optional;
+
+/**
+ * CDI event fired by {@link ConfigReload} with the reloadable settings whose value changed
+ * in the config file, new values keyed by property name. Beans owning one of them observe
+ * it and apply the value in place.
+ */
+public record ConfigChanged(Map<String, String> values) {
+
+    public Optional<String> get(String key) {
+        return Optional.ofNullable(values.get(key));
+    }
+}
@@ -0,0 +1,70 @@
+package tech.kayys.gollek.server;
+
+import java.util.LinkedHashMap;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Properties;
+
+/**
+ * Environment and system property overrides for config keys, following the MicroProfile
+ * Config rules: {@code -Dgollek.server.sampling.top-p=0.8} beats
+ * {@code GOLLEK_SERVER_SAMPLING_TOP_P=0.8}, which beats the config file, which beats the
+ * default. An environment variable matches a key as written, with every non-alphanumeric
+ * character replaced by {@code _}, or that form upper-cased.
+ *
+ * <p>Quarkus applies these rules itself when a key is looked up by name. This class is
+ * for the places that cannot look up by name: prefix scans such as
+ * {@code gollek.server.sampling.*}, where {@code getPropertyNames()} reports an
+ * environment variable only as {@code GOLLEK_SERVER_SAMPLING_TOP_P}, and {@link ConfigReload},
+ * which reads the file directly.
+ */
+public final class ConfigOverrides {
+
+    private ConfigOverrides() {
+    }
+
+    /** {@code gollek.server.model-cache.dir} becomes {@code GOLLEK_SERVER_MODEL_CACHE_DIR}. */
+    public static String envName(String key) {
+        return key.replaceAll("[^A-Za-z0-9]", "_").toUpperCase(Locale.ROOT);
+    }
+
+    /** The system property or environment variable that overrides {@code key}, if any. */
+    public static Optional<String> override(String key, Properties system, Map<String, String> env) {
+        String value = system.getProperty(key);
+        if (value == null) {
+            value = env.get(key);
+        }
+        if (value == null) {
+            value = env.get(key.replaceAll("[^A-Za-z0-9]", "_"));
+        }
+        if (value == null) {
+            value = env.get(envName(key));
+        }
+        return Optional.ofNullable(value);
+    }
+
+    /** The effective value of {@code key}: system property, environment, then the file. */
+    public static Optional<String> resolve(String key, Properties system, Map<String, String> env,
+            Properties file) {
+        return override(key, system, env).or(() -> Optional.ofNullable(file.getProperty(key)));
+    }
+
+    /**
+     * Keys under {@code prefix} (ending in {@code .}) set only through the environment, with
+     * their values. Word separators in the rest of the name become {@code -}, the form this
+     * server's keys use: {@code GOLLEK_SERVER_SAMPLING_REPEAT_PENALTY} is
+     * {@code gollek.server.sampling.repeat-penalty}.
+     */
+    public static Map<String, String> withPrefix(String prefix, Map<String, String> env) {
+        String envPrefix = envName(prefix);
+        Map<String, String> values = new LinkedHashMap<>();
+        env.forEach((name, value) -> {
+            if (name.length() > envPrefix.length() && name.startsWith(envPrefix)) {
+                String rest = name.substring(envPrefix.length()).toLowerCase(Locale.ROOT).replace('_', '-');
+                values.put(prefix + rest, value);
+            }
+        });
+        return values;
+    }
+}
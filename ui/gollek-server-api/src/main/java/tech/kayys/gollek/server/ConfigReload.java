@@ -0,0 +1,227 @@
+package tech.kayys.gollek.server;
+
+import io.quarkus.runtime.StartupEvent;
+import io.quarkus.scheduler.Scheduled;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Event;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.io.Reader;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.attribute.FileTime;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Properties;
+import java.util.Set;
+import java.util.TreeSet;
+import java.util.regex.Pattern;
+
+/**
+ * Applies edits to the config file without a restart. When enabled, the file is re-read
+ * when it changes on disk and on {@code SIGHUP}; settings that can change at runtime (log
+ * levels, rate limits, the request timeout, sampling defaults) are applied through a
+ * {@link ConfigChanged} event, and everything else that changed is logged as needing a
+ * restart. Environment variables and system properties still win over the file, as at
+ * startup.
+ */
+@ApplicationScoped
+public class ConfigReload {
+
+    private static final Logger LOG = Logger.getLogger(ConfigReload.class);
+
+    /** Settings applied in place; anything else needs a restart. */
+    static final List<Pattern> RELOADABLE = List.of(
+            Pattern.compile("quarkus\\.log\\.level"),
+            Pattern.compile("quarkus\\.log\\.category\\.\"?[^\"]+\"?\\.level"),
+            Pattern.compile("gollek\\.server\\.rate-limit\\.(enabled|per-key\\.(rps|burst)|per-ip\\.(rps|burst))"),
+            Pattern.compile("gollek\\.server\\.request-timeout-ms"),
+            Pattern.compile("gollek\\.server\\.timeout\\.(queue|prefill|inter-token)-ms"),
+            Pattern.compile("gollek\\.server\\.sampling\\.[a-z-]+"));
+
+    private static final Pattern CATEGORY_LEVEL = Pattern.compile("quarkus\\.log\\.category\\.\"?([^\"]+)\"?\\.level");
+    private static final Pattern SECRET = Pattern.compile("secret|password|token|api-keys|key-file|\\.key$");
+
+    /** What a reload found: settings applied in place and ones that need a restart. */
+    public record Result(Map<String, String> applied, Set<String> restartRequired) {
+    }
+
+    @ConfigProperty(name = "gollek.server.config.reload.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.config.reload.file", defaultValue = "config/application.properties")
+    String file;
+
+    @Inject
+    Event<ConfigChanged> changed;
+
+    private Properties current = new Properties();
+    private FileTime lastModified;
+
+    void onStart(@Observes StartupEvent event) {
+        if (!enabled) {
+            return;
+        }
+        Path path = Path.of(file);
+        try {
+            current = read(path);
+            lastModified = Files.exists(path) ? Files.getLastModifiedTime(path) : null;
+        } catch (IOException e) {
+            LOG.warnf("Cannot read %s for config reload: %s", path, e.getMessage());
+        }
+        try {
+            sun.misc.Signal.handle(new sun.misc.Signal("HUP"), signal -> reload());
+            LOG.infof("Config reload watching %s; send SIGHUP to reload now", path.toAbsolutePath());
+        } catch (IllegalArgumentException | UnsupportedOperationException e) {
+            // no SIGHUP on Windows; the file watch still works
+            LOG.infof("Config reload watching %s", path.toAbsolutePath());
+        }
+    }
+
+    @Scheduled(every = "${gollek.server.config.reload.poll-every:5s}",
+            concurrentExecution = Scheduled.ConcurrentExecution.SKIP)
+    void poll() {
+        if (!enabled) {
+            return;
+        }
+        Path path = Path.of(file);
+        try {
+            FileTime modified = Files.exists(path) ? Files.getLastModifiedTime(path) : null;
+            if (modified != null && !modified.equals(lastModified)) {
+                reload();
+            }
+        } catch (IOException e) {
+            LOG.debugf("Config reload cannot stat %s: %s", path, e.getMessage());
+        }
+    }
+
+    /** Re-reads the file and applies what changed. */
+    public synchronized Result reload() {
+        Path path = Path.of(file);
+        Properties next;
+        try {
+            lastModified = Files.exists(path) ? Files.getLastModifiedTime(path) : null;
+            next = read(path);
+        } catch (IOException e) {
+            LOG.warnf("Config reload failed to read %s: %s", path, e.getMessage());
+            return new Result(Map.of(), Set.of());
+        }
+        Result result = withoutOverrides(diff(current, next), System.getProperties(), System.getenv());
+        String description = describe(current, result);
+        current = next;
+        if (result.applied().isEmpty() && result.restartRequired().isEmpty()) {
+            LOG.debugf("Config reload: %s unchanged", path);
+            return result;
+        }
+        applyLogLevels(result.applied());
+        if (!result.applied().isEmpty()) {
+            try {
+                changed.fire(new ConfigChanged(result.applied()));
+            } catch (RuntimeException e) {
+                LOG.warnf(e, "Config reload could not apply every setting from %s", path);
+            }
+        }
+        LOG.infof("Config reloaded from %s: %s", path, description);
+        return result;
+    }
+
+    /**
+     * Settings that differ between {@code before} and {@code after}: reloadable ones with
+     * their new value, the rest (removals included) by name only.
+     */
+    static Result diff(Properties before, Properties after) {
+        Map<String, String> applied = new LinkedHashMap<>();
+        Set<String> restart = new TreeSet<>();
+        Set<String> keys = new TreeSet<>(before.stringPropertyNames());
+        keys.addAll(after.stringPropertyNames());
+        for (String key : keys) {
+            String old = before.getProperty(key);
+            String now = after.getProperty(key);
+            if (now != null && now.equals(old)) {
+                continue;
+            }
+            if (now != null && reloadable(key)) {
+                applied.put(key, now);
+            } else {
+                restart.add(key);
+            }
+        }
+        return new Result(applied, restart);
+    }
+
+    /**
+     * Drops applied settings that a system property or environment variable overrides:
+     * editing the file does not change their effective value.
+     */
+    static Result withoutOverrides(Result result, Properties system, Map<String, String> env) {
+        Map<String, String> applied = new LinkedHashMap<>();
+        result.applied().forEach((key, value) -> {
+            if (ConfigOverrides.override(key, system, env).isPresent()) {
+                LOG.infof("Config reload: %s is overridden by a system property or %s, keeping it",
+                        key, ConfigOverrides.envName(key));
+            } else {
+                applied.put(key, value);
+            }
+        });
+        return new Result(applied, result.restartRequired());
+    }
+
+    static boolean reloadable(String key) {
+        return RELOADABLE.stream().anyMatch(p -> p.matcher(key).matches());
+    }
+
+    /** The diff for the log, {@code key: old -> new}, with secrets masked. */
+    static String describe(Properties before, Result result) {
+        List<String> parts = new ArrayList<>();
+        result.applied().forEach((key, value) -> parts.add(key + ": "
+                + mask(key, before.getProperty(key, "(default)")) + " -> " + mask(key, value)));
+        String text = parts.isEmpty() ? "nothing applied" : "applied " + String.join(", ", parts);
+        if (!result.restartRequired().isEmpty()) {
+            text += "; restart required for " + String.join(", ", result.restartRequired());
+        }
+        return text;
+    }
+
+    static String mask(String key, String value) {
+        return SECRET.matcher(key.toLowerCase(Locale.ROOT)).find() ? "***" : value;
+    }
+
+    private static void applyLogLevels(Map<String, String> applied) {
+        applied.forEach((key, value) -> {
+            String category;
+            if (key.equals("quarkus.log.level")) {
+                category = "";
+            } else {
+                var m = CATEGORY_LEVEL.matcher(key);
+                if (!m.matches()) {
+                    return;
+                }
+                category = m.group(1);
+            }
+            try {
+                java.util.logging.Logger.getLogger(category)
+                        .setLevel(org.jboss.logmanager.Level.parse(value.trim().toUpperCase(Locale.ROOT)));
+            } catch (IllegalArgumentException e) {
+                LOG.warnf("Ignoring %s=%s: %s", key, value, e.getMessage());
+            }
+        });
+    }
+
+    private static Properties read(Path path) throws IOException {
+        Properties props = new Properties();
+        if (Files.exists(path)) {
+            try (Reader reader = Files.newBufferedReader(path)) {
+                props.load(reader);
+            }
+        }
+        return props;
+    }
+}
@@ -0,0 +1,252 @@
+package tech.kayys.gollek.server;
+
+import io.quarkus.runtime.Quarkus;
+import io.quarkus.runtime.ShutdownEvent;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.admission.AdmissionController;
+import tech.kayys.gollek.server.cluster.LeaderElection;
+import tech.kayys.gollek.server.models.ModelBackends;
+
+import java.time.Duration;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.concurrent.atomic.AtomicInteger;
+
+/**
+ * Liveness and readiness for orchestrators. Live only means the process answers, so it
+ * holds while models are still loading; ready means the required models are loaded, the
+ * worker pool has room (when admission control is on) and the server is not stopping.
+ *
+ * <p>The required models are {@code gollek.server.ready.models}, defaulting to the
+ * provider's prewarm list when prewarming is enabled; with neither, a server that loads
+ * models on demand is ready as soon as it is up.
+ *
+ * <p>Inference requests and streams are counted in with {@link #enter()} and out with
+ * {@link #exit()}. Draining ({@link #drain}) turns new ones away and fails readiness
+ * while those in flight finish, up to a deadline. Shutdown waits for them the same way
+ * for {@code gollek.server.drain.timeout}: Quarkus already lets open HTTP requests,
+ * SSE streams included, finish within {@code quarkus.shutdown.timeout}, but not
+ * WebSocket streams.
+ */
+@ApplicationScoped
+public class Lifecycle {
+
+    private static final Logger LOG = Logger.getLogger(Lifecycle.class);
+
+    @ConfigProperty(name = "gollek.server.ready.models")
+    Optional<List<String>> readyModels;
+
+    @ConfigProperty(name = "gguf.provider.prewarm.enabled", defaultValue = "false")
+    boolean prewarmEnabled;
+
+    @ConfigProperty(name = "gguf.provider.prewarm.models")
+    Optional<List<String>> prewarmModels;
+
+    @Inject
+    ModelBackends modelBackends;
+
+    @Inject
+    AdmissionController admission;
+
+    @Inject
+    LeaderElection leaderElection;
+
+    @ConfigProperty(name = "gollek.server.drain.timeout", defaultValue = "30s")
+    Duration drainTimeout;
+
+    private volatile boolean stopping;
+    private final AtomicInteger inFlight = new AtomicInteger();
+    private final Object idle = new Object();
+    /** Start and deadline of the current drain; null when not draining. */
+    private volatile Instant drainingSince;
+    private volatile Instant drainDeadline;
+
+    /** Whether the server can take traffic, and the checks that decided it. */
+    public record Readiness(boolean ready, Map<String, Object> checks, List<String> reasons) {
+    }
+
+    public Readiness readiness() {
+        Map<String, Object> checks = new LinkedHashMap<>();
+        List<String> reasons = new ArrayList<>();
+        checks.put("stopping", stopping);
+        if (stopping) {
+            reasons.add("server is shutting down");
+        }
+        checks.put("draining", isDraining());
+        if (isDraining()) {
+            reasons.add("server is draining");
+        }
+
+        List<String> required = requiredModels();
+        if (!required.isEmpty()) {
+            ModelBackends.Snapshot snapshot = modelBackends.snapshot();
+            List<String> missing = required.stream().filter(m -> snapshot.backendOf(m) == null).toList();
+            checks.put("models_required", required);
+            checks.put("models_missing", missing);
+            if (!missing.isEmpty()) {
+                reasons.add("models not loaded: " + String.join(", ", missing));
+            }
+        }
+
+        if (admission.isEnabled()) {
+            Object available = admission.status().get("available");
+            checks.put("slots_available", available);
+            if (available instanceof Number n && n.intValue() <= 0) {
+                reasons.add("no worker slot available");
+            }
+        }
+        if (leaderElection.isEnabled()) {
+            // informational: followers take traffic too
+            checks.put("leader", leaderElection.isLeader());
+        }
+        return new Readiness(reasons.isEmpty(), checks, reasons);
+    }
+
+    List<String> requiredModels() {
+        if (readyModels.isPresent()) {
+            return readyModels.get();
+        }
+        return prewarmEnabled ? prewarmModels.orElse(List.of()) : List.of();
+    }
+
+    public boolean isStopping() {
+        return stopping;
+    }
+
+    /** Fails readiness, then exits the application; in-flight requests get the shutdown timeout. */
+    public void shutdown(String cause) {
+        LOG.infof("Shutdown requested: %s", cause);
+        stopping = true;
+        Quarkus.asyncExit();
+    }
+
+    public Duration drainTimeout() {
+        return drainTimeout;
+    }
+
+    public boolean isDraining() {
+        return drainingSince != null;
+    }
+
+    /**
+     * Counts an inference request or stream in. Returns false, counting nothing, while
+     * the server drains or stops; otherwise the caller must {@link #exit()} when it ends.
+     */
+    public boolean enter() {
+        if (stopping || drainingSince != null) {
+            return false;
+        }
+        inFlight.incrementAndGet();
+        return true;
+    }
+
+    public void exit() {
+        if (inFlight.decrementAndGet() <= 0) {
+            synchronized (idle) {
+                idle.notifyAll();
+            }
+        }
+    }
+
+    public int inFlight() {
+        return Math.max(0, inFlight.get());
+    }
+
+    /**
+     * Stops taking inference requests and lets those in flight finish within
+     * {@code timeout}; with {@code shutdown}, the server then exits, whether or not they
+     * did. Draining again while a drain is on only reports it.
+     */
+    public synchronized Map<String, Object> drain(Duration timeout, boolean shutdown) {
+        if (drainingSince == null) {
+            Instant since = Instant.now();
+            drainingSince = since;
+            drainDeadline = since.plus(timeout);
+            LOG.infof("Draining %d in-flight request(s) for up to %s", inFlight(), timeout);
+            Thread.ofVirtual().name("gollek-drain").start(() -> {
+                boolean drained = awaitIdle(drainDeadline);
+                if (!since.equals(drainingSince)) {
+                    return;
+                }
+                if (drained) {
+                    LOG.info("Drained");
+                } else {
+                    LOG.warnf("Drain deadline passed with %d request(s) in flight", inFlight());
+                }
+                if (shutdown) {
+                    shutdown("drain");
+                }
+            });
+        }
+        return drainStatus();
+    }
+
+    /** Takes requests again; a pending drain no longer shuts the server down. */
+    public synchronized void resume() {
+        if (drainingSince != null) {
+            LOG.info("Drain cancelled");
+        }
+        drainingSince = null;
+        drainDeadline = null;
+        synchronized (idle) {
+            idle.notifyAll();
+        }
+    }
+
+    public Map<String, Object> drainStatus() {
+        Map<String, Object> status = new LinkedHashMap<>();
+        Instant since = drainingSince;
+        Instant deadline = drainDeadline;
+        status.put("draining", since != null);
+        status.put("in_flight", inFlight());
+        if (since != null && deadline != null) {
+            status.put("since", since.toString());
+            status.put("deadline", deadline.toString());
+            status.put("drained", inFlight() == 0);
+        }
+        return status;
+    }
+
+    /**
+     * Waits until nothing is in flight; false when {@code deadline} passes first or the
+     * drain is cancelled.
+     */
+    boolean awaitIdle(Instant deadline) {
+        Instant since = drainingSince;
+        synchronized (idle) {
+            while (inFlight.get() > 0) {
+                long remaining = Duration.between(Instant.now(), deadline).toMillis();
+                if (remaining <= 0 || (since != null && drainingSince != since)) {
+                    return false;
+                }
+                try {
+                    idle.wait(remaining);
+                } catch (InterruptedException e) {
+                    Thread.currentThread().interrupt();
+                    return false;
+                }
+            }
+            return true;
+        }
+    }
+
+    void onStop(@Observes ShutdownEvent event) {
+        stopping = true;
+        if (inFlight() > 0) {
+            LOG.infof("Waiting up to %s for %d in-flight request(s)", drainTimeout, inFlight());
+            if (!awaitIdle(Instant.now().plus(drainTimeout))) {
+                LOG.warnf("Stopping with %d request(s) still in flight", inFlight());
+            }
+        }
+    }
+}
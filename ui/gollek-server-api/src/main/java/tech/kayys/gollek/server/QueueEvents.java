@@ -0,0 +1,44 @@
+package tech.kayys.gollek.server;
+
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+
+import java.util.LinkedHashMap;
+import java.util.Map;
+
+/**
+ * Opt-in queue status for streaming clients. With the {@value #HEADER} header (or the
+ * {@value #PARAMETER} request parameter) set, a runner that has to queue the request emits
+ * empty-delta chunks whose {@code queue} metadata holds the position and estimated start
+ * until generation begins.
+ */
+public final class QueueEvents {
+
+    public static final String HEADER = "X-Gollek-Queue-Events";
+    public static final String PARAMETER = "queue_events";
+
+    private QueueEvents() {
+    }
+
+    /**
+     * Sets {@value #PARAMETER} on the request when {@code headerValue} is {@code true}.
+     */
+    public static InferenceRequest apply(InferenceRequest request, String headerValue) {
+        if (request == null || !Boolean.parseBoolean(headerValue)) {
+            return request;
+        }
+        return request.toBuilder().parameter(PARAMETER, true).build();
+    }
+
+    /**
+     * The queue status carried by {@code chunk}, or null for ordinary chunks.
+     */
+    public static Map<String, Object> status(StreamingInferenceChunk chunk) {
+        if (chunk == null || chunk.metadata() == null || !(chunk.metadata().get("queue") instanceof Map<?, ?> queue)) {
+            return null;
+        }
+        Map<String, Object> out = new LinkedHashMap<>();
+        queue.forEach((k, v) -> out.put(String.valueOf(k), v));
+        return out;
+    }
+}
@@ -0,0 +1,134 @@
+package tech.kayys.gollek.server;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import tech.kayys.gollek.spi.exception.InferenceTimeoutException;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.Map;
+
+/**
+ * Server-wide bounds on how long a request may take, per stage, since a hang looks
+ * different at each one:
+ *
+ * <ul>
+ * <li>{@value #QUEUE_TIMEOUT} ({@code gollek.server.timeout.queue-ms}): waiting for the
+ * runner to take the request; fails with {@code 503}.</li>
+ * <li>{@value #PREFILL_TIMEOUT} ({@code gollek.server.timeout.prefill-ms}): processing the
+ * prompt, up to the first token; fails with {@code 504}.</li>
+ * <li>{@value #INTER_TOKEN_TIMEOUT} ({@code gollek.server.timeout.inter-token-ms}): the
+ * longest gap between two tokens; ends generation with finish reason {@code stalled} and
+ * the output so far.</li>
+ * <li>{@value #TIMEOUT} ({@code gollek.server.request-timeout-ms}): the whole request;
+ * fails with {@code 504}, or ends with {@code timeout} when partial output was asked for.</li>
+ * </ul>
+ *
+ * A client's value for each only shortens the server's; a server value of 0 leaves that
+ * stage unbounded except by the total. A client's {@value #MAX_TIME} is capped at the
+ * total; when it runs out the runner stops generating and returns what it has with finish
+ * reason {@code time_limit}.
+ */
+@ApplicationScoped
+public class RequestTimeout {
+
+    public static final String MAX_TIME = "max_time_ms";
+    public static final String TIMEOUT = "inference_timeout_ms";
+    public static final String QUEUE_TIMEOUT = "queue_timeout_ms";
+    public static final String PREFILL_TIMEOUT = "prefill_timeout_ms";
+    public static final String INTER_TOKEN_TIMEOUT = "inter_token_timeout_ms";
+
+    @ConfigProperty(name = "gollek.server.request-timeout-ms", defaultValue = "120000")
+    volatile long requestTimeoutMs;
+
+    @ConfigProperty(name = "gollek.server.timeout.queue-ms", defaultValue = "0")
+    volatile long queueTimeoutMs;
+
+    @ConfigProperty(name = "gollek.server.timeout.prefill-ms", defaultValue = "0")
+    volatile long prefillTimeoutMs;
+
+    @ConfigProperty(name = "gollek.server.timeout.inter-token-ms", defaultValue = "0")
+    volatile long interTokenTimeoutMs;
+
+    void onConfigChanged(@Observes ConfigChanged event) {
+        event.get("gollek.server.request-timeout-ms").ifPresent(v -> requestTimeoutMs = Long.parseLong(v.trim()));
+        event.get("gollek.server.timeout.queue-ms").ifPresent(v -> queueTimeoutMs = Long.parseLong(v.trim()));
+        event.get("gollek.server.timeout.prefill-ms").ifPresent(v -> prefillTimeoutMs = Long.parseLong(v.trim()));
+        event.get("gollek.server.timeout.inter-token-ms")
+                .ifPresent(v -> interTokenTimeoutMs = Long.parseLong(v.trim()));
+    }
+
+    /**
+     * @throws IllegalArgumentException if {@value #MAX_TIME} or a stage timeout is not a
+     *         positive number
+     */
+    public InferenceRequest apply(InferenceRequest request) {
+        if (request == null) {
+            return null;
+        }
+        Object timeoutValue = request.getParameters().get(TIMEOUT);
+        long timeout = timeoutValue instanceof Number t && t.longValue() > 0
+                ? Math.min(t.longValue(), requestTimeoutMs)
+                : requestTimeoutMs;
+        var builder = request.toBuilder().parameter(TIMEOUT, timeout);
+        Object maxTime = request.getParameters().get(MAX_TIME);
+        if (maxTime != null) {
+            if (!(maxTime instanceof Number limit) || limit.longValue() <= 0) {
+                throw new IllegalArgumentException(MAX_TIME + " must be a positive number of milliseconds");
+            }
+            builder.parameter(MAX_TIME, Math.min(limit.longValue(), timeout));
+        }
+        stage(request, builder, QUEUE_TIMEOUT, queueTimeoutMs, timeout);
+        stage(request, builder, PREFILL_TIMEOUT, prefillTimeoutMs, timeout);
+        stage(request, builder, INTER_TOKEN_TIMEOUT, interTokenTimeoutMs, timeout);
+        return builder.build();
+    }
+
+    /** Sets {@code name} to the client's value, the server's, or the lower of both, within the total. */
+    private static void stage(InferenceRequest request, InferenceRequest.Builder builder, String name,
+            long serverMs, long timeout) {
+        Object value = request.getParameters().get(name);
+        long ms = serverMs > 0 ? Math.min(serverMs, timeout) : 0;
+        if (value != null) {
+            if (!(value instanceof Number client) || client.longValue() <= 0) {
+                throw new IllegalArgumentException(name + " must be a positive number of milliseconds");
+            }
+            ms = Math.min(client.longValue(), ms > 0 ? ms : timeout);
+        }
+        if (ms > 0) {
+            builder.parameter(name, ms);
+        }
+    }
+
+    /** The {@link InferenceTimeoutException} anywhere in {@code e}'s causes, or null. */
+    public static InferenceTimeoutException timedOut(Throwable e) {
+        for (Throwable cause = e; cause != null; cause = cause.getCause() == cause ? null : cause.getCause()) {
+            if (cause instanceof InferenceTimeoutException timeout) {
+                return timeout;
+            }
+        }
+        return null;
+    }
+
+    /**
+     * The {@code 503} (queue) or {@code 504} (prefill, total) for a request that ran out of
+     * a time budget, naming the stage in {@code timeout}; null when {@code e} is some other
+     * failure.
+     */
+    public static Response reject(Throwable e) {
+        InferenceTimeoutException timeout = timedOut(e);
+        if (timeout == null) {
+            return null;
+        }
+        return Response.status(timeout.getErrorCode().getHttpStatus())
+                .type(MediaType.APPLICATION_JSON)
+                .entity(Map.of("error", String.valueOf(timeout.getMessage()),
+                        "code", timeout.getErrorCode().getCode(),
+                        "timeout", timeout.stage().name().toLowerCase()))
+                .build();
+    }
+}
@@ -0,0 +1,404 @@
+package tech.kayys.gollek.server;
+
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.SerializationFeature;
+import io.quarkus.redis.datasource.RedisDataSource;
+import jakarta.annotation.PostConstruct;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.eclipse.microprofile.metrics.MetricRegistry;
+import org.eclipse.microprofile.metrics.Tag;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.admission.PriorityPolicy;
+import tech.kayys.gollek.server.security.AtRestCipher;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+
+import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.HexFormat;
+import java.util.Iterator;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+import java.util.TreeMap;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Answers repeated deterministic requests from a cache instead of the model. A request is
+ * deterministic when it samples greedily ({@code temperature} 0) or fixes a {@code seed};
+ * its key is the SHA-256 of the model, messages, tools and parameters, with whitespace
+ * around message content and the parameters that only bound or schedule a request
+ * (timeouts, priority, queue events) left out.
+ *
+ * <p>Entries live in process, least recently used first out past {@code max-entries}, or
+ * with {@code backend=redis} in Redis so replicas share them; either way they expire after
+ * {@code ttl}. With {@code mode=semantic} a request that misses can also take the entry of
+ * an earlier one with the same parameters whose prompt embeds within
+ * {@code semantic.threshold} cosine similarity, using {@code semantic.model}; that index
+ * is kept per instance.
+ *
+ * <p>Entries are per API key ({@code scope=api-key}): one tenant never gets an answer
+ * cached for another, whose prompt (or, in semantic mode, a merely similar one) may hold
+ * that tenant's data. {@code scope=shared} pools them, for single-tenant deployments.
+ *
+ * <p>A request's {@code Cache-Control: no-cache} skips the lookup but stores the fresh
+ * answer; {@code no-store} bypasses the cache altogether. Lookups are counted in
+ * {@code gollek.response_cache.requests}, tagged {@code hit}, {@code miss} or
+ * {@code bypass}.
+ *
+ * <p>With a storage encryption key configured, answers kept in Redis are AES-GCM
+ * encrypted; the local cache is process memory and holds them in the clear.
+ */
+@ApplicationScoped
+public class ResponseCache {
+
+    private static final Logger LOG = Logger.getLogger(ResponseCache.class);
+
+    public static final String HEADER = "X-Gollek-Cache";
+
+    // bound or schedule a request without changing its output
+    private static final Set<String> VOLATILE = Set.of(RequestTimeout.TIMEOUT, RequestTimeout.MAX_TIME,
+            RequestTimeout.QUEUE_TIMEOUT, RequestTimeout.PREFILL_TIMEOUT, RequestTimeout.INTER_TOKEN_TIMEOUT,
+            QueueEvents.PARAMETER, PriorityPolicy.PARAMETER);
+
+    // finish reasons of an answer worth repeating
+    private static final Set<InferenceResponse.FinishReason> COMPLETE = Set.of(InferenceResponse.FinishReason.STOP,
+            InferenceResponse.FinishReason.LENGTH, InferenceResponse.FinishReason.TOOL_CALLS);
+
+    @ConfigProperty(name = "gollek.server.response-cache.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.response-cache.backend", defaultValue = "local")
+    String backend;
+
+    @ConfigProperty(name = "gollek.server.response-cache.ttl", defaultValue = "10m")
+    Duration ttl;
+
+    @ConfigProperty(name = "gollek.server.response-cache.max-entries", defaultValue = "1000")
+    int maxEntries;
+
+    @ConfigProperty(name = "gollek.server.response-cache.mode", defaultValue = "exact")
+    String mode;
+
+    /** {@code api-key} or {@code shared}. */
+    @ConfigProperty(name = "gollek.server.response-cache.scope", defaultValue = "api-key")
+    String scope;
+
+    @ConfigProperty(name = "gollek.server.response-cache.semantic.model")
+    Optional<String> semanticModel;
+
+    @ConfigProperty(name = "gollek.server.response-cache.semantic.threshold", defaultValue = "0.95")
+    double semanticThreshold;
+
+    @ConfigProperty(name = "gollek.server.response-cache.redis.prefix", defaultValue = "gollek:rc")
+    String redisPrefix;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key")
+    Optional<String> encryptionKey;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key-file")
+    Optional<String> encryptionKeyFile;
+
+    @Inject
+    Instance<RedisDataSource> redis;
+
+    @Inject
+    MetricRegistry registry;
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    ObjectMapper mapper = new ObjectMapper().configure(SerializationFeature.ORDER_MAP_ENTRIES_BY_KEYS, true);
+    private AtRestCipher cipher;
+
+    @PostConstruct
+    void init() {
+        try {
+            cipher = AtRestCipher.fromConfig(encryptionKey, encryptionKeyFile).orElse(null);
+        } catch (IOException e) {
+            throw new IllegalStateException("Failed to read storage encryption key: " + e.getMessage(), e);
+        }
+    }
+
+    private record Entry(String value, long expiresAtMillis) {
+    }
+
+    /** An earlier request's prompt embedding and its cache key. */
+    private record Indexed(float[] embedding, String key) {
+    }
+
+    private final Map<String, Entry> local = new LinkedHashMap<>(16, 0.75f, true);
+    // semantic index per parameter scope
+    private final Map<String, List<Indexed>> index = new ConcurrentHashMap<>();
+
+    /** A cacheable request: the entry it hit, if any, and where to store its answer. */
+    public final class Lookup {
+        private final String key;
+        private final String scope;
+        private final String text;
+        private final boolean store;
+        private final String hit;
+        private float[] embedding;
+
+        private Lookup(String key, String scope, String text, boolean store, String hit, float[] embedding) {
+            this.key = key;
+            this.scope = scope;
+            this.text = text;
+            this.store = store;
+            this.hit = hit;
+            this.embedding = embedding;
+        }
+
+        public boolean isHit() {
+            return hit != null;
+        }
+
+        /** The cached answer, or null on a miss. */
+        public String hit() {
+            return hit;
+        }
+
+        /** Caches {@code value} as the answer when the request allows it. */
+        public void store(String value) {
+            if (!store || value == null) {
+                return;
+            }
+            put(key, value);
+            if (semantic()) {
+                if (embedding == null) {
+                    embedding = embed(text);
+                }
+                if (embedding != null) {
+                    List<Indexed> entries = index.computeIfAbsent(scope, s -> new ArrayList<>());
+                    synchronized (entries) {
+                        entries.removeIf(e -> e.key().equals(key));
+                        entries.add(new Indexed(embedding, key));
+                        while (entries.size() > maxEntries) {
+                            entries.remove(0);
+                        }
+                    }
+                }
+            }
+        }
+    }
+
+    public boolean isEnabled() {
+        return enabled;
+    }
+
+    /**
+     * Looks {@code request}, asking for {@code choices} choices, up. Returns null when the
+     * cache is off, the request is not deterministic or {@code cacheControl} says
+     * {@code no-store}.
+     */
+    public Lookup lookup(InferenceRequest request, int choices, String cacheControl) {
+        if (!enabled || request == null) {
+            return null;
+        }
+        String directives = cacheControl == null ? "" : cacheControl.toLowerCase(Locale.ROOT);
+        if (request.isCacheBypass() || directives.contains("no-store") || !deterministic(request)) {
+            count("bypass");
+            return null;
+        }
+        String key;
+        String scope;
+        try {
+            key = hash(canonical(request, choices, true));
+            scope = hash(canonical(request, choices, false));
+        } catch (JsonProcessingException e) {
+            LOG.debugf("Request %s has no stable cache key: %s", request.getRequestId(), e.getMessage());
+            count("bypass");
+            return null;
+        }
+        String text = prompt(request);
+        if (directives.contains("no-cache")) {
+            count("miss");
+            return new Lookup(key, scope, text, true, null, null);
+        }
+        String hit = get(key);
+        float[] embedding = null;
+        if (hit == null && semantic()) {
+            embedding = embed(text);
+            hit = nearest(scope, embedding);
+        }
+        count(hit != null ? "hit" : "miss");
+        return new Lookup(key, scope, text, true, hit, embedding);
+    }
+
+    /** Whether {@code responses} all finished on their own and may be repeated. */
+    public static boolean cacheable(List<InferenceResponse> responses) {
+        return responses.stream().allMatch(r -> r != null && COMPLETE.contains(r.getFinishReason()));
+    }
+
+    static boolean deterministic(InferenceRequest request) {
+        Map<String, Object> parameters = request.getParameters();
+        if (parameters.get("seed") instanceof Number seed && seed.longValue() >= 0) {
+            return true;
+        }
+        return parameters.get("temperature") instanceof Number temperature && temperature.doubleValue() == 0;
+    }
+
+    /** The normalized request, with or without its messages, as sorted JSON. */
+    String canonical(InferenceRequest request, int choices, boolean withMessages) throws JsonProcessingException {
+        Map<String, Object> canonical = new TreeMap<>();
+        canonical.put("model", request.getModel());
+        canonical.put("n", choices);
+        if (!"shared".equalsIgnoreCase(scope)) {
+            canonical.put("tenant", request.getApiKey() == null ? "" : hash(request.getApiKey()));
+        }
+        Map<String, Object> parameters = new TreeMap<>(request.getParameters());
+        parameters.keySet().removeAll(VOLATILE);
+        canonical.put("parameters", parameters);
+        if (request.getTools() != null && !request.getTools().isEmpty()) {
+            canonical.put("tools", request.getTools());
+        }
+        if (request.getToolChoice() != null) {
+            canonical.put("tool_choice", request.getToolChoice());
+        }
+        if (withMessages) {
+            List<Map<String, String>> messages = new ArrayList<>();
+            for (Message message : request.getMessages()) {
+                messages.add(Map.of("role", message.getRole().name().toLowerCase(Locale.ROOT),
+                        "content", message.getContent() == null ? "" : message.getContent().strip()));
+            }
+            canonical.put("messages", messages);
+        }
+        return mapper.writeValueAsString(canonical);
+    }
+
+    static String hash(String canonical) {
+        try {
+            MessageDigest digest = MessageDigest.getInstance("SHA-256");
+            return HexFormat.of().formatHex(digest.digest(canonical.getBytes(StandardCharsets.UTF_8)));
+        } catch (NoSuchAlgorithmException e) {
+            throw new IllegalStateException("SHA-256 unavailable", e);
+        }
+    }
+
+    static double cosine(float[] a, float[] b) {
+        if (a == null || b == null || a.length != b.length || a.length == 0) {
+            return 0;
+        }
+        double dot = 0, na = 0, nb = 0;
+        for (int i = 0; i < a.length; i++) {
+            dot += a[i] * b[i];
+            na += a[i] * a[i];
+            nb += b[i] * b[i];
+        }
+        return na == 0 || nb == 0 ? 0 : dot / Math.sqrt(na * nb);
+    }
+
+    private boolean semantic() {
+        return "semantic".equalsIgnoreCase(mode) && semanticModel.filter(m -> !m.isBlank()).isPresent();
+    }
+
+    private static String prompt(InferenceRequest request) {
+        StringBuilder text = new StringBuilder();
+        for (Message message : request.getMessages()) {
+            text.append(message.getRole().name().toLowerCase(Locale.ROOT)).append(": ")
+                    .append(message.getContent() == null ? "" : message.getContent().strip()).append('\n');
+        }
+        return text.toString();
+    }
+
+    private float[] embed(String text) {
+        try {
+            var embeddings = sdkProvider.getSdk().embed(semanticModel.get(), text).embeddings();
+            return embeddings == null || embeddings.isEmpty() ? null : embeddings.get(0);
+        } catch (Exception e) {
+            // fall back to exact matching rather than failing the request
+            LOG.debugf("Response cache embedding failed: %s", e.getMessage());
+            return null;
+        }
+    }
+
+    /** The freshest entry of {@code scope} close enough to {@code embedding}. */
+    private String nearest(String scope, float[] embedding) {
+        List<Indexed> entries = index.get(scope);
+        if (embedding == null || entries == null) {
+            return null;
+        }
+        synchronized (entries) {
+            for (int i = entries.size() - 1; i >= 0; i--) {
+                Indexed entry = entries.get(i);
+                if (cosine(entry.embedding(), embedding) < semanticThreshold) {
+                    continue;
+                }
+                String value = get(entry.key());
+                if (value != null) {
+                    return value;
+                }
+                // expired or evicted
+                entries.remove(i);
+            }
+        }
+        return null;
+    }
+
+    private String get(String key) {
+        if ("redis".equalsIgnoreCase(backend)) {
+            try {
+                String value = redis.get().value(String.class).get(redisPrefix + ":" + key);
+                return value == null || cipher == null ? value : cipher.decryptToString(value);
+            } catch (IllegalStateException e) {
+                // written without the current key: a miss, overwritten by the fresh answer
+                LOG.debugf("Cannot decrypt response cache entry %s: %s", key, e.getMessage());
+                return null;
+            } catch (RuntimeException e) {
+                // a Redis outage should degrade to a per-instance cache, not fail requests
+                LOG.warnf("Redis response cache unavailable, using the local cache: %s", e.getMessage());
+            }
+        }
+        synchronized (local) {
+            Entry entry = local.get(key);
+            if (entry == null) {
+                return null;
+            }
+            if (entry.expiresAtMillis() <= System.currentTimeMillis()) {
+                local.remove(key);
+                return null;
+            }
+            return entry.value();
+        }
+    }
+
+    private void put(String key, String value) {
+        if ("redis".equalsIgnoreCase(backend)) {
+            try {
+                redis.get().value(String.class).setex(redisPrefix + ":" + key, Math.max(1, ttl.toSeconds()),
+                        cipher == null ? value : cipher.encryptToString(value));
+                return;
+            } catch (RuntimeException e) {
+                LOG.warnf("Redis response cache unavailable, using the local cache: %s", e.getMessage());
+            }
+        }
+        synchronized (local) {
+            local.put(key, new Entry(value, System.currentTimeMillis() + ttl.toMillis()));
+            Iterator<String> eldest = local.keySet().iterator();
+            while (local.size() > maxEntries && eldest.hasNext()) {
+                eldest.next();
+                eldest.remove();
+            }
+        }
+    }
+
+    private void count(String result) {
+        if (registry != null) {
+            registry.counter("gollek.response_cache.requests", new Tag("result", result)).inc();
+        }
+    }
+}
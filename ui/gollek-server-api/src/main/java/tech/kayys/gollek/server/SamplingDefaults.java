@@ -0,0 +1,104 @@
+package tech.kayys.gollek.server;
+
+import jakarta.annotation.PostConstruct;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+
+import org.eclipse.microprofile.config.Config;
+import org.eclipse.microprofile.config.ConfigProvider;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.LinkedHashMap;
+import java.util.LinkedHashSet;
+import java.util.Map;
+import java.util.Set;
+
+/**
+ * Server-wide sampling defaults for parameters a request leaves unset, from
+ * {@code gollek.server.sampling.<name>}: {@code temperature}, {@code top-p},
+ * {@code top-k}, {@code min-p}, {@code max-tokens}, {@code repeat-penalty} and so on
+ * become the runner parameters {@code temperature}, {@code top_p}, ... Parameters without
+ * a default keep the runner's own. {@code GOLLEK_SERVER_SAMPLING_TOP_P} and the like set
+ * them from the environment. Reloadable through {@link ConfigReload}.
+ */
+@ApplicationScoped
+public class SamplingDefaults {
+
+    private static final Logger LOG = Logger.getLogger(SamplingDefaults.class);
+
+    static final String PREFIX = "gollek.server.sampling.";
+
+    private volatile Map<String, Object> defaults = Map.of();
+
+    @PostConstruct
+    void init() {
+        Config config = ConfigProvider.getConfig();
+        Set<String> names = new LinkedHashSet<>();
+        for (String name : config.getPropertyNames()) {
+            if (name.startsWith(PREFIX)) {
+                names.add(name);
+            }
+        }
+        // GOLLEK_SERVER_SAMPLING_TOP_P is listed under its env name only
+        names.addAll(ConfigOverrides.withPrefix(PREFIX, System.getenv()).keySet());
+        Map<String, String> values = new LinkedHashMap<>();
+        for (String name : names) {
+            config.getOptionalValue(name, String.class).ifPresent(v -> values.put(name, v));
+        }
+        update(values);
+    }
+
+    void onConfigChanged(@Observes ConfigChanged event) {
+        Map<String, String> values = new LinkedHashMap<>();
+        event.values().forEach((k, v) -> {
+            if (k.startsWith(PREFIX)) {
+                values.put(k, v);
+            }
+        });
+        if (!values.isEmpty()) {
+            update(values);
+        }
+    }
+
+    /** Merges {@code gollek.server.sampling.*} values into the defaults. */
+    void update(Map<String, String> values) {
+        Map<String, Object> next = new LinkedHashMap<>(defaults);
+        values.forEach((key, value) -> {
+            String parameter = key.substring(PREFIX.length()).replace('-', '_');
+            try {
+                next.put(parameter, parse(value.trim()));
+            } catch (NumberFormatException e) {
+                LOG.warnf("Ignoring %s=%s: not a number", key, value);
+            }
+        });
+        defaults = Map.copyOf(next);
+    }
+
+    public Map<String, Object> defaults() {
+        return defaults;
+    }
+
+    public InferenceRequest apply(InferenceRequest request) {
+        Map<String, Object> current = defaults;
+        if (request == null || current.isEmpty()) {
+            return request;
+        }
+        var builder = request.toBuilder();
+        boolean changed = false;
+        for (var entry : current.entrySet()) {
+            if (!request.getParameters().containsKey(entry.getKey())) {
+                builder.parameter(entry.getKey(), entry.getValue());
+                changed = true;
+            }
+        }
+        return changed ? builder.build() : request;
+    }
+
+    private static Number parse(String value) {
+        return value.contains(".") || value.contains("e") || value.contains("E")
+                ? Double.parseDouble(value)
+                : Integer.parseInt(value);
+    }
+}
@@ -10,6 +10,14 @@ import org.jboss.logging.Logger;
 import tech.kayys.gollek.factory.GollekSdkFactory;
 import tech.kayys.gollek.sdk.core.GollekSdk;
 import tech.kayys.gollek.sdk.exception.SdkException;
+import tech.kayys.gollek.server.audit.AuditLog;
+import tech.kayys.gollek.server.audit.AuditingSdk;
+import tech.kayys.gollek.server.metrics.UsageCountingSdk;
+import tech.kayys.gollek.server.metrics.UsageReports;
+import tech.kayys.gollek.server.metrics.UsageTotals;
+import tech.kayys.gollek.server.models.ModelCache;
+import tech.kayys.gollek.server.replay.FixtureStore;
+import tech.kayys.gollek.server.replay.RecordReplaySdk;
 import tech.kayys.gollek.spi.model.ModelInfo;
 import tech.kayys.gollek.spi.inference.InferenceRequest;
 import tech.kayys.gollek.spi.inference.InferenceResponse;
@@ -38,6 +46,25 @@ public class SdkProvider {
     @ConfigProperty(name = "gollek.server.allowed-api-keys", defaultValue = "community")
     String allowedApiKeys;
 
+    /** {@code live} (default), {@code record} or {@code replay}; see {@link RecordReplaySdk}. */
+    @ConfigProperty(name = "gollek.server.engine.mode", defaultValue = "live")
+    String engineMode;
+
+    @ConfigProperty(name = "gollek.server.engine.fixtures", defaultValue = "./data/fixtures.json")
+    String fixturesFile;
+
+    @Inject
+    AuditLog auditLog;
+
+    @Inject
+    UsageTotals usageTotals;
+
+    @Inject
+    UsageReports usageReports;
+
+    @Inject
+    ModelCache modelCache;
+
     @PostConstruct
     void init() {
         try {
@@ -46,6 +73,16 @@ public class SdkProvider {
             LOG.warn("No local Gollek SDK found on classpath; using demo fallback. " + e.getMessage());
             this.sdk = new DemoSdk();
         }
+        if (!"live".equalsIgnoreCase(engineMode)) {
+            var mode = RecordReplaySdk.Mode.valueOf(engineMode.toUpperCase());
+            var store = new FixtureStore(java.nio.file.Path.of(fixturesFile));
+            LOG.infof("Engine %s mode using %s (%d fixtures)", mode, fixturesFile, store.size());
+            this.sdk = new RecordReplaySdk(sdk, store, mode);
+        }
+        if (auditLog.enabled()) {
+            this.sdk = new AuditingSdk(sdk, auditLog);
+        }
+        this.sdk = new UsageCountingSdk(sdk, usageTotals, usageReports, modelCache);
     }
 
     public GollekSdk getSdk() {
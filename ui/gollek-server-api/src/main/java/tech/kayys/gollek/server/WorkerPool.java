@@ -0,0 +1,139 @@
+package tech.kayys.gollek.server;
+
+import jakarta.annotation.PreDestroy;
+import jakarta.enterprise.context.ApplicationScoped;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import java.util.ArrayDeque;
+import java.util.Deque;
+import java.util.concurrent.Executor;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Future;
+import java.util.concurrent.LinkedBlockingQueue;
+import java.util.concurrent.RejectedExecutionException;
+import java.util.concurrent.ThreadPoolExecutor;
+import java.util.concurrent.TimeUnit;
+import java.util.concurrent.atomic.AtomicInteger;
+
+/**
+ * The server's shared pool for blocking side work that does not go through a runner:
+ * transcription subprocesses, map-reduce chunks, grammar conversion. Features do not
+ * start threads of their own; each takes a {@link #limit} on its share instead, so one
+ * busy feature cannot starve the others or grow the thread count.
+ */
+@ApplicationScoped
+public class WorkerPool {
+
+    @ConfigProperty(name = "gollek.server.workers.threads", defaultValue = "16")
+    int threads;
+
+    @ConfigProperty(name = "gollek.server.workers.max-queue", defaultValue = "1000")
+    int maxQueue;
+
+    private volatile ThreadPoolExecutor pool;
+
+    public ExecutorService executor() {
+        ThreadPoolExecutor p = pool;
+        if (p == null) {
+            synchronized (this) {
+                if (pool == null) {
+                    int size = Math.max(1, threads);
+                    AtomicInteger count = new AtomicInteger();
+                    pool = new ThreadPoolExecutor(size, size, 60L, TimeUnit.SECONDS,
+                            new LinkedBlockingQueue<>(Math.max(1, maxQueue)), r -> {
+                                Thread t = new Thread(r, "gollek-worker-" + count.incrementAndGet());
+                                t.setDaemon(true);
+                                return t;
+                            });
+                    pool.allowCoreThreadTimeOut(true);
+                }
+                p = pool;
+            }
+        }
+        return p;
+    }
+
+    /**
+     * An executor running at most {@code concurrency} of its tasks at a time on the shared
+     * pool and holding up to {@code maxQueue} more; beyond that {@code execute} throws
+     * {@link RejectedExecutionException}.
+     */
+    public Executor limit(int concurrency, int maxQueue) {
+        return new Limited(executor(), Math.max(1, concurrency), Math.max(0, maxQueue));
+    }
+
+    @PreDestroy
+    void shutdown() {
+        if (pool != null) {
+            pool.shutdownNow();
+        }
+    }
+
+    static final class Limited implements Executor {
+
+        private final Executor delegate;
+        private final int concurrency;
+        private final int maxQueue;
+        private final Deque<Runnable> queued = new ArrayDeque<>();
+        private int running;
+
+        Limited(Executor delegate, int concurrency, int maxQueue) {
+            this.delegate = delegate;
+            this.concurrency = concurrency;
+            this.maxQueue = maxQueue;
+        }
+
+        @Override
+        public void execute(Runnable task) {
+            synchronized (this) {
+                if (running >= concurrency) {
+                    if (queued.size() >= maxQueue) {
+                        throw new RejectedExecutionException("queue is full");
+                    }
+                    queued.add(task);
+                    return;
+                }
+                running++;
+            }
+            try {
+                dispatch(task);
+            } catch (RejectedExecutionException e) {
+                synchronized (this) {
+                    running--;
+                }
+                throw e;
+            }
+        }
+
+        private void dispatch(Runnable task) {
+            delegate.execute(() -> {
+                try {
+                    task.run();
+                } finally {
+                    next();
+                }
+            });
+        }
+
+        private void next() {
+            Runnable task;
+            synchronized (this) {
+                task = queued.poll();
+                if (task == null) {
+                    running--;
+                    return;
+                }
+            }
+            try {
+                dispatch(task);
+            } catch (RejectedExecutionException e) {
+                // the shared pool is full or shut down: fail the task rather than lose it
+                if (task instanceof Future<?> future) {
+                    future.cancel(false);
+                }
+                next();
+            }
+        }
+    }
+}
@@ -0,0 +1,174 @@
+package tech.kayys.gollek.server.admission;
+
+import jakarta.enterprise.context.ApplicationScoped;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import java.time.Instant;
+import java.util.Iterator;
+import java.util.LinkedHashMap;
+import java.util.Map;
+import java.util.Objects;
+import java.util.UUID;
+import java.util.concurrent.atomic.AtomicBoolean;
+
+/**
+ * Capacity reservations for latency-critical callers that schedule their own work. The
+ * server has {@code capacity} worker slots; a reservation holds one for up to its TTL, and
+ * a request presenting the reservation's token in {@value #HEADER} is admitted into that
+ * slot. Requests without a valid token only get slots that are neither running nor
+ * reserved, and are turned away with {@code 503} when there are none.
+ */
+@ApplicationScoped
+public class AdmissionController {
+
+    public static final String HEADER = "X-Gollek-Admission";
+
+    public record Reservation(String token, String owner, long expiresAtMillis) {
+
+        public Instant expiresAt() {
+            return Instant.ofEpochMilli(expiresAtMillis);
+        }
+    }
+
+    /** A running request's hold on a slot; releasing it more than once is harmless. */
+    public final class Permit {
+
+        private final AtomicBoolean released = new AtomicBoolean();
+        private final boolean reserved;
+
+        private Permit(boolean reserved) {
+            this.reserved = reserved;
+        }
+
+        /** Whether the slot came from a reservation. */
+        public boolean reserved() {
+            return reserved;
+        }
+
+        public void release() {
+            if (released.compareAndSet(false, true)) {
+                synchronized (AdmissionController.this) {
+                    inFlight--;
+                }
+            }
+        }
+    }
+
+    @ConfigProperty(name = "gollek.server.admission.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.admission.capacity", defaultValue = "4")
+    int capacity;
+
+    @ConfigProperty(name = "gollek.server.admission.default-ttl-seconds", defaultValue = "5")
+    int defaultTtlSeconds;
+
+    @ConfigProperty(name = "gollek.server.admission.max-ttl-seconds", defaultValue = "60")
+    int maxTtlSeconds;
+
+    private final Map<String, Reservation> reservations = new LinkedHashMap<>();
+    private int inFlight;
+
+    public boolean isEnabled() {
+        return enabled;
+    }
+
+    /**
+     * Reserve a slot for {@code ttlSeconds} (the default when null, capped at the maximum),
+     * or null when every slot is running or reserved.
+     */
+    public Reservation reserve(Integer ttlSeconds, String owner) {
+        return reserve(ttlSeconds, owner, System.currentTimeMillis());
+    }
+
+    synchronized Reservation reserve(Integer ttlSeconds, String owner, long now) {
+        expire(now);
+        if (free() <= 0) {
+            return null;
+        }
+        int ttl = ttlSeconds == null || ttlSeconds <= 0 ? defaultTtlSeconds : Math.min(ttlSeconds, maxTtlSeconds);
+        Reservation reservation = new Reservation(UUID.randomUUID().toString(), owner, now + ttl * 1000L);
+        reservations.put(reservation.token(), reservation);
+        return reservation;
+    }
+
+    /**
+     * Admit a request. A live token reserved by the same {@code owner} is redeemed for its
+     * slot; without one the request needs an unreserved free slot. Returns null when the
+     * request has to wait.
+     */
+    public Permit admit(String token, String owner) {
+        return admit(token, owner, System.currentTimeMillis());
+    }
+
+    synchronized Permit admit(String token, String owner, long now) {
+        expire(now);
+        Reservation reservation = token == null ? null : reservations.get(token);
+        if (reservation != null && Objects.equals(reservation.owner(), owner)) {
+            reservations.remove(token);
+            inFlight++;
+            return new Permit(true);
+        }
+        if (free() <= 0) {
+            return null;
+        }
+        inFlight++;
+        return new Permit(false);
+    }
+
+    /**
+     * Give back an unused reservation. Only its owner may cancel it.
+     */
+    public synchronized boolean cancel(String token, String owner) {
+        Reservation reservation = reservations.get(token);
+        if (reservation == null || !Objects.equals(reservation.owner(), owner)) {
+            return false;
+        }
+        reservations.remove(token);
+        return true;
+    }
+
+    /**
+     * Seconds until the earliest reservation lapses, a hint for {@code Retry-After}.
+     */
+    public long retryAfterSeconds() {
+        return retryAfterSeconds(System.currentTimeMillis());
+    }
+
+    synchronized long retryAfterSeconds(long now) {
+        expire(now);
+        long earliest = reservations.values().stream()
+                .mapToLong(Reservation::expiresAtMillis)
+                .min()
+                .orElse(now + 1000L);
+        return Math.max(1, (earliest - now + 999) / 1000);
+    }
+
+    public Map<String, Object> status() {
+        return status(System.currentTimeMillis());
+    }
+
+    synchronized Map<String, Object> status(long now) {
+        expire(now);
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("enabled", enabled);
+        out.put("capacity", capacity);
+        out.put("in_flight", inFlight);
+        out.put("reserved", reservations.size());
+        out.put("available", Math.max(0, free()));
+        return out;
+    }
+
+    private int free() {
+        return capacity - inFlight - reservations.size();
+    }
+
+    private void expire(long now) {
+        for (Iterator<Reservation> it = reservations.values().iterator(); it.hasNext();) {
+            if (it.next().expiresAtMillis() <= now) {
+                it.remove();
+            }
+        }
+    }
+}
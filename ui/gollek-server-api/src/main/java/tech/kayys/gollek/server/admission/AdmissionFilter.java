@@ -0,0 +1,54 @@
+package tech.kayys.gollek.server.admission;
+
+import jakarta.annotation.Priority;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Priorities;
+import jakarta.ws.rs.container.ContainerRequestContext;
+import jakarta.ws.rs.container.ContainerRequestFilter;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.ext.Provider;
+
+import io.vertx.ext.web.RoutingContext;
+
+import java.util.Map;
+
+/**
+ * Holds an admission slot for the lifetime of each inference request when admission
+ * control is enabled. The slot is released when the response ends or the client goes
+ * away, so streamed responses keep theirs until the last event.
+ */
+@Provider
+@Priority(Priorities.AUTHENTICATION + 20)
+public class AdmissionFilter implements ContainerRequestFilter {
+
+    @Inject
+    AdmissionController admission;
+
+    @Context
+    RoutingContext routingContext;
+
+    @Override
+    public void filter(ContainerRequestContext requestContext) {
+        if (!admission.isEnabled() || !InferenceRoutes.matches(requestContext)) {
+            return;
+        }
+        AdmissionController.Permit permit = admission.admit(
+                requestContext.getHeaderString(AdmissionController.HEADER),
+                requestContext.getHeaderString("X-API-Key"));
+        if (permit == null) {
+            requestContext.abortWith(Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                    .header("Retry-After", admission.retryAfterSeconds())
+                    .type(MediaType.APPLICATION_JSON)
+                    .entity(Map.of("error", "No worker slot available")).build());
+            return;
+        }
+        if (routingContext == null) {
+            permit.release();
+            return;
+        }
+        // fires on a normal end as well as on a closed connection
+        routingContext.addEndHandler(ar -> permit.release());
+    }
+}
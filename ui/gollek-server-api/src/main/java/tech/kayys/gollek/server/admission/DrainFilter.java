@@ -0,0 +1,54 @@
+package tech.kayys.gollek.server.admission;
+
+import jakarta.annotation.Priority;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Priorities;
+import jakarta.ws.rs.container.ContainerRequestContext;
+import jakarta.ws.rs.container.ContainerRequestFilter;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.ext.Provider;
+
+import io.vertx.ext.web.RoutingContext;
+
+import tech.kayys.gollek.server.Lifecycle;
+
+import java.util.Map;
+
+/**
+ * Counts inference requests in flight for {@link Lifecycle}, from the request until its
+ * response ends, so a drain or shutdown waits for streamed responses to finish. While
+ * the server drains or stops, new ones get {@code 503}. Runs before admission control,
+ * so a turned-away request never holds a worker slot.
+ */
+@Provider
+@Priority(Priorities.AUTHENTICATION + 15)
+public class DrainFilter implements ContainerRequestFilter {
+
+    @Inject
+    Lifecycle lifecycle;
+
+    @Context
+    RoutingContext routingContext;
+
+    @Override
+    public void filter(ContainerRequestContext requestContext) {
+        if (!InferenceRoutes.matches(requestContext)) {
+            return;
+        }
+        if (!lifecycle.enter()) {
+            requestContext.abortWith(Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                    .type(MediaType.APPLICATION_JSON)
+                    .entity(Map.of("error", lifecycle.isDraining() ? "Server is draining" : "Server is shutting down"))
+                    .build());
+            return;
+        }
+        if (routingContext == null) {
+            lifecycle.exit();
+            return;
+        }
+        // fires on a normal end as well as on a closed connection
+        routingContext.addEndHandler(ar -> lifecycle.exit());
+    }
+}
@@ -0,0 +1,31 @@
+package tech.kayys.gollek.server.admission;
+
+import jakarta.ws.rs.container.ContainerRequestContext;
+
+import java.util.Set;
+
+/**
+ * The POST routes that run inference, on every API the server speaks. {@link DrainFilter}
+ * counts them for drain and shutdown, streamed responses included, and
+ * {@link AdmissionFilter} holds a worker slot for each.
+ */
+final class InferenceRoutes {
+
+    static final Set<String> PATHS = Set.of(
+            "v1/chat/completions", "v1/completions", "v1/completions/stream", "v1/embeddings",
+            // Ollama
+            "api/generate", "api/chat",
+            // llama-server
+            "completion", "infill");
+
+    private InferenceRoutes() {
+    }
+
+    static boolean matches(ContainerRequestContext requestContext) {
+        if (!"POST".equals(requestContext.getMethod())) {
+            return false;
+        }
+        String path = requestContext.getUriInfo().getPath();
+        return PATHS.contains(path.startsWith("/") ? path.substring(1) : path);
+    }
+}
@@ -0,0 +1,82 @@
+package tech.kayys.gollek.server.admission;
+
+import jakarta.enterprise.context.ApplicationScoped;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.Priority;
+
+import java.util.List;
+import java.util.Locale;
+import java.util.Optional;
+
+/**
+ * Scheduling priority of a request. Each API key has a tier ({@code key-tiers} entries of
+ * the form {@code key=high}, else the default tier); a caller may ask for a priority in
+ * {@value #HEADER} but never above its tier, so only tiered keys can jump the queue while
+ * anyone can step aside for interactive traffic. Runners read the result from the
+ * {@value #PARAMETER} request parameter.
+ */
+@ApplicationScoped
+public class PriorityPolicy {
+
+    public static final String HEADER = "X-Gollek-Priority";
+    public static final String PARAMETER = "priority";
+
+    @ConfigProperty(name = "gollek.server.priority.default", defaultValue = "normal")
+    String defaultTier;
+
+    @ConfigProperty(name = "gollek.server.priority.key-tiers")
+    Optional<List<String>> keyTiers;
+
+    /** The most urgent priority {@code apiKey} may use. */
+    public Priority tier(String apiKey) {
+        if (apiKey != null) {
+            for (String entry : keyTiers.orElse(List.of())) {
+                int eq = entry.lastIndexOf('=');
+                if (eq > 0 && entry.substring(0, eq).trim().equals(apiKey)) {
+                    return parse(entry.substring(eq + 1)).orElse(Priority.NORMAL);
+                }
+            }
+        }
+        return parse(defaultTier).orElse(Priority.NORMAL);
+    }
+
+    /**
+     * The requested priority ({@code requested}, else the request's {@value #PARAMETER}
+     * parameter, else the tier) capped at the tier. Unknown names are ignored.
+     */
+    public Priority resolve(String apiKey, String requested, InferenceRequest request) {
+        Priority tier = tier(apiKey);
+        Optional<Priority> asked = parse(requested);
+        if (asked.isEmpty() && request != null && request.getParameters().get(PARAMETER) != null) {
+            asked = parse(String.valueOf(request.getParameters().get(PARAMETER)));
+        }
+        Priority priority = asked.orElse(tier);
+        return priority.level() < tier.level() ? tier : priority;
+    }
+
+    /** {@code request} with its priority resolved and set for the runner. */
+    public InferenceRequest apply(InferenceRequest request, String apiKey, String headerValue) {
+        if (request == null) {
+            return null;
+        }
+        Priority priority = resolve(apiKey != null ? apiKey : request.getApiKey(), headerValue, request);
+        return request.toBuilder()
+                .priority(priority)
+                .parameter(PARAMETER, priority.name().toLowerCase(Locale.ROOT))
+                .build();
+    }
+
+    static Optional<Priority> parse(String value) {
+        if (value == null || value.isBlank()) {
+            return Optional.empty();
+        }
+        try {
+            return Optional.of(Priority.valueOf(value.trim().toUpperCase(Locale.ROOT)));
+        } catch (IllegalArgumentException e) {
+            return Optional.empty();
+        }
+    }
+}
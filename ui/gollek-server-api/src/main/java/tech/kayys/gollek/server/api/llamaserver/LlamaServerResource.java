@@ -0,0 +1,272 @@
+package tech.kayys.gollek.server.api.llamaserver;
+
+import jakarta.enterprise.inject.Any;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.QueryParam;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.HttpHeaders;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.core.StreamingOutput;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import io.vertx.core.http.HttpServerRequest;
+
+import tech.kayys.gollek.sdk.core.GollekSdk;
+import tech.kayys.gollek.server.Backpressure;
+import tech.kayys.gollek.server.ClientCancellation;
+import tech.kayys.gollek.server.QueueEvents;
+import tech.kayys.gollek.server.RequestTimeout;
+import tech.kayys.gollek.server.SamplingDefaults;
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.admission.PriorityPolicy;
+import tech.kayys.gollek.server.api.v1.SlotsAdminResource;
+import tech.kayys.gollek.server.llamaserver.LlamaServer;
+import tech.kayys.gollek.server.models.TokenizerService;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+import tech.kayys.gollek.spi.provider.LLMProvider;
+
+import java.io.IOException;
+import java.io.OutputStream;
+import java.nio.charset.StandardCharsets;
+import java.util.List;
+import java.util.Map;
+import java.util.Objects;
+import java.util.Optional;
+import java.util.UUID;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Endpoints of the llama.cpp HTTP server ({@code llama-server}) for clients and
+ * benchmarks written against it: {@code /completion}, {@code /infill}, {@code /props} and
+ * {@code /slots}. Requests run on the same runners, with the same priority, timeouts,
+ * sampling defaults and backpressure, as {@code /v1}, and drain and admission control
+ * cover them too (see {@code DrainFilter}). Bodies without a {@code model} use
+ * the first configured one, like a single-model llama-server. {@code /props} and
+ * {@code /slots} are admin endpoints (see {@code SecurityFilter.ADMIN_PATHS}).
+ */
+@Path("/")
+public class LlamaServerResource {
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    ObjectMapper mapper;
+
+    @Inject
+    PriorityPolicy priorityPolicy;
+
+    @Inject
+    RequestTimeout requestTimeout;
+
+    @Inject
+    SamplingDefaults samplingDefaults;
+
+    @Inject
+    Backpressure backpressure;
+
+    @Inject
+    TokenizerService tokenizers;
+
+    @Inject
+    @Any
+    Instance<LLMProvider> providers;
+
+    @Context
+    HttpServerRequest httpRequest;
+
+    /** Fill-in-the-middle markers per model; absent when the model has none. */
+    private final Map<String, Optional<LlamaServer.Fim>> fimFormats = new ConcurrentHashMap<>();
+
+    @POST
+    @Path("/completion")
+    @Consumes(MediaType.APPLICATION_JSON)
+    @Produces({ MediaType.APPLICATION_JSON, MediaType.SERVER_SENT_EVENTS })
+    public Response completion(@Context HttpHeaders headers, Map<String, Object> body) {
+        if (body == null) {
+            return error(Response.Status.BAD_REQUEST, "request body is required");
+        }
+        String model = model(body);
+        if (model == null) {
+            return error(Response.Status.BAD_REQUEST, "model is required");
+        }
+        InferenceRequest request;
+        try {
+            request = prepare(LlamaServer.toInferenceRequest(body, model, newId(),
+                    tokens -> tokenizers.detokenize(model, tokens)), headers);
+        } catch (IllegalArgumentException e) {
+            return error(Response.Status.BAD_REQUEST, e.getMessage());
+        }
+        return run(request, model);
+    }
+
+    @POST
+    @Path("/infill")
+    @Consumes(MediaType.APPLICATION_JSON)
+    @Produces({ MediaType.APPLICATION_JSON, MediaType.SERVER_SENT_EVENTS })
+    public Response infill(@Context HttpHeaders headers, Map<String, Object> body) {
+        if (body == null) {
+            return error(Response.Status.BAD_REQUEST, "request body is required");
+        }
+        String model = model(body);
+        if (model == null) {
+            return error(Response.Status.BAD_REQUEST, "model is required");
+        }
+        InferenceRequest request;
+        try {
+            LlamaServer.Fim fim = fimFormats.computeIfAbsent(model,
+                    m -> Optional.ofNullable(LlamaServer.fim(text -> tokenizers.tokenize(m, text, false))))
+                    .orElse(null);
+            if (fim == null) {
+                return error(Response.Status.BAD_REQUEST, "model '" + model + "' has no fill-in-the-middle tokens");
+            }
+            request = prepare(LlamaServer.toInfillRequest(body, model, newId(), fim), headers);
+        } catch (IllegalArgumentException e) {
+            return error(Response.Status.BAD_REQUEST, e.getMessage());
+        } catch (UnsupportedOperationException e) {
+            return error(Response.Status.NOT_IMPLEMENTED, e.getMessage());
+        }
+        return run(request, model);
+    }
+
+    @GET
+    @Path("/props")
+    @Produces(MediaType.APPLICATION_JSON)
+    public Response props() {
+        List<Map<String, Object>> runners = SlotsAdminResource.details(providers, "slots");
+        String model = tokenizers.defaultModel()
+                .orElse(runners.isEmpty() ? null : String.valueOf(runners.get(0).get("model")));
+        if (model == null) {
+            return error(Response.Status.SERVICE_UNAVAILABLE, "no model is loaded");
+        }
+        String template = SlotsAdminResource.details(providers, "models").stream()
+                .filter(m -> model.equals(m.get("model")))
+                .map(m -> m.get("chat_template") instanceof Map<?, ?> t && t.get("name") instanceof String name
+                        ? name
+                        : null)
+                .filter(Objects::nonNull)
+                .findFirst().orElse(null);
+        return Response.ok(LlamaServer.props(model, LlamaServer.slots(runners, model), template,
+                samplingDefaults.defaults())).build();
+    }
+
+    /** With {@code fail_on_no_slot}, answers 503 when every slot is busy, as llama-server does. */
+    @GET
+    @Path("/slots")
+    @Produces(MediaType.APPLICATION_JSON)
+    public Response slots(@QueryParam("fail_on_no_slot") String failOnNoSlot) {
+        List<Map<String, Object>> slots = LlamaServer.slots(SlotsAdminResource.details(providers, "slots"), null);
+        boolean fail = failOnNoSlot != null && !failOnNoSlot.equals("0") && !failOnNoSlot.equalsIgnoreCase("false");
+        if (fail && slots.stream().allMatch(s -> Boolean.TRUE.equals(s.get("is_processing")))) {
+            return error(Response.Status.SERVICE_UNAVAILABLE, "no slot available");
+        }
+        return Response.ok(slots).build();
+    }
+
+    private String model(Map<String, Object> body) {
+        if (body.get("model") instanceof String model && !model.isBlank()) {
+            return model;
+        }
+        return tokenizers.defaultModel().orElse(null);
+    }
+
+    private InferenceRequest prepare(InferenceRequest request, HttpHeaders headers) {
+        String apiKey = headers.getHeaderString("X-API-Key");
+        if (apiKey != null) {
+            request = request.toBuilder().apiKey(apiKey).build();
+        }
+        request = priorityPolicy.apply(request, apiKey, headers.getHeaderString(PriorityPolicy.HEADER));
+        return requestTimeout.apply(samplingDefaults.apply(request));
+    }
+
+    /**
+     * Streams {@code request} as server-sent events ending with the {@code stop: true}
+     * result, or answers it with the result alone.
+     */
+    private Response run(InferenceRequest request, String model) {
+        GollekSdk sdk = sdkProvider.getSdk();
+        ClientCancellation.onDisconnect(httpRequest, request.getRequestId());
+        long start = System.nanoTime();
+        if (request.isStreaming()) {
+            StreamingOutput body = out -> {
+                long firstToken = 0;
+                boolean done = false;
+                try (var chunks = sdk.streamCompletion(request).subscribe().asStream()) {
+                    for (var it = chunks.iterator(); it.hasNext() && !done;) {
+                        var chunk = it.next();
+                        if (QueueEvents.status(chunk) != null) {
+                            continue;
+                        }
+                        if (chunk.delta() != null && !chunk.delta().isEmpty()) {
+                            if (firstToken == 0) {
+                                firstToken = System.nanoTime();
+                            }
+                            writeEvent(out, LlamaServer.chunk(chunk.delta()));
+                        }
+                        if (chunk.finished()) {
+                            long input = chunk.usage() == null ? 0 : chunk.usage().inputTokens();
+                            long output = chunk.usage() == null ? 0 : chunk.usage().outputTokens();
+                            long now = System.nanoTime();
+                            writeEvent(out, LlamaServer.result(model, "", LlamaServer.stopType(chunk.finishReason()),
+                                    input, output, (firstToken == 0 ? now : firstToken) - start, now - start));
+                            done = true;
+                        }
+                    }
+                    if (!done) {
+                        long elapsed = System.nanoTime() - start;
+                        writeEvent(out, LlamaServer.result(model, "", "eos", 0, 0, elapsed, elapsed));
+                    }
+                } catch (IOException e) {
+                    RequestCancellation.cancel(request.getRequestId());
+                    throw e;
+                } catch (RuntimeException e) {
+                    // headers are out; like llama-server, report the failure as a last event
+                    writeEvent(out, Map.of("error", Map.of("code", 500, "message", String.valueOf(e.getMessage()),
+                            "type", "server_error")));
+                }
+            };
+            return Response.ok(body, MediaType.SERVER_SENT_EVENTS).build();
+        }
+        try {
+            InferenceResponse response = sdk.createCompletion(request);
+            return Response.ok(LlamaServer.result(model, response.getContent(),
+                    LlamaServer.stopType(response.getFinishReason()), response.getInputTokens(),
+                    response.getOutputTokens(), 0, System.nanoTime() - start), MediaType.APPLICATION_JSON).build();
+        } catch (Exception e) {
+            Response rejected = backpressure.reject(e, "llama-server");
+            if (rejected != null) {
+                return rejected;
+            }
+            Response timedOut = RequestTimeout.reject(e);
+            if (timedOut != null) {
+                return timedOut;
+            }
+            return error(Response.Status.INTERNAL_SERVER_ERROR, e.getMessage());
+        }
+    }
+
+    private void writeEvent(OutputStream out, Object data) throws IOException {
+        out.write(("data: " + mapper.writeValueAsString(data) + "\n\n").getBytes(StandardCharsets.UTF_8));
+        out.flush();
+    }
+
+    private static String newId() {
+        return "llama-" + UUID.randomUUID().toString().replace("-", "");
+    }
+
+    private static Response error(Response.Status status, String message) {
+        return Response.status(status)
+                .entity(Map.of("error", Map.of("code", status.getStatusCode(), "message", String.valueOf(message),
+                        "type", status.getStatusCode() < 500 ? "invalid_request_error" : "server_error")))
+                .type(MediaType.APPLICATION_JSON).build();
+    }
+}
@@ -0,0 +1,261 @@
+package tech.kayys.gollek.server.api.ollama;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.HttpHeaders;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.core.StreamingOutput;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import io.vertx.core.http.HttpServerRequest;
+
+import tech.kayys.gollek.sdk.core.GollekSdk;
+import tech.kayys.gollek.sdk.model.ModelListRequest;
+import tech.kayys.gollek.server.Backpressure;
+import tech.kayys.gollek.server.ClientCancellation;
+import tech.kayys.gollek.server.QueueEvents;
+import tech.kayys.gollek.server.RequestTimeout;
+import tech.kayys.gollek.server.SamplingDefaults;
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.admission.PriorityPolicy;
+import tech.kayys.gollek.server.models.ModelCapabilities;
+import tech.kayys.gollek.server.models.ModelCapabilityService;
+import tech.kayys.gollek.server.ollama.Ollama;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+import tech.kayys.gollek.spi.model.ModelInfo;
+
+import java.nio.charset.StandardCharsets;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.UUID;
+import java.util.function.Function;
+
+/**
+ * Ollama-compatible endpoints for tools that only speak its API: {@code /api/generate},
+ * {@code /api/chat}, {@code /api/tags} and {@code /api/show}. Requests run on the same
+ * runners, with the same priority, timeouts, sampling defaults and backpressure, as
+ * {@code /v1}, and are likewise held by a drain or shutdown until their stream ends and
+ * subject to admission control (see {@code DrainFilter} and {@code AdmissionFilter}).
+ */
+@Path("/api")
+public class OllamaResource {
+
+    private static final int MAX_MODELS = 1000;
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    ObjectMapper mapper;
+
+    @Inject
+    PriorityPolicy priorityPolicy;
+
+    @Inject
+    RequestTimeout requestTimeout;
+
+    @Inject
+    SamplingDefaults samplingDefaults;
+
+    @Inject
+    Backpressure backpressure;
+
+    @Inject
+    ModelCapabilityService capabilityService;
+
+    @Context
+    HttpServerRequest httpRequest;
+
+    @POST
+    @Path("/generate")
+    @Consumes(MediaType.APPLICATION_JSON)
+    @Produces({ MediaType.APPLICATION_JSON, Ollama.NDJSON })
+    public Response generate(@Context HttpHeaders headers, Ollama.GenerateRequest body) {
+        if (body == null) {
+            return error(Response.Status.BAD_REQUEST, "request body is required");
+        }
+        InferenceRequest request;
+        try {
+            request = prepare(Ollama.toInferenceRequest(body, newId()), headers);
+        } catch (IllegalArgumentException e) {
+            return error(Response.Status.BAD_REQUEST, e.getMessage());
+        }
+        if (!Boolean.TRUE.equals(body.raw()) && (body.prompt() == null || body.prompt().isEmpty())) {
+            // Ollama answers an empty prompt with an empty, finished response
+            return Response.ok(Ollama.done(Ollama.generateLine(body.model(), ""), "load", 0, 0, 0),
+                    MediaType.APPLICATION_JSON).build();
+        }
+        return run(request, body.isStream(), text -> Ollama.generateLine(body.model(), text));
+    }
+
+    @POST
+    @Path("/chat")
+    @Consumes(MediaType.APPLICATION_JSON)
+    @Produces({ MediaType.APPLICATION_JSON, Ollama.NDJSON })
+    public Response chat(@Context HttpHeaders headers, Ollama.ChatRequest body) {
+        if (body == null) {
+            return error(Response.Status.BAD_REQUEST, "request body is required");
+        }
+        InferenceRequest request;
+        try {
+            request = prepare(Ollama.toInferenceRequest(body, newId()), headers);
+        } catch (IllegalArgumentException e) {
+            return error(Response.Status.BAD_REQUEST, e.getMessage());
+        }
+        return run(request, body.isStream(), text -> Ollama.chatLine(body.model(), text));
+    }
+
+    @GET
+    @Path("/tags")
+    @Produces(MediaType.APPLICATION_JSON)
+    public Response tags() {
+        try {
+            List<ModelInfo> models = sdkProvider.getSdk().listModels(ModelListRequest.builder()
+                    .runnableOnly(true)
+                    .limit(MAX_MODELS)
+                    .dedupe(true)
+                    .sort(true)
+                    .build());
+            return Response.ok(Map.of("models", models.stream()
+                    .map(m -> Ollama.tag(m, capabilityService.capabilities(m)))
+                    .toList())).build();
+        } catch (Exception e) {
+            return error(Response.Status.INTERNAL_SERVER_ERROR, e.getMessage());
+        }
+    }
+
+    @POST
+    @Path("/show")
+    @Consumes(MediaType.APPLICATION_JSON)
+    @Produces(MediaType.APPLICATION_JSON)
+    public Response show(Ollama.ShowRequest body) {
+        if (body == null || body.modelId() == null || body.modelId().isBlank()) {
+            return error(Response.Status.BAD_REQUEST, "model is required");
+        }
+        try {
+            Optional<ModelInfo> info = sdkProvider.getSdk().getModelInfo(body.modelId());
+            if (info.isEmpty()) {
+                return error(Response.Status.NOT_FOUND, "model '" + body.modelId() + "' not found");
+            }
+            ModelInfo m = info.get();
+            ModelCapabilities capabilities = capabilityService.capabilities(m);
+            Map<String, Object> details = Ollama.details(m, capabilities);
+            Map<String, Object> modelInfo = new LinkedHashMap<>();
+            String family = (String) details.get("family");
+            modelInfo.put("general.architecture", family);
+            if (capabilities != null && capabilities.parameters() != null) {
+                modelInfo.put("general.parameter_count", capabilities.parameters());
+            }
+            Long contextLength = capabilities != null && capabilities.contextLength() != null
+                    ? capabilities.contextLength()
+                    : m.getContextLength();
+            if (contextLength != null && !family.isEmpty()) {
+                modelInfo.put(family + ".context_length", contextLength);
+            }
+            Map<String, Object> out = new LinkedHashMap<>();
+            out.put("modelfile", "");
+            out.put("parameters", "");
+            out.put("template", "");
+            out.put("details", details);
+            out.put("model_info", modelInfo);
+            out.put("capabilities", Ollama.capabilities(capabilities));
+            return Response.ok(out).build();
+        } catch (Exception e) {
+            return error(Response.Status.INTERNAL_SERVER_ERROR, e.getMessage());
+        }
+    }
+
+    private InferenceRequest prepare(InferenceRequest request, HttpHeaders headers) {
+        String apiKey = headers.getHeaderString("X-API-Key");
+        if (apiKey != null) {
+            request = request.toBuilder().apiKey(apiKey).build();
+        }
+        request = priorityPolicy.apply(request, apiKey, headers.getHeaderString(PriorityPolicy.HEADER));
+        return requestTimeout.apply(samplingDefaults.apply(request));
+    }
+
+    /**
+     * Streams {@code request} as JSON lines built by {@code line}, or answers it with one
+     * object; the last line carries {@code done}.
+     */
+    private Response run(InferenceRequest request, boolean stream, Function<String, Map<String, Object>> line) {
+        GollekSdk sdk = sdkProvider.getSdk();
+        ClientCancellation.onDisconnect(httpRequest, request.getRequestId());
+        long start = System.nanoTime();
+        if (stream) {
+            StreamingOutput body = out -> {
+                boolean done = false;
+                try (var chunks = sdk.streamCompletion(request).subscribe().asStream()) {
+                    for (var it = chunks.iterator(); it.hasNext() && !done;) {
+                        var chunk = it.next();
+                        if (QueueEvents.status(chunk) != null) {
+                            continue;
+                        }
+                        if (!chunk.finished()) {
+                            if (chunk.delta() != null && !chunk.delta().isEmpty()) {
+                                writeLine(out, line.apply(chunk.delta()));
+                            }
+                            continue;
+                        }
+                        long input = chunk.usage() == null ? 0 : chunk.usage().inputTokens();
+                        long output = chunk.usage() == null ? 0 : chunk.usage().outputTokens();
+                        writeLine(out, Ollama.done(line.apply(chunk.delta()), Ollama.doneReason(chunk), input,
+                                output, System.nanoTime() - start));
+                        done = true;
+                    }
+                    if (!done) {
+                        writeLine(out, Ollama.done(line.apply(""), "stop", 0, 0, System.nanoTime() - start));
+                    }
+                } catch (java.io.IOException e) {
+                    RequestCancellation.cancel(request.getRequestId());
+                    throw e;
+                } catch (RuntimeException e) {
+                    // the status line is gone; Ollama reports a failed stream in a line of its own
+                    writeLine(out, Map.of("error", String.valueOf(e.getMessage())));
+                }
+            };
+            return Response.ok(body, Ollama.NDJSON).build();
+        }
+        try {
+            InferenceResponse response = sdk.createCompletion(request);
+            return Response.ok(Ollama.done(line.apply(response.getContent()),
+                    Ollama.doneReason(response.getFinishReason()), response.getInputTokens(),
+                    response.getOutputTokens(), System.nanoTime() - start), MediaType.APPLICATION_JSON).build();
+        } catch (Exception e) {
+            Response rejected = backpressure.reject(e, "ollama");
+            if (rejected != null) {
+                return rejected;
+            }
+            Response timedOut = RequestTimeout.reject(e);
+            if (timedOut != null) {
+                return timedOut;
+            }
+            return error(Response.Status.INTERNAL_SERVER_ERROR, e.getMessage());
+        }
+    }
+
+    private void writeLine(java.io.OutputStream out, Object line) throws java.io.IOException {
+        out.write((mapper.writeValueAsString(line) + "\n").getBytes(StandardCharsets.UTF_8));
+        out.flush();
+    }
+
+    private static String newId() {
+        return "ollama-" + UUID.randomUUID().toString().replace("-", "");
+    }
+
+    private static Response error(Response.Status status, String message) {
+        return Response.status(status)
+                .entity(Map.of("error", String.valueOf(message)))
+                .type(MediaType.APPLICATION_JSON).build();
+    }
+}
@@ -0,0 +1,67 @@
+package tech.kayys.gollek.server.api.v1;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.DELETE;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.HeaderParam;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.PathParam;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import tech.kayys.gollek.server.admission.AdmissionController;
+
+import java.util.LinkedHashMap;
+import java.util.Map;
+
+/**
+ * Admission tokens: a caller reserves a worker slot, then sends its request within the
+ * TTL with the token in {@code X-Gollek-Admission} and is admitted ahead of unreserved
+ * traffic. Tokens are bound to the API key that reserved them.
+ */
+@Path("/v1/admission")
+@Produces(MediaType.APPLICATION_JSON)
+public class AdmissionResource {
+
+    @Inject
+    AdmissionController admission;
+
+    public static record ReserveDTO(@JsonProperty("ttl_seconds") Integer ttlSeconds) { }
+
+    @GET
+    public Response status() {
+        return Response.ok(admission.status()).build();
+    }
+
+    @POST
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response reserve(ReserveDTO dto, @HeaderParam("X-API-Key") String apiKey) {
+        if (!admission.isEnabled()) {
+            return Response.status(Response.Status.NOT_FOUND)
+                    .entity(Map.of("error", "Admission control is disabled")).build();
+        }
+        var reservation = admission.reserve(dto == null ? null : dto.ttlSeconds(), apiKey);
+        if (reservation == null) {
+            return Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                    .header("Retry-After", admission.retryAfterSeconds())
+                    .entity(Map.of("error", "No worker slot available")).build();
+        }
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("token", reservation.token());
+        out.put("expires_at", reservation.expiresAt().toString());
+        out.put("header", AdmissionController.HEADER);
+        return Response.status(Response.Status.CREATED).entity(out).build();
+    }
+
+    @DELETE
+    @Path("/{token}")
+    public Response cancel(@PathParam("token") String token, @HeaderParam("X-API-Key") String apiKey) {
+        boolean cancelled = admission.cancel(token, apiKey);
+        return Response.status(cancelled ? Response.Status.NO_CONTENT : Response.Status.NOT_FOUND).build();
+    }
+}
@@ -0,0 +1,133 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.FormParam;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.core.StreamingOutput;
+
+import org.jboss.resteasy.reactive.multipart.FileUpload;
+
+import tech.kayys.gollek.server.audio.SpeechEngine;
+import tech.kayys.gollek.server.audio.TranscriptionEngine;
+
+/**
+ * OpenAI-compatible audio routes backed by a local {@link TranscriptionEngine} and
+ * {@link SpeechEngine}.
+ */
+@Path("/v1/audio")
+public class AudioResource {
+
+    @Inject
+    TranscriptionEngine transcriptionEngine;
+
+    @Inject
+    SpeechEngine speechEngine;
+
+    public static record SpeechRequest(String model, String input, String voice, String response_format, Float speed) { }
+
+    @POST
+    @Path("/speech")
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response speech(SpeechRequest request) {
+        if (request == null || request.input() == null || request.input().isBlank()) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "input is required")).type(MediaType.APPLICATION_JSON).build();
+        }
+        if (!speechEngine.isAvailable()) {
+            return Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                    .entity(java.util.Map.of("error", "speech engine is not configured")).type(MediaType.APPLICATION_JSON).build();
+        }
+        try {
+            var audio = speechEngine.synthesize(new SpeechEngine.Request(
+                    request.input(), request.voice(), request.response_format(), request.speed()));
+            StreamingOutput body = out -> {
+                try (audio) {
+                    byte[] buf = new byte[8192];
+                    int n;
+                    while ((n = audio.read(buf)) != -1) {
+                        out.write(buf, 0, n);
+                        out.flush();
+                    }
+                }
+            };
+            return Response.ok(body, speechEngine.contentType(request.response_format())).build();
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", e.getMessage())).type(MediaType.APPLICATION_JSON).build();
+        } catch (IllegalStateException e) {
+            return Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                    .entity(java.util.Map.of("error", e.getMessage())).type(MediaType.APPLICATION_JSON).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(java.util.Map.of("error", e.getMessage())).type(MediaType.APPLICATION_JSON).build();
+        }
+    }
+
+    @POST
+    @Path("/transcriptions")
+    @Consumes(MediaType.MULTIPART_FORM_DATA)
+    @Produces({ MediaType.APPLICATION_JSON, MediaType.TEXT_PLAIN })
+    public Response transcribe(
+            @FormParam("file") FileUpload file,
+            @FormParam("model") String model,
+            @FormParam("language") String language,
+            @FormParam("prompt") String prompt,
+            @FormParam("temperature") Float temperature,
+            @FormParam("response_format") String responseFormat) {
+        return handle(file, new TranscriptionEngine.Options(model, language, prompt, temperature, false), responseFormat);
+    }
+
+    @POST
+    @Path("/translations")
+    @Consumes(MediaType.MULTIPART_FORM_DATA)
+    @Produces({ MediaType.APPLICATION_JSON, MediaType.TEXT_PLAIN })
+    public Response translate(
+            @FormParam("file") FileUpload file,
+            @FormParam("model") String model,
+            @FormParam("prompt") String prompt,
+            @FormParam("temperature") Float temperature,
+            @FormParam("response_format") String responseFormat) {
+        return handle(file, new TranscriptionEngine.Options(model, null, prompt, temperature, true), responseFormat);
+    }
+
+    private Response handle(FileUpload file, TranscriptionEngine.Options options, String responseFormat) {
+        if (file == null) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "file is required")).build();
+        }
+        if (!transcriptionEngine.isAvailable()) {
+            return Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                    .entity(java.util.Map.of("error", "transcription engine is not configured")).build();
+        }
+        try {
+            var result = transcriptionEngine.transcribe(file.uploadedFile(), options);
+            String format = responseFormat == null ? "json" : responseFormat;
+            return switch (format) {
+                case "text" -> Response.ok(result.text(), MediaType.TEXT_PLAIN).build();
+                case "verbose_json" -> Response.ok(java.util.Map.of(
+                        "task", options.translate() ? "translate" : "transcribe",
+                        "language", result.language() == null ? "" : result.language(),
+                        "duration", result.duration(),
+                        "text", result.text(),
+                        "segments", result.segments()), MediaType.APPLICATION_JSON).build();
+                case "json" -> Response.ok(java.util.Map.of("text", result.text()), MediaType.APPLICATION_JSON).build();
+                default -> Response.status(Response.Status.BAD_REQUEST)
+                        .entity(java.util.Map.of("error", "unsupported response_format: " + format)).build();
+            };
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
+        } catch (IllegalStateException e) {
+            return Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
+        }
+    }
+}
@@ -0,0 +1,409 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.HttpHeaders;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.core.StreamingOutput;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import io.smallrye.mutiny.Multi;
+import io.vertx.core.http.HttpServerRequest;
+
+import tech.kayys.gollek.sdk.core.GollekSdk;
+import tech.kayys.gollek.server.Backpressure;
+import tech.kayys.gollek.server.ClientCancellation;
+import tech.kayys.gollek.server.QueueEvents;
+import tech.kayys.gollek.server.RequestTimeout;
+import tech.kayys.gollek.server.ResponseCache;
+import tech.kayys.gollek.server.SamplingDefaults;
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.admission.PriorityPolicy;
+import tech.kayys.gollek.server.chat.HistoryBudget;
+import tech.kayys.gollek.server.chat.LanguageRouting;
+import tech.kayys.gollek.server.conversations.ConversationStore;
+import tech.kayys.gollek.server.models.ModelRouter;
+import tech.kayys.gollek.server.openai.ChatCompletion;
+import tech.kayys.gollek.server.openai.ChatCompletionRequest;
+import tech.kayys.gollek.server.openai.ChatCompletions;
+import tech.kayys.gollek.server.openai.ResponseFormat;
+import tech.kayys.gollek.server.publish.TokenChannels;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+
+import java.nio.charset.StandardCharsets;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.concurrent.CompletableFuture;
+
+/**
+ * OpenAI-compatible chat completions. Returns a {@code chat.completion} object, or an
+ * SSE stream of {@code chat.completion.chunk} objects terminated by {@code [DONE]} when
+ * {@code stream} is true.
+ */
+@Path("/v1/chat/completions")
+public class ChatCompletionsResource {
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    ObjectMapper mapper;
+
+    @Inject
+    ConversationStore conversations;
+
+    @Inject
+    ModelRouter router;
+
+    @Inject
+    PriorityPolicy priorityPolicy;
+
+    @Inject
+    RequestTimeout requestTimeout;
+
+    @Inject
+    SamplingDefaults samplingDefaults;
+
+    @Inject
+    Backpressure backpressure;
+
+    @Inject
+    LanguageRouting languageRouting;
+
+    @Inject
+    TokenChannels channels;
+
+    @Inject
+    ResponseCache responseCache;
+
+    @Context
+    HttpServerRequest httpRequest;
+
+    /** Extra attempts when output does not match {@code response_format}. */
+    @ConfigProperty(name = "gollek.server.response-format.retries", defaultValue = "1")
+    int responseFormatRetries;
+
+    @POST
+    @Consumes(MediaType.APPLICATION_JSON)
+    @Produces({ MediaType.APPLICATION_JSON, MediaType.SERVER_SENT_EVENTS })
+    public Response chatCompletions(@Context HttpHeaders headers, ChatCompletionRequest clientRequest) {
+        if (clientRequest == null) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "request body is required")).type(MediaType.APPLICATION_JSON).build();
+        }
+        final String conversationId = clientRequest.conversationId();
+        ChatCompletionRequest resolved;
+        if (conversationId != null) {
+            var conversation = conversations.get(conversationId);
+            if (conversation.isEmpty()) {
+                return Response.status(Response.Status.NOT_FOUND)
+                        .entity(java.util.Map.of("error", "No such conversation: " + conversationId))
+                        .type(MediaType.APPLICATION_JSON).build();
+            }
+            resolved = conversation.get().applyTo(clientRequest);
+        } else {
+            resolved = clientRequest;
+        }
+        ModelRouter.Selection selection = null;
+        final LanguageRouting.Decision language = languageRouting.decide(resolved);
+        if (language != null && language.model() != null) {
+            selection = new ModelRouter.Selection(resolved.model(), language.model(),
+                    "prompt language " + language.language(), 0, null);
+            resolved = resolved.withModel(language.model());
+        } else if (ModelRouter.isAuto(resolved.model())) {
+            try {
+                selection = router.select(resolved.modelHints()).orElse(null);
+            } catch (Exception e) {
+                return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                        .entity(java.util.Map.of("error", "Model selection failed: " + e.getMessage()))
+                        .type(MediaType.APPLICATION_JSON).build();
+            }
+            if (selection == null) {
+                return Response.status(Response.Status.NOT_FOUND)
+                        .entity(java.util.Map.of("error", "No runnable model satisfies the model hints"))
+                        .type(MediaType.APPLICATION_JSON).build();
+            }
+            resolved = resolved.withModel(selection.selected());
+        }
+        final ChatCompletionRequest request = resolved;
+        final ModelRouter.Selection modelSelection = selection;
+        String id = ChatCompletions.newId();
+        var sdk = sdkProvider.getSdk();
+        InferenceRequest inferenceRequest;
+        HistoryBudget.Report historyReport = null;
+        TokenChannels.Destination publish = null;
+        int n;
+        try {
+            n = request.choices(ChatCompletions.MAX_CHOICES);
+            var messages = ChatCompletions.toMessages(request);
+            if (language != null) {
+                messages = LanguageRouting.withSystemPrompt(messages, language.systemPrompt());
+            }
+            if (request.history() != null) {
+                var trimmed = HistoryBudget.apply(messages, request.history(),
+                        dropped -> summarize(request.model(), dropped));
+                messages = trimmed.messages();
+                historyReport = trimmed.report();
+            }
+            inferenceRequest = ChatCompletions.toInferenceRequest(request, id, messages);
+            String apiKey = headers.getHeaderString("X-API-Key");
+            if (apiKey != null) {
+                inferenceRequest = inferenceRequest.toBuilder().apiKey(apiKey).build();
+            }
+            inferenceRequest = priorityPolicy.apply(inferenceRequest, apiKey,
+                    headers.getHeaderString(PriorityPolicy.HEADER));
+            inferenceRequest = requestTimeout.apply(samplingDefaults.apply(inferenceRequest));
+            if (request.isStream()) {
+                inferenceRequest = QueueEvents.apply(inferenceRequest, headers.getHeaderString(QueueEvents.HEADER));
+                publish = channels.destination(headers.getHeaderString(TokenChannels.HEADER),
+                        headers.getHeaderString(TokenChannels.MODE_HEADER));
+            }
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", e.getMessage())).type(MediaType.APPLICATION_JSON).build();
+        }
+
+        final List<InferenceRequest> choiceRequests = ChatCompletions.choiceRequests(inferenceRequest, n);
+        final TokenChannels.Destination destination = publish;
+        if (destination == null || !destination.only()) {
+            ClientCancellation.onDisconnect(httpRequest,
+                    choiceRequests.stream().map(InferenceRequest::getRequestId).toArray(String[]::new));
+        }
+        if (request.isStream()) {
+            long created = Instant.now().getEpochSecond();
+            StreamingOutput body = sink -> {
+                var out = channels.tee(sink, destination);
+                boolean[] started = new boolean[n];
+                StringBuilder[] replies = new StringBuilder[n];
+                // closing the stream cancels the upstream subscriptions, and with them generation;
+                // closing the turn then saves what the client was sent, however the stream ended
+                try (var turn = conversations.streamedTurn(conversationId, clientRequest.messages());
+                        var chunks = streamChoices(sdk, choiceRequests).subscribe().asStream()) {
+                    replies[0] = turn.reply();
+                    for (int i = 1; i < n; i++) {
+                        replies[i] = new StringBuilder();
+                    }
+                    for (var it = chunks.iterator(); it.hasNext();) {
+                        var indexed = it.next();
+                        var chunk = indexed.chunk();
+                        var queued = QueueEvents.status(chunk);
+                        if (queued != null) {
+                            var status = new java.util.LinkedHashMap<String, Object>();
+                            status.put("id", id);
+                            status.put("object", "queue.status");
+                            if (n > 1) {
+                                status.put("index", indexed.index());
+                            }
+                            status.putAll(queued);
+                            writeEvent(out, "queued", mapper.writeValueAsString(status));
+                            continue;
+                        }
+                        writeEvent(out, mapper.writeValueAsString(ChatCompletions.toChunk(id, request.model(), created,
+                                chunk, !started[indexed.index()], indexed.index())));
+                        started[indexed.index()] = true;
+                        if (chunk.delta() != null) {
+                            replies[indexed.index()].append(chunk.delta());
+                        }
+                    }
+                    turn.complete();
+                    for (int i = 0; i < n && request.responseFormat() != null; i++) {
+                        var problems = request.responseFormat().validate(replies[i].toString());
+                        if (!problems.isEmpty()) {
+                            // already streamed, so the violation can only be reported after the fact
+                            writeEvent(out, mapper.writeValueAsString(
+                                    formatViolation(problems, replies[i].toString(), 1)));
+                            break;
+                        }
+                    }
+                } catch (java.io.IOException e) {
+                    choiceRequests.forEach(r -> RequestCancellation.cancel(r.getRequestId()));
+                    throw e;
+                } catch (RuntimeException e) {
+                    writeEvent(out, mapper.writeValueAsString(java.util.Map.of("error",
+                            java.util.Map.of("message", String.valueOf(e.getMessage())))));
+                }
+                writeEvent(out, "[DONE]");
+            };
+            if (destination != null && destination.only()) {
+                // nobody holds the connection, so generation runs to completion on its own
+                channels.detach(body);
+                return Response.accepted(java.util.Map.of("id", id, "channel", destination.toString()))
+                        .type(MediaType.APPLICATION_JSON).build();
+            }
+            var sse = Response.ok(body, MediaType.SERVER_SENT_EVENTS);
+            if (historyReport != null) {
+                sse.header("X-Gollek-History-Dropped-Messages", historyReport.droppedMessages())
+                        .header("X-Gollek-History-Dropped-Tokens", historyReport.droppedTokens());
+            }
+            if (modelSelection != null) {
+                sse.header("X-Gollek-Model-Selected", modelSelection.selected());
+            }
+            if (language != null) {
+                sse.header("X-Gollek-Language", language.language());
+            }
+            return sse.build();
+        }
+
+        ResponseCache.Lookup cached = responseCache.lookup(inferenceRequest, n,
+                headers.getHeaderString(HttpHeaders.CACHE_CONTROL));
+        try {
+            ChatCompletion hit = cached != null && cached.isHit() ? cachedCompletion(cached.hit(), id) : null;
+            if (hit != null) {
+                if (conversationId != null) {
+                    conversations.appendTurn(conversationId, clientRequest.messages(),
+                            hit.choices().get(0).message().content());
+                }
+                var completion = hit.withHistoryReport(historyReport)
+                        .withModelSelection(modelSelection)
+                        .withDetectedLanguage(language == null ? null : language.language());
+                return Response.ok(completion, MediaType.APPLICATION_JSON).header(ResponseCache.HEADER, "HIT").build();
+            }
+            List<InferenceResponse> responses = complete(sdk, choiceRequests);
+            ResponseFormat format = request.responseFormat();
+            for (int i = 0; i < n && format != null && format.isJson(); i++) {
+                var resp = responses.get(i);
+                var problems = format.validate(resp.getContent());
+                int attempts = 1;
+                while (!problems.isEmpty() && attempts <= responseFormatRetries) {
+                    resp = sdk.createCompletion(choiceRequests.get(i));
+                    problems = format.validate(resp.getContent());
+                    attempts++;
+                }
+                if (!problems.isEmpty()) {
+                    return Response.status(422)
+                            .entity(formatViolation(problems, resp.getContent(), attempts))
+                            .type(MediaType.APPLICATION_JSON).build();
+                }
+                responses.set(i, resp);
+            }
+            if (conversationId != null) {
+                conversations.appendTurn(conversationId, clientRequest.messages(), responses.get(0).getContent());
+            }
+            var answer = ChatCompletions.toChatCompletion(id, request.model(), responses);
+            if (cached != null && ResponseCache.cacheable(responses)) {
+                cached.store(mapper.writeValueAsString(answer));
+            }
+            var completion = answer.withHistoryReport(historyReport)
+                    .withModelSelection(modelSelection)
+                    .withDetectedLanguage(language == null ? null : language.language());
+            var ok = Response.ok(completion, MediaType.APPLICATION_JSON);
+            if (cached != null) {
+                ok.header(ResponseCache.HEADER, "MISS");
+            }
+            return ok.build();
+        } catch (Exception e) {
+            Response rejected = backpressure.reject(e, "chat.completions");
+            if (rejected != null) {
+                return rejected;
+            }
+            Response timedOut = RequestTimeout.reject(e);
+            if (timedOut != null) {
+                return timedOut;
+            }
+            Throwable cause = e;
+            while (cause.getCause() != null && cause.getCause() != cause) {
+                cause = cause.getCause();
+            }
+            if (cause instanceof IllegalArgumentException) {
+                // e.g. a prompt that does not fit the model's context window
+                return Response.status(Response.Status.BAD_REQUEST)
+                        .entity(java.util.Map.of("error", String.valueOf(cause.getMessage())))
+                        .type(MediaType.APPLICATION_JSON).build();
+            }
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(java.util.Map.of("error", e.getMessage())).type(MediaType.APPLICATION_JSON).build();
+        }
+    }
+
+    /** A cached completion under this request's id, or null if it no longer parses. */
+    private ChatCompletion cachedCompletion(String json, String id) {
+        try {
+            return mapper.readValue(json, ChatCompletion.class).withId(id, Instant.now().getEpochSecond());
+        } catch (java.io.IOException e) {
+            return null;
+        }
+    }
+
+    /** A streamed chunk of choice {@code index}. */
+    private record IndexedChunk(int index, StreamingInferenceChunk chunk) {
+    }
+
+    /** The choices' streams interleaved as chunks arrive, so they generate side by side. */
+    private static Multi<IndexedChunk> streamChoices(GollekSdk sdk, List<InferenceRequest> requests) {
+        List<Multi<IndexedChunk>> streams = new ArrayList<>(requests.size());
+        for (int i = 0; i < requests.size(); i++) {
+            int index = i;
+            streams.add(sdk.streamCompletion(requests.get(i)).map(chunk -> new IndexedChunk(index, chunk)));
+        }
+        return streams.size() == 1 ? streams.get(0) : Multi.createBy().merging().streams(streams);
+    }
+
+    /** Runs the choices concurrently; a single choice stays on the calling thread. */
+    private static List<InferenceResponse> complete(GollekSdk sdk, List<InferenceRequest> requests)
+            throws Exception {
+        if (requests.size() == 1) {
+            return new ArrayList<>(List.of(sdk.createCompletion(requests.get(0))));
+        }
+        List<CompletableFuture<InferenceResponse>> futures = requests.stream()
+                .map(sdk::createCompletionAsync)
+                .toList();
+        List<InferenceResponse> responses = new ArrayList<>(futures.size());
+        for (CompletableFuture<InferenceResponse> future : futures) {
+            responses.add(future.join());
+        }
+        return responses;
+    }
+
+    private String summarize(String model, java.util.List<Message> dropped) {
+        StringBuilder transcript = new StringBuilder();
+        for (Message m : dropped) {
+            transcript.append(m.getRole().name().toLowerCase()).append(": ").append(m.getContent()).append('\n');
+        }
+        var request = InferenceRequest.builder()
+                .model(model)
+                .message(Message.system("Summarize this conversation in a few sentences, keeping names, facts "
+                        + "and decisions that later messages may refer to."))
+                .message(Message.user(transcript.toString()))
+                .temperature(0.0)
+                .maxTokens(256)
+                .build();
+        try {
+            return sdkProvider.getSdk().createCompletion(request).getContent();
+        } catch (Exception e) {
+            // fall back to a plain drop rather than failing the user's request
+            return null;
+        }
+    }
+
+    private static java.util.Map<String, Object> formatViolation(java.util.List<String> problems, String output,
+            int attempts) {
+        return java.util.Map.of("error", java.util.Map.of(
+                "type", "response_format_violation",
+                "message", "model output does not match response_format",
+                "violations", problems,
+                "output", output == null ? "" : output,
+                "attempts", attempts));
+    }
+
+    private static void writeEvent(java.io.OutputStream out, String data) throws java.io.IOException {
+        out.write(("data: " + data + "\n\n").getBytes(StandardCharsets.UTF_8));
+        out.flush();
+    }
+
+    private static void writeEvent(java.io.OutputStream out, String event, String data) throws java.io.IOException {
+        out.write(("event: " + event + "\ndata: " + data + "\n\n").getBytes(StandardCharsets.UTF_8));
+        out.flush();
+    }
+}
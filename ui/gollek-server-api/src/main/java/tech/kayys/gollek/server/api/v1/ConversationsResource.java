@@ -0,0 +1,90 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.DELETE;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.PATCH;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.PathParam;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import tech.kayys.gollek.server.conversations.Conversation;
+import tech.kayys.gollek.server.conversations.ConversationStore;
+
+/**
+ * Manage stored conversations. Pass {@code conversation_id} to
+ * {@code /v1/chat/completions} to apply a conversation's system prompt, defaults and history.
+ */
+@Path("/v1/conversations")
+@Produces(MediaType.APPLICATION_JSON)
+public class ConversationsResource {
+
+    @Inject
+    ConversationStore store;
+
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public static record ConversationDTO(
+            String title,
+            String model,
+            @JsonProperty("system_prompt") String systemPrompt,
+            Conversation.Defaults defaults) {
+    }
+
+    @GET
+    public Response list() {
+        return Response.ok(store.list()).build();
+    }
+
+    @POST
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response create(ConversationDTO dto) {
+        ConversationDTO d = dto == null ? new ConversationDTO(null, null, null, null) : dto;
+        return Response.status(Response.Status.CREATED)
+                .entity(store.create(d.title(), d.model(), d.systemPrompt(), d.defaults())).build();
+    }
+
+    @GET
+    @Path("/{id}")
+    public Response get(@PathParam("id") String id) {
+        return store.get(id)
+                .map(c -> Response.ok(c).build())
+                .orElseGet(() -> notFound(id));
+    }
+
+    @PATCH
+    @Path("/{id}")
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response update(@PathParam("id") String id, ConversationDTO dto) {
+        if (dto == null) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "request body is required")).build();
+        }
+        return store.update(id, dto.title(), dto.model(), dto.systemPrompt(), dto.defaults())
+                .map(c -> Response.ok(c).build())
+                .orElseGet(() -> notFound(id));
+    }
+
+    @DELETE
+    @Path("/{id}/messages")
+    public Response clearMessages(@PathParam("id") String id) {
+        return store.clearMessages(id) ? Response.noContent().build() : notFound(id);
+    }
+
+    @DELETE
+    @Path("/{id}")
+    public Response delete(@PathParam("id") String id) {
+        return store.delete(id) ? Response.noContent().build() : notFound(id);
+    }
+
+    private static Response notFound(String id) {
+        return Response.status(Response.Status.NOT_FOUND)
+                .entity(java.util.Map.of("error", "No such conversation: " + id)).build();
+    }
+}
@@ -0,0 +1,53 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.DELETE;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.QueryParam;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import tech.kayys.gollek.server.Lifecycle;
+
+import java.time.Duration;
+import java.util.Map;
+
+/**
+ * Takes a replica out of rotation without cutting anyone off. {@code POST} starts a
+ * drain: readiness fails and new inference requests get {@code 503}, while queued and
+ * streaming ones run to completion for up to {@code timeout_seconds} (default
+ * {@code gollek.server.drain.timeout}); with {@code shutdown=true} the server then exits.
+ * {@code GET} reports progress and {@code DELETE} takes traffic again.
+ */
+@Path("/v1/admin/drain")
+@Produces(MediaType.APPLICATION_JSON)
+public class DrainAdminResource {
+
+    @Inject
+    Lifecycle lifecycle;
+
+    @GET
+    public Response status() {
+        return Response.ok(lifecycle.drainStatus()).build();
+    }
+
+    @POST
+    public Response drain(@QueryParam("timeout_seconds") Long timeoutSeconds,
+            @QueryParam("shutdown") boolean shutdown) {
+        if (timeoutSeconds != null && timeoutSeconds < 0) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(Map.of("error", "timeout_seconds must not be negative")).build();
+        }
+        Duration timeout = timeoutSeconds != null ? Duration.ofSeconds(timeoutSeconds) : lifecycle.drainTimeout();
+        return Response.accepted(lifecycle.drain(timeout, shutdown)).build();
+    }
+
+    @DELETE
+    public Response resume() {
+        lifecycle.resume();
+        return Response.ok(lifecycle.drainStatus()).build();
+    }
+}
@@ -8,27 +8,81 @@ import jakarta.ws.rs.Produces;
 import jakarta.ws.rs.core.MediaType;
 import jakarta.ws.rs.core.Response;
 
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.Backpressure;
 import tech.kayys.gollek.server.SdkProvider;
 import tech.kayys.gollek.spi.embedding.EmbeddingRequest;
 import tech.kayys.gollek.spi.embedding.EmbeddingResponse;
 
+import java.util.ArrayList;
+import java.util.List;
+
+/**
+ * Embeddings. Accepts the native {@link EmbeddingRequest} shape ({@code inputs}) and the
+ * OpenAI shape ({@code input} as a string or array); OpenAI-shaped requests get an
+ * OpenAI {@code list} response.
+ */
 @Path("/v1/embeddings")
 public class EmbeddingsResource {
 
     @Inject
     SdkProvider sdkProvider;
 
+    @Inject
+    ObjectMapper mapper;
+
+    @Inject
+    Backpressure backpressure;
+
     @POST
     @Consumes(MediaType.APPLICATION_JSON)
     @Produces(MediaType.APPLICATION_JSON)
-    public Response createEmbedding(EmbeddingRequest request) {
+    public Response createEmbedding(JsonNode body) {
         try {
             var sdk = sdkProvider.getSdk();
+            if (body != null && body.has("input")) {
+                List<String> inputs = new ArrayList<>();
+                JsonNode input = body.get("input");
+                if (input.isArray()) {
+                    input.forEach(n -> inputs.add(n.asText()));
+                } else {
+                    inputs.add(input.asText());
+                }
+                var request = EmbeddingRequest.builder()
+                        .model(body.path("model").asText(null))
+                        .inputs(inputs)
+                        .build();
+                return Response.ok(toOpenAi(sdk.createEmbedding(request), inputs)).build();
+            }
+            EmbeddingRequest request = mapper.treeToValue(body, EmbeddingRequest.class);
             EmbeddingResponse resp = sdk.createEmbedding(request);
             return Response.ok(resp).build();
+        } catch (IllegalArgumentException | NullPointerException e) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", String.valueOf(e.getMessage()))).build();
         } catch (Exception e) {
+            Response rejected = backpressure.reject(e, "embeddings");
+            if (rejected != null) {
+                return rejected;
+            }
             return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
                     .entity(java.util.Map.of("error", e.getMessage())).build();
         }
     }
+
+    private static java.util.Map<String, Object> toOpenAi(EmbeddingResponse resp, List<String> inputs) {
+        List<java.util.Map<String, Object>> data = new ArrayList<>();
+        for (int i = 0; i < resp.embeddings().size(); i++) {
+            data.add(java.util.Map.of("object", "embedding", "index", i, "embedding", resp.embeddings().get(i)));
+        }
+        // rough estimate; the runner does not report token counts for embeddings
+        int tokens = inputs.stream().mapToInt(s -> (s.length() + 3) / 4).sum();
+        return java.util.Map.of(
+                "object", "list",
+                "model", resp.model() == null ? "" : resp.model(),
+                "data", data,
+                "usage", java.util.Map.of("prompt_tokens", tokens, "total_tokens", tokens));
+    }
 }
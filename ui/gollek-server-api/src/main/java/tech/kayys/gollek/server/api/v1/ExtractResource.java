@@ -0,0 +1,53 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import com.fasterxml.jackson.databind.JsonNode;
+
+import tech.kayys.gollek.server.extract.ExtractionService;
+
+import java.util.List;
+
+/**
+ * Batch structured extraction: one JSON Schema, many documents, one row per document.
+ */
+@Path("/v1/extract")
+public class ExtractResource {
+
+    @Inject
+    ExtractionService extractionService;
+
+    public static record ExtractRequest(String model, JsonNode schema, List<String> documents,
+            String instruction, Integer max_tokens) { }
+
+    public static record ExtractResponse(String model, int succeeded, int failed, List<ExtractionService.Row> rows) { }
+
+    @POST
+    @Consumes(MediaType.APPLICATION_JSON)
+    @Produces(MediaType.APPLICATION_JSON)
+    public Response extract(ExtractRequest request) {
+        if (request == null || request.schema() == null || !request.schema().isObject()) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "schema must be a JSON object")).build();
+        }
+        if (request.documents() == null || request.documents().isEmpty()) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "documents must not be empty")).build();
+        }
+        try {
+            var rows = extractionService.extract(request.model(), request.schema(), request.documents(),
+                    request.instruction(), request.max_tokens());
+            int ok = (int) rows.stream().filter(r -> "ok".equals(r.status())).count();
+            return Response.ok(new ExtractResponse(request.model(), ok, rows.size() - ok, rows)).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
+        }
+    }
+}
@@ -1,17 +1,35 @@
 package tech.kayys.gollek.server.api.v1;
 
+import jakarta.inject.Inject;
 import jakarta.ws.rs.GET;
 import jakarta.ws.rs.Path;
 import jakarta.ws.rs.Produces;
 import jakarta.ws.rs.core.MediaType;
 import jakarta.ws.rs.core.Response;
 
+import tech.kayys.gollek.server.models.ModelBackends;
+
+import java.util.LinkedHashMap;
+import java.util.Map;
+
+/**
+ * Liveness plus the compute backends of in-process providers: {@code backends} lists what
+ * each provider detected, {@code models} the backend each loaded model runs on.
+ */
 @Path("/health")
 public class HealthResource {
 
+    @Inject
+    ModelBackends modelBackends;
+
     @GET
     @Produces(MediaType.APPLICATION_JSON)
     public Response health() {
-        return Response.ok(java.util.Map.of("status", "ok")).build();
+        ModelBackends.Snapshot backends = modelBackends.snapshot();
+        Map<String, Object> body = new LinkedHashMap<>();
+        body.put("status", "ok");
+        body.put("backends", backends.available());
+        body.put("models", backends.models());
+        return Response.ok(body).build();
     }
 }
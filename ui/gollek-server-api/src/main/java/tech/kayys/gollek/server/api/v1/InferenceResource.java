@@ -13,10 +13,18 @@ import jakarta.ws.rs.core.Response;
 import org.jboss.resteasy.reactive.SseElementType;
 
 import io.smallrye.mutiny.Multi;
+import io.vertx.core.http.HttpServerRequest;
+import tech.kayys.gollek.server.Backpressure;
+import tech.kayys.gollek.server.ClientCancellation;
+import tech.kayys.gollek.server.QueueEvents;
+import tech.kayys.gollek.server.RequestTimeout;
+import tech.kayys.gollek.server.SamplingDefaults;
 import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.admission.PriorityPolicy;
 import tech.kayys.gollek.sdk.core.GollekSdk;
 import tech.kayys.gollek.spi.inference.InferenceRequest;
 import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
 import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
 
 @Path("/v1/completions")
@@ -25,6 +33,21 @@ public class InferenceResource {
     @Inject
     SdkProvider sdkProvider;
 
+    @Inject
+    PriorityPolicy priorityPolicy;
+
+    @Inject
+    RequestTimeout requestTimeout;
+
+    @Inject
+    SamplingDefaults samplingDefaults;
+
+    @Inject
+    Backpressure backpressure;
+
+    @Context
+    HttpServerRequest httpRequest;
+
     @POST
     @Consumes(MediaType.APPLICATION_JSON)
     @Produces(MediaType.APPLICATION_JSON)
@@ -35,9 +58,23 @@ public class InferenceResource {
             if (apiKey != null && (request.getApiKey() == null || request.getApiKey().isBlank())) {
                 request = request.toBuilder().apiKey(apiKey).build();
             }
+            request = priorityPolicy.apply(request, apiKey, headers.getHeaderString(PriorityPolicy.HEADER));
+            request = requestTimeout.apply(samplingDefaults.apply(request));
+            ClientCancellation.onDisconnect(httpRequest, request.getRequestId());
             InferenceResponse resp = sdk.createCompletion(request);
             return Response.ok(resp).build();
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
         } catch (Exception e) {
+            Response rejected = backpressure.reject(e, "completions");
+            if (rejected != null) {
+                return rejected;
+            }
+            Response timedOut = RequestTimeout.reject(e);
+            if (timedOut != null) {
+                return timedOut;
+            }
             return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
                     .entity(java.util.Map.of("error", e.getMessage())).build();
         }
@@ -54,6 +91,15 @@ public class InferenceResource {
         if (apiKey != null && (request.getApiKey() == null || request.getApiKey().isBlank())) {
             request = request.toBuilder().apiKey(apiKey).build();
         }
-        return sdk.streamCompletion(request);
+        request = priorityPolicy.apply(request, apiKey, headers.getHeaderString(PriorityPolicy.HEADER));
+        request = QueueEvents.apply(request, headers.getHeaderString(QueueEvents.HEADER));
+        try {
+            request = requestTimeout.apply(samplingDefaults.apply(request));
+        } catch (IllegalArgumentException e) {
+            return Multi.createFrom().failure(e);
+        }
+        String requestId = request.getRequestId();
+        return sdk.streamCompletion(request)
+                .onCancellation().invoke(() -> RequestCancellation.cancel(requestId));
     }
 }
@@ -7,10 +7,17 @@ import jakarta.ws.rs.POST;
 import jakarta.ws.rs.Path;
 import jakarta.ws.rs.PathParam;
 import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.HttpHeaders;
 import jakarta.ws.rs.core.MediaType;
 import jakarta.ws.rs.core.Response;
 
+import tech.kayys.gollek.server.RequestTimeout;
+import tech.kayys.gollek.server.SamplingDefaults;
 import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.admission.PriorityPolicy;
+import tech.kayys.gollek.server.jobs.JobQueue;
+import tech.kayys.gollek.server.jobs.MapReduceJob;
 import tech.kayys.gollek.spi.inference.AsyncJobStatus;
 import tech.kayys.gollek.spi.inference.InferenceRequest;
 
@@ -20,14 +27,66 @@ public class JobsResource {
     @Inject
     SdkProvider sdkProvider;
 
+    @Inject
+    JobQueue jobQueue;
+
+    @Inject
+    PriorityPolicy priorityPolicy;
+
+    @Inject
+    RequestTimeout requestTimeout;
+
+    @Inject
+    SamplingDefaults samplingDefaults;
+
     @POST
     @Consumes(MediaType.APPLICATION_JSON)
     @Produces(MediaType.APPLICATION_JSON)
-    public Response submitJob(InferenceRequest request) {
+    public Response submitJob(@Context HttpHeaders headers, InferenceRequest request) {
+        if (request == null) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "request body is required")).build();
+        }
         try {
-            var sdk = sdkProvider.getSdk();
-            String jobId = sdk.submitAsyncJob(request);
-            return Response.accepted(java.util.Map.of("jobId", jobId)).build();
+            String priority = headers.getHeaderString(PriorityPolicy.HEADER);
+            if (priority == null && request.getParameters().get(PriorityPolicy.PARAMETER) == null) {
+                // batch work yields to interactive requests unless it asks otherwise
+                priority = "low";
+            }
+            request = priorityPolicy.apply(request, headers.getHeaderString("X-API-Key"), priority);
+            request = requestTimeout.apply(samplingDefaults.apply(request));
+            var jobId = jobQueue.submit(request);
+            if (jobId.isEmpty()) {
+                return Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                        .entity(java.util.Map.of("error", "Job queue is full")).build();
+            }
+            return Response.accepted(java.util.Map.of("jobId", jobId.get(), "type", JobQueue.TYPE)).build();
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
+        }
+    }
+
+    @POST
+    @Path("/map-reduce")
+    @Consumes(MediaType.APPLICATION_JSON)
+    @Produces(MediaType.APPLICATION_JSON)
+    public Response submitMapReduceJob(MapReduceJob.Spec spec) {
+        if (spec == null || spec.input() == null || spec.input().isBlank()) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "input is required")).build();
+        }
+        if (spec.mapPrompt() == null || spec.mapPrompt().isBlank()) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "mapPrompt is required")).build();
+        }
+        try {
+            var mgr = jakarta.enterprise.inject.spi.CDI.current().select(tech.kayys.gollek.server.jobs.BackgroundJobManager.class).get();
+            String jobId = mgr.startMapReduceJob(spec);
+            return Response.accepted(java.util.Map.of("jobId", jobId, "type", MapReduceJob.TYPE)).build();
         } catch (Exception e) {
             return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
                     .entity(java.util.Map.of("error", e.getMessage())).build();
@@ -40,11 +99,7 @@ public class JobsResource {
         try {
             var mgr = jakarta.enterprise.inject.spi.CDI.current().select(tech.kayys.gollek.server.jobs.BackgroundJobManager.class).get();
             var list = mgr.listJobs();
-            var out = list.stream().map(x -> java.util.Map.of(
-                    "jobId", x.jobId(),
-                    "status", x.status(),
-                    "lastProgress", x.lastProgress(),
-                    "error", x.error())).toList();
+            var out = list.stream().map(JobsResource::toMap).toList();
             return Response.ok(out).build();
         } catch (Exception e) {
             return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
@@ -52,6 +107,13 @@ public class JobsResource {
         }
     }
 
+    @GET
+    @Path("/queue")
+    @Produces(MediaType.APPLICATION_JSON)
+    public Response queueDepth() {
+        return Response.ok(jobQueue.depth()).build();
+    }
+
     @GET
     @Path("/{id}")
     @Produces(MediaType.APPLICATION_JSON)
@@ -61,11 +123,7 @@ public class JobsResource {
                     .select(tech.kayys.gollek.server.jobs.BackgroundJobManager.class).get().getJobInfo(id);
             if (opt.isPresent()) {
                 var x = opt.get();
-                return Response.ok(java.util.Map.of(
-                        "jobId", x.jobId(),
-                        "status", x.status(),
-                        "lastProgress", x.lastProgress(),
-                        "error", x.error())).build();
+                return Response.ok(toMap(x)).build();
             }
             var sdk = sdkProvider.getSdk();
             AsyncJobStatus status = sdk.getJobStatus(id);
@@ -90,10 +148,16 @@ public class JobsResource {
         }
     }
 
-    // helper to query job manager
-    private Object jobStatus(String id) {
-        var mgr = jakarta.enterprise.inject.spi.CDI.current().select(tech.kayys.gollek.server.jobs.BackgroundJobManager.class).get();
-        var opt = mgr.getJobInfo(id);
-        return opt.map(x -> java.util.Map.of("jobId", x.jobId(), "status", x.status(), "lastProgress", x.lastProgress(), "error", x.error())).orElse(null);
+    // job info may carry nulls (no progress yet, no error), so Map.of is not usable here
+    private static java.util.Map<String, Object> toMap(tech.kayys.gollek.server.jobs.JobRecord.Info x) {
+        var m = new java.util.LinkedHashMap<String, Object>();
+        m.put("jobId", x.jobId());
+        m.put("type", x.type());
+        m.put("status", x.status());
+        m.put("lastProgress", x.lastProgress());
+        m.put("stageProgress", x.stageProgress());
+        m.put("result", x.result());
+        m.put("error", x.error());
+        return m;
     }
 }
@@ -0,0 +1,94 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.HttpHeaders;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import io.vertx.core.http.HttpServerRequest;
+import tech.kayys.gollek.server.Lifecycle;
+
+import java.net.InetAddress;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Orchestration endpoints: {@code GET /live} answers as long as the process does and
+ * {@code GET /ready} returns {@code 503} until the server can take traffic, both
+ * unauthenticated like {@code /health}. {@code POST /quitquitquit} shuts the server down;
+ * it is off unless enabled, needs the admin secret, and only accepts direct connections
+ * from the loopback interface, so a sidecar or {@code docker exec} can stop the server
+ * but remote clients cannot.
+ */
+@Path("/")
+@Produces(MediaType.APPLICATION_JSON)
+public class LifecycleResource {
+
+    @Inject
+    Lifecycle lifecycle;
+
+    /** Headers a proxy adds; any of them means the socket's address is not the client's. */
+    static final List<String> PROXY_HEADERS = List.of("X-Forwarded-For", "Forwarded", "X-Real-IP");
+
+    @ConfigProperty(name = "gollek.server.quitquitquit.enabled", defaultValue = "false")
+    boolean quitEnabled;
+
+    @Context
+    HttpServerRequest httpRequest;
+
+    @GET
+    @Path("live")
+    public Response live() {
+        return Response.ok(Map.of("status", "alive")).build();
+    }
+
+    @GET
+    @Path("ready")
+    public Response ready() {
+        Lifecycle.Readiness readiness = lifecycle.readiness();
+        Map<String, Object> body = new LinkedHashMap<>();
+        body.put("status", readiness.ready() ? "ready" : "not_ready");
+        body.put("checks", readiness.checks());
+        if (!readiness.ready()) {
+            body.put("reasons", readiness.reasons());
+        }
+        return Response.status(readiness.ready() ? Response.Status.OK : Response.Status.SERVICE_UNAVAILABLE)
+                .entity(body).build();
+    }
+
+    @POST
+    @Path("quitquitquit")
+    public Response quit(@Context HttpHeaders headers) {
+        if (!quitEnabled) {
+            return Response.status(Response.Status.NOT_FOUND)
+                    .entity(Map.of("error", "Shutdown endpoint is disabled")).build();
+        }
+        // a forwarded request reached us through a proxy, whatever its socket says
+        if (PROXY_HEADERS.stream().anyMatch(h -> headers.getHeaderString(h) != null) || !fromLoopback()) {
+            return Response.status(Response.Status.FORBIDDEN)
+                    .entity(Map.of("error", "Shutdown is only accepted from localhost")).build();
+        }
+        lifecycle.shutdown("POST /quitquitquit");
+        return Response.accepted(Map.of("status", "shutting_down")).build();
+    }
+
+    private boolean fromLoopback() {
+        if (httpRequest == null || httpRequest.remoteAddress() == null
+                || httpRequest.remoteAddress().hostAddress() == null) {
+            return false;
+        }
+        try {
+            return InetAddress.getByName(httpRequest.remoteAddress().hostAddress()).isLoopbackAddress();
+        } catch (Exception e) {
+            return false;
+        }
+    }
+}
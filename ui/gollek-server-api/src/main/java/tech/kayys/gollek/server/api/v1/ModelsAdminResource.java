@@ -0,0 +1,173 @@
+package tech.kayys.gollek.server.api.v1;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+import jakarta.enterprise.event.Event;
+import jakarta.enterprise.inject.Any;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.models.ModelCache;
+import tech.kayys.gollek.server.models.ModelReloaded;
+import tech.kayys.gollek.server.versioning.Sunset;
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.ReloadableProvider;
+
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Hot model reload: loads a new file for a model, warms it up and swaps it in without a
+ * restart. In-flight requests finish on the old weights. The same path changes a loaded
+ * model's GPU offload. Also lists and collects the content-addressed {@link ModelCache}.
+ */
+@Path("/v1/admin/models")
+@Produces(MediaType.APPLICATION_JSON)
+public class ModelsAdminResource {
+
+    private static final Logger LOG = Logger.getLogger(ModelsAdminResource.class);
+    private static final Duration DEFAULT_DRAIN_TIMEOUT = Duration.ofSeconds(60);
+
+    @Inject
+    @Any
+    Instance<LLMProvider> providers;
+
+    @Inject
+    Event<ModelReloaded> reloaded;
+
+    @Inject
+    ModelCache modelCache;
+
+    /**
+     * {@code path} is optional (reload the current file); {@code provider} limits the
+     * reload to one provider id.
+     */
+    public static record ReloadDTO(String model, String path, String provider) { }
+
+    /**
+     * {@code drain} defaults to true: the old instances are unloaded before the new ones
+     * load, so the VRAM they held is available. {@code drain_timeout_ms} defaults to 60 s.
+     */
+    public static record OffloadDTO(String model, @JsonProperty("gpu_layers") Integer gpuLayers, Boolean drain,
+            @JsonProperty("drain_timeout_ms") Long drainTimeoutMs, String provider) { }
+
+    @POST
+    @Path("/reload")
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response reload(ReloadDTO dto) {
+        if (dto == null || dto.model() == null || dto.model().isBlank()) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(Map.of("error", "model required")).build();
+        }
+        return reload(dto.model(), dto.path(), dto.provider(), ReloadableProvider.ReloadOptions.DEFAULT);
+    }
+
+    /**
+     * Reloads a model with a different {@code gpu_layers}, e.g. after another process freed
+     * VRAM. Requests queue while the pool drains and the model loads; the new value also
+     * applies to later loads of the model.
+     */
+    @POST
+    @Path("/offload")
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response offload(OffloadDTO dto) {
+        if (dto == null || dto.model() == null || dto.model().isBlank()) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(Map.of("error", "model required")).build();
+        }
+        if (dto.gpuLayers() == null) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(Map.of("error", "gpu_layers required")).build();
+        }
+        Duration timeout = dto.drainTimeoutMs() == null ? DEFAULT_DRAIN_TIMEOUT
+                : Duration.ofMillis(Math.max(0, dto.drainTimeoutMs()));
+        return reload(dto.model(), null, dto.provider(), new ReloadableProvider.ReloadOptions(
+                dto.gpuLayers(), !Boolean.FALSE.equals(dto.drain()), timeout));
+    }
+
+    private Response reload(String model, String path, String providerId, ReloadableProvider.ReloadOptions options) {
+        List<ReloadableProvider.ModelReload> reloads = new ArrayList<>();
+        try {
+            for (LLMProvider provider : providers) {
+                if (!(provider instanceof ReloadableProvider reloadable)) {
+                    continue;
+                }
+                if (providerId != null && !providerId.equals(provider.id())) {
+                    continue;
+                }
+                try {
+                    reloads.add(reloadable.reloadModel(model, path, options));
+                } catch (UnsupportedOperationException e) {
+                    LOG.debugf("Provider %s cannot reload %s with %s: %s", provider.id(), model, options,
+                            e.getMessage());
+                }
+            }
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(Map.of("error", e.getMessage())).build();
+        } catch (IllegalStateException e) {
+            return Response.status(Response.Status.CONFLICT)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        } catch (Exception e) {
+            LOG.warnf(e, "Reload of %s failed", model);
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        }
+        if (reloads.isEmpty()) {
+            return Response.status(Response.Status.NOT_FOUND)
+                    .entity(Map.of("error", "no provider supports reloading"
+                            + (providerId == null ? "" : " for " + providerId))).build();
+        }
+        modelCache.touch(model);
+        modelCache.touch(path);
+        reloaded.fire(new ModelReloaded(model));
+        return Response.ok(Map.of("model", model, "reloads", reloads)).build();
+    }
+
+    /** Superseded by {@code GET /v1/admin/storage}, which lists each file's blob too. */
+    @GET
+    @Path("/cache")
+    @Sunset(date = "2027-06-30", successor = "/v1/admin/storage")
+    public Response cache() {
+        if (!modelCache.isEnabled()) {
+            return cacheDisabled();
+        }
+        try {
+            return Response.ok(modelCache.describe()).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        }
+    }
+
+    /** Deduplicates new files, evicts unpinned models over the size cap, drops orphan blobs. */
+    @POST
+    @Path("/cache/gc")
+    public Response gc() {
+        if (!modelCache.isEnabled()) {
+            return cacheDisabled();
+        }
+        try {
+            return Response.ok(modelCache.gc()).build();
+        } catch (Exception e) {
+            LOG.warnf(e, "Model cache gc failed");
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        }
+    }
+
+    private static Response cacheDisabled() {
+        return Response.status(Response.Status.CONFLICT)
+                .entity(Map.of("error", "model cache disabled; set gollek.server.model-cache.enabled=true")).build();
+    }
+}
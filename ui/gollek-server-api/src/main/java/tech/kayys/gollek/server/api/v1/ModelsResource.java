@@ -11,15 +11,22 @@ import jakarta.ws.rs.Produces;
 import jakarta.ws.rs.core.MediaType;
 import jakarta.ws.rs.core.Response;
 
+import com.fasterxml.jackson.annotation.JsonProperty;
+import com.fasterxml.jackson.annotation.JsonUnwrapped;
 import io.smallrye.mutiny.Multi;
 import org.jboss.resteasy.reactive.SseElementType;
 
 import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.models.KvCacheEstimate;
+import tech.kayys.gollek.server.models.ModelBackends;
+import tech.kayys.gollek.server.models.ModelCapabilities;
+import tech.kayys.gollek.server.models.ModelCapabilityService;
 import tech.kayys.gollek.sdk.core.GollekSdk;
 import tech.kayys.gollek.spi.model.ModelInfo;
 import tech.kayys.gollek.sdk.model.PullProgress;
 
 import java.util.List;
+import java.util.Map;
 import java.util.Optional;
 import java.util.function.Consumer;
 import java.util.stream.Collectors;
@@ -30,12 +37,31 @@ public class ModelsResource {
     @Inject
     SdkProvider sdkProvider;
 
+    @Inject
+    ModelCapabilityService capabilityService;
+
+    @Inject
+    ModelBackends modelBackends;
+
+    /**
+     * A listed model with its derived capability flags, KV cache estimate, chat template
+     * and, once loaded, the compute backend it runs on.
+     */
+    public static record ModelEntry(@JsonUnwrapped ModelInfo model, ModelCapabilities capabilities,
+            @JsonProperty("kv_cache") KvCacheEstimate kvCache, String backend,
+            @JsonProperty("chat_template") Map<?, ?> chatTemplate) { }
+
+    /**
+     * Lists models. {@code capability} (chat, vision, embeddings, reranker, tools) keeps only
+     * models that have it, e.g. {@code ?capability=vision}.
+     */
     @GET
     @Produces(MediaType.APPLICATION_JSON)
     public Response listModels(
             @jakarta.ws.rs.QueryParam("runnableOnly") boolean runnableOnly,
             @jakarta.ws.rs.QueryParam("limit") @jakarta.ws.rs.DefaultValue("50") int limit,
-            @jakarta.ws.rs.QueryParam("namespace") String namespace) {
+            @jakarta.ws.rs.QueryParam("namespace") String namespace,
+            @jakarta.ws.rs.QueryParam("capability") String capability) {
         GollekSdk sdk = sdkProvider.getSdk();
         try {
             tech.kayys.gollek.sdk.model.ModelListRequest request = tech.kayys.gollek.sdk.model.ModelListRequest.builder()
@@ -46,7 +72,12 @@ public class ModelsResource {
                     .sort(true)
                     .build();
             
-            List<ModelInfo> models = sdk.listModels(request);
+            ModelBackends.Snapshot backends = modelBackends.snapshot();
+            List<ModelEntry> models = sdk.listModels(request).stream()
+                    .map(m -> new ModelEntry(m, capabilityService.capabilities(m), capabilityService.kvCache(m),
+                            backends.backendOf(m.getModelId()), chatTemplate(m, backends)))
+                    .filter(e -> capability == null || capability.isBlank() || e.capabilities().has(capability))
+                    .collect(Collectors.toList());
             return Response.ok(models).build();
         } catch (Exception e) {
             return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
@@ -63,11 +94,26 @@ public class ModelsResource {
             Optional<ModelInfo> info = sdk.getModelInfo(id);
             if (info.isPresent()) {
                 ModelInfo m = info.get();
-                return Response.ok(java.util.Map.of(
+                var body = new java.util.LinkedHashMap<String, Object>(java.util.Map.of(
                         "modelId", m.getModelId(),
                         "format", m.getFormat(),
                         "description", m.getDescription(),
-                        "size", m.getSize())).build();
+                        "size", m.getSize(),
+                        "capabilities", capabilityService.capabilities(m)));
+                KvCacheEstimate kvCache = capabilityService.kvCache(m);
+                if (kvCache != null) {
+                    body.put("kv_cache", kvCache);
+                }
+                ModelBackends.Snapshot backends = modelBackends.snapshot();
+                String backend = backends.backendOf(m.getModelId());
+                if (backend != null) {
+                    body.put("backend", backend);
+                }
+                Map<?, ?> chatTemplate = chatTemplate(m, backends);
+                if (chatTemplate != null) {
+                    body.put("chat_template", chatTemplate);
+                }
+                return Response.ok(body).build();
             } else {
                 return Response.status(Response.Status.NOT_FOUND).build();
             }
@@ -77,6 +123,15 @@ public class ModelsResource {
         }
     }
 
+    /**
+     * The template chat requests are rendered with: what the runner reports once the model
+     * is loaded (including the detected family), otherwise what config and the GGUF imply.
+     */
+    private Map<?, ?> chatTemplate(ModelInfo m, ModelBackends.Snapshot backends) {
+        Map<?, ?> loaded = backends.chatTemplateOf(m.getModelId());
+        return loaded != null ? loaded : capabilityService.chatTemplateInfo(m);
+    }
+
     public static record PullRequestDTO(String modelSpec, String revision, boolean force) { }
 
     @Inject
@@ -0,0 +1,27 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import tech.kayys.gollek.server.metrics.ScalingSignals;
+
+/**
+ * Autoscaling input: a 0..1 load {@code score} with the signals it was taken from. Point
+ * a KEDA {@code metrics-api} trigger at it with {@code valueLocation: score}.
+ */
+@Path("/v1/admin/scaling")
+@Produces(MediaType.APPLICATION_JSON)
+public class ScalingAdminResource {
+
+    @Inject
+    ScalingSignals signals;
+
+    @GET
+    public Response scaling() {
+        return Response.ok(signals.snapshot()).build();
+    }
+}
@@ -0,0 +1,92 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.DELETE;
+import jakarta.ws.rs.DefaultValue;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.PUT;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.PathParam;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.QueryParam;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import tech.kayys.gollek.server.schedules.ScheduledTask;
+import tech.kayys.gollek.server.schedules.TaskScheduler;
+
+@Path("/v1/schedules")
+@Produces(MediaType.APPLICATION_JSON)
+public class SchedulesResource {
+
+    @Inject
+    TaskScheduler scheduler;
+
+    @GET
+    public Response list() {
+        return Response.ok(scheduler.list()).build();
+    }
+
+    @POST
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response create(ScheduledTask spec) {
+        if (spec == null) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "request body is required")).build();
+        }
+        try {
+            return Response.status(Response.Status.CREATED).entity(scheduler.create(spec)).build();
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.BAD_REQUEST).entity(java.util.Map.of("error", e.getMessage())).build();
+        }
+    }
+
+    @GET
+    @Path("/{id}")
+    public Response get(@PathParam("id") String id) {
+        return scheduler.get(id).map(t -> Response.ok(t).build()).orElseGet(() -> notFound(id));
+    }
+
+    @PUT
+    @Path("/{id}")
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response replace(@PathParam("id") String id, ScheduledTask spec) {
+        if (spec == null) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(java.util.Map.of("error", "request body is required")).build();
+        }
+        try {
+            return scheduler.replace(id, spec).map(t -> Response.ok(t).build()).orElseGet(() -> notFound(id));
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.BAD_REQUEST).entity(java.util.Map.of("error", e.getMessage())).build();
+        }
+    }
+
+    @DELETE
+    @Path("/{id}")
+    public Response delete(@PathParam("id") String id) {
+        return scheduler.delete(id) ? Response.noContent().build() : notFound(id);
+    }
+
+    @POST
+    @Path("/{id}/run")
+    public Response runNow(@PathParam("id") String id) {
+        return scheduler.runNow(id).map(r -> Response.ok(r).build()).orElseGet(() -> notFound(id));
+    }
+
+    @GET
+    @Path("/{id}/results")
+    public Response results(@PathParam("id") String id, @QueryParam("limit") @DefaultValue("20") int limit) {
+        if (scheduler.get(id).isEmpty()) {
+            return notFound(id);
+        }
+        return Response.ok(scheduler.results(id, limit)).build();
+    }
+
+    private static Response notFound(String id) {
+        return Response.status(Response.Status.NOT_FOUND)
+                .entity(java.util.Map.of("error", "No such schedule: " + id)).build();
+    }
+}
@@ -0,0 +1,71 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.enterprise.inject.Any;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.ProviderHealth;
+
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Per-slot KV cache occupancy of in-process providers. Providers that track slots report
+ * them in their health details under {@code slots}; one entry per loaded runner.
+ */
+@Path("/v1/admin/slots")
+@Produces(MediaType.APPLICATION_JSON)
+public class SlotsAdminResource {
+
+    private static final Logger LOG = Logger.getLogger(SlotsAdminResource.class);
+    private static final Duration HEALTH_TIMEOUT = Duration.ofSeconds(2);
+
+    @Inject
+    @Any
+    Instance<LLMProvider> providers;
+
+    @GET
+    public Response slots() {
+        return Response.ok(Map.of("runners", details(providers, "slots"))).build();
+    }
+
+    /**
+     * The entries of the list-valued health detail {@code name} of every provider, each
+     * tagged with its {@code provider}. Providers whose health is unavailable are skipped.
+     */
+    public static List<Map<String, Object>> details(Iterable<LLMProvider> providers, String name) {
+        List<Map<String, Object>> runners = new ArrayList<>();
+        for (LLMProvider provider : providers) {
+            ProviderHealth health;
+            try {
+                health = provider.health().await().atMost(HEALTH_TIMEOUT);
+            } catch (Exception e) {
+                LOG.debugf("Health of provider %s unavailable: %s", provider.id(), e.getMessage());
+                continue;
+            }
+            if (health == null || !(health.details().get(name) instanceof List<?> entries)) {
+                continue;
+            }
+            for (Object entry : entries) {
+                if (entry instanceof Map<?, ?> runner) {
+                    Map<String, Object> out = new LinkedHashMap<>();
+                    out.put("provider", provider.id());
+                    runner.forEach((k, v) -> out.put(String.valueOf(k), v));
+                    runners.add(out);
+                }
+            }
+        }
+        return runners;
+    }
+}
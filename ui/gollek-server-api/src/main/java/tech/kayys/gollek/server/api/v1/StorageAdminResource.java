@@ -0,0 +1,172 @@
+package tech.kayys.gollek.server.api.v1;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.DELETE;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.PUT;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.PathParam;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.QueryParam;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import tech.kayys.gollek.server.models.ModelCache;
+import tech.kayys.gollek.server.store.BackupService;
+import tech.kayys.gollek.server.store.RetentionService;
+
+import java.time.Duration;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Store backups, restores and retention, plus the model directory: disk usage, per-file sizes and
+ * last use, deleting unused GGUFs and the LRU size bound of the {@link ModelCache}.
+ */
+@Path("/v1/admin/storage")
+@Produces(MediaType.APPLICATION_JSON)
+public class StorageAdminResource {
+
+    @Inject
+    BackupService backups;
+
+    @Inject
+    RetentionService retention;
+
+    @Inject
+    ModelCache modelCache;
+
+    public static record PolicyDTO(@JsonProperty("max_cache_bytes") Long maxCacheBytes) { }
+
+    @GET
+    public Response usage() {
+        try {
+            List<ModelCache.FileUsage> files = modelCache.files();
+            Map<String, Object> out = new LinkedHashMap<>();
+            out.put("dir", modelCache.root().toString());
+            out.put("disk", modelCache.disk());
+            out.put("models_bytes", modelCache.usedBytes());
+            out.put("max_cache_bytes", modelCache.maxSizeBytes());
+            out.put("content_store", modelCache.isEnabled());
+            out.put("files", files);
+            return Response.ok(out).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        }
+    }
+
+    @DELETE
+    @Path("/models/{path: .+}")
+    public Response deleteModel(@PathParam("path") String path) {
+        try {
+            long freed = modelCache.delete(path);
+            return Response.ok(Map.of("deleted", path, "freed_bytes", freed)).build();
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.NOT_FOUND).entity(Map.of("error", e.getMessage())).build();
+        } catch (IllegalStateException e) {
+            return Response.status(Response.Status.CONFLICT).entity(Map.of("error", e.getMessage())).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        }
+    }
+
+    /** Deletes unpinned model files not used for {@code unused_days} days. */
+    @POST
+    @Path("/models/prune")
+    public Response prune(@QueryParam("unused_days") Integer unusedDays) {
+        if (unusedDays == null || unusedDays < 0) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(Map.of("error", "unused_days required")).build();
+        }
+        try {
+            return Response.ok(Map.of("deleted", modelCache.prune(Duration.ofDays(unusedDays)))).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        }
+    }
+
+    @GET
+    @Path("/models/policy")
+    public Response policy() {
+        return Response.ok(Map.of("max_cache_bytes", modelCache.maxSizeBytes())).build();
+    }
+
+    /**
+     * Sets the LRU bound until restart ({@code gollek.server.model-cache.max-size} keeps it)
+     * and evicts right away; 0 turns eviction off.
+     */
+    @PUT
+    @Path("/models/policy")
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response setPolicy(PolicyDTO dto) {
+        if (dto == null || dto.maxCacheBytes() == null || dto.maxCacheBytes() < 0) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .entity(Map.of("error", "max_cache_bytes >= 0 required")).build();
+        }
+        try {
+            modelCache.setMaxSizeBytes(dto.maxCacheBytes());
+            return Response.ok(Map.of("max_cache_bytes", dto.maxCacheBytes(), "gc", modelCache.gc())).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        }
+    }
+
+    @GET
+    @Path("/backups")
+    public Response listBackups() {
+        try {
+            return Response.ok(backups.list()).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
+        }
+    }
+
+    @POST
+    @Path("/backups")
+    public Response backup() {
+        try {
+            return Response.status(Response.Status.CREATED).entity(backups.backup()).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(java.util.Map.of("error", e.getMessage())).build();
+        }
+    }
+
+    /**
+     * Replaces the store's contents with backup {@code file}: every namespace it holds, or
+     * only those given as {@code namespace} query parameters.
+     */
+    @POST
+    @Path("/backups/{file}/restore")
+    public Response restore(@PathParam("file") String file, @QueryParam("namespace") List<String> namespaces) {
+        try {
+            return Response.ok(Map.of("file", file, "restored", backups.restore(file, namespaces))).build();
+        } catch (IllegalArgumentException e) {
+            return Response.status(Response.Status.NOT_FOUND).entity(Map.of("error", e.getMessage())).build();
+        } catch (Exception e) {
+            return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
+                    .entity(Map.of("error", String.valueOf(e.getMessage()))).build();
+        }
+    }
+
+    @GET
+    @Path("/retention")
+    public Response retentionPolicy() {
+        return Response.ok(retention.policy()).build();
+    }
+
+    @POST
+    @Path("/retention/enforce")
+    public Response enforceRetention() {
+        return Response.ok(retention.enforce()).build();
+    }
+}
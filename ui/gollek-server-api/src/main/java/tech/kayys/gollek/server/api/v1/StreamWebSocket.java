@@ -0,0 +1,135 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+
+import io.quarkus.websockets.next.OnClose;
+import io.quarkus.websockets.next.OnError;
+import io.quarkus.websockets.next.OnTextMessage;
+import io.quarkus.websockets.next.WebSocket;
+import io.quarkus.websockets.next.WebSocketConnection;
+import io.smallrye.mutiny.Multi;
+
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.Lifecycle;
+import tech.kayys.gollek.server.QueueEvents;
+import tech.kayys.gollek.server.RequestTimeout;
+import tech.kayys.gollek.server.SamplingDefaults;
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.admission.AdmissionController;
+import tech.kayys.gollek.server.admission.PriorityPolicy;
+import tech.kayys.gollek.server.security.RateLimiter;
+import tech.kayys.gollek.server.security.WebSocketUpgradeCheck;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+
+import java.util.Map;
+import java.util.Set;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Token streaming over WebSocket for clients that handle it better than SSE. Each text
+ * message is a completion request (the body of {@code POST /v1/completions/stream}); each
+ * reply frame is a {@link StreamingInferenceChunk}, the same schema as the SSE events.
+ * Requests on one connection may overlap and are told apart by {@code requestId}. Closing
+ * the connection cancels its in-flight generations. While the server drains, new requests
+ * are refused and those in flight stream to the end.
+ *
+ * <p>The handshake is authenticated and rate limited by {@link WebSocketUpgradeCheck}; each
+ * request is then charged to its API key's rate limit and, when admission control is on,
+ * holds a worker slot until it ends, like a request over HTTP.
+ */
+@WebSocket(path = "/v1/stream")
+public class StreamWebSocket {
+
+    private static final Logger LOG = Logger.getLogger(StreamWebSocket.class);
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    PriorityPolicy priorityPolicy;
+
+    @Inject
+    RequestTimeout requestTimeout;
+
+    @Inject
+    SamplingDefaults samplingDefaults;
+
+    @Inject
+    WebSocketConnection connection;
+
+    @Inject
+    Lifecycle lifecycle;
+
+    @Inject
+    RateLimiter limiter;
+
+    @Inject
+    AdmissionController admission;
+
+    /** Request ids in flight, by connection id. */
+    private final Map<String, Set<String>> inFlight = new ConcurrentHashMap<>();
+
+    @OnTextMessage
+    public Multi<StreamingInferenceChunk> onMessage(InferenceRequest request) {
+        String apiKey = WebSocketUpgradeCheck.apiKey(connection.handshakeRequest().header("X-API-Key"),
+                connection.handshakeRequest().header("Authorization"));
+        if (apiKey != null && (request.getApiKey() == null || request.getApiKey().isBlank())) {
+            request = request.toBuilder().apiKey(apiKey).build();
+        }
+        request = priorityPolicy.apply(request, apiKey, connection.handshakeRequest().header(PriorityPolicy.HEADER));
+        request = QueueEvents.apply(request, connection.handshakeRequest().header(QueueEvents.HEADER));
+        try {
+            request = requestTimeout.apply(samplingDefaults.apply(request));
+        } catch (IllegalArgumentException e) {
+            return Multi.createFrom().failure(e);
+        }
+        if (apiKey != null && !limiter.checkApiKey(apiKey).allowed()) {
+            return Multi.createFrom().failure(new IllegalStateException("Rate limit exceeded"));
+        }
+        if (!lifecycle.enter()) {
+            return Multi.createFrom().failure(new IllegalStateException(
+                    lifecycle.isDraining() ? "Server is draining" : "Server is shutting down"));
+        }
+        AdmissionController.Permit permit = null;
+        if (admission.isEnabled()) {
+            permit = admission.admit(connection.handshakeRequest().header(AdmissionController.HEADER), apiKey);
+            if (permit == null) {
+                lifecycle.exit();
+                return Multi.createFrom().failure(new IllegalStateException("No worker slot available"));
+            }
+        }
+        AdmissionController.Permit held = permit;
+        String connectionId = connection.id();
+        String requestId = request.getRequestId();
+        inFlight.computeIfAbsent(connectionId, k -> ConcurrentHashMap.newKeySet()).add(requestId);
+        return sdkProvider.getSdk().streamCompletion(request)
+                .onCancellation().invoke(() -> RequestCancellation.cancel(requestId))
+                .onTermination().invoke(() -> {
+                    lifecycle.exit();
+                    if (held != null) {
+                        held.release();
+                    }
+                    Set<String> ids = inFlight.get(connectionId);
+                    if (ids != null) {
+                        ids.remove(requestId);
+                    }
+                });
+    }
+
+    @OnClose
+    public void onClose() {
+        Set<String> ids = inFlight.remove(connection.id());
+        if (ids != null && !ids.isEmpty()) {
+            LOG.debugf("WebSocket %s closed with %d request(s) in flight", connection.id(), ids.size());
+            ids.forEach(RequestCancellation::cancel);
+        }
+    }
+
+    @OnError
+    public Map<String, Object> onError(Throwable error) {
+        return Map.of("error", Map.of("message", String.valueOf(error.getMessage())));
+    }
+}
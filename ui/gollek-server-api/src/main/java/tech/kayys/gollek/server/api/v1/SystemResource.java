@@ -1,5 +1,7 @@
 package tech.kayys.gollek.server.api.v1;
 
+import jakarta.enterprise.inject.Any;
+import jakarta.enterprise.inject.Instance;
 import jakarta.inject.Inject;
 import jakarta.ws.rs.GET;
 import jakarta.ws.rs.Path;
@@ -7,25 +9,86 @@ import jakarta.ws.rs.Produces;
 import jakarta.ws.rs.core.MediaType;
 import jakarta.ws.rs.core.Response;
 
+import org.jboss.logging.Logger;
+
 import tech.kayys.gollek.server.SdkProvider;
 import tech.kayys.gollek.sdk.model.SystemInfo;
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.ProviderHealth;
+
+import java.time.Duration;
+import java.util.LinkedHashMap;
+import java.util.Map;
 
+/**
+ * Host and engine identity, the first thing a bug report needs. Besides the SDK's host
+ * details, {@code metadata} carries the gollek version and, under {@code engines}, what
+ * each in-process provider reports about its native engine in its health details under
+ * {@code engine} (for llama.cpp: ggml version and commit, compiled-in backends, devices,
+ * CUDA/Metal versions and the binding).
+ */
 @Path("/v1/system")
 public class SystemResource {
 
+    private static final Logger LOG = Logger.getLogger(SystemResource.class);
+    private static final Duration HEALTH_TIMEOUT = Duration.ofSeconds(2);
+
     @Inject
     SdkProvider sdkProvider;
 
+    @Inject
+    @Any
+    Instance<LLMProvider> providers;
+
     @GET
     @Produces(MediaType.APPLICATION_JSON)
     public Response getSystemInfo() {
         try {
             var sdk = sdkProvider.getSdk();
             SystemInfo info = sdk.getSystemInfo();
-            return Response.ok(info).build();
+            Map<String, Object> metadata = new LinkedHashMap<>(info.getMetadata());
+            metadata.put("gollek_version", serverVersion());
+            metadata.put("engines", engines());
+            return Response.ok(SystemInfo.builder()
+                    .cliVersion(info.getCliVersion())
+                    .javaVersion(info.getJavaVersion())
+                    .osName(info.getOsName())
+                    .osVersion(info.getOsVersion())
+                    .osArch(info.getOsArch())
+                    .userName(info.getUserName())
+                    .userHome(info.getUserHome())
+                    .totalMemory(info.getTotalMemory())
+                    .freeMemory(info.getFreeMemory())
+                    .maxMemory(info.getMaxMemory())
+                    .availableProcessors(info.getAvailableProcessors())
+                    .metadata(metadata)
+                    .build()).build();
         } catch (Exception e) {
             return Response.status(Response.Status.INTERNAL_SERVER_ERROR)
                     .entity(java.util.Map.of("error", e.getMessage())).build();
         }
     }
+
+    /** Provider id to its {@code engine} health detail, for providers that report one. */
+    Map<String, Object> engines() {
+        Map<String, Object> engines = new LinkedHashMap<>();
+        for (LLMProvider provider : providers) {
+            ProviderHealth health;
+            try {
+                health = provider.health().await().atMost(HEALTH_TIMEOUT);
+            } catch (Exception e) {
+                LOG.debugf("Health of provider %s unavailable: %s", provider.id(), e.getMessage());
+                continue;
+            }
+            if (health != null && health.details().get("engine") instanceof Map<?, ?> engine) {
+                engines.put(provider.id(), engine);
+            }
+        }
+        return engines;
+    }
+
+    private static String serverVersion() {
+        String version = SystemResource.class.getPackage().getImplementationVersion();
+        return version != null ? version : "dev";
+    }
 }
@@ -0,0 +1,88 @@
+package tech.kayys.gollek.server.api.v1;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import tech.kayys.gollek.server.models.TokenizerService;
+
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.function.Supplier;
+
+/**
+ * The loaded model's tokenizer over HTTP, with the request and response shapes of the
+ * llama.cpp server's {@code /tokenize} and {@code /detokenize}.
+ */
+@Path("/v1")
+@Produces(MediaType.APPLICATION_JSON)
+@Consumes(MediaType.APPLICATION_JSON)
+public class TokenizeResource {
+
+    @Inject
+    TokenizerService tokenizers;
+
+    public static record TokenizeDTO(
+            String model,
+            String content,
+            @JsonProperty("add_special") Boolean addSpecial,
+            @JsonProperty("with_pieces") Boolean withPieces) { }
+
+    public static record DetokenizeDTO(String model, List<Integer> tokens) { }
+
+    @POST
+    @Path("/tokenize")
+    public Response tokenize(TokenizeDTO dto) {
+        if (dto == null || dto.content() == null) {
+            return badRequest("content required");
+        }
+        return run(() -> {
+            int[] ids = tokenizers.tokenize(dto.model(), dto.content(), Boolean.TRUE.equals(dto.addSpecial()));
+            List<Object> tokens = new ArrayList<>(ids.length);
+            for (int id : ids) {
+                if (Boolean.TRUE.equals(dto.withPieces())) {
+                    Map<String, Object> token = new LinkedHashMap<>();
+                    token.put("id", id);
+                    token.put("piece", tokenizers.tokenPiece(dto.model(), id));
+                    tokens.add(token);
+                } else {
+                    tokens.add(id);
+                }
+            }
+            return Map.of("tokens", tokens);
+        });
+    }
+
+    @POST
+    @Path("/detokenize")
+    public Response detokenize(DetokenizeDTO dto) {
+        if (dto == null || dto.tokens() == null) {
+            return badRequest("tokens required");
+        }
+        return run(() -> Map.of("content", tokenizers.detokenize(dto.model(),
+                dto.tokens().stream().mapToInt(Integer::intValue).toArray())));
+    }
+
+    private static Response run(Supplier<Map<String, Object>> action) {
+        try {
+            return Response.ok(action.get()).build();
+        } catch (IllegalArgumentException e) {
+            return badRequest(e.getMessage());
+        } catch (UnsupportedOperationException e) {
+            return Response.status(Response.Status.NOT_IMPLEMENTED)
+                    .entity(Map.of("error", e.getMessage())).build();
+        }
+    }
+
+    private static Response badRequest(String message) {
+        return Response.status(Response.Status.BAD_REQUEST).entity(Map.of("error", message)).build();
+    }
+}
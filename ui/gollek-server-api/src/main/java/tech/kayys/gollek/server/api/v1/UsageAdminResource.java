@@ -0,0 +1,93 @@
+package tech.kayys.gollek.server.api.v1;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.DefaultValue;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.PathParam;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.QueryParam;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+
+import tech.kayys.gollek.server.metrics.UsageReports;
+import tech.kayys.gollek.server.metrics.UsageTotals;
+
+import java.time.YearMonth;
+import java.time.format.DateTimeParseException;
+import java.util.Map;
+
+/**
+ * Lifetime completion and token counts. With {@code gollek.server.metrics.persist.enabled}
+ * they carry over from earlier runs; otherwise they start at this run. Monthly per-tenant
+ * reports are under {@code /reports}.
+ */
+@Path("/v1/admin/usage")
+@Produces(MediaType.APPLICATION_JSON)
+public class UsageAdminResource {
+
+    @Inject
+    UsageTotals usage;
+
+    @Inject
+    UsageReports reports;
+
+    @GET
+    public Response usage() {
+        return Response.ok(usage.snapshot()).build();
+    }
+
+    @GET
+    @Path("/reports")
+    public Response reports() {
+        return Response.ok(Map.of("reports", reports.months())).build();
+    }
+
+    /** A saved monthly report, {@code ?format=csv} for the spreadsheet form. */
+    @GET
+    @Path("/reports/{month}")
+    @Produces({ MediaType.APPLICATION_JSON, "text/csv" })
+    public Response report(@PathParam("month") String month,
+            @QueryParam("format") @DefaultValue("json") String format) {
+        YearMonth parsed = parse(month);
+        if (parsed == null) {
+            return error(Response.Status.BAD_REQUEST, "month must be yyyy-MM");
+        }
+        if (!format.equals("json") && !format.equals("csv")) {
+            return error(Response.Status.BAD_REQUEST, "format must be json or csv");
+        }
+        return reports.report(parsed, format)
+                .map(body -> Response.ok(body, format.equals("csv") ? "text/csv" : MediaType.APPLICATION_JSON)
+                        .header("Content-Disposition",
+                                "attachment; filename=\"gollek-usage-" + parsed + "." + format + "\"")
+                        .build())
+                .orElseGet(() -> error(Response.Status.NOT_FOUND, "no usage report for " + parsed));
+    }
+
+    /** Builds a report now; for the current month it covers usage so far. */
+    @POST
+    @Path("/reports/{month}")
+    public Response generate(@PathParam("month") String month) {
+        YearMonth parsed = parse(month);
+        if (parsed == null) {
+            return error(Response.Status.BAD_REQUEST, "month must be yyyy-MM");
+        }
+        if (!reports.enabled()) {
+            return error(Response.Status.CONFLICT, "usage reports are disabled");
+        }
+        return Response.ok(reports.generate(parsed)).build();
+    }
+
+    private static YearMonth parse(String month) {
+        try {
+            return YearMonth.parse(month);
+        } catch (DateTimeParseException e) {
+            return null;
+        }
+    }
+
+    private static Response error(Response.Status status, String message) {
+        return Response.status(status).entity(Map.of("error", message)).type(MediaType.APPLICATION_JSON).build();
+    }
+}
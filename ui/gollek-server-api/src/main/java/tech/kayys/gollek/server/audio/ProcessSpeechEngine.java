@@ -0,0 +1,191 @@
+package tech.kayys.gollek.server.audio;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.WorkerPool;
+
+import java.io.ByteArrayInputStream;
+import java.io.IOException;
+import java.io.InputStream;
+import java.io.OutputStream;
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.concurrent.ExecutionException;
+import java.util.concurrent.Executor;
+import java.util.concurrent.FutureTask;
+import java.util.concurrent.RejectedExecutionException;
+import java.util.concurrent.TimeUnit;
+import java.util.concurrent.TimeoutException;
+import java.util.concurrent.atomic.AtomicReference;
+
+/**
+ * Speech engine that pipes text into a local TTS command (piper, espeak-ng, ...) and
+ * returns the audio it writes to stdout.
+ *
+ * <p>The command is split on whitespace; {@code {voice}} and {@code {speed}} are substituted
+ * per request, e.g. {@code piper --model {voice} --length_scale {speed} --output_file -}.
+ * Clients pick a voice by name from {@code voices.<name>=<value>}, and only the operator's
+ * value reaches the command; other names are rejected. Without any voices configured
+ * every request uses {@code default-voice}.
+ *
+ * <p>Like {@link WhisperCppEngine}, commands run on the shared {@link WorkerPool}, at most
+ * {@code workers} at a time with {@code max-queue} more waiting, and are killed after
+ * {@code timeout-seconds}. The audio is buffered until the command exits, so a failed
+ * command is reported as an error instead of a truncated response.
+ */
+@ApplicationScoped
+public class ProcessSpeechEngine implements SpeechEngine {
+
+    private static final Logger LOG = Logger.getLogger(ProcessSpeechEngine.class);
+
+    @ConfigProperty(name = "gollek.server.audio.speech.command")
+    Optional<String> command;
+
+    @ConfigProperty(name = "gollek.server.audio.speech.default-voice", defaultValue = "")
+    String defaultVoice;
+
+    @ConfigProperty(name = "gollek.server.audio.speech.voices")
+    Optional<Map<String, String>> voices;
+
+    @ConfigProperty(name = "gollek.server.audio.speech.format", defaultValue = "wav")
+    String nativeFormat;
+
+    @ConfigProperty(name = "gollek.server.audio.speech.workers", defaultValue = "1")
+    int workers;
+
+    @ConfigProperty(name = "gollek.server.audio.speech.max-queue", defaultValue = "8")
+    int maxQueue;
+
+    @ConfigProperty(name = "gollek.server.audio.speech.timeout-seconds", defaultValue = "120")
+    long timeoutSeconds;
+
+    @Inject
+    WorkerPool workerPool;
+
+    private volatile Executor executor;
+
+    @Override
+    public boolean isAvailable() {
+        return command.filter(c -> !c.isBlank()).isPresent();
+    }
+
+    @Override
+    public String contentType(String format) {
+        return switch (format == null ? nativeFormat : format) {
+            case "mp3" -> "audio/mpeg";
+            case "opus" -> "audio/opus";
+            case "flac" -> "audio/flac";
+            case "pcm" -> "audio/L16";
+            default -> "audio/wav";
+        };
+    }
+
+    @Override
+    public InputStream synthesize(Request request) throws IOException {
+        if (!isAvailable()) {
+            throw new IllegalStateException("Speech is not enabled; set gollek.server.audio.speech.command");
+        }
+        if (request.format() != null && !request.format().equals(nativeFormat)) {
+            throw new IllegalArgumentException("unsupported response_format: " + request.format()
+                    + " (backend produces " + nativeFormat + ")");
+        }
+        // piper-style length scale: larger is slower, so invert the OpenAI speed factor
+        String speed = String.valueOf(request.speed() == null || request.speed() <= 0 ? 1.0f : 1.0f / request.speed());
+
+        List<String> cmd = new ArrayList<>();
+        String voice = voice(request.voice());
+        for (String part : command.get().trim().split("\\s+")) {
+            cmd.add(part.replace("{voice}", voice).replace("{speed}", speed));
+        }
+        byte[] input = request.input().getBytes(StandardCharsets.UTF_8);
+        AtomicReference<Process> running = new AtomicReference<>();
+        FutureTask<byte[]> future = new FutureTask<>(() -> run(cmd, input, running));
+        try {
+            executor().execute(future);
+        } catch (RejectedExecutionException e) {
+            throw new IllegalStateException("Speech queue is full");
+        }
+        try {
+            return new ByteArrayInputStream(future.get(timeoutSeconds, TimeUnit.SECONDS));
+        } catch (TimeoutException e) {
+            future.cancel(true);
+            throw new IllegalStateException("Speech synthesis timed out after " + timeoutSeconds + "s");
+        } catch (InterruptedException e) {
+            future.cancel(true);
+            Thread.currentThread().interrupt();
+            throw new IOException("interrupted waiting for speech synthesis", e);
+        } catch (ExecutionException e) {
+            if (e.getCause() instanceof IOException cause) {
+                throw cause;
+            }
+            throw new IOException(e.getCause());
+        } finally {
+            // reading stdout does not notice interrupts; killing the command ends it
+            Process process = running.get();
+            if (process != null) {
+                process.destroyForcibly();
+            }
+        }
+    }
+
+    private static byte[] run(List<String> cmd, byte[] input, AtomicReference<Process> running)
+            throws IOException, InterruptedException {
+        Process process = new ProcessBuilder(cmd)
+                .redirectError(ProcessBuilder.Redirect.DISCARD)
+                .start();
+        running.set(process);
+        // fed from its own thread: a backend that fills its stdout pipe before reading all
+        // of stdin would otherwise block both sides
+        Thread.ofVirtual().name("gollek-tts-stdin").start(() -> {
+            try (OutputStream stdin = process.getOutputStream()) {
+                stdin.write(input);
+            } catch (IOException e) {
+                // the backend exited early; its exit status tells the rest
+                LOG.debugf("TTS input not fully written: %s", e.getMessage());
+            }
+        });
+        byte[] audio;
+        try (InputStream stdout = process.getInputStream()) {
+            audio = stdout.readAllBytes();
+        }
+        int exit = process.waitFor();
+        if (exit != 0) {
+            throw new IOException("TTS command exited with status " + exit);
+        }
+        return audio;
+    }
+
+    private Executor executor() {
+        Executor e = executor;
+        if (e == null) {
+            synchronized (this) {
+                if (executor == null) {
+                    executor = workerPool.limit(workers, maxQueue);
+                }
+                e = executor;
+            }
+        }
+        return e;
+    }
+
+    /** The configured value for a client's voice name. */
+    String voice(String requested) {
+        Map<String, String> allowed = voices.orElse(Map.of());
+        if (requested == null || requested.isBlank() || allowed.isEmpty()) {
+            return defaultVoice;
+        }
+        String value = allowed.get(requested);
+        if (value == null || value.isBlank()) {
+            throw new IllegalArgumentException("unknown voice: " + requested + " (available: "
+                    + String.join(", ", allowed.keySet().stream().sorted().toList()) + ")");
+        }
+        return value;
+    }
+}
@@ -0,0 +1,29 @@
+package tech.kayys.gollek.server.audio;
+
+import java.io.IOException;
+import java.io.InputStream;
+
+/**
+ * Text-to-speech backend used by the {@code /v1/audio/speech} route.
+ */
+public interface SpeechEngine {
+
+    /**
+     * Whether a TTS backend is configured.
+     */
+    boolean isAvailable();
+
+    /**
+     * Media type of the audio produced for the requested format, e.g. {@code audio/wav}.
+     */
+    String contentType(String format);
+
+    /**
+     * Synthesize the input and return the audio once the backend finished successfully.
+     * A backend that fails throws {@link IOException}; one that is busy or too slow throws
+     * {@link IllegalStateException}.
+     */
+    InputStream synthesize(Request request) throws IOException;
+
+    public static record Request(String input, String voice, String format, Float speed) { }
+}
@@ -0,0 +1,29 @@
+package tech.kayys.gollek.server.audio;
+
+import java.nio.file.Path;
+import java.util.List;
+
+/**
+ * Speech-to-text backend used by the {@code /v1/audio/transcriptions} route.
+ */
+public interface TranscriptionEngine {
+
+    /**
+     * Whether the engine is configured and its runtime (binary, model) is present.
+     */
+    boolean isAvailable();
+
+    /**
+     * Transcribe (or translate to English) the given audio file.
+     *
+     * @throws IllegalArgumentException if the audio cannot be decoded or the model is unknown
+     * @throws IllegalStateException if the engine is busy, disabled or timed out
+     */
+    Transcription transcribe(Path audioFile, Options options) throws Exception;
+
+    public static record Options(String model, String language, String prompt, Float temperature, boolean translate) { }
+
+    public static record Segment(int id, double start, double end, String text) { }
+
+    public static record Transcription(String text, String language, double duration, List<Segment> segments) { }
+}
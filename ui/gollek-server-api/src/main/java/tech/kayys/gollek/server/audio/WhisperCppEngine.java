@@ -0,0 +1,230 @@
+package tech.kayys.gollek.server.audio;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.WorkerPool;
+
+import java.io.FileNotFoundException;
+import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.concurrent.ExecutionException;
+import java.util.concurrent.Executor;
+import java.util.concurrent.FutureTask;
+import java.util.concurrent.RejectedExecutionException;
+import java.util.concurrent.TimeUnit;
+import java.util.concurrent.TimeoutException;
+
+/**
+ * Transcription engine backed by the whisper.cpp command line tool.
+ *
+ * <p>Each request runs {@code whisper-cli} as a subprocess on the shared
+ * {@link WorkerPool}, at most {@code workers} at a time so speech jobs cannot starve the
+ * rest of the server. The JSON output ({@code -oj}) is parsed into segments with
+ * millisecond offsets.
+ *
+ * <p>A request's {@code model} picks one of the {@code models.<name>} files; without
+ * any configured, or for OpenAI's {@code whisper-1}, {@code model} is used.
+ */
+@ApplicationScoped
+public class WhisperCppEngine implements TranscriptionEngine {
+
+    private static final Logger LOG = Logger.getLogger(WhisperCppEngine.class);
+
+    @ConfigProperty(name = "gollek.server.audio.whisper.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.audio.whisper.binary", defaultValue = "whisper-cli")
+    String binary;
+
+    @ConfigProperty(name = "gollek.server.audio.whisper.model", defaultValue = "")
+    String modelPath;
+
+    @ConfigProperty(name = "gollek.server.audio.whisper.models")
+    Optional<Map<String, String>> models;
+
+    @ConfigProperty(name = "gollek.server.audio.whisper.threads", defaultValue = "4")
+    int threads;
+
+    @ConfigProperty(name = "gollek.server.audio.whisper.workers", defaultValue = "1")
+    int workers;
+
+    @ConfigProperty(name = "gollek.server.audio.whisper.max-queue", defaultValue = "8")
+    int maxQueue;
+
+    @ConfigProperty(name = "gollek.server.audio.whisper.timeout-seconds", defaultValue = "300")
+    long timeoutSeconds;
+
+    @Inject
+    WorkerPool workerPool;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+    private volatile Executor executor;
+
+    @Override
+    public boolean isAvailable() {
+        return enabled && !modelPath.isBlank() && Files.exists(Path.of(modelPath));
+    }
+
+    @Override
+    public Transcription transcribe(Path audioFile, Options options) throws Exception {
+        if (!isAvailable()) {
+            throw new IllegalStateException("Transcription is not enabled; set gollek.server.audio.whisper.enabled and .model");
+        }
+        if (Files.size(audioFile) == 0) {
+            throw new IllegalArgumentException("audio file is empty");
+        }
+        String model = model(options.model());
+        FutureTask<Transcription> future = new FutureTask<>(() -> run(audioFile, model, options));
+        try {
+            executor().execute(future);
+        } catch (RejectedExecutionException e) {
+            throw new IllegalStateException("Transcription queue is full");
+        }
+        try {
+            return future.get(timeoutSeconds, TimeUnit.SECONDS);
+        } catch (TimeoutException e) {
+            future.cancel(true);
+            throw new IllegalStateException("Transcription timed out after " + timeoutSeconds + "s");
+        } catch (ExecutionException e) {
+            // keep the cause's type: bad audio is the client's fault, a crash is ours
+            if (e.getCause() instanceof Exception cause) {
+                throw cause;
+            }
+            throw e;
+        }
+    }
+
+    /** The model file for a request's {@code model} field. */
+    String model(String requested) {
+        Map<String, String> named = models.orElse(Map.of());
+        if (named.isEmpty() || requested == null || requested.isBlank() || requested.equals("whisper-1")) {
+            return modelPath;
+        }
+        String path = named.get(requested);
+        if (path == null) {
+            throw new IllegalArgumentException("unknown model: " + requested);
+        }
+        return path;
+    }
+
+    private Transcription run(Path audioFile, String model, Options options) throws IOException, InterruptedException {
+        Path outDir = Files.createTempDirectory("gollek-whisper");
+        Path outBase = outDir.resolve("out");
+        List<String> cmd = new ArrayList<>(List.of(
+                binary,
+                "-m", model,
+                "-f", audioFile.toString(),
+                "-t", String.valueOf(Math.max(1, threads)),
+                "-oj", "-of", outBase.toString(),
+                "-np"));
+        if (options.language() != null && !options.language().isBlank()) {
+            cmd.add("-l");
+            cmd.add(options.language());
+        } else {
+            cmd.add("-l");
+            cmd.add("auto");
+        }
+        if (options.prompt() != null && !options.prompt().isBlank()) {
+            cmd.add("--prompt");
+            cmd.add(options.prompt());
+        }
+        if (options.temperature() != null) {
+            cmd.add("--temperature");
+            cmd.add(String.valueOf(options.temperature()));
+        }
+        if (options.translate()) {
+            cmd.add("-tr");
+        }
+
+        Process process = new ProcessBuilder(cmd)
+                .redirectErrorStream(true)
+                .redirectOutput(outDir.resolve("whisper.log").toFile())
+                .start();
+        try {
+            int exit = process.waitFor();
+            Path json = outBase.resolveSibling("out.json");
+            if (exit != 0 || !Files.exists(json)) {
+                String log = Files.readString(outDir.resolve("whisper.log"), StandardCharsets.UTF_8);
+                LOG.debug("whisper.cpp output: " + log);
+                // whisper-cli skips an unreadable input, sometimes with status 0 and no output
+                if (unreadableAudio(log)) {
+                    throw new IllegalArgumentException("could not decode the audio file; send WAV, MP3, FLAC or OGG");
+                }
+                throw exit != 0 ? new IOException("whisper.cpp exited with status " + exit)
+                        : new FileNotFoundException("whisper.cpp wrote no transcription");
+            }
+            return parse(mapper.readTree(json.toFile()), options);
+        } finally {
+            process.destroyForcibly();
+            deleteQuietly(outDir);
+        }
+    }
+
+    Transcription parse(JsonNode root, Options options) {
+        List<Segment> segments = new ArrayList<>();
+        StringBuilder text = new StringBuilder();
+        double duration = 0.0;
+        int id = 0;
+        for (JsonNode seg : root.path("transcription")) {
+            double start = seg.path("offsets").path("from").asLong() / 1000.0;
+            double end = seg.path("offsets").path("to").asLong() / 1000.0;
+            String segText = seg.path("text").asText("");
+            segments.add(new Segment(id++, start, end, segText.strip()));
+            text.append(segText);
+            duration = Math.max(duration, end);
+        }
+        String language = root.path("result").path("language").asText(null);
+        if (options.translate()) {
+            language = "en";
+        } else if (language == null) {
+            language = options.language();
+        }
+        return new Transcription(text.toString().strip(), language, duration, segments);
+    }
+
+    /** Whether whisper-cli's output says it could not read the input audio. */
+    static boolean unreadableAudio(String log) {
+        String lower = log.toLowerCase(Locale.ROOT);
+        return lower.contains("failed to read audio") || lower.contains("as wav file")
+                || lower.contains("failed to decode");
+    }
+
+    private Executor executor() {
+        Executor e = executor;
+        if (e == null) {
+            synchronized (this) {
+                if (executor == null) {
+                    executor = workerPool.limit(workers, maxQueue);
+                }
+                e = executor;
+            }
+        }
+        return e;
+    }
+
+    private static void deleteQuietly(Path dir) {
+        try (var files = Files.walk(dir)) {
+            files.sorted(java.util.Comparator.reverseOrder()).forEach(p -> {
+                try {
+                    Files.deleteIfExists(p);
+                } catch (IOException ignored) {
+                }
+            });
+        } catch (IOException ignored) {
+        }
+    }
+}
@@ -0,0 +1,254 @@
+package tech.kayys.gollek.server.audit;
+
+import jakarta.annotation.PostConstruct;
+import jakarta.annotation.PreDestroy;
+import jakarta.enterprise.context.ApplicationScoped;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.security.AtRestCipher;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.io.IOException;
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.StandardOpenOption;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.HexFormat;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.TreeMap;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.concurrent.TimeUnit;
+import java.util.regex.Pattern;
+
+/**
+ * Per-request audit trail: one JSON line per completion with the request id, masked API
+ * key, model, parameters, token counts, latency and finish reason, written to a file or
+ * POSTed to an HTTP sink off the request thread.
+ *
+ * <p>Prompt and response text are recorded as SHA-256 hashes by default
+ * ({@code capture=hash}), verbatim ({@code full}, after the {@code redact-patterns} are
+ * replaced) or not at all ({@code none}). Parameters named in {@code redact-parameters}
+ * are masked; API keys never appear beyond their last four characters.
+ *
+ * <p>With a storage encryption key configured, each line of the audit file is the
+ * base64 of the AES-GCM sealed record (see {@link AtRestCipher}); records sent to the
+ * HTTP sink are not encrypted.
+ */
+@ApplicationScoped
+public class AuditLog {
+
+    private static final Logger LOG = Logger.getLogger(AuditLog.class);
+    static final String REDACTED = "[REDACTED]";
+
+    @ConfigProperty(name = "gollek.server.audit.enabled", defaultValue = "false")
+    boolean enabled;
+
+    /** {@code file} or {@code http}. */
+    @ConfigProperty(name = "gollek.server.audit.sink", defaultValue = "file")
+    String sink;
+
+    @ConfigProperty(name = "gollek.server.audit.file", defaultValue = "./data/audit.jsonl")
+    String file;
+
+    @ConfigProperty(name = "gollek.server.audit.url")
+    Optional<String> url;
+
+    /** {@code hash}, {@code full} or {@code none}. */
+    @ConfigProperty(name = "gollek.server.audit.capture", defaultValue = "hash")
+    String capture;
+
+    @ConfigProperty(name = "gollek.server.audit.redact-patterns")
+    Optional<List<String>> redactPatterns;
+
+    @ConfigProperty(name = "gollek.server.audit.redact-parameters")
+    Optional<List<String>> redactParameters;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key")
+    Optional<String> encryptionKey;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key-file")
+    Optional<String> encryptionKeyFile;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+    private final HttpClient http = HttpClient.newBuilder().connectTimeout(Duration.ofSeconds(10)).build();
+    private final ExecutorService writer = Executors.newSingleThreadExecutor(r -> {
+        Thread t = new Thread(r, "gollek-audit");
+        t.setDaemon(true);
+        return t;
+    });
+    private volatile List<Pattern> patterns;
+    AtRestCipher cipher;
+
+    @PostConstruct
+    void init() {
+        try {
+            cipher = AtRestCipher.fromConfig(encryptionKey, encryptionKeyFile).orElse(null);
+        } catch (IOException e) {
+            throw new IllegalStateException("Failed to read storage encryption key: " + e.getMessage(), e);
+        }
+    }
+
+    public boolean enabled() {
+        return enabled;
+    }
+
+    /**
+     * Records a finished request. {@code error} is set instead of a finish reason when
+     * the request failed.
+     */
+    public void record(InferenceRequest request, boolean stream, String response, int inputTokens, int outputTokens,
+            String finishReason, long startNanos, Throwable error) {
+        if (!enabled) {
+            return;
+        }
+        Map<String, Object> entry = entry(request, stream, response, inputTokens, outputTokens, finishReason,
+                (System.nanoTime() - startNanos) / 1_000_000, error);
+        String line;
+        try {
+            line = mapper.writeValueAsString(entry);
+        } catch (JsonProcessingException e) {
+            LOG.warnf("Cannot serialize audit record for %s: %s", request.getRequestId(), e.getMessage());
+            return;
+        }
+        writer.execute(() -> write(line));
+    }
+
+    /** The line written to the audit file for {@code record}. */
+    String fileLine(String record) {
+        return cipher == null ? record : cipher.encryptToString(record);
+    }
+
+    Map<String, Object> entry(InferenceRequest request, boolean stream, String response, int inputTokens,
+            int outputTokens, String finishReason, long latencyMs, Throwable error) {
+        Map<String, Object> entry = new LinkedHashMap<>();
+        entry.put("timestamp", Instant.now().toString());
+        entry.put("request_id", request.getRequestId());
+        entry.put("api_key", maskKey(request.getApiKey()));
+        entry.put("model", request.getModel());
+        entry.put("stream", stream);
+        String mode = capture.toLowerCase(Locale.ROOT);
+        if (mode.equals("full")) {
+            List<Map<String, String>> messages = new ArrayList<>();
+            for (Message m : request.getMessages()) {
+                messages.add(Map.of("role", m.getRole().name().toLowerCase(Locale.ROOT),
+                        "content", redact(m.getContent() == null ? "" : m.getContent())));
+            }
+            entry.put("prompt", messages);
+            entry.put("response", response == null ? null : redact(response));
+        } else if (mode.equals("hash")) {
+            entry.put("prompt_sha256", sha256(transcript(request)));
+            entry.put("response_sha256", response == null ? null : sha256(response));
+        }
+        Map<String, Object> params = new TreeMap<>();
+        List<String> masked = redactParameters.orElse(List.of());
+        request.getParameters().forEach((k, v) -> {
+            // the raw prompt is text, captured (or not) with the messages
+            if (!k.equals("prompt")) {
+                params.put(k, masked.contains(k) ? REDACTED : v);
+            }
+        });
+        entry.put("parameters", params);
+        entry.put("input_tokens", inputTokens);
+        entry.put("output_tokens", outputTokens);
+        entry.put("latency_ms", latencyMs);
+        entry.put("finish_reason", finishReason);
+        if (error != null) {
+            entry.put("error", error.getClass().getSimpleName() + ": " + error.getMessage());
+        }
+        return entry;
+    }
+
+    private void write(String line) {
+        try {
+            if ("http".equalsIgnoreCase(sink)) {
+                HttpRequest req = HttpRequest.newBuilder(URI.create(url.orElseThrow(
+                        () -> new IllegalArgumentException("gollek.server.audit.url is not set"))))
+                        .timeout(Duration.ofSeconds(30))
+                        .header("Content-Type", "application/x-ndjson")
+                        .POST(HttpRequest.BodyPublishers.ofString(line + "\n"))
+                        .build();
+                HttpResponse<Void> resp = http.send(req, HttpResponse.BodyHandlers.discarding());
+                if (resp.statusCode() / 100 != 2) {
+                    LOG.warnf("Audit sink returned HTTP %d", resp.statusCode());
+                }
+            } else {
+                Path path = Path.of(file);
+                if (path.getParent() != null) {
+                    Files.createDirectories(path.getParent());
+                }
+                Files.writeString(path, fileLine(line) + "\n", StandardCharsets.UTF_8, StandardOpenOption.CREATE,
+                        StandardOpenOption.APPEND);
+            }
+        } catch (IOException | IllegalArgumentException e) {
+            LOG.warnf("Audit record not written: %s", e.getMessage());
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+        }
+    }
+
+    @PreDestroy
+    void close() {
+        writer.shutdown();
+        try {
+            // let queued records reach the sink before exit
+            writer.awaitTermination(5, TimeUnit.SECONDS);
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+        }
+    }
+
+    String redact(String text) {
+        if (patterns == null) {
+            patterns = redactPatterns.orElse(List.of()).stream().map(Pattern::compile).toList();
+        }
+        for (Pattern pattern : patterns) {
+            text = pattern.matcher(text).replaceAll(REDACTED);
+        }
+        return text;
+    }
+
+    public static String maskKey(String apiKey) {
+        if (apiKey == null || apiKey.isEmpty()) {
+            return null;
+        }
+        return apiKey.length() <= 4 ? "****" : "****" + apiKey.substring(apiKey.length() - 4);
+    }
+
+    private static String transcript(InferenceRequest request) {
+        StringBuilder sb = new StringBuilder();
+        for (Message m : request.getMessages()) {
+            sb.append(m.getRole().name().toLowerCase(Locale.ROOT)).append(": ")
+                    .append(m.getContent() == null ? "" : m.getContent()).append('\n');
+        }
+        return sb.toString();
+    }
+
+    static String sha256(String text) {
+        try {
+            return HexFormat.of().formatHex(MessageDigest.getInstance("SHA-256")
+                    .digest(text.getBytes(StandardCharsets.UTF_8)));
+        } catch (NoSuchAlgorithmException e) {
+            throw new IllegalStateException("SHA-256 unavailable", e);
+        }
+    }
+}
@@ -0,0 +1,194 @@
+package tech.kayys.gollek.server.audit;
+
+import io.smallrye.mutiny.Multi;
+
+import tech.kayys.gollek.sdk.core.GollekSdk;
+import tech.kayys.gollek.sdk.exception.SdkException;
+import tech.kayys.gollek.sdk.model.ModelResolution;
+import tech.kayys.gollek.sdk.model.PullProgress;
+import tech.kayys.gollek.sdk.model.SystemInfo;
+import tech.kayys.gollek.spi.batch.BatchInferenceRequest;
+import tech.kayys.gollek.spi.embedding.EmbeddingRequest;
+import tech.kayys.gollek.spi.embedding.EmbeddingResponse;
+import tech.kayys.gollek.spi.inference.AsyncJobStatus;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+import tech.kayys.gollek.spi.model.ModelInfo;
+import tech.kayys.gollek.spi.provider.ProviderInfo;
+
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Optional;
+import java.util.concurrent.CompletableFuture;
+import java.util.function.Consumer;
+
+/**
+ * SDK decorator that writes an {@link AuditLog} record for every completion, stream and
+ * batch item, including failed and cancelled ones. All other operations are delegated
+ * unchanged.
+ */
+public class AuditingSdk implements GollekSdk {
+
+    private final GollekSdk delegate;
+    private final AuditLog audit;
+
+    public AuditingSdk(GollekSdk delegate, AuditLog audit) {
+        this.delegate = delegate;
+        this.audit = audit;
+    }
+
+    @Override
+    public InferenceResponse createCompletion(InferenceRequest request) throws SdkException {
+        long start = System.nanoTime();
+        try {
+            InferenceResponse resp = delegate.createCompletion(request);
+            record(request, resp, start);
+            return resp;
+        } catch (SdkException | RuntimeException e) {
+            audit.record(request, false, null, 0, 0, null, start, e);
+            throw e;
+        }
+    }
+
+    @Override
+    public CompletableFuture<InferenceResponse> createCompletionAsync(InferenceRequest request) {
+        long start = System.nanoTime();
+        return delegate.createCompletionAsync(request).whenComplete((resp, error) -> {
+            if (error != null) {
+                audit.record(request, false, null, 0, 0, null, start, error);
+            } else {
+                record(request, resp, start);
+            }
+        });
+    }
+
+    @Override
+    public Multi<StreamingInferenceChunk> streamCompletion(InferenceRequest request) {
+        long start = System.nanoTime();
+        StringBuilder content = new StringBuilder();
+        String[] finish = new String[1];
+        int[] usage = new int[2];
+        return delegate.streamCompletion(request)
+                .onItem().invoke(chunk -> {
+                    if (chunk.delta() != null) {
+                        content.append(chunk.delta());
+                    }
+                    if (chunk.finishReason() != null) {
+                        finish[0] = chunk.finishReason();
+                    }
+                    if (chunk.usage() != null) {
+                        usage[0] = chunk.usage().inputTokens();
+                        usage[1] = chunk.usage().outputTokens();
+                    }
+                })
+                .onCompletion().invoke(() -> audit.record(request, true, content.toString(), usage[0], usage[1],
+                        finish[0], start, null))
+                .onFailure().invoke(error -> audit.record(request, true, content.toString(), usage[0], usage[1],
+                        null, start, error))
+                .onCancellation().invoke(() -> audit.record(request, true, content.toString(), usage[0], usage[1],
+                        "cancelled", start, null));
+    }
+
+    private void record(InferenceRequest request, InferenceResponse resp, long start) {
+        audit.record(request, false, resp.getContent(), resp.getInputTokens(), resp.getOutputTokens(),
+                resp.getFinishReason() == null ? null : resp.getFinishReason().name().toLowerCase(), start, null);
+    }
+
+    @Override
+    public EmbeddingResponse createEmbedding(EmbeddingRequest request) throws SdkException {
+        return delegate.createEmbedding(request);
+    }
+
+    @Override
+    public String submitAsyncJob(InferenceRequest request) throws SdkException {
+        return delegate.submitAsyncJob(request);
+    }
+
+    @Override
+    public AsyncJobStatus getJobStatus(String jobId) throws SdkException {
+        return delegate.getJobStatus(jobId);
+    }
+
+    @Override
+    public AsyncJobStatus waitForJob(String jobId, Duration maxWaitTime, Duration pollInterval) throws SdkException {
+        return delegate.waitForJob(jobId, maxWaitTime, pollInterval);
+    }
+
+    @Override
+    public List<InferenceResponse> batchInference(BatchInferenceRequest batchRequest) throws SdkException {
+        List<InferenceResponse> out = new ArrayList<>();
+        for (InferenceRequest r : batchRequest.getRequests()) {
+            out.add(createCompletion(r));
+        }
+        return out;
+    }
+
+    @Override
+    public List<ProviderInfo> listAvailableProviders() throws SdkException {
+        return delegate.listAvailableProviders();
+    }
+
+    @Override
+    public ProviderInfo getProviderInfo(String providerId) throws SdkException {
+        return delegate.getProviderInfo(providerId);
+    }
+
+    @Override
+    public void setPreferredProvider(String providerId) throws SdkException {
+        delegate.setPreferredProvider(providerId);
+    }
+
+    @Override
+    public Optional<String> getPreferredProvider() {
+        return delegate.getPreferredProvider();
+    }
+
+    @Override
+    public List<ModelInfo> listModels() throws SdkException {
+        return delegate.listModels();
+    }
+
+    @Override
+    public List<ModelInfo> listModels(int offset, int limit) throws SdkException {
+        return delegate.listModels(offset, limit);
+    }
+
+    @Override
+    public Optional<ModelInfo> getModelInfo(String modelId) throws SdkException {
+        return delegate.getModelInfo(modelId);
+    }
+
+    @Override
+    public void pullModel(String modelSpec, Consumer<PullProgress> progressCallback) throws SdkException {
+        delegate.pullModel(modelSpec, progressCallback);
+    }
+
+    @Override
+    public void pullModel(String modelSpec, String revision, boolean force, Consumer<PullProgress> progressCallback)
+            throws SdkException {
+        delegate.pullModel(modelSpec, revision, force, progressCallback);
+    }
+
+    @Override
+    public void deleteModel(String modelId) throws SdkException {
+        delegate.deleteModel(modelId);
+    }
+
+    @Override
+    public ModelResolution prepareModel(String modelId, boolean forceGguf, Consumer<PullProgress> progressCallback)
+            throws SdkException {
+        return delegate.prepareModel(modelId, forceGguf, progressCallback);
+    }
+
+    @Override
+    public Optional<String> autoSelectProvider(String modelId, boolean forceGguf) throws SdkException {
+        return delegate.autoSelectProvider(modelId, forceGguf);
+    }
+
+    @Override
+    public SystemInfo getSystemInfo() throws SdkException {
+        return delegate.getSystemInfo();
+    }
+}
@@ -0,0 +1,122 @@
+package tech.kayys.gollek.server.chat;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import tech.kayys.gollek.spi.Message;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.function.Function;
+
+/**
+ * Trims chat history to fit a token budget before it is sent to the model.
+ *
+ * <p>Token counts are estimated (about four characters per token plus a small per-message
+ * overhead), which is close enough for budgeting without loading the model's tokenizer.
+ * The newest message is always kept.
+ */
+public final class HistoryBudget {
+
+    public enum Strategy {
+        /** Drop the oldest non-system messages until the history fits. */
+        DROP_OLDEST,
+        /** Replace the dropped messages with a model-written summary. */
+        SUMMARIZE_OLDEST;
+
+        static Strategy parse(String value) {
+            if (value == null || value.isBlank()) {
+                return DROP_OLDEST;
+            }
+            try {
+                return Strategy.valueOf(value.trim().toUpperCase().replace('-', '_'));
+            } catch (IllegalArgumentException e) {
+                throw new IllegalArgumentException("unknown history strategy: " + value);
+            }
+        }
+    }
+
+    /** Per-request options, sent as {@code "history": {...}}. */
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public static record Options(
+            @JsonProperty("max_tokens") Integer maxTokens,
+            String strategy,
+            @JsonProperty("keep_system") Boolean keepSystem) {
+    }
+
+    /** What was removed; returned to the client so trimming is never silent. */
+    public static record Report(
+            String strategy,
+            @JsonProperty("dropped_messages") int droppedMessages,
+            @JsonProperty("dropped_tokens") int droppedTokens,
+            boolean summarized) {
+    }
+
+    public static record Result(List<Message> messages, Report report) {
+    }
+
+    private static final int MESSAGE_OVERHEAD_TOKENS = 4;
+
+    private HistoryBudget() {
+    }
+
+    public static int estimateTokens(Message m) {
+        String content = m.getContent();
+        return MESSAGE_OVERHEAD_TOKENS + (content == null ? 0 : (content.length() + 3) / 4);
+    }
+
+    /**
+     * Apply the budget. {@code summarizer} is only called for {@link Strategy#SUMMARIZE_OLDEST}
+     * and receives the messages being dropped.
+     */
+    public static Result apply(List<Message> messages, Options options, Function<List<Message>, String> summarizer) {
+        if (options == null || options.maxTokens() == null || options.maxTokens() <= 0) {
+            return new Result(messages, null);
+        }
+        Strategy strategy = Strategy.parse(options.strategy());
+        boolean keepSystem = options.keepSystem() == null || options.keepSystem();
+        int budget = options.maxTokens();
+
+        int total = messages.stream().mapToInt(HistoryBudget::estimateTokens).sum();
+        if (total <= budget) {
+            return new Result(messages, null);
+        }
+
+        List<Message> pinned = new ArrayList<>();
+        List<Message> rest = new ArrayList<>();
+        for (Message m : messages) {
+            if (keepSystem && m.getRole() == Message.Role.SYSTEM) {
+                pinned.add(m);
+            } else {
+                rest.add(m);
+            }
+        }
+
+        List<Message> dropped = new ArrayList<>();
+        int droppedTokens = 0;
+        // leave room for the summary message we are about to insert
+        int target = strategy == Strategy.SUMMARIZE_OLDEST ? budget - budget / 8 : budget;
+        while (total > target && rest.size() > 1) {
+            Message m = rest.remove(0);
+            int t = estimateTokens(m);
+            total -= t;
+            droppedTokens += t;
+            dropped.add(m);
+        }
+        if (dropped.isEmpty()) {
+            return new Result(messages, null);
+        }
+
+        List<Message> out = new ArrayList<>(pinned);
+        boolean summarized = false;
+        if (strategy == Strategy.SUMMARIZE_OLDEST && summarizer != null) {
+            String summary = summarizer.apply(dropped);
+            if (summary != null && !summary.isBlank()) {
+                out.add(Message.system("Summary of the earlier conversation: " + summary.strip()));
+                summarized = true;
+            }
+        }
+        out.addAll(rest);
+        return new Result(out, new Report(strategy.name().toLowerCase(), dropped.size(), droppedTokens, summarized));
+    }
+}
@@ -0,0 +1,158 @@
+package tech.kayys.gollek.server.chat;
+
+import jakarta.enterprise.context.ApplicationScoped;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import tech.kayys.gollek.server.openai.ChatCompletionRequest;
+import tech.kayys.gollek.spi.Message;
+
+import java.util.ArrayList;
+import java.util.EnumMap;
+import java.util.HashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+
+/**
+ * Per-language handling of chat requests. When enabled, the language of the last user
+ * message is detected and the request goes to the model configured for it
+ * ({@code gollek.server.language.models.de=...}) and/or gets its system prompt
+ * ({@code gollek.server.language.system-prompts.de=...}) placed first.
+ *
+ * <p>Detection is a cheap heuristic: the dominant script for non-Latin text, and common
+ * function words for Latin-script languages. Text too short or too mixed to call is
+ * left alone rather than guessed.
+ */
+@ApplicationScoped
+public class LanguageRouting {
+
+    @ConfigProperty(name = "gollek.server.language.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.language.models")
+    Optional<Map<String, String>> models;
+
+    @ConfigProperty(name = "gollek.server.language.system-prompts")
+    Optional<Map<String, String>> systemPrompts;
+
+    /** What was detected and what it maps to; {@code model} and {@code systemPrompt} may be null. */
+    public record Decision(String language, String model, String systemPrompt) {
+    }
+
+    private static final Map<Character.UnicodeScript, String> SCRIPTS = Map.of(
+            Character.UnicodeScript.HAN, "zh",
+            Character.UnicodeScript.HIRAGANA, "ja",
+            Character.UnicodeScript.KATAKANA, "ja",
+            Character.UnicodeScript.HANGUL, "ko",
+            Character.UnicodeScript.CYRILLIC, "ru",
+            Character.UnicodeScript.ARABIC, "ar",
+            Character.UnicodeScript.HEBREW, "he",
+            Character.UnicodeScript.GREEK, "el",
+            Character.UnicodeScript.DEVANAGARI, "hi",
+            Character.UnicodeScript.THAI, "th");
+
+    private static final Map<String, Set<String>> FUNCTION_WORDS = Map.of(
+            "en", Set.of("the", "and", "is", "are", "of", "to", "in", "that", "what", "how", "you", "with", "this"),
+            "de", Set.of("der", "die", "das", "und", "ist", "nicht", "ich", "sie", "mit", "ein", "eine", "wie", "was"),
+            "fr", Set.of("le", "la", "les", "et", "est", "une", "des", "que", "pas", "pour", "dans", "je", "vous"),
+            "es", Set.of("el", "los", "las", "y", "es", "una", "que", "por", "para", "con", "como", "qué", "está"),
+            "it", Set.of("il", "gli", "che", "è", "di", "una", "per", "non", "sono", "come", "della", "questo"),
+            "pt", Set.of("o", "os", "as", "que", "é", "não", "uma", "para", "com", "como", "você", "está", "do"),
+            "nl", Set.of("de", "het", "een", "en", "is", "niet", "ik", "je", "van", "dat", "wat", "hoe", "met"),
+            "id", Set.of("yang", "dan", "di", "ini", "itu", "tidak", "saya", "anda", "untuk", "dengan", "apa", "ada"));
+
+    /**
+     * The decision for the request's last user message, or null when routing is off or
+     * the language could not be told.
+     */
+    public Decision decide(ChatCompletionRequest request) {
+        if (!enabled || request == null || request.messages() == null) {
+            return null;
+        }
+        String text = null;
+        for (ChatCompletionRequest.ChatMessage message : request.messages()) {
+            if ("user".equalsIgnoreCase(message.role()) && message.content() != null) {
+                text = message.content();
+            }
+        }
+        return detect(text)
+                .map(language -> new Decision(language, lookup(models, language), lookup(systemPrompts, language)))
+                .orElse(null);
+    }
+
+    private static String lookup(Optional<Map<String, String>> entries, String language) {
+        String value = entries.orElse(Map.of()).get(language);
+        return value == null || value.isBlank() ? null : value;
+    }
+
+    /** {@code messages} with {@code systemPrompt} as the first system message. */
+    public static List<Message> withSystemPrompt(List<Message> messages, String systemPrompt) {
+        if (systemPrompt == null) {
+            return messages;
+        }
+        List<Message> out = new ArrayList<>(messages.size() + 1);
+        out.add(Message.system(systemPrompt));
+        out.addAll(messages);
+        return out;
+    }
+
+    /** ISO 639-1 code of {@code text}'s language, if it can be told with some confidence. */
+    static Optional<String> detect(String text) {
+        if (text == null || text.isBlank()) {
+            return Optional.empty();
+        }
+        Map<Character.UnicodeScript, Integer> scripts = new EnumMap<>(Character.UnicodeScript.class);
+        int letters = 0;
+        for (int i = 0; i < text.length();) {
+            int cp = text.codePointAt(i);
+            i += Character.charCount(cp);
+            if (Character.isLetter(cp)) {
+                letters++;
+                scripts.merge(Character.UnicodeScript.of(cp), 1, Integer::sum);
+            }
+        }
+        if (letters == 0) {
+            return Optional.empty();
+        }
+        // kana marks Japanese even when kanji outnumber it
+        if (scripts.getOrDefault(Character.UnicodeScript.HIRAGANA, 0)
+                + scripts.getOrDefault(Character.UnicodeScript.KATAKANA, 0) > 0
+                && scripts.getOrDefault(Character.UnicodeScript.LATIN, 0) * 2 < letters) {
+            return Optional.of("ja");
+        }
+        Character.UnicodeScript dominant = scripts.entrySet().stream()
+                .max(Map.Entry.comparingByValue()).map(Map.Entry::getKey).orElseThrow();
+        if (dominant != Character.UnicodeScript.LATIN) {
+            return scripts.get(dominant) * 2 > letters ? Optional.ofNullable(SCRIPTS.get(dominant)) : Optional.empty();
+        }
+        return byFunctionWords(text);
+    }
+
+    private static Optional<String> byFunctionWords(String text) {
+        Map<String, Integer> scores = new HashMap<>();
+        for (String word : text.toLowerCase(Locale.ROOT).split("[^\\p{L}]+")) {
+            for (Map.Entry<String, Set<String>> language : FUNCTION_WORDS.entrySet()) {
+                if (language.getValue().contains(word)) {
+                    scores.merge(language.getKey(), 1, Integer::sum);
+                }
+            }
+        }
+        String best = null;
+        int bestScore = 0;
+        int runnerUp = 0;
+        for (Map.Entry<String, Integer> score : scores.entrySet()) {
+            if (score.getValue() > bestScore) {
+                runnerUp = bestScore;
+                best = score.getKey();
+                bestScore = score.getValue();
+            } else if (score.getValue() > runnerUp) {
+                runnerUp = score.getValue();
+            }
+        }
+        // two hits and a clear lead, or a one-word greeting would pick a language at random
+        return bestScore >= 2 && bestScore > runnerUp ? Optional.of(best) : Optional.empty();
+    }
+}
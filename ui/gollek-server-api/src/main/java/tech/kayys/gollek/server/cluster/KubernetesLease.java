@@ -0,0 +1,202 @@
+package tech.kayys.gollek.server.cluster;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.node.ObjectNode;
+
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.io.InputStream;
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.security.KeyStore;
+import java.security.cert.Certificate;
+import java.security.cert.CertificateFactory;
+import java.time.Clock;
+import java.time.Duration;
+import java.time.Instant;
+import java.time.ZoneOffset;
+import java.time.format.DateTimeFormatter;
+
+import javax.net.ssl.SSLContext;
+import javax.net.ssl.TrustManagerFactory;
+
+/**
+ * A {@code coordination.k8s.io/v1} Lease, the object Kubernetes controllers use for leader
+ * election. Talks to the API server with the pod's service account; updates carry the
+ * lease's {@code resourceVersion}, so of two replicas racing for it only one succeeds.
+ * The service account needs {@code get}, {@code create} and {@code update} on
+ * {@code leases} in the pod's namespace.
+ */
+final class KubernetesLease implements Lease {
+
+    private static final Logger LOG = Logger.getLogger(KubernetesLease.class);
+    private static final Path SERVICE_ACCOUNT = Path.of("/var/run/secrets/kubernetes.io/serviceaccount");
+    private static final DateTimeFormatter MICRO_TIME =
+            DateTimeFormatter.ofPattern("yyyy-MM-dd'T'HH:mm:ss.SSSSSS'Z'").withZone(ZoneOffset.UTC);
+
+    private final HttpClient http;
+    private final URI leases;
+    private final String name;
+    private final String namespace;
+    private final Path tokenFile;
+    private final Clock clock;
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    private KubernetesLease(HttpClient http, URI api, String namespace, String name, Path tokenFile, Clock clock) {
+        this.http = http;
+        this.namespace = namespace;
+        this.name = name;
+        this.leases = api.resolve("/apis/coordination.k8s.io/v1/namespaces/" + namespace + "/leases");
+        this.tokenFile = tokenFile;
+        this.clock = clock;
+    }
+
+    /**
+     * Lease {@code name} in {@code namespace}, or the pod's own namespace when null.
+     *
+     * @throws IllegalStateException when not running in a pod
+     */
+    static KubernetesLease inCluster(String namespace, String name, Clock clock) {
+        String host = System.getenv("KUBERNETES_SERVICE_HOST");
+        String port = System.getenv().getOrDefault("KUBERNETES_SERVICE_PORT", "443");
+        if (host == null || !Files.isReadable(SERVICE_ACCOUNT.resolve("token"))) {
+            throw new IllegalStateException("Kubernetes leader election needs to run in a pod with a service account");
+        }
+        try {
+            String ns = namespace != null ? namespace
+                    : Files.readString(SERVICE_ACCOUNT.resolve("namespace")).trim();
+            HttpClient http = HttpClient.newBuilder()
+                    .sslContext(trusting(SERVICE_ACCOUNT.resolve("ca.crt")))
+                    .connectTimeout(Duration.ofSeconds(5))
+                    .build();
+            String hostPart = host.contains(":") ? "[" + host + "]" : host;
+            return new KubernetesLease(http, URI.create("https://" + hostPart + ":" + port), ns, name,
+                    SERVICE_ACCOUNT.resolve("token"), clock);
+        } catch (Exception e) {
+            throw new IllegalStateException("Cannot set up the Kubernetes API client: " + e.getMessage(), e);
+        }
+    }
+
+    @Override
+    public boolean tryAcquire(String holder, Duration duration) {
+        try {
+            HttpResponse<String> current = send(HttpRequest.newBuilder(leases.resolve(leases.getPath() + "/" + name)).GET());
+            Instant now = clock.instant();
+            if (current.statusCode() == 404) {
+                ObjectNode lease = mapper.createObjectNode();
+                lease.put("apiVersion", "coordination.k8s.io/v1");
+                lease.put("kind", "Lease");
+                lease.putObject("metadata").put("name", name).put("namespace", namespace);
+                ObjectNode spec = lease.putObject("spec");
+                spec.put("holderIdentity", holder);
+                spec.put("leaseDurationSeconds", seconds(duration));
+                spec.put("acquireTime", MICRO_TIME.format(now));
+                spec.put("renewTime", MICRO_TIME.format(now));
+                spec.put("leaseTransitions", 0);
+                return send(HttpRequest.newBuilder(leases).POST(body(lease))).statusCode() == 201;
+            }
+            if (current.statusCode() != 200) {
+                LOG.debugf("Reading lease %s returned HTTP %d", name, current.statusCode());
+                return false;
+            }
+            ObjectNode lease = (ObjectNode) mapper.readTree(current.body());
+            ObjectNode spec = lease.has("spec") ? (ObjectNode) lease.get("spec") : lease.putObject("spec");
+            String owner = text(spec, "holderIdentity");
+            boolean mine = holder.equals(owner);
+            if (!mine && owner != null && !owner.isEmpty() && expiry(spec).isAfter(now)) {
+                return false;
+            }
+            if (!mine) {
+                spec.put("acquireTime", MICRO_TIME.format(now));
+                spec.put("leaseTransitions", spec.path("leaseTransitions").asInt(0) + 1);
+            }
+            spec.put("holderIdentity", holder);
+            spec.put("leaseDurationSeconds", seconds(duration));
+            spec.put("renewTime", MICRO_TIME.format(now));
+            // resourceVersion is kept, so a concurrent update makes this one fail with 409
+            return send(HttpRequest.newBuilder(leases.resolve(leases.getPath() + "/" + name)).PUT(body(lease)))
+                    .statusCode() == 200;
+        } catch (IOException e) {
+            LOG.debugf("Lease %s unavailable: %s", name, e.getMessage());
+            return false;
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+            return false;
+        }
+    }
+
+    @Override
+    public void release(String holder) {
+        try {
+            URI uri = leases.resolve(leases.getPath() + "/" + name);
+            HttpResponse<String> current = send(HttpRequest.newBuilder(uri).GET());
+            if (current.statusCode() != 200) {
+                return;
+            }
+            ObjectNode lease = (ObjectNode) mapper.readTree(current.body());
+            if (!(lease.get("spec") instanceof ObjectNode spec) || !holder.equals(text(spec, "holderIdentity"))) {
+                return;
+            }
+            // what client-go does on release: no holder, and lapsed for anyone who looks
+            spec.put("holderIdentity", "");
+            spec.put("leaseDurationSeconds", 1);
+            send(HttpRequest.newBuilder(uri).PUT(body(lease)));
+        } catch (IOException e) {
+            LOG.debugf("Could not release lease %s: %s", name, e.getMessage());
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+        }
+    }
+
+    private HttpResponse<String> send(HttpRequest.Builder request) throws IOException, InterruptedException {
+        return http.send(request
+                .timeout(Duration.ofSeconds(10))
+                .header("Authorization", "Bearer " + Files.readString(tokenFile).trim())
+                .header("Accept", "application/json")
+                .header("Content-Type", "application/json")
+                .build(), HttpResponse.BodyHandlers.ofString());
+    }
+
+    private HttpRequest.BodyPublisher body(JsonNode node) throws IOException {
+        return HttpRequest.BodyPublishers.ofString(mapper.writeValueAsString(node));
+    }
+
+    private static Instant expiry(JsonNode spec) {
+        String renewed = text(spec, "renewTime");
+        if (renewed == null) {
+            return Instant.EPOCH;
+        }
+        return Instant.parse(renewed).plusSeconds(spec.path("leaseDurationSeconds").asLong(0));
+    }
+
+    private static String text(JsonNode node, String field) {
+        JsonNode value = node.get(field);
+        return value == null || value.isNull() ? null : value.asText();
+    }
+
+    private static int seconds(Duration duration) {
+        return (int) Math.max(1, (duration.toMillis() + 999) / 1000);
+    }
+
+    private static SSLContext trusting(Path caFile) throws Exception {
+        KeyStore trust = KeyStore.getInstance(KeyStore.getDefaultType());
+        trust.load(null, null);
+        try (InputStream in = Files.newInputStream(caFile)) {
+            int i = 0;
+            for (Certificate cert : CertificateFactory.getInstance("X.509").generateCertificates(in)) {
+                trust.setCertificateEntry("k8s-ca-" + i++, cert);
+            }
+        }
+        TrustManagerFactory factory = TrustManagerFactory.getInstance(TrustManagerFactory.getDefaultAlgorithm());
+        factory.init(trust);
+        SSLContext context = SSLContext.getInstance("TLS");
+        context.init(null, factory.getTrustManagers(), null);
+        return context;
+    }
+}
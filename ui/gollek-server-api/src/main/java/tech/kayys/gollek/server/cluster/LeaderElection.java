@@ -0,0 +1,145 @@
+package tech.kayys.gollek.server.cluster;
+
+import io.quarkus.runtime.ShutdownEvent;
+import io.quarkus.runtime.StartupEvent;
+import io.quarkus.scheduler.Scheduled;
+import io.quarkus.scheduler.ScheduledExecution;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+import jakarta.inject.Singleton;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.store.Store;
+
+import java.time.Clock;
+import java.time.Duration;
+import java.util.LinkedHashMap;
+import java.util.Map;
+import java.util.Optional;
+import java.util.UUID;
+
+/**
+ * Picks one replica to run fleet-wide background work (scheduled tasks, retention,
+ * backups) when several replicas share a store. Off by default, in which case every
+ * replica is its own leader. With {@code gollek.server.leader-election.backend=store} the
+ * lease lives in the shared {@link Store}; with {@code kubernetes} it is a
+ * {@code coordination.k8s.io} Lease in the pod's namespace.
+ *
+ * <p>The leader renews every {@code renew-every}; it steps down as soon as a renewal
+ * fails or the lease would lapse, so two replicas never act as leader for longer than
+ * the clock skew between them.
+ */
+@ApplicationScoped
+public class LeaderElection {
+
+    private static final Logger LOG = Logger.getLogger(LeaderElection.class);
+
+    @ConfigProperty(name = "gollek.server.leader-election.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.leader-election.backend", defaultValue = "store")
+    String backend;
+
+    @ConfigProperty(name = "gollek.server.leader-election.lease-name", defaultValue = "gollek-leader")
+    String leaseName;
+
+    @ConfigProperty(name = "gollek.server.leader-election.lease-duration", defaultValue = "15s")
+    Duration leaseDuration;
+
+    @ConfigProperty(name = "gollek.server.leader-election.kubernetes.namespace")
+    Optional<String> kubernetesNamespace;
+
+    @ConfigProperty(name = "gollek.server.leader-election.identity")
+    Optional<String> identity;
+
+    @Inject
+    Store store;
+
+    private final Clock clock = Clock.systemUTC();
+    private Lease lease;
+    private String holder;
+    private volatile long leaderUntil;
+
+    void onStart(@Observes StartupEvent event) {
+        if (!enabled) {
+            return;
+        }
+        holder = identity.filter(s -> !s.isBlank())
+                .or(() -> Optional.ofNullable(System.getenv("HOSTNAME")))
+                .orElse("gollek") + "-" + UUID.randomUUID().toString().substring(0, 8);
+        lease = switch (backend.trim().toLowerCase()) {
+            case "store" -> new StoreLease(store, leaseName, clock);
+            case "kubernetes", "k8s" -> KubernetesLease.inCluster(kubernetesNamespace.orElse(null), leaseName, clock);
+            default -> throw new IllegalStateException("Unknown gollek.server.leader-election.backend: " + backend);
+        };
+        LOG.infof("Leader election on lease %s (%s backend) as %s", leaseName, backend, holder);
+        renew();
+    }
+
+    void onStop(@Observes ShutdownEvent event) {
+        if (lease != null && isLeader()) {
+            leaderUntil = 0;
+            lease.release(holder);
+        }
+    }
+
+    @Scheduled(every = "${gollek.server.leader-election.renew-every:5s}",
+            concurrentExecution = Scheduled.ConcurrentExecution.SKIP)
+    void renew() {
+        if (lease == null) {
+            return;
+        }
+        boolean was = isLeader();
+        long started = clock.millis();
+        boolean acquired;
+        try {
+            acquired = lease.tryAcquire(holder, leaseDuration);
+        } catch (RuntimeException e) {
+            LOG.debugf("Lease %s renewal failed: %s", leaseName, e.getMessage());
+            acquired = false;
+        }
+        // count from before the attempt, so local leadership ends no later than the lease
+        leaderUntil = acquired ? started + leaseDuration.toMillis() : 0;
+        if (acquired && !was) {
+            LOG.infof("Became leader for %s", leaseName);
+        } else if (!acquired && was) {
+            LOG.infof("Lost leadership for %s", leaseName);
+        }
+    }
+
+    public boolean isEnabled() {
+        return enabled;
+    }
+
+    /** Whether this replica should run fleet-wide work now; always true when election is off. */
+    public boolean isLeader() {
+        return !enabled || clock.millis() < leaderUntil;
+    }
+
+    public Map<String, Object> status() {
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("enabled", enabled);
+        out.put("leader", isLeader());
+        if (enabled) {
+            out.put("backend", backend);
+            out.put("lease", leaseName);
+            out.put("identity", holder);
+        }
+        return out;
+    }
+
+    /** {@code skipExecutionIf} for jobs that must run on a single replica. */
+    @Singleton
+    public static class NotLeader implements Scheduled.SkipPredicate {
+
+        @Inject
+        LeaderElection election;
+
+        @Override
+        public boolean test(ScheduledExecution execution) {
+            return !election.isLeader();
+        }
+    }
+}
@@ -0,0 +1,16 @@
+package tech.kayys.gollek.server.cluster;
+
+import java.time.Duration;
+
+/**
+ * A named lease that at most one replica holds at a time. Holders renew it well within
+ * its duration; if a holder stops renewing, another replica can take it over once it lapses.
+ */
+interface Lease {
+
+    /** Acquires or renews the lease for {@code holder}; false while another replica holds it. */
+    boolean tryAcquire(String holder, Duration duration);
+
+    /** Gives the lease up early if {@code holder} still holds it. */
+    void release(String holder);
+}
@@ -0,0 +1,78 @@
+package tech.kayys.gollek.server.cluster;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.store.Store;
+
+import java.time.Clock;
+import java.time.Duration;
+import java.util.Optional;
+
+/**
+ * Lease kept in the shared {@link Store} under the {@value #NAMESPACE} namespace and
+ * updated with {@link Store#compareAndSet}, so it works with any backend the replicas
+ * share (Redis or Postgres; file and memory stores are single-node). Expiry uses the
+ * replicas' wall clocks, which must roughly agree.
+ */
+final class StoreLease implements Lease {
+
+    static final String NAMESPACE = "leases";
+
+    record Holder(String holder, @JsonProperty("expires_at") long expiresAt) {
+    }
+
+    private final Store store;
+    private final String name;
+    private final Clock clock;
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    StoreLease(Store store, String name, Clock clock) {
+        this.store = store;
+        this.name = name;
+        this.clock = clock;
+    }
+
+    @Override
+    public boolean tryAcquire(String holder, Duration duration) {
+        long now = clock.millis();
+        String next = write(new Holder(holder, now + duration.toMillis()));
+        Optional<String> current = store.get(NAMESPACE, name);
+        if (current.isEmpty()) {
+            return store.compareAndSet(NAMESPACE, name, null, next);
+        }
+        Holder held = read(current.get());
+        if (held != null && !holder.equals(held.holder()) && held.expiresAt() > now) {
+            return false;
+        }
+        return store.compareAndSet(NAMESPACE, name, current.get(), next);
+    }
+
+    @Override
+    public void release(String holder) {
+        store.get(NAMESPACE, name).ifPresent(current -> {
+            Holder held = read(current);
+            if (held != null && holder.equals(held.holder())) {
+                store.compareAndSet(NAMESPACE, name, current, write(new Holder(holder, 0)));
+            }
+        });
+    }
+
+    private String write(Holder holder) {
+        try {
+            return mapper.writeValueAsString(holder);
+        } catch (JsonProcessingException e) {
+            throw new IllegalStateException(e);
+        }
+    }
+
+    /** The stored holder, or null when the entry is unreadable and may be taken over. */
+    private Holder read(String value) {
+        try {
+            return mapper.readValue(value, Holder.class);
+        } catch (JsonProcessingException e) {
+            return null;
+        }
+    }
+}
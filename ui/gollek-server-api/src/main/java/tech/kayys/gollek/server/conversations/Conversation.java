@@ -0,0 +1,73 @@
+package tech.kayys.gollek.server.conversations;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import tech.kayys.gollek.server.openai.ChatCompletionRequest;
+import tech.kayys.gollek.server.openai.ChatCompletionRequest.ChatMessage;
+
+import java.util.ArrayList;
+import java.util.List;
+
+/**
+ * A stored conversation: its history plus a persistent system prompt and sampling
+ * defaults, so clients only send the new turn.
+ */
+@JsonIgnoreProperties(ignoreUnknown = true)
+public record Conversation(
+        String id,
+        String title,
+        String model,
+        @JsonProperty("system_prompt") String systemPrompt,
+        Defaults defaults,
+        List<ChatMessage> messages,
+        @JsonProperty("created_at") long createdAt,
+        @JsonProperty("updated_at") long updatedAt) {
+
+    /** Sampling defaults; a field left null falls through to the runner's own default. */
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public static record Defaults(
+            Double temperature,
+            @JsonProperty("top_p") Double topP,
+            @JsonProperty("top_k") Integer topK,
+            @JsonProperty("min_p") Double minP,
+            @JsonProperty("max_tokens") Integer maxTokens,
+            @JsonProperty("repeat_penalty") Double repeatPenalty,
+            Integer seed,
+            Object stop) {
+    }
+
+    public Conversation {
+        messages = messages == null ? List.of() : List.copyOf(messages);
+    }
+
+    /**
+     * Builds the request actually sent to the engine: stored system prompt and history
+     * first, then the client's new messages. Values set on the request always win over
+     * the conversation's defaults.
+     */
+    public ChatCompletionRequest applyTo(ChatCompletionRequest req) {
+        List<ChatMessage> turn = req.messages() == null ? List.of() : req.messages();
+        List<ChatMessage> all = new ArrayList<>(messages.size() + turn.size() + 1);
+        boolean requestHasSystem = turn.stream().anyMatch(m -> "system".equalsIgnoreCase(m.role()));
+        if (systemPrompt != null && !systemPrompt.isBlank() && !requestHasSystem) {
+            all.add(new ChatMessage("system", systemPrompt, null));
+        }
+        all.addAll(messages);
+        all.addAll(turn);
+
+        Defaults d = defaults == null ? new Defaults(null, null, null, null, null, null, null, null) : defaults;
+        return req.toBuilder()
+                .model(req.model() != null ? req.model() : model)
+                .messages(all)
+                .temperature(req.temperature() != null ? req.temperature() : d.temperature())
+                .topP(req.topP() != null ? req.topP() : d.topP())
+                .topK(req.topK() != null ? req.topK() : d.topK())
+                .minP(req.minP() != null ? req.minP() : d.minP())
+                .maxTokens(req.maxTokens() != null ? req.maxTokens() : d.maxTokens())
+                .repeatPenalty(req.repeatPenalty() != null ? req.repeatPenalty() : d.repeatPenalty())
+                .seed(req.seed() != null ? req.seed() : d.seed())
+                .stop(req.stop() != null ? req.stop() : d.stop())
+                .build();
+    }
+}
@@ -0,0 +1,294 @@
+package tech.kayys.gollek.server.conversations;
+
+import jakarta.annotation.PostConstruct;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.StandardCopyOption;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.Comparator;
+import java.util.List;
+import java.util.Objects;
+import java.util.Optional;
+import java.util.UUID;
+
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.openai.ChatCompletionRequest.ChatMessage;
+import tech.kayys.gollek.server.security.AtRestCipher;
+import tech.kayys.gollek.server.store.Store;
+
+/**
+ * Conversation persistence on top of the server {@link Store}: conversation metadata is
+ * a key in the {@code conversations} namespace and the history is a list under the same
+ * id. When a storage encryption key is configured every value is AES-GCM encrypted.
+ *
+ * <p>The single conversations file written by earlier versions is imported on startup
+ * and renamed to {@code .migrated}; if it cannot be read the server does not start.
+ */
+@ApplicationScoped
+public class ConversationStore {
+
+    private static final Logger LOG = Logger.getLogger(ConversationStore.class);
+    static final String NAMESPACE = "conversations";
+
+    @Inject
+    Store store;
+
+    /** Where earlier versions kept every conversation in one JSON array. */
+    @ConfigProperty(name = "gollek.server.conversations-file", defaultValue = "./data/conversations.json")
+    String legacyFile;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key")
+    Optional<String> encryptionKey;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key-file")
+    Optional<String> encryptionKeyFile;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+    private AtRestCipher cipher;
+
+    @PostConstruct
+    void init() {
+        try {
+            cipher = AtRestCipher.fromConfig(encryptionKey, encryptionKeyFile).orElse(null);
+        } catch (IOException e) {
+            // refuse to silently fall back to plaintext when a key was asked for
+            throw new IllegalStateException("Failed to read storage encryption key: " + e.getMessage(), e);
+        }
+        checkKey();
+        migrateLegacyFile();
+    }
+
+    /**
+     * Refuses to start when stored conversations cannot be read with the configured key:
+     * running on would mix plaintext (or a second key) into an encrypted store.
+     */
+    void checkKey() {
+        for (String id : store.keys(NAMESPACE)) {
+            Optional<String> value = store.get(NAMESPACE, id);
+            if (value.isEmpty() || value.get().startsWith("{")) {
+                continue;
+            }
+            if (cipher == null) {
+                throw new IllegalStateException(
+                        "Stored conversations are encrypted but no storage encryption key is configured");
+            }
+            // throws on a wrong key
+            cipher.decryptToString(value.get());
+            return;
+        }
+    }
+
+    /**
+     * Imports the legacy file into the store. History goes in before metadata, so a run
+     * interrupted half-way redoes the conversations it had not finished.
+     */
+    void migrateLegacyFile() {
+        Path p = Path.of(legacyFile);
+        if (!Files.isRegularFile(p)) {
+            return;
+        }
+        Conversation[] legacy;
+        try {
+            byte[] data = Files.readAllBytes(p);
+            if (AtRestCipher.isEncrypted(data)) {
+                if (cipher == null) {
+                    throw new IllegalStateException("Conversations file " + p
+                            + " is encrypted but no storage encryption key is configured");
+                }
+                data = cipher.decrypt(data);
+            }
+            legacy = mapper.readValue(data, Conversation[].class);
+        } catch (IOException e) {
+            throw new IllegalStateException("Failed to read conversations file " + p + ": " + e.getMessage(), e);
+        }
+        int imported = 0;
+        for (Conversation c : legacy) {
+            if (store.get(NAMESPACE, c.id()).isPresent()) {
+                continue;
+            }
+            store.deleteList(NAMESPACE, c.id());
+            for (ChatMessage m : c.messages()) {
+                store.append(NAMESPACE, c.id(), seal(m));
+            }
+            store.put(NAMESPACE, c.id(), seal(withMessages(c, List.of())));
+            imported++;
+        }
+        try {
+            Files.move(p, p.resolveSibling(p.getFileName() + ".migrated"), StandardCopyOption.REPLACE_EXISTING);
+        } catch (IOException e) {
+            // harmless: already imported conversations are skipped on the next start
+            LOG.warnf("Could not rename %s after migrating it: %s", p, e.getMessage());
+        }
+        LOG.infof("Migrated %d conversation(s) from %s into the %s store", imported, p, store.backend());
+    }
+
+    public List<Conversation> list() {
+        return store.keys(NAMESPACE).stream()
+                .map(this::meta)
+                .flatMap(Optional::stream)
+                .sorted(Comparator.comparingLong(Conversation::updatedAt).reversed())
+                .toList();
+    }
+
+    public Optional<Conversation> get(String id) {
+        return meta(id).map(c -> withMessages(c, history(id)));
+    }
+
+    public Conversation create(String title, String model, String systemPrompt, Conversation.Defaults defaults) {
+        long now = Instant.now().getEpochSecond();
+        String id = "conv-" + UUID.randomUUID().toString().replace("-", "");
+        Conversation c = new Conversation(id, title, model, systemPrompt, defaults, List.of(), now, now);
+        store.put(NAMESPACE, id, seal(c));
+        return c;
+    }
+
+    /** Replaces the non-null fields of the patch; history is left untouched. */
+    public synchronized Optional<Conversation> update(String id, String title, String model, String systemPrompt,
+            Conversation.Defaults defaults) {
+        return meta(id).map(c -> {
+            Conversation updated = new Conversation(c.id(),
+                    title != null ? title : c.title(),
+                    model != null ? model : c.model(),
+                    systemPrompt != null ? systemPrompt : c.systemPrompt(),
+                    defaults != null ? defaults : c.defaults(),
+                    List.of(), c.createdAt(), Instant.now().getEpochSecond());
+            store.put(NAMESPACE, id, seal(updated));
+            return withMessages(updated, history(id));
+        });
+    }
+
+    /** Appends a completed turn (the client's messages and the assistant reply). */
+    public synchronized void appendTurn(String id, List<ChatMessage> turn, String reply) {
+        if (meta(id).isEmpty()) {
+            return;
+        }
+        for (ChatMessage m : turn == null ? List.<ChatMessage>of() : turn) {
+            // the persistent system prompt lives on the conversation, not in the history
+            if (!"system".equalsIgnoreCase(m.role())) {
+                store.append(NAMESPACE, id, seal(m));
+            }
+        }
+        store.append(NAMESPACE, id, seal(new ChatMessage("assistant", reply, null)));
+        touch(id);
+    }
+
+    /**
+     * A streamed turn for conversation {@code id} ({@code null} for none), saved on
+     * {@link StreamedTurn#close()} with the text sent so far.
+     */
+    public StreamedTurn streamedTurn(String id, List<ChatMessage> turn) {
+        return new StreamedTurn(id, turn);
+    }
+
+    /**
+     * The reply of a streaming completion, collected as it is sent. Closing it appends the
+     * turn with whatever the client received, so a stream that fails or is cancelled part way
+     * leaves the history as the client saw it; a stream that sent nothing and did not
+     * {@link #complete()} is not recorded, since the client will send that turn again.
+     */
+    public final class StreamedTurn implements AutoCloseable {
+
+        private final String id;
+        private final List<ChatMessage> turn;
+        private final StringBuilder reply = new StringBuilder();
+        private boolean complete;
+
+        private StreamedTurn(String id, List<ChatMessage> turn) {
+            this.id = id;
+            this.turn = turn;
+        }
+
+        public StringBuilder reply() {
+            return reply;
+        }
+
+        /** Marks the stream as finished normally, so even an empty reply is recorded. */
+        public void complete() {
+            complete = true;
+        }
+
+        @Override
+        public void close() {
+            if (id != null && (complete || !reply.isEmpty())) {
+                appendTurn(id, turn, reply.toString());
+            }
+        }
+    }
+
+    public synchronized boolean clearMessages(String id) {
+        if (meta(id).isEmpty()) {
+            return false;
+        }
+        store.deleteList(NAMESPACE, id);
+        touch(id);
+        return true;
+    }
+
+    public synchronized boolean delete(String id) {
+        store.deleteList(NAMESPACE, id);
+        return store.delete(NAMESPACE, id);
+    }
+
+    /** Deletes conversations whose last update is older than {@code cutoff}; returns how many. */
+    public synchronized int deleteUpdatedBefore(Instant cutoff) {
+        int deleted = 0;
+        for (Conversation c : list()) {
+            if (c.updatedAt() < cutoff.getEpochSecond() && delete(c.id())) {
+                deleted++;
+            }
+        }
+        return deleted;
+    }
+
+    private void touch(String id) {
+        meta(id).ifPresent(c -> store.put(NAMESPACE, id, seal(new Conversation(c.id(), c.title(), c.model(),
+                c.systemPrompt(), c.defaults(), List.of(), c.createdAt(), Instant.now().getEpochSecond()))));
+    }
+
+    private Optional<Conversation> meta(String id) {
+        return store.get(NAMESPACE, id).map(v -> open(v, Conversation.class));
+    }
+
+    private List<ChatMessage> history(String id) {
+        List<ChatMessage> out = new ArrayList<>();
+        for (String v : store.range(NAMESPACE, id, 0, -1)) {
+            out.add(open(v, ChatMessage.class));
+        }
+        return out;
+    }
+
+    private static Conversation withMessages(Conversation c, List<ChatMessage> messages) {
+        return new Conversation(c.id(), c.title(), c.model(), c.systemPrompt(), c.defaults(), messages,
+                c.createdAt(), c.updatedAt());
+    }
+
+    private String seal(Object value) {
+        try {
+            String json = mapper.writeValueAsString(value);
+            return cipher == null ? json : cipher.encryptToString(json);
+        } catch (JsonProcessingException e) {
+            throw new IllegalStateException("Failed to serialize conversation", e);
+        }
+    }
+
+    private <T> T open(String value, Class<T> type) {
+        try {
+            if (value.startsWith("{")) {
+                return mapper.readValue(value, type);
+            }
+            Objects.requireNonNull(cipher, "Conversation store holds encrypted data but no storage encryption key is configured");
+            return mapper.readValue(cipher.decryptToString(value), type);
+        } catch (IOException e) {
+            throw new IllegalStateException("Failed to read conversation", e);
+        }
+    }
+}
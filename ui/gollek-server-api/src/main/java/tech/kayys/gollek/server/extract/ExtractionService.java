@@ -0,0 +1,132 @@
+package tech.kayys.gollek.server.extract;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.WorkerPool;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.concurrent.Executor;
+import java.util.concurrent.Future;
+import java.util.concurrent.FutureTask;
+
+/**
+ * Runs schema-constrained extraction over a batch of documents. Each document becomes
+ * one completion with a GBNF grammar derived from the schema; documents are spread over
+ * the shared {@link WorkerPool}, {@code workers} at a time, and failures are reported per
+ * row instead of failing the batch.
+ */
+@ApplicationScoped
+public class ExtractionService {
+
+    private static final String DEFAULT_INSTRUCTION = "Extract the requested fields from the document. "
+            + "Respond only with JSON matching this schema:\n";
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    WorkerPool workerPool;
+
+    @ConfigProperty(name = "gollek.server.extract.workers", defaultValue = "4")
+    int workers;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+    private volatile Executor executor;
+
+    public static record Row(int index, String status, JsonNode data, String error) {
+        static Row ok(int index, JsonNode data) {
+            return new Row(index, "ok", data, null);
+        }
+
+        static Row failed(int index, String error) {
+            return new Row(index, "error", null, error);
+        }
+    }
+
+    public List<Row> extract(String model, JsonNode schema, List<String> documents, String instruction,
+            Integer maxTokens) throws InterruptedException {
+        String grammar = JsonSchemaGrammar.fromSchema(schema);
+        String preamble = (instruction == null || instruction.isBlank() ? DEFAULT_INSTRUCTION : instruction + "\n")
+                + schema.toString();
+
+        List<Future<Row>> futures = new ArrayList<>(documents.size());
+        for (int i = 0; i < documents.size(); i++) {
+            final int index = i;
+            final String doc = documents.get(i);
+            FutureTask<Row> task = new FutureTask<>(() -> extractOne(index, model, grammar, preamble, doc, schema,
+                    maxTokens));
+            futures.add(task);
+            executor().execute(task);
+        }
+        List<Row> rows = new ArrayList<>(documents.size());
+        for (int i = 0; i < futures.size(); i++) {
+            try {
+                rows.add(futures.get(i).get());
+            } catch (java.util.concurrent.ExecutionException e) {
+                rows.add(Row.failed(i, String.valueOf(e.getCause().getMessage())));
+            }
+        }
+        return rows;
+    }
+
+    private Row extractOne(int index, String model, String grammar, String preamble, String doc,
+            JsonNode schema, Integer maxTokens) {
+        if (doc == null || doc.isBlank()) {
+            return Row.failed(index, "empty document");
+        }
+        try {
+            var request = InferenceRequest.builder()
+                    .model(model)
+                    .message(Message.system(preamble))
+                    .message(Message.user(doc))
+                    .temperature(0.0)
+                    .maxTokens(maxTokens != null ? maxTokens : 512)
+                    .jsonMode(true)
+                    .grammar(grammar)
+                    .build();
+            var response = sdkProvider.getSdk().createCompletion(request);
+            JsonNode data = mapper.readTree(response.getContent());
+            String missing = missingRequired(schema, data);
+            if (missing != null) {
+                return new Row(index, "error", data, "missing required field: " + missing);
+            }
+            return Row.ok(index, data);
+        } catch (com.fasterxml.jackson.core.JsonProcessingException e) {
+            return Row.failed(index, "model output is not valid JSON: " + e.getOriginalMessage());
+        } catch (Exception e) {
+            return Row.failed(index, e.getMessage());
+        }
+    }
+
+    private static String missingRequired(JsonNode schema, JsonNode data) {
+        for (JsonNode req : schema.path("required")) {
+            if (!data.has(req.asText())) {
+                return req.asText();
+            }
+        }
+        return null;
+    }
+
+    private Executor executor() {
+        Executor e = executor;
+        if (e == null) {
+            synchronized (this) {
+                if (executor == null) {
+                    executor = workerPool.limit(workers, Integer.MAX_VALUE);
+                }
+                e = executor;
+            }
+        }
+        return e;
+    }
+}
@@ -0,0 +1,140 @@
+package tech.kayys.gollek.server.extract;
+
+import com.fasterxml.jackson.databind.JsonNode;
+
+import java.util.ArrayList;
+import java.util.HashMap;
+import java.util.Iterator;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Converts a (subset of) JSON Schema into a GBNF grammar so the sampler can only emit
+ * documents of the requested shape.
+ *
+ * <p>Supported: {@code object} (properties emitted in declaration order, all properties
+ * required), {@code array}, {@code string}, {@code number}, {@code integer},
+ * {@code boolean}, {@code null}, {@code enum} and local {@code $ref}s
+ * ({@code #/$defs/...}, {@code #/definitions/...}, recursive ones included). Anything else
+ * falls back to a generic JSON value.
+ */
+public final class JsonSchemaGrammar {
+
+    private static final String PRIMITIVES = """
+            ws ::= [ \\t\\n]*
+            string ::= "\\"" ( [^"\\\\] | "\\\\" ["\\\\/bfnrt] )* "\\"" ws
+            number ::= "-"? [0-9]+ ("." [0-9]+)? ([eE] [-+]? [0-9]+)? ws
+            integer ::= "-"? [0-9]+ ws
+            boolean ::= ("true" | "false") ws
+            null ::= "null" ws
+            value ::= object-any | array-any | string | number | boolean | null
+            object-any ::= "{" ws ( string ":" ws value ( "," ws string ":" ws value )* )? "}" ws
+            array-any ::= "[" ws ( value ( "," ws value )* )? "]" ws
+            """;
+
+    private final Map<String, String> rules = new LinkedHashMap<>();
+    /** Rule name per {@code $ref}, set before the target is visited so cycles terminate. */
+    private final Map<String, String> refs = new HashMap<>();
+    private final JsonNode root;
+
+    private JsonSchemaGrammar(JsonNode root) {
+        this.root = root;
+    }
+
+    public static String fromSchema(JsonNode schema) {
+        JsonSchemaGrammar g = new JsonSchemaGrammar(schema);
+        String root = g.visit(schema, "root-value");
+        StringBuilder sb = new StringBuilder();
+        sb.append("root ::= ws ").append(root).append('\n');
+        g.rules.forEach((name, body) -> sb.append(name).append(" ::= ").append(body).append('\n'));
+        sb.append(PRIMITIVES);
+        return sb.toString();
+    }
+
+    private String visit(JsonNode schema, String name) {
+        if (schema == null || schema.isMissingNode()) {
+            return "value";
+        }
+        if (schema.has("$ref")) {
+            return ref(schema.get("$ref").asText());
+        }
+        if (schema.has("enum")) {
+            List<String> alts = new ArrayList<>();
+            for (JsonNode v : schema.get("enum")) {
+                alts.add(literal(v.toString()));
+            }
+            return define(name, "(" + String.join(" | ", alts) + ") ws");
+        }
+        String type = schema.path("type").asText("");
+        switch (type) {
+            case "object": {
+                JsonNode props = schema.path("properties");
+                if (!props.isObject() || props.isEmpty()) {
+                    return "object-any";
+                }
+                StringBuilder body = new StringBuilder("\"{\" ws");
+                Iterator<Map.Entry<String, JsonNode>> it = props.fields();
+                boolean first = true;
+                while (it.hasNext()) {
+                    var e = it.next();
+                    String child = visit(e.getValue(), name + "-" + sanitize(e.getKey()));
+                    if (!first) {
+                        body.append(" \",\" ws");
+                    }
+                    body.append(' ').append(literal("\"" + e.getKey() + "\"")).append(" ws \":\" ws ").append(child);
+                    first = false;
+                }
+                body.append(" \"}\" ws");
+                return define(name, body.toString());
+            }
+            case "array": {
+                String item = visit(schema.path("items"), name + "-item");
+                return define(name, "\"[\" ws ( " + item + " ( \",\" ws " + item + " )* )? \"]\" ws");
+            }
+            case "string":
+            case "number":
+            case "integer":
+            case "boolean":
+            case "null":
+                return type;
+            default:
+                return "value";
+        }
+    }
+
+    private String ref(String ref) {
+        String known = refs.get(ref);
+        if (known != null) {
+            return known;
+        }
+        JsonNode target = ref.startsWith("#") && root != null ? root.at(ref.substring(1)) : null;
+        if (target == null || target.isMissingNode()) {
+            return "value";
+        }
+        String name = "ref-" + sanitize(ref.substring(ref.lastIndexOf('/') + 1));
+        if (refs.containsValue(name) || rules.containsKey(name)) {
+            name += "-" + refs.size();
+        }
+        refs.put(ref, name);
+        String rule = visit(target, name);
+        if (!rule.equals(name)) {
+            define(name, rule);
+        }
+        return name;
+    }
+
+    private String define(String name, String body) {
+        rules.put(name, body);
+        return name;
+    }
+
+    private static String literal(String text) {
+        return "\"" + text.replace("\\", "\\\\").replace("\"", "\\\"") + "\"";
+    }
+
+    private static String sanitize(String key) {
+        String s = key.replaceAll("[^A-Za-z0-9-]", "-").toLowerCase();
+        return s.isEmpty() ? "field" : s;
+    }
+}
@@ -0,0 +1,81 @@
+package tech.kayys.gollek.server.extract;
+
+import com.fasterxml.jackson.databind.JsonNode;
+
+import java.util.ArrayList;
+import java.util.Iterator;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Checks a JSON document against the same JSON Schema subset that {@link JsonSchemaGrammar}
+ * compiles: {@code type}, {@code properties}, {@code required}, {@code items} and
+ * {@code enum}. Used to verify output from runners that cannot enforce a grammar.
+ */
+public final class JsonSchemaValidator {
+
+    private JsonSchemaValidator() {
+    }
+
+    /**
+     * Returns the violations found, each prefixed with a JSON-pointer-like path; empty
+     * when the document conforms.
+     */
+    public static List<String> validate(JsonNode schema, JsonNode data) {
+        List<String> errors = new ArrayList<>();
+        visit(schema, data, "$", errors);
+        return errors;
+    }
+
+    private static void visit(JsonNode schema, JsonNode data, String path, List<String> errors) {
+        if (schema == null || schema.isMissingNode() || !schema.isObject()) {
+            return;
+        }
+        if (schema.has("enum")) {
+            boolean match = false;
+            for (JsonNode v : schema.get("enum")) {
+                match |= v.equals(data);
+            }
+            if (!match) {
+                errors.add(path + ": value not in enum");
+            }
+            return;
+        }
+        String type = schema.path("type").asText("");
+        if (!type.isEmpty() && !hasType(data, type)) {
+            errors.add(path + ": expected " + type);
+            return;
+        }
+        if (data.isObject()) {
+            for (JsonNode req : schema.path("required")) {
+                if (!data.has(req.asText())) {
+                    errors.add(path + ": missing required field " + req.asText());
+                }
+            }
+            Iterator<Map.Entry<String, JsonNode>> it = schema.path("properties").fields();
+            while (it.hasNext()) {
+                var e = it.next();
+                if (data.has(e.getKey())) {
+                    visit(e.getValue(), data.get(e.getKey()), path + "." + e.getKey(), errors);
+                }
+            }
+        } else if (data.isArray() && schema.has("items")) {
+            for (int i = 0; i < data.size(); i++) {
+                visit(schema.get("items"), data.get(i), path + "[" + i + "]", errors);
+            }
+        }
+    }
+
+    private static boolean hasType(JsonNode data, String type) {
+        return switch (type) {
+            case "object" -> data.isObject();
+            case "array" -> data.isArray();
+            case "string" -> data.isTextual();
+            case "number" -> data.isNumber();
+            case "integer" -> data.isIntegralNumber();
+            case "boolean" -> data.isBoolean();
+            case "null" -> data.isNull();
+            default -> true;
+        };
+    }
+}
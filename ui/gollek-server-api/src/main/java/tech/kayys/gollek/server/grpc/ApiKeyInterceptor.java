@@ -0,0 +1,53 @@
+package tech.kayys.gollek.server.grpc;
+
+import io.grpc.Metadata;
+import io.grpc.ServerCall;
+import io.grpc.ServerCallHandler;
+import io.grpc.ServerInterceptor;
+import io.grpc.Status;
+import io.quarkus.grpc.GlobalInterceptor;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.spi.Prioritized;
+import jakarta.inject.Inject;
+
+import tech.kayys.gollek.server.security.ApiKeyStore;
+
+/**
+ * Applies the HTTP {@code X-API-Key} check to gRPC calls via {@code x-api-key} metadata.
+ * Health stays open, matching {@code /health}.
+ */
+@ApplicationScoped
+@GlobalInterceptor
+public class ApiKeyInterceptor implements ServerInterceptor, Prioritized {
+
+    static final Metadata.Key<String> API_KEY = Metadata.Key.of("x-api-key", Metadata.ASCII_STRING_MARSHALLER);
+
+    /** Higher runs first; {@link RateLimitInterceptor} comes after. */
+    static final int PRIORITY = 100;
+
+    @Override
+    public int getPriority() {
+        return PRIORITY;
+    }
+
+    @Inject
+    ApiKeyStore apiKeyStore;
+
+    @Override
+    public <Q, R> ServerCall.Listener<Q> interceptCall(ServerCall<Q, R> call, Metadata headers,
+            ServerCallHandler<Q, R> next) {
+        if (call.getMethodDescriptor().getFullMethodName().endsWith("/Health")) {
+            return next.startCall(call, headers);
+        }
+        String key = headers.get(API_KEY);
+        if (key == null || key.isBlank()) {
+            call.close(Status.UNAUTHENTICATED.withDescription("Missing API key"), new Metadata());
+            return new ServerCall.Listener<>() { };
+        }
+        if (!apiKeyStore.listKeys().contains(key)) {
+            call.close(Status.PERMISSION_DENIED.withDescription("Invalid API key"), new Metadata());
+            return new ServerCall.Listener<>() { };
+        }
+        return next.startCall(call, headers);
+    }
+}
@@ -0,0 +1,219 @@
+package tech.kayys.gollek.server.grpc;
+
+import io.grpc.Context;
+import io.grpc.Status;
+import io.quarkus.grpc.GrpcService;
+import io.smallrye.common.annotation.Blocking;
+import io.smallrye.mutiny.Multi;
+import io.smallrye.mutiny.Uni;
+import jakarta.inject.Inject;
+
+import tech.kayys.gollek.server.ClientCancellation;
+import tech.kayys.gollek.server.RequestTimeout;
+import tech.kayys.gollek.server.SamplingDefaults;
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.grpc.proto.ChatMessage;
+import tech.kayys.gollek.server.grpc.proto.CompletionChunk;
+import tech.kayys.gollek.server.grpc.proto.CompletionRequest;
+import tech.kayys.gollek.server.grpc.proto.CompletionResponse;
+import tech.kayys.gollek.server.grpc.proto.EmbedRequest;
+import tech.kayys.gollek.server.grpc.proto.EmbedResponse;
+import tech.kayys.gollek.server.grpc.proto.Embedding;
+import tech.kayys.gollek.server.grpc.proto.GollekInference;
+import tech.kayys.gollek.server.grpc.proto.HealthRequest;
+import tech.kayys.gollek.server.grpc.proto.HealthResponse;
+import tech.kayys.gollek.server.grpc.proto.TokenizeRequest;
+import tech.kayys.gollek.server.grpc.proto.TokenizeResponse;
+import tech.kayys.gollek.server.grpc.proto.Usage;
+import tech.kayys.gollek.server.models.TokenizerService;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.embedding.EmbeddingRequest;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+
+import java.util.UUID;
+
+/**
+ * gRPC counterpart of the HTTP inference endpoints. Both front ends go through the
+ * same {@link SdkProvider}, so they share models, runners and admission limits.
+ *
+ * <p>Request ids are always assigned here, since they key cancellation and the runner's
+ * bookkeeping; a client's {@code request_id} is kept as the {@value #CLIENT_REQUEST_ID}
+ * parameter and echoed back, never used as the id.
+ */
+@GrpcService
+public class InferenceGrpcService implements GollekInference {
+
+    static final String CLIENT_REQUEST_ID = "client_request_id";
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    TokenizerService tokenizers;
+
+    @Inject
+    RequestTimeout requestTimeout;
+
+    @Inject
+    SamplingDefaults samplingDefaults;
+
+    @Override
+    @Blocking
+    public Uni<CompletionResponse> complete(CompletionRequest request) {
+        Context call = Context.current();
+        return Uni.createFrom().item(() -> {
+            InferenceRequest req = bounded(toInferenceRequest(request, false));
+            ClientCancellation.onGrpcCancel(call, req.getRequestId());
+            InferenceResponse resp;
+            try {
+                resp = sdkProvider.getSdk().createCompletion(req);
+            } catch (Exception e) {
+                throw Status.INTERNAL.withDescription(e.getMessage()).withCause(e).asRuntimeException();
+            }
+            return CompletionResponse.newBuilder()
+                    .setRequestId(req.getRequestId())
+                    .setClientRequestId(request.getRequestId())
+                    .setModel(resp.getModel() != null ? resp.getModel() : request.getModel())
+                    .setContent(resp.getContent() == null ? "" : resp.getContent())
+                    .setFinishReason(resp.getFinishReason() == null ? "stop" : resp.getFinishReason().name().toLowerCase())
+                    .setUsage(Usage.newBuilder()
+                            .setInputTokens(resp.getInputTokens())
+                            .setOutputTokens(resp.getOutputTokens()))
+                    .setDurationMs(resp.getDurationMs())
+                    .build();
+        });
+    }
+
+    @Override
+    public Multi<CompletionChunk> completeStream(CompletionRequest request) {
+        InferenceRequest req;
+        try {
+            req = bounded(toInferenceRequest(request, true));
+        } catch (RuntimeException e) {
+            return Multi.createFrom().failure(e);
+        }
+        String requestId = req.getRequestId();
+        return sdkProvider.getSdk().streamCompletion(req)
+                .onCancellation().invoke(() -> RequestCancellation.cancel(requestId))
+                .map(chunk -> toChunk(chunk, request.getRequestId()));
+    }
+
+    @Override
+    @Blocking
+    public Uni<EmbedResponse> embed(EmbedRequest request) {
+        return Uni.createFrom().item(() -> {
+            if (request.getInputsCount() == 0) {
+                throw Status.INVALID_ARGUMENT.withDescription("inputs must not be empty").asRuntimeException();
+            }
+            try {
+                var resp = sdkProvider.getSdk().createEmbedding(EmbeddingRequest.builder()
+                        .requestId(UUID.randomUUID().toString())
+                        .model(request.getModel())
+                        .inputs(request.getInputsList())
+                        .build());
+                var out = EmbedResponse.newBuilder()
+                        .setModel(resp.model() != null ? resp.model() : request.getModel())
+                        .setDimension(resp.dimension());
+                for (float[] vec : resp.embeddings()) {
+                    var e = Embedding.newBuilder();
+                    for (float v : vec) {
+                        e.addValues(v);
+                    }
+                    out.addEmbeddings(e);
+                }
+                return out.build();
+            } catch (Exception e) {
+                throw Status.INTERNAL.withDescription(e.getMessage()).withCause(e).asRuntimeException();
+            }
+        });
+    }
+
+    @Override
+    @Blocking
+    public Uni<TokenizeResponse> tokenize(TokenizeRequest request) {
+        return Uni.createFrom().item(() -> {
+            int[] tokens;
+            try {
+                tokens = tokenizers.tokenize(request.getModel(), request.getText(), request.getAddSpecial());
+            } catch (IllegalArgumentException e) {
+                throw Status.INVALID_ARGUMENT.withDescription(e.getMessage()).asRuntimeException();
+            } catch (UnsupportedOperationException e) {
+                throw Status.UNIMPLEMENTED.withDescription(e.getMessage()).asRuntimeException();
+            }
+            TokenizeResponse.Builder out = TokenizeResponse.newBuilder();
+            for (int token : tokens) {
+                out.addTokens(token);
+            }
+            return out.build();
+        });
+    }
+
+    @Override
+    public Uni<HealthResponse> health(HealthRequest request) {
+        return Uni.createFrom().item(HealthResponse.newBuilder().setStatus("ok").build());
+    }
+
+    private InferenceRequest bounded(InferenceRequest request) {
+        try {
+            return requestTimeout.apply(samplingDefaults.apply(request));
+        } catch (IllegalArgumentException e) {
+            throw Status.INVALID_ARGUMENT.withDescription(e.getMessage()).asRuntimeException();
+        }
+    }
+
+    static InferenceRequest toInferenceRequest(CompletionRequest request, boolean streaming) {
+        if (request.getMessagesCount() == 0) {
+            throw Status.INVALID_ARGUMENT.withDescription("messages must not be empty").asRuntimeException();
+        }
+        var builder = InferenceRequest.builder()
+                .requestId(UUID.randomUUID().toString())
+                .model(request.getModel())
+                .streaming(streaming);
+        if (!request.getRequestId().isEmpty()) {
+            builder.parameter(CLIENT_REQUEST_ID, request.getRequestId());
+        }
+        for (ChatMessage m : request.getMessagesList()) {
+            Message.Role role;
+            try {
+                role = Message.Role.valueOf(m.getRole().toUpperCase());
+            } catch (IllegalArgumentException e) {
+                throw Status.INVALID_ARGUMENT.withDescription("unsupported message role: " + m.getRole())
+                        .asRuntimeException();
+            }
+            builder.message(new Message(role, m.getContent(), null, null, null));
+        }
+        if (request.hasParams()) {
+            var p = request.getParams();
+            if (p.hasTemperature()) builder.temperature(p.getTemperature());
+            if (p.hasTopP()) builder.topP(p.getTopP());
+            if (p.hasTopK()) builder.topK(p.getTopK());
+            if (p.hasMaxTokens()) builder.maxTokens(p.getMaxTokens());
+            if (p.hasRepeatPenalty()) builder.repeatPenalty(p.getRepeatPenalty());
+            if (p.getStopCount() > 0) builder.parameter("stop", p.getStopList());
+            if (p.hasSeed()) builder.parameter("seed", p.getSeed());
+            if (p.hasMaxTimeMs()) builder.parameter(RequestTimeout.MAX_TIME, p.getMaxTimeMs());
+        }
+        return builder.build();
+    }
+
+    private static CompletionChunk toChunk(StreamingInferenceChunk chunk, String clientRequestId) {
+        var out = CompletionChunk.newBuilder()
+                .setRequestId(chunk.requestId() == null ? "" : chunk.requestId())
+                .setClientRequestId(clientRequestId)
+                .setIndex(chunk.index())
+                .setDelta(chunk.delta() == null ? "" : chunk.delta())
+                .setFinished(chunk.finished());
+        if (chunk.finishReason() != null) {
+            out.setFinishReason(chunk.finishReason());
+        }
+        if (chunk.usage() != null) {
+            out.setUsage(Usage.newBuilder()
+                    .setInputTokens((int) chunk.usage().inputTokens())
+                    .setOutputTokens((int) chunk.usage().outputTokens()));
+        }
+        return out.build();
+    }
+}
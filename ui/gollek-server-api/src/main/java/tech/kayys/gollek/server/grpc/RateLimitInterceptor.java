@@ -0,0 +1,61 @@
+package tech.kayys.gollek.server.grpc;
+
+import io.grpc.Grpc;
+import io.grpc.Metadata;
+import io.grpc.ServerCall;
+import io.grpc.ServerCallHandler;
+import io.grpc.ServerInterceptor;
+import io.grpc.Status;
+import io.quarkus.grpc.GlobalInterceptor;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.spi.Prioritized;
+import jakarta.inject.Inject;
+
+import tech.kayys.gollek.server.security.RateLimiter;
+
+import java.net.InetSocketAddress;
+
+/**
+ * The HTTP per-IP and per-API-key budgets for gRPC calls, charged once per call, streams
+ * included. Runs after {@link ApiKeyInterceptor}; over-budget calls fail with
+ * {@code RESOURCE_EXHAUSTED} and a {@code retry-after} trailer in seconds.
+ */
+@ApplicationScoped
+@GlobalInterceptor
+public class RateLimitInterceptor implements ServerInterceptor, Prioritized {
+
+    private static final Metadata.Key<String> FORWARDED_FOR = Metadata.Key.of("x-forwarded-for",
+            Metadata.ASCII_STRING_MARSHALLER);
+    private static final Metadata.Key<String> RETRY_AFTER = Metadata.Key.of("retry-after",
+            Metadata.ASCII_STRING_MARSHALLER);
+
+    @Inject
+    RateLimiter limiter;
+
+    @Override
+    public int getPriority() {
+        return ApiKeyInterceptor.PRIORITY - 10;
+    }
+
+    @Override
+    public <Q, R> ServerCall.Listener<Q> interceptCall(ServerCall<Q, R> call, Metadata headers,
+            ServerCallHandler<Q, R> next) {
+        if (!limiter.isEnabled() || call.getMethodDescriptor().getFullMethodName().endsWith("/Health")) {
+            return next.startCall(call, headers);
+        }
+        String peer = call.getAttributes().get(Grpc.TRANSPORT_ATTR_REMOTE_ADDR) instanceof InetSocketAddress address
+                && address.getAddress() != null ? address.getAddress().getHostAddress() : null;
+        RateLimiter.Decision decision = limiter.checkIp(limiter.clientIp(peer, headers.get(FORWARDED_FOR)));
+        String apiKey = headers.get(ApiKeyInterceptor.API_KEY);
+        if (decision.allowed() && apiKey != null && !apiKey.isBlank()) {
+            decision = limiter.checkApiKey(apiKey);
+        }
+        if (!decision.allowed()) {
+            Metadata trailers = new Metadata();
+            trailers.put(RETRY_AFTER, String.valueOf(decision.retryAfterSeconds()));
+            call.close(Status.RESOURCE_EXHAUSTED.withDescription("Rate limit exceeded"), trailers);
+            return new ServerCall.Listener<>() { };
+        }
+        return next.startCall(call, headers);
+    }
+}
@@ -1,28 +1,53 @@
 package tech.kayys.gollek.server.jobs;
 
+import jakarta.annotation.PostConstruct;
 import jakarta.enterprise.context.ApplicationScoped;
 import jakarta.inject.Inject;
 
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.DeserializationFeature;
+import com.fasterxml.jackson.databind.ObjectMapper;
 import io.smallrye.mutiny.Multi;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
 import tech.kayys.gollek.sdk.model.PullProgress;
 import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.WorkerPool;
+import tech.kayys.gollek.server.models.ModelCache;
+import tech.kayys.gollek.server.security.AtRestCipher;
+import tech.kayys.gollek.server.store.Store;
 
+import java.io.IOException;
+import java.util.Comparator;
+import java.util.LinkedHashMap;
 import java.util.List;
+import java.util.Map;
 import java.util.Optional;
 import java.util.UUID;
 import java.util.concurrent.ConcurrentHashMap;
 import java.util.concurrent.ConcurrentMap;
 import java.util.concurrent.ExecutorService;
 import java.util.concurrent.Executors;
-import java.util.ArrayList;
-import java.util.Collections;
 
 /**
  * Simple background job manager for long-running tasks (model pulls).
+ *
+ * <p>Running jobs live in memory; every status change is also written to the
+ * {@value #NAMESPACE} store namespace, so listings survive a restart and show jobs
+ * from every replica sharing the store. A job this node left unfinished is marked
+ * failed when it starts again. Job results can hold model output, so with a storage
+ * encryption key configured the stored records are AES-GCM encrypted.
  */
 @ApplicationScoped
 public class BackgroundJobManager {
 
+    private static final Logger LOG = Logger.getLogger(BackgroundJobManager.class);
+    static final String NAMESPACE = "jobs";
+
+    /** A job as kept in the store, with the node that ran it. */
+    record Stored(String node, JobRecord.Info info) {
+    }
+
     private final ExecutorService executor = Executors.newCachedThreadPool(r -> {
         Thread t = new Thread(r);
         t.setDaemon(true);
@@ -34,10 +59,57 @@ public class BackgroundJobManager {
     @Inject
     SdkProvider sdkProvider;
 
+    @Inject
+    ModelCache modelCache;
+
+    @Inject
+    Store store;
+
+    @Inject
+    WorkerPool workerPool;
+
+    /** Most chunks one map-reduce job maps at once, whatever it asks for. */
+    @ConfigProperty(name = "gollek.server.jobs.map-reduce.max-concurrency", defaultValue = "4")
+    int mapReduceMaxConcurrency;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key")
+    Optional<String> encryptionKey;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key-file")
+    Optional<String> encryptionKeyFile;
+
+    private final ObjectMapper mapper = new ObjectMapper()
+            .configure(DeserializationFeature.FAIL_ON_UNKNOWN_PROPERTIES, false);
+    private AtRestCipher cipher;
+    private final String node = Optional.ofNullable(System.getenv("HOSTNAME")).orElse("gollek");
+
+    /** Marks jobs this node was running when it stopped as failed; they will not resume. */
+    @PostConstruct
+    void init() {
+        try {
+            cipher = AtRestCipher.fromConfig(encryptionKey, encryptionKeyFile).orElse(null);
+        } catch (IOException e) {
+            throw new IllegalStateException("Failed to read storage encryption key: " + e.getMessage(), e);
+        }
+        int interrupted = 0;
+        for (String jobId : store.keys(NAMESPACE)) {
+            Optional<Stored> stored = read(jobId);
+            if (stored.isEmpty() || !node.equals(stored.get().node()) || finished(stored.get().info().status())) {
+                continue;
+            }
+            JobRecord.Info info = stored.get().info();
+            write(new JobRecord.Info(info.jobId(), info.type(), "FAILED", info.lastProgress(), info.stageProgress(),
+                    info.result(), "Interrupted by a server restart"));
+            interrupted++;
+        }
+        if (interrupted > 0) {
+            LOG.infof("Marked %d job(s) interrupted by the last shutdown as failed", interrupted);
+        }
+    }
+
     public String startPullJob(String modelSpec, String revision, boolean force) {
         String jobId = UUID.randomUUID().toString();
-        JobRecord jr = new JobRecord(jobId);
-        jobs.put(jobId, jr);
+        JobRecord jr = track(new JobRecord(jobId));
 
         var future = executor.submit(() -> {
             try {
@@ -46,6 +118,7 @@ public class BackgroundJobManager {
                 sdk.pullModel(modelSpec, revision, force, p -> {
                     jr.addProgress(p);
                 });
+                adoptPulled(modelSpec);
                 jr.setStatus("COMPLETED");
             } catch (Exception e) {
                 jr.setStatus("FAILED");
@@ -57,6 +130,49 @@ public class BackgroundJobManager {
         return jobId;
     }
 
+    /**
+     * Moves a freshly pulled file into the content store and applies the cache size bound;
+     * the pull itself already succeeded.
+     */
+    private void adoptPulled(String modelSpec) {
+        if (modelCache == null) {
+            return;
+        }
+        try {
+            modelCache.afterPull();
+        } catch (Exception e) {
+            LOG.warnf("Model cache could not take in %s: %s", modelSpec, e.getMessage());
+        }
+    }
+
+    public String startMapReduceJob(MapReduceJob.Spec spec) {
+        String jobId = UUID.randomUUID().toString();
+        JobRecord jr = new JobRecord(jobId);
+        jr.setType(MapReduceJob.TYPE);
+        track(jr);
+        int parallelism = MapReduceJob.parallelism(spec.concurrency(), mapReduceMaxConcurrency);
+        jr.setFuture(executor.submit(new MapReduceJob(spec, jr, sdkProvider.getSdk(),
+                workerPool.limit(parallelism, Integer.MAX_VALUE))));
+        return jobId;
+    }
+
+    /**
+     * Track a job that is run elsewhere (e.g. by {@link JobQueue}) so it shows up in the
+     * job listing and status endpoints.
+     */
+    public JobRecord register(String jobId, String type) {
+        JobRecord jr = new JobRecord(jobId);
+        jr.setType(type);
+        return track(jr);
+    }
+
+    private JobRecord track(JobRecord jr) {
+        jobs.put(jr.getJobId(), jr);
+        jr.onChange(changed -> write(changed.toInfo()));
+        write(jr.toInfo());
+        return jr;
+    }
+
     public Multi<PullProgress> streamProgress(String jobId) {
         JobRecord jr = jobs.get(jobId);
         if (jr == null) {
@@ -94,13 +210,24 @@ public class BackgroundJobManager {
         });
     }
 
+    public Optional<JobRecord> getRecord(String jobId) {
+        return Optional.ofNullable(jobs.get(jobId));
+    }
+
     public Optional<JobRecord.Info> getJobInfo(String jobId) {
         JobRecord jr = jobs.get(jobId);
-        return jr == null ? Optional.empty() : Optional.of(jr.toInfo());
+        return jr != null ? Optional.of(jr.toInfo()) : read(jobId).map(Stored::info);
     }
 
+    /** Jobs on this node with their live progress, then the rest of the store. */
     public java.util.List<JobRecord.Info> listJobs() {
-        return jobs.values().stream().map(JobRecord::toInfo).toList();
+        Map<String, JobRecord.Info> all = new LinkedHashMap<>();
+        jobs.values().forEach(jr -> all.put(jr.getJobId(), jr.toInfo()));
+        store.keys(NAMESPACE).stream()
+                .filter(id -> !all.containsKey(id))
+                .sorted(Comparator.naturalOrder())
+                .forEach(id -> read(id).ifPresent(stored -> all.put(id, stored.info())));
+        return List.copyOf(all.values());
     }
 
     public boolean cancelJob(String jobId) {
@@ -112,5 +239,39 @@ public class BackgroundJobManager {
         }
         return cancelled;
     }
-}
 
+    private void write(JobRecord.Info info) {
+        try {
+            String json = mapper.writeValueAsString(new Stored(node, info));
+            store.put(NAMESPACE, info.jobId(), cipher == null ? json : cipher.encryptToString(json));
+        } catch (JsonProcessingException | RuntimeException e) {
+            // the job itself is unaffected; only its listing after a restart is
+            LOG.warnf("Failed to store job %s: %s", info.jobId(), e.getMessage());
+        }
+    }
+
+    private Optional<Stored> read(String jobId) {
+        try {
+            return store.get(NAMESPACE, jobId).map(v -> {
+                try {
+                    if (v.startsWith("{")) {
+                        return mapper.readValue(v, Stored.class);
+                    }
+                    if (cipher == null) {
+                        throw new IllegalStateException("encrypted, but no storage encryption key is configured");
+                    }
+                    return mapper.readValue(cipher.decryptToString(v), Stored.class);
+                } catch (JsonProcessingException e) {
+                    throw new IllegalStateException(e.getMessage(), e);
+                }
+            });
+        } catch (IllegalStateException e) {
+            LOG.warnf("Skipping unreadable job %s: %s", jobId, e.getMessage());
+            return Optional.empty();
+        }
+    }
+
+    private static boolean finished(String status) {
+        return "COMPLETED".equals(status) || "FAILED".equals(status) || "CANCELLED".equals(status);
+    }
+}
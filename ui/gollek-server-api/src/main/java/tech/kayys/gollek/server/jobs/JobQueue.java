@@ -0,0 +1,319 @@
+package tech.kayys.gollek.server.jobs;
+
+import io.quarkus.runtime.ShutdownEvent;
+import io.quarkus.runtime.StartupEvent;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.UUID;
+import java.util.concurrent.BlockingQueue;
+import java.util.concurrent.CompletableFuture;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.concurrent.LinkedBlockingQueue;
+
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.DeserializationFeature;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.security.ApiKeyStore;
+import tech.kayys.gollek.server.security.AtRestCipher;
+import tech.kayys.gollek.server.store.Store;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+
+/**
+ * Batch inference jobs ({@code POST /v1/jobs}). Up to {@code max-queue} jobs wait in memory
+ * for one of the workers. With spilling enabled, jobs beyond that go to the
+ * {@value #NAMESPACE} store namespace and are pulled back in submission order as room
+ * frees up; jobs still waiting at shutdown are spilled too, and the store is reloaded on
+ * startup, so a deploy does not lose accepted submissions.
+ *
+ * <p>A spilled job keeps the hash of its API key, not the key, and runs again only while
+ * that key is still allowed. With a storage encryption key configured the spilled
+ * messages are AES-GCM encrypted like stored conversations.
+ */
+@ApplicationScoped
+public class JobQueue {
+
+    private static final Logger LOG = Logger.getLogger(JobQueue.class);
+    static final String NAMESPACE = "job-queue";
+    public static final String TYPE = "inference";
+
+    /**
+     * What a spilled job needs to run again after a restart. {@code apiKey} is only read,
+     * from jobs spilled by earlier versions.
+     */
+    record Spilled(String jobId, long submittedAt, String requestId, String apiKeyHash, String apiKey,
+            String model, List<Message> messages, Map<String, Object> parameters) {
+    }
+
+    private record Pending(JobRecord record, InferenceRequest request, long submittedAt) {
+    }
+
+    @ConfigProperty(name = "gollek.server.jobs.workers", defaultValue = "1")
+    int workers;
+
+    @ConfigProperty(name = "gollek.server.jobs.max-queue", defaultValue = "100")
+    int maxQueue;
+
+    @ConfigProperty(name = "gollek.server.jobs.spill.enabled", defaultValue = "false")
+    boolean spillEnabled;
+
+    @ConfigProperty(name = "gollek.server.jobs.spill.max", defaultValue = "10000")
+    int spillMax;
+
+    @Inject
+    Store store;
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    BackgroundJobManager jobs;
+
+    @Inject
+    ApiKeyStore apiKeyStore;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key")
+    Optional<String> encryptionKey;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key-file")
+    Optional<String> encryptionKeyFile;
+
+    private final ObjectMapper mapper = new ObjectMapper()
+            .configure(DeserializationFeature.FAIL_ON_UNKNOWN_PROPERTIES, false);
+    private AtRestCipher cipher;
+    private BlockingQueue<Pending> pending;
+    private ExecutorService pool;
+    private int spilled;
+    private long spillSequence;
+
+    void onStart(@Observes StartupEvent event) {
+        init();
+        pool = Executors.newFixedThreadPool(Math.max(1, workers), r -> {
+            Thread t = new Thread(r, "gollek-jobs");
+            t.setDaemon(true);
+            return t;
+        });
+        for (int i = 0; i < Math.max(1, workers); i++) {
+            pool.execute(this::work);
+        }
+    }
+
+    synchronized void onStop(@Observes ShutdownEvent event) {
+        if (pool != null) {
+            pool.shutdownNow();
+        }
+        if (!spillEnabled || pending == null) {
+            return;
+        }
+        List<Pending> waiting = new ArrayList<>();
+        pending.drainTo(waiting);
+        for (Pending job : waiting) {
+            if (!job.record().isFinished()) {
+                spill(job);
+            }
+        }
+        if (!waiting.isEmpty()) {
+            LOG.infof("Spilled %d queued job(s) for the next start", waiting.size());
+        }
+    }
+
+    /** Create the in-memory queue and pick up jobs spilled by a previous run. */
+    synchronized void init() {
+        try {
+            cipher = AtRestCipher.fromConfig(encryptionKey, encryptionKeyFile).orElse(null);
+        } catch (IOException e) {
+            throw new IllegalStateException("Failed to read storage encryption key: " + e.getMessage(), e);
+        }
+        pending = new LinkedBlockingQueue<>(Math.max(1, maxQueue));
+        List<String> keys = store.keys(NAMESPACE);
+        for (String key : keys) {
+            read(key).ifPresent(job -> track(job.jobId(), job.requestId()));
+        }
+        spilled = keys.size();
+        if (spilled > 0) {
+            LOG.infof("Reloaded %d spilled job(s)", spilled);
+        }
+        refill();
+    }
+
+    /**
+     * Queue a job, or return empty when both the memory queue and the spill are full.
+     */
+    public synchronized Optional<String> submit(InferenceRequest request) {
+        String jobId = UUID.randomUUID().toString();
+        if (request.getRequestId() == null) {
+            request = request.toBuilder().requestId(jobId).build();
+        }
+        long now = System.currentTimeMillis();
+        // once anything is spilled, new jobs queue behind it to keep submission order
+        if (spilled == 0 && pending.remainingCapacity() > 0) {
+            pending.add(new Pending(track(jobId, request.getRequestId()), request, now));
+            return Optional.of(jobId);
+        }
+        if (!spillEnabled || spilled >= spillMax) {
+            return Optional.empty();
+        }
+        spill(new Pending(track(jobId, request.getRequestId()), request, now));
+        spilled++;
+        return Optional.of(jobId);
+    }
+
+    /** Jobs waiting in memory and on disk. */
+    public synchronized Map<String, Object> depth() {
+        return Map.of("memory", pending.size(), "spilled", spilled);
+    }
+
+    private void work() {
+        while (!Thread.currentThread().isInterrupted()) {
+            Pending job;
+            try {
+                job = pending.take();
+            } catch (InterruptedException e) {
+                Thread.currentThread().interrupt();
+                return;
+            }
+            refill();
+            run(job);
+        }
+    }
+
+    private void run(Pending job) {
+        JobRecord record = job.record();
+        if (record.isFinished()) {
+            return;
+        }
+        record.setStatus("RUNNING");
+        try {
+            var resp = sdkProvider.getSdk().createCompletion(job.request());
+            if (!record.isFinished()) {
+                record.setResult(resp.getContent());
+                record.setStatus("COMPLETED");
+            }
+        } catch (Exception e) {
+            if (!record.isFinished()) {
+                record.setError(e.getMessage());
+                record.setStatus("FAILED");
+            }
+        } finally {
+            if (record.getFuture() instanceof CompletableFuture<?> handle) {
+                handle.complete(null);
+            }
+        }
+    }
+
+    /** Move spilled jobs into memory, oldest first, while there is room. */
+    synchronized void refill() {
+        if (spilled == 0) {
+            return;
+        }
+        for (String key : store.keys(NAMESPACE).stream().sorted().toList()) {
+            if (pending.remainingCapacity() == 0) {
+                break;
+            }
+            Optional<Spilled> job = read(key);
+            store.delete(NAMESPACE, key);
+            spilled--;
+            if (job.isEmpty()) {
+                continue;
+            }
+            Spilled entry = job.get();
+            JobRecord record = jobs.getRecord(entry.jobId()).orElseGet(() -> track(entry.jobId(), entry.requestId()));
+            if (record.isFinished()) {
+                continue;
+            }
+            Optional<String> apiKey = apiKey(entry);
+            if (apiKey.isEmpty() && (entry.apiKeyHash() != null || entry.apiKey() != null)) {
+                record.setError("API key no longer allowed");
+                record.setStatus("FAILED");
+                continue;
+            }
+            pending.add(new Pending(record, toRequest(entry, apiKey.orElse(null)), entry.submittedAt()));
+        }
+    }
+
+    private JobRecord track(String jobId, String requestId) {
+        JobRecord record = jobs.register(jobId, TYPE);
+        // completed by the worker; cancelling it first marks the job cancelled and stops generation
+        CompletableFuture<Void> handle = new CompletableFuture<>();
+        handle.whenComplete((v, e) -> {
+            if (handle.isCancelled()) {
+                RequestCancellation.cancel(requestId != null ? requestId : jobId);
+            }
+        });
+        record.setFuture(handle);
+        return record;
+    }
+
+    private void spill(Pending job) {
+        InferenceRequest r = job.request();
+        Spilled entry = new Spilled(job.record().getJobId(), job.submittedAt(), r.getRequestId(),
+                r.getApiKey() == null ? null : ApiKeyStore.hash(r.getApiKey()), null,
+                r.getModel(), r.getMessages(), r.getParameters());
+        try {
+            String json = mapper.writeValueAsString(entry);
+            // zero-padded so key order is submission order, even within one millisecond
+            store.put(NAMESPACE, String.format("%015d-%09d-%s", job.submittedAt(), spillSequence++, entry.jobId()),
+                    cipher == null ? json : cipher.encryptToString(json));
+        } catch (JsonProcessingException e) {
+            throw new IllegalStateException("Cannot spill job " + entry.jobId(), e);
+        }
+    }
+
+    private Optional<Spilled> read(String key) {
+        try {
+            return store.get(NAMESPACE, key).map(v -> {
+                try {
+                    if (v.startsWith("{")) {
+                        return mapper.readValue(v, Spilled.class);
+                    }
+                    if (cipher == null) {
+                        throw new IllegalStateException("encrypted, but no storage encryption key is configured");
+                    }
+                    return mapper.readValue(cipher.decryptToString(v), Spilled.class);
+                } catch (JsonProcessingException e) {
+                    throw new IllegalStateException(e);
+                }
+            });
+        } catch (IllegalStateException e) {
+            LOG.warnf("Dropping unreadable spilled job %s: %s", key, e.getMessage());
+            return Optional.empty();
+        }
+    }
+
+    /** The allowed key a spilled job was submitted with; empty if it had none or it was revoked. */
+    private Optional<String> apiKey(Spilled job) {
+        if (job.apiKeyHash() != null) {
+            return apiKeyStore.findByHash(job.apiKeyHash());
+        }
+        return Optional.ofNullable(job.apiKey()).filter(apiKeyStore.listKeys()::contains);
+    }
+
+    private static InferenceRequest toRequest(Spilled job, String apiKey) {
+        var builder = InferenceRequest.builder()
+                .requestId(job.requestId() != null ? job.requestId() : job.jobId())
+                .model(job.model());
+        if (apiKey != null) {
+            builder.apiKey(apiKey);
+        }
+        if (job.messages() != null) {
+            builder.messages(job.messages());
+        }
+        if (job.parameters() != null) {
+            builder.parameters(job.parameters());
+        }
+        return builder.build();
+    }
+}
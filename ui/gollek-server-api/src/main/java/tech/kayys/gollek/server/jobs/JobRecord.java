@@ -6,6 +6,7 @@ import java.util.ArrayList;
 import java.util.Collections;
 import java.util.List;
 import java.util.concurrent.Future;
+import java.util.function.Consumer;
 
 public class JobRecord {
     private final String jobId;
@@ -13,6 +14,10 @@ public class JobRecord {
     private final List<PullProgress> history = Collections.synchronizedList(new ArrayList<>());
     private volatile String error;
     private volatile Future<?> future;
+    private volatile String type = "pull";
+    private volatile StageProgress stageProgress;
+    private volatile String result;
+    private volatile Consumer<JobRecord> onChange = jr -> { };
 
     public JobRecord(String jobId) {
         this.jobId = jobId;
@@ -30,8 +35,14 @@ public class JobRecord {
         return jobId;
     }
 
+    /** Called after each change of status, error or result; progress updates are not reported. */
+    void onChange(Consumer<JobRecord> listener) {
+        this.onChange = listener;
+    }
+
     public void setStatus(String status) {
         this.status = status;
+        onChange.accept(this);
     }
 
     public String getStatus() {
@@ -44,16 +55,46 @@ public class JobRecord {
 
     public void setError(String error) {
         this.error = error;
+        onChange.accept(this);
     }
 
     public String getError() {
         return error;
     }
 
+    public void setType(String type) {
+        this.type = type;
+    }
+
+    public String getType() {
+        return type;
+    }
+
+    public void setStageProgress(String stage, int completed, int total) {
+        this.stageProgress = new StageProgress(stage, completed, total);
+    }
+
+    public StageProgress getStageProgress() {
+        return stageProgress;
+    }
+
+    public void setResult(String result) {
+        this.result = result;
+        onChange.accept(this);
+    }
+
+    public String getResult() {
+        return result;
+    }
+
     public void setFuture(Future<?> future) {
         this.future = future;
     }
 
+    public Future<?> getFuture() {
+        return future;
+    }
+
     public boolean cancel() {
         if (future != null) {
             boolean cancelled = future.cancel(true);
@@ -67,8 +108,11 @@ public class JobRecord {
 
     public Info toInfo() {
         PullProgress last = history.isEmpty() ? null : history.get(history.size() - 1);
-        return new Info(jobId, status, last, error);
+        return new Info(jobId, type, status, last, stageProgress, result, error);
     }
 
-    public static record Info(String jobId, String status, PullProgress lastProgress, String error) { }
+    public static record StageProgress(String stage, int completed, int total) { }
+
+    public static record Info(String jobId, String type, String status, PullProgress lastProgress,
+            StageProgress stageProgress, String result, String error) { }
 }
@@ -0,0 +1,146 @@
+package tech.kayys.gollek.server.jobs;
+
+import tech.kayys.gollek.sdk.core.GollekSdk;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.concurrent.ExecutionException;
+import java.util.concurrent.Executor;
+import java.util.concurrent.FutureTask;
+import java.util.concurrent.atomic.AtomicInteger;
+
+/**
+ * Long-document job: split the input into chunks, run {@code mapPrompt} over each chunk
+ * concurrently, then combine the partial answers with {@code reducePrompt}.
+ *
+ * <p>Progress is published on the {@link JobRecord} as {@code map} and {@code reduce}
+ * stages so clients can poll {@code /v1/jobs/{id}}.
+ *
+ * <p>Chunks run on {@code mapExecutor}, which bounds how many run at once; the
+ * {@code concurrency} a client asks for is clamped by {@link #parallelism}.
+ */
+public class MapReduceJob implements Runnable {
+
+    public static final String TYPE = "map-reduce";
+
+    public static record Spec(String model, String input, String mapPrompt, String reducePrompt,
+            Integer chunkSize, Integer chunkOverlap, Integer concurrency, Integer maxTokens) { }
+
+    private final Spec spec;
+    private final JobRecord record;
+    private final GollekSdk sdk;
+    private final Executor mapExecutor;
+    private final List<FutureTask<String>> futures = new ArrayList<>();
+
+    public MapReduceJob(Spec spec, JobRecord record, GollekSdk sdk, Executor mapExecutor) {
+        this.spec = spec;
+        this.record = record;
+        this.sdk = sdk;
+        this.mapExecutor = mapExecutor;
+    }
+
+    /** Chunks to map at once: the requested {@code concurrency} (default 4), at most {@code max}. */
+    public static int parallelism(Integer requested, int max) {
+        return Math.max(1, Math.min(Math.max(1, max), requested != null ? requested : 4));
+    }
+
+    @Override
+    public void run() {
+        record.setStatus("RUNNING");
+        try {
+            List<String> chunks = split(spec.input(),
+                    spec.chunkSize() != null ? spec.chunkSize() : 4000,
+                    spec.chunkOverlap() != null ? spec.chunkOverlap() : 200);
+            record.setStageProgress("map", 0, chunks.size());
+
+            AtomicInteger done = new AtomicInteger();
+            for (String chunk : chunks) {
+                FutureTask<String> task = new FutureTask<>(() -> {
+                    String out = complete(spec.mapPrompt(), chunk);
+                    record.setStageProgress("map", done.incrementAndGet(), chunks.size());
+                    return out;
+                });
+                futures.add(task);
+                mapExecutor.execute(task);
+            }
+            List<String> partials = new ArrayList<>(chunks.size());
+            for (FutureTask<String> f : futures) {
+                partials.add(f.get());
+            }
+
+            String result;
+            if (partials.size() == 1 && (spec.reducePrompt() == null || spec.reducePrompt().isBlank())) {
+                result = partials.get(0);
+            } else {
+                record.setStageProgress("reduce", 0, 1);
+                StringBuilder joined = new StringBuilder();
+                for (int i = 0; i < partials.size(); i++) {
+                    joined.append("### Part ").append(i + 1).append('\n').append(partials.get(i)).append("\n\n");
+                }
+                String reducePrompt = spec.reducePrompt() != null && !spec.reducePrompt().isBlank()
+                        ? spec.reducePrompt()
+                        : "Combine the following partial results into a single coherent answer.";
+                result = complete(reducePrompt, joined.toString());
+                record.setStageProgress("reduce", 1, 1);
+            }
+            record.setResult(result);
+            record.setStatus("COMPLETED");
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+            record.setStatus("CANCELLED");
+        } catch (ExecutionException e) {
+            record.setStatus("FAILED");
+            record.setError(e.getCause() != null ? e.getCause().getMessage() : e.getMessage());
+        } catch (Exception e) {
+            record.setStatus("FAILED");
+            record.setError(e.getMessage());
+        } finally {
+            // chunks not yet run, e.g. after a failure or cancellation, are dropped
+            futures.forEach(f -> f.cancel(true));
+        }
+    }
+
+    private String complete(String instruction, String content) throws Exception {
+        var request = InferenceRequest.builder()
+                .model(spec.model())
+                .message(Message.system(instruction))
+                .message(Message.user(content))
+                .maxTokens(spec.maxTokens() != null ? spec.maxTokens() : 512)
+                .build();
+        return sdk.createCompletion(request).getContent();
+    }
+
+    /**
+     * Split on paragraph or sentence boundaries where possible so chunks stay readable.
+     */
+    static List<String> split(String input, int chunkSize, int overlap) {
+        List<String> chunks = new ArrayList<>();
+        if (input == null || input.isEmpty()) {
+            return chunks;
+        }
+        int size = Math.max(1, chunkSize);
+        int step = Math.max(0, Math.min(overlap, size / 2));
+        int start = 0;
+        while (start < input.length()) {
+            int end = Math.min(input.length(), start + size);
+            if (end < input.length()) {
+                int cut = input.lastIndexOf("\n\n", end);
+                if (cut <= start + size / 2) {
+                    cut = input.lastIndexOf(". ", end);
+                }
+                if (cut > start + size / 2) {
+                    end = cut + 1;
+                }
+            }
+            chunks.add(input.substring(start, end).strip());
+            if (end >= input.length()) {
+                break;
+            }
+            start = Math.max(start + 1, end - step);
+        }
+        chunks.removeIf(String::isEmpty);
+        return chunks;
+    }
+}
@@ -0,0 +1,250 @@
+package tech.kayys.gollek.server.llamaserver;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.openai.ResponseFormat;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.function.Function;
+
+/**
+ * Mapping between the llama.cpp HTTP server's API ({@code /completion}, {@code /infill},
+ * {@code /props}, {@code /slots}) and the runner's requests and responses. Sampling
+ * parameters sit at the top level of the body; prompts are sent without a chat template.
+ * Streams are server-sent events without a {@code [DONE]} marker, the last with
+ * {@code stop: true} and the token counts and timings.
+ */
+public final class LlamaServer {
+
+    private static final ObjectMapper MAPPER = new ObjectMapper();
+
+    /** Fill-in-the-middle markers, in prefix-suffix-middle order. */
+    public record Fim(String prefix, String suffix, String middle) {
+    }
+
+    /** Marker sets of the common code models; the first one the tokenizer knows is used. */
+    static final List<Fim> FIM_FORMATS = List.of(
+            new Fim("<|fim_prefix|>", "<|fim_suffix|>", "<|fim_middle|>"),
+            new Fim("<fim_prefix>", "<fim_suffix>", "<fim_middle>"),
+            new Fim("<｜fim▁begin｜>", "<｜fim▁hole｜>", "<｜fim▁end｜>"),
+            new Fim("<PRE>", "<SUF>", "<MID>"));
+
+    private static final List<String> DOUBLE_PARAMETERS = List.of("min_p", "typical_p", "presence_penalty",
+            "frequency_penalty", "mirostat_tau", "mirostat_eta");
+
+    private LlamaServer() {
+    }
+
+    public static boolean isStream(Map<String, Object> body) {
+        return Boolean.TRUE.equals(body.get("stream"));
+    }
+
+    /**
+     * A {@code /completion} body as a raw request. {@code prompt} is a string, or token ids
+     * turned back into text with {@code detokenize}.
+     */
+    public static InferenceRequest toInferenceRequest(Map<String, Object> body, String model, String requestId,
+            Function<int[], String> detokenize) {
+        return raw(body, model, requestId, prompt(body.get("prompt"), detokenize));
+    }
+
+    /**
+     * An {@code /infill} body as a raw prompt in the model's fill-in-the-middle format:
+     * the {@code input_extra} chunks, then prefix, suffix and middle markers, with
+     * {@code prompt} as the start of the middle.
+     */
+    public static InferenceRequest toInfillRequest(Map<String, Object> body, String model, String requestId,
+            Fim fim) {
+        StringBuilder prompt = new StringBuilder();
+        if (body.get("input_extra") instanceof List<?> extra) {
+            for (Object chunk : extra) {
+                if (!(chunk instanceof Map<?, ?> c) || !(c.get("text") instanceof String text)) {
+                    throw new IllegalArgumentException("input_extra entries need a text");
+                }
+                prompt.append(text);
+                if (!text.endsWith("\n")) {
+                    prompt.append('\n');
+                }
+            }
+        }
+        prompt.append(fim.prefix()).append(text(body, "input_prefix"))
+                .append(fim.suffix()).append(text(body, "input_suffix"))
+                .append(fim.middle()).append(text(body, "prompt"));
+        return raw(body, model, requestId, prompt.toString());
+    }
+
+    /** The first marker set whose markers are single tokens of the model, or null. */
+    public static Fim fim(Function<String, int[]> tokenize) {
+        for (Fim fim : FIM_FORMATS) {
+            if (tokenize.apply(fim.prefix()).length == 1 && tokenize.apply(fim.suffix()).length == 1
+                    && tokenize.apply(fim.middle()).length == 1) {
+                return fim;
+            }
+        }
+        return null;
+    }
+
+    private static InferenceRequest raw(Map<String, Object> body, String model, String requestId, String prompt) {
+        var builder = InferenceRequest.builder()
+                .requestId(requestId)
+                .model(model)
+                .streaming(isStream(body))
+                // the runner feeds the prompt parameter as is; the message only satisfies the request
+                .parameter("raw", true)
+                .parameter("prompt", prompt)
+                .message(Message.user(prompt));
+        applyParameters(builder, body);
+        return builder.build();
+    }
+
+    private static String prompt(Object prompt, Function<int[], String> detokenize) {
+        if (prompt == null || prompt instanceof String) {
+            return prompt == null ? "" : (String) prompt;
+        }
+        if (prompt instanceof List<?> list && list.size() == 1 && list.get(0) instanceof String s) {
+            return s;
+        }
+        if (prompt instanceof List<?> list && list.stream().allMatch(t -> t instanceof Number)) {
+            return detokenize.apply(list.stream().mapToInt(t -> ((Number) t).intValue()).toArray());
+        }
+        throw new IllegalArgumentException("prompt must be a string or a list of token ids");
+    }
+
+    private static String text(Map<String, Object> body, String field) {
+        Object value = body.get(field);
+        if (value != null && !(value instanceof String)) {
+            throw new IllegalArgumentException(field + " must be a string");
+        }
+        return value == null ? "" : (String) value;
+    }
+
+    /**
+     * llama-server's sampling fields as runner parameters. {@code n_predict} of -1 and
+     * {@code seed} of -1 keep the runner's defaults; {@code cache_prompt}, {@code n_probs}
+     * and other unknown fields are ignored.
+     */
+    static void applyParameters(InferenceRequest.Builder builder, Map<String, Object> body) {
+        if (body.get("temperature") instanceof Number n) builder.temperature(n.doubleValue());
+        if (body.get("top_p") instanceof Number n) builder.topP(n.doubleValue());
+        if (body.get("top_k") instanceof Number n) builder.topK(n.intValue());
+        if (body.get("repeat_penalty") instanceof Number n) builder.repeatPenalty(n.doubleValue());
+        if (body.get("n_predict") instanceof Number n && n.intValue() >= 0) builder.maxTokens(n.intValue());
+        if (body.get("n_keep") instanceof Number n) builder.parameter("n_keep", n.intValue());
+        if (body.get("seed") instanceof Number n && n.longValue() >= 0) builder.parameter("seed", n.intValue());
+        if (body.get("stop") instanceof List<?> stop && !stop.isEmpty()) builder.parameter("stop", stop);
+        for (String name : DOUBLE_PARAMETERS) {
+            if (body.get(name) instanceof Number n) builder.parameter(name, n.doubleValue());
+        }
+        if (body.get("mirostat") instanceof Number n) builder.parameter("mirostat", n.intValue());
+        if (body.get("json_schema") instanceof Map<?, ?> schema) {
+            ResponseFormat format = new ResponseFormat("json_schema", MAPPER.valueToTree(schema), null);
+            format.check();
+            builder.jsonMode(true).grammar(format.grammar());
+        } else if (body.get("grammar") instanceof String grammar && !grammar.isBlank()) {
+            builder.grammar(grammar);
+        }
+    }
+
+    /** One streamed piece of text. */
+    public static Map<String, Object> chunk(String content) {
+        Map<String, Object> line = new LinkedHashMap<>();
+        line.put("content", content == null ? "" : content);
+        line.put("stop", false);
+        line.put("id_slot", -1);
+        return line;
+    }
+
+    /**
+     * The final object: the whole text when not streaming, empty otherwise.
+     * {@code promptNanos} is the time to the first token, 0 when unknown.
+     */
+    public static Map<String, Object> result(String model, String content, String stopType, long promptTokens,
+            long predictedTokens, long promptNanos, long totalNanos) {
+        Map<String, Object> line = chunk(content);
+        line.put("stop", true);
+        line.put("model", model);
+        line.put("tokens_predicted", predictedTokens);
+        line.put("tokens_evaluated", promptTokens);
+        line.put("stop_type", stopType);
+        line.put("stopping_word", "");
+        line.put("truncated", false);
+        line.put("timings", timings(promptTokens, predictedTokens, promptNanos, totalNanos - promptNanos));
+        return line;
+    }
+
+    static Map<String, Object> timings(long promptTokens, long predictedTokens, long promptNanos,
+            long predictedNanos) {
+        double promptMs = promptNanos / 1e6;
+        double predictedMs = Math.max(0, predictedNanos) / 1e6;
+        Map<String, Object> timings = new LinkedHashMap<>();
+        timings.put("prompt_n", promptTokens);
+        timings.put("prompt_ms", promptMs);
+        timings.put("prompt_per_second", promptMs > 0 ? promptTokens * 1000 / promptMs : 0.0);
+        timings.put("predicted_n", predictedTokens);
+        timings.put("predicted_ms", predictedMs);
+        timings.put("predicted_per_second", predictedMs > 0 ? predictedTokens * 1000 / predictedMs : 0.0);
+        return timings;
+    }
+
+    /** llama-server's {@code stop_type}: {@code limit} for the token limit, else {@code eos}. */
+    public static String stopType(InferenceResponse.FinishReason reason) {
+        return stopType(reason == null ? null : reason.name());
+    }
+
+    public static String stopType(String finishReason) {
+        return finishReason != null && finishReason.toLowerCase(Locale.ROOT).equals("length") ? "limit" : "eos";
+    }
+
+    /**
+     * {@code /slots} entries from the runners' slot details, numbered across runners.
+     * Returns the entries of {@code model} only when it is set.
+     */
+    public static List<Map<String, Object>> slots(List<Map<String, Object>> runners, String model) {
+        List<Map<String, Object>> out = new ArrayList<>();
+        for (Map<String, Object> runner : runners) {
+            if (model != null && !model.equals(runner.get("model"))) {
+                continue;
+            }
+            if (!(runner.get("slots") instanceof List<?> slots)) {
+                continue;
+            }
+            for (Object s : slots) {
+                if (!(s instanceof Map<?, ?> slot)) {
+                    continue;
+                }
+                Map<String, Object> entry = new LinkedHashMap<>();
+                entry.put("id", out.size());
+                entry.put("id_task", slot.get("request_id") == null ? -1 : slot.get("request_id"));
+                entry.put("model", runner.get("model"));
+                entry.put("n_ctx", slot.get("capacity"));
+                entry.put("n_past", slot.get("tokens"));
+                entry.put("is_processing", Boolean.TRUE.equals(slot.get("active")));
+                out.add(entry);
+            }
+        }
+        return out;
+    }
+
+    /** The {@code /props} object for {@code model}. */
+    public static Map<String, Object> props(String model, List<Map<String, Object>> slots, String chatTemplate,
+            Map<String, Object> samplingDefaults) {
+        Map<String, Object> settings = new LinkedHashMap<>();
+        settings.put("model", model);
+        settings.put("n_ctx", slots.isEmpty() ? 0 : slots.get(0).get("n_ctx"));
+        settings.put("params", samplingDefaults);
+        Map<String, Object> props = new LinkedHashMap<>();
+        props.put("default_generation_settings", settings);
+        props.put("total_slots", slots.size());
+        props.put("model_path", model);
+        props.put("chat_template", chatTemplate == null ? "" : chatTemplate);
+        props.put("build_info", "gollek");
+        return props;
+    }
+}
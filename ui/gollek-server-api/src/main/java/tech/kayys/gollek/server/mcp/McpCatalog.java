@@ -0,0 +1,171 @@
+package tech.kayys.gollek.server.mcp;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.Any;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.sdk.model.ModelListRequest;
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.models.ModelCapabilities;
+import tech.kayys.gollek.server.models.ModelCapabilityService;
+import tech.kayys.gollek.spi.model.ModelInfo;
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.ProviderHealth;
+
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.HashMap;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+
+/**
+ * Model list and limits reported to MCP clients. Limits come from what is actually served:
+ * a loaded runner's slot capacity (its share of {@code n_ctx}), otherwise the configured
+ * {@code gguf.provider.max-context-tokens} capped by the model's trained context.
+ */
+@ApplicationScoped
+public class McpCatalog {
+
+    private static final Logger LOG = Logger.getLogger(McpCatalog.class);
+    private static final Duration HEALTH_TIMEOUT = Duration.ofSeconds(2);
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    ModelCapabilityService capabilityService;
+
+    @Inject
+    @Any
+    Instance<LLMProvider> providers;
+
+    @ConfigProperty(name = "gguf.provider.max-context-tokens", defaultValue = "4096")
+    int maxContextTokens;
+
+    /** Models the server is configured to serve; listed first and marked {@code configured}. */
+    @ConfigProperty(name = "gguf.provider.prewarm.models")
+    Optional<List<String>> configuredModels;
+
+    @ConfigProperty(name = "gollek.server.mcp.models-limit", defaultValue = "200")
+    int modelsLimit;
+
+    public List<Map<String, Object>> models() throws Exception {
+        List<String> configured = configuredModels.orElse(List.of());
+        Map<String, Long> loaded = loadedContexts();
+        List<ModelInfo> infos = listModels();
+
+        List<Map<String, Object>> out = new ArrayList<>();
+        List<Map<String, Object>> rest = new ArrayList<>();
+        for (ModelInfo info : infos) {
+            ModelCapabilities caps = capabilityService.capabilities(info);
+            Long trained = caps.contextLength();
+            Long loadedCapacity = loaded.get(info.getModelId());
+            long context = servingContext(trained, maxContextTokens, loadedCapacity);
+
+            Map<String, Object> entry = new LinkedHashMap<>();
+            entry.put("id", info.getModelId());
+            entry.put("format", info.getFormat());
+            entry.put("loaded", loadedCapacity != null);
+            entry.put("configured", configured.contains(info.getModelId()));
+            entry.put("context_length", trained);
+            entry.put("context_size", context);
+            // the runner clamps max_tokens to what is left of the context after the prompt
+            entry.put("max_tokens", context);
+            entry.put("capabilities", caps);
+            (configured.contains(info.getModelId()) ? out : rest).add(entry);
+        }
+        out.addAll(rest);
+        return out;
+    }
+
+    /** A runnable model by id, as listed by {@link #models()}. */
+    public Optional<ModelInfo> find(String modelId) throws Exception {
+        return listModels().stream().filter(info -> info.getModelId().equals(modelId)).findFirst();
+    }
+
+    /** Ids of the models some provider currently has loaded. */
+    public Set<String> loadedModels() {
+        return loadedContexts().keySet();
+    }
+
+    public Map<String, Object> capabilities() throws Exception {
+        List<Map<String, Object>> models = models();
+        long maxContext = models.stream()
+                .mapToLong(m -> ((Number) m.get("context_size")).longValue())
+                .max()
+                .orElse(maxContextTokens);
+        boolean toolCalling = models.stream()
+                .anyMatch(m -> ((ModelCapabilities) m.get("capabilities")).toolCalling());
+
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("streaming", true);
+        out.put("tool_calling", toolCalling);
+        out.put("models", models.size());
+        out.put("loaded_models", models.stream().filter(m -> Boolean.TRUE.equals(m.get("loaded"))).count());
+        out.put("default_model", models.isEmpty() ? null : models.get(0).get("id"));
+        out.put("max_context_tokens", maxContext);
+        out.put("max_tokens", maxContext);
+        return out;
+    }
+
+    List<ModelInfo> listModels() throws Exception {
+        return sdkProvider.getSdk().listModels(ModelListRequest.builder()
+                .runnableOnly(true)
+                .limit(modelsLimit)
+                .dedupe(true)
+                .sort(true)
+                .build());
+    }
+
+    /**
+     * Context a request can use: the loaded slot's capacity if known, else the configured
+     * context capped by the model's trained length.
+     */
+    static long servingContext(Long trained, int configured, Long loadedCapacity) {
+        if (loadedCapacity != null && loadedCapacity > 0) {
+            return loadedCapacity;
+        }
+        if (trained != null && trained > 0) {
+            return Math.min(trained, configured);
+        }
+        return configured;
+    }
+
+    /**
+     * Smallest slot capacity per loaded model, from provider health details.
+     */
+    private Map<String, Long> loadedContexts() {
+        Map<String, Long> out = new HashMap<>();
+        for (LLMProvider provider : providers) {
+            ProviderHealth health;
+            try {
+                health = provider.health().await().atMost(HEALTH_TIMEOUT);
+            } catch (Exception e) {
+                LOG.debugf("Health of provider %s unavailable: %s", provider.id(), e.getMessage());
+                continue;
+            }
+            if (health == null || !(health.details().get("slots") instanceof List<?> runners)) {
+                continue;
+            }
+            for (Object runner : runners) {
+                if (!(runner instanceof Map<?, ?> r) || !(r.get("slots") instanceof List<?> slots)) {
+                    continue;
+                }
+                String model = String.valueOf(r.get("model"));
+                for (Object slot : slots) {
+                    if (slot instanceof Map<?, ?> s && s.get("capacity") instanceof Number capacity) {
+                        out.merge(model, capacity.longValue(), Math::min);
+                    }
+                }
+            }
+        }
+        return out;
+    }
+}
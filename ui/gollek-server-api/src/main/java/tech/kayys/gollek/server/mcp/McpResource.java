@@ -0,0 +1,116 @@
+package tech.kayys.gollek.server.mcp;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import io.smallrye.mutiny.Multi;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Consumes;
+import jakarta.ws.rs.DELETE;
+import jakarta.ws.rs.GET;
+import jakarta.ws.rs.HeaderParam;
+import jakarta.ws.rs.NotFoundException;
+import jakarta.ws.rs.POST;
+import jakarta.ws.rs.Path;
+import jakarta.ws.rs.Produces;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.core.StreamingOutput;
+
+import org.jboss.resteasy.reactive.RestStreamElementType;
+
+import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.util.Map;
+
+/**
+ * MCP over streamable HTTP. Each POST carries one JSON-RPC message: notifications are
+ * acknowledged with 202, requests answered with a JSON response, and streamed
+ * generations with an SSE stream of notifications ending in the response.
+ *
+ * <p>{@code initialize} opens a session and returns its id in {@value #SESSION_HEADER};
+ * a client that sends it back can {@code GET /mcp} for a stream of server notifications
+ * (resource updates) and {@code DELETE /mcp} to end the session.
+ */
+@Path("/mcp")
+public class McpResource {
+
+    static final String SESSION_HEADER = "Mcp-Session-Id";
+
+    @Inject
+    McpServer server;
+
+    @Inject
+    McpSessions sessions;
+
+    @Inject
+    ObjectMapper mapper;
+
+    @POST
+    @Consumes(MediaType.APPLICATION_JSON)
+    public Response post(@HeaderParam(SESSION_HEADER) String session, JsonNode message) {
+        if (message == null || !message.isObject()) {
+            return Response.status(Response.Status.BAD_REQUEST)
+                    .type(MediaType.APPLICATION_JSON)
+                    .entity(McpServer.error(null, McpServer.INVALID_REQUEST, "expected a JSON-RPC object"))
+                    .build();
+        }
+        boolean initialize = "initialize".equals(message.path("method").asText());
+        if (session != null && !initialize && !sessions.touch(session)) {
+            // expired or evicted: the client starts over with initialize
+            return Response.status(Response.Status.NOT_FOUND)
+                    .type(MediaType.APPLICATION_JSON)
+                    .entity(McpServer.error(null, McpServer.INVALID_REQUEST, "unknown MCP session"))
+                    .build();
+        }
+        if (McpServer.isNotification(message)) {
+            server.notify(message, session);
+            return Response.accepted().build();
+        }
+        if (server.isStreaming(message)) {
+            StreamingOutput body = out -> server.stream(message, session, event -> {
+                out.write(("event: message\ndata: " + mapper.writeValueAsString(event) + "\n\n")
+                        .getBytes(StandardCharsets.UTF_8));
+                out.flush();
+            });
+            return Response.ok(body, MediaType.SERVER_SENT_EVENTS).build();
+        }
+        if (initialize) {
+            String id = sessions.open().orElse(null);
+            if (id == null) {
+                return Response.status(Response.Status.SERVICE_UNAVAILABLE)
+                        .type(MediaType.APPLICATION_JSON)
+                        .entity(McpServer.error(null, McpServer.INTERNAL_ERROR, "too many MCP sessions"))
+                        .build();
+            }
+            return Response.ok(server.handle(message, id), MediaType.APPLICATION_JSON)
+                    .header(SESSION_HEADER, id)
+                    .build();
+        }
+        return Response.ok(server.handle(message, session), MediaType.APPLICATION_JSON).build();
+    }
+
+    @GET
+    @Produces(MediaType.SERVER_SENT_EVENTS)
+    @RestStreamElementType(MediaType.APPLICATION_JSON)
+    public Multi<Map<String, Object>> notifications(@HeaderParam(SESSION_HEADER) String session) {
+        if (!sessions.exists(session)) {
+            return Multi.createFrom().failure(new NotFoundException("unknown MCP session"));
+        }
+        return Multi.createFrom().emitter(emitter -> {
+            McpServer.Sink sink = message -> {
+                if (emitter.isCancelled()) {
+                    throw new IOException("stream closed");
+                }
+                emitter.emit(message);
+            };
+            sessions.attach(session, sink);
+            emitter.onTermination(() -> sessions.detach(session, sink));
+        });
+    }
+
+    @DELETE
+    public Response close(@HeaderParam(SESSION_HEADER) String session) {
+        return sessions.close(session) ? Response.noContent().build() : Response.status(Response.Status.NOT_FOUND).build();
+    }
+}
@@ -0,0 +1,178 @@
+package tech.kayys.gollek.server.mcp;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import io.quarkus.scheduler.Scheduled;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.models.ModelCapabilityService;
+import tech.kayys.gollek.server.models.ModelReloaded;
+import tech.kayys.gollek.spi.model.ModelInfo;
+
+import java.net.URLDecoder;
+import java.net.URLEncoder;
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+import java.util.TreeSet;
+
+/**
+ * MCP resources: each servable model ({@code gollek://models/{id}}, its catalog entry as
+ * JSON), the chat template embedded in it ({@code gollek://models/{id}/chat-template}) and
+ * the server's prompt templates ({@code gollek://prompts/{name}}, the stable version, or
+ * {@code gollek://prompts/{name}@{version}}). Model ids are URL-encoded in URIs.
+ *
+ * <p>Subscribers of a model hear {@code notifications/resources/updated} when it is
+ * loaded, unloaded or hot-reloaded; {@code notifications/resources/list_changed} and
+ * {@code notifications/prompts/list_changed} go out when prompt templates or versions are
+ * added or removed, or a rollout changes the stable version. Changes are polled only while some client
+ * has a notification stream open.
+ */
+@ApplicationScoped
+public class McpResourceCatalog {
+
+    private static final Logger LOG = Logger.getLogger(McpResourceCatalog.class);
+
+    static final String MODELS = "gollek://models/";
+    static final String PROMPTS = "gollek://prompts/";
+    static final String CHAT_TEMPLATE = "/chat-template";
+
+    @Inject
+    McpCatalog catalog;
+
+    @Inject
+    ModelCapabilityService capabilityService;
+
+    @Inject
+    PromptTemplates prompts;
+
+    @Inject
+    McpSessions sessions;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+    private Set<String> lastLoaded;
+    private List<String> lastPrompts;
+
+    /** {@code resources/list} entries: models, then chat templates, then prompt templates. */
+    public List<Map<String, Object>> list() throws Exception {
+        List<Map<String, Object>> out = new ArrayList<>();
+        List<Map<String, Object>> templates = new ArrayList<>();
+        for (ModelInfo info : catalog.listModels()) {
+            String id = info.getModelId();
+            out.add(resource(modelUri(id), id, "Model " + id + ": format, context size and capabilities",
+                    "application/json"));
+            if (capabilityService.chatTemplate(info) != null) {
+                templates.add(resource(modelUri(id) + CHAT_TEMPLATE, id + " chat template",
+                        "Jinja chat template embedded in " + id, "text/x-jinja2"));
+            }
+        }
+        out.addAll(templates);
+        for (PromptTemplates.Template template : prompts.list()) {
+            out.add(resource(PROMPTS + template.name(), template.name(), "Server prompt template", "text/plain"));
+        }
+        return out;
+    }
+
+    /**
+     * {@code resources/read} contents for a URI.
+     *
+     * @throws IllegalArgumentException for an unknown resource
+     */
+    public List<Map<String, Object>> read(String uri) throws Exception {
+        if (uri != null && uri.startsWith(PROMPTS)) {
+            PromptTemplates.Template template = prompts.get(uri.substring(PROMPTS.length()))
+                    .orElseThrow(() -> notFound(uri));
+            return List.of(contents(uri, "text/plain", template.read()));
+        }
+        if (uri == null || !uri.startsWith(MODELS)) {
+            throw notFound(uri);
+        }
+        String path = uri.substring(MODELS.length());
+        boolean chatTemplate = path.endsWith(CHAT_TEMPLATE);
+        String id = URLDecoder.decode(chatTemplate ? path.substring(0, path.length() - CHAT_TEMPLATE.length()) : path,
+                StandardCharsets.UTF_8);
+        if (chatTemplate) {
+            ModelInfo info = catalog.find(id).orElseThrow(() -> notFound(uri));
+            String template = Optional.ofNullable(capabilityService.chatTemplate(info)).orElseThrow(() -> notFound(uri));
+            return List.of(contents(uri, "text/x-jinja2", template));
+        }
+        Map<String, Object> model = catalog.models().stream()
+                .filter(m -> id.equals(m.get("id")))
+                .findFirst()
+                .orElseThrow(() -> notFound(uri));
+        return List.of(contents(uri, "application/json", mapper.writeValueAsString(model)));
+    }
+
+    void onReload(@Observes ModelReloaded event) {
+        sessions.resourceUpdated(modelUri(event.model()));
+        sessions.resourceUpdated(modelUri(event.model()) + CHAT_TEMPLATE);
+    }
+
+    @Scheduled(every = "${gollek.server.mcp.resources.poll-every:5s}",
+            concurrentExecution = Scheduled.ConcurrentExecution.SKIP)
+    synchronized void poll() {
+        if (!sessions.hasListeners()) {
+            lastLoaded = null;
+            lastPrompts = null;
+            return;
+        }
+        Set<String> loaded = catalog.loadedModels();
+        if (lastLoaded != null) {
+            for (String id : changed(lastLoaded, loaded)) {
+                sessions.resourceUpdated(modelUri(id));
+            }
+        }
+        lastLoaded = loaded;
+
+        // every version, and which one is stable, so rollout changes are announced too
+        List<String> names = new ArrayList<>(prompts.all().stream().map(PromptTemplates.Template::ref).toList());
+        prompts.list().forEach(t -> names.add("stable:" + t.ref()));
+        if (lastPrompts != null && !lastPrompts.equals(names)) {
+            LOG.debug("Prompt templates changed");
+            sessions.broadcast("notifications/resources/list_changed");
+            sessions.broadcast("notifications/prompts/list_changed");
+        }
+        lastPrompts = names;
+    }
+
+    /** Ids loaded in one set and not the other. */
+    static Set<String> changed(Set<String> before, Set<String> after) {
+        Set<String> out = new TreeSet<>(before);
+        out.addAll(after);
+        out.removeIf(id -> before.contains(id) && after.contains(id));
+        return out;
+    }
+
+    static String modelUri(String modelId) {
+        return MODELS + URLEncoder.encode(modelId, StandardCharsets.UTF_8);
+    }
+
+    private static Map<String, Object> resource(String uri, String name, String description, String mimeType) {
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("uri", uri);
+        out.put("name", name);
+        out.put("description", description);
+        out.put("mimeType", mimeType);
+        return out;
+    }
+
+    private static Map<String, Object> contents(String uri, String mimeType, String text) {
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("uri", uri);
+        out.put("mimeType", mimeType);
+        out.put("text", text);
+        return out;
+    }
+
+    private static IllegalArgumentException notFound(String uri) {
+        return new IllegalArgumentException("resource not found: " + uri);
+    }
+}
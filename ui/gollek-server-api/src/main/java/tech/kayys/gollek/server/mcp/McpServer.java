@@ -0,0 +1,537 @@
+package tech.kayys.gollek.server.mcp;
+
+import com.fasterxml.jackson.databind.JsonNode;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.metrics.MetricRegistry;
+import org.eclipse.microprofile.metrics.Tag;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.sdk.prompt.PromptTemplate;
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+
+import java.io.IOException;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.UUID;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * JSON-RPC 2.0 dispatcher for the server side of MCP. Transport-agnostic: a request maps
+ * to exactly one response, and streamed generations are delivered as
+ * {@value #STREAM_NOTIFICATION} notifications (which carry no id) followed by that single
+ * final response.
+ */
+@ApplicationScoped
+public class McpServer {
+
+    private static final Logger LOG = Logger.getLogger(McpServer.class);
+
+    public static final String PROTOCOL_VERSION = "2025-11-25";
+
+    /** Notification carrying one streamed delta: {@code streamToken}, {@code cursor}, {@code delta}. */
+    public static final String STREAM_NOTIFICATION = "notifications/gollek/stream";
+
+    static final String GENERATE = "gollek/generate";
+    static final String MODELS = "gollek/models";
+    static final String CAPABILITIES = "gollek/capabilities";
+    static final String TOOLS_LIST = "tools/list";
+    static final String TOOLS_CALL = "tools/call";
+    static final String RESOURCES_LIST = "resources/list";
+    static final String RESOURCES_READ = "resources/read";
+    static final String RESOURCES_SUBSCRIBE = "resources/subscribe";
+    static final String RESOURCES_UNSUBSCRIBE = "resources/unsubscribe";
+    static final String PROMPTS_LIST = "prompts/list";
+    static final String PROMPTS_GET = "prompts/get";
+
+    /** Sent by the client to abandon one of its requests: {@code requestId}, {@code reason}. */
+    static final String CANCELLED_NOTIFICATION = "notifications/cancelled";
+
+    /** JSON-RPC/LSP spelling of the same, naming the request as {@code id}; some clients send it. */
+    static final String CANCEL_REQUEST = "$/cancelRequest";
+
+    /** Standard MCP progress notification, used for streamed tool output. */
+    static final String PROGRESS_NOTIFICATION = "notifications/progress";
+
+    static final int INVALID_REQUEST = -32600;
+    static final int METHOD_NOT_FOUND = -32601;
+    static final int INVALID_PARAMS = -32602;
+    static final int INTERNAL_ERROR = -32603;
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    McpCatalog catalog;
+
+    @Inject
+    McpTools tools;
+
+    @Inject
+    McpResourceCatalog resources;
+
+    @Inject
+    McpSessions sessions;
+
+    @Inject
+    PromptTemplates prompts;
+
+    @Inject
+    MetricRegistry registry;
+
+    /** Inference request id of each in-flight generation, by session and JSON-RPC id. */
+    private final Map<String, String> inFlight = new ConcurrentHashMap<>();
+
+    /**
+     * Where a transport writes outgoing messages.
+     */
+    @FunctionalInterface
+    public interface Sink {
+        void send(Map<String, Object> message) throws IOException;
+    }
+
+    /**
+     * Requests carry an id; notifications do not and get no response.
+     */
+    public static boolean isNotification(JsonNode message) {
+        return message != null && !message.has("id");
+    }
+
+    /**
+     * {@code gollek/generate} with {@code stream: true}, or a {@code tools/call} that carries
+     * a {@code _meta.progressToken}.
+     */
+    public boolean isStreaming(JsonNode message) {
+        String method = message.path("method").asText();
+        JsonNode params = message.path("params");
+        if (TOOLS_CALL.equals(method)) {
+            return params.path("_meta").hasNonNull("progressToken");
+        }
+        return GENERATE.equals(method) && params.path("stream").asBoolean(false);
+    }
+
+    public Map<String, Object> handle(JsonNode message) {
+        return handle(message, null);
+    }
+
+    /**
+     * Handles a request within an MCP session ({@code null} when the client sent no
+     * {@code Mcp-Session-Id}); resource subscriptions and cancellation need one.
+     */
+    public Map<String, Object> handle(JsonNode message, String session) {
+        Object id = id(message);
+        String method = message == null ? null : message.path("method").asText(null);
+        if (method == null) {
+            return error(id, INVALID_REQUEST, "method required");
+        }
+        JsonNode params = message.path("params");
+        try {
+            return switch (method) {
+                case "initialize" -> result(id, initializeResult());
+                case "ping" -> result(id, Map.of());
+                case GENERATE -> result(id, generate(params, begin(session, id)));
+                case MODELS -> result(id, Map.of("models", catalog.models()));
+                case CAPABILITIES -> result(id, catalog.capabilities());
+                case TOOLS_LIST -> result(id, Map.of("tools", tools.list()));
+                case TOOLS_CALL -> result(id, tools.call(tool(params), params.path("arguments"), begin(session, id),
+                        McpTool.Progress.NONE));
+                case RESOURCES_LIST -> result(id, Map.of("resources", resources.list()));
+                case RESOURCES_READ -> result(id, Map.of("contents", resources.read(params.path("uri").asText(null))));
+                case RESOURCES_SUBSCRIBE -> sessions.subscribe(session, uri(params))
+                        ? result(id, Map.of())
+                        : error(id, INVALID_REQUEST, "subscriptions need the Mcp-Session-Id returned by initialize");
+                case PROMPTS_LIST -> result(id, Map.of("prompts", listPrompts()));
+                case PROMPTS_GET -> result(id, getPrompt(params, session));
+                case RESOURCES_UNSUBSCRIBE -> {
+                    sessions.unsubscribe(session, uri(params));
+                    yield result(id, Map.of());
+                }
+                default -> error(id, METHOD_NOT_FOUND, "method not found: " + method);
+            };
+        } catch (IllegalArgumentException e) {
+            return error(id, INVALID_PARAMS, e.getMessage());
+        } catch (Exception e) {
+            LOG.warnf(e, "MCP %s failed", method);
+            return error(id, INTERNAL_ERROR, String.valueOf(e.getMessage()));
+        } finally {
+            end(session, id);
+        }
+    }
+
+    public void notify(JsonNode message) {
+        notify(message, null);
+    }
+
+    /**
+     * Handles a notification from the client. Nothing is sent back;
+     * {@value #CANCELLED_NOTIFICATION} (or {@value #CANCEL_REQUEST}) stops the session's
+     * named request if it is still generating, and is ignored otherwise, as MCP allows.
+     */
+    public void notify(JsonNode message, String session) {
+        String method = message.path("method").asText();
+        LOG.debugf("MCP notification %s", method);
+        if (CANCELLED_NOTIFICATION.equals(method) || CANCEL_REQUEST.equals(method)) {
+            JsonNode params = message.path("params");
+            String id = (params.has("requestId") ? params.path("requestId") : params.path("id")).asText(null);
+            String requestId = id == null ? null : inFlight.get(key(session, id));
+            if (requestId != null) {
+                LOG.debugf("MCP request %s cancelled: %s", id, params.path("reason").asText("no reason given"));
+                RequestCancellation.cancel(requestId);
+            }
+        }
+    }
+
+    /**
+     * Inference request id for a session's request, remembered until {@link #end} so the
+     * client can cancel it. Requests outside a session cannot be told apart and are not
+     * tracked.
+     */
+    private String begin(String session, Object id) {
+        String requestId = UUID.randomUUID().toString();
+        if (session != null && id != null) {
+            inFlight.put(key(session, String.valueOf(id)), requestId);
+        }
+        return requestId;
+    }
+
+    private void end(String session, Object id) {
+        if (session != null && id != null) {
+            inFlight.remove(key(session, String.valueOf(id)));
+        }
+    }
+
+    private static String key(String session, String id) {
+        return session + "/" + id;
+    }
+
+    /**
+     * Streams a {@code gollek/generate} request: one notification per delta, each tagged
+     * with the stream token (the client's {@code _meta.progressToken} when given) and a
+     * cursor counting from 0, then the final response. A failed write cancels generation.
+     */
+    public void stream(JsonNode message, Sink sink) throws IOException {
+        stream(message, null, sink);
+    }
+
+    /**
+     * {@link #stream(JsonNode, Sink)} within an MCP session, whose client can cancel it
+     * with {@value #CANCELLED_NOTIFICATION}.
+     */
+    public void stream(JsonNode message, String session, Sink sink) throws IOException {
+        Object id = id(message);
+        try {
+            if (TOOLS_CALL.equals(message.path("method").asText())) {
+                streamTool(message, begin(session, id), sink);
+            } else {
+                streamGenerate(message, begin(session, id), sink);
+            }
+        } finally {
+            end(session, id);
+        }
+    }
+
+    private void streamGenerate(JsonNode message, String requestId, Sink sink) throws IOException {
+        Object id = id(message);
+        JsonNode params = message.path("params");
+        InferenceRequest request;
+        try {
+            request = toRequest(params, requestId);
+        } catch (IllegalArgumentException e) {
+            sink.send(error(id, INVALID_PARAMS, e.getMessage()));
+            return;
+        }
+        JsonNode progressToken = params.path("_meta").path("progressToken");
+        String token = progressToken.isMissingNode() || progressToken.isNull()
+                ? "stream-" + request.getRequestId()
+                : progressToken.asText();
+
+        StringBuilder text = new StringBuilder();
+        int cursor = 0;
+        String finishReason = null;
+        StreamingInferenceChunk.ChunkUsage usage = null;
+        try (var chunks = sdkProvider.getSdk().streamCompletion(request).subscribe().asStream()) {
+            for (var it = chunks.iterator(); it.hasNext();) {
+                StreamingInferenceChunk chunk = it.next();
+                if (chunk.delta() != null && !chunk.delta().isEmpty()) {
+                    Map<String, Object> delta = new LinkedHashMap<>();
+                    delta.put("streamToken", token);
+                    delta.put("cursor", cursor++);
+                    delta.put("delta", chunk.delta());
+                    sink.send(notification(STREAM_NOTIFICATION, delta));
+                    text.append(chunk.delta());
+                }
+                if (chunk.finishReason() != null) {
+                    finishReason = chunk.finishReason();
+                }
+                if (chunk.usage() != null) {
+                    usage = chunk.usage();
+                }
+            }
+        } catch (IOException e) {
+            RequestCancellation.cancel(request.getRequestId());
+            throw e;
+        } catch (RuntimeException e) {
+            sink.send(error(id, INTERNAL_ERROR, String.valueOf(e.getMessage())));
+            return;
+        }
+
+        Map<String, Object> result = generateResult(text.toString(), request.getModel(),
+                finishReason == null ? "stop" : finishReason);
+        result.put("streamToken", token);
+        result.put("cursor", cursor);
+        if (usage != null) {
+            result.put("usage", Map.of("input_tokens", usage.inputTokens(), "output_tokens", usage.outputTokens()));
+        }
+        sink.send(result(id, result));
+    }
+
+    /**
+     * Runs a {@code tools/call}, sending each piece of partial output as a
+     * {@value #PROGRESS_NOTIFICATION} for the client's progress token ({@code progress}
+     * counts pieces from 1, {@code message} is the piece), then the tool result.
+     */
+    private void streamTool(JsonNode message, String requestId, Sink sink) throws IOException {
+        Object id = id(message);
+        JsonNode params = message.path("params");
+        McpTool tool;
+        try {
+            tool = tool(params);
+        } catch (IllegalArgumentException e) {
+            sink.send(error(id, INVALID_PARAMS, e.getMessage()));
+            return;
+        }
+        JsonNode token = params.path("_meta").get("progressToken");
+        Object progressToken = token.isNumber() ? token.numberValue() : token.asText();
+        int[] progress = {0};
+        Map<String, Object> result = tools.call(tool, params.path("arguments"), requestId, chunk -> {
+            Map<String, Object> update = new LinkedHashMap<>();
+            update.put("progressToken", progressToken);
+            update.put("progress", ++progress[0]);
+            update.put("message", chunk);
+            sink.send(notification(PROGRESS_NOTIFICATION, update));
+        });
+        sink.send(result(id, result));
+    }
+
+    /** Templates that do not parse are left out and logged. */
+    private List<Map<String, Object>> listPrompts() {
+        List<Map<String, Object>> out = new ArrayList<>();
+        for (PromptTemplates.Template template : prompts.list()) {
+            PromptTemplate parsed;
+            try {
+                parsed = template.parse();
+            } catch (IOException | IllegalArgumentException e) {
+                LOG.warnf("Skipping prompt template %s: %s", template.file(), e.getMessage());
+                continue;
+            }
+            List<Map<String, Object>> arguments = new ArrayList<>();
+            for (PromptTemplate.Argument argument : parsed.arguments()) {
+                arguments.add(Map.of("name", argument.name(), "required", argument.required()));
+            }
+            Map<String, Object> entry = new LinkedHashMap<>();
+            entry.put("name", template.name());
+            if (parsed.description() != null) {
+                entry.put("description", parsed.description());
+            }
+            entry.put("arguments", arguments);
+            entry.put("_meta", Map.of(
+                    "gollek/version", template.version(),
+                    "gollek/versions", prompts.versions(template.name()).stream()
+                            .map(PromptTemplates.Template::version).toList()));
+            out.add(entry);
+        }
+        return out;
+    }
+
+    /**
+     * Renders a template with the string {@code arguments} into a single user message.
+     * {@code version} pins a template version; otherwise the session gets the stable one
+     * or the rollout's canary. {@code _meta} names the version served, so clients can
+     * attribute outcomes to it.
+     */
+    private Map<String, Object> getPrompt(JsonNode params, String session) throws IOException {
+        String name = params.path("name").asText(null);
+        if (name == null || name.isBlank()) {
+            throw new IllegalArgumentException("name required");
+        }
+        String version = params.path("version").asText(null);
+        PromptTemplates.Template selected = prompts.select(name, version, session)
+                .orElseThrow(() -> new IllegalArgumentException(version == null
+                        ? "unknown prompt: " + name
+                        : "unknown version " + version + " of prompt " + name));
+        Map<String, String> values = new LinkedHashMap<>();
+        for (Map.Entry<String, JsonNode> argument : params.path("arguments").properties()) {
+            values.put(argument.getKey(), argument.getValue().asText());
+        }
+
+        String text;
+        PromptTemplate template;
+        try {
+            template = selected.parse();
+            text = template.render(values);
+        } catch (IllegalArgumentException e) {
+            countRender(selected, "error");
+            throw e;
+        }
+        countRender(selected, "ok");
+
+        Map<String, Object> result = new LinkedHashMap<>();
+        if (template.description() != null) {
+            result.put("description", template.description());
+        }
+        result.put("messages", List.of(Map.of(
+                "role", "user",
+                "content", Map.of("type", "text", "text", text))));
+        result.put("_meta", Map.of("gollek/version", selected.version()));
+        return result;
+    }
+
+    private void countRender(PromptTemplates.Template template, String outcome) {
+        if (registry != null) {
+            registry.counter("gollek.prompts.rendered", new Tag("prompt", template.name()),
+                    new Tag("version", template.version()), new Tag("outcome", outcome)).inc();
+        }
+    }
+
+    private static String uri(JsonNode params) {
+        String uri = params.path("uri").asText(null);
+        if (uri == null || uri.isBlank()) {
+            throw new IllegalArgumentException("uri required");
+        }
+        return uri;
+    }
+
+    private McpTool tool(JsonNode params) {
+        String name = params.path("name").asText(null);
+        if (name == null || name.isBlank()) {
+            throw new IllegalArgumentException("name required");
+        }
+        return tools.get(name).orElseThrow(() -> new IllegalArgumentException("unknown tool: " + name));
+    }
+
+    private Map<String, Object> initializeResult() {
+        Map<String, Object> streaming = new LinkedHashMap<>();
+        streaming.put("methods", List.of(GENERATE));
+        streaming.put("notification", STREAM_NOTIFICATION);
+        streaming.put("cursor", true);
+
+        Map<String, Object> result = new LinkedHashMap<>();
+        result.put("protocolVersion", PROTOCOL_VERSION);
+        Map<String, Object> capabilities = new LinkedHashMap<>();
+        capabilities.put("tools", Map.of("listChanged", false));
+        capabilities.put("resources", Map.of("subscribe", true, "listChanged", true));
+        capabilities.put("prompts", Map.of("listChanged", true));
+        capabilities.put("experimental", Map.of("gollek/streaming", streaming));
+        result.put("capabilities", capabilities);
+        result.put("serverInfo", Map.of("name", "gollek", "version", serverVersion()));
+        return result;
+    }
+
+    private Map<String, Object> generate(JsonNode params, String requestId) throws Exception {
+        InferenceRequest request = toRequest(params, requestId);
+        InferenceResponse response = sdkProvider.getSdk().createCompletion(request);
+        String finish = response.getFinishReason() == null
+                ? "stop"
+                : response.getFinishReason().name().toLowerCase(Locale.ROOT);
+        Map<String, Object> result = generateResult(response.getContent(), response.getModel(), finish);
+        result.put("usage", Map.of("input_tokens", response.getInputTokens(),
+                "output_tokens", response.getOutputTokens()));
+        return result;
+    }
+
+    private static Map<String, Object> generateResult(String text, String model, String finishReason) {
+        Map<String, Object> result = new LinkedHashMap<>();
+        result.put("content", List.of(Map.of("type", "text", "text", text)));
+        result.put("model", model);
+        result.put("finish_reason", finishReason);
+        return result;
+    }
+
+    /**
+     * {@code model} plus either {@code prompt} or {@code messages} ({@code role}/{@code content}
+     * pairs); {@code max_tokens} and {@code temperature} are optional.
+     */
+    static InferenceRequest toRequest(JsonNode params) {
+        return toRequest(params, UUID.randomUUID().toString());
+    }
+
+    static InferenceRequest toRequest(JsonNode params, String requestId) {
+        String model = params.path("model").asText(null);
+        if (model == null || model.isBlank()) {
+            throw new IllegalArgumentException("model required");
+        }
+        List<Message> messages = new ArrayList<>();
+        for (JsonNode m : params.path("messages")) {
+            String role = m.path("role").asText("user").toUpperCase(Locale.ROOT);
+            try {
+                messages.add(new Message(Message.Role.valueOf(role), m.path("content").asText("")));
+            } catch (IllegalArgumentException e) {
+                throw new IllegalArgumentException("unknown role: " + m.path("role").asText());
+            }
+        }
+        if (params.hasNonNull("prompt")) {
+            messages.add(Message.user(params.get("prompt").asText()));
+        }
+        if (messages.isEmpty()) {
+            throw new IllegalArgumentException("prompt or messages required");
+        }
+        InferenceRequest.Builder builder = InferenceRequest.builder()
+                .requestId(requestId)
+                .model(model)
+                .messages(messages);
+        if (params.hasNonNull("max_tokens")) {
+            builder.maxTokens(params.get("max_tokens").asInt());
+        }
+        if (params.hasNonNull("temperature")) {
+            builder.temperature(params.get("temperature").asDouble());
+        }
+        return builder.build();
+    }
+
+    private static String serverVersion() {
+        String version = McpServer.class.getPackage().getImplementationVersion();
+        return version != null ? version : "dev";
+    }
+
+    private static Object id(JsonNode message) {
+        if (message == null || !message.has("id") || message.get("id").isNull()) {
+            return null;
+        }
+        JsonNode id = message.get("id");
+        return id.isNumber() ? id.numberValue() : id.asText();
+    }
+
+    static Map<String, Object> result(Object id, Object result) {
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("jsonrpc", "2.0");
+        out.put("id", id);
+        out.put("result", result);
+        return out;
+    }
+
+    static Map<String, Object> error(Object id, int code, String message) {
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("jsonrpc", "2.0");
+        out.put("id", id);
+        out.put("error", Map.of("code", code, "message", String.valueOf(message)));
+        return out;
+    }
+
+    static Map<String, Object> notification(String method, Map<String, Object> params) {
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("jsonrpc", "2.0");
+        out.put("method", method);
+        out.put("params", params);
+        return out;
+    }
+}
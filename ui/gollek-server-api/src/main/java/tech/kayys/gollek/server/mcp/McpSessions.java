@@ -0,0 +1,178 @@
+package tech.kayys.gollek.server.mcp;
+
+import io.quarkus.scheduler.Scheduled;
+import jakarta.enterprise.context.ApplicationScoped;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.time.Duration;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+import java.util.UUID;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * MCP sessions, created on {@code initialize} and named by the {@code Mcp-Session-Id}
+ * header. A session holds its resource subscriptions and, while the client keeps a
+ * {@code GET /mcp} stream open, the sink that server-initiated notifications go to.
+ *
+ * <p>At most {@code gollek.server.mcp.max-sessions} exist at once. Sessions without an
+ * open stream that have not been used for {@code gollek.server.mcp.session-idle-timeout}
+ * are evicted once a minute, and right away when a new session would exceed the limit.
+ */
+@ApplicationScoped
+public class McpSessions {
+
+    private static final Logger LOG = Logger.getLogger(McpSessions.class);
+
+    static final class Session {
+        final String id;
+        final Set<String> subscriptions = ConcurrentHashMap.newKeySet();
+        volatile McpServer.Sink sink;
+        volatile long lastSeen;
+
+        Session(String id, long now) {
+            this.id = id;
+            this.lastSeen = now;
+        }
+    }
+
+    @ConfigProperty(name = "gollek.server.mcp.max-sessions", defaultValue = "256")
+    int maxSessions;
+
+    @ConfigProperty(name = "gollek.server.mcp.session-idle-timeout", defaultValue = "30m")
+    Duration idleTimeout;
+
+    private final Map<String, Session> sessions = new ConcurrentHashMap<>();
+
+    /**
+     * Opens a session, evicting idle ones if the limit is reached.
+     *
+     * @return the session id, or empty when every session is in use
+     */
+    public Optional<String> open() {
+        return open(System.currentTimeMillis());
+    }
+
+    synchronized Optional<String> open(long now) {
+        if (maxSessions > 0 && sessions.size() >= maxSessions) {
+            evictIdle(now);
+            if (sessions.size() >= maxSessions) {
+                return Optional.empty();
+            }
+        }
+        String id = UUID.randomUUID().toString().replace("-", "");
+        sessions.put(id, new Session(id, now));
+        return Optional.of(id);
+    }
+
+    /** Marks a session as used; false when it does not exist (any more). */
+    public boolean touch(String id) {
+        Optional<Session> session = get(id);
+        session.ifPresent(s -> s.lastSeen = System.currentTimeMillis());
+        return session.isPresent();
+    }
+
+    public int size() {
+        return sessions.size();
+    }
+
+    @Scheduled(every = "1m", concurrentExecution = Scheduled.ConcurrentExecution.SKIP)
+    void scheduledEviction() {
+        evictIdle(System.currentTimeMillis());
+    }
+
+    /** Drops sessions idle past the timeout; those with an open stream are never idle. */
+    synchronized int evictIdle(long now) {
+        if (idleTimeout == null || idleTimeout.isZero() || idleTimeout.isNegative()) {
+            return 0;
+        }
+        long cutoff = now - idleTimeout.toMillis();
+        int before = sessions.size();
+        sessions.values().removeIf(s -> s.sink == null && s.lastSeen < cutoff);
+        int evicted = before - sessions.size();
+        if (evicted > 0) {
+            LOG.debugf("Evicted %d idle MCP session(s)", evicted);
+        }
+        return evicted;
+    }
+
+    public boolean close(String id) {
+        return id != null && sessions.remove(id) != null;
+    }
+
+    public boolean exists(String id) {
+        return id != null && sessions.containsKey(id);
+    }
+
+    Optional<Session> get(String id) {
+        return id == null ? Optional.empty() : Optional.ofNullable(sessions.get(id));
+    }
+
+    /** Routes the session's notifications to {@code sink}, replacing an earlier stream. */
+    public boolean attach(String id, McpServer.Sink sink) {
+        return get(id).map(s -> {
+            s.sink = sink;
+            s.lastSeen = System.currentTimeMillis();
+            return true;
+        }).orElse(false);
+    }
+
+    public void detach(String id, McpServer.Sink sink) {
+        get(id).ifPresent(s -> {
+            if (s.sink == sink) {
+                s.sink = null;
+                s.lastSeen = System.currentTimeMillis();
+            }
+        });
+    }
+
+    /** Whether any session has a notification stream open. */
+    public boolean hasListeners() {
+        return sessions.values().stream().anyMatch(s -> s.sink != null);
+    }
+
+    public boolean subscribe(String id, String uri) {
+        Optional<Session> session = get(id);
+        session.ifPresent(s -> s.subscriptions.add(uri));
+        return session.isPresent();
+    }
+
+    public void unsubscribe(String id, String uri) {
+        get(id).ifPresent(s -> s.subscriptions.remove(uri));
+    }
+
+    /** Sends {@code notifications/resources/updated} to the sessions subscribed to {@code uri}. */
+    public void resourceUpdated(String uri) {
+        for (Session session : sessions.values()) {
+            if (session.subscriptions.contains(uri)) {
+                send(session, McpServer.notification("notifications/resources/updated", Map.of("uri", uri)));
+            }
+        }
+    }
+
+    /** Sends a notification to every session with an open stream. */
+    public void broadcast(String method) {
+        for (Session session : sessions.values()) {
+            send(session, McpServer.notification(method, Map.of()));
+        }
+    }
+
+    private static void send(Session session, Map<String, Object> message) {
+        McpServer.Sink sink = session.sink;
+        if (sink == null) {
+            return;
+        }
+        try {
+            sink.send(message);
+        } catch (IOException e) {
+            LOG.debugf("MCP session %s stream closed: %s", session.id, e.getMessage());
+            if (session.sink == sink) {
+                session.sink = null;
+            }
+        }
+    }
+}
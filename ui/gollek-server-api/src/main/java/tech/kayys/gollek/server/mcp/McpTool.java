@@ -0,0 +1,57 @@
+package tech.kayys.gollek.server.mcp;
+
+import com.fasterxml.jackson.databind.JsonNode;
+
+import java.io.IOException;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * A tool exposed through MCP {@code tools/list} and {@code tools/call}. Register one with
+ * {@link McpTools#register}; webhook tools are loaded from {@code gollek.server.mcp.tools-file}.
+ */
+public interface McpTool {
+
+    String name();
+
+    String description();
+
+    /** JSON Schema of the {@code arguments} object. */
+    Map<String, Object> inputSchema();
+
+    /**
+     * Runs the tool and returns its MCP content blocks. Tools that produce output
+     * incrementally pass each piece to {@link Call#progress} as well; it is a no-op unless
+     * the client asked for progress.
+     *
+     * @throws IllegalArgumentException for bad arguments, reported to the client as a tool error
+     * @throws IOException when progress can no longer be written; the call is abandoned
+     */
+    List<Map<String, Object>> call(JsonNode arguments, Call call) throws Exception;
+
+    /** One invocation of a tool. */
+    interface Call {
+
+        /**
+         * Id to give any inference the tool runs; the client cancelling the call cancels it
+         * through {@link tech.kayys.gollek.spi.inference.RequestCancellation}.
+         */
+        String requestId();
+
+        void progress(String chunk) throws IOException;
+    }
+
+    /** Receives partial tool output. */
+    @FunctionalInterface
+    interface Progress {
+
+        Progress NONE = chunk -> {
+        };
+
+        void send(String chunk) throws IOException;
+    }
+
+    static Map<String, Object> text(String text) {
+        return Map.of("type", "text", "text", text);
+    }
+}
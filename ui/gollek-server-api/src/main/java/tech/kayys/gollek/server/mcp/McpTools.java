@@ -0,0 +1,336 @@
+package tech.kayys.gollek.server.mcp;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+import com.fasterxml.jackson.core.type.TypeReference;
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import io.quarkus.runtime.StartupEvent;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.models.TokenizerService;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+
+import java.io.IOException;
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.time.Duration;
+import java.util.Comparator;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Tools served over MCP. Built in are {@code tokenize}, {@code model_info} and
+ * {@code generate} (which streams its output as progress); operators add webhook tools
+ * in {@code gollek.server.mcp.tools-file}, a JSON array of
+ * {@code {name, description, input_schema, url, headers}}. A webhook tool POSTs
+ * {@code {"tool", "arguments"}} to its URL and returns the response body as text, or
+ * the {@code content} array when the body is an MCP tool result.
+ */
+@ApplicationScoped
+public class McpTools {
+
+    private static final Logger LOG = Logger.getLogger(McpTools.class);
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    McpCatalog catalog;
+
+    @Inject
+    TokenizerService tokenizers;
+
+    @ConfigProperty(name = "gollek.server.mcp.tools.builtin", defaultValue = "true")
+    boolean builtin;
+
+    @ConfigProperty(name = "gollek.server.mcp.tools-file")
+    Optional<String> toolsFile;
+
+    private final Map<String, McpTool> tools = new ConcurrentHashMap<>();
+    private final ObjectMapper mapper = new ObjectMapper();
+    private final HttpClient http = HttpClient.newBuilder().connectTimeout(Duration.ofSeconds(10)).build();
+
+    /** One entry of the tools file. */
+    public record WebhookSpec(String name, String description, @JsonProperty("input_schema") Map<String, Object> inputSchema,
+            String url, Map<String, String> headers) {
+    }
+
+    void onStart(@Observes StartupEvent event) {
+        if (builtin) {
+            register(new Tokenize());
+            register(new ModelInfo());
+            register(new Generate());
+        }
+        toolsFile.filter(f -> !f.isBlank()).ifPresent(this::load);
+    }
+
+    public void register(McpTool tool) {
+        if (tools.putIfAbsent(tool.name(), tool) != null) {
+            throw new IllegalArgumentException("MCP tool already registered: " + tool.name());
+        }
+    }
+
+    public boolean unregister(String name) {
+        return tools.remove(name) != null;
+    }
+
+    public Optional<McpTool> get(String name) {
+        return Optional.ofNullable(name).map(tools::get);
+    }
+
+    /** {@code tools/list} entries, by name. */
+    public List<Map<String, Object>> list() {
+        return tools.values().stream()
+                .sorted(Comparator.comparing(McpTool::name))
+                .map(tool -> {
+                    Map<String, Object> entry = new LinkedHashMap<>();
+                    entry.put("name", tool.name());
+                    entry.put("description", tool.description());
+                    entry.put("inputSchema", tool.inputSchema());
+                    return entry;
+                })
+                .toList();
+    }
+
+    /**
+     * Runs a tool and returns the {@code tools/call} result. Failures inside the tool are
+     * reported in the result with {@code isError}, as MCP asks, so the model can see them.
+     *
+     * @throws IOException when progress could not be written
+     */
+    public Map<String, Object> call(McpTool tool, JsonNode arguments, String requestId, McpTool.Progress progress)
+            throws IOException {
+        Map<String, Object> result = new LinkedHashMap<>();
+        McpTool.Call call = new McpTool.Call() {
+            @Override
+            public String requestId() {
+                return requestId;
+            }
+
+            @Override
+            public void progress(String chunk) throws IOException {
+                try {
+                    progress.send(chunk);
+                } catch (IOException e) {
+                    throw new ClientGone(e);
+                }
+            }
+        };
+        try {
+            result.put("content", tool.call(arguments.isMissingNode() ? mapper.createObjectNode() : arguments, call));
+            result.put("isError", false);
+        } catch (ClientGone e) {
+            throw (IOException) e.getCause();
+        } catch (Exception e) {
+            LOG.debugf("MCP tool %s failed: %s", tool.name(), e.getMessage());
+            result.put("content", List.of(McpTool.text(String.valueOf(e.getMessage()))));
+            result.put("isError", true);
+        }
+        return result;
+    }
+
+    /** Marks a failed progress write, as opposed to I/O failing inside the tool. */
+    private static final class ClientGone extends IOException {
+        ClientGone(IOException cause) {
+            super(cause);
+        }
+    }
+
+    private void load(String file) {
+        List<WebhookSpec> specs;
+        try {
+            specs = mapper.readValue(Files.readString(Path.of(file)), new TypeReference<List<WebhookSpec>>() {
+            });
+        } catch (IOException e) {
+            LOG.warnf("Could not read MCP tools file %s: %s", file, e.getMessage());
+            return;
+        }
+        for (WebhookSpec spec : specs) {
+            try {
+                register(webhook(spec));
+            } catch (IllegalArgumentException e) {
+                LOG.warnf("Skipping MCP tool from %s: %s", file, e.getMessage());
+            }
+        }
+        LOG.infof("Loaded %d MCP webhook tools from %s", specs.size(), file);
+    }
+
+    McpTool webhook(WebhookSpec spec) {
+        if (spec.name() == null || spec.name().isBlank() || spec.url() == null || spec.url().isBlank()) {
+            throw new IllegalArgumentException("name and url are required");
+        }
+        URI url = URI.create(spec.url());
+        Map<String, Object> schema = spec.inputSchema() != null ? spec.inputSchema() : Map.of("type", "object");
+        String description = spec.description() != null ? spec.description() : "";
+        Map<String, String> headers = spec.headers() != null ? spec.headers() : Map.of();
+        return new McpTool() {
+            @Override
+            public String name() {
+                return spec.name();
+            }
+
+            @Override
+            public String description() {
+                return description;
+            }
+
+            @Override
+            public Map<String, Object> inputSchema() {
+                return schema;
+            }
+
+            @Override
+            public List<Map<String, Object>> call(JsonNode arguments, Call call) throws Exception {
+                HttpRequest.Builder request = HttpRequest.newBuilder(url)
+                        .timeout(Duration.ofSeconds(60))
+                        .header("Content-Type", "application/json");
+                headers.forEach(request::header);
+                String body = mapper.writeValueAsString(Map.of("tool", spec.name(), "arguments", arguments));
+                HttpResponse<String> response = http.send(request.POST(HttpRequest.BodyPublishers.ofString(body)).build(),
+                        HttpResponse.BodyHandlers.ofString());
+                if (response.statusCode() / 100 != 2) {
+                    throw new IllegalStateException("tool endpoint returned HTTP " + response.statusCode());
+                }
+                return webhookContent(response.body());
+            }
+        };
+    }
+
+    List<Map<String, Object>> webhookContent(String body) {
+        try {
+            JsonNode json = mapper.readTree(body);
+            if (json != null && json.path("content").isArray()) {
+                return mapper.convertValue(json.get("content"), new TypeReference<List<Map<String, Object>>>() {
+                });
+            }
+        } catch (IOException e) {
+            // not JSON: plain text result
+        }
+        return List.of(McpTool.text(body));
+    }
+
+    private static Map<String, Object> schema(Map<String, Object> properties, List<String> required) {
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("type", "object");
+        out.put("properties", properties);
+        out.put("required", required);
+        return out;
+    }
+
+    private final class Tokenize implements McpTool {
+
+        @Override
+        public String name() {
+            return "tokenize";
+        }
+
+        @Override
+        public String description() {
+            return "Tokenizes text with a model's tokenizer and returns the token ids and count.";
+        }
+
+        @Override
+        public Map<String, Object> inputSchema() {
+            return schema(Map.of(
+                    "text", Map.of("type", "string"),
+                    "model", Map.of("type", "string", "description", "defaults to the server's first configured model")),
+                    List.of("text"));
+        }
+
+        @Override
+        public List<Map<String, Object>> call(JsonNode arguments, Call call) throws Exception {
+            if (!arguments.hasNonNull("text")) {
+                throw new IllegalArgumentException("text required");
+            }
+            int[] ids = tokenizers.tokenize(arguments.path("model").asText(null), arguments.get("text").asText(), false);
+            return List.of(McpTool.text(mapper.writeValueAsString(Map.of("tokens", ids, "count", ids.length))));
+        }
+    }
+
+    private final class ModelInfo implements McpTool {
+
+        @Override
+        public String name() {
+            return "model_info";
+        }
+
+        @Override
+        public String description() {
+            return "Describes a served model: format, context size, capabilities and whether it is loaded.";
+        }
+
+        @Override
+        public Map<String, Object> inputSchema() {
+            return schema(Map.of("model", Map.of("type", "string")), List.of("model"));
+        }
+
+        @Override
+        public List<Map<String, Object>> call(JsonNode arguments, Call call) throws Exception {
+            String model = arguments.path("model").asText("");
+            Map<String, Object> info = catalog.models().stream()
+                    .filter(m -> model.equals(m.get("id")))
+                    .findFirst()
+                    .orElseThrow(() -> new IllegalArgumentException("unknown model: " + model));
+            return List.of(McpTool.text(mapper.writeValueAsString(info)));
+        }
+    }
+
+    private final class Generate implements McpTool {
+
+        @Override
+        public String name() {
+            return "generate";
+        }
+
+        @Override
+        public String description() {
+            return "Generates text with a served model. Output is streamed as progress when the client asks for it.";
+        }
+
+        @Override
+        public Map<String, Object> inputSchema() {
+            Map<String, Object> properties = new LinkedHashMap<>();
+            properties.put("model", Map.of("type", "string"));
+            properties.put("prompt", Map.of("type", "string"));
+            properties.put("messages", Map.of("type", "array", "items", Map.of("type", "object")));
+            properties.put("max_tokens", Map.of("type", "integer"));
+            properties.put("temperature", Map.of("type", "number"));
+            return schema(properties, List.of("model"));
+        }
+
+        @Override
+        public List<Map<String, Object>> call(JsonNode arguments, Call call) throws Exception {
+            InferenceRequest request = McpServer.toRequest(arguments, call.requestId());
+            StringBuilder text = new StringBuilder();
+            try (var chunks = sdkProvider.getSdk().streamCompletion(request).subscribe().asStream()) {
+                for (var it = chunks.iterator(); it.hasNext();) {
+                    StreamingInferenceChunk chunk = it.next();
+                    if (chunk.delta() != null && !chunk.delta().isEmpty()) {
+                        call.progress(chunk.delta());
+                        text.append(chunk.delta());
+                    }
+                }
+            } catch (IOException e) {
+                RequestCancellation.cancel(request.getRequestId());
+                throw e;
+            }
+            return List.of(McpTool.text(text.toString()));
+        }
+    }
+}
@@ -0,0 +1,247 @@
+package tech.kayys.gollek.server.mcp;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import jakarta.enterprise.context.ApplicationScoped;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.sdk.prompt.PromptTemplate;
+
+import java.io.IOException;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.ArrayList;
+import java.util.Comparator;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+import java.util.concurrent.ThreadLocalRandom;
+import java.util.stream.Stream;
+
+/**
+ * Server-defined prompt templates: the files in {@code gollek.server.mcp.prompts-dir},
+ * named after the file without its extension and written in the Jinja subset of
+ * {@link PromptTemplate}. Files are read on each access, so edits show up without a
+ * restart.
+ *
+ * <p>A template may have versions, as {@code name@version.ext} files next to (or instead
+ * of) {@code name.ext}, which is version {@value #UNVERSIONED}. Requests get the stable
+ * version unless they pin one. {@value #ROLLOUT_FILE} in the same directory picks the
+ * stable version and canaries another to a share of traffic:
+ *
+ * <pre>
+ * {"summarize": {"stable": "2", "canary": "3", "percent": 10}}
+ * </pre>
+ *
+ * Without an entry the stable version is {@value #UNVERSIONED} if that file exists, else
+ * the highest version.
+ */
+@ApplicationScoped
+public class PromptTemplates {
+
+    private static final Logger LOG = Logger.getLogger(PromptTemplates.class);
+    private static final Set<String> EXTENSIONS = Set.of("txt", "md", "prompt", "j2", "jinja", "tmpl");
+
+    /** Version of a template file without one in its name. */
+    static final String UNVERSIONED = "default";
+    static final String ROLLOUT_FILE = "rollout.json";
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    @ConfigProperty(name = "gollek.server.mcp.prompts-dir")
+    Optional<String> dir;
+
+    public record Template(String name, String version, Path file) {
+
+        /** {@code name@version}, which {@link PromptTemplates#get} resolves back to this file. */
+        public String ref() {
+            return name + "@" + version;
+        }
+
+        public String read() throws IOException {
+            return Files.readString(file);
+        }
+
+        /**
+         * @throws IllegalArgumentException when the template does not parse
+         */
+        PromptTemplate parse() throws IOException {
+            return PromptTemplate.parse(read());
+        }
+    }
+
+    /** Serves {@code canary} to {@code percent} of requests and {@code stable} to the rest. */
+    record Rollout(String stable, String canary, int percent) {
+    }
+
+    /** The stable version of each template, by name; empty when no directory is configured. */
+    public List<Template> list() {
+        Map<String, Rollout> rollouts = rollouts();
+        List<Template> out = new ArrayList<>();
+        byName().forEach((name, versions) -> stable(name, versions, rollouts.get(name)).ifPresent(out::add));
+        return out;
+    }
+
+    /** Every version of every template, by name and then version. */
+    public List<Template> all() {
+        return byName().values().stream().flatMap(List::stream).toList();
+    }
+
+    /** The versions of one template, oldest first. */
+    public List<Template> versions(String name) {
+        return byName().getOrDefault(name, List.of());
+    }
+
+    /**
+     * The stable version of {@code name}, or exactly the version a {@code name@version}
+     * reference pins.
+     */
+    public Optional<Template> get(String name) {
+        int at = name.lastIndexOf('@');
+        if (at > 0) {
+            return get(name.substring(0, at), name.substring(at + 1));
+        }
+        return stable(name, versions(name), rollouts().get(name));
+    }
+
+    public Optional<Template> get(String name, String version) {
+        return versions(name).stream().filter(t -> t.version().equals(version)).findFirst();
+    }
+
+    /**
+     * The version to serve a request: {@code version} when pinned, otherwise the stable
+     * one or, for the rollout's share of requests, its canary. Requests with the same
+     * {@code routingKey} (an MCP session) land on the same side; without one each request
+     * is assigned at random.
+     */
+    public Optional<Template> select(String name, String version, String routingKey) {
+        if (version != null && !version.isBlank()) {
+            return get(name, version);
+        }
+        List<Template> versions = versions(name);
+        Rollout rollout = rollouts().get(name);
+        if (rollout != null && rollout.canary() != null && inCanary(routingKey, name, rollout.percent())) {
+            Optional<Template> canary = versions.stream().filter(t -> t.version().equals(rollout.canary())).findFirst();
+            if (canary.isPresent()) {
+                return canary;
+            }
+            LOG.warnf("Canary version %s of prompt %s does not exist", rollout.canary(), name);
+        }
+        return stable(name, versions, rollout);
+    }
+
+    /** Whether a request falls in the first {@code percent} of 100 buckets. */
+    static boolean inCanary(String routingKey, String name, int percent) {
+        if (percent <= 0) {
+            return false;
+        }
+        int bucket = routingKey == null
+                ? ThreadLocalRandom.current().nextInt(100)
+                : Math.floorMod((routingKey + "/" + name).hashCode(), 100);
+        return bucket < percent;
+    }
+
+    private static Optional<Template> stable(String name, List<Template> versions, Rollout rollout) {
+        if (rollout != null && rollout.stable() != null) {
+            Optional<Template> pinned = versions.stream().filter(t -> t.version().equals(rollout.stable())).findFirst();
+            if (pinned.isPresent()) {
+                return pinned;
+            }
+            LOG.warnf("Stable version %s of prompt %s does not exist", rollout.stable(), name);
+        }
+        return versions.stream().filter(t -> t.version().equals(UNVERSIONED)).findFirst()
+                .or(() -> versions.stream().reduce((a, b) -> b));
+    }
+
+    private Map<String, List<Template>> byName() {
+        Map<String, List<Template>> out = new LinkedHashMap<>();
+        for (Template template : files()) {
+            out.computeIfAbsent(template.name(), n -> new ArrayList<>()).add(template);
+        }
+        return out;
+    }
+
+    private List<Template> files() {
+        Optional<Path> root = root();
+        if (root.isEmpty()) {
+            return List.of();
+        }
+        try (Stream<Path> files = Files.list(root.get())) {
+            return files.filter(Files::isRegularFile)
+                    .filter(p -> EXTENSIONS.contains(extension(p)))
+                    .map(PromptTemplates::template)
+                    .sorted(Comparator.comparing(Template::name)
+                            .thenComparing(Template::version, PromptTemplates::compareVersions))
+                    .toList();
+        } catch (IOException e) {
+            LOG.warnf("Could not list prompt templates in %s: %s", root.get(), e.getMessage());
+            return List.of();
+        }
+    }
+
+    /** Rollouts by template name; a rollout file that does not parse is ignored. */
+    Map<String, Rollout> rollouts() {
+        Optional<Path> file = root().map(r -> r.resolve(ROLLOUT_FILE)).filter(Files::isRegularFile);
+        if (file.isEmpty()) {
+            return Map.of();
+        }
+        Map<String, Rollout> out = new LinkedHashMap<>();
+        try {
+            for (Map.Entry<String, JsonNode> entry : mapper.readTree(file.get().toFile()).properties()) {
+                JsonNode r = entry.getValue();
+                out.put(entry.getKey(), new Rollout(r.path("stable").asText(null), r.path("canary").asText(null),
+                        Math.max(0, Math.min(100, r.path("percent").asInt(0)))));
+            }
+        } catch (IOException e) {
+            LOG.warnf("Ignoring prompt rollout %s: %s", file.get(), e.getMessage());
+            return Map.of();
+        }
+        return out;
+    }
+
+    private Optional<Path> root() {
+        return dir.filter(d -> !d.isBlank()).map(Path::of).filter(Files::isDirectory);
+    }
+
+    static Template template(Path file) {
+        String name = name(file);
+        int at = name.lastIndexOf('@');
+        return at > 0
+                ? new Template(name.substring(0, at), name.substring(at + 1), file)
+                : new Template(name, UNVERSIONED, file);
+    }
+
+    static String name(Path file) {
+        String name = file.getFileName().toString();
+        int dot = name.lastIndexOf('.');
+        return dot > 0 ? name.substring(0, dot) : name;
+    }
+
+    /**
+     * Numbered versions ({@code 3}, {@code v3}) in numeric order and before any others,
+     * which sort as text.
+     */
+    static int compareVersions(String a, String b) {
+        boolean numberedA = a.matches("v?\\d{1,9}");
+        boolean numberedB = b.matches("v?\\d{1,9}");
+        if (numberedA && numberedB) {
+            return Integer.compare(Integer.parseInt(a.replace("v", "")), Integer.parseInt(b.replace("v", "")));
+        }
+        if (numberedA != numberedB) {
+            return numberedA ? -1 : 1;
+        }
+        return a.compareTo(b);
+    }
+
+    private static String extension(Path file) {
+        String name = file.getFileName().toString();
+        int dot = name.lastIndexOf('.');
+        return dot < 0 ? "" : name.substring(dot + 1).toLowerCase(Locale.ROOT);
+    }
+}
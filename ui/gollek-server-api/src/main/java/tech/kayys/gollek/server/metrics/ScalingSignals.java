@@ -0,0 +1,175 @@
+package tech.kayys.gollek.server.metrics;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.Any;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.admission.AdmissionController;
+import tech.kayys.gollek.server.jobs.JobQueue;
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.ProviderHealth;
+
+import java.time.Duration;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * One load score for autoscalers (KEDA {@code metrics-api}, an HPA external metric). Each
+ * signal is normalized to 0..1, where 1 means this replica is at its limit:
+ * <ul>
+ * <li>{@code queue}: requests waiting for a sequence, relative to the sequence count,
+ * plus batch jobs relative to {@code gollek.server.jobs.max-queue};</li>
+ * <li>{@code saturation}: busy sequences, or admitted requests when admission control is on;</li>
+ * <li>{@code kv_cache}: KV cache tokens in use over capacity;</li>
+ * <li>{@code wait}: the longest predicted queue wait over {@code gollek.server.scaling.target-wait}.</li>
+ * </ul>
+ * The score is the highest signal, since the tightest resource decides whether a replica
+ * can take more work. Values above 1 are clipped.
+ */
+@ApplicationScoped
+public class ScalingSignals {
+
+    private static final Logger LOG = Logger.getLogger(ScalingSignals.class);
+    private static final Duration HEALTH_TIMEOUT = Duration.ofSeconds(2);
+
+    @Inject
+    @Any
+    Instance<LLMProvider> providers;
+
+    @Inject
+    AdmissionController admission;
+
+    @Inject
+    JobQueue jobQueue;
+
+    @ConfigProperty(name = "gollek.server.scaling.target-wait", defaultValue = "5s")
+    Duration targetWait;
+
+    @ConfigProperty(name = "gollek.server.jobs.max-queue", defaultValue = "100")
+    int jobsMaxQueue;
+
+    /** Raw load figures summed over all loaded runners. */
+    record Load(int sequences, int activeSequences, int queued, long kvTokens, long kvCapacity,
+            long maxWaitMs, int admissionCapacity, int admissionInFlight, int jobsQueued) {
+    }
+
+    public Map<String, Object> snapshot() {
+        Load load = collect();
+        Map<String, Double> signals = signals(load, targetWait.toMillis(), jobsMaxQueue);
+        double score = signals.values().stream().mapToDouble(Double::doubleValue).max().orElse(0);
+
+        Map<String, Object> raw = new LinkedHashMap<>();
+        raw.put("sequences", load.sequences());
+        raw.put("active_sequences", load.activeSequences());
+        raw.put("queued", load.queued());
+        raw.put("kv_tokens", load.kvTokens());
+        raw.put("kv_capacity", load.kvCapacity());
+        raw.put("max_estimated_wait_ms", load.maxWaitMs());
+        raw.put("jobs_queued", load.jobsQueued());
+        if (admission.isEnabled()) {
+            raw.put("admission_capacity", load.admissionCapacity());
+            raw.put("admission_in_flight", load.admissionInFlight());
+        }
+
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("score", round(score));
+        // integer form for scalers that only take whole numbers
+        out.put("score_percent", (int) Math.round(score * 100));
+        out.put("signals", signals);
+        out.put("load", raw);
+        return out;
+    }
+
+    static Map<String, Double> signals(Load load, long targetWaitMs, int jobsMaxQueue) {
+        double queue = ratio(load.queued(), load.sequences())
+                + ratio(load.jobsQueued(), jobsMaxQueue);
+        double saturation = load.admissionCapacity() > 0
+                ? Math.max(ratio(load.activeSequences(), load.sequences()),
+                        ratio(load.admissionInFlight(), load.admissionCapacity()))
+                : ratio(load.activeSequences(), load.sequences());
+
+        Map<String, Double> out = new LinkedHashMap<>();
+        out.put("queue", round(Math.min(1, queue)));
+        out.put("saturation", round(saturation));
+        out.put("kv_cache", round(ratio(load.kvTokens(), load.kvCapacity())));
+        out.put("wait", round(ratio(load.maxWaitMs(), targetWaitMs)));
+        return out;
+    }
+
+    private Load collect() {
+        int sequences = 0;
+        int active = 0;
+        int queued = 0;
+        long kvTokens = 0;
+        long kvCapacity = 0;
+        long maxWait = 0;
+        for (LLMProvider provider : providers) {
+            ProviderHealth health;
+            try {
+                health = provider.health().await().atMost(HEALTH_TIMEOUT);
+            } catch (Exception e) {
+                LOG.debugf("Health of provider %s unavailable: %s", provider.id(), e.getMessage());
+                continue;
+            }
+            if (health == null || !(health.details().get("slots") instanceof List<?> runners)) {
+                continue;
+            }
+            for (Object entry : runners) {
+                if (!(entry instanceof Map<?, ?> runner)) {
+                    continue;
+                }
+                if (runner.get("queued") instanceof Number n) {
+                    queued += n.intValue();
+                }
+                if (runner.get("estimated_wait_ms") instanceof Number n) {
+                    maxWait = Math.max(maxWait, n.longValue());
+                }
+                if (!(runner.get("slots") instanceof List<?> slots)) {
+                    continue;
+                }
+                for (Object s : slots) {
+                    if (!(s instanceof Map<?, ?> slot)) {
+                        continue;
+                    }
+                    sequences++;
+                    if (Boolean.TRUE.equals(slot.get("active"))) {
+                        active++;
+                    }
+                    if (slot.get("tokens") instanceof Number n) {
+                        kvTokens += n.longValue();
+                    }
+                    if (slot.get("capacity") instanceof Number n) {
+                        kvCapacity += n.longValue();
+                    }
+                }
+            }
+        }
+        int admissionCapacity = 0;
+        int admissionInFlight = 0;
+        if (admission.isEnabled()) {
+            Map<String, Object> status = admission.status();
+            admissionCapacity = ((Number) status.get("capacity")).intValue();
+            admissionInFlight = ((Number) status.get("in_flight")).intValue();
+        }
+        Map<String, Object> depth = jobQueue.depth();
+        int jobs = ((Number) depth.get("memory")).intValue() + ((Number) depth.get("spilled")).intValue();
+        return new Load(sequences, active, queued, kvTokens, kvCapacity, maxWait, admissionCapacity,
+                admissionInFlight, jobs);
+    }
+
+    private static double ratio(double value, double limit) {
+        if (limit <= 0) {
+            return value > 0 ? 1 : 0;
+        }
+        return Math.min(1, value / limit);
+    }
+
+    private static double round(double value) {
+        return Math.round(value * 1000) / 1000d;
+    }
+}
@@ -0,0 +1,184 @@
+package tech.kayys.gollek.server.metrics;
+
+import io.smallrye.mutiny.Multi;
+
+import tech.kayys.gollek.sdk.core.GollekSdk;
+import tech.kayys.gollek.sdk.exception.SdkException;
+import tech.kayys.gollek.sdk.model.ModelResolution;
+import tech.kayys.gollek.sdk.model.PullProgress;
+import tech.kayys.gollek.sdk.model.SystemInfo;
+import tech.kayys.gollek.server.models.ModelCache;
+import tech.kayys.gollek.spi.batch.BatchInferenceRequest;
+import tech.kayys.gollek.spi.embedding.EmbeddingRequest;
+import tech.kayys.gollek.spi.embedding.EmbeddingResponse;
+import tech.kayys.gollek.spi.inference.AsyncJobStatus;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+import tech.kayys.gollek.spi.model.ModelInfo;
+import tech.kayys.gollek.spi.provider.ProviderInfo;
+
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Optional;
+import java.util.concurrent.CompletableFuture;
+import java.util.function.Consumer;
+
+/**
+ * SDK decorator that counts every completion, stream and batch item, with its tokens, in
+ * {@link UsageTotals}, and hands it to {@link UsageReports} with its latency. Streams are
+ * counted when they end, however they end. Completions and embeddings also mark their model
+ * used in the {@link ModelCache}. All other operations are delegated unchanged.
+ */
+public class UsageCountingSdk implements GollekSdk {
+
+    private final GollekSdk delegate;
+    private final UsageTotals usage;
+    private final UsageReports reports;
+    private final ModelCache modelCache;
+
+    public UsageCountingSdk(GollekSdk delegate, UsageTotals usage, UsageReports reports, ModelCache modelCache) {
+        this.delegate = delegate;
+        this.usage = usage;
+        this.reports = reports;
+        this.modelCache = modelCache;
+    }
+
+    @Override
+    public InferenceResponse createCompletion(InferenceRequest request) throws SdkException {
+        long start = System.nanoTime();
+        modelCache.touch(request.getModel());
+        InferenceResponse resp = delegate.createCompletion(request);
+        count(request, resp.getInputTokens(), resp.getOutputTokens(), start);
+        return resp;
+    }
+
+    @Override
+    public CompletableFuture<InferenceResponse> createCompletionAsync(InferenceRequest request) {
+        long start = System.nanoTime();
+        modelCache.touch(request.getModel());
+        return delegate.createCompletionAsync(request).whenComplete((resp, error) -> {
+            if (error == null) {
+                count(request, resp.getInputTokens(), resp.getOutputTokens(), start);
+            }
+        });
+    }
+
+    @Override
+    public Multi<StreamingInferenceChunk> streamCompletion(InferenceRequest request) {
+        long start = System.nanoTime();
+        int[] tokens = new int[2];
+        modelCache.touch(request.getModel());
+        return delegate.streamCompletion(request)
+                .onItem().invoke(chunk -> {
+                    if (chunk.usage() != null) {
+                        tokens[0] = chunk.usage().inputTokens();
+                        tokens[1] = chunk.usage().outputTokens();
+                    }
+                })
+                .onTermination().invoke(() -> count(request, tokens[0], tokens[1], start));
+    }
+
+    private void count(InferenceRequest request, int inputTokens, int outputTokens, long start) {
+        usage.record(inputTokens, outputTokens);
+        reports.record(request, inputTokens, outputTokens, (System.nanoTime() - start) / 1_000_000);
+    }
+
+    @Override
+    public EmbeddingResponse createEmbedding(EmbeddingRequest request) throws SdkException {
+        modelCache.touch(request.model());
+        return delegate.createEmbedding(request);
+    }
+
+    @Override
+    public String submitAsyncJob(InferenceRequest request) throws SdkException {
+        return delegate.submitAsyncJob(request);
+    }
+
+    @Override
+    public AsyncJobStatus getJobStatus(String jobId) throws SdkException {
+        return delegate.getJobStatus(jobId);
+    }
+
+    @Override
+    public AsyncJobStatus waitForJob(String jobId, Duration maxWaitTime, Duration pollInterval) throws SdkException {
+        return delegate.waitForJob(jobId, maxWaitTime, pollInterval);
+    }
+
+    @Override
+    public List<InferenceResponse> batchInference(BatchInferenceRequest batchRequest) throws SdkException {
+        List<InferenceResponse> out = new ArrayList<>();
+        for (InferenceRequest r : batchRequest.getRequests()) {
+            out.add(createCompletion(r));
+        }
+        return out;
+    }
+
+    @Override
+    public List<ProviderInfo> listAvailableProviders() throws SdkException {
+        return delegate.listAvailableProviders();
+    }
+
+    @Override
+    public ProviderInfo getProviderInfo(String providerId) throws SdkException {
+        return delegate.getProviderInfo(providerId);
+    }
+
+    @Override
+    public void setPreferredProvider(String providerId) throws SdkException {
+        delegate.setPreferredProvider(providerId);
+    }
+
+    @Override
+    public Optional<String> getPreferredProvider() {
+        return delegate.getPreferredProvider();
+    }
+
+    @Override
+    public List<ModelInfo> listModels() throws SdkException {
+        return delegate.listModels();
+    }
+
+    @Override
+    public List<ModelInfo> listModels(int offset, int limit) throws SdkException {
+        return delegate.listModels(offset, limit);
+    }
+
+    @Override
+    public Optional<ModelInfo> getModelInfo(String modelId) throws SdkException {
+        return delegate.getModelInfo(modelId);
+    }
+
+    @Override
+    public void pullModel(String modelSpec, Consumer<PullProgress> progressCallback) throws SdkException {
+        delegate.pullModel(modelSpec, progressCallback);
+    }
+
+    @Override
+    public void pullModel(String modelSpec, String revision, boolean force, Consumer<PullProgress> progressCallback)
+            throws SdkException {
+        delegate.pullModel(modelSpec, revision, force, progressCallback);
+    }
+
+    @Override
+    public void deleteModel(String modelId) throws SdkException {
+        delegate.deleteModel(modelId);
+    }
+
+    @Override
+    public ModelResolution prepareModel(String modelId, boolean forceGguf, Consumer<PullProgress> progressCallback)
+            throws SdkException {
+        return delegate.prepareModel(modelId, forceGguf, progressCallback);
+    }
+
+    @Override
+    public Optional<String> autoSelectProvider(String modelId, boolean forceGguf) throws SdkException {
+        return delegate.autoSelectProvider(modelId, forceGguf);
+    }
+
+    @Override
+    public SystemInfo getSystemInfo() throws SdkException {
+        return delegate.getSystemInfo();
+    }
+}
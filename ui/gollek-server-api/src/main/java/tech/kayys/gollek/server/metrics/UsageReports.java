@@ -0,0 +1,367 @@
+package tech.kayys.gollek.server.metrics;
+
+import io.quarkus.scheduler.Scheduled;
+import jakarta.annotation.PreDestroy;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.eclipse.microprofile.metrics.MetricRegistry;
+import org.jboss.logging.Logger;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.DeserializationFeature;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.audit.AuditLog;
+import tech.kayys.gollek.server.cluster.LeaderElection;
+import tech.kayys.gollek.server.store.Store;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.io.IOException;
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.nio.charset.StandardCharsets;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.time.Clock;
+import java.time.Duration;
+import java.time.Instant;
+import java.time.YearMonth;
+import java.time.format.DateTimeParseException;
+import java.util.ArrayList;
+import java.util.Comparator;
+import java.util.HexFormat;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.TreeMap;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.concurrent.TimeUnit;
+
+/**
+ * Monthly usage reports per tenant, a tenant being an API key. With
+ * {@code gollek.server.usage-reports.enabled}, every completion is appended to a per-month
+ * list in the {@value #EVENTS} store namespace. Shortly after a month ends the leader
+ * turns its list into a report (requests, tokens, top models and p95 latency per tenant),
+ * saved as {@code <yyyy-MM>.json} and {@code <yyyy-MM>.csv} in {@value #NAMESPACE} and,
+ * when {@code upload-url} is set, PUT to {@code <upload-url>/<yyyy-MM>.json|.csv} (an
+ * object storage bucket or presigned prefix). The month's events are then dropped.
+ *
+ * <p>Keys never appear in reports: tenants are named by a hash of the key and its last four
+ * characters.
+ */
+@ApplicationScoped
+public class UsageReports {
+
+    private static final Logger LOG = Logger.getLogger(UsageReports.class);
+    static final String EVENTS = "usage-events";
+    static final String NAMESPACE = "usage-reports";
+    static final String ANONYMOUS = "anonymous";
+    private static final int TOP_MODELS = 5;
+    private static final int PAGE = 10_000;
+
+    /** One completion, kept short as there is one per request. */
+    record Event(@JsonProperty("t") String tenant, @JsonProperty("k") String key, @JsonProperty("m") String model,
+            @JsonProperty("i") int inputTokens, @JsonProperty("o") int outputTokens,
+            @JsonProperty("l") long latencyMs) {
+    }
+
+    @ConfigProperty(name = "gollek.server.usage-reports.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.usage-reports.upload-url")
+    Optional<String> uploadUrl;
+
+    /** Sent as a bearer token with uploads. */
+    @ConfigProperty(name = "gollek.server.usage-reports.upload-token")
+    Optional<String> uploadToken;
+
+    @Inject
+    Store store;
+
+    @Inject
+    MetricRegistry registry;
+
+    Clock clock = Clock.systemUTC();
+
+    private final ObjectMapper mapper = new ObjectMapper()
+            .configure(DeserializationFeature.FAIL_ON_UNKNOWN_PROPERTIES, false);
+    private final HttpClient http = HttpClient.newBuilder().connectTimeout(Duration.ofSeconds(10)).build();
+    private final ExecutorService writer = Executors.newSingleThreadExecutor(r -> {
+        Thread t = new Thread(r, "gollek-usage-events");
+        t.setDaemon(true);
+        return t;
+    });
+
+    public boolean enabled() {
+        return enabled;
+    }
+
+    /** Records a finished completion against the current month, off the request thread. */
+    public void record(InferenceRequest request, int inputTokens, int outputTokens, long latencyMs) {
+        if (!enabled) {
+            return;
+        }
+        String apiKey = request.getApiKey();
+        Event event = new Event(tenant(apiKey), AuditLog.maskKey(apiKey), request.getModel(),
+                Math.max(0, inputTokens), Math.max(0, outputTokens), latencyMs);
+        YearMonth month = YearMonth.now(clock);
+        writer.execute(() -> append(month, event));
+    }
+
+    void append(YearMonth month, Event event) {
+        try {
+            store.append(EVENTS, month.toString(), mapper.writeValueAsString(event));
+        } catch (JsonProcessingException | RuntimeException e) {
+            LOG.warnf("Usage event not recorded: %s", e.getMessage());
+        }
+    }
+
+    @PreDestroy
+    void close() {
+        writer.shutdown();
+        try {
+            writer.awaitTermination(5, TimeUnit.SECONDS);
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+        }
+    }
+
+    @Scheduled(cron = "${gollek.server.usage-reports.cron:0 15 0 1 * ?}",
+            concurrentExecution = Scheduled.ConcurrentExecution.SKIP,
+            skipExecutionIf = LeaderElection.NotLeader.class)
+    void scheduledReports() {
+        if (enabled) {
+            generateDue();
+        }
+    }
+
+    /**
+     * Reports every finished month that still has events, then drops them. Months missed
+     * while the server was down are caught up on the next run.
+     */
+    public List<YearMonth> generateDue() {
+        YearMonth current = YearMonth.now(clock);
+        List<YearMonth> done = new ArrayList<>();
+        for (String list : store.lists(EVENTS)) {
+            YearMonth month;
+            try {
+                month = YearMonth.parse(list);
+            } catch (DateTimeParseException e) {
+                continue;
+            }
+            if (month.isBefore(current)) {
+                generate(month);
+                store.deleteList(EVENTS, list);
+                done.add(month);
+            }
+        }
+        return done;
+    }
+
+    /**
+     * Builds and saves the report for {@code month} from its events; months in progress
+     * give a partial report. A month whose events were already dropped keeps its report.
+     */
+    public Map<String, Object> generate(YearMonth month) {
+        List<Event> events = events(month);
+        if (events.isEmpty()) {
+            Optional<String> existing = store.get(NAMESPACE, month + ".json");
+            if (existing.isPresent()) {
+                try {
+                    @SuppressWarnings("unchecked")
+                    Map<String, Object> report = mapper.readValue(existing.get(), Map.class);
+                    return report;
+                } catch (JsonProcessingException e) {
+                    LOG.warnf("Replacing unreadable usage report for %s: %s", month, e.getMessage());
+                }
+            }
+        }
+        Map<String, Object> report = report(month, events, clock.instant());
+        String json;
+        try {
+            json = mapper.writeValueAsString(report);
+        } catch (JsonProcessingException e) {
+            throw new IllegalStateException(e);
+        }
+        String csv = csv(report);
+        store.put(NAMESPACE, month + ".json", json);
+        store.put(NAMESPACE, month + ".csv", csv);
+        uploadUrl.filter(u -> !u.isBlank()).ifPresent(url -> {
+            upload(url, month + ".json", json, "application/json");
+            upload(url, month + ".csv", csv, "text/csv");
+        });
+        if (registry != null) {
+            registry.counter("gollek.usage_reports.generated").inc();
+        }
+        LOG.infof("Usage report for %s: %d requests from %d tenant(s)", month, events.size(),
+                ((List<?>) report.get("tenants")).size());
+        return report;
+    }
+
+    /** Months with a saved report, oldest first. */
+    public List<String> months() {
+        return store.keys(NAMESPACE).stream()
+                .filter(k -> k.endsWith(".json"))
+                .map(k -> k.substring(0, k.length() - ".json".length()))
+                .sorted()
+                .toList();
+    }
+
+    /** A saved report as {@code json} or {@code csv}. */
+    public Optional<String> report(YearMonth month, String format) {
+        return store.get(NAMESPACE, month + "." + format);
+    }
+
+    private List<Event> events(YearMonth month) {
+        List<Event> events = new ArrayList<>();
+        for (int offset = 0;; offset += PAGE) {
+            List<String> page = store.range(EVENTS, month.toString(), offset, PAGE);
+            for (String line : page) {
+                try {
+                    events.add(mapper.readValue(line, Event.class));
+                } catch (JsonProcessingException e) {
+                    LOG.debugf("Skipping unreadable usage event: %s", e.getMessage());
+                }
+            }
+            if (page.size() < PAGE) {
+                return events;
+            }
+        }
+    }
+
+    static Map<String, Object> report(YearMonth month, List<Event> events, Instant generatedAt) {
+        record Usage(String key, long[] counts, List<Long> latencies, Map<String, long[]> models) {
+        }
+        Map<String, Usage> byTenant = new TreeMap<>();
+        long[] totals = new long[3];
+        for (Event e : events) {
+            Usage u = byTenant.computeIfAbsent(e.tenant(),
+                    t -> new Usage(e.key(), new long[3], new ArrayList<>(), new TreeMap<>()));
+            add(u.counts(), e);
+            add(totals, e);
+            u.latencies().add(e.latencyMs());
+            long[] model = u.models().computeIfAbsent(String.valueOf(e.model()), m -> new long[2]);
+            model[0]++;
+            model[1] += (long) e.inputTokens() + e.outputTokens();
+        }
+        List<Map<String, Object>> tenants = new ArrayList<>();
+        byTenant.forEach((tenant, u) -> {
+            Map<String, Object> row = new LinkedHashMap<>();
+            row.put("tenant", tenant);
+            row.put("key", u.key());
+            putCounts(row, u.counts());
+            row.put("p95_latency_ms", percentile(u.latencies(), 0.95));
+            row.put("top_models", u.models().entrySet().stream()
+                    .sorted(Comparator.comparingLong((Map.Entry<String, long[]> m) -> m.getValue()[0]).reversed())
+                    .limit(TOP_MODELS)
+                    .map(m -> Map.<String, Object>of("model", m.getKey(), "requests", m.getValue()[0],
+                            "total_tokens", m.getValue()[1]))
+                    .toList());
+            tenants.add(row);
+        });
+        tenants.sort(Comparator.comparingLong((Map<String, Object> row) -> (long) row.get("total_tokens")).reversed());
+
+        Map<String, Object> total = new LinkedHashMap<>();
+        putCounts(total, totals);
+        Map<String, Object> report = new LinkedHashMap<>();
+        report.put("month", month.toString());
+        report.put("generated_at", generatedAt.toString());
+        report.put("totals", total);
+        report.put("tenants", tenants);
+        return report;
+    }
+
+    private static void add(long[] counts, Event e) {
+        counts[0]++;
+        counts[1] += e.inputTokens();
+        counts[2] += e.outputTokens();
+    }
+
+    private static void putCounts(Map<String, Object> row, long[] counts) {
+        row.put("requests", counts[0]);
+        row.put("input_tokens", counts[1]);
+        row.put("output_tokens", counts[2]);
+        row.put("total_tokens", counts[1] + counts[2]);
+    }
+
+    /** Nearest-rank percentile; 0 without samples. */
+    static long percentile(List<Long> values, double p) {
+        if (values.isEmpty()) {
+            return 0;
+        }
+        List<Long> sorted = values.stream().sorted().toList();
+        int rank = (int) Math.ceil(p * sorted.size());
+        return sorted.get(Math.max(0, rank - 1));
+    }
+
+    /** One line per tenant; top models as {@code model:requests} separated by {@code ;}. */
+    @SuppressWarnings("unchecked")
+    static String csv(Map<String, Object> report) {
+        StringBuilder sb = new StringBuilder(
+                "month,tenant,key,requests,input_tokens,output_tokens,total_tokens,p95_latency_ms,top_models\n");
+        for (Map<String, Object> row : (List<Map<String, Object>>) report.get("tenants")) {
+            StringBuilder models = new StringBuilder();
+            for (Map<String, Object> m : (List<Map<String, Object>>) row.get("top_models")) {
+                if (!models.isEmpty()) {
+                    models.append(';');
+                }
+                models.append(m.get("model")).append(':').append(m.get("requests"));
+            }
+            sb.append(report.get("month")).append(',')
+                    .append(cell(row.get("tenant"))).append(',')
+                    .append(cell(row.get("key"))).append(',')
+                    .append(row.get("requests")).append(',')
+                    .append(row.get("input_tokens")).append(',')
+                    .append(row.get("output_tokens")).append(',')
+                    .append(row.get("total_tokens")).append(',')
+                    .append(row.get("p95_latency_ms")).append(',')
+                    .append(cell(models.toString())).append('\n');
+        }
+        return sb.toString();
+    }
+
+    private static String cell(Object value) {
+        String s = value == null ? "" : value.toString();
+        if (s.contains(",") || s.contains("\"") || s.contains("\n")) {
+            return "\"" + s.replace("\"", "\"\"") + "\"";
+        }
+        return s;
+    }
+
+    private void upload(String url, String name, String body, String contentType) {
+        try {
+            HttpRequest.Builder req = HttpRequest.newBuilder(URI.create(url.replaceAll("/+$", "") + "/" + name))
+                    .timeout(Duration.ofSeconds(30))
+                    .header("Content-Type", contentType)
+                    .PUT(HttpRequest.BodyPublishers.ofString(body, StandardCharsets.UTF_8));
+            uploadToken.filter(t -> !t.isBlank()).ifPresent(t -> req.header("Authorization", "Bearer " + t));
+            HttpResponse<Void> resp = http.send(req.build(), HttpResponse.BodyHandlers.discarding());
+            if (resp.statusCode() / 100 != 2) {
+                LOG.warnf("Usage report upload of %s returned HTTP %d", name, resp.statusCode());
+            }
+        } catch (IOException | IllegalArgumentException e) {
+            LOG.warnf("Usage report %s not uploaded: %s", name, e.getMessage());
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+        }
+    }
+
+    /** A stable, non-reversible tenant name for an API key. */
+    static String tenant(String apiKey) {
+        if (apiKey == null || apiKey.isEmpty()) {
+            return ANONYMOUS;
+        }
+        try {
+            byte[] digest = MessageDigest.getInstance("SHA-256").digest(apiKey.getBytes(StandardCharsets.UTF_8));
+            return HexFormat.of().formatHex(digest, 0, 6);
+        } catch (NoSuchAlgorithmException e) {
+            throw new IllegalStateException("SHA-256 unavailable", e);
+        }
+    }
+}
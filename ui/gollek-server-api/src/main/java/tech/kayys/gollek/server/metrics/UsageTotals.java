@@ -0,0 +1,181 @@
+package tech.kayys.gollek.server.metrics;
+
+import io.quarkus.runtime.ShutdownEvent;
+import io.quarkus.runtime.StartupEvent;
+import io.quarkus.scheduler.Scheduled;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.eclipse.microprofile.metrics.MetricRegistry;
+import org.jboss.logging.Logger;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.DeserializationFeature;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.store.Store;
+
+import java.time.Instant;
+import java.util.LinkedHashMap;
+import java.util.Map;
+import java.util.Optional;
+import java.util.concurrent.atomic.LongAdder;
+
+/**
+ * Lifetime completion and token counts. Every run counts from zero; with
+ * {@code gollek.server.metrics.persist.enabled} the counts are added to the totals in the
+ * {@value #NAMESPACE} store namespace on shutdown and every
+ * {@code gollek.server.metrics.persist.every}, and the totals are restored on start, so
+ * lifetime usage survives deploys. Counts are merged with {@link Store#compareAndSet}, so
+ * replicas sharing a store add up to one fleet-wide total.
+ */
+@ApplicationScoped
+public class UsageTotals {
+
+    private static final Logger LOG = Logger.getLogger(UsageTotals.class);
+    static final String NAMESPACE = "metrics";
+    static final String KEY = "usage";
+    private static final int MERGE_ATTEMPTS = 5;
+
+    record Totals(long requests, @JsonProperty("input_tokens") long inputTokens,
+            @JsonProperty("output_tokens") long outputTokens, long since) {
+
+        Totals plus(long requests, long inputTokens, long outputTokens) {
+            return new Totals(this.requests + requests, this.inputTokens + inputTokens,
+                    this.outputTokens + outputTokens, since);
+        }
+    }
+
+    @ConfigProperty(name = "gollek.server.metrics.persist.enabled", defaultValue = "false")
+    boolean persist;
+
+    @Inject
+    Store store;
+
+    @Inject
+    MetricRegistry registry;
+
+    private final ObjectMapper mapper = new ObjectMapper()
+            .configure(DeserializationFeature.FAIL_ON_UNKNOWN_PROPERTIES, false);
+    private final LongAdder requests = new LongAdder();
+    private final LongAdder inputTokens = new LongAdder();
+    private final LongAdder outputTokens = new LongAdder();
+    private volatile Totals restored = new Totals(0, 0, 0, Instant.now().getEpochSecond());
+    /** This run's counts already merged into the store. */
+    private Totals flushed = new Totals(0, 0, 0, 0);
+
+    void onStart(@Observes StartupEvent event) {
+        init();
+    }
+
+    void onStop(@Observes ShutdownEvent event) {
+        flush();
+    }
+
+    @Scheduled(every = "${gollek.server.metrics.persist.every:5m}",
+            concurrentExecution = Scheduled.ConcurrentExecution.SKIP)
+    void scheduledFlush() {
+        flush();
+    }
+
+    /** Restore the stored totals and seed the lifetime counters with them. */
+    void init() {
+        if (!persist) {
+            return;
+        }
+        try {
+            store.get(NAMESPACE, KEY).map(this::read).ifPresent(totals -> {
+                restored = totals;
+                LOG.infof("Restored lifetime usage: %d requests, %d input and %d output tokens",
+                        totals.requests(), totals.inputTokens(), totals.outputTokens());
+            });
+        } catch (RuntimeException e) {
+            LOG.warn("Could not restore lifetime usage: " + e.getMessage());
+        }
+        count(restored.requests(), restored.inputTokens(), restored.outputTokens());
+    }
+
+    /** Count one completion; negative token counts (unknown) are taken as zero. */
+    public void record(int inputTokens, int outputTokens) {
+        long in = Math.max(0, inputTokens);
+        long out = Math.max(0, outputTokens);
+        requests.increment();
+        this.inputTokens.add(in);
+        this.outputTokens.add(out);
+        count(1, in, out);
+    }
+
+    /**
+     * Add this run's counts since the last flush to the stored totals. A no-op when
+     * persistence is off or nothing new was counted.
+     */
+    synchronized void flush() {
+        if (!persist) {
+            return;
+        }
+        Totals run = new Totals(requests.sum(), inputTokens.sum(), outputTokens.sum(), 0);
+        long r = run.requests() - flushed.requests();
+        long in = run.inputTokens() - flushed.inputTokens();
+        long out = run.outputTokens() - flushed.outputTokens();
+        if (r == 0 && in == 0 && out == 0) {
+            return;
+        }
+        try {
+            for (int attempt = 0; attempt < MERGE_ATTEMPTS; attempt++) {
+                Optional<String> current = store.get(NAMESPACE, KEY);
+                Totals stored = current.map(this::read).orElse(new Totals(0, 0, 0, restored.since()));
+                if (store.compareAndSet(NAMESPACE, KEY, current.orElse(null), write(stored.plus(r, in, out)))) {
+                    flushed = run;
+                    return;
+                }
+            }
+            LOG.warnf("Lifetime usage not saved: the stored totals kept changing (%d attempts)", MERGE_ATTEMPTS);
+        } catch (RuntimeException e) {
+            LOG.warn("Lifetime usage not saved: " + e.getMessage());
+        }
+    }
+
+    /** Lifetime counts: the restored totals plus this run. */
+    public Map<String, Object> snapshot() {
+        Totals base = restored;
+        long input = base.inputTokens() + inputTokens.sum();
+        long output = base.outputTokens() + outputTokens.sum();
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("requests", base.requests() + requests.sum());
+        out.put("input_tokens", input);
+        out.put("output_tokens", output);
+        out.put("total_tokens", input + output);
+        out.put("since", Instant.ofEpochSecond(base.since()).toString());
+        out.put("persisted", persist);
+        return out;
+    }
+
+    private void count(long requests, long inputTokens, long outputTokens) {
+        if (registry == null) {
+            return;
+        }
+        registry.counter("gollek.usage.requests").inc(requests);
+        registry.counter("gollek.usage.tokens.input").inc(inputTokens);
+        registry.counter("gollek.usage.tokens.output").inc(outputTokens);
+    }
+
+    private String write(Totals totals) {
+        try {
+            return mapper.writeValueAsString(totals);
+        } catch (JsonProcessingException e) {
+            throw new IllegalStateException(e);
+        }
+    }
+
+    /** The stored totals; an unreadable entry counts as zero and is overwritten. */
+    private Totals read(String value) {
+        try {
+            return mapper.readValue(value, Totals.class);
+        } catch (JsonProcessingException e) {
+            LOG.warn("Ignoring unreadable lifetime usage: " + e.getMessage());
+            return new Totals(0, 0, 0, Instant.now().getEpochSecond());
+        }
+    }
+}
@@ -0,0 +1,169 @@
+package tech.kayys.gollek.server.models;
+
+import java.io.BufferedInputStream;
+import java.io.DataInputStream;
+import java.io.EOFException;
+import java.io.IOException;
+import java.io.InputStream;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Streaming reader for the metadata section of a GGUF file. Only the key/value pairs and
+ * tensor descriptors are read; tensor data is never touched, so this is cheap enough to
+ * run while listing models. Large arrays (vocabularies, merges) are skipped.
+ */
+public record GgufHeader(int version, Map<String, Object> metadata, long parameterCount) {
+
+    private static final int MAGIC = 0x46554747; // "GGUF" little-endian
+    private static final int MAX_ARRAY_ELEMENTS = 64;
+
+    public static GgufHeader read(Path file) throws IOException {
+        try (InputStream in = new BufferedInputStream(Files.newInputStream(file), 1 << 16)) {
+            return read(in);
+        }
+    }
+
+    public static GgufHeader read(InputStream stream) throws IOException {
+        LittleEndian in = new LittleEndian(stream);
+        if (in.i32() != MAGIC) {
+            throw new IOException("not a GGUF file");
+        }
+        int version = in.i32();
+        if (version < 2) {
+            throw new IOException("unsupported GGUF version " + version);
+        }
+        long tensorCount = in.i64();
+        long kvCount = in.i64();
+        Map<String, Object> metadata = new LinkedHashMap<>();
+        for (long i = 0; i < kvCount; i++) {
+            String key = in.string();
+            int type = in.i32();
+            metadata.put(key, in.value(type));
+        }
+        long parameters = 0;
+        for (long i = 0; i < tensorCount; i++) {
+            in.skipString();
+            int dims = in.i32();
+            long elements = 1;
+            for (int d = 0; d < dims; d++) {
+                elements *= in.i64();
+            }
+            in.i32(); // ggml type
+            in.i64(); // data offset
+            parameters += elements;
+        }
+        return new GgufHeader(version, metadata, parameters);
+    }
+
+    public String string(String key) {
+        Object v = metadata.get(key);
+        return v == null ? null : v.toString();
+    }
+
+    public Long number(String key) {
+        return metadata.get(key) instanceof Number n ? n.longValue() : null;
+    }
+
+    public Boolean bool(String key) {
+        return metadata.get(key) instanceof Boolean b ? b : null;
+    }
+
+    /** Marker for arrays too large to keep; only the length is retained. */
+    public record SkippedArray(long length) {
+    }
+
+    private static final class LittleEndian {
+        private final DataInputStream in;
+
+        LittleEndian(InputStream in) {
+            this.in = new DataInputStream(in);
+        }
+
+        int i32() throws IOException {
+            return Integer.reverseBytes(in.readInt());
+        }
+
+        long i64() throws IOException {
+            return Long.reverseBytes(in.readLong());
+        }
+
+        String string() throws IOException {
+            long len = i64();
+            if (len < 0 || len > Integer.MAX_VALUE) {
+                throw new IOException("invalid string length " + len);
+            }
+            byte[] bytes = new byte[(int) len];
+            in.readFully(bytes);
+            return new String(bytes, StandardCharsets.UTF_8);
+        }
+
+        void skipString() throws IOException {
+            skip(i64());
+        }
+
+        void skip(long n) throws IOException {
+            while (n > 0) {
+                long skipped = in.skip(n);
+                if (skipped <= 0) {
+                    if (in.read() < 0) {
+                        throw new EOFException();
+                    }
+                    skipped = 1;
+                }
+                n -= skipped;
+            }
+        }
+
+        Object value(int type) throws IOException {
+            return switch (type) {
+                case 0 -> (long) in.readUnsignedByte();
+                case 1 -> (long) in.readByte();
+                case 2 -> (long) Short.toUnsignedInt(Short.reverseBytes(in.readShort()));
+                case 3 -> (long) Short.reverseBytes(in.readShort());
+                case 4 -> Integer.toUnsignedLong(i32());
+                case 5 -> (long) i32();
+                case 6 -> (double) Float.intBitsToFloat(i32());
+                case 7 -> in.readByte() != 0;
+                case 8 -> string();
+                case 9 -> array();
+                case 10, 11 -> i64();
+                case 12 -> Double.longBitsToDouble(i64());
+                default -> throw new IOException("unknown GGUF value type " + type);
+            };
+        }
+
+        Object array() throws IOException {
+            int elementType = i32();
+            long length = i64();
+            if (length > MAX_ARRAY_ELEMENTS) {
+                for (long i = 0; i < length; i++) {
+                    skipValue(elementType);
+                }
+                return new SkippedArray(length);
+            }
+            List<Object> values = new ArrayList<>((int) length);
+            for (long i = 0; i < length; i++) {
+                values.add(value(elementType));
+            }
+            return values;
+        }
+
+        void skipValue(int type) throws IOException {
+            switch (type) {
+                case 0, 1, 7 -> skip(1);
+                case 2, 3 -> skip(2);
+                case 4, 5, 6 -> skip(4);
+                case 10, 11, 12 -> skip(8);
+                case 8 -> skipString();
+                case 9 -> array();
+                default -> throw new IOException("unknown GGUF value type " + type);
+            }
+        }
+    }
+}
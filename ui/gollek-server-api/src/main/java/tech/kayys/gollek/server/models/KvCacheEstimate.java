@@ -0,0 +1,53 @@
+package tech.kayys.gollek.server.models;
+
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+/**
+ * KV cache memory a GGUF model needs, using the same layout as the llama.cpp runner's
+ * load-time estimate: per layer and token, {@code key_length + value_length} f16 values
+ * for each KV head.
+ */
+@JsonInclude(JsonInclude.Include.NON_NULL)
+public record KvCacheEstimate(
+        @JsonProperty("bytes_per_token") long bytesPerToken,
+        @JsonProperty("context_size") int contextSize,
+        @JsonProperty("bytes") long bytes,
+        @JsonProperty("model_context_size") Long modelContextSize,
+        @JsonProperty("model_context_bytes") Long modelContextBytes) {
+
+    private static final int F16_BYTES = 2;
+
+    /**
+     * Estimate for {@code contextSize} tokens, plus the model's trained context when
+     * known; null when the header lacks the needed hyper-parameters.
+     */
+    public static KvCacheEstimate fromGguf(GgufHeader header, int contextSize) {
+        String arch = header.string("general.architecture");
+        if (arch == null) {
+            return null;
+        }
+        long layers = orZero(header.number(arch + ".block_count"));
+        long embd = orZero(header.number(arch + ".embedding_length"));
+        long heads = orZero(header.number(arch + ".attention.head_count"));
+        long kvHeads = orZero(header.number(arch + ".attention.head_count_kv"));
+        if (kvHeads <= 0) {
+            kvHeads = heads;
+        }
+        long headDim = heads > 0 ? embd / heads : 0;
+        long keyLength = orZero(header.number(arch + ".attention.key_length"));
+        long valueLength = orZero(header.number(arch + ".attention.value_length"));
+        long perToken = layers * kvHeads * ((keyLength > 0 ? keyLength : headDim)
+                + (valueLength > 0 ? valueLength : headDim)) * F16_BYTES;
+        if (perToken <= 0) {
+            return null;
+        }
+        Long trained = header.number(arch + ".context_length");
+        return new KvCacheEstimate(perToken, contextSize, perToken * contextSize,
+                trained, trained == null ? null : perToken * trained);
+    }
+
+    private static long orZero(Long v) {
+        return v == null ? 0L : v;
+    }
+}
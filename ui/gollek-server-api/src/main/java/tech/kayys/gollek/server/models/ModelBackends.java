@@ -0,0 +1,107 @@
+package tech.kayys.gollek.server.models;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.Any;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.spi.model.DeviceType;
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.ProviderCapabilities;
+import tech.kayys.gollek.spi.provider.ProviderHealth;
+
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Compute backends of in-process providers, read from their health details: the
+ * backends a provider detected ({@code backends}, e.g. cpu, cuda, vulkan) and the one each
+ * loaded model runs on ({@code models}). Remote providers are skipped so a slow upstream
+ * cannot stall {@code /health}.
+ */
+@ApplicationScoped
+public class ModelBackends {
+
+    private static final Logger LOG = Logger.getLogger(ModelBackends.class);
+    private static final Duration HEALTH_TIMEOUT = Duration.ofSeconds(2);
+
+    @Inject
+    @Any
+    Instance<LLMProvider> providers;
+
+    /**
+     * Backends per provider id and one entry per loaded model ({@code provider},
+     * {@code model}, {@code backend}, {@code gpu_layers}).
+     */
+    public record Snapshot(Map<String, List<String>> available, List<Map<String, Object>> models) {
+
+        /** Backend of a loaded model, or null if no provider has it loaded. */
+        public String backendOf(String modelId) {
+            for (Map<String, Object> model : models) {
+                if (modelId != null && modelId.equals(model.get("model"))) {
+                    return String.valueOf(model.get("backend"));
+                }
+            }
+            return null;
+        }
+
+        /** {@code chat_template} a provider reports for a loaded model, or null. */
+        public Map<?, ?> chatTemplateOf(String modelId) {
+            for (Map<String, Object> model : models) {
+                if (modelId != null && modelId.equals(model.get("model"))
+                        && model.get("chat_template") instanceof Map<?, ?> template && !template.isEmpty()) {
+                    return template;
+                }
+            }
+            return null;
+        }
+    }
+
+    public Snapshot snapshot() {
+        Map<String, List<String>> available = new LinkedHashMap<>();
+        List<Map<String, Object>> models = new ArrayList<>();
+        for (LLMProvider provider : providers) {
+            if (!isLocal(provider)) {
+                continue;
+            }
+            ProviderHealth health;
+            try {
+                health = provider.health().await().atMost(HEALTH_TIMEOUT);
+            } catch (Exception e) {
+                LOG.debugf("Health of provider %s unavailable: %s", provider.id(), e.getMessage());
+                continue;
+            }
+            if (health == null) {
+                continue;
+            }
+            if (health.details().get("backends") instanceof List<?> backends) {
+                available.put(provider.id(), backends.stream().map(String::valueOf).toList());
+            }
+            if (health.details().get("models") instanceof List<?> entries) {
+                for (Object entry : entries) {
+                    if (entry instanceof Map<?, ?> model) {
+                        Map<String, Object> out = new LinkedHashMap<>();
+                        out.put("provider", provider.id());
+                        model.forEach((k, v) -> out.put(String.valueOf(k), v));
+                        models.add(out);
+                    }
+                }
+            }
+        }
+        return new Snapshot(available, models);
+    }
+
+    private static boolean isLocal(LLMProvider provider) {
+        try {
+            ProviderCapabilities capabilities = provider.capabilities();
+            return capabilities != null && capabilities.getSupportedDevices().contains(DeviceType.CPU);
+        } catch (RuntimeException e) {
+            return false;
+        }
+    }
+}
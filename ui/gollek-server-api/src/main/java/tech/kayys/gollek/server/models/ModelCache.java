@@ -0,0 +1,572 @@
+package tech.kayys.gollek.server.models;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+import io.quarkus.runtime.StartupEvent;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.io.InputStream;
+import java.io.OutputStream;
+import java.nio.file.FileStore;
+import java.nio.file.Files;
+import java.nio.file.InvalidPathException;
+import java.nio.file.LinkOption;
+import java.nio.file.NoSuchFileException;
+import java.nio.file.Path;
+import java.nio.file.StandardCopyOption;
+import java.nio.file.attribute.BasicFileAttributes;
+import java.security.DigestInputStream;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.Comparator;
+import java.util.HashMap;
+import java.util.HashSet;
+import java.util.HexFormat;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+import java.util.concurrent.ConcurrentHashMap;
+import java.util.stream.Stream;
+
+/**
+ * Content-addressable store for the model directory. Model files are moved to
+ * {@code .blobs/sha256-<hash>} and the original path becomes an alias linking to the
+ * blob: a symbolic link, or a hard link where symbolic links are not allowed. Two
+ * entries with the same content then share one blob on disk. Runners keep resolving the
+ * alias paths they always used. At startup this runs in the background.
+ *
+ * <p>{@link #gc()} deletes blobs no alias points to. With a maximum size (from
+ * {@code gollek.server.model-cache.max-size} or {@link #setMaxSizeBytes}) it also evicts
+ * the least recently used models until the directory fits; that part, like the storage
+ * report and deletes, works on plain files too when the content store is off. Models
+ * referenced by config are pinned and never evicted or deleted:
+ * {@code gollek.server.model-cache.pinned}, {@code gollek.server.ready.models} and
+ * {@code gguf.provider.prewarm.models}.
+ *
+ * <p>Last use is what this process saw: {@link #touch} on every request and load, falling
+ * back to the file's modification time for models not used since startup. Access times
+ * are not trusted, as most mounts do not keep them and hashing a file updates them. A
+ * model a provider has loaded is never evicted or pruned, nor is the blob its file
+ * points to.
+ */
+@ApplicationScoped
+public class ModelCache {
+
+    private static final Logger LOG = Logger.getLogger(ModelCache.class);
+
+    static final String BLOBS = ".blobs";
+    private static final String PREFIX = "sha256-";
+    private static final Set<String> MODEL_EXTENSIONS = Set.of(".gguf", ".safetensors", ".bin", ".onnx");
+
+    /** One blob and the aliases that point at it. */
+    public record Entry(String digest, long sizeBytes, List<String> aliases, boolean pinned) {
+    }
+
+    /** One model file as the storage report shows it. */
+    public record FileUsage(String path, @JsonProperty("size_bytes") long sizeBytes,
+            @JsonProperty("last_used") Instant lastUsed, String digest, boolean pinned) {
+    }
+
+    /** What a {@link #gc()} run did. */
+    public record GcResult(int deduplicated, int evictedAliases, int deletedBlobs, long freedBytes) {
+    }
+
+    @ConfigProperty(name = "gollek.server.model-cache.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.model-cache.dir", defaultValue = "${gguf.provider.model.base-path:${user.home}/.gollek/models/gguf}")
+    String dir;
+
+    /** Upper bound on model bytes before unpinned models are evicted; 0 for none. */
+    @ConfigProperty(name = "gollek.server.model-cache.max-size", defaultValue = "0")
+    volatile long maxSizeBytes;
+
+    @ConfigProperty(name = "gollek.server.model-cache.pinned")
+    Optional<List<String>> pinned;
+
+    @ConfigProperty(name = "gollek.server.ready.models")
+    Optional<List<String>> readyModels;
+
+    @ConfigProperty(name = "gguf.provider.prewarm.models")
+    Optional<List<String>> prewarmModels;
+
+    @Inject
+    ModelBackends modelBackends;
+
+    /** Last use per model name or absolute path, lower case, since startup. */
+    private final Map<String, Instant> used = new ConcurrentHashMap<>();
+
+    void onStart(@Observes StartupEvent event) {
+        if (enabled) {
+            // hashing a large model directory takes minutes; startup does not wait for it
+            Thread.ofVirtual().name("gollek-model-cache").start(() -> {
+                try {
+                    int moved = adopt();
+                    LOG.infof("Model cache at %s: %d files moved into the content store", root(), moved);
+                } catch (IOException e) {
+                    LOG.warnf("Model cache could not scan %s: %s", root(), e.getMessage());
+                }
+            });
+        }
+    }
+
+    public boolean isEnabled() {
+        return enabled;
+    }
+
+    public long maxSizeBytes() {
+        return maxSizeBytes;
+    }
+
+    /** Changes the eviction bound until restart; 0 turns eviction off. */
+    public void setMaxSizeBytes(long maxSizeBytes) {
+        if (maxSizeBytes < 0) {
+            throw new IllegalArgumentException("max size must be >= 0");
+        }
+        this.maxSizeBytes = maxSizeBytes;
+    }
+
+    /** Records a use of a model, by name or file path, for the LRU order. */
+    public void touch(String model) {
+        if (model != null && !model.isBlank()) {
+            used.put(key(model), Instant.now());
+        }
+    }
+
+    /** After a pull: adopts the new file and enforces the size bound. */
+    public void afterPull() throws IOException {
+        if (enabled) {
+            adopt();
+        }
+        if (maxSizeBytes > 0) {
+            gc();
+        }
+    }
+
+    public Path root() {
+        return Path.of(dir);
+    }
+
+    /** Bytes the model files take, counting a shared blob once. */
+    public synchronized long usedBytes() throws IOException {
+        long total = 0;
+        for (Path storage : aliases().keySet()) {
+            total += Files.size(storage);
+        }
+        return total;
+    }
+
+    /**
+     * Moves every regular model file under the cache directory into the blob store and
+     * links it back, so a second copy of the same content costs no disk. Returns how many
+     * files were moved or deduplicated.
+     *
+     * <p>Files are hashed without holding the cache, so reports, deletes and gc runs are
+     * not stuck behind it; a file whose size or modification time changed while it was
+     * hashed is left for the next run.
+     */
+    public int adopt() throws IOException {
+        Path root = root();
+        if (!Files.isDirectory(root)) {
+            return 0;
+        }
+        Path blobs = Files.createDirectories(root.resolve(BLOBS));
+        List<Path> files;
+        synchronized (this) {
+            try (Stream<Path> walk = Files.walk(root)) {
+                files = walk.filter(p -> !p.startsWith(blobs) && isModelFile(p)
+                        && Files.isRegularFile(p, LinkOption.NOFOLLOW_LINKS)).toList();
+            }
+            List<Path> unlinked = new ArrayList<>();
+            for (Path file : files) {
+                if (blobOf(file).isEmpty()) {
+                    unlinked.add(file); // otherwise already a hard link into the store
+                }
+            }
+            files = unlinked;
+        }
+        int moved = 0;
+        for (Path file : files) {
+            BasicFileAttributes hashed;
+            String digest;
+            try {
+                hashed = Files.readAttributes(file, BasicFileAttributes.class, LinkOption.NOFOLLOW_LINKS);
+                digest = sha256(file);
+            } catch (NoSuchFileException e) {
+                continue; // deleted since the scan
+            }
+            synchronized (this) {
+                if (adopt(file, hashed, blobs.resolve(PREFIX + digest))) {
+                    moved++;
+                }
+            }
+        }
+        return moved;
+    }
+
+    private boolean adopt(Path file, BasicFileAttributes hashed, Path blob) throws IOException {
+        if (!Files.isRegularFile(file, LinkOption.NOFOLLOW_LINKS) || blobOf(file).isPresent()) {
+            return false; // deleted or adopted meanwhile
+        }
+        BasicFileAttributes now = Files.readAttributes(file, BasicFileAttributes.class, LinkOption.NOFOLLOW_LINKS);
+        if (now.size() != hashed.size() || !now.lastModifiedTime().equals(hashed.lastModifiedTime())) {
+            LOG.debugf("Model cache: %s changed while hashing; adopting it later", file);
+            return false;
+        }
+        if (Files.exists(blob)) {
+            Files.delete(file);
+            LOG.infof("Model cache: %s duplicates %s", root().relativize(file), blob.getFileName());
+        } else {
+            Files.move(file, blob, StandardCopyOption.ATOMIC_MOVE);
+        }
+        link(file, blob);
+        return true;
+    }
+
+    /** Blobs with their aliases, largest first. */
+    public synchronized List<Entry> list() throws IOException {
+        Map<Path, List<Path>> aliases = aliases();
+        Set<String> pins = pins();
+        List<Entry> entries = new ArrayList<>();
+        for (Path blob : blobs()) {
+            List<Path> links = aliases.getOrDefault(blob, List.of());
+            entries.add(new Entry(blob.getFileName().toString(), Files.size(blob),
+                    links.stream().map(this::relative).sorted().toList(),
+                    links.stream().anyMatch(p -> isPinned(p, pins))));
+        }
+        entries.sort(Comparator.comparingLong(Entry::sizeBytes).reversed());
+        return entries;
+    }
+
+    /**
+     * Adopts new files, evicts least recently used unpinned models while the directory is
+     * over the maximum size, then deletes blobs no alias points to.
+     */
+    public GcResult gc() throws IOException {
+        return gc(enabled ? adopt() : 0);
+    }
+
+    private synchronized GcResult gc(int deduplicated) throws IOException {
+        Map<Path, List<Path>> aliases = aliases();
+        Set<String> pins = pins();
+        int evicted = 0;
+        long freed = 0;
+        if (maxSizeBytes > 0) {
+            long total = usedBytes();
+            Set<Path> loaded = loaded(aliases);
+            Map<Path, List<Path>> all = aliases;
+            List<Path> candidates = aliases.entrySet().stream()
+                    .filter(e -> e.getValue().stream().noneMatch(p -> isPinned(p, pins)))
+                    .map(Map.Entry::getKey)
+                    .filter(storage -> !loaded.contains(storage))
+                    .sorted(Comparator.comparing(storage -> lastUsed(storage, all.get(storage))))
+                    .toList();
+            for (Path storage : candidates) {
+                if (total <= maxSizeBytes) {
+                    break;
+                }
+                long size = Files.size(storage);
+                for (Path alias : aliases.get(storage)) {
+                    Files.deleteIfExists(alias);
+                    evicted++;
+                }
+                if (!blobs().contains(storage)) {
+                    freed += size; // a plain file, gone with its only alias
+                }
+                total -= size;
+            }
+            aliases = aliases();
+        }
+        int deleted = 0;
+        for (Path blob : blobs()) {
+            if (!aliases.containsKey(blob)) {
+                freed += Files.size(blob);
+                Files.delete(blob);
+                deleted++;
+            }
+        }
+        if (deduplicated + evicted + deleted > 0) {
+            LOG.infof("Model cache gc: %d adopted, %d aliases evicted, %d blobs deleted (%d bytes)",
+                    deduplicated, evicted, deleted, freed);
+        }
+        return new GcResult(deduplicated, evicted, deleted, freed);
+    }
+
+    private List<Path> blobs() throws IOException {
+        Path blobs = root().resolve(BLOBS);
+        if (!Files.isDirectory(blobs)) {
+            return List.of();
+        }
+        try (Stream<Path> list = Files.list(blobs)) {
+            return list.filter(p -> p.getFileName().toString().startsWith(PREFIX)).toList();
+        }
+    }
+
+    /**
+     * Blob to the aliases pointing at it, and each plain model file to itself; dangling
+     * symbolic links are removed on the way.
+     */
+    private Map<Path, List<Path>> aliases() throws IOException {
+        Path root = root();
+        Path blobs = root.resolve(BLOBS);
+        Map<Path, List<Path>> aliases = new HashMap<>();
+        if (!Files.isDirectory(root)) {
+            return aliases;
+        }
+        List<Path> candidates;
+        try (Stream<Path> walk = Files.walk(root)) {
+            candidates = walk.filter(p -> !p.startsWith(blobs) && isModelFile(p)).toList();
+        }
+        for (Path alias : candidates) {
+            Optional<Path> blob = blobOf(alias);
+            if (blob.isPresent()) {
+                aliases.computeIfAbsent(blob.get(), b -> new ArrayList<>()).add(alias);
+            } else if (Files.isSymbolicLink(alias)) {
+                if (!Files.exists(alias)) {
+                    Files.deleteIfExists(alias);
+                }
+            } else if (Files.isRegularFile(alias)) {
+                aliases.put(alias, List.of(alias));
+            }
+        }
+        return aliases;
+    }
+
+    /** The blob {@code alias} points to, by symbolic link or shared inode. */
+    private Optional<Path> blobOf(Path alias) throws IOException {
+        Path blobs = root().resolve(BLOBS).toAbsolutePath().normalize();
+        if (Files.isSymbolicLink(alias)) {
+            Path target = Files.readSymbolicLink(alias);
+            Path resolved = alias.toAbsolutePath().getParent().resolve(target).normalize();
+            return resolved.startsWith(blobs) && Files.exists(resolved) ? Optional.of(root().resolve(BLOBS)
+                    .resolve(resolved.getFileName())) : Optional.empty();
+        }
+        Object key = Files.readAttributes(alias, BasicFileAttributes.class).fileKey();
+        if (key == null) {
+            return Optional.empty();
+        }
+        for (Path blob : blobs()) {
+            if (key.equals(Files.readAttributes(blob, BasicFileAttributes.class).fileKey())) {
+                return Optional.of(blob);
+            }
+        }
+        return Optional.empty();
+    }
+
+    private static void link(Path alias, Path blob) throws IOException {
+        try {
+            Files.createSymbolicLink(alias, alias.toAbsolutePath().getParent().relativize(blob.toAbsolutePath()));
+        } catch (UnsupportedOperationException | IOException e) {
+            // e.g. Windows without the symlink privilege
+            Files.createLink(alias, blob);
+        }
+    }
+
+    private Set<String> pins() {
+        Set<String> pins = new HashSet<>();
+        Stream.of(pinned, readyModels, prewarmModels)
+                .forEach(list -> list.orElse(List.of()).forEach(m -> pins.add(m.trim().toLowerCase(Locale.ROOT))));
+        return pins;
+    }
+
+    /** Whether an alias is the file of a pinned model: its path or name with or without extension. */
+    boolean isPinned(Path alias, Set<String> pins) {
+        String relative = root().relativize(alias).toString().replace('\\', '/').toLowerCase(Locale.ROOT);
+        String name = alias.getFileName().toString().toLowerCase(Locale.ROOT);
+        int dot = name.lastIndexOf('.');
+        return pins.contains(relative) || pins.contains(name) || (dot > 0 && pins.contains(name.substring(0, dot)));
+    }
+
+    private static boolean isModelFile(Path path) {
+        String name = path.getFileName().toString().toLowerCase(Locale.ROOT);
+        return MODEL_EXTENSIONS.stream().anyMatch(name::endsWith);
+    }
+
+    /** When any alias of the model was last used, or else when its file was written. */
+    private Instant lastUsed(Path storage, List<Path> aliases) {
+        Instant last;
+        try {
+            last = Files.getLastModifiedTime(storage).toInstant();
+        } catch (IOException e) {
+            last = Instant.EPOCH;
+        }
+        for (Path alias : aliases) {
+            for (String key : keys(alias)) {
+                Instant at = used.get(key);
+                if (at != null && at.isAfter(last)) {
+                    last = at;
+                }
+            }
+        }
+        return last;
+    }
+
+    /**
+     * Storage (blob or plain file) of every model a provider has loaded, matched by the
+     * file the provider reports or else by model name.
+     */
+    private Set<Path> loaded(Map<Path, List<Path>> aliases) throws IOException {
+        Set<Path> loaded = new HashSet<>();
+        if (modelBackends == null) {
+            return loaded;
+        }
+        Set<String> names = new HashSet<>();
+        for (Map<String, Object> model : modelBackends.snapshot().models()) {
+            if (model.get("path") instanceof String path && !path.isBlank()) {
+                Path file = Path.of(path);
+                if (Files.exists(file)) {
+                    Path storage = blobOf(file).orElse(file);
+                    aliases.keySet().stream().filter(s -> sameFile(s, storage)).forEach(loaded::add);
+                }
+            }
+            if (model.get("model") != null) {
+                names.add(key(String.valueOf(model.get("model"))));
+            }
+        }
+        aliases.forEach((storage, links) -> {
+            if (links.stream().anyMatch(alias -> keys(alias).stream().anyMatch(names::contains))) {
+                loaded.add(storage);
+            }
+        });
+        return loaded;
+    }
+
+    private static boolean sameFile(Path a, Path b) {
+        return a.toAbsolutePath().normalize().equals(b.toAbsolutePath().normalize());
+    }
+
+    /** What a model may be called: its relative or absolute path, or file name with or without extension. */
+    private List<String> keys(Path alias) {
+        String name = alias.getFileName().toString().toLowerCase(Locale.ROOT);
+        int dot = name.lastIndexOf('.');
+        List<String> keys = new ArrayList<>(List.of(key(relative(alias)),
+                key(alias.toAbsolutePath().normalize().toString()), name));
+        if (dot > 0) {
+            keys.add(name.substring(0, dot));
+        }
+        return keys;
+    }
+
+    private static String key(String model) {
+        String key = model.trim();
+        try {
+            if (Path.of(key).isAbsolute()) {
+                key = Path.of(key).normalize().toString();
+            }
+        } catch (InvalidPathException e) {
+            // a model name such as hf:owner/repo on Windows
+        }
+        return key.replace('\\', '/').toLowerCase(Locale.ROOT);
+    }
+
+    static String sha256(Path file) throws IOException {
+        MessageDigest digest;
+        try {
+            digest = MessageDigest.getInstance("SHA-256");
+        } catch (NoSuchAlgorithmException e) {
+            throw new IllegalStateException(e);
+        }
+        try (InputStream in = new DigestInputStream(Files.newInputStream(file), digest)) {
+            in.transferTo(OutputStream.nullOutputStream());
+        }
+        return HexFormat.of().formatHex(digest.digest());
+    }
+
+    /** Every model file with its size, last use and blob, most recently used first. */
+    public synchronized List<FileUsage> files() throws IOException {
+        Set<String> pins = pins();
+        List<FileUsage> files = new ArrayList<>();
+        for (var entry : aliases().entrySet()) {
+            Path storage = entry.getKey();
+            String digest = storage.getFileName().toString().startsWith(PREFIX)
+                    && storage.getParent().getFileName().toString().equals(BLOBS)
+                    ? storage.getFileName().toString() : null;
+            for (Path alias : entry.getValue()) {
+                files.add(new FileUsage(relative(alias), Files.size(storage), lastUsed(storage, entry.getValue()),
+                        digest, isPinned(alias, pins)));
+            }
+        }
+        files.sort(Comparator.comparing(FileUsage::lastUsed).reversed());
+        return files;
+    }
+
+    /**
+     * Deletes one model file, given relative to the cache directory, and its blob once no
+     * other alias shares it. Returns the bytes freed.
+     *
+     * @throws IllegalArgumentException if the path is not a model file in the directory
+     * @throws IllegalStateException if the model is pinned
+     */
+    public synchronized long delete(String path) throws IOException {
+        Path root = root().toAbsolutePath().normalize();
+        Path file = root.resolve(path).normalize();
+        if (!file.startsWith(root) || file.startsWith(root.resolve(BLOBS)) || !isModelFile(file)
+                || !Files.exists(file, LinkOption.NOFOLLOW_LINKS)) {
+            throw new IllegalArgumentException("no model file " + path);
+        }
+        if (isPinned(root().resolve(root.relativize(file)), pins())) {
+            throw new IllegalStateException(path + " is pinned by config");
+        }
+        Optional<Path> blob = blobOf(file);
+        long size = Files.size(file);
+        Files.delete(file);
+        if (blob.isPresent() && !aliases().containsKey(blob.get())) {
+            Files.delete(blob.get());
+            return size;
+        }
+        return blob.isPresent() ? 0 : size;
+    }
+
+    /** Deletes unpinned, unloaded model files not used within {@code unusedFor}; returns their paths. */
+    public synchronized List<String> prune(Duration unusedFor) throws IOException {
+        Instant cutoff = Instant.now().minus(unusedFor);
+        Map<Path, List<Path>> aliases = aliases();
+        Set<String> loaded = new HashSet<>();
+        for (Path storage : loaded(aliases)) {
+            aliases.get(storage).forEach(alias -> loaded.add(relative(alias)));
+        }
+        List<String> deleted = new ArrayList<>();
+        for (FileUsage file : files()) {
+            if (!file.pinned() && !loaded.contains(file.path()) && file.lastUsed().isBefore(cutoff)) {
+                delete(file.path());
+                deleted.add(file.path());
+            }
+        }
+        return deleted;
+    }
+
+    /** Size of the volume holding the cache directory. */
+    public Map<String, Object> disk() throws IOException {
+        FileStore store = Files.getFileStore(Files.isDirectory(root()) ? root() : Path.of("."));
+        Map<String, Object> disk = new LinkedHashMap<>();
+        disk.put("total_bytes", store.getTotalSpace());
+        disk.put("usable_bytes", store.getUsableSpace());
+        return disk;
+    }
+
+    private String relative(Path alias) {
+        return root().relativize(alias).toString().replace('\\', '/');
+    }
+
+    /** Summary for the admin endpoint. */
+    public synchronized Map<String, Object> describe() throws IOException {
+        List<Entry> entries = list();
+        Map<String, Object> out = new LinkedHashMap<>();
+        out.put("dir", root().toString());
+        out.put("total_bytes", entries.stream().mapToLong(Entry::sizeBytes).sum());
+        out.put("max_bytes", maxSizeBytes);
+        out.put("blobs", entries);
+        return out;
+    }
+}
@@ -0,0 +1,115 @@
+package tech.kayys.gollek.server.models;
+
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.Locale;
+import java.util.Set;
+
+/**
+ * Capability flags for a model, derived from its GGUF metadata where available, so clients
+ * can pick a model for a task (chat, vision, embeddings, reranking, tool calling).
+ */
+@JsonInclude(JsonInclude.Include.NON_NULL)
+public record ModelCapabilities(
+        boolean chat,
+        boolean vision,
+        @JsonProperty("embeddings_only") boolean embeddingsOnly,
+        boolean reranker,
+        @JsonProperty("tool_calling") boolean toolCalling,
+        @JsonProperty("context_length") Long contextLength,
+        String quantization,
+        String architecture,
+        Long parameters,
+        @JsonProperty("parameter_label") String parameterLabel) {
+
+    /** Encoder-only architectures that llama.cpp serves for embeddings, not generation. */
+    private static final Set<String> ENCODER_ARCHITECTURES = Set.of(
+            "bert", "nomic-bert", "nomic-bert-moe", "jina-bert-v2", "jina-bert-v3",
+            "t5encoder", "neo-bert", "modern-bert");
+
+    /** llama_pooling_type value for rank (reranker) heads. */
+    private static final long POOLING_RANK = 4;
+
+    /** llama_ftype names indexed by {@code general.file_type}. */
+    private static final String[] FILE_TYPES = {
+            "F32", "F16", "Q4_0", "Q4_1", null, null, null, "Q8_0", "Q5_0", "Q5_1",
+            "Q2_K", "Q3_K_S", "Q3_K_M", "Q3_K_L", "Q4_K_S", "Q4_K_M", "Q5_K_S", "Q5_K_M", "Q6_K", "IQ2_XXS",
+            "IQ2_XS", "Q2_K_S", "IQ3_XS", "IQ3_XXS", "IQ1_S", "IQ4_NL", "IQ3_S", "IQ3_M", "IQ2_S", "IQ2_M",
+            "IQ4_XS", "IQ1_M", "BF16", null, null, null, "TQ1_0", "TQ2_0" };
+
+    /**
+     * Derive capabilities from a parsed header. {@code modelFile} is used to look for a
+     * sibling multimodal projector ({@code mmproj*.gguf}).
+     */
+    public static ModelCapabilities fromGguf(GgufHeader header, Path modelFile) {
+        String arch = header.string("general.architecture");
+        String name = String.valueOf(header.string("general.name")).toLowerCase(Locale.ROOT);
+        String template = header.string("tokenizer.chat_template");
+        Long pooling = arch == null ? null : header.number(arch + ".pooling_type");
+        Boolean causal = arch == null ? null : header.bool(arch + ".attention.causal");
+
+        boolean reranker = (pooling != null && pooling == POOLING_RANK) || name.contains("rerank");
+        boolean embeddingsOnly = reranker
+                || (arch != null && ENCODER_ARCHITECTURES.contains(arch))
+                || Boolean.FALSE.equals(causal);
+        boolean vision = Boolean.TRUE.equals(header.bool("clip.has_vision_encoder"))
+                || hasProjector(modelFile);
+        boolean toolCalling = template != null && (template.contains("tools") || template.contains("tool_call"));
+
+        long parameters = header.parameterCount();
+        String label = header.string("general.size_label");
+        return new ModelCapabilities(
+                !embeddingsOnly && template != null,
+                vision,
+                embeddingsOnly,
+                reranker,
+                toolCalling,
+                arch == null ? null : header.number(arch + ".context_length"),
+                quantization(header.number("general.file_type")),
+                arch,
+                parameters > 0 ? parameters : null,
+                label != null ? label : (parameters > 0 ? parameterLabel(parameters) : null));
+    }
+
+    static String quantization(Long fileType) {
+        if (fileType == null || fileType < 0 || fileType >= FILE_TYPES.length) {
+            return null;
+        }
+        return FILE_TYPES[fileType.intValue()];
+    }
+
+    static String parameterLabel(long parameters) {
+        if (parameters >= 1_000_000_000L) {
+            return String.format(Locale.ROOT, "%.1fB", parameters / 1e9);
+        }
+        return String.format(Locale.ROOT, "%.0fM", parameters / 1e6);
+    }
+
+    private static boolean hasProjector(Path modelFile) {
+        Path dir = modelFile == null ? null : modelFile.getParent();
+        if (dir == null || !Files.isDirectory(dir)) {
+            return false;
+        }
+        try (var files = Files.list(dir)) {
+            return files.map(p -> p.getFileName().toString().toLowerCase(Locale.ROOT))
+                    .anyMatch(f -> f.contains("mmproj") && f.endsWith(".gguf"));
+        } catch (Exception e) {
+            return false;
+        }
+    }
+
+    /** True when the model has the named capability (chat, vision, embeddings, reranker, tools). */
+    public boolean has(String capability) {
+        return switch (capability.toLowerCase(Locale.ROOT)) {
+            case "chat" -> chat;
+            case "vision" -> vision;
+            case "embeddings", "embedding", "embeddings_only" -> embeddingsOnly;
+            case "reranker", "rerank" -> reranker;
+            case "tools", "tool_calling" -> toolCalling;
+            default -> false;
+        };
+    }
+}
@@ -0,0 +1,121 @@
+package tech.kayys.gollek.server.models;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import org.eclipse.microprofile.config.ConfigProvider;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.sdk.model.ModelResolver;
+import tech.kayys.gollek.spi.model.ModelInfo;
+
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.Locale;
+import java.util.Map;
+import java.util.Optional;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Resolves {@link ModelCapabilities} and {@link KvCacheEstimate}s for listed models. GGUF
+ * headers are parsed once per file and cached until the file's size or modification time
+ * changes; other formats fall back to whatever the SDK reported in {@link ModelInfo}.
+ */
+@ApplicationScoped
+public class ModelCapabilityService {
+
+    private static final Logger LOG = Logger.getLogger(ModelCapabilityService.class);
+
+    /** Context the KV estimate is computed for; matches the llama.cpp runner's default. */
+    @ConfigProperty(name = "gguf.provider.max-context-tokens", defaultValue = "4096")
+    int contextSize;
+
+    private static final String CHAT_TEMPLATE_PREFIX = "gguf.provider.chat-template.";
+
+    private record CacheKey(Path path, long size, long modified) {
+    }
+
+    private record Derived(CacheKey key, ModelCapabilities capabilities, KvCacheEstimate kvCache, String chatTemplate) {
+    }
+
+    private final Map<Path, Derived> cache = new ConcurrentHashMap<>();
+
+    public ModelCapabilities capabilities(ModelInfo info) {
+        Derived derived = derive(info);
+        return derived != null ? derived.capabilities() : fallback(info);
+    }
+
+    /**
+     * KV cache estimate at the configured context size, or null for non-GGUF models.
+     */
+    public KvCacheEstimate kvCache(ModelInfo info) {
+        Derived derived = derive(info);
+        return derived == null ? null : derived.kvCache();
+    }
+
+    /**
+     * The Jinja chat template embedded in the model, or null when it has none or is not GGUF.
+     */
+    public String chatTemplate(ModelInfo info) {
+        Derived derived = derive(info);
+        return derived == null ? null : derived.chatTemplate();
+    }
+
+    /**
+     * The chat template a GGUF model will use before it is loaded: the configured
+     * {@code gguf.provider.chat-template."<id>"} ({@code named} or {@code custom}), else the
+     * {@code embedded} one, else the ChatML {@code fallback}. Null for other formats.
+     */
+    public Map<String, Object> chatTemplateInfo(ModelInfo info) {
+        Derived derived = derive(info);
+        if (derived == null) {
+            return null;
+        }
+        Optional<String> override = ConfigProvider.getConfig()
+                .getOptionalValue(CHAT_TEMPLATE_PREFIX + "\"" + info.getModelId() + "\"", String.class)
+                .filter(v -> !v.isBlank());
+        if (override.isPresent()) {
+            String value = override.get();
+            return value.contains("{{") || value.contains("{%")
+                    ? Map.of("source", "custom")
+                    : Map.of("source", "named", "name", value.strip().toLowerCase(Locale.ROOT));
+        }
+        return derived.chatTemplate() != null ? Map.of("source", "embedded")
+                : Map.of("source", "fallback", "name", "chatml");
+    }
+
+    private Derived derive(ModelInfo info) {
+        Path file = ModelResolver.extractPath(info).filter(ModelCapabilityService::isGguf).orElse(null);
+        if (file == null) {
+            return null;
+        }
+        try {
+            CacheKey key = new CacheKey(file, Files.size(file), Files.getLastModifiedTime(file).toMillis());
+            Derived cached = cache.get(file);
+            if (cached != null && cached.key().equals(key)) {
+                return cached;
+            }
+            GgufHeader header = GgufHeader.read(file);
+            Derived derived = new Derived(key, ModelCapabilities.fromGguf(header, file),
+                    KvCacheEstimate.fromGguf(header, contextSize), header.string("tokenizer.chat_template"));
+            cache.put(file, derived);
+            return derived;
+        } catch (Exception e) {
+            LOG.debugf("Could not read GGUF metadata from %s: %s", file, e.getMessage());
+            return null;
+        }
+    }
+
+    private static ModelCapabilities fallback(ModelInfo info) {
+        String arch = info.getArchitecture();
+        boolean embeddingsOnly = info.getEmbeddingSize() != null && info.getOutputTokenLimit() == null
+                && arch != null && arch.toLowerCase(Locale.ROOT).contains("bert");
+        return new ModelCapabilities(!embeddingsOnly, false, embeddingsOnly, false, false,
+                info.getContextLength(), info.getQuantization(), arch, null, info.getParameterCount());
+    }
+
+    private static boolean isGguf(Path path) {
+        return path.getFileName() != null
+                && path.getFileName().toString().toLowerCase(Locale.ROOT).endsWith(".gguf")
+                && Files.isRegularFile(path);
+    }
+}
@@ -0,0 +1,7 @@
+package tech.kayys.gollek.server.models;
+
+/**
+ * CDI event fired after a model was hot-reloaded with new weights.
+ */
+public record ModelReloaded(String model) {
+}
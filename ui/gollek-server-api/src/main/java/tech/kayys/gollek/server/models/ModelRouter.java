@@ -0,0 +1,135 @@
+package tech.kayys.gollek.server.models;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonProperty;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import tech.kayys.gollek.sdk.model.ModelListRequest;
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.spi.model.ModelInfo;
+
+import java.util.ArrayList;
+import java.util.Comparator;
+import java.util.List;
+import java.util.Optional;
+
+/**
+ * Picks a concrete model for requests that ask for {@code model: "auto"}. Candidates are
+ * the runnable models the SDK reports; they are filtered by the client's hints and the
+ * largest model that satisfies them wins, larger context breaking ties.
+ *
+ * <p>Latency is estimated from parameter count: {@code gollek.server.router.ms-per-billion-params}
+ * milliseconds per generated token for every billion parameters.
+ */
+@ApplicationScoped
+public class ModelRouter {
+
+    public static final String AUTO = "auto";
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    ModelCapabilityService capabilityService;
+
+    @ConfigProperty(name = "gollek.server.router.ms-per-billion-params", defaultValue = "20")
+    double msPerBillionParams;
+
+    @ConfigProperty(name = "gollek.server.router.max-candidates", defaultValue = "200")
+    int maxCandidates;
+
+    /**
+     * Optional routing hints. {@code max_latency} is the acceptable estimated per-token
+     * latency in milliseconds; {@code min_context} is a context length in tokens.
+     */
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public static record Hints(
+            @JsonProperty("needs_vision") Boolean needsVision,
+            @JsonProperty("needs_tools") Boolean needsTools,
+            @JsonProperty("max_latency") Integer maxLatency,
+            @JsonProperty("min_context") Integer minContext) {
+
+        public static final Hints NONE = new Hints(null, null, null, null);
+    }
+
+    /** The routing decision, echoed back to the client. */
+    public static record Selection(
+            String requested,
+            String selected,
+            String reason,
+            @JsonProperty("candidates_considered") int candidatesConsidered,
+            ModelCapabilities capabilities) {
+    }
+
+    public static boolean isAuto(String model) {
+        return model != null && AUTO.equalsIgnoreCase(model.trim());
+    }
+
+    /**
+     * Select a model for the hints, or empty when no runnable model satisfies them.
+     */
+    public Optional<Selection> select(Hints hints) throws Exception {
+        Hints h = hints == null ? Hints.NONE : hints;
+        List<ModelInfo> models = sdkProvider.getSdk().listModels(ModelListRequest.builder()
+                .runnableOnly(true)
+                .limit(maxCandidates)
+                .dedupe(true)
+                .build());
+        List<Candidate> candidates = new ArrayList<>();
+        for (ModelInfo model : models) {
+            candidates.add(new Candidate(model.getModelId(), capabilityService.capabilities(model)));
+        }
+        return choose(candidates, h, msPerBillionParams);
+    }
+
+    record Candidate(String modelId, ModelCapabilities capabilities) {
+    }
+
+    static Optional<Selection> choose(List<Candidate> candidates, Hints h, double msPerBillionParams) {
+        List<Candidate> eligible = candidates.stream()
+                .filter(c -> c.modelId() != null && c.capabilities() != null)
+                .filter(c -> !c.capabilities().embeddingsOnly())
+                .filter(c -> !Boolean.TRUE.equals(h.needsVision()) || c.capabilities().vision())
+                .filter(c -> !Boolean.TRUE.equals(h.needsTools()) || c.capabilities().toolCalling())
+                .filter(c -> h.minContext() == null || (c.capabilities().contextLength() != null
+                        && c.capabilities().contextLength() >= h.minContext()))
+                .filter(c -> h.maxLatency() == null || estimatedMsPerToken(c.capabilities(), msPerBillionParams)
+                        .map(ms -> ms <= h.maxLatency()).orElse(false))
+                .toList();
+        return eligible.stream()
+                .max(Comparator.comparingLong((Candidate c) -> orZero(c.capabilities().parameters()))
+                        .thenComparingLong(c -> orZero(c.capabilities().contextLength())))
+                .map(c -> new Selection(AUTO, c.modelId(), reason(c, h, msPerBillionParams), candidates.size(),
+                        c.capabilities()));
+    }
+
+    static Optional<Double> estimatedMsPerToken(ModelCapabilities caps, double msPerBillionParams) {
+        Long params = caps.parameters();
+        return params == null ? Optional.empty() : Optional.of(params / 1e9 * msPerBillionParams);
+    }
+
+    private static String reason(Candidate c, Hints h, double msPerBillionParams) {
+        List<String> parts = new ArrayList<>();
+        parts.add("largest eligible model");
+        if (Boolean.TRUE.equals(h.needsVision())) {
+            parts.add("vision");
+        }
+        if (Boolean.TRUE.equals(h.needsTools())) {
+            parts.add("tool calling");
+        }
+        if (h.minContext() != null) {
+            parts.add("context " + c.capabilities().contextLength() + " >= " + h.minContext());
+        }
+        if (h.maxLatency() != null) {
+            estimatedMsPerToken(c.capabilities(), msPerBillionParams).ifPresent(ms -> parts.add(
+                    String.format(java.util.Locale.ROOT, "~%.0f ms/token <= %d", ms, h.maxLatency())));
+        }
+        return String.join("; ", parts);
+    }
+
+    private static long orZero(Long v) {
+        return v == null ? 0L : v;
+    }
+}
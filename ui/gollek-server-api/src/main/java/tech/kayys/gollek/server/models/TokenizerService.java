@@ -0,0 +1,83 @@
+package tech.kayys.gollek.server.models;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.Any;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.TokenizingProvider;
+
+import java.util.List;
+import java.util.Optional;
+import java.util.function.Function;
+
+/**
+ * Model tokenizers for the tokenize/detokenize endpoints, served by the first provider
+ * that can tokenize and knows the model. Requests without a model use the first
+ * configured ({@code gguf.provider.prewarm.models}) one, like a single-model server.
+ */
+@ApplicationScoped
+public class TokenizerService {
+
+    @Inject
+    @Any
+    Instance<LLMProvider> providers;
+
+    @ConfigProperty(name = "gguf.provider.prewarm.models")
+    Optional<List<String>> configuredModels;
+
+    public int[] tokenize(String model, String text, boolean addSpecial) {
+        return apply(model, tokenizer -> tokenizer.tokenize(resolve(model), text, addSpecial));
+    }
+
+    public String detokenize(String model, int[] tokens) {
+        return apply(model, tokenizer -> tokenizer.detokenize(resolve(model), tokens));
+    }
+
+    public String tokenPiece(String model, int token) {
+        return apply(model, tokenizer -> tokenizer.tokenPiece(resolve(model), token));
+    }
+
+    /** The first configured model, used when a request names none. */
+    public Optional<String> defaultModel() {
+        return configuredModels.orElse(List.of()).stream().findFirst();
+    }
+
+    private String resolve(String model) {
+        if (model != null && !model.isBlank()) {
+            return model;
+        }
+        return defaultModel().orElseThrow(() -> new IllegalArgumentException("model required"));
+    }
+
+    /**
+     * @throws IllegalArgumentException if no tokenizing provider knows the model
+     * @throws UnsupportedOperationException if no provider can tokenize
+     */
+    private <T> T apply(String model, Function<TokenizingProvider, T> action) {
+        String modelId = resolve(model);
+        IllegalArgumentException unknown = null;
+        boolean any = false;
+        for (LLMProvider provider : providers) {
+            if (!(provider instanceof TokenizingProvider tokenizer)) {
+                continue;
+            }
+            any = true;
+            if (!provider.supports(modelId, null)) {
+                continue;
+            }
+            try {
+                return action.apply(tokenizer);
+            } catch (IllegalArgumentException e) {
+                unknown = e;
+            }
+        }
+        if (!any) {
+            throw new UnsupportedOperationException("No provider exposes a tokenizer");
+        }
+        throw unknown != null ? unknown : new IllegalArgumentException("Unknown model: " + modelId);
+    }
+}
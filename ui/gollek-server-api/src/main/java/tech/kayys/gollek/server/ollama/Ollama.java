@@ -0,0 +1,244 @@
+package tech.kayys.gollek.server.ollama;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.databind.JsonNode;
+
+import tech.kayys.gollek.server.models.ModelCapabilities;
+import tech.kayys.gollek.server.openai.ResponseFormat;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+import tech.kayys.gollek.spi.model.ModelInfo;
+
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+
+/**
+ * Mapping between Ollama's REST API ({@code /api/generate}, {@code /api/chat},
+ * {@code /api/tags}, {@code /api/show}) and the runner's requests and responses. Ollama
+ * streams by default, one JSON object per line; the last has {@code done: true} with the
+ * token counts and durations in nanoseconds.
+ */
+public final class Ollama {
+
+    public static final String NDJSON = "application/x-ndjson";
+
+    private static final Map<String, Message.Role> ROLES = Map.of(
+            "system", Message.Role.SYSTEM,
+            "user", Message.Role.USER,
+            "assistant", Message.Role.ASSISTANT,
+            "tool", Message.Role.TOOL);
+
+    private Ollama() {
+    }
+
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public record GenerateRequest(String model, String prompt, String system, Boolean raw, Boolean stream,
+            JsonNode format, Map<String, Object> options) {
+
+        public boolean isStream() {
+            return stream == null || stream;
+        }
+    }
+
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public record ChatRequest(String model, List<ChatMessage> messages, Boolean stream, JsonNode format,
+            Map<String, Object> options) {
+
+        public boolean isStream() {
+            return stream == null || stream;
+        }
+    }
+
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public record ChatMessage(String role, String content) {
+    }
+
+    /** {@code model}, or the older {@code name}. */
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public record ShowRequest(String model, String name) {
+
+        public String modelId() {
+            return model != null && !model.isBlank() ? model : name;
+        }
+    }
+
+    public static InferenceRequest toInferenceRequest(GenerateRequest req, String requestId) {
+        requireModel(req.model());
+        var builder = InferenceRequest.builder()
+                .requestId(requestId)
+                .model(req.model())
+                .streaming(req.isStream());
+        String prompt = req.prompt() == null ? "" : req.prompt();
+        if (Boolean.TRUE.equals(req.raw())) {
+            // the runner feeds the prompt parameter as is; the message only satisfies the request
+            builder.parameter("raw", true).parameter("prompt", prompt).message(Message.user(prompt));
+        } else {
+            if (req.system() != null && !req.system().isBlank()) {
+                builder.message(Message.system(req.system()));
+            }
+            builder.message(Message.user(prompt));
+        }
+        applyOptions(builder, req.options(), req.format());
+        return builder.build();
+    }
+
+    public static InferenceRequest toInferenceRequest(ChatRequest req, String requestId) {
+        requireModel(req.model());
+        if (req.messages() == null || req.messages().isEmpty()) {
+            throw new IllegalArgumentException("messages must not be empty");
+        }
+        List<Message> messages = new ArrayList<>(req.messages().size());
+        for (ChatMessage m : req.messages()) {
+            Message.Role role = m.role() == null ? null : ROLES.get(m.role().toLowerCase(Locale.ROOT));
+            if (role == null) {
+                throw new IllegalArgumentException("unsupported message role: " + m.role());
+            }
+            messages.add(new Message(role, m.content() == null ? "" : m.content()));
+        }
+        var builder = InferenceRequest.builder()
+                .requestId(requestId)
+                .model(req.model())
+                .messages(messages)
+                .streaming(req.isStream());
+        applyOptions(builder, req.options(), req.format());
+        return builder.build();
+    }
+
+    private static void requireModel(String model) {
+        if (model == null || model.isBlank()) {
+            throw new IllegalArgumentException("model is required");
+        }
+    }
+
+    /**
+     * Ollama's {@code options} as runner parameters. {@code num_predict} of -1 or -2 leaves
+     * the runner's limit; load-time options such as {@code num_ctx} and {@code num_gpu} are
+     * ignored, as the server owns model loading.
+     */
+    static void applyOptions(InferenceRequest.Builder builder, Map<String, Object> options, JsonNode format) {
+        if (options != null) {
+            if (options.get("temperature") instanceof Number n) builder.temperature(n.doubleValue());
+            if (options.get("top_p") instanceof Number n) builder.topP(n.doubleValue());
+            if (options.get("top_k") instanceof Number n) builder.topK(n.intValue());
+            if (options.get("repeat_penalty") instanceof Number n) builder.repeatPenalty(n.doubleValue());
+            if (options.get("num_predict") instanceof Number n && n.intValue() >= 0) builder.maxTokens(n.intValue());
+            if (options.get("num_keep") instanceof Number n) builder.parameter("n_keep", n.intValue());
+            if (options.get("seed") instanceof Number n) builder.parameter("seed", n.intValue());
+            if (options.get("stop") instanceof List<?> stop) builder.parameter("stop", stop);
+            for (String name : List.of("min_p", "typical_p", "presence_penalty", "frequency_penalty",
+                    "mirostat_tau", "mirostat_eta")) {
+                if (options.get(name) instanceof Number n) builder.parameter(name, n.doubleValue());
+            }
+            if (options.get("mirostat") instanceof Number n) builder.parameter("mirostat", n.intValue());
+        }
+        if (format != null && !format.isNull()) {
+            ResponseFormat responseFormat;
+            if (format.isTextual() && "json".equalsIgnoreCase(format.asText())) {
+                responseFormat = new ResponseFormat("json_object", null, null);
+            } else if (format.isObject()) {
+                responseFormat = new ResponseFormat("json_schema", format, null);
+            } else {
+                throw new IllegalArgumentException("format must be \"json\" or a JSON schema");
+            }
+            responseFormat.check();
+            builder.jsonMode(true).grammar(responseFormat.grammar());
+        }
+    }
+
+    /** One {@code /api/generate} line; {@code done} lines add the final statistics. */
+    public static Map<String, Object> generateLine(String model, String text) {
+        Map<String, Object> line = header(model);
+        line.put("response", text == null ? "" : text);
+        line.put("done", false);
+        return line;
+    }
+
+    /** One {@code /api/chat} line carrying an assistant message or delta. */
+    public static Map<String, Object> chatLine(String model, String text) {
+        Map<String, Object> line = header(model);
+        line.put("message", Map.of("role", "assistant", "content", text == null ? "" : text));
+        line.put("done", false);
+        return line;
+    }
+
+    /** Marks {@code line} as the last, with Ollama's counts and durations. */
+    public static Map<String, Object> done(Map<String, Object> line, String doneReason, long promptTokens,
+            long outputTokens, long totalNanos) {
+        line.put("done", true);
+        line.put("done_reason", doneReason);
+        line.put("total_duration", totalNanos);
+        line.put("prompt_eval_count", promptTokens);
+        line.put("eval_count", outputTokens);
+        return line;
+    }
+
+    /** Ollama's reason for a runner finish reason: {@code stop}, {@code length} or the reason itself. */
+    public static String doneReason(InferenceResponse.FinishReason reason) {
+        return reason == null ? "stop" : reason.name().toLowerCase(Locale.ROOT);
+    }
+
+    public static String doneReason(StreamingInferenceChunk chunk) {
+        return chunk.finishReason() != null ? chunk.finishReason().toLowerCase(Locale.ROOT) : "stop";
+    }
+
+    /** An {@code /api/tags} entry. */
+    public static Map<String, Object> tag(ModelInfo model, ModelCapabilities capabilities) {
+        Map<String, Object> entry = new LinkedHashMap<>();
+        entry.put("name", model.getModelId());
+        entry.put("model", model.getModelId());
+        Instant modified = model.getUpdatedAt() != null ? model.getUpdatedAt() : model.getCreatedAt();
+        entry.put("modified_at", String.valueOf(modified != null ? modified : Instant.EPOCH));
+        entry.put("size", model.getSizeBytes() == null ? 0L : model.getSizeBytes());
+        entry.put("digest", "");
+        entry.put("details", details(model, capabilities));
+        return entry;
+    }
+
+    /** The {@code details} object of {@code /api/tags} and {@code /api/show}. */
+    public static Map<String, Object> details(ModelInfo model, ModelCapabilities capabilities) {
+        String family = capabilities != null && capabilities.architecture() != null ? capabilities.architecture()
+                : model.getArchitecture();
+        String parameterSize = capabilities != null && capabilities.parameterLabel() != null
+                ? capabilities.parameterLabel()
+                : model.getParameterCount();
+        String quantization = capabilities != null && capabilities.quantization() != null
+                ? capabilities.quantization()
+                : model.getQuantization();
+        Map<String, Object> details = new LinkedHashMap<>();
+        details.put("format", model.getFormat() == null ? "" : model.getFormat().toLowerCase(Locale.ROOT));
+        details.put("family", family == null ? "" : family);
+        details.put("families", family == null ? List.of() : List.of(family));
+        details.put("parameter_size", parameterSize == null ? "" : parameterSize);
+        details.put("quantization_level", quantization == null ? "" : quantization);
+        return details;
+    }
+
+    /** Ollama's capability names: {@code completion}, {@code vision}, {@code tools}, {@code embedding}. */
+    public static List<String> capabilities(ModelCapabilities capabilities) {
+        if (capabilities == null) {
+            return List.of("completion");
+        }
+        List<String> out = new ArrayList<>();
+        if (capabilities.embeddingsOnly()) {
+            out.add("embedding");
+        } else {
+            out.add("completion");
+        }
+        if (capabilities.vision()) out.add("vision");
+        if (capabilities.toolCalling()) out.add("tools");
+        return out;
+    }
+
+    private static Map<String, Object> header(String model) {
+        Map<String, Object> line = new LinkedHashMap<>();
+        line.put("model", model);
+        line.put("created_at", Instant.now().toString());
+        return line;
+    }
+}
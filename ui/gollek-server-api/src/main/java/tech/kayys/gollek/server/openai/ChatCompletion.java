@@ -0,0 +1,86 @@
+package tech.kayys.gollek.server.openai;
+
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import tech.kayys.gollek.server.chat.HistoryBudget;
+import tech.kayys.gollek.server.models.ModelRouter;
+
+import java.util.List;
+import java.util.Map;
+
+/**
+ * {@code chat.completion} and {@code chat.completion.chunk} response objects.
+ */
+@JsonInclude(JsonInclude.Include.NON_NULL)
+public record ChatCompletion(
+        String id,
+        String object,
+        long created,
+        String model,
+        List<Choice> choices,
+        Usage usage,
+        @JsonProperty("history_trimmed") HistoryBudget.Report historyTrimmed,
+        @JsonProperty("model_selection") ModelRouter.Selection modelSelection,
+        @JsonProperty("detected_language") String detectedLanguage) {
+
+    public ChatCompletion(String id, String object, long created, String model, List<Choice> choices, Usage usage) {
+        this(id, object, created, model, choices, usage, null, null, null);
+    }
+
+    /** The same completion under a new id, e.g. when it is served from the response cache. */
+    public ChatCompletion withId(String id, long created) {
+        return new ChatCompletion(id, object, created, model, choices, usage, historyTrimmed, modelSelection,
+                detectedLanguage);
+    }
+
+    public ChatCompletion withHistoryReport(HistoryBudget.Report report) {
+        return new ChatCompletion(id, object, created, model, choices, usage, report, modelSelection,
+                detectedLanguage);
+    }
+
+    public ChatCompletion withModelSelection(ModelRouter.Selection selection) {
+        return new ChatCompletion(id, object, created, model, choices, usage, historyTrimmed, selection,
+                detectedLanguage);
+    }
+
+    public ChatCompletion withDetectedLanguage(String language) {
+        return new ChatCompletion(id, object, created, model, choices, usage, historyTrimmed, modelSelection,
+                language);
+    }
+
+    @JsonInclude(JsonInclude.Include.NON_NULL)
+    public static record Choice(
+            int index,
+            Message message,
+            Message delta,
+            @JsonProperty("finish_reason") String finishReason,
+            Logprobs logprobs,
+            Map<String, Object> verbose) {
+
+        public Choice(int index, Message message, Message delta, String finishReason) {
+            this(index, message, delta, finishReason, null, null);
+        }
+
+        public Choice(int index, Message message, Message delta, String finishReason, Logprobs logprobs) {
+            this(index, message, delta, finishReason, logprobs, null);
+        }
+    }
+
+    /**
+     * Per-token log probabilities: {@code token}, {@code logprob}, {@code bytes} and
+     * {@code top_logprobs} for each generated token.
+     */
+    public static record Logprobs(List<Map<String, Object>> content) {
+    }
+
+    @JsonInclude(JsonInclude.Include.NON_NULL)
+    public static record Message(String role, String content) {
+    }
+
+    public static record Usage(
+            @JsonProperty("prompt_tokens") int promptTokens,
+            @JsonProperty("completion_tokens") int completionTokens,
+            @JsonProperty("total_tokens") int totalTokens) {
+    }
+}
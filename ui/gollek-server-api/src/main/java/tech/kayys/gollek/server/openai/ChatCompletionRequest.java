@@ -0,0 +1,356 @@
+package tech.kayys.gollek.server.openai;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import tech.kayys.gollek.server.chat.HistoryBudget;
+import tech.kayys.gollek.server.models.ModelRouter;
+
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Request body of {@code POST /v1/chat/completions}, following the OpenAI schema.
+ * Numeric sampling fields are boxed so that "unset" and "zero" stay distinguishable.
+ */
+@JsonIgnoreProperties(ignoreUnknown = true)
+public record ChatCompletionRequest(
+        String model,
+        List<ChatMessage> messages,
+        Double temperature,
+        @JsonProperty("top_p") Double topP,
+        @JsonProperty("top_k") Integer topK,
+        @JsonProperty("min_p") Double minP,
+        @JsonProperty("max_tokens") Integer maxTokens,
+        @JsonProperty("max_completion_tokens") Integer maxCompletionTokens,
+        @JsonProperty("presence_penalty") Double presencePenalty,
+        @JsonProperty("frequency_penalty") Double frequencyPenalty,
+        @JsonProperty("repeat_penalty") Double repeatPenalty,
+        Integer seed,
+        Object stop,
+        Boolean stream,
+        String user,
+        HistoryBudget.Options history,
+        @JsonProperty("conversation_id") String conversationId,
+        @JsonProperty("model_hints") ModelRouter.Hints modelHints,
+        @JsonProperty("response_format") ResponseFormat responseFormat,
+        @JsonProperty("max_time_ms") Long maxTimeMs,
+        @JsonProperty("logit_bias") Map<String, Double> logitBias,
+        @JsonProperty("typical_p") Double typicalP,
+        Integer mirostat,
+        @JsonProperty("mirostat_tau") Double mirostatTau,
+        @JsonProperty("mirostat_eta") Double mirostatEta,
+        Object logprobs,
+        @JsonProperty("top_logprobs") Integer topLogprobs,
+        Integer n,
+        @JsonProperty("context_overflow") String contextOverflow,
+        @JsonProperty("n_keep") Integer nKeep,
+        @JsonProperty("queue_timeout_ms") Long queueTimeoutMs,
+        @JsonProperty("prefill_timeout_ms") Long prefillTimeoutMs,
+        @JsonProperty("inter_token_timeout_ms") Long interTokenTimeoutMs) {
+
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public static record ChatMessage(String role, String content, String name) {
+    }
+
+    /** OpenAI renamed {@code max_tokens}; accept either. */
+    public Integer effectiveMaxTokens() {
+        return maxCompletionTokens != null ? maxCompletionTokens : maxTokens;
+    }
+
+    /** Copy with {@code model} replaced, e.g. after resolving {@code "auto"}. */
+    public ChatCompletionRequest withModel(String newModel) {
+        return toBuilder().model(newModel).build();
+    }
+
+    /**
+     * Alternatives per token to return log probabilities with, or null when not asked for.
+     * Accepts the chat style ({@code "logprobs": true} with {@code top_logprobs}) and the
+     * completions style ({@code "logprobs": N}).
+     */
+    public Integer logprobAlternatives() {
+        if (logprobs == null || Boolean.FALSE.equals(logprobs)) {
+            return null;
+        }
+        if (Boolean.TRUE.equals(logprobs)) {
+            return topLogprobs != null ? topLogprobs : 0;
+        }
+        if (logprobs instanceof Number n) {
+            return n.intValue();
+        }
+        throw new IllegalArgumentException("logprobs must be a boolean or a number: " + logprobs);
+    }
+
+    /**
+     * Number of choices to generate, 1 when unset.
+     *
+     * @throws IllegalArgumentException if {@code n} is outside {@code [1, max]}
+     */
+    public int choices(int max) {
+        if (n == null) {
+            return 1;
+        }
+        if (n < 1 || n > max) {
+            throw new IllegalArgumentException("n must be between 1 and " + max + ": " + n);
+        }
+        return n;
+    }
+
+    public boolean isStream() {
+        return Boolean.TRUE.equals(stream);
+    }
+
+    public static Builder builder() {
+        return new Builder();
+    }
+
+    /** A builder holding this request's values, for copies with some fields changed. */
+    public Builder toBuilder() {
+        return new Builder()
+                .model(model)
+                .messages(messages)
+                .temperature(temperature)
+                .topP(topP)
+                .topK(topK)
+                .minP(minP)
+                .maxTokens(maxTokens)
+                .maxCompletionTokens(maxCompletionTokens)
+                .presencePenalty(presencePenalty)
+                .frequencyPenalty(frequencyPenalty)
+                .repeatPenalty(repeatPenalty)
+                .seed(seed)
+                .stop(stop)
+                .stream(stream)
+                .user(user)
+                .history(history)
+                .conversationId(conversationId)
+                .modelHints(modelHints)
+                .responseFormat(responseFormat)
+                .maxTimeMs(maxTimeMs)
+                .logitBias(logitBias)
+                .typicalP(typicalP)
+                .mirostat(mirostat)
+                .mirostatTau(mirostatTau)
+                .mirostatEta(mirostatEta)
+                .logprobs(logprobs)
+                .topLogprobs(topLogprobs)
+                .n(n)
+                .contextOverflow(contextOverflow)
+                .nKeep(nKeep)
+                .queueTimeoutMs(queueTimeoutMs)
+                .prefillTimeoutMs(prefillTimeoutMs)
+                .interTokenTimeoutMs(interTokenTimeoutMs);
+    }
+
+    public static class Builder {
+        private String model;
+        private List<ChatMessage> messages;
+        private Double temperature;
+        private Double topP;
+        private Integer topK;
+        private Double minP;
+        private Integer maxTokens;
+        private Integer maxCompletionTokens;
+        private Double presencePenalty;
+        private Double frequencyPenalty;
+        private Double repeatPenalty;
+        private Integer seed;
+        private Object stop;
+        private Boolean stream;
+        private String user;
+        private HistoryBudget.Options history;
+        private String conversationId;
+        private ModelRouter.Hints modelHints;
+        private ResponseFormat responseFormat;
+        private Long maxTimeMs;
+        private Map<String, Double> logitBias;
+        private Double typicalP;
+        private Integer mirostat;
+        private Double mirostatTau;
+        private Double mirostatEta;
+        private Object logprobs;
+        private Integer topLogprobs;
+        private Integer n;
+        private String contextOverflow;
+        private Integer nKeep;
+        private Long queueTimeoutMs;
+        private Long prefillTimeoutMs;
+        private Long interTokenTimeoutMs;
+
+        private Builder() {
+        }
+
+        public Builder model(String model) {
+            this.model = model;
+            return this;
+        }
+
+        public Builder messages(List<ChatMessage> messages) {
+            this.messages = messages;
+            return this;
+        }
+
+        public Builder temperature(Double temperature) {
+            this.temperature = temperature;
+            return this;
+        }
+
+        public Builder topP(Double topP) {
+            this.topP = topP;
+            return this;
+        }
+
+        public Builder topK(Integer topK) {
+            this.topK = topK;
+            return this;
+        }
+
+        public Builder minP(Double minP) {
+            this.minP = minP;
+            return this;
+        }
+
+        public Builder maxTokens(Integer maxTokens) {
+            this.maxTokens = maxTokens;
+            return this;
+        }
+
+        public Builder maxCompletionTokens(Integer maxCompletionTokens) {
+            this.maxCompletionTokens = maxCompletionTokens;
+            return this;
+        }
+
+        public Builder presencePenalty(Double presencePenalty) {
+            this.presencePenalty = presencePenalty;
+            return this;
+        }
+
+        public Builder frequencyPenalty(Double frequencyPenalty) {
+            this.frequencyPenalty = frequencyPenalty;
+            return this;
+        }
+
+        public Builder repeatPenalty(Double repeatPenalty) {
+            this.repeatPenalty = repeatPenalty;
+            return this;
+        }
+
+        public Builder seed(Integer seed) {
+            this.seed = seed;
+            return this;
+        }
+
+        public Builder stop(Object stop) {
+            this.stop = stop;
+            return this;
+        }
+
+        public Builder stream(Boolean stream) {
+            this.stream = stream;
+            return this;
+        }
+
+        public Builder user(String user) {
+            this.user = user;
+            return this;
+        }
+
+        public Builder history(HistoryBudget.Options history) {
+            this.history = history;
+            return this;
+        }
+
+        public Builder conversationId(String conversationId) {
+            this.conversationId = conversationId;
+            return this;
+        }
+
+        public Builder modelHints(ModelRouter.Hints modelHints) {
+            this.modelHints = modelHints;
+            return this;
+        }
+
+        public Builder responseFormat(ResponseFormat responseFormat) {
+            this.responseFormat = responseFormat;
+            return this;
+        }
+
+        public Builder maxTimeMs(Long maxTimeMs) {
+            this.maxTimeMs = maxTimeMs;
+            return this;
+        }
+
+        public Builder logitBias(Map<String, Double> logitBias) {
+            this.logitBias = logitBias;
+            return this;
+        }
+
+        public Builder typicalP(Double typicalP) {
+            this.typicalP = typicalP;
+            return this;
+        }
+
+        public Builder mirostat(Integer mirostat) {
+            this.mirostat = mirostat;
+            return this;
+        }
+
+        public Builder mirostatTau(Double mirostatTau) {
+            this.mirostatTau = mirostatTau;
+            return this;
+        }
+
+        public Builder mirostatEta(Double mirostatEta) {
+            this.mirostatEta = mirostatEta;
+            return this;
+        }
+
+        public Builder logprobs(Object logprobs) {
+            this.logprobs = logprobs;
+            return this;
+        }
+
+        public Builder topLogprobs(Integer topLogprobs) {
+            this.topLogprobs = topLogprobs;
+            return this;
+        }
+
+        public Builder n(Integer n) {
+            this.n = n;
+            return this;
+        }
+
+        public Builder contextOverflow(String contextOverflow) {
+            this.contextOverflow = contextOverflow;
+            return this;
+        }
+
+        public Builder nKeep(Integer nKeep) {
+            this.nKeep = nKeep;
+            return this;
+        }
+
+        public Builder queueTimeoutMs(Long queueTimeoutMs) {
+            this.queueTimeoutMs = queueTimeoutMs;
+            return this;
+        }
+
+        public Builder prefillTimeoutMs(Long prefillTimeoutMs) {
+            this.prefillTimeoutMs = prefillTimeoutMs;
+            return this;
+        }
+
+        public Builder interTokenTimeoutMs(Long interTokenTimeoutMs) {
+            this.interTokenTimeoutMs = interTokenTimeoutMs;
+            return this;
+        }
+
+        public ChatCompletionRequest build() {
+            return new ChatCompletionRequest(model, messages, temperature, topP, topK, minP, maxTokens,
+                    maxCompletionTokens, presencePenalty, frequencyPenalty, repeatPenalty, seed, stop, stream,
+                    user, history, conversationId, modelHints, responseFormat, maxTimeMs, logitBias, typicalP,
+                    mirostat, mirostatTau, mirostatEta, logprobs, topLogprobs, n, contextOverflow, nKeep,
+                    queueTimeoutMs, prefillTimeoutMs, interTokenTimeoutMs);
+        }
+    }
+}
@@ -0,0 +1,245 @@
+package tech.kayys.gollek.server.openai;
+
+import tech.kayys.gollek.server.RequestTimeout;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.UUID;
+
+/**
+ * Maps between the OpenAI chat schema and Gollek's inference types. The chat template
+ * itself is applied by the runner, which renders {@link InferenceRequest#getMessages()}
+ * with the template embedded in the model.
+ */
+public final class ChatCompletions {
+
+    private static final Map<String, Message.Role> ROLES = Map.of(
+            "system", Message.Role.SYSTEM,
+            "developer", Message.Role.SYSTEM,
+            "user", Message.Role.USER,
+            "assistant", Message.Role.ASSISTANT,
+            "tool", Message.Role.TOOL,
+            "function", Message.Role.FUNCTION);
+
+    /** Request parameter and response metadata key for per-token log probabilities. */
+    static final String LOGPROBS = "logprobs";
+    /** Request parameter and response metadata key for the runner's debugging report. */
+    static final String VERBOSE = "verbose";
+    static final int MAX_TOP_LOGPROBS = 20;
+    /** Strategies the runner accepts for {@code context_overflow}. */
+    static final List<String> CONTEXT_OVERFLOW = List.of("error", "truncate", "shift");
+    /** Most choices one request may ask for with {@code n}. */
+    public static final int MAX_CHOICES = 16;
+
+    private ChatCompletions() {
+    }
+
+    public static String newId() {
+        return "chatcmpl-" + UUID.randomUUID().toString().replace("-", "");
+    }
+
+    public static InferenceRequest toInferenceRequest(ChatCompletionRequest req, String requestId) {
+        return toInferenceRequest(req, requestId, toMessages(req));
+    }
+
+    public static List<Message> toMessages(ChatCompletionRequest req) {
+        if (req.messages() == null || req.messages().isEmpty()) {
+            throw new IllegalArgumentException("messages must not be empty");
+        }
+        List<Message> messages = new ArrayList<>(req.messages().size());
+        for (ChatCompletionRequest.ChatMessage m : req.messages()) {
+            Message.Role role = m.role() == null ? null : ROLES.get(m.role().toLowerCase());
+            if (role == null) {
+                throw new IllegalArgumentException("unsupported message role: " + m.role());
+            }
+            messages.add(new Message(role, m.content() == null ? "" : m.content(), m.name(),
+                    null, null));
+        }
+        return messages;
+    }
+
+    public static InferenceRequest toInferenceRequest(ChatCompletionRequest req, String requestId,
+            List<Message> messages) {
+        var builder = InferenceRequest.builder()
+                .requestId(requestId)
+                .model(req.model())
+                .messages(messages)
+                .streaming(req.isStream());
+        // only forward fields the client set, so runner defaults still apply to the rest
+        if (req.temperature() != null) builder.temperature(req.temperature());
+        if (req.topP() != null) builder.topP(req.topP());
+        if (req.topK() != null) builder.topK(req.topK());
+        if (req.minP() != null) builder.parameter("min_p", req.minP());
+        if (req.effectiveMaxTokens() != null) builder.maxTokens(req.effectiveMaxTokens());
+        if (req.presencePenalty() != null) builder.parameter("presence_penalty", req.presencePenalty());
+        if (req.frequencyPenalty() != null) builder.parameter("frequency_penalty", req.frequencyPenalty());
+        if (req.repeatPenalty() != null) builder.repeatPenalty(req.repeatPenalty());
+        if (req.seed() != null) builder.parameter("seed", req.seed());
+        if (req.stop() != null) builder.parameter("stop", req.stop());
+        if (req.maxTimeMs() != null) builder.parameter(RequestTimeout.MAX_TIME, req.maxTimeMs());
+        if (req.queueTimeoutMs() != null) builder.parameter(RequestTimeout.QUEUE_TIMEOUT, req.queueTimeoutMs());
+        if (req.prefillTimeoutMs() != null) builder.parameter(RequestTimeout.PREFILL_TIMEOUT, req.prefillTimeoutMs());
+        if (req.interTokenTimeoutMs() != null) {
+            builder.parameter(RequestTimeout.INTER_TOKEN_TIMEOUT, req.interTokenTimeoutMs());
+        }
+        if (req.typicalP() != null) {
+            if (req.typicalP() <= 0 || req.typicalP() > 1) {
+                throw new IllegalArgumentException("typical_p must be in (0, 1]: " + req.typicalP());
+            }
+            builder.parameter("typical_p", req.typicalP());
+        }
+        if (req.mirostat() != null) {
+            if (req.mirostat() < 0 || req.mirostat() > 2) {
+                throw new IllegalArgumentException("mirostat must be 0 (off), 1 or 2: " + req.mirostat());
+            }
+            builder.parameter("mirostat", req.mirostat());
+        }
+        if (req.mirostatTau() != null) builder.parameter("mirostat_tau", req.mirostatTau());
+        if (req.mirostatEta() != null) builder.parameter("mirostat_eta", req.mirostatEta());
+        if (req.contextOverflow() != null) {
+            if (!CONTEXT_OVERFLOW.contains(req.contextOverflow())) {
+                throw new IllegalArgumentException("context_overflow must be one of " + CONTEXT_OVERFLOW + ": "
+                        + req.contextOverflow());
+            }
+            builder.parameter("context_overflow", req.contextOverflow());
+        }
+        if (req.nKeep() != null) builder.parameter("n_keep", req.nKeep());
+        Integer logprobs = req.logprobAlternatives();
+        if (logprobs != null) {
+            if (logprobs < 0 || logprobs > MAX_TOP_LOGPROBS) {
+                throw new IllegalArgumentException("top_logprobs must be between 0 and " + MAX_TOP_LOGPROBS + ": "
+                        + logprobs);
+            }
+            builder.parameter(LOGPROBS, logprobs);
+        }
+        if (req.logitBias() != null && !req.logitBias().isEmpty()) {
+            builder.parameter("logit_bias", checkLogitBias(req.logitBias()));
+        }
+        if (req.responseFormat() != null) {
+            req.responseFormat().check();
+            if (req.responseFormat().isJson()) {
+                builder.jsonMode(true).grammar(req.responseFormat().grammar());
+            }
+            if (req.responseFormat().isVerbose()) {
+                builder.parameter(VERBOSE, true);
+                if (logprobs == null) {
+                    builder.parameter(LOGPROBS, 0);
+                }
+            }
+        }
+        return builder.build();
+    }
+
+    /** OpenAI's limits: token ids as keys, biases in {@code [-100, 100]}. */
+    static Map<String, Double> checkLogitBias(Map<String, Double> logitBias) {
+        for (Map.Entry<String, Double> entry : logitBias.entrySet()) {
+            try {
+                Integer.parseInt(entry.getKey().trim());
+            } catch (NumberFormatException e) {
+                throw new IllegalArgumentException("logit_bias keys must be token ids: " + entry.getKey());
+            }
+            if (entry.getValue() == null || entry.getValue() < -100 || entry.getValue() > 100) {
+                throw new IllegalArgumentException("logit_bias values must be between -100 and 100: "
+                        + entry.getKey() + "=" + entry.getValue());
+            }
+        }
+        return logitBias;
+    }
+
+    /**
+     * One runner request per choice. Choices after the first get their own request id and,
+     * for seeded requests, the seed plus their index, so they neither share cancellation nor
+     * sample the same tokens. Submitted together, the runner decodes them as parallel
+     * sequences of one batch.
+     */
+    public static List<InferenceRequest> choiceRequests(InferenceRequest request, int n) {
+        List<InferenceRequest> requests = new ArrayList<>(n);
+        requests.add(request);
+        for (int i = 1; i < n; i++) {
+            var builder = request.toBuilder().requestId(request.getRequestId() + "-" + i);
+            if (request.getParameters().get("seed") instanceof Number seed && seed.longValue() >= 0) {
+                builder.parameter("seed", seed.intValue() + i);
+            }
+            requests.add(builder.build());
+        }
+        return requests;
+    }
+
+    public static ChatCompletion toChatCompletion(String id, String model, InferenceResponse resp) {
+        return toChatCompletion(id, model, List.of(resp));
+    }
+
+    /**
+     * A completion with one choice per response, in order. The prompt is counted once in
+     * {@code usage}, as all choices share it.
+     */
+    public static ChatCompletion toChatCompletion(String id, String model, List<InferenceResponse> responses) {
+        List<ChatCompletion.Choice> choices = new ArrayList<>(responses.size());
+        int completionTokens = 0;
+        for (int i = 0; i < responses.size(); i++) {
+            InferenceResponse resp = responses.get(i);
+            choices.add(new ChatCompletion.Choice(i, new ChatCompletion.Message("assistant", resp.getContent()), null,
+                    finishReason(resp.getFinishReason()), logprobs(resp.getMetadata()), verbose(resp.getMetadata())));
+            completionTokens += resp.getOutputTokens();
+        }
+        InferenceResponse first = responses.get(0);
+        return new ChatCompletion(id, "chat.completion", first.getTimestamp().getEpochSecond(),
+                first.getModel() != null ? first.getModel() : model, choices,
+                new ChatCompletion.Usage(first.getInputTokens(), completionTokens,
+                        first.getInputTokens() + completionTokens));
+    }
+
+    public static ChatCompletion toChunk(String id, String model, long created, StreamingInferenceChunk chunk,
+            boolean first) {
+        return toChunk(id, model, created, chunk, first, 0);
+    }
+
+    /** A chunk of choice {@code index}; {@code first} marks that choice's first chunk. */
+    public static ChatCompletion toChunk(String id, String model, long created, StreamingInferenceChunk chunk,
+            boolean first, int index) {
+        var delta = new ChatCompletion.Message(first ? "assistant" : null, chunk.delta() == null ? "" : chunk.delta());
+        var choice = new ChatCompletion.Choice(index, null, delta, chunk.finished() ? chunkFinishReason(chunk) : null,
+                logprobs(chunk.metadata()), verbose(chunk.metadata()));
+        ChatCompletion.Usage usage = chunk.usage() == null ? null
+                : new ChatCompletion.Usage((int) chunk.usage().inputTokens(), (int) chunk.usage().outputTokens(),
+                        (int) (chunk.usage().inputTokens() + chunk.usage().outputTokens()));
+        return new ChatCompletion(id, "chat.completion.chunk", created, model, List.of(choice), usage);
+    }
+
+    /** The runner's {@code logprobs} metadata as a choice's {@code logprobs}, or null. */
+    @SuppressWarnings("unchecked")
+    static ChatCompletion.Logprobs logprobs(Map<String, Object> metadata) {
+        if (metadata == null || !(metadata.get(LOGPROBS) instanceof List<?> content)) {
+            return null;
+        }
+        return new ChatCompletion.Logprobs((List<Map<String, Object>>) content);
+    }
+
+    /** The runner's {@code verbose} report, or null; streams carry it on the final chunk. */
+    @SuppressWarnings("unchecked")
+    static Map<String, Object> verbose(Map<String, Object> metadata) {
+        if (metadata == null || !(metadata.get(VERBOSE) instanceof Map<?, ?> report)) {
+            return null;
+        }
+        return (Map<String, Object>) report;
+    }
+
+    static String finishReason(InferenceResponse.FinishReason reason) {
+        if (reason == null) {
+            return "stop";
+        }
+        return switch (reason) {
+            case TOOL_CALLS -> "tool_calls";
+            default -> reason.name().toLowerCase();
+        };
+    }
+
+    private static String chunkFinishReason(StreamingInferenceChunk chunk) {
+        return chunk.finishReason() != null ? chunk.finishReason() : "stop";
+    }
+}
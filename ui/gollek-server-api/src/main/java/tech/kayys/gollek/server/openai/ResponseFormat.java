@@ -0,0 +1,90 @@
+package tech.kayys.gollek.server.openai;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonProperty;
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.node.JsonNodeFactory;
+
+import tech.kayys.gollek.server.extract.JsonSchemaGrammar;
+import tech.kayys.gollek.server.extract.JsonSchemaValidator;
+
+import java.util.List;
+
+/**
+ * {@code response_format} of a completion request: {@code text} (default),
+ * {@code json_object}, or {@code json_schema}. The schema may be given OpenAI-style
+ * under {@code json_schema.schema} or directly as {@code schema}. {@code verbose_json}
+ * is plain text output plus, per choice, the runner's debugging report and per-token
+ * log probabilities.
+ */
+@JsonIgnoreProperties(ignoreUnknown = true)
+public record ResponseFormat(
+        String type,
+        JsonNode schema,
+        @JsonProperty("json_schema") JsonSchemaSpec jsonSchema) {
+
+    private static final ObjectMapper MAPPER = new ObjectMapper();
+
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public static record JsonSchemaSpec(String name, JsonNode schema, Boolean strict) {
+    }
+
+    public boolean isVerbose() {
+        return "verbose_json".equals(type);
+    }
+
+    public boolean isJson() {
+        return "json_object".equals(type) || "json_schema".equals(type);
+    }
+
+    /** The effective schema; {@code json_object} means "any object". */
+    public JsonNode effectiveSchema() {
+        if ("json_schema".equals(type)) {
+            if (jsonSchema != null && jsonSchema.schema() != null) {
+                return jsonSchema.schema();
+            }
+            if (schema != null) {
+                return schema;
+            }
+        }
+        return JsonNodeFactory.instance.objectNode().put("type", "object");
+    }
+
+    /**
+     * Rejects unknown types and a {@code json_schema} without a schema.
+     */
+    public void check() {
+        if (type == null || !(type.equals("text") || isJson() || isVerbose())) {
+            throw new IllegalArgumentException(
+                    "response_format.type must be text, json_object, json_schema or verbose_json");
+        }
+        if ("json_schema".equals(type) && (jsonSchema == null || jsonSchema.schema() == null) && schema == null) {
+            throw new IllegalArgumentException("response_format json_schema requires a schema");
+        }
+    }
+
+    public String grammar() {
+        return JsonSchemaGrammar.fromSchema(effectiveSchema());
+    }
+
+    /**
+     * Validates model output; returns the problems found, empty when the output conforms.
+     */
+    public List<String> validate(String output) {
+        if (!isJson()) {
+            return List.of();
+        }
+        JsonNode data;
+        try {
+            data = MAPPER.readTree(output == null ? "" : output.strip());
+        } catch (JsonProcessingException e) {
+            return List.of("output is not valid JSON: " + e.getOriginalMessage());
+        }
+        if (data == null || data.isMissingNode()) {
+            return List.of("output is empty");
+        }
+        return JsonSchemaValidator.validate(effectiveSchema(), data);
+    }
+}
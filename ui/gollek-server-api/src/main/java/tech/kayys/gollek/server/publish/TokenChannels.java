@@ -0,0 +1,214 @@
+package tech.kayys.gollek.server.publish;
+
+import io.quarkus.redis.datasource.RedisDataSource;
+import jakarta.annotation.PreDestroy;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.core.StreamingOutput;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.ByteArrayOutputStream;
+import java.io.IOException;
+import java.io.OutputStream;
+import java.net.URI;
+import java.nio.charset.StandardCharsets;
+import java.util.Locale;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.function.Consumer;
+
+/**
+ * Token streams published to a Redis or NATS channel, so several backend consumers can
+ * follow a generation without holding its HTTP connection. A streaming request names the
+ * channel with {@value #HEADER} ({@code redis:<channel>} or {@code nats:<subject>}); each
+ * event's {@code data} payload is published as one message, {@code [DONE]} included.
+ * With {@value #MODE_HEADER}{@code : only} the request is answered with 202 at once and
+ * the stream goes to the channel alone; the default, {@code tee}, also streams over SSE.
+ *
+ * <p>Off unless {@code gollek.server.publish.enabled}; channels must start with
+ * {@code gollek.server.publish.channel-prefix}, so clients cannot write to unrelated ones.
+ */
+@ApplicationScoped
+public class TokenChannels {
+
+    private static final Logger LOG = Logger.getLogger(TokenChannels.class);
+    public static final String HEADER = "X-Gollek-Publish";
+    public static final String MODE_HEADER = "X-Gollek-Publish-Mode";
+
+    /** Where a stream goes; {@code only} drops the SSE response. */
+    public record Destination(String transport, String channel, boolean only) {
+
+        @Override
+        public String toString() {
+            return transport + ":" + channel;
+        }
+    }
+
+    @ConfigProperty(name = "gollek.server.publish.enabled", defaultValue = "false")
+    boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.publish.channel-prefix", defaultValue = "gollek.")
+    String channelPrefix;
+
+    @ConfigProperty(name = "gollek.server.publish.nats.url", defaultValue = "nats://localhost:4222")
+    String natsUrl;
+
+    @Inject
+    Instance<RedisDataSource> redis;
+
+    private NatsPublisher nats;
+    private final ExecutorService detached = Executors.newCachedThreadPool(r -> {
+        Thread t = new Thread(r, "gollek-publish");
+        t.setDaemon(true);
+        return t;
+    });
+
+    /**
+     * The destination named by the headers, or null without {@value #HEADER}.
+     *
+     * @throws IllegalArgumentException if publishing is off or the destination is invalid
+     */
+    public Destination destination(String header, String mode) {
+        if (header == null || header.isBlank()) {
+            return null;
+        }
+        if (!enabled) {
+            throw new IllegalArgumentException(HEADER + " is not enabled on this server");
+        }
+        int colon = header.indexOf(':');
+        String transport = colon < 0 ? "" : header.substring(0, colon).trim().toLowerCase(Locale.ROOT);
+        String channel = colon < 0 ? "" : header.substring(colon + 1).trim();
+        if (!transport.equals("redis") && !transport.equals("nats")) {
+            throw new IllegalArgumentException(HEADER + " must be redis:<channel> or nats:<subject>");
+        }
+        if (channel.isEmpty() || channel.chars().anyMatch(Character::isWhitespace)) {
+            throw new IllegalArgumentException("invalid channel: '" + channel + "'");
+        }
+        if (!channel.startsWith(channelPrefix)) {
+            throw new IllegalArgumentException("channel must start with '" + channelPrefix + "'");
+        }
+        boolean only;
+        if (mode == null || mode.isBlank() || mode.equalsIgnoreCase("tee")) {
+            only = false;
+        } else if (mode.equalsIgnoreCase("only")) {
+            only = true;
+        } else {
+            throw new IllegalArgumentException(MODE_HEADER + " must be tee or only");
+        }
+        return new Destination(transport, channel, only);
+    }
+
+    /** {@code out}, also publishing every event written to it; {@code out} itself without a destination. */
+    public OutputStream tee(OutputStream out, Destination destination) {
+        if (destination == null) {
+            return out;
+        }
+        return new EventTee(out, message -> publish(destination, message));
+    }
+
+    /** Runs {@code body} in the background, its events reaching the channel only. */
+    public void detach(StreamingOutput body) {
+        detached.execute(() -> {
+            try {
+                body.write(OutputStream.nullOutputStream());
+            } catch (IOException | RuntimeException e) {
+                LOG.warnf("Published stream failed: %s", e.getMessage());
+            }
+        });
+    }
+
+    /** A failed publish is logged; the generation and its SSE stream go on. */
+    void publish(Destination destination, String message) {
+        try {
+            if (destination.transport().equals("redis")) {
+                redis.get().pubsub(String.class).publish(destination.channel(), message);
+            } else {
+                nats().publish(destination.channel(), message);
+            }
+        } catch (IOException | RuntimeException e) {
+            LOG.warnf("Publishing to %s failed: %s", destination, e.getMessage());
+        }
+    }
+
+    private synchronized NatsPublisher nats() {
+        if (nats == null) {
+            nats = new NatsPublisher(URI.create(natsUrl));
+        }
+        return nats;
+    }
+
+    @PreDestroy
+    synchronized void close() {
+        detached.shutdownNow();
+        if (nats != null) {
+            nats.close();
+        }
+    }
+
+    /**
+     * Passes SSE bytes through to the client and hands the {@code data} of each complete
+     * event ({@code event:} lines are dropped) to {@code publish}.
+     */
+    static final class EventTee extends OutputStream {
+
+        private final OutputStream out;
+        private final Consumer<String> publish;
+        private final ByteArrayOutputStream pending = new ByteArrayOutputStream();
+
+        EventTee(OutputStream out, Consumer<String> publish) {
+            this.out = out;
+            this.publish = publish;
+        }
+
+        @Override
+        public void write(int b) throws IOException {
+            write(new byte[] { (byte) b }, 0, 1);
+        }
+
+        @Override
+        public void write(byte[] b, int off, int len) throws IOException {
+            out.write(b, off, len);
+            pending.write(b, off, len);
+            byte[] buffered = pending.toByteArray();
+            int end = -1;
+            for (int i = buffered.length - 2; i >= 0 && end < 0; i--) {
+                if (buffered[i] == '\n' && buffered[i + 1] == '\n') {
+                    end = i;
+                }
+            }
+            if (end < 0) {
+                return;
+            }
+            // Split on bytes so a multi-byte character cut across writes stays intact.
+            pending.reset();
+            pending.write(buffered, end + 2, buffered.length - end - 2);
+            String text = new String(buffered, 0, end, StandardCharsets.UTF_8);
+            for (String event : text.split("\n\n")) {
+                StringBuilder data = new StringBuilder();
+                for (String line : event.split("\n")) {
+                    if (line.startsWith("data:")) {
+                        if (!data.isEmpty()) {
+                            data.append('\n');
+                        }
+                        data.append(line.substring(line.startsWith("data: ") ? 6 : 5));
+                    }
+                }
+                if (!data.isEmpty()) {
+                    publish.accept(data.toString());
+                }
+            }
+        }
+
+        @Override
+        public void flush() throws IOException {
+            out.flush();
+        }
+
+        @Override
+        public void close() throws IOException {
+            out.close();
+        }
+    }
+}
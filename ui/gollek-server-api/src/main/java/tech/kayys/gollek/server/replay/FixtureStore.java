@@ -0,0 +1,105 @@
+package tech.kayys.gollek.server.replay;
+
+import com.fasterxml.jackson.core.type.TypeReference;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.SerializationFeature;
+
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.io.IOException;
+import java.io.UncheckedIOException;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.util.ArrayList;
+import java.util.HexFormat;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Set;
+import java.util.TreeMap;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * JSON fixture file of recorded engine outputs, keyed by a stable hash of the request.
+ */
+public class FixtureStore {
+
+    /** Parameters that vary per call without changing the model output. */
+    private static final Set<String> VOLATILE_PARAMS = Set.of("request_id", "api_key", "apiKey", "inference_timeout_ms");
+
+    public static record Fixture(String content, String model, int inputTokens, int outputTokens,
+            String finishReason, List<String> chunks) { }
+
+    private final Path file;
+    private final ObjectMapper mapper = new ObjectMapper().enable(SerializationFeature.INDENT_OUTPUT);
+    private final Map<String, Fixture> fixtures = new ConcurrentHashMap<>();
+
+    public FixtureStore(Path file) {
+        this.file = file;
+        load();
+    }
+
+    public Fixture get(String key) {
+        return fixtures.get(key);
+    }
+
+    public int size() {
+        return fixtures.size();
+    }
+
+    public synchronized void put(String key, Fixture fixture) {
+        fixtures.put(key, fixture);
+        try {
+            if (file.getParent() != null) {
+                Files.createDirectories(file.getParent());
+            }
+            // sorted so fixture files diff cleanly in version control
+            mapper.writeValue(file.toFile(), new TreeMap<>(fixtures));
+        } catch (IOException e) {
+            throw new UncheckedIOException("Failed to write fixtures to " + file, e);
+        }
+    }
+
+    private void load() {
+        if (!Files.exists(file)) {
+            return;
+        }
+        try {
+            fixtures.putAll(mapper.readValue(file.toFile(), new TypeReference<Map<String, Fixture>>() { }));
+        } catch (IOException e) {
+            throw new UncheckedIOException("Failed to read fixtures from " + file, e);
+        }
+    }
+
+    /**
+     * Hash of everything that determines the output: model, messages, sampling
+     * parameters and whether the call was streamed.
+     */
+    public String key(InferenceRequest request, boolean streaming) {
+        Map<String, Object> canonical = new LinkedHashMap<>();
+        canonical.put("model", request.getModel());
+        List<Map<String, String>> messages = new ArrayList<>();
+        for (Message m : request.getMessages()) {
+            messages.add(Map.of("role", String.valueOf(m.getRole()),
+                    "content", m.getContent() == null ? "" : m.getContent()));
+        }
+        canonical.put("messages", messages);
+        Map<String, String> params = new TreeMap<>();
+        request.getParameters().forEach((k, v) -> {
+            if (!VOLATILE_PARAMS.contains(k)) {
+                params.put(k, String.valueOf(v));
+            }
+        });
+        canonical.put("parameters", params);
+        canonical.put("stream", streaming);
+        try {
+            byte[] json = mapper.copy().disable(SerializationFeature.INDENT_OUTPUT).writeValueAsBytes(canonical);
+            return HexFormat.of().formatHex(MessageDigest.getInstance("SHA-256").digest(json));
+        } catch (IOException | NoSuchAlgorithmException e) {
+            throw new IllegalStateException("Cannot hash request", e);
+        }
+    }
+}
@@ -0,0 +1,243 @@
+package tech.kayys.gollek.server.replay;
+
+import io.smallrye.mutiny.Multi;
+
+import tech.kayys.gollek.sdk.core.GollekSdk;
+import tech.kayys.gollek.sdk.exception.SdkException;
+import tech.kayys.gollek.sdk.model.ModelResolution;
+import tech.kayys.gollek.sdk.model.PullProgress;
+import tech.kayys.gollek.sdk.model.SystemInfo;
+import tech.kayys.gollek.spi.batch.BatchInferenceRequest;
+import tech.kayys.gollek.spi.embedding.EmbeddingRequest;
+import tech.kayys.gollek.spi.embedding.EmbeddingResponse;
+import tech.kayys.gollek.spi.inference.AsyncJobStatus;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+import tech.kayys.gollek.spi.model.ModelInfo;
+import tech.kayys.gollek.spi.provider.ProviderInfo;
+
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.List;
+import java.util.Optional;
+import java.util.concurrent.CompletableFuture;
+import java.util.function.Consumer;
+
+/**
+ * SDK decorator that records completions to a {@link FixtureStore} or replays them.
+ *
+ * <p>In {@link Mode#RECORD} every completion and stream is forwarded to the real SDK and
+ * its output stored under the request hash. In {@link Mode#REPLAY} completions are served
+ * from the fixture file only, so handler and streaming tests run without a model; a
+ * request with no recording fails with error code {@code FIXTURE_MISSING}. All other
+ * operations are delegated unchanged.
+ */
+public class RecordReplaySdk implements GollekSdk {
+
+    public enum Mode {
+        RECORD, REPLAY
+    }
+
+    private final GollekSdk delegate;
+    private final FixtureStore store;
+    private final Mode mode;
+
+    public RecordReplaySdk(GollekSdk delegate, FixtureStore store, Mode mode) {
+        this.delegate = delegate;
+        this.store = store;
+        this.mode = mode;
+    }
+
+    @Override
+    public InferenceResponse createCompletion(InferenceRequest request) throws SdkException {
+        String key = store.key(request, false);
+        if (mode == Mode.REPLAY) {
+            FixtureStore.Fixture f = require(key, request);
+            return new InferenceResponse.Builder()
+                    .requestId(request.getRequestId())
+                    .model(f.model() != null ? f.model() : request.getModel())
+                    .content(f.content())
+                    .inputTokens(f.inputTokens())
+                    .outputTokens(f.outputTokens())
+                    .tokensUsed(f.inputTokens() + f.outputTokens())
+                    .finishReason(f.finishReason() != null
+                            ? InferenceResponse.FinishReason.valueOf(f.finishReason())
+                            : InferenceResponse.FinishReason.STOP)
+                    .metadata("replayed", true)
+                    .build();
+        }
+        InferenceResponse resp = delegate.createCompletion(request);
+        store.put(key, new FixtureStore.Fixture(resp.getContent(), resp.getModel(), resp.getInputTokens(),
+                resp.getOutputTokens(), resp.getFinishReason() != null ? resp.getFinishReason().name() : null, null));
+        return resp;
+    }
+
+    @Override
+    public CompletableFuture<InferenceResponse> createCompletionAsync(InferenceRequest request) {
+        if (mode == Mode.RECORD) {
+            return delegate.createCompletionAsync(request).thenApply(resp -> {
+                String key = store.key(request, false);
+                store.put(key, new FixtureStore.Fixture(resp.getContent(), resp.getModel(), resp.getInputTokens(),
+                        resp.getOutputTokens(), resp.getFinishReason() != null ? resp.getFinishReason().name() : null,
+                        null));
+                return resp;
+            });
+        }
+        try {
+            return CompletableFuture.completedFuture(createCompletion(request));
+        } catch (SdkException e) {
+            return CompletableFuture.failedFuture(e);
+        }
+    }
+
+    @Override
+    public Multi<StreamingInferenceChunk> streamCompletion(InferenceRequest request) {
+        String key = store.key(request, true);
+        if (mode == Mode.REPLAY) {
+            FixtureStore.Fixture f = store.get(key);
+            if (f == null) {
+                return Multi.createFrom().failure(missing(key, request));
+            }
+            List<String> chunks = f.chunks() != null ? f.chunks() : List.of(f.content());
+            List<StreamingInferenceChunk> out = new ArrayList<>(chunks.size() + 1);
+            for (int i = 0; i < chunks.size(); i++) {
+                out.add(StreamingInferenceChunk.of(request.getRequestId(), i, chunks.get(i)));
+            }
+            out.add(new StreamingInferenceChunk(request.getRequestId(), chunks.size(),
+                    tech.kayys.gollek.spi.model.ModalityType.TEXT, "", null, true,
+                    f.finishReason() != null ? f.finishReason() : "stop",
+                    new StreamingInferenceChunk.ChunkUsage(f.inputTokens(), f.outputTokens(), 0),
+                    java.time.Instant.now(), java.util.Map.of("replayed", true)));
+            return Multi.createFrom().iterable(out);
+        }
+        List<String> deltas = Collections.synchronizedList(new ArrayList<>());
+        String[] finish = new String[1];
+        long[] usage = new long[2];
+        return delegate.streamCompletion(request)
+                .onItem().invoke(chunk -> {
+                    if (chunk.delta() != null && !chunk.delta().isEmpty()) {
+                        deltas.add(chunk.delta());
+                    }
+                    if (chunk.finishReason() != null) {
+                        finish[0] = chunk.finishReason();
+                    }
+                    if (chunk.usage() != null) {
+                        usage[0] = chunk.usage().inputTokens();
+                        usage[1] = chunk.usage().outputTokens();
+                    }
+                })
+                .onCompletion().invoke(() -> store.put(key, new FixtureStore.Fixture(String.join("", deltas),
+                        request.getModel(), (int) usage[0], (int) usage[1], finish[0], List.copyOf(deltas))));
+    }
+
+    private FixtureStore.Fixture require(String key, InferenceRequest request) throws SdkException {
+        FixtureStore.Fixture f = store.get(key);
+        if (f == null) {
+            throw missing(key, request);
+        }
+        return f;
+    }
+
+    private static SdkException missing(String key, InferenceRequest request) {
+        return new SdkException("FIXTURE_MISSING",
+                "No recorded response for model " + request.getModel() + " (key " + key + ")");
+    }
+
+    @Override
+    public EmbeddingResponse createEmbedding(EmbeddingRequest request) throws SdkException {
+        return delegate.createEmbedding(request);
+    }
+
+    @Override
+    public String submitAsyncJob(InferenceRequest request) throws SdkException {
+        return delegate.submitAsyncJob(request);
+    }
+
+    @Override
+    public AsyncJobStatus getJobStatus(String jobId) throws SdkException {
+        return delegate.getJobStatus(jobId);
+    }
+
+    @Override
+    public AsyncJobStatus waitForJob(String jobId, Duration maxWaitTime, Duration pollInterval) throws SdkException {
+        return delegate.waitForJob(jobId, maxWaitTime, pollInterval);
+    }
+
+    @Override
+    public List<InferenceResponse> batchInference(BatchInferenceRequest batchRequest) throws SdkException {
+        List<InferenceResponse> out = new ArrayList<>();
+        for (InferenceRequest r : batchRequest.getRequests()) {
+            out.add(createCompletion(r));
+        }
+        return out;
+    }
+
+    @Override
+    public List<ProviderInfo> listAvailableProviders() throws SdkException {
+        return delegate.listAvailableProviders();
+    }
+
+    @Override
+    public ProviderInfo getProviderInfo(String providerId) throws SdkException {
+        return delegate.getProviderInfo(providerId);
+    }
+
+    @Override
+    public void setPreferredProvider(String providerId) throws SdkException {
+        delegate.setPreferredProvider(providerId);
+    }
+
+    @Override
+    public Optional<String> getPreferredProvider() {
+        return delegate.getPreferredProvider();
+    }
+
+    @Override
+    public List<ModelInfo> listModels() throws SdkException {
+        return delegate.listModels();
+    }
+
+    @Override
+    public List<ModelInfo> listModels(int offset, int limit) throws SdkException {
+        return delegate.listModels(offset, limit);
+    }
+
+    @Override
+    public Optional<ModelInfo> getModelInfo(String modelId) throws SdkException {
+        return delegate.getModelInfo(modelId);
+    }
+
+    @Override
+    public void pullModel(String modelSpec, Consumer<PullProgress> progressCallback) throws SdkException {
+        delegate.pullModel(modelSpec, progressCallback);
+    }
+
+    @Override
+    public void pullModel(String modelSpec, String revision, boolean force, Consumer<PullProgress> progressCallback)
+            throws SdkException {
+        delegate.pullModel(modelSpec, revision, force, progressCallback);
+    }
+
+    @Override
+    public void deleteModel(String modelId) throws SdkException {
+        delegate.deleteModel(modelId);
+    }
+
+    @Override
+    public ModelResolution prepareModel(String modelId, boolean forceGguf, Consumer<PullProgress> progressCallback)
+            throws SdkException {
+        return delegate.prepareModel(modelId, forceGguf, progressCallback);
+    }
+
+    @Override
+    public Optional<String> autoSelectProvider(String modelId, boolean forceGguf) throws SdkException {
+        return delegate.autoSelectProvider(modelId, forceGguf);
+    }
+
+    @Override
+    public SystemInfo getSystemInfo() throws SdkException {
+        return delegate.getSystemInfo();
+    }
+}
@@ -0,0 +1,52 @@
+package tech.kayys.gollek.server.schedules;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import java.util.Map;
+
+/**
+ * A prompt run on a schedule. Exactly one of {@code cron} (Quartz syntax, seconds first,
+ * e.g. {@code 0 0 * * * ?}) or {@code every} (e.g. {@code 1h}) is set. Results are kept
+ * in the store and optionally POSTed to {@code webhook}.
+ */
+@JsonIgnoreProperties(ignoreUnknown = true)
+public record ScheduledTask(
+        String id,
+        String name,
+        String cron,
+        String every,
+        String model,
+        @JsonProperty("system_prompt") String systemPrompt,
+        String prompt,
+        Map<String, Object> parameters,
+        String webhook,
+        Boolean enabled,
+        @JsonProperty("created_at") long createdAt,
+        @JsonProperty("last_run") RunResult lastRun) {
+
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public static record RunResult(
+            @JsonProperty("task_id") String taskId,
+            @JsonProperty("started_at") long startedAt,
+            @JsonProperty("duration_ms") long durationMs,
+            String status,
+            String content,
+            String error) {
+    }
+
+    /** Tasks are enabled unless explicitly switched off. */
+    public boolean isEnabled() {
+        return enabled == null || enabled;
+    }
+
+    ScheduledTask withId(String id, long createdAt) {
+        return new ScheduledTask(id, name, cron, every, model, systemPrompt, prompt, parameters, webhook, enabled,
+                createdAt, lastRun);
+    }
+
+    ScheduledTask withLastRun(RunResult run) {
+        return new ScheduledTask(id, name, cron, every, model, systemPrompt, prompt, parameters, webhook, enabled,
+                createdAt, run);
+    }
+}
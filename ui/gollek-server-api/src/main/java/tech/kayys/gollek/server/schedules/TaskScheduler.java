@@ -0,0 +1,315 @@
+package tech.kayys.gollek.server.schedules;
+
+import io.quarkus.runtime.StartupEvent;
+import jakarta.annotation.PostConstruct;
+import io.quarkus.scheduler.Scheduled;
+import io.quarkus.scheduler.Scheduler;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.net.URI;
+import java.net.http.HttpClient;
+import java.net.http.HttpRequest;
+import java.net.http.HttpResponse;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.Comparator;
+import java.util.List;
+import java.util.Locale;
+import java.util.Optional;
+import java.util.UUID;
+
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.SdkProvider;
+import tech.kayys.gollek.server.cluster.LeaderElection;
+import tech.kayys.gollek.server.security.AtRestCipher;
+import tech.kayys.gollek.server.store.Store;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+/**
+ * Runs {@link ScheduledTask}s on the Quarkus scheduler. Task definitions and run
+ * history live in the {@code schedules} store namespace, so tasks are re-registered on
+ * startup and survive restarts. Every replica registers every task; with leader election
+ * on, only the leader runs them. Each task keeps its newest {@code history} runs.
+ *
+ * <p>Webhooks may only point at hosts in {@code webhook-allowed-hosts} (exact names, or
+ * {@code *.example.com} for subdomains); with none configured, tasks cannot set one.
+ *
+ * <p>Tasks hold prompts and runs hold model output, so with a storage encryption key
+ * configured both are AES-GCM encrypted, like stored conversations.
+ */
+@ApplicationScoped
+public class TaskScheduler {
+
+    private static final Logger LOG = Logger.getLogger(TaskScheduler.class);
+    static final String NAMESPACE = "schedules";
+
+    @Inject
+    Scheduler scheduler;
+
+    @Inject
+    Store store;
+
+    @Inject
+    SdkProvider sdkProvider;
+
+    @Inject
+    LeaderElection leaderElection;
+
+    @ConfigProperty(name = "gollek.server.schedules.history", defaultValue = "100")
+    int history;
+
+    @ConfigProperty(name = "gollek.server.schedules.webhook-allowed-hosts")
+    Optional<List<String>> webhookAllowedHosts;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key")
+    Optional<String> encryptionKey;
+
+    @ConfigProperty(name = "gollek.server.storage.encryption.key-file")
+    Optional<String> encryptionKeyFile;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+    private AtRestCipher cipher;
+    private final HttpClient http = HttpClient.newBuilder().connectTimeout(Duration.ofSeconds(10)).build();
+
+    @PostConstruct
+    void init() {
+        try {
+            cipher = AtRestCipher.fromConfig(encryptionKey, encryptionKeyFile).orElse(null);
+        } catch (IOException e) {
+            throw new IllegalStateException("Failed to read storage encryption key: " + e.getMessage(), e);
+        }
+    }
+
+    void onStart(@Observes StartupEvent event) {
+        for (ScheduledTask task : list()) {
+            try {
+                register(task);
+            } catch (RuntimeException e) {
+                LOG.warnf("Could not schedule task %s: %s", task.id(), e.getMessage());
+            }
+        }
+    }
+
+    public List<ScheduledTask> list() {
+        return store.keys(NAMESPACE).stream()
+                .map(this::get)
+                .flatMap(Optional::stream)
+                .sorted(Comparator.comparingLong(ScheduledTask::createdAt))
+                .toList();
+    }
+
+    public Optional<ScheduledTask> get(String id) {
+        return store.get(NAMESPACE, id).map(v -> open(v, ScheduledTask.class));
+    }
+
+    public synchronized ScheduledTask create(ScheduledTask spec) {
+        validate(spec);
+        ScheduledTask task = spec.withId("sched-" + UUID.randomUUID().toString().replace("-", ""),
+                Instant.now().getEpochSecond());
+        // scheduling checks the cron or interval, so a task that cannot run is never stored
+        register(task);
+        try {
+            save(task);
+        } catch (RuntimeException e) {
+            scheduler.unscheduleJob(task.id());
+            throw e;
+        }
+        return task;
+    }
+
+    public synchronized Optional<ScheduledTask> replace(String id, ScheduledTask spec) {
+        Optional<ScheduledTask> existing = get(id);
+        if (existing.isEmpty()) {
+            return Optional.empty();
+        }
+        validate(spec);
+        ScheduledTask task = spec.withId(id, existing.get().createdAt()).withLastRun(existing.get().lastRun());
+        scheduler.unscheduleJob(id);
+        try {
+            register(task);
+        } catch (IllegalArgumentException e) {
+            register(existing.get());
+            throw e;
+        }
+        save(task);
+        return Optional.of(task);
+    }
+
+    public synchronized boolean delete(String id) {
+        scheduler.unscheduleJob(id);
+        store.deleteList(NAMESPACE, id);
+        return store.delete(NAMESPACE, id);
+    }
+
+    /** Newest first. */
+    public List<ScheduledTask.RunResult> results(String id, int limit) {
+        List<ScheduledTask.RunResult> out = new ArrayList<>();
+        for (String v : store.range(NAMESPACE, id, 0, -1)) {
+            out.add(open(v, ScheduledTask.RunResult.class));
+        }
+        Collections.reverse(out);
+        return out.subList(0, Math.min(out.size(), Math.max(0, limit)));
+    }
+
+    public Optional<ScheduledTask.RunResult> runNow(String id) {
+        return get(id).map(this::execute);
+    }
+
+    /**
+     * Disabled tasks are registered too, and skipped, so their schedule is still checked.
+     *
+     * @throws IllegalArgumentException if the cron expression or interval is invalid
+     */
+    private void register(ScheduledTask task) {
+        var job = scheduler.newJob(task.id())
+                .setConcurrentExecution(Scheduled.ConcurrentExecution.SKIP)
+                .setSkipPredicate(execution -> !task.isEnabled() || !leaderElection.isLeader())
+                .setTask(execution -> get(task.id()).ifPresent(this::execute));
+        if (task.cron() != null && !task.cron().isBlank()) {
+            job.setCron(task.cron());
+        } else {
+            job.setInterval(task.every());
+        }
+        try {
+            job.schedule();
+        } catch (IllegalArgumentException | IllegalStateException e) {
+            throw new IllegalArgumentException("invalid schedule: " + e.getMessage(), e);
+        }
+    }
+
+    private ScheduledTask.RunResult execute(ScheduledTask task) {
+        long started = System.currentTimeMillis();
+        ScheduledTask.RunResult result;
+        try {
+            var builder = InferenceRequest.builder()
+                    .requestId(UUID.randomUUID().toString())
+                    .model(task.model());
+            if (task.systemPrompt() != null && !task.systemPrompt().isBlank()) {
+                builder.message(Message.system(task.systemPrompt()));
+            }
+            builder.message(Message.user(task.prompt()));
+            if (task.parameters() != null) {
+                task.parameters().forEach(builder::parameter);
+            }
+            var resp = sdkProvider.getSdk().createCompletion(builder.build());
+            result = new ScheduledTask.RunResult(task.id(), started / 1000, System.currentTimeMillis() - started,
+                    "COMPLETED", resp.getContent(), null);
+        } catch (Exception e) {
+            result = new ScheduledTask.RunResult(task.id(), started / 1000, System.currentTimeMillis() - started,
+                    "FAILED", null, e.getMessage());
+        }
+        store.append(NAMESPACE, task.id(), seal(result));
+        store.trim(NAMESPACE, task.id(), history);
+        final ScheduledTask.RunResult run = result;
+        synchronized (this) {
+            get(task.id()).ifPresent(current -> save(current.withLastRun(run)));
+        }
+        if (task.webhook() != null && !task.webhook().isBlank()) {
+            postWebhook(task, result);
+        }
+        return result;
+    }
+
+    private void postWebhook(ScheduledTask task, ScheduledTask.RunResult result) {
+        if (!webhookAllowed(task.webhook())) {
+            LOG.warnf("Webhook for task %s skipped: %s is not an allowed host", task.id(), task.webhook());
+            return;
+        }
+        try {
+            HttpRequest req = HttpRequest.newBuilder(URI.create(task.webhook()))
+                    .timeout(Duration.ofSeconds(30))
+                    .header("Content-Type", "application/json")
+                    .POST(HttpRequest.BodyPublishers.ofString(write(java.util.Map.of(
+                            "task", task.name() == null ? task.id() : task.name(),
+                            "result", result))))
+                    .build();
+            HttpResponse<Void> resp = http.send(req, HttpResponse.BodyHandlers.discarding());
+            if (resp.statusCode() / 100 != 2) {
+                LOG.warnf("Webhook for task %s returned HTTP %d", task.id(), resp.statusCode());
+            }
+        } catch (IOException | IllegalArgumentException e) {
+            LOG.warnf("Webhook for task %s failed: %s", task.id(), e.getMessage());
+        } catch (InterruptedException e) {
+            Thread.currentThread().interrupt();
+        }
+    }
+
+    private void validate(ScheduledTask spec) {
+        boolean hasCron = spec.cron() != null && !spec.cron().isBlank();
+        boolean hasEvery = spec.every() != null && !spec.every().isBlank();
+        if (hasCron == hasEvery) {
+            throw new IllegalArgumentException("exactly one of cron or every is required");
+        }
+        if (spec.prompt() == null || spec.prompt().isBlank()) {
+            throw new IllegalArgumentException("prompt is required");
+        }
+        if (spec.webhook() != null && !spec.webhook().isBlank() && !webhookAllowed(spec.webhook())) {
+            throw new IllegalArgumentException("webhook must be an http(s) URL on an allowed host "
+                    + "(gollek.server.schedules.webhook-allowed-hosts)");
+        }
+    }
+
+    boolean webhookAllowed(String webhook) {
+        URI uri;
+        try {
+            uri = URI.create(webhook);
+        } catch (IllegalArgumentException e) {
+            return false;
+        }
+        String scheme = uri.getScheme() == null ? "" : uri.getScheme().toLowerCase(Locale.ROOT);
+        if (uri.getHost() == null || uri.getRawUserInfo() != null
+                || !(scheme.equals("http") || scheme.equals("https"))) {
+            return false;
+        }
+        String host = uri.getHost().toLowerCase(Locale.ROOT);
+        for (String allowed : webhookAllowedHosts.orElse(List.of())) {
+            String pattern = allowed.strip().toLowerCase(Locale.ROOT);
+            if (pattern.startsWith("*.") ? host.endsWith(pattern.substring(1)) : host.equals(pattern)) {
+                return true;
+            }
+        }
+        return false;
+    }
+
+    private void save(ScheduledTask task) {
+        store.put(NAMESPACE, task.id(), seal(task));
+    }
+
+    private String write(Object value) {
+        try {
+            return mapper.writeValueAsString(value);
+        } catch (JsonProcessingException e) {
+            throw new IllegalStateException(e);
+        }
+    }
+
+    /** {@link #write}, encrypted when a storage key is configured. */
+    private String seal(Object value) {
+        String json = write(value);
+        return cipher == null ? json : cipher.encryptToString(json);
+    }
+
+    private <T> T open(String value, Class<T> type) {
+        try {
+            if (value.startsWith("{")) {
+                return mapper.readValue(value, type);
+            }
+            if (cipher == null) {
+                throw new IllegalStateException("Schedules are encrypted but no storage encryption key is configured");
+            }
+            return mapper.readValue(cipher.decryptToString(value), type);
+        } catch (JsonProcessingException e) {
+            throw new IllegalStateException("Corrupt schedule entry", e);
+        }
+    }
+}
@@ -6,10 +6,15 @@ import org.eclipse.microprofile.config.inject.ConfigProperty;
 import org.jboss.logging.Logger;
 
 import java.io.IOException;
+import java.nio.charset.StandardCharsets;
 import java.nio.file.Files;
 import java.nio.file.Path;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
 import java.util.Collections;
 import java.util.HashSet;
+import java.util.HexFormat;
+import java.util.Optional;
 import java.util.Set;
 import java.util.stream.Collectors;
 
@@ -60,6 +65,24 @@ public class ApiKeyStore {
         return removed;
     }
 
+    /**
+     * The SHA-256 of {@code key}, for records that outlive a request and must not hold the
+     * key itself; {@link #findByHash} turns it back into a key while that key is allowed.
+     */
+    public static String hash(String key) {
+        try {
+            byte[] digest = MessageDigest.getInstance("SHA-256").digest(key.getBytes(StandardCharsets.UTF_8));
+            return HexFormat.of().formatHex(digest);
+        } catch (NoSuchAlgorithmException e) {
+            throw new IllegalStateException("SHA-256 unavailable", e);
+        }
+    }
+
+    /** The allowed key whose {@link #hash} is {@code hash}, if any. */
+    public Optional<String> findByHash(String hash) {
+        return hash == null ? Optional.empty() : listKeys().stream().filter(k -> hash.equals(hash(k))).findFirst();
+    }
+
     private void persist() {
         Path p = Path.of(keysFilePath);
         try {
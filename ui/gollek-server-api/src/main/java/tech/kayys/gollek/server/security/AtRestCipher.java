@@ -0,0 +1,119 @@
+package tech.kayys.gollek.server.security;
+
+import javax.crypto.Cipher;
+import javax.crypto.spec.GCMParameterSpec;
+import javax.crypto.spec.SecretKeySpec;
+
+import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.security.GeneralSecurityException;
+import java.security.SecureRandom;
+import java.util.Arrays;
+import java.util.Base64;
+import java.util.Optional;
+
+/**
+ * AES-GCM encryption for files that hold prompt content. Encrypted payloads start with
+ * a short magic header, so files written before encryption was enabled still load.
+ *
+ * <p>The key is 16, 24 or 32 bytes, given base64-encoded either inline or in a key file
+ * (the file form suits mounted secrets from a KMS or secret manager).
+ */
+public final class AtRestCipher {
+
+    private static final byte[] MAGIC = "GKE1".getBytes(StandardCharsets.US_ASCII);
+    private static final int IV_BYTES = 12;
+    private static final int TAG_BITS = 128;
+
+    private final SecretKeySpec key;
+    private final SecureRandom random = new SecureRandom();
+
+    public AtRestCipher(byte[] key) {
+        if (key.length != 16 && key.length != 24 && key.length != 32) {
+            throw new IllegalArgumentException("AES key must be 16, 24 or 32 bytes, got " + key.length);
+        }
+        this.key = new SecretKeySpec(key, "AES");
+    }
+
+    /**
+     * Resolves the cipher from config; empty when neither a key nor a key file is set,
+     * in which case callers store plaintext.
+     */
+    public static Optional<AtRestCipher> fromConfig(Optional<String> base64Key, Optional<String> keyFile)
+            throws IOException {
+        String encoded = null;
+        if (keyFile.isPresent() && !keyFile.get().isBlank()) {
+            encoded = Files.readString(Path.of(keyFile.get()), StandardCharsets.US_ASCII);
+        } else if (base64Key.isPresent() && !base64Key.get().isBlank()) {
+            encoded = base64Key.get();
+        }
+        if (encoded == null) {
+            return Optional.empty();
+        }
+        return Optional.of(new AtRestCipher(Base64.getDecoder().decode(encoded.strip())));
+    }
+
+    public static boolean isEncrypted(byte[] data) {
+        return data.length >= MAGIC.length && Arrays.equals(data, 0, MAGIC.length, MAGIC, 0, MAGIC.length);
+    }
+
+    public byte[] encrypt(byte[] plaintext) {
+        byte[] iv = new byte[IV_BYTES];
+        random.nextBytes(iv);
+        try {
+            Cipher cipher = Cipher.getInstance("AES/GCM/NoPadding");
+            cipher.init(Cipher.ENCRYPT_MODE, key, new GCMParameterSpec(TAG_BITS, iv));
+            cipher.updateAAD(MAGIC);
+            byte[] sealed = cipher.doFinal(plaintext);
+            byte[] out = new byte[MAGIC.length + IV_BYTES + sealed.length];
+            System.arraycopy(MAGIC, 0, out, 0, MAGIC.length);
+            System.arraycopy(iv, 0, out, MAGIC.length, IV_BYTES);
+            System.arraycopy(sealed, 0, out, MAGIC.length + IV_BYTES, sealed.length);
+            return out;
+        } catch (GeneralSecurityException e) {
+            throw new IllegalStateException("Encryption failed", e);
+        }
+    }
+
+    /** {@code text} encrypted and base64-encoded, for backends that hold strings. */
+    public String encryptToString(String text) {
+        return Base64.getEncoder().encodeToString(encrypt(text.getBytes(StandardCharsets.UTF_8)));
+    }
+
+    /** Reverses {@link #encryptToString}. */
+    public String decryptToString(String sealed) {
+        byte[] data;
+        try {
+            data = Base64.getDecoder().decode(sealed);
+        } catch (IllegalArgumentException e) {
+            throw new IllegalStateException("Encrypted payload is not base64", e);
+        }
+        if (!isEncrypted(data)) {
+            throw new IllegalStateException("Payload is not encrypted");
+        }
+        return new String(decrypt(data), StandardCharsets.UTF_8);
+    }
+
+    /** Decrypts an encrypted payload; plaintext written before encryption was enabled passes through. */
+    public byte[] decrypt(byte[] data) {
+        if (!isEncrypted(data)) {
+            return data;
+        }
+        if (data.length < MAGIC.length + IV_BYTES) {
+            throw new IllegalStateException("Encrypted payload is truncated");
+        }
+        try {
+            Cipher cipher = Cipher.getInstance("AES/GCM/NoPadding");
+            cipher.init(Cipher.DECRYPT_MODE, key,
+                    new GCMParameterSpec(TAG_BITS, data, MAGIC.length, IV_BYTES));
+            cipher.updateAAD(MAGIC);
+            int offset = MAGIC.length + IV_BYTES;
+            return cipher.doFinal(data, offset, data.length - offset);
+        } catch (GeneralSecurityException e) {
+            // wrong key or tampered file
+            throw new IllegalStateException("Decryption failed: " + e.getMessage(), e);
+        }
+    }
+}
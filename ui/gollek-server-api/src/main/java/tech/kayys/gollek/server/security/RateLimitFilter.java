@@ -0,0 +1,62 @@
+package tech.kayys.gollek.server.security;
+
+import jakarta.annotation.Priority;
+import jakarta.inject.Inject;
+import jakarta.ws.rs.Priorities;
+import jakarta.ws.rs.container.ContainerRequestContext;
+import jakarta.ws.rs.container.ContainerRequestFilter;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.ext.Provider;
+
+import io.vertx.core.http.HttpServerRequest;
+
+/**
+ * Rejects requests over the per-IP or per-API-key budget with {@code 429} and a
+ * {@code Retry-After} header. Runs after authentication so unknown keys never get a bucket.
+ */
+@Provider
+@Priority(Priorities.AUTHENTICATION + 10)
+public class RateLimitFilter implements ContainerRequestFilter {
+
+    @Inject
+    RateLimiter limiter;
+
+    @Context
+    HttpServerRequest httpRequest;
+
+    @Override
+    public void filter(ContainerRequestContext requestContext) {
+        if (!limiter.isEnabled()) {
+            return;
+        }
+        String path = requestContext.getUriInfo().getPath();
+        if (path.startsWith("/")) {
+            path = path.substring(1);
+        }
+        if (SecurityFilter.OPEN_PATHS.contains(path) || path.startsWith("q/")) {
+            return;
+        }
+
+        RateLimiter.Decision decision = limiter.checkIp(clientIp(requestContext));
+        String apiKey = requestContext.getHeaderString("X-API-Key");
+        if (decision.allowed() && apiKey != null && !apiKey.isBlank()) {
+            decision = limiter.checkApiKey(apiKey);
+        }
+        if (!decision.allowed()) {
+            requestContext.abortWith(Response.status(429)
+                    .header("Retry-After", decision.retryAfterSeconds())
+                    .header("X-RateLimit-Remaining", 0)
+                    .type(MediaType.APPLICATION_JSON)
+                    .entity(java.util.Map.of("error", "Rate limit exceeded")).build());
+        }
+    }
+
+    private String clientIp(ContainerRequestContext ctx) {
+        String peer = httpRequest != null && httpRequest.remoteAddress() != null
+                ? httpRequest.remoteAddress().hostAddress()
+                : null;
+        return limiter.clientIp(peer, ctx.getHeaderString("X-Forwarded-For"));
+    }
+}
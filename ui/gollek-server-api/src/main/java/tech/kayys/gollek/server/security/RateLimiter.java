@@ -0,0 +1,213 @@
+package tech.kayys.gollek.server.security;
+
+import io.quarkus.redis.datasource.RedisDataSource;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import tech.kayys.gollek.server.ConfigChanged;
+
+import java.net.InetAddress;
+import java.net.UnknownHostException;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Per-client token-bucket rate limiting, keyed by API key and by client IP. Buckets are
+ * kept in process by default; with {@code backend=redis} they live in Redis so every
+ * instance behind a load balancer shares the same budget.
+ *
+ * <p>The client IP is the connection's peer. {@code X-Forwarded-For} is only believed when
+ * that peer is one of {@code trusted-proxies} (addresses or CIDR ranges), and then read
+ * from the right, skipping further trusted hops; otherwise any client could pick a fresh
+ * IP, and bucket, per request.
+ */
+@ApplicationScoped
+public class RateLimiter {
+
+    private static final Logger LOG = Logger.getLogger(RateLimiter.class);
+
+    // KEYS[1]=bucket, ARGV: rate, burst, now (ms). Returns {allowed, remaining, retry_ms}.
+    private static final String REDIS_SCRIPT = """
+            local b = redis.call('HMGET', KEYS[1], 't', 'ts')
+            local rate, burst, now = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+            local tokens = tonumber(b[1]) or burst
+            local ts = tonumber(b[2]) or now
+            tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+            local allowed, retry = 0, 0
+            if tokens >= 1 then
+              tokens = tokens - 1
+              allowed = 1
+            else
+              retry = math.ceil((1 - tokens) / rate * 1000)
+            end
+            redis.call('HSET', KEYS[1], 't', tostring(tokens), 'ts', tostring(now))
+            redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+            return {allowed, math.floor(tokens), retry}
+            """;
+
+    public record Decision(boolean allowed, int remaining, long retryAfterSeconds) {
+        static final Decision UNLIMITED = new Decision(true, -1, 0);
+    }
+
+    @ConfigProperty(name = "gollek.server.rate-limit.enabled", defaultValue = "false")
+    volatile boolean enabled;
+
+    @ConfigProperty(name = "gollek.server.rate-limit.backend", defaultValue = "local")
+    String backend;
+
+    @ConfigProperty(name = "gollek.server.rate-limit.per-key.rps", defaultValue = "10")
+    volatile double perKeyRps;
+
+    @ConfigProperty(name = "gollek.server.rate-limit.per-key.burst", defaultValue = "20")
+    volatile double perKeyBurst;
+
+    @ConfigProperty(name = "gollek.server.rate-limit.per-ip.rps", defaultValue = "20")
+    volatile double perIpRps;
+
+    @ConfigProperty(name = "gollek.server.rate-limit.per-ip.burst", defaultValue = "40")
+    volatile double perIpBurst;
+
+    @ConfigProperty(name = "gollek.server.rate-limit.redis.prefix", defaultValue = "gollek:rl")
+    String redisPrefix;
+
+    @ConfigProperty(name = "gollek.server.rate-limit.trusted-proxies")
+    Optional<List<String>> trustedProxies;
+
+    @Inject
+    Instance<RedisDataSource> redis;
+
+    private static final int SWEEP_THRESHOLD = 10_000;
+
+    private final Map<String, TokenBucket> buckets = new ConcurrentHashMap<>();
+
+    /** Applies reloaded limits; buckets are dropped so they refill at the new rate. */
+    void onConfigChanged(@Observes ConfigChanged event) {
+        String prefix = "gollek.server.rate-limit.";
+        event.get(prefix + "enabled").ifPresent(v -> enabled = Boolean.parseBoolean(v.trim()));
+        event.get(prefix + "per-key.rps").ifPresent(v -> perKeyRps = Double.parseDouble(v.trim()));
+        event.get(prefix + "per-key.burst").ifPresent(v -> perKeyBurst = Double.parseDouble(v.trim()));
+        event.get(prefix + "per-ip.rps").ifPresent(v -> perIpRps = Double.parseDouble(v.trim()));
+        event.get(prefix + "per-ip.burst").ifPresent(v -> perIpBurst = Double.parseDouble(v.trim()));
+        if (event.values().keySet().stream().anyMatch(k -> k.startsWith(prefix))) {
+            buckets.clear();
+        }
+    }
+
+    public boolean isEnabled() {
+        return enabled;
+    }
+
+    /**
+     * The address a request's IP budget is charged to: {@code peer}, unless it is a
+     * trusted proxy, in which case the nearest untrusted hop of {@code forwardedFor}.
+     */
+    public String clientIp(String peer, String forwardedFor) {
+        if (peer == null) {
+            return "unknown";
+        }
+        if (forwardedFor == null || forwardedFor.isBlank() || !trusted(peer)) {
+            return peer;
+        }
+        String[] hops = forwardedFor.split(",");
+        String client = peer;
+        for (int i = hops.length - 1; i >= 0; i--) {
+            client = hops[i].strip();
+            if (client.isEmpty() || !trusted(client)) {
+                break;
+            }
+        }
+        return client.isEmpty() ? peer : client;
+    }
+
+    boolean trusted(String address) {
+        for (String proxy : trustedProxies.orElse(List.of())) {
+            if (inRange(address, proxy.strip())) {
+                return true;
+            }
+        }
+        return false;
+    }
+
+    /** Whether {@code address} is {@code range}, an IP or an IP/prefix-length CIDR. */
+    static boolean inRange(String address, String range) {
+        try {
+            int slash = range.indexOf('/');
+            // only literal addresses: never resolve names a client sent
+            if (!literal(address) || !literal(slash < 0 ? range : range.substring(0, slash))) {
+                return false;
+            }
+            byte[] ip = InetAddress.getByName(address).getAddress();
+            byte[] net = InetAddress.getByName(slash < 0 ? range : range.substring(0, slash)).getAddress();
+            if (ip.length != net.length) {
+                return false;
+            }
+            int bits = slash < 0 ? ip.length * 8 : Integer.parseInt(range.substring(slash + 1));
+            for (int i = 0; i < ip.length && bits > 0; i++, bits -= 8) {
+                int mask = bits >= 8 ? 0xff : (0xff << (8 - bits)) & 0xff;
+                if ((ip[i] & mask) != (net[i] & mask)) {
+                    return false;
+                }
+            }
+            return true;
+        } catch (UnknownHostException | NumberFormatException e) {
+            return false;
+        }
+    }
+
+    private static boolean literal(String address) {
+        return !address.isEmpty() && (address.indexOf(':') >= 0 || address.chars().allMatch(c -> c == '.'
+                || Character.isDigit(c)));
+    }
+
+    public Decision checkApiKey(String apiKey) {
+        return check("key:" + apiKey, perKeyRps, perKeyBurst);
+    }
+
+    public Decision checkIp(String ip) {
+        return check("ip:" + ip, perIpRps, perIpBurst);
+    }
+
+    private Decision check(String id, double rps, double burst) {
+        if (!enabled || rps <= 0) {
+            return Decision.UNLIMITED;
+        }
+        if ("redis".equalsIgnoreCase(backend)) {
+            try {
+                return checkRedis(id, rps, burst);
+            } catch (RuntimeException e) {
+                // a Redis outage should degrade to per-instance limits, not reject all traffic
+                LOG.warnf("Redis rate limiter unavailable, using local buckets: %s", e.getMessage());
+            }
+        }
+        long now = System.nanoTime();
+        if (buckets.size() > SWEEP_THRESHOLD) {
+            evictIdle(now);
+        }
+        TokenBucket bucket = buckets.computeIfAbsent(id, k -> new TokenBucket(rps, burst, now));
+        long waitNanos = bucket.tryAcquire(now);
+        return new Decision(waitNanos == 0, bucket.remaining(now), secondsCeil(waitNanos));
+    }
+
+    private Decision checkRedis(String id, double rps, double burst) {
+        var reply = redis.get().execute("EVAL", REDIS_SCRIPT, "1", redisPrefix + ":" + id,
+                String.valueOf(rps), String.valueOf(burst), String.valueOf(System.currentTimeMillis()));
+        boolean allowed = reply.get(0).toLong() == 1;
+        long retryMs = reply.get(2).toLong();
+        return new Decision(allowed, reply.get(1).toInteger(), secondsCeil(retryMs * 1_000_000));
+    }
+
+    private static long secondsCeil(long nanos) {
+        return nanos <= 0 ? 0 : Math.max(1, (nanos + 999_999_999L) / 1_000_000_000L);
+    }
+
+    /** Full buckets behave exactly like missing ones, so drop them to bound memory. */
+    private void evictIdle(long now) {
+        buckets.entrySet().removeIf(e -> e.getValue().isIdle(now));
+    }
+}
@@ -12,6 +12,7 @@ import org.jboss.logging.Logger;
 
 import java.io.IOException;
 import java.util.Arrays;
+import java.util.Locale;
 import java.util.Set;
 import java.util.stream.Collectors;
 
@@ -21,6 +22,15 @@ public class SecurityFilter implements ContainerRequestFilter {
 
     private static final Logger LOG = Logger.getLogger(SecurityFilter.class);
 
+    /** Health and lifecycle endpoints orchestrators call without credentials. */
+    public static final Set<String> OPEN_PATHS = Set.of("health", "live", "ready");
+
+    /**
+     * Admin endpoints outside {@code v1/admin}: shutdown, and the llama-server views that
+     * show every tenant's requests and model paths like {@code /v1/admin/slots}.
+     */
+    public static final Set<String> ADMIN_PATHS = Set.of("quitquitquit", "slots", "props");
+
     @Inject
     @ConfigProperty(name = "gollek.server.allowed-api-keys", defaultValue = "community")
     String allowedApiKeys;
@@ -29,19 +39,28 @@ public class SecurityFilter implements ContainerRequestFilter {
     @ConfigProperty(name = "gollek.server.admin-secret", defaultValue = "admin-secret")
     String adminSecret;
 
+    /** Serve Ollama's {@code /api/*} without a key, for clients that cannot send one. */
+    @Inject
+    @ConfigProperty(name = "gollek.server.ollama.open", defaultValue = "false")
+    boolean ollamaOpen;
+
     @Inject
     ApiKeyStore apiKeyStore;
 
     @Override
     public void filter(ContainerRequestContext requestContext) throws IOException {
         String path = requestContext.getUriInfo().getPath();
-        // Allow unauthenticated access to health and open endpoints
-        if (path.equals("health") || path.startsWith("q/")) {
+        // relative to the base URI, with or without a leading slash depending on the stack
+        if (path.startsWith("/")) {
+            path = path.substring(1);
+        }
+        // Allow unauthenticated access to health, lifecycle and open endpoints
+        if (OPEN_PATHS.contains(path) || path.startsWith("q/") || (ollamaOpen && path.startsWith("api/"))) {
             return;
         }
 
         // Admin endpoints require admin-secret header
-        if (path.startsWith("v1/admin")) {
+        if (path.startsWith("v1/admin") || ADMIN_PATHS.contains(path)) {
             String admin = requestContext.getHeaderString("X-ADMIN-SECRET");
             if (admin == null || !admin.equals(adminSecret)) {
                 requestContext.abortWith(javax.ws.rs.core.Response.status(javax.ws.rs.core.Response.Status.FORBIDDEN)
@@ -51,6 +70,14 @@ public class SecurityFilter implements ContainerRequestFilter {
         }
 
         String header = requestContext.getHeaderString("X-API-Key");
+        if (header == null || header.isBlank()) {
+            // OpenAI SDKs send the key as a bearer token
+            header = bearer(requestContext.getHeaderString("Authorization"));
+            if (header != null) {
+                // resources and later filters read the key from X-API-Key
+                requestContext.getHeaders().putSingle("X-API-Key", header);
+            }
+        }
         if (header == null || header.isBlank()) {
             LOG.debug("Missing API key for " + path);
             requestContext.abortWith(javax.ws.rs.core.Response.status(javax.ws.rs.core.Response.Status.UNAUTHORIZED)
@@ -65,4 +92,13 @@ public class SecurityFilter implements ContainerRequestFilter {
                     .entity(java.util.Map.of("error", "Invalid API key")).build());
         }
     }
+
+    /** The token of an {@code Authorization: Bearer <token>} header, or null. */
+    static String bearer(String authorization) {
+        if (authorization == null || !authorization.toLowerCase(Locale.ROOT).startsWith("bearer ")) {
+            return null;
+        }
+        String token = authorization.substring("bearer ".length()).strip();
+        return token.isEmpty() ? null : token;
+    }
 }
@@ -0,0 +1,53 @@
+package tech.kayys.gollek.server.security;
+
+/**
+ * Classic token bucket: holds up to {@code burst} tokens and refills at {@code rate}
+ * tokens per second. Time is passed in so the arithmetic is deterministic to test.
+ */
+final class TokenBucket {
+
+    private final double rate;
+    private final double burst;
+    private double tokens;
+    private long lastNanos;
+
+    TokenBucket(double rate, double burst, long nowNanos) {
+        this.rate = rate;
+        this.burst = burst;
+        this.tokens = burst;
+        this.lastNanos = nowNanos;
+    }
+
+    /**
+     * Takes one token if available.
+     *
+     * @return 0 when the request is admitted, otherwise nanoseconds until a token frees up
+     */
+    synchronized long tryAcquire(long nowNanos) {
+        refill(nowNanos);
+        if (tokens >= 1.0) {
+            tokens -= 1.0;
+            return 0;
+        }
+        return (long) Math.ceil((1.0 - tokens) / rate * 1e9);
+    }
+
+    synchronized int remaining(long nowNanos) {
+        refill(nowNanos);
+        return (int) Math.floor(tokens);
+    }
+
+    /** True once the bucket is full again, i.e. it carries no state worth keeping. */
+    synchronized boolean isIdle(long nowNanos) {
+        refill(nowNanos);
+        return tokens >= burst;
+    }
+
+    private void refill(long nowNanos) {
+        long elapsed = nowNanos - lastNanos;
+        if (elapsed > 0) {
+            tokens = Math.min(burst, tokens + elapsed / 1e9 * rate);
+            lastNanos = nowNanos;
+        }
+    }
+}
@@ -0,0 +1,63 @@
+package tech.kayys.gollek.server.security;
+
+import io.quarkus.websockets.next.HttpUpgradeCheck;
+import io.smallrye.mutiny.Uni;
+import io.vertx.core.http.HttpServerRequest;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+
+import org.jboss.logging.Logger;
+
+import java.util.List;
+import java.util.Map;
+
+/**
+ * The {@link SecurityFilter} and {@link RateLimitFilter} checks for WebSocket handshakes,
+ * which JAX-RS filters never see: a missing key is refused with {@code 401}, an unknown one
+ * with {@code 403} and an over-budget client with {@code 429}, before the connection opens.
+ */
+@ApplicationScoped
+public class WebSocketUpgradeCheck implements HttpUpgradeCheck {
+
+    private static final Logger LOG = Logger.getLogger(WebSocketUpgradeCheck.class);
+
+    @Inject
+    ApiKeyStore apiKeyStore;
+
+    @Inject
+    RateLimiter limiter;
+
+    @Override
+    public Uni<CheckResult> perform(HttpUpgradeContext context) {
+        HttpServerRequest request = context.httpRequest();
+        String apiKey = apiKey(request.getHeader("X-API-Key"), request.getHeader("Authorization"));
+        if (apiKey == null) {
+            LOG.debug("Missing API key for WebSocket " + request.path());
+            return CheckResult.rejectUpgrade(401);
+        }
+        if (!apiKeyStore.listKeys().contains(apiKey)) {
+            LOG.debug("Invalid API key for WebSocket " + request.path());
+            return CheckResult.rejectUpgrade(403);
+        }
+        if (limiter.isEnabled()) {
+            String peer = request.remoteAddress() != null ? request.remoteAddress().hostAddress() : null;
+            RateLimiter.Decision decision = limiter.checkIp(limiter.clientIp(peer, request.getHeader("X-Forwarded-For")));
+            if (decision.allowed()) {
+                decision = limiter.checkApiKey(apiKey);
+            }
+            if (!decision.allowed()) {
+                return CheckResult.rejectUpgrade(429,
+                        Map.of("Retry-After", List.of(String.valueOf(decision.retryAfterSeconds()))));
+            }
+        }
+        return CheckResult.permitUpgrade();
+    }
+
+    /** The key from {@code X-API-Key}, or else from {@code Authorization: Bearer}; null if neither. */
+    public static String apiKey(String xApiKey, String authorization) {
+        if (xApiKey != null && !xApiKey.isBlank()) {
+            return xApiKey;
+        }
+        return SecurityFilter.bearer(authorization);
+    }
+}
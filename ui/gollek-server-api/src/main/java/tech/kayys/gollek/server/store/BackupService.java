@@ -0,0 +1,177 @@
+package tech.kayys.gollek.server.store;
+
+import io.quarkus.scheduler.Scheduled;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.eclipse.microprofile.metrics.MetricRegistry;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.StandardCopyOption;
+import java.time.Instant;
+import java.time.ZoneOffset;
+import java.time.format.DateTimeFormatter;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.stream.Stream;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.cluster.LeaderElection;
+
+/**
+ * Exports store namespaces (conversations and usage by default) to timestamped JSON files
+ * and restores them. Values are copied as stored, so backups of an encrypted store stay
+ * encrypted. Runs on {@code gollek.server.backup.every} (off by default) and on demand
+ * from the admin API.
+ */
+@ApplicationScoped
+public class BackupService {
+
+    private static final Logger LOG = Logger.getLogger(BackupService.class);
+    private static final DateTimeFormatter STAMP = DateTimeFormatter.ofPattern("yyyyMMdd-HHmmss").withZone(ZoneOffset.UTC);
+    private static final String PREFIX = "gollek-backup-";
+
+    public record BackupInfo(String file, long sizeBytes, long createdAt) {
+    }
+
+    @Inject
+    Store store;
+
+    @Inject
+    MetricRegistry registry;
+
+    @ConfigProperty(name = "gollek.server.backup.dir", defaultValue = "./data/backups")
+    String dir;
+
+    @ConfigProperty(name = "gollek.server.backup.namespaces", defaultValue = "conversations,metrics,usage-events,usage-reports")
+    List<String> namespaces;
+
+    @ConfigProperty(name = "gollek.server.backup.keep", defaultValue = "7")
+    int keep;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    @Scheduled(every = "${gollek.server.backup.every:off}", concurrentExecution = Scheduled.ConcurrentExecution.SKIP,
+            skipExecutionIf = LeaderElection.NotLeader.class)
+    void scheduledBackup() {
+        try {
+            backup();
+        } catch (IOException e) {
+            LOG.warn("Scheduled backup failed: " + e.getMessage());
+        }
+    }
+
+    public synchronized BackupInfo backup() throws IOException {
+        Instant now = Instant.now();
+        Map<String, Object> dump = new LinkedHashMap<>();
+        dump.put("created_at", now.getEpochSecond());
+        dump.put("backend", store.backend());
+        Map<String, Object> data = new LinkedHashMap<>();
+        for (String ns : namespaces) {
+            Map<String, String> kv = new LinkedHashMap<>();
+            for (String key : store.keys(ns)) {
+                store.get(ns, key).ifPresent(v -> kv.put(key, v));
+            }
+            Map<String, List<String>> lists = new LinkedHashMap<>();
+            for (String list : store.lists(ns)) {
+                lists.put(list, store.range(ns, list, 0, -1));
+            }
+            data.put(ns, Map.of("kv", kv, "lists", lists));
+        }
+        dump.put("namespaces", data);
+
+        Path target = Path.of(dir).resolve(PREFIX + STAMP.format(now) + ".json");
+        try {
+            Files.createDirectories(target.getParent());
+            Path tmp = target.resolveSibling(target.getFileName() + ".tmp");
+            mapper.writeValue(tmp.toFile(), dump);
+            Files.move(tmp, target, StandardCopyOption.REPLACE_EXISTING, StandardCopyOption.ATOMIC_MOVE);
+        } catch (IOException e) {
+            registry.counter("gollek.backup.failed").inc();
+            throw e;
+        }
+        registry.counter("gollek.backup.completed").inc();
+        LOG.infof("Store backup written to %s", target);
+        prune();
+        return new BackupInfo(target.toString(), Files.size(target), now.getEpochSecond());
+    }
+
+    /**
+     * Replaces each namespace held in backup {@code file} (a file name, or a path from
+     * {@link #list}) with the backed-up keys and lists; {@code only}, when not empty,
+     * restricts the restore to those namespaces. Returns the entries written per namespace.
+     *
+     * @throws IllegalArgumentException if there is no such backup in the backup directory
+     */
+    public synchronized Map<String, Integer> restore(String file, List<String> only) throws IOException {
+        Path source = Path.of(dir).resolve(Path.of(file).getFileName().toString());
+        if (!isBackup(source) || !Files.isRegularFile(source)) {
+            throw new IllegalArgumentException("no such backup: " + file);
+        }
+        JsonNode data = mapper.readTree(source.toFile()).path("namespaces");
+        Map<String, Integer> restored = new LinkedHashMap<>();
+        for (Map.Entry<String, JsonNode> ns : data.properties()) {
+            if (only != null && !only.isEmpty() && !only.contains(ns.getKey())) {
+                continue;
+            }
+            String namespace = ns.getKey();
+            for (String key : store.keys(namespace)) {
+                store.delete(namespace, key);
+            }
+            for (String list : store.lists(namespace)) {
+                store.deleteList(namespace, list);
+            }
+            int written = 0;
+            for (Map.Entry<String, JsonNode> kv : ns.getValue().path("kv").properties()) {
+                store.put(namespace, kv.getKey(), kv.getValue().asText());
+                written++;
+            }
+            for (Map.Entry<String, JsonNode> list : ns.getValue().path("lists").properties()) {
+                for (JsonNode value : list.getValue()) {
+                    store.append(namespace, list.getKey(), value.asText());
+                    written++;
+                }
+            }
+            restored.put(namespace, written);
+        }
+        registry.counter("gollek.backup.restored").inc();
+        LOG.infof("Store restored from %s: %s", source, restored);
+        return restored;
+    }
+
+    public List<BackupInfo> list() throws IOException {
+        Path d = Path.of(dir);
+        if (!Files.isDirectory(d)) {
+            return List.of();
+        }
+        List<BackupInfo> out = new ArrayList<>();
+        try (Stream<Path> files = Files.list(d)) {
+            for (Path p : files.filter(BackupService::isBackup).sorted().toList()) {
+                out.add(new BackupInfo(p.toString(), Files.size(p), Files.getLastModifiedTime(p).toInstant().getEpochSecond()));
+            }
+        }
+        return out;
+    }
+
+    private void prune() throws IOException {
+        if (keep <= 0) {
+            return;
+        }
+        List<BackupInfo> all = list();
+        for (int i = 0; i < all.size() - keep; i++) {
+            Files.deleteIfExists(Path.of(all.get(i).file()));
+        }
+    }
+
+    private static boolean isBackup(Path p) {
+        String name = p.getFileName().toString();
+        return name.startsWith(PREFIX) && name.endsWith(".json");
+    }
+}
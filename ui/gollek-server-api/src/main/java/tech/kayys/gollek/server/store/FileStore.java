@@ -0,0 +1,143 @@
+package tech.kayys.gollek.server.store;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.StandardCopyOption;
+import java.util.ArrayList;
+import java.util.HashMap;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+/**
+ * Single-node store that keeps each namespace in {@code <dir>/<namespace>.json}.
+ * Every write rewrites that namespace's file through a temp file, so it suits small
+ * amounts of state; use a database backend for anything larger.
+ *
+ * <p>A namespace file that exists but cannot be read is an error, never an empty
+ * namespace: the next write would replace whatever the file held.
+ */
+@ApplicationScoped
+@StoreBackend("file")
+public class FileStore implements Store {
+
+    private static final Logger LOG = Logger.getLogger(FileStore.class);
+
+    @ConfigProperty(name = "gollek.server.store.file.dir", defaultValue = "./data/store")
+    String dir;
+
+    static class Namespace {
+        public Map<String, String> kv = new LinkedHashMap<>();
+        public Map<String, List<String>> lists = new LinkedHashMap<>();
+    }
+
+    private final Map<String, Namespace> loaded = new HashMap<>();
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    @Override
+    public String backend() {
+        return "file";
+    }
+
+    @Override
+    public synchronized Optional<String> get(String namespace, String key) {
+        return Optional.ofNullable(ns(namespace).kv.get(key));
+    }
+
+    @Override
+    public synchronized void put(String namespace, String key, String value) {
+        ns(namespace).kv.put(key, value);
+        persist(namespace);
+    }
+
+    @Override
+    public synchronized boolean delete(String namespace, String key) {
+        boolean removed = ns(namespace).kv.remove(key) != null;
+        if (removed) persist(namespace);
+        return removed;
+    }
+
+    @Override
+    public synchronized boolean compareAndSet(String namespace, String key, String expected, String value) {
+        if (!java.util.Objects.equals(ns(namespace).kv.get(key), expected)) {
+            return false;
+        }
+        put(namespace, key, value);
+        return true;
+    }
+
+    @Override
+    public synchronized List<String> keys(String namespace) {
+        return List.copyOf(ns(namespace).kv.keySet());
+    }
+
+    @Override
+    public synchronized List<String> lists(String namespace) {
+        return List.copyOf(ns(namespace).lists.keySet());
+    }
+
+    @Override
+    public synchronized void append(String namespace, String list, String value) {
+        ns(namespace).lists.computeIfAbsent(list, k -> new ArrayList<>()).add(value);
+        persist(namespace);
+    }
+
+    @Override
+    public synchronized List<String> range(String namespace, String list, int offset, int limit) {
+        return Stores.slice(ns(namespace).lists.getOrDefault(list, List.of()), offset, limit);
+    }
+
+    @Override
+    public synchronized void deleteList(String namespace, String list) {
+        if (ns(namespace).lists.remove(list) != null) persist(namespace);
+    }
+
+    @Override
+    public synchronized void trim(String namespace, String list, int keep) {
+        List<String> entries = ns(namespace).lists.get(list);
+        if (entries != null && entries.size() > Math.max(0, keep)) {
+            entries.subList(0, entries.size() - Math.max(0, keep)).clear();
+            persist(namespace);
+        }
+    }
+
+    private Namespace ns(String namespace) {
+        return loaded.computeIfAbsent(namespace, n -> {
+            Path p = file(n);
+            if (!Files.exists(p)) {
+                return new Namespace();
+            }
+            try {
+                return mapper.readValue(p.toFile(), Namespace.class);
+            } catch (IOException e) {
+                // not cached, so every later read and write fails too instead of overwriting the file
+                throw new IllegalStateException("Failed to load store file " + p + " (fix or move it away): "
+                        + e.getMessage(), e);
+            }
+        });
+    }
+
+    private void persist(String namespace) {
+        Path p = file(namespace);
+        try {
+            Files.createDirectories(p.getParent());
+            Path tmp = p.resolveSibling(p.getFileName() + ".tmp");
+            mapper.writeValue(tmp.toFile(), loaded.get(namespace));
+            Files.move(tmp, p, StandardCopyOption.REPLACE_EXISTING, StandardCopyOption.ATOMIC_MOVE);
+        } catch (IOException e) {
+            LOG.warn("Failed to persist store file " + p + ": " + e.getMessage());
+        }
+    }
+
+    private Path file(String namespace) {
+        return Path.of(dir).toAbsolutePath().resolve(namespace + ".json");
+    }
+}
@@ -0,0 +1,98 @@
+package tech.kayys.gollek.server.store;
+
+import jakarta.enterprise.context.ApplicationScoped;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Process-local store; contents are lost on restart. Used in tests and for
+ * throwaway deployments.
+ */
+@ApplicationScoped
+@StoreBackend("memory")
+public class InMemoryStore implements Store {
+
+    private final Map<String, Map<String, String>> kv = new ConcurrentHashMap<>();
+    private final Map<String, Map<String, List<String>>> lists = new ConcurrentHashMap<>();
+
+    @Override
+    public String backend() {
+        return "memory";
+    }
+
+    @Override
+    public Optional<String> get(String namespace, String key) {
+        return Optional.ofNullable(ns(namespace).get(key));
+    }
+
+    @Override
+    public void put(String namespace, String key, String value) {
+        ns(namespace).put(key, value);
+    }
+
+    @Override
+    public boolean delete(String namespace, String key) {
+        return ns(namespace).remove(key) != null;
+    }
+
+    @Override
+    public boolean compareAndSet(String namespace, String key, String expected, String value) {
+        return expected == null
+                ? ns(namespace).putIfAbsent(key, value) == null
+                : ns(namespace).replace(key, expected, value);
+    }
+
+    @Override
+    public List<String> keys(String namespace) {
+        return List.copyOf(ns(namespace).keySet());
+    }
+
+    @Override
+    public List<String> lists(String namespace) {
+        return List.copyOf(lists.getOrDefault(namespace, Map.of()).keySet());
+    }
+
+    @Override
+    public void append(String namespace, String list, String value) {
+        List<String> entries = lists.computeIfAbsent(namespace, k -> new ConcurrentHashMap<>())
+                .computeIfAbsent(list, k -> new ArrayList<>());
+        synchronized (entries) {
+            entries.add(value);
+        }
+    }
+
+    @Override
+    public List<String> range(String namespace, String list, int offset, int limit) {
+        List<String> entries = lists.getOrDefault(namespace, Map.of()).get(list);
+        if (entries == null) {
+            return List.of();
+        }
+        synchronized (entries) {
+            return Stores.slice(entries, offset, limit);
+        }
+    }
+
+    @Override
+    public void deleteList(String namespace, String list) {
+        lists.getOrDefault(namespace, Map.of()).remove(list);
+    }
+
+    @Override
+    public void trim(String namespace, String list, int keep) {
+        List<String> entries = lists.getOrDefault(namespace, Map.of()).get(list);
+        if (entries == null) {
+            return;
+        }
+        synchronized (entries) {
+            entries.subList(0, Math.max(0, entries.size() - Math.max(0, keep))).clear();
+        }
+    }
+
+    private Map<String, String> ns(String namespace) {
+        return kv.computeIfAbsent(namespace, k -> new ConcurrentHashMap<>());
+    }
+}
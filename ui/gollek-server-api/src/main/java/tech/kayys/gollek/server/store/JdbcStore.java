@@ -0,0 +1,197 @@
+package tech.kayys.gollek.server.store;
+
+import io.agroal.api.AgroalDataSource;
+import io.agroal.api.configuration.supplier.AgroalDataSourceConfigurationSupplier;
+import io.agroal.api.security.NamePrincipal;
+import io.agroal.api.security.SimplePassword;
+import jakarta.annotation.PreDestroy;
+import jakarta.enterprise.context.ApplicationScoped;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.sql.Connection;
+import java.sql.PreparedStatement;
+import java.sql.ResultSet;
+import java.sql.SQLException;
+import java.sql.Statement;
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Optional;
+
+/**
+ * SQL store for SQLite ({@code jdbc:sqlite:./data/gollek.db}) and Postgres
+ * ({@code jdbc:postgresql://host/db}). Both dialects share the same upsert and paging
+ * syntax; only the list table's auto-increment column differs.
+ *
+ * <p>Connections come from an Agroal pool built from the URL at first use. SQLite
+ * allows one writer at a time, so its pool holds a single connection.
+ */
+@ApplicationScoped
+@StoreBackend("jdbc")
+public class JdbcStore implements Store {
+
+    private static final Logger LOG = Logger.getLogger(JdbcStore.class);
+
+    @ConfigProperty(name = "gollek.server.store.jdbc.url", defaultValue = "jdbc:sqlite:./data/gollek.db")
+    String url;
+
+    @ConfigProperty(name = "gollek.server.store.jdbc.username")
+    Optional<String> username;
+
+    @ConfigProperty(name = "gollek.server.store.jdbc.password")
+    Optional<String> password;
+
+    @ConfigProperty(name = "gollek.server.store.jdbc.pool-size", defaultValue = "8")
+    int poolSize;
+
+    private volatile AgroalDataSource pool;
+
+    @Override
+    public String backend() {
+        return url.startsWith("jdbc:postgresql:") ? "postgres" : "sqlite";
+    }
+
+    @Override
+    public Optional<String> get(String namespace, String key) {
+        return query("SELECT v FROM gollek_kv WHERE ns = ? AND k = ?", namespace, key).stream().findFirst();
+    }
+
+    @Override
+    public void put(String namespace, String key, String value) {
+        update("INSERT INTO gollek_kv (ns, k, v) VALUES (?, ?, ?) "
+                + "ON CONFLICT (ns, k) DO UPDATE SET v = excluded.v", namespace, key, value);
+    }
+
+    @Override
+    public boolean delete(String namespace, String key) {
+        return update("DELETE FROM gollek_kv WHERE ns = ? AND k = ?", namespace, key) > 0;
+    }
+
+    @Override
+    public boolean compareAndSet(String namespace, String key, String expected, String value) {
+        if (expected == null) {
+            return update("INSERT INTO gollek_kv (ns, k, v) VALUES (?, ?, ?) ON CONFLICT (ns, k) DO NOTHING",
+                    namespace, key, value) > 0;
+        }
+        return update("UPDATE gollek_kv SET v = ? WHERE ns = ? AND k = ? AND v = ?", value, namespace, key,
+                expected) > 0;
+    }
+
+    @Override
+    public List<String> keys(String namespace) {
+        return query("SELECT k FROM gollek_kv WHERE ns = ? ORDER BY k", namespace);
+    }
+
+    @Override
+    public List<String> lists(String namespace) {
+        return query("SELECT DISTINCT name FROM gollek_list WHERE ns = ? ORDER BY name", namespace);
+    }
+
+    @Override
+    public void append(String namespace, String list, String value) {
+        update("INSERT INTO gollek_list (ns, name, v) VALUES (?, ?, ?)", namespace, list, value);
+    }
+
+    @Override
+    public List<String> range(String namespace, String list, int offset, int limit) {
+        return query("SELECT v FROM gollek_list WHERE ns = ? AND name = ? ORDER BY id LIMIT ? OFFSET ?",
+                namespace, list, limit < 0 ? Integer.MAX_VALUE : limit, Math.max(0, offset));
+    }
+
+    @Override
+    public void deleteList(String namespace, String list) {
+        update("DELETE FROM gollek_list WHERE ns = ? AND name = ?", namespace, list);
+    }
+
+    @Override
+    public void trim(String namespace, String list, int keep) {
+        update("DELETE FROM gollek_list WHERE ns = ? AND name = ? AND id NOT IN "
+                + "(SELECT id FROM gollek_list WHERE ns = ? AND name = ? ORDER BY id DESC LIMIT ?)",
+                namespace, list, namespace, list, Math.max(0, keep));
+    }
+
+    private Connection connect() throws SQLException {
+        AgroalDataSource ds = pool;
+        if (ds == null) {
+            synchronized (this) {
+                ds = pool;
+                if (ds == null) {
+                    ds = openPool();
+                    try (Connection c = ds.getConnection()) {
+                        createSchema(c);
+                    } catch (SQLException e) {
+                        ds.close();
+                        throw e;
+                    }
+                    pool = ds;
+                }
+            }
+        }
+        return ds.getConnection();
+    }
+
+    private AgroalDataSource openPool() throws SQLException {
+        int size = "sqlite".equals(backend()) ? 1 : Math.max(1, poolSize);
+        var config = new AgroalDataSourceConfigurationSupplier()
+                .connectionPoolConfiguration(cp -> cp
+                        .minSize(0)
+                        .maxSize(size)
+                        .acquisitionTimeout(Duration.ofSeconds(10))
+                        .connectionFactoryConfiguration(cf -> {
+                            cf.jdbcUrl(url);
+                            username.ifPresent(u -> cf.principal(new NamePrincipal(u)));
+                            password.ifPresent(p -> cf.credential(new SimplePassword(p)));
+                            return cf;
+                        }));
+        return AgroalDataSource.from(config);
+    }
+
+    @PreDestroy
+    synchronized void close() {
+        if (pool != null) {
+            pool.close();
+            pool = null;
+        }
+    }
+
+    private void createSchema(Connection c) throws SQLException {
+        String id = "postgres".equals(backend()) ? "id BIGSERIAL PRIMARY KEY" : "id INTEGER PRIMARY KEY AUTOINCREMENT";
+        try (Statement st = c.createStatement()) {
+            st.execute("CREATE TABLE IF NOT EXISTS gollek_kv (ns VARCHAR(128) NOT NULL, k VARCHAR(512) NOT NULL, "
+                    + "v TEXT NOT NULL, PRIMARY KEY (ns, k))");
+            st.execute("CREATE TABLE IF NOT EXISTS gollek_list (" + id + ", ns VARCHAR(128) NOT NULL, "
+                    + "name VARCHAR(512) NOT NULL, v TEXT NOT NULL)");
+            st.execute("CREATE INDEX IF NOT EXISTS gollek_list_ns_name ON gollek_list (ns, name, id)");
+        }
+        LOG.infof("JDBC store ready (%s)", backend());
+    }
+
+    private List<String> query(String sql, Object... args) {
+        try (Connection c = connect(); PreparedStatement ps = prepare(c, sql, args); ResultSet rs = ps.executeQuery()) {
+            List<String> out = new ArrayList<>();
+            while (rs.next()) {
+                out.add(rs.getString(1));
+            }
+            return out;
+        } catch (SQLException e) {
+            throw new IllegalStateException("Store query failed: " + e.getMessage(), e);
+        }
+    }
+
+    private int update(String sql, Object... args) {
+        try (Connection c = connect(); PreparedStatement ps = prepare(c, sql, args)) {
+            return ps.executeUpdate();
+        } catch (SQLException e) {
+            throw new IllegalStateException("Store update failed: " + e.getMessage(), e);
+        }
+    }
+
+    private static PreparedStatement prepare(Connection c, String sql, Object... args) throws SQLException {
+        PreparedStatement ps = c.prepareStatement(sql);
+        for (int i = 0; i < args.length; i++) {
+            ps.setObject(i + 1, args[i]);
+        }
+        return ps;
+    }
+}
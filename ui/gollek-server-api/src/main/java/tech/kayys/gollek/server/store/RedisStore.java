@@ -0,0 +1,128 @@
+package tech.kayys.gollek.server.store;
+
+import io.quarkus.redis.datasource.RedisDataSource;
+import io.quarkus.redis.datasource.keys.KeyScanArgs;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.Instance;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Optional;
+
+/**
+ * Redis-backed store for multi-instance deployments. Each namespace is one hash
+ * ({@code <prefix>:<ns>}) plus one Redis list per named list ({@code <prefix>:<ns>:list:<name>}).
+ * Connection settings come from the standard {@code quarkus.redis.*} properties.
+ */
+@ApplicationScoped
+@StoreBackend("redis")
+public class RedisStore implements Store {
+
+    /** HSET only if the field still holds ARGV[2], in one atomic step. */
+    private static final String COMPARE_AND_SET = "if redis.call('HGET', KEYS[1], ARGV[1]) == ARGV[2] then "
+            + "redis.call('HSET', KEYS[1], ARGV[1], ARGV[3]) return 1 else return 0 end";
+
+    @Inject
+    Instance<RedisDataSource> redis;
+
+    @ConfigProperty(name = "gollek.server.store.redis.prefix", defaultValue = "gollek")
+    String prefix;
+
+    @Override
+    public String backend() {
+        return "redis";
+    }
+
+    @Override
+    public Optional<String> get(String namespace, String key) {
+        return Optional.ofNullable(redis.get().hash(String.class).hget(hashKey(namespace), key));
+    }
+
+    @Override
+    public void put(String namespace, String key, String value) {
+        redis.get().hash(String.class).hset(hashKey(namespace), key, value);
+    }
+
+    @Override
+    public boolean delete(String namespace, String key) {
+        return redis.get().hash(String.class).hdel(hashKey(namespace), key) > 0;
+    }
+
+    @Override
+    public boolean compareAndSet(String namespace, String key, String expected, String value) {
+        if (expected == null) {
+            return redis.get().hash(String.class).hsetnx(hashKey(namespace), key, value);
+        }
+        return redis.get().execute("EVAL", COMPARE_AND_SET, "1", hashKey(namespace), key, expected, value)
+                .toInteger() == 1;
+    }
+
+    @Override
+    public List<String> keys(String namespace) {
+        return redis.get().hash(String.class).hkeys(hashKey(namespace));
+    }
+
+    @Override
+    public List<String> lists(String namespace) {
+        String listPrefix = listKey(namespace, "");
+        List<String> out = new ArrayList<>();
+        var cursor = redis.get().key().scan(new KeyScanArgs().match(globEscape(listPrefix) + "*"));
+        while (cursor.hasNext()) {
+            for (String key : cursor.next()) {
+                out.add(key.substring(listPrefix.length()));
+            }
+        }
+        return out;
+    }
+
+    @Override
+    public void append(String namespace, String list, String value) {
+        redis.get().list(String.class).rpush(listKey(namespace, list), value);
+    }
+
+    @Override
+    public List<String> range(String namespace, String list, int offset, int limit) {
+        long start = Math.max(0, offset);
+        long stop = limit < 0 ? -1 : start + limit - 1;
+        if (limit == 0) {
+            return List.of();
+        }
+        return redis.get().list(String.class).lrange(listKey(namespace, list), start, stop);
+    }
+
+    @Override
+    public void deleteList(String namespace, String list) {
+        redis.get().key().del(listKey(namespace, list));
+    }
+
+    @Override
+    public void trim(String namespace, String list, int keep) {
+        if (keep <= 0) {
+            deleteList(namespace, list);
+        } else {
+            redis.get().list(String.class).ltrim(listKey(namespace, list), -keep, -1);
+        }
+    }
+
+    /** {@code s} as a literal in a SCAN/KEYS pattern. */
+    static String globEscape(String s) {
+        StringBuilder out = new StringBuilder(s.length());
+        for (char c : s.toCharArray()) {
+            if (c == '*' || c == '?' || c == '[' || c == ']' || c == '\\') {
+                out.append('\\');
+            }
+            out.append(c);
+        }
+        return out.toString();
+    }
+
+    private String hashKey(String namespace) {
+        return prefix + ":" + namespace;
+    }
+
+    private String listKey(String namespace, String list) {
+        return prefix + ":" + namespace + ":list:" + list;
+    }
+}
@@ -0,0 +1,64 @@
+package tech.kayys.gollek.server.store;
+
+import io.quarkus.scheduler.Scheduled;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.inject.Inject;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.eclipse.microprofile.metrics.MetricRegistry;
+import org.jboss.logging.Logger;
+
+import java.time.Duration;
+import java.time.Instant;
+import java.util.Optional;
+
+import tech.kayys.gollek.server.cluster.LeaderElection;
+import tech.kayys.gollek.server.conversations.ConversationStore;
+
+/**
+ * Enforces TTL-based retention on stored prompt content. Conversations untouched for
+ * longer than {@code gollek.server.retention.conversations.max-age} are deleted hourly,
+ * by the leader only when replicas elect one.
+ */
+@ApplicationScoped
+public class RetentionService {
+
+    private static final Logger LOG = Logger.getLogger(RetentionService.class);
+
+    public record Policy(Long conversationsMaxAgeSeconds) {
+    }
+
+    public record Result(int conversationsDeleted) {
+    }
+
+    @Inject
+    ConversationStore conversations;
+
+    @Inject
+    MetricRegistry registry;
+
+    @ConfigProperty(name = "gollek.server.retention.conversations.max-age")
+    Optional<Duration> conversationsMaxAge;
+
+    public Policy policy() {
+        return new Policy(conversationsMaxAge.map(Duration::toSeconds).orElse(null));
+    }
+
+    @Scheduled(every = "1h", delayed = "1m", concurrentExecution = Scheduled.ConcurrentExecution.SKIP,
+            skipExecutionIf = LeaderElection.NotLeader.class)
+    void scheduledEnforce() {
+        enforce();
+    }
+
+    public Result enforce() {
+        int deleted = 0;
+        if (conversationsMaxAge.isPresent()) {
+            Instant cutoff = Instant.now().minus(conversationsMaxAge.get());
+            deleted = conversations.deleteUpdatedBefore(cutoff);
+            if (deleted > 0) {
+                registry.counter("gollek.retention.conversations.deleted").inc(deleted);
+                LOG.infof("Retention removed %d conversations idle since before %s", deleted, cutoff);
+            }
+        }
+        return new Result(deleted);
+    }
+}
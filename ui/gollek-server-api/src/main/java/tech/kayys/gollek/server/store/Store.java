@@ -0,0 +1,44 @@
+package tech.kayys.gollek.server.store;
+
+import java.util.List;
+import java.util.Optional;
+
+/**
+ * Storage backend shared by server-side state (conversations, jobs, usage, caches).
+ * Values are opaque strings, usually JSON; callers own serialization and encryption.
+ *
+ * <p>Every key lives in a namespace so independent features never collide. Lists are
+ * append-only logs read back in insertion order.
+ */
+public interface Store {
+
+    String backend();
+
+    Optional<String> get(String namespace, String key);
+
+    void put(String namespace, String key, String value);
+
+    boolean delete(String namespace, String key);
+
+    /**
+     * Atomically sets {@code key} to {@code value} if it currently holds {@code expected}
+     * ({@code null}: if it is absent). Returns whether the value was written; replicas
+     * sharing a store use it for leases.
+     */
+    boolean compareAndSet(String namespace, String key, String expected, String value);
+
+    List<String> keys(String namespace);
+
+    /** Names of the non-empty lists in a namespace. */
+    List<String> lists(String namespace);
+
+    void append(String namespace, String list, String value);
+
+    /** Returns up to {@code limit} entries starting at {@code offset}; a negative limit means all. */
+    List<String> range(String namespace, String list, int offset, int limit);
+
+    void deleteList(String namespace, String list);
+
+    /** Drops all but the newest {@code keep} entries of a list. */
+    void trim(String namespace, String list, int keep);
+}
@@ -0,0 +1,22 @@
+package tech.kayys.gollek.server.store;
+
+import jakarta.inject.Qualifier;
+import java.lang.annotation.Documented;
+import java.lang.annotation.Retention;
+import java.lang.annotation.Target;
+
+import static java.lang.annotation.ElementType.*;
+import static java.lang.annotation.RetentionPolicy.RUNTIME;
+
+/**
+ * CDI qualifier that selects the {@link Store} implementation for a backend name.
+ *
+ * <p>Valid values: {@code "memory"}, {@code "file"}, {@code "jdbc"}, {@code "redis"}.
+ */
+@Qualifier
+@Documented
+@Retention(RUNTIME)
+@Target({TYPE, METHOD, FIELD, PARAMETER})
+public @interface StoreBackend {
+    String value();
+}
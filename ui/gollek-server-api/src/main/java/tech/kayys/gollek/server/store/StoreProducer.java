@@ -0,0 +1,54 @@
+package tech.kayys.gollek.server.store;
+
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.inject.Instance;
+import jakarta.enterprise.inject.Produces;
+import jakarta.inject.Inject;
+import jakarta.inject.Singleton;
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+/**
+ * Produces the active {@link Store} from {@code gollek.server.store.backend}:
+ * {@code file} (default), {@code memory}, {@code sqlite}, {@code postgres} or {@code redis}.
+ * Mirrors {@code PromptCacheBeanProducer}; consumers inject {@link Store} and never an
+ * implementation class.
+ */
+@ApplicationScoped
+public class StoreProducer {
+
+    private static final Logger LOG = Logger.getLogger(StoreProducer.class);
+
+    @ConfigProperty(name = "gollek.server.store.backend", defaultValue = "file")
+    String backend;
+
+    @Inject
+    @StoreBackend("memory")
+    Instance<Store> memory;
+
+    @Inject
+    @StoreBackend("file")
+    Instance<Store> file;
+
+    @Inject
+    @StoreBackend("jdbc")
+    Instance<Store> jdbc;
+
+    @Inject
+    @StoreBackend("redis")
+    Instance<Store> redis;
+
+    @Produces
+    @Singleton
+    public Store store() {
+        Store store = switch (backend.trim().toLowerCase()) {
+            case "memory" -> memory.get();
+            case "sqlite", "postgres", "jdbc" -> jdbc.get();
+            case "redis" -> redis.get();
+            case "file" -> file.get();
+            default -> throw new IllegalStateException("Unknown gollek.server.store.backend: " + backend);
+        };
+        LOG.infof("Server store backend: %s", store.backend());
+        return store;
+    }
+}
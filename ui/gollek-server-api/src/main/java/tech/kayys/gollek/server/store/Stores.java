@@ -0,0 +1,15 @@
+package tech.kayys.gollek.server.store;
+
+import java.util.List;
+
+final class Stores {
+
+    private Stores() {
+    }
+
+    static List<String> slice(List<String> entries, int offset, int limit) {
+        int from = Math.min(Math.max(0, offset), entries.size());
+        int to = limit < 0 ? entries.size() : (int) Math.min(entries.size(), (long) from + limit);
+        return List.copyOf(entries.subList(from, to));
+    }
+}
@@ -0,0 +1,79 @@
+package tech.kayys.gollek.server.transform;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.node.ObjectNode;
+
+import jakarta.inject.Inject;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.HttpHeaders;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.UriInfo;
+import jakarta.ws.rs.ext.Provider;
+import jakarta.ws.rs.ext.ReaderInterceptor;
+import jakarta.ws.rs.ext.ReaderInterceptorContext;
+import jakarta.ws.rs.ext.WriterInterceptor;
+import jakarta.ws.rs.ext.WriterInterceptorContext;
+
+import java.io.ByteArrayInputStream;
+import java.io.IOException;
+
+/**
+ * Applies {@link Transforms} to JSON request bodies before they are read and to JSON
+ * responses before they are written. Streamed events are left alone. Routes are matched
+ * after API version negotiation, so {@code /v2} requests use their {@code v1/} route.
+ */
+@Provider
+public class TransformInterceptor implements ReaderInterceptor, WriterInterceptor {
+
+    @Inject
+    Transforms transforms;
+
+    @Inject
+    ObjectMapper mapper;
+
+    @Context
+    UriInfo uriInfo;
+
+    @Context
+    HttpHeaders headers;
+
+    @Override
+    public Object aroundReadFrom(ReaderInterceptorContext context) throws IOException {
+        if (transforms.isEmpty() || !isJson(context.getMediaType()) || uriInfo == null) {
+            return context.proceed();
+        }
+        var ops = transforms.requestOps(uriInfo.getPath(), context.getHeaders()::getFirst);
+        if (ops.isEmpty()) {
+            return context.proceed();
+        }
+        JsonNode body = mapper.readTree(context.getInputStream());
+        if (body instanceof ObjectNode object) {
+            Transforms.apply(object, ops);
+        }
+        context.setInputStream(new ByteArrayInputStream(body == null ? new byte[0] : mapper.writeValueAsBytes(body)));
+        return context.proceed();
+    }
+
+    @Override
+    public void aroundWriteTo(WriterInterceptorContext context) throws IOException {
+        if (transforms.isEmpty() || !isJson(context.getMediaType()) || uriInfo == null || headers == null) {
+            context.proceed();
+            return;
+        }
+        var ops = transforms.responseOps(uriInfo.getPath(), headers::getHeaderString);
+        if (!ops.isEmpty() && context.getEntity() != null
+                && mapper.valueToTree(context.getEntity()) instanceof ObjectNode object) {
+            Transforms.apply(object, ops);
+            context.setEntity(object);
+            context.setType(ObjectNode.class);
+            context.setGenericType(ObjectNode.class);
+        }
+        context.proceed();
+    }
+
+    private static boolean isJson(MediaType type) {
+        return type != null && (MediaType.APPLICATION_JSON_TYPE.isCompatible(type)
+                || type.getSubtype().endsWith("+json"));
+    }
+}
@@ -0,0 +1,215 @@
+package tech.kayys.gollek.server.transform;
+
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.core.type.TypeReference;
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.node.ArrayNode;
+import com.fasterxml.jackson.databind.node.ObjectNode;
+
+import io.quarkus.runtime.StartupEvent;
+import jakarta.enterprise.context.ApplicationScoped;
+import jakarta.enterprise.event.Observes;
+
+import org.eclipse.microprofile.config.inject.ConfigProperty;
+import org.jboss.logging.Logger;
+
+import java.io.IOException;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.function.Function;
+import java.util.regex.Pattern;
+import java.util.stream.Stream;
+
+/**
+ * Field mappings for legacy clients, so an odd client needs a config entry rather than
+ * code. {@code gollek.server.transforms-file} is a JSON array of rules:
+ *
+ * <pre>{@code
+ * [{"route": "v1/chat/completions", "headers": {"X-Client": "crm"},
+ *   "request":  [{"op": "rename", "field": "max_length", "to": "max_tokens"},
+ *                {"op": "default", "field": "temperature", "value": 0.3},
+ *                {"op": "split", "field": "stop", "separator": "|"},
+ *                {"op": "drop", "field": "session"}],
+ *   "response": [{"op": "rename", "field": "usage.total_tokens", "to": "usage.tokens"}]}]
+ * }</pre>
+ *
+ * A rule applies to JSON bodies on its route when every listed header matches. Fields
+ * are dotted paths into objects. {@code rename} moves a field unless the target is
+ * already set, {@code default} fills a missing field, {@code drop} removes one and
+ * {@code split} turns a string into a list of strings. Rules apply in file order.
+ */
+@ApplicationScoped
+public class Transforms {
+
+    private static final Logger LOG = Logger.getLogger(Transforms.class);
+
+    /** One mapping step. */
+    @JsonInclude(JsonInclude.Include.NON_NULL)
+    public record Op(String op, String field, String to, JsonNode value, String separator) {
+    }
+
+    /** The mappings of one route, for clients whose headers match. */
+    public record Rule(String route, Map<String, String> headers, List<Op> request, List<Op> response) {
+
+        boolean matches(String path, Function<String, String> header) {
+            String p = path.startsWith("/") ? path.substring(1) : path;
+            if (route == null || !route.replaceFirst("^/", "").equals(p)) {
+                return false;
+            }
+            if (headers != null) {
+                for (var entry : headers.entrySet()) {
+                    if (!entry.getValue().equals(header.apply(entry.getKey()))) {
+                        return false;
+                    }
+                }
+            }
+            return true;
+        }
+    }
+
+    @ConfigProperty(name = "gollek.server.transforms-file")
+    Optional<String> transformsFile;
+
+    private final ObjectMapper mapper = new ObjectMapper();
+    private volatile List<Rule> rules = List.of();
+
+    void onStart(@Observes StartupEvent event) {
+        transformsFile.filter(f -> !f.isBlank()).ifPresent(this::load);
+    }
+
+    void load(String file) {
+        List<Rule> loaded;
+        try {
+            loaded = mapper.readValue(Files.readString(Path.of(file)), new TypeReference<List<Rule>>() {
+            });
+        } catch (IOException e) {
+            LOG.warnf("Could not read transforms file %s: %s", file, e.getMessage());
+            return;
+        }
+        try {
+            setRules(loaded);
+        } catch (IllegalArgumentException e) {
+            LOG.warnf("Ignoring transforms file %s: %s", file, e.getMessage());
+            return;
+        }
+        LOG.infof("Loaded %d request transformation rules from %s", loaded.size(), file);
+    }
+
+    /**
+     * @throws IllegalArgumentException if a step is malformed
+     */
+    void setRules(List<Rule> rules) {
+        rules.forEach(rule -> Stream.concat(steps(rule.request()), steps(rule.response())).forEach(Transforms::validate));
+        this.rules = List.copyOf(rules);
+    }
+
+    public boolean isEmpty() {
+        return rules.isEmpty();
+    }
+
+    /** Request steps for {@code path}, in file order; empty when no rule matches. */
+    public List<Op> requestOps(String path, Function<String, String> header) {
+        return rules.stream().filter(r -> r.request() != null && r.matches(path, header))
+                .flatMap(r -> r.request().stream()).toList();
+    }
+
+    /** Response steps for {@code path}, in file order; empty when no rule matches. */
+    public List<Op> responseOps(String path, Function<String, String> header) {
+        return rules.stream().filter(r -> r.response() != null && r.matches(path, header))
+                .flatMap(r -> r.response().stream()).toList();
+    }
+
+    /** Applies {@code ops} to {@code body} in place. */
+    public static void apply(ObjectNode body, List<Op> ops) {
+        for (Op op : ops) {
+            switch (op.op()) {
+                case "rename" -> {
+                    JsonNode value = get(body, op.field());
+                    if (value != null && get(body, op.to()) == null) {
+                        remove(body, op.field());
+                        put(body, op.to(), value);
+                    }
+                }
+                case "default" -> {
+                    if (get(body, op.field()) == null) {
+                        put(body, op.field(), op.value());
+                    }
+                }
+                case "drop" -> remove(body, op.field());
+                case "split" -> {
+                    if (get(body, op.field()) instanceof JsonNode text && text.isTextual()) {
+                        ArrayNode list = body.arrayNode();
+                        for (String part : text.asText().split(Pattern.quote(op.separator()), -1)) {
+                            if (!part.isEmpty()) {
+                                list.add(part);
+                            }
+                        }
+                        put(body, op.field(), list);
+                    }
+                }
+                default -> throw new IllegalStateException("unknown op " + op.op());
+            }
+        }
+    }
+
+    static void validate(Op op) {
+        if (op.op() == null || op.field() == null || op.field().isBlank()) {
+            throw new IllegalArgumentException("transform needs op and field: " + op);
+        }
+        switch (op.op()) {
+            case "rename" -> {
+                if (op.to() == null || op.to().isBlank()) {
+                    throw new IllegalArgumentException("rename of " + op.field() + " needs to");
+                }
+            }
+            case "default" -> {
+                if (op.value() == null) {
+                    throw new IllegalArgumentException("default of " + op.field() + " needs value");
+                }
+            }
+            case "split" -> {
+                if (op.separator() == null || op.separator().isEmpty()) {
+                    throw new IllegalArgumentException("split of " + op.field() + " needs separator");
+                }
+            }
+            case "drop" -> {
+            }
+            default -> throw new IllegalArgumentException("unknown transform op " + op.op()
+                    + "; expected rename, default, drop or split");
+        }
+    }
+
+    private static JsonNode get(ObjectNode body, String path) {
+        JsonNode node = body;
+        for (String part : path.split("\\.")) {
+            node = node == null || !node.isObject() ? null : node.get(part);
+        }
+        return node == null || node.isMissingNode() ? null : node;
+    }
+
+    private static void put(ObjectNode body, String path, JsonNode value) {
+        String[] parts = path.split("\\.");
+        ObjectNode parent = body;
+        for (int i = 0; i < parts.length - 1; i++) {
+            JsonNode child = parent.get(parts[i]);
+            parent = child instanceof ObjectNode object ? object : parent.putObject(parts[i]);
+        }
+        parent.set(parts[parts.length - 1], value);
+    }
+
+    private static void remove(ObjectNode body, String path) {
+        int dot = path.lastIndexOf('.');
+        JsonNode parent = dot < 0 ? body : get(body, path.substring(0, dot));
+        if (parent instanceof ObjectNode object) {
+            object.remove(path.substring(dot + 1));
+        }
+    }
+
+    private static Stream<Op> steps(List<Op> ops) {
+        return ops == null ? Stream.empty() : ops.stream();
+    }
+}
@@ -0,0 +1,106 @@
+package tech.kayys.gollek.server.versioning;
+
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Locale;
+import java.util.Map;
+
+/**
+ * API versions the server speaks. {@code /v1} stays compatible with the OpenAI-style
+ * schemas clients already use; breaking schema changes land in {@code /v2}, which serves
+ * the same routes. A client picks a version with the path or with
+ * {@value #HEADER}, and every versioned response says which one it got.
+ *
+ * <p>Version 2 so far changes the error schema: {@code {"error": "message"}} becomes
+ * {@code {"error": {"type", "code", "message", "retryable"}}}, with {@code type} from a
+ * fixed taxonomy so clients can branch without parsing messages.
+ */
+public final class ApiVersion {
+
+    public static final String HEADER = "X-API-Version";
+
+    public static final int V1 = 1;
+    public static final int V2 = 2;
+    public static final List<Integer> SUPPORTED = List.of(V1, V2);
+
+    /** Request property holding the negotiated version of a versioned route. */
+    static final String PROPERTY = "gollek.api.version";
+
+    private ApiVersion() {
+    }
+
+    /**
+     * The version a {@value #HEADER} value asks for ({@code 2} or {@code v2}), or
+     * {@code fallback} when the header is absent.
+     *
+     * @throws IllegalArgumentException if the header names a version the server does not speak
+     */
+    public static int parse(String header, int fallback) {
+        if (header == null || header.isBlank()) {
+            return fallback;
+        }
+        String value = header.trim().toLowerCase(Locale.ROOT);
+        if (value.startsWith("v")) {
+            value = value.substring(1);
+        }
+        try {
+            int version = Integer.parseInt(value);
+            if (SUPPORTED.contains(version)) {
+                return version;
+            }
+        } catch (NumberFormatException ignored) {
+            // reported below
+        }
+        throw new IllegalArgumentException("Unsupported " + HEADER + " '" + header.trim()
+                + "'; supported versions are " + SUPPORTED);
+    }
+
+    /** The version in the first path segment ({@code v1/...}), or 0 for an unversioned path. */
+    public static int ofPath(String path) {
+        String p = path.startsWith("/") ? path.substring(1) : path;
+        for (int version : SUPPORTED) {
+            String prefix = "v" + version;
+            if (p.equals(prefix) || p.startsWith(prefix + "/")) {
+                return version;
+            }
+        }
+        return 0;
+    }
+
+    /**
+     * The version 2 form of a version 1 error body: the {@code error} string moves into an
+     * object with its {@link #errorType(int) type}, any {@code code} the body carried (or
+     * the type) and whether retrying can help; other fields stay where they were.
+     */
+    public static Map<String, Object> v2Error(int status, Map<?, ?> v1Body) {
+        Map<String, Object> error = new LinkedHashMap<>();
+        String type = errorType(status);
+        error.put("type", type);
+        error.put("code", v1Body.get("code") != null ? String.valueOf(v1Body.get("code")) : type);
+        error.put("message", String.valueOf(v1Body.get("error")));
+        error.put("retryable", status == 408 || status == 429 || status == 503 || status == 504);
+        Map<String, Object> body = new LinkedHashMap<>();
+        body.put("error", error);
+        v1Body.forEach((k, v) -> {
+            if (!"error".equals(k) && !"code".equals(k)) {
+                body.put(String.valueOf(k), v);
+            }
+        });
+        return body;
+    }
+
+    /** The taxonomy type of an HTTP status. */
+    public static String errorType(int status) {
+        return switch (status) {
+            case 400, 413, 415, 422 -> "invalid_request_error";
+            case 401 -> "authentication_error";
+            case 403 -> "permission_error";
+            case 404 -> "not_found_error";
+            case 408, 504 -> "timeout_error";
+            case 409 -> "conflict_error";
+            case 429 -> "rate_limit_error";
+            case 503 -> "overloaded_error";
+            default -> status >= 500 ? "server_error" : "invalid_request_error";
+        };
+    }
+}
@@ -0,0 +1,111 @@
+package tech.kayys.gollek.server.versioning;
+
+import jakarta.annotation.Priority;
+import jakarta.ws.rs.Priorities;
+import jakarta.ws.rs.container.ContainerRequestContext;
+import jakarta.ws.rs.container.ContainerRequestFilter;
+import jakarta.ws.rs.container.ContainerResponseContext;
+import jakarta.ws.rs.container.ContainerResponseFilter;
+import jakarta.ws.rs.container.PreMatching;
+import jakarta.ws.rs.container.ResourceInfo;
+import jakarta.ws.rs.core.Context;
+import jakarta.ws.rs.core.MediaType;
+import jakarta.ws.rs.core.Response;
+import jakarta.ws.rs.core.UriInfo;
+import jakarta.ws.rs.ext.Provider;
+
+import java.net.URI;
+import java.time.LocalDate;
+import java.time.ZoneOffset;
+import java.time.format.DateTimeFormatter;
+import java.util.Locale;
+import java.util.Map;
+
+/**
+ * Negotiates the {@link ApiVersion} of {@code /v1} and {@code /v2} requests. A
+ * {@code /v2} path, or {@value ApiVersion#HEADER}{@code : 2} on a {@code /v1} one, is
+ * served by the {@code /v1} resource and its response converted to the version 2 schema;
+ * rewriting before matching keeps authentication, admission and rate limiting, which key
+ * on {@code v1/} paths, in force. Responses carry the version served, plus
+ * {@code Deprecation}/{@code Sunset}/{@code Link} headers for {@link Sunset} endpoints.
+ */
+@Provider
+@PreMatching
+@Priority(Priorities.AUTHENTICATION - 100)
+public class ApiVersionFilter implements ContainerRequestFilter, ContainerResponseFilter {
+
+    private static final DateTimeFormatter HTTP_DATE =
+            DateTimeFormatter.ofPattern("EEE, dd MMM yyyy HH:mm:ss 'GMT'", Locale.US);
+
+    @Context
+    ResourceInfo resourceInfo;
+
+    @Override
+    public void filter(ContainerRequestContext requestContext) {
+        UriInfo uriInfo = requestContext.getUriInfo();
+        String path = uriInfo.getPath();
+        int pathVersion = ApiVersion.ofPath(path);
+        if (pathVersion == 0) {
+            return;
+        }
+        int version;
+        try {
+            version = ApiVersion.parse(requestContext.getHeaderString(ApiVersion.HEADER), pathVersion);
+        } catch (IllegalArgumentException e) {
+            requestContext.abortWith(error(e.getMessage()));
+            return;
+        }
+        if (pathVersion == ApiVersion.V2 && version != ApiVersion.V2) {
+            requestContext.abortWith(error(ApiVersion.HEADER + " " + version + " conflicts with a /v2 path"));
+            return;
+        }
+        requestContext.setProperty(ApiVersion.PROPERTY, version);
+        if (pathVersion == ApiVersion.V2) {
+            String relative = path.startsWith("/") ? path.substring(1) : path;
+            URI target = uriInfo.getRequestUriBuilder()
+                    .replacePath(uriInfo.getBaseUri().getPath() + "v1" + relative.substring(2))
+                    .build();
+            requestContext.setRequestUri(uriInfo.getBaseUri(), target);
+        }
+    }
+
+    @Override
+    public void filter(ContainerRequestContext requestContext, ContainerResponseContext responseContext) {
+        if (requestContext.getProperty(ApiVersion.PROPERTY) instanceof Integer version) {
+            responseContext.getHeaders().putSingle(ApiVersion.HEADER, version);
+            if (version >= ApiVersion.V2 && responseContext.getStatus() >= 400
+                    && responseContext.getEntity() instanceof Map<?, ?> body
+                    && body.get("error") instanceof String) {
+                responseContext.setEntity(ApiVersion.v2Error(responseContext.getStatus(), body));
+            }
+        }
+        Sunset sunset = sunset();
+        if (sunset != null) {
+            responseContext.getHeaders().putSingle("Deprecation", "true");
+            responseContext.getHeaders().putSingle("Sunset", httpDate(sunset.date()));
+            if (!sunset.successor().isBlank()) {
+                responseContext.getHeaders().add("Link", "<" + sunset.successor() + ">; rel=\"successor-version\"");
+            }
+        }
+    }
+
+    private Sunset sunset() {
+        if (resourceInfo == null || resourceInfo.getResourceMethod() == null) {
+            return null;
+        }
+        Sunset sunset = resourceInfo.getResourceMethod().getAnnotation(Sunset.class);
+        return sunset != null ? sunset : resourceInfo.getResourceClass().getAnnotation(Sunset.class);
+    }
+
+    /** {@code 2027-06-30} as the IMF-fixdate {@code Sunset} wants. */
+    static String httpDate(String isoDate) {
+        return HTTP_DATE.format(LocalDate.parse(isoDate).atStartOfDay(ZoneOffset.UTC));
+    }
+
+    private static Response error(String message) {
+        return Response.status(Response.Status.BAD_REQUEST)
+                .type(MediaType.APPLICATION_JSON)
+                .entity(Map.of("error", message))
+                .build();
+    }
+}
@@ -0,0 +1,23 @@
+package tech.kayys.gollek.server.versioning;
+
+import java.lang.annotation.ElementType;
+import java.lang.annotation.Retention;
+import java.lang.annotation.RetentionPolicy;
+import java.lang.annotation.Target;
+
+/**
+ * Marks a resource class or method as deprecated. {@link ApiVersionFilter} answers every
+ * call with {@code Deprecation: true}, a {@code Sunset} header for {@link #date()} and,
+ * when set, a {@code Link} to the {@link #successor()} with
+ * {@code rel="successor-version"}, so clients learn about removals before they happen.
+ */
+@Retention(RetentionPolicy.RUNTIME)
+@Target({ ElementType.TYPE, ElementType.METHOD })
+public @interface Sunset {
+
+    /** ISO date after which the endpoint may be removed, e.g. {@code 2027-06-30}. */
+    String date();
+
+    /** Path of the replacement, e.g. {@code /v2/chat/completions}; empty for none. */
+    String successor() default "";
+}
@@ -0,0 +1,28 @@
+package tech.kayys.gollek.server;
+
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertSame;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.exception.RunnerBusyException;
+
+import java.util.concurrent.ExecutionException;
+
+class BackpressureTest {
+
+    @Test
+    void findsTheRunnerRejectionAmongTheCauses() {
+        RunnerBusyException busy = new RunnerBusyException("Runner busy", 3);
+
+        assertSame(busy, Backpressure.busy(busy));
+        assertSame(busy, Backpressure.busy(new RuntimeException("Embedding failed",
+                new ExecutionException(busy))));
+        assertNull(Backpressure.busy(new RuntimeException("Inference failed", new IllegalStateException())));
+    }
+
+    @Test
+    void otherFailuresAreLeftToTheCaller() {
+        assertNull(new Backpressure().reject(new IllegalStateException("boom"), "completions"));
+    }
+}
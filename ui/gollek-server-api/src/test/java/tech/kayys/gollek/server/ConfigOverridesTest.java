@@ -0,0 +1,70 @@
+package tech.kayys.gollek.server;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import org.junit.jupiter.api.Test;
+
+import java.util.Map;
+import java.util.Optional;
+import java.util.Properties;
+
+class ConfigOverridesTest {
+
+    private static final String KEY = "gollek.server.sampling.top-p";
+
+    private static Properties props(String... pairs) {
+        Properties props = new Properties();
+        for (int i = 0; i < pairs.length; i += 2) {
+            props.setProperty(pairs[i], pairs[i + 1]);
+        }
+        return props;
+    }
+
+    private static String effective(Properties system, Map<String, String> env, Properties file) {
+        return ConfigOverrides.resolve(KEY, system, env, file).orElse("default");
+    }
+
+    @Test
+    void systemPropertyBeatsEnvironmentBeatsFileBeatsDefault() {
+        Properties file = props(KEY, "0.7");
+        Map<String, String> env = Map.of("GOLLEK_SERVER_SAMPLING_TOP_P", "0.8");
+        Properties system = props(KEY, "0.9");
+
+        assertEquals("0.9", effective(system, env, file));
+        assertEquals("0.8", effective(new Properties(), env, file));
+        assertEquals("0.7", effective(new Properties(), Map.of(), file));
+        assertEquals("default", effective(new Properties(), Map.of(), new Properties()));
+    }
+
+    @Test
+    void mapsNestedKeysToEnvironmentNames() {
+        assertEquals("GOLLEK_SERVER_MODEL_CACHE_DIR", ConfigOverrides.envName("gollek.server.model-cache.dir"));
+        assertEquals("QUARKUS_LOG_CATEGORY__TECH_KAYYS__LEVEL",
+                ConfigOverrides.envName("quarkus.log.category.\"tech.kayys\".level"));
+        assertEquals(Optional.of("x"), ConfigOverrides.override("gollek.server.model-cache.dir",
+                new Properties(), Map.of("gollek_server_model_cache_dir", "x")));
+        assertTrue(ConfigOverrides.override("gollek.server.model-cache.dir", new Properties(),
+                Map.of("GOLLEK_SERVER_MODEL_CACHE", "x")).isEmpty());
+    }
+
+    @Test
+    void prefixScanFindsEnvironmentOnlyKeys() {
+        Map<String, String> env = Map.of("GOLLEK_SERVER_SAMPLING_REPEAT_PENALTY", "1.1",
+                "GOLLEK_SERVER_SAMPLING_", "ignored", "GOLLEK_SERVER_PORT", "9090");
+
+        assertEquals(Map.of("gollek.server.sampling.repeat-penalty", "1.1"),
+                ConfigOverrides.withPrefix(SamplingDefaults.PREFIX, env));
+    }
+
+    @Test
+    void reloadSkipsSettingsOverriddenOutsideTheFile() {
+        var result = ConfigReload.diff(props("gollek.server.request-timeout-ms", "1000", KEY, "0.7"),
+                props("gollek.server.request-timeout-ms", "2000", KEY, "0.5"));
+
+        var kept = ConfigReload.withoutOverrides(result, new Properties(),
+                Map.of("GOLLEK_SERVER_SAMPLING_TOP_P", "0.8"));
+
+        assertEquals(Map.of("gollek.server.request-timeout-ms", "2000"), kept.applied());
+    }
+}
@@ -0,0 +1,63 @@
+package tech.kayys.gollek.server;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.util.Map;
+import java.util.Properties;
+import java.util.Set;
+
+class ConfigReloadTest {
+
+    private static Properties props(String... pairs) {
+        Properties props = new Properties();
+        for (int i = 0; i < pairs.length; i += 2) {
+            props.setProperty(pairs[i], pairs[i + 1]);
+        }
+        return props;
+    }
+
+    @Test
+    void splitsReloadableSettingsFromOnesNeedingARestart() {
+        var before = props("gollek.server.request-timeout-ms", "120000", "quarkus.http.port", "8080",
+                "gollek.server.admin-secret", "old", "gollek.server.jobs.workers", "2");
+        var after = props("gollek.server.request-timeout-ms", "60000", "quarkus.http.port", "9090",
+                "gollek.server.admin-secret", "new", "quarkus.log.category.\"tech.kayys\".level", "DEBUG");
+
+        var result = ConfigReload.diff(before, after);
+
+        assertEquals(Map.of("gollek.server.request-timeout-ms", "60000",
+                "quarkus.log.category.\"tech.kayys\".level", "DEBUG"), result.applied());
+        assertEquals(Set.of("quarkus.http.port", "gollek.server.admin-secret", "gollek.server.jobs.workers"),
+                result.restartRequired());
+        String log = ConfigReload.describe(before, result);
+        assertTrue(log.contains("gollek.server.request-timeout-ms: 120000 -> 60000"), log);
+        assertFalse(log.contains("new"), log);
+        assertTrue(ConfigReload.reloadable("gollek.server.rate-limit.per-key.rps"));
+        assertFalse(ConfigReload.reloadable("gollek.server.rate-limit.backend"));
+    }
+
+    @Test
+    void reloadedTimeoutAndSamplingDefaultsApplyToNewRequests() {
+        RequestTimeout timeout = new RequestTimeout();
+        timeout.requestTimeoutMs = 120_000;
+        SamplingDefaults sampling = new SamplingDefaults();
+        var event = new ConfigChanged(Map.of("gollek.server.request-timeout-ms", "5000",
+                "gollek.server.sampling.temperature", "0.2", "gollek.server.sampling.top-k", "20"));
+
+        timeout.onConfigChanged(event);
+        sampling.onConfigChanged(event);
+
+        var request = InferenceRequest.builder().model("m").message(Message.user("hi")).topK(5).build();
+        var params = timeout.apply(sampling.apply(request)).getParameters();
+        assertEquals(5000L, params.get(RequestTimeout.TIMEOUT));
+        assertEquals(0.2, params.get("temperature"));
+        assertEquals(5, params.get("top_k"));
+    }
+}
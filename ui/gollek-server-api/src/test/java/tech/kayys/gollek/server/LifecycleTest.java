@@ -0,0 +1,50 @@
+package tech.kayys.gollek.server;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import org.junit.jupiter.api.Test;
+
+import java.time.Duration;
+import java.time.Instant;
+import java.util.Map;
+
+class LifecycleTest {
+
+    @Test
+    void drainRefusesNewRequestsAndWaitsForThoseInFlight() throws Exception {
+        Lifecycle lifecycle = new Lifecycle();
+        assertTrue(lifecycle.enter());
+
+        var status = lifecycle.drain(Duration.ofSeconds(5), false);
+        assertEquals(true, status.get("draining"));
+        assertEquals(1, status.get("in_flight"));
+        assertFalse(lifecycle.enter());
+        assertFalse(lifecycle.awaitIdle(Instant.now().plusMillis(50)));
+
+        Thread.ofVirtual().start(() -> {
+            try {
+                Thread.sleep(50);
+            } catch (InterruptedException e) {
+                Thread.currentThread().interrupt();
+            }
+            lifecycle.exit();
+        });
+        assertTrue(lifecycle.awaitIdle(Instant.now().plusSeconds(5)));
+        assertEquals(true, lifecycle.drainStatus().get("drained"));
+    }
+
+    @Test
+    void resumeTakesRequestsAgain() {
+        Lifecycle lifecycle = new Lifecycle();
+        lifecycle.drain(Duration.ofSeconds(5), false);
+        assertTrue(lifecycle.isDraining());
+
+        lifecycle.resume();
+
+        assertFalse(lifecycle.isDraining());
+        assertTrue(lifecycle.enter());
+        assertEquals(Map.of("draining", false, "in_flight", 1), lifecycle.drainStatus());
+    }
+}
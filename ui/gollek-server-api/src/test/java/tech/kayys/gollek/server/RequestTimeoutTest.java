@@ -0,0 +1,94 @@
+package tech.kayys.gollek.server;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertSame;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.exception.InferenceTimeoutException;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+class RequestTimeoutTest {
+
+    private static RequestTimeout timeout(long ms) {
+        RequestTimeout timeout = new RequestTimeout();
+        timeout.requestTimeoutMs = ms;
+        return timeout;
+    }
+
+    private static InferenceRequest.Builder request() {
+        return InferenceRequest.builder().model("m").message(Message.user("hi"));
+    }
+
+    @Test
+    void maxTimeIsBoundedByTheServerTimeout() {
+        InferenceRequest applied = timeout(30_000).apply(request().parameter(RequestTimeout.MAX_TIME, 60_000).build());
+
+        assertEquals(30_000L, applied.getParameters().get(RequestTimeout.TIMEOUT));
+        assertEquals(30_000L, applied.getParameters().get(RequestTimeout.MAX_TIME));
+    }
+
+    @Test
+    void clientTimeoutsOnlyShortenTheServerTimeout() {
+        RequestTimeout timeout = timeout(30_000);
+
+        assertEquals(5_000L, timeout.apply(request().parameter(RequestTimeout.TIMEOUT, 5_000).build())
+                .getParameters().get(RequestTimeout.TIMEOUT));
+        assertEquals(30_000L, timeout.apply(request().parameter(RequestTimeout.TIMEOUT, 90_000).build())
+                .getParameters().get(RequestTimeout.TIMEOUT));
+        assertEquals(1_500L, timeout.apply(request().parameter(RequestTimeout.MAX_TIME, 1_500).build())
+                .getParameters().get(RequestTimeout.MAX_TIME));
+    }
+
+    @Test
+    void rejectsNonPositiveMaxTime() {
+        assertThrows(IllegalArgumentException.class,
+                () -> timeout(30_000).apply(request().parameter(RequestTimeout.MAX_TIME, 0).build()));
+        assertThrows(IllegalArgumentException.class,
+                () -> timeout(30_000).apply(request().parameter(RequestTimeout.MAX_TIME, "soon").build()));
+    }
+
+    @Test
+    void stageTimeoutsComeFromTheServerAndOnlyShrink() {
+        RequestTimeout timeout = timeout(30_000);
+        timeout.prefillTimeoutMs = 10_000;
+        timeout.interTokenTimeoutMs = 60_000;
+
+        InferenceRequest applied = timeout.apply(request()
+                .parameter(RequestTimeout.PREFILL_TIMEOUT, 20_000)
+                .parameter(RequestTimeout.QUEUE_TIMEOUT, 2_000)
+                .build());
+
+        assertEquals(10_000L, applied.getParameters().get(RequestTimeout.PREFILL_TIMEOUT));
+        assertEquals(2_000L, applied.getParameters().get(RequestTimeout.QUEUE_TIMEOUT));
+        // a stage never outlasts the whole request
+        assertEquals(30_000L, applied.getParameters().get(RequestTimeout.INTER_TOKEN_TIMEOUT));
+        assertThrows(IllegalArgumentException.class,
+                () -> timeout.apply(request().parameter(RequestTimeout.INTER_TOKEN_TIMEOUT, -1).build()));
+    }
+
+    @Test
+    void unsetStagesAreLeftToTheTotal() {
+        InferenceRequest applied = timeout(30_000).apply(request().build());
+
+        assertFalse(applied.getParameters().containsKey(RequestTimeout.QUEUE_TIMEOUT));
+        assertFalse(applied.getParameters().containsKey(RequestTimeout.PREFILL_TIMEOUT));
+        assertFalse(applied.getParameters().containsKey(RequestTimeout.INTER_TOKEN_TIMEOUT));
+    }
+
+    @Test
+    void findsTheTimeoutAmongTheCauses() {
+        InferenceTimeoutException queue = new InferenceTimeoutException(InferenceTimeoutException.Stage.QUEUE,
+                "Queue wait timed out");
+
+        assertSame(queue, RequestTimeout.timedOut(new RuntimeException("Inference failed", queue)));
+        assertEquals(503, queue.getErrorCode().getHttpStatus());
+        assertEquals(504, new InferenceTimeoutException(InferenceTimeoutException.Stage.PREFILL, "slow")
+                .getErrorCode().getHttpStatus());
+        assertNull(RequestTimeout.timedOut(new IllegalStateException("boom")));
+    }
+}
@@ -0,0 +1,104 @@
+package tech.kayys.gollek.server;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNotEquals;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+import java.time.Duration;
+import java.util.Optional;
+
+class ResponseCacheTest {
+
+    private static ResponseCache cache(int maxEntries) {
+        ResponseCache cache = new ResponseCache();
+        cache.enabled = true;
+        cache.backend = "local";
+        cache.ttl = Duration.ofMinutes(10);
+        cache.maxEntries = maxEntries;
+        cache.mode = "exact";
+        cache.scope = "api-key";
+        cache.semanticModel = Optional.empty();
+        return cache;
+    }
+
+    private static InferenceRequest.Builder greedy(String prompt) {
+        return InferenceRequest.builder().model("m").message(Message.user(prompt)).parameter("temperature", 0.0);
+    }
+
+    @Test
+    void keyIgnoresWhitespaceAndSchedulingParameters() throws Exception {
+        ResponseCache cache = cache(10);
+        String key = cache.canonical(greedy("hi").build(), 1, true);
+
+        assertEquals(key, cache.canonical(greedy("  hi\n").parameter(RequestTimeout.TIMEOUT, 5_000)
+                .parameter(RequestTimeout.QUEUE_TIMEOUT, 100).build(), 1, true));
+        assertNotEquals(key, cache.canonical(greedy("hi").parameter("max_tokens", 8).build(), 1, true));
+        assertNotEquals(key, cache.canonical(greedy("hi").build(), 2, true));
+    }
+
+    @Test
+    void tenantsDoNotShareEntriesUnlessScopeIsShared() throws Exception {
+        ResponseCache cache = cache(10);
+        ResponseCache.Lookup first = cache.lookup(greedy("hi").apiKey("tenant-a").build(), 1, null);
+        first.store("answer for a");
+
+        assertFalse(cache.lookup(greedy("hi").apiKey("tenant-b").build(), 1, null).isHit());
+        assertTrue(cache.lookup(greedy("hi").apiKey("tenant-a").build(), 1, null).isHit());
+        // the semantic scope is keyed the same way
+        assertNotEquals(cache.canonical(greedy("hi").apiKey("tenant-a").build(), 1, false),
+                cache.canonical(greedy("hi").apiKey("tenant-b").build(), 1, false));
+
+        cache.scope = "shared";
+        assertEquals(cache.canonical(greedy("hi").apiKey("tenant-a").build(), 1, false),
+                cache.canonical(greedy("hi").apiKey("tenant-b").build(), 1, false));
+    }
+
+    @Test
+    void onlyDeterministicRequestsAreCached() {
+        ResponseCache cache = cache(10);
+
+        assertNull(cache.lookup(InferenceRequest.builder().model("m").message(Message.user("hi"))
+                .parameter("temperature", 0.7).build(), 1, null));
+        assertTrue(ResponseCache.deterministic(InferenceRequest.builder().model("m").message(Message.user("hi"))
+                .parameter("temperature", 0.7).parameter("seed", 42).build()));
+        assertNull(cache.lookup(greedy("hi").build(), 1, "no-store"));
+    }
+
+    @Test
+    void storedAnswersAreServedUntilEvicted() {
+        ResponseCache cache = cache(1);
+        ResponseCache.Lookup first = cache.lookup(greedy("hi").build(), 1, null);
+        assertFalse(first.isHit());
+        first.store("{\"answer\":1}");
+
+        assertEquals("{\"answer\":1}", cache.lookup(greedy("hi ").build(), 1, null).hit());
+        // no-cache skips the lookup
+        assertFalse(cache.lookup(greedy("hi").build(), 1, "no-cache").isHit());
+
+        cache.lookup(greedy("other").build(), 1, null).store("{\"answer\":2}");
+        assertFalse(cache.lookup(greedy("hi").build(), 1, null).isHit());
+    }
+
+    @Test
+    void expiredAnswersMiss() {
+        ResponseCache cache = cache(10);
+        cache.ttl = Duration.ZERO;
+        cache.lookup(greedy("hi").build(), 1, null).store("{}");
+
+        assertFalse(cache.lookup(greedy("hi").build(), 1, null).isHit());
+    }
+
+    @Test
+    void cosineSimilarity() {
+        assertEquals(1.0, ResponseCache.cosine(new float[] { 1, 2 }, new float[] { 2, 4 }), 1e-9);
+        assertEquals(0.0, ResponseCache.cosine(new float[] { 1, 0 }, new float[] { 0, 1 }), 1e-9);
+        assertEquals(0.0, ResponseCache.cosine(new float[] { 1 }, new float[] { 1, 1 }), 1e-9);
+    }
+}
@@ -1,15 +1,34 @@
 package tech.kayys.gollek.server;
 
+import io.quarkus.test.common.http.TestHTTPResource;
 import io.quarkus.test.junit.QuarkusTest;
+import io.quarkus.websockets.next.BasicWebSocketConnector;
 import io.restassured.RestAssured;
+import io.vertx.core.http.UpgradeRejectedException;
+import jakarta.inject.Inject;
 import org.junit.jupiter.api.Test;
 
+import java.net.URI;
+import java.util.concurrent.LinkedBlockingQueue;
+import java.util.concurrent.TimeUnit;
+
 import static org.hamcrest.Matchers.equalTo;
 import static org.hamcrest.Matchers.hasSize;
+import static org.hamcrest.Matchers.nullValue;
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertNotNull;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
 
 @QuarkusTest
 public class ServerApiTest {
 
+    @Inject
+    BasicWebSocketConnector connector;
+
+    @TestHTTPResource("/")
+    URI baseUri;
+
     @Test
     public void testHealth() {
         RestAssured.given()
@@ -18,6 +37,143 @@ public class ServerApiTest {
                 .body("status", equalTo("ok"));
     }
 
+    @Test
+    public void testLiveAndReady() {
+        RestAssured.given()
+                .when().get("/live")
+                .then().statusCode(200)
+                .body("status", equalTo("alive"));
+        RestAssured.given()
+                .when().get("/ready")
+                .then().statusCode(200)
+                .body("status", equalTo("ready"));
+    }
+
+    @Test
+    public void testQuitRejectsForwardedRequests() {
+        RestAssured.given().header("X-Forwarded-For", "203.0.113.7")
+                .when().post("/quitquitquit")
+                .then().statusCode(403);
+    }
+
+    @Test
+    public void testQuitNeedsTheAdminSecretAndIsOffByDefault() {
+        RestAssured.given()
+                .when().post("/quitquitquit")
+                .then().statusCode(403);
+        RestAssured.given().header("X-ADMIN-SECRET", "admin-secret")
+                .when().post("/quitquitquit")
+                .then().statusCode(404);
+    }
+
+    @Test
+    public void testLlamaServerSlotsNeedTheAdminSecret() {
+        RestAssured.given().header("X-API-Key", "community")
+                .when().get("/slots")
+                .then().statusCode(403);
+        RestAssured.given().header("X-API-Key", "community")
+                .when().get("/props")
+                .then().statusCode(403);
+        RestAssured.given().header("X-ADMIN-SECRET", "admin-secret")
+                .when().get("/slots")
+                .then().statusCode(200);
+    }
+
+    @Test
+    public void testSunsetRoutesCarryDeprecationHeaders() {
+        RestAssured.given().header("X-ADMIN-SECRET", "admin-secret")
+                .when().get("/v1/admin/models/cache")
+                .then()
+                .header("Deprecation", "true")
+                .header("Sunset", "Wed, 30 Jun 2027 00:00:00 GMT")
+                .header("Link", "</v1/admin/storage>; rel=\"successor-version\"");
+        RestAssured.given().header("X-ADMIN-SECRET", "admin-secret")
+                .when().get("/v1/admin/storage")
+                .then().statusCode(200)
+                .header("Deprecation", nullValue());
+    }
+
+    @Test
+    public void testSchedulesRejectInvalidSchedulesAndWebhooks() {
+        RestAssured.given().header("X-API-Key", "community").contentType("application/json")
+                .body("{\"cron\": \"not a cron\", \"prompt\": \"hi\"}")
+                .when().post("/v1/schedules")
+                .then().statusCode(400);
+        RestAssured.given().header("X-API-Key", "community").contentType("application/json")
+                .body("{\"every\": \"1h\", \"prompt\": \"hi\", \"webhook\": \"http://169.254.169.254/\"}")
+                .when().post("/v1/schedules")
+                .then().statusCode(400);
+    }
+
+    @Test
+    public void testBearerTokensAreAcceptedAsApiKeys() {
+        RestAssured.given().header("Authorization", "Bearer community")
+                .when().get("/v1/models")
+                .then().statusCode(200);
+        RestAssured.given().header("Authorization", "Bearer not-a-key")
+                .when().get("/v1/models")
+                .then().statusCode(403);
+    }
+
+    @Test
+    public void testOllamaApiNeedsAKeyUnlessOpened() {
+        RestAssured.given()
+                .when().get("/api/tags")
+                .then().statusCode(401);
+    }
+
+    @Test
+    public void testWebSocketStreamReportsFailuresAsFramesAndStaysOpen() throws Exception {
+        LinkedBlockingQueue<String> frames = new LinkedBlockingQueue<>();
+        var connection = connector.baseUri(baseUri).path("/v1/stream")
+                .addHeader("X-API-Key", "community")
+                .onTextMessage((c, message) -> frames.add(message))
+                .connectAndAwait();
+        try {
+            String request = "{\"requestId\": \"%s\", \"model\": \"no-such-model\", "
+                    + "\"messages\": [{\"role\": \"user\", \"content\": \"hi\"}]}";
+            connection.sendTextAndAwait(request.formatted("ws-1"));
+            String first = frames.poll(10, TimeUnit.SECONDS);
+            assertNotNull(first);
+            assertTrue(first.contains("\"error\""), first);
+
+            // the connection survives a failed request and takes the next one
+            connection.sendTextAndAwait(request.formatted("ws-2"));
+            String second = frames.poll(10, TimeUnit.SECONDS);
+            assertNotNull(second);
+            assertTrue(connection.isOpen());
+        } finally {
+            connection.closeAndAwait();
+        }
+    }
+
+    @Test
+    public void testWebSocketHandshakeNeedsAValidApiKey() {
+        assertEquals(401, rejectedHandshake(null));
+        assertEquals(403, rejectedHandshake("not-a-key"));
+
+        // each handshake gets its own connector so no headers carry over
+        var connection = BasicWebSocketConnector.create().baseUri(baseUri).path("/v1/stream")
+                .addHeader("Authorization", "Bearer community")
+                .connectAndAwait();
+        assertTrue(connection.isOpen());
+        connection.closeAndAwait();
+    }
+
+    private int rejectedHandshake(String apiKey) {
+        BasicWebSocketConnector c = BasicWebSocketConnector.create().baseUri(baseUri).path("/v1/stream");
+        if (apiKey != null) {
+            c = c.addHeader("X-API-Key", apiKey);
+        }
+        BasicWebSocketConnector unauthenticated = c;
+        Throwable error = assertThrows(Throwable.class, unauthenticated::connectAndAwait);
+        while (!(error instanceof UpgradeRejectedException) && error.getCause() != null) {
+            error = error.getCause();
+        }
+        assertTrue(error instanceof UpgradeRejectedException, String.valueOf(error));
+        return ((UpgradeRejectedException) error).getStatus();
+    }
+
     @Test
     public void testListModels() {
         RestAssured.given().header("X-API-Key", "community")
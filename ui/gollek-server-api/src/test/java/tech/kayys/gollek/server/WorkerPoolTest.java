@@ -0,0 +1,56 @@
+package tech.kayys.gollek.server;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.util.concurrent.CountDownLatch;
+import java.util.concurrent.Executor;
+import java.util.concurrent.RejectedExecutionException;
+import java.util.concurrent.TimeUnit;
+import java.util.concurrent.atomic.AtomicInteger;
+
+import org.junit.jupiter.api.AfterEach;
+import org.junit.jupiter.api.Test;
+
+class WorkerPoolTest {
+
+    private final WorkerPool pool = new WorkerPool();
+
+    WorkerPoolTest() {
+        pool.threads = 4;
+        pool.maxQueue = 100;
+    }
+
+    @AfterEach
+    void shutdown() {
+        pool.shutdown();
+    }
+
+    @Test
+    void limitCapsConcurrencyAndQueue() throws Exception {
+        Executor limited = pool.limit(1, 1);
+        CountDownLatch release = new CountDownLatch(1);
+        CountDownLatch done = new CountDownLatch(2);
+        AtomicInteger running = new AtomicInteger();
+        AtomicInteger peak = new AtomicInteger();
+        Runnable task = () -> {
+            peak.accumulateAndGet(running.incrementAndGet(), Math::max);
+            try {
+                release.await();
+            } catch (InterruptedException e) {
+                Thread.currentThread().interrupt();
+            }
+            running.decrementAndGet();
+            done.countDown();
+        };
+
+        limited.execute(task);
+        limited.execute(task);
+        assertThrows(RejectedExecutionException.class, () -> limited.execute(task));
+        release.countDown();
+
+        assertTrue(done.await(10, TimeUnit.SECONDS));
+        assertEquals(1, peak.get());
+    }
+}
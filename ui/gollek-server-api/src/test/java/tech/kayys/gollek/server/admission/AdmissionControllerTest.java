@@ -0,0 +1,73 @@
+package tech.kayys.gollek.server.admission;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNotNull;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import org.junit.jupiter.api.Test;
+
+class AdmissionControllerTest {
+
+    private static AdmissionController controller(int capacity) {
+        AdmissionController controller = new AdmissionController();
+        controller.enabled = true;
+        controller.capacity = capacity;
+        controller.defaultTtlSeconds = 5;
+        controller.maxTtlSeconds = 10;
+        return controller;
+    }
+
+    @Test
+    void reservedSlotIsHeldBackFromUnreservedRequests() {
+        AdmissionController admission = controller(2);
+        var reservation = admission.reserve(null, "key-a", 0);
+        assertNotNull(reservation);
+        assertEquals(5_000, reservation.expiresAtMillis());
+
+        assertNotNull(admission.admit(null, null, 0));
+        assertNull(admission.admit(null, null, 0));
+
+        var permit = admission.admit(reservation.token(), "key-a", 1_000);
+        assertNotNull(permit);
+        assertTrue(permit.reserved());
+        assertEquals(2, admission.status(1_000).get("in_flight"));
+    }
+
+    @Test
+    void tokensAreBoundToTheirOwner() {
+        AdmissionController admission = controller(1);
+        var reservation = admission.reserve(3, "key-a", 0);
+
+        assertNull(admission.admit(reservation.token(), "key-b", 0));
+        assertFalse(admission.cancel(reservation.token(), "key-b"));
+        assertTrue(admission.cancel(reservation.token(), "key-a"));
+        assertNotNull(admission.admit(null, null, 0));
+    }
+
+    @Test
+    void expiredReservationsFreeTheirSlot() {
+        AdmissionController admission = controller(1);
+        var reservation = admission.reserve(60, "key-a", 0);
+        // capped at the maximum TTL
+        assertEquals(10_000, reservation.expiresAtMillis());
+        assertNull(admission.reserve(null, "key-b", 1_000));
+        assertEquals(9, admission.retryAfterSeconds(1_000));
+
+        var permit = admission.admit(reservation.token(), "key-a", 10_000);
+        assertNotNull(permit);
+        assertFalse(permit.reserved());
+    }
+
+    @Test
+    void releasingTwiceFreesOneSlot() {
+        AdmissionController admission = controller(2);
+        var one = admission.admit(null, null, 0);
+        admission.admit(null, null, 0);
+
+        one.release();
+        one.release();
+        assertEquals(1, admission.status(0).get("in_flight"));
+    }
+}
@@ -0,0 +1,42 @@
+package tech.kayys.gollek.server.admission;
+
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.lang.reflect.Proxy;
+
+import org.junit.jupiter.api.Test;
+
+import jakarta.ws.rs.container.ContainerRequestContext;
+import jakarta.ws.rs.core.UriInfo;
+
+class InferenceRoutesTest {
+
+    private static ContainerRequestContext request(String method, String path) {
+        UriInfo uriInfo = (UriInfo) Proxy.newProxyInstance(UriInfo.class.getClassLoader(),
+                new Class<?>[] { UriInfo.class }, (proxy, m, args) -> m.getName().equals("getPath") ? path : null);
+        return (ContainerRequestContext) Proxy.newProxyInstance(ContainerRequestContext.class.getClassLoader(),
+                new Class<?>[] { ContainerRequestContext.class }, (proxy, m, args) -> switch (m.getName()) {
+                    case "getMethod" -> method;
+                    case "getUriInfo" -> uriInfo;
+                    default -> null;
+                });
+    }
+
+    @Test
+    void coversTheOllamaInferenceRoutes() {
+        assertTrue(InferenceRoutes.matches(request("POST", "/v1/chat/completions")));
+        assertTrue(InferenceRoutes.matches(request("POST", "api/chat")));
+        assertTrue(InferenceRoutes.matches(request("POST", "/api/generate")));
+        assertFalse(InferenceRoutes.matches(request("POST", "/api/show")));
+        assertFalse(InferenceRoutes.matches(request("GET", "/api/tags")));
+    }
+
+    @Test
+    void coversTheLlamaServerInferenceRoutes() {
+        assertTrue(InferenceRoutes.matches(request("POST", "/completion")));
+        assertTrue(InferenceRoutes.matches(request("POST", "infill")));
+        assertFalse(InferenceRoutes.matches(request("GET", "/slots")));
+        assertFalse(InferenceRoutes.matches(request("POST", "/tokenize")));
+    }
+}
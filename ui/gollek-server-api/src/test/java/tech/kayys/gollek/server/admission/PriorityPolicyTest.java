@@ -0,0 +1,53 @@
+package tech.kayys.gollek.server.admission;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+
+import java.util.List;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.Priority;
+
+class PriorityPolicyTest {
+
+    private static PriorityPolicy policy() {
+        PriorityPolicy policy = new PriorityPolicy();
+        policy.defaultTier = "normal";
+        policy.keyTiers = Optional.of(List.of("ops=high", "batch=low"));
+        return policy;
+    }
+
+    private static InferenceRequest request() {
+        return InferenceRequest.builder().model("m").message(Message.user("hi")).build();
+    }
+
+    @Test
+    void keysDefaultToTheirTier() {
+        PriorityPolicy policy = policy();
+
+        assertEquals(Priority.HIGH, policy.resolve("ops", null, request()));
+        assertEquals(Priority.LOW, policy.resolve("batch", null, request()));
+        assertEquals(Priority.NORMAL, policy.resolve("someone", null, request()));
+        assertEquals(Priority.NORMAL, policy.resolve(null, "bogus", request()));
+    }
+
+    @Test
+    void requestsCannotRiseAboveTheirTier() {
+        PriorityPolicy policy = policy();
+
+        assertEquals(Priority.NORMAL, policy.resolve("someone", "critical", request()));
+        assertEquals(Priority.LOW, policy.resolve("batch", "high", request()));
+        assertEquals(Priority.LOW, policy.resolve("ops", "low", request()));
+    }
+
+    @Test
+    void appliedPriorityIsPassedToTheRunner() {
+        InferenceRequest applied = policy().apply(request(), "ops", "high");
+
+        assertEquals(Priority.HIGH, applied.getPriority());
+        assertEquals("high", applied.getParameters().get(PriorityPolicy.PARAMETER));
+    }
+}
@@ -0,0 +1,85 @@
+package tech.kayys.gollek.server.audio;
+
+import static org.junit.jupiter.api.Assertions.assertArrayEquals;
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTimeoutPreemptively;
+
+import java.io.IOException;
+import java.io.InputStream;
+import java.nio.charset.StandardCharsets;
+import java.time.Duration;
+import java.util.Map;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.condition.DisabledOnOs;
+import org.junit.jupiter.api.condition.OS;
+
+import tech.kayys.gollek.server.WorkerPool;
+
+class ProcessSpeechEngineTest {
+
+    private static ProcessSpeechEngine engine(String command, Map<String, String> voices) {
+        ProcessSpeechEngine engine = new ProcessSpeechEngine();
+        engine.command = Optional.of(command);
+        engine.defaultVoice = "default.onnx";
+        engine.voices = Optional.ofNullable(voices);
+        engine.nativeFormat = "wav";
+        engine.workers = 1;
+        engine.maxQueue = 1;
+        engine.timeoutSeconds = 30;
+        WorkerPool pool = new WorkerPool();
+        pool.threads = 2;
+        pool.maxQueue = 10;
+        engine.workerPool = pool;
+        return engine;
+    }
+
+    @Test
+    void voicesAreMappedThroughTheAllowList() {
+        ProcessSpeechEngine engine = engine("tts {voice}", Map.of("alloy", "alloy.onnx"));
+
+        assertEquals("alloy.onnx", engine.voice("alloy"));
+        assertEquals("default.onnx", engine.voice(null));
+        assertThrows(IllegalArgumentException.class, () -> engine.voice("/etc/passwd"));
+        // nothing configured: client names never reach the command
+        assertEquals("default.onnx", engine("tts {voice}", null).voice("--evil"));
+    }
+
+    @Test
+    @DisabledOnOs(OS.WINDOWS)
+    void outputLargerThanThePipeBufferDoesNotDeadlock() {
+        // cat echoes stdin, so it fills its stdout pipe long before the input is written
+        byte[] text = "x".repeat(1 << 20).getBytes(StandardCharsets.UTF_8);
+        ProcessSpeechEngine engine = engine("cat", null);
+
+        byte[] audio = assertTimeoutPreemptively(Duration.ofSeconds(20), () -> {
+            try (InputStream in = engine.synthesize(
+                    new SpeechEngine.Request(new String(text, StandardCharsets.UTF_8), null, null, null))) {
+                return in.readAllBytes();
+            }
+        });
+        assertArrayEquals(text, audio);
+    }
+
+    @Test
+    @DisabledOnOs(OS.WINDOWS)
+    void failingCommandIsAnErrorNotAShortResponse() {
+        ProcessSpeechEngine engine = engine("false", null);
+
+        IOException e = assertThrows(IOException.class,
+                () -> engine.synthesize(new SpeechEngine.Request("hello", null, null, null)));
+        assertEquals("TTS command exited with status 1", e.getMessage());
+    }
+
+    @Test
+    @DisabledOnOs(OS.WINDOWS)
+    void slowCommandIsKilledAtTheDeadline() {
+        ProcessSpeechEngine engine = engine("sleep 30", null);
+        engine.timeoutSeconds = 1;
+
+        assertTimeoutPreemptively(Duration.ofSeconds(10), () -> assertThrows(IllegalStateException.class,
+                () -> engine.synthesize(new SpeechEngine.Request("hello", null, null, null))));
+    }
+}
@@ -0,0 +1,112 @@
+package tech.kayys.gollek.server.audio;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.Map;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.condition.DisabledOnOs;
+import org.junit.jupiter.api.condition.OS;
+import org.junit.jupiter.api.io.TempDir;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+
+import tech.kayys.gollek.server.WorkerPool;
+
+class WhisperCppEngineTest {
+
+    private static final String OUTPUT = "{\"result\":{\"language\":\"de\"},\"transcription\":["
+            + "{\"offsets\":{\"from\":0,\"to\":1500},\"text\":\" Hallo\"},"
+            + "{\"offsets\":{\"from\":1500,\"to\":3200},\"text\":\" Welt.\"}]}";
+
+    // stands in for whisper-cli: reads -f and -of, and rejects inputs containing "bad"
+    private static final String FAKE_CLI = """
+            #!/bin/sh
+            while [ $# -gt 0 ]; do
+              case "$1" in -f) in="$2"; shift ;; -of) out="$2"; shift ;; esac
+              shift
+            done
+            if grep -q bad "$in"; then echo "error: failed to read audio file '$in'"; exit 0; fi
+            printf '%s' '""" + OUTPUT + """
+            ' > "$out.json"
+            """;
+
+    @TempDir
+    Path dir;
+
+    private WhisperCppEngine engine(Map<String, String> models) throws Exception {
+        WhisperCppEngine engine = new WhisperCppEngine();
+        engine.enabled = true;
+        engine.modelPath = Files.writeString(dir.resolve("ggml-base.bin"), "model").toString();
+        engine.models = Optional.ofNullable(models);
+        engine.threads = 1;
+        engine.workers = 1;
+        engine.maxQueue = 1;
+        engine.timeoutSeconds = 30;
+        WorkerPool pool = new WorkerPool();
+        pool.threads = 2;
+        pool.maxQueue = 10;
+        engine.workerPool = pool;
+        return engine;
+    }
+
+    @Test
+    void parsesSegmentsAndLanguage() throws Exception {
+        WhisperCppEngine engine = engine(null);
+        var options = new TranscriptionEngine.Options(null, null, null, null, false);
+
+        var result = engine.parse(new ObjectMapper().readTree(OUTPUT), options);
+
+        assertEquals("Hallo Welt.", result.text());
+        assertEquals("de", result.language());
+        assertEquals(3.2, result.duration());
+        assertEquals(2, result.segments().size());
+        assertEquals(new TranscriptionEngine.Segment(1, 1.5, 3.2, "Welt."), result.segments().get(1));
+        var translated = engine.parse(new ObjectMapper().readTree(OUTPUT),
+                new TranscriptionEngine.Options(null, null, null, null, true));
+        assertEquals("en", translated.language());
+    }
+
+    @Test
+    void modelIsPickedFromTheConfiguredSet() throws Exception {
+        WhisperCppEngine engine = engine(Map.of("large-v3", "large.bin"));
+
+        assertEquals("large.bin", engine.model("large-v3"));
+        assertEquals(engine.modelPath, engine.model("whisper-1"));
+        assertEquals(engine.modelPath, engine.model(null));
+        assertThrows(IllegalArgumentException.class, () -> engine.model("../../etc/passwd"));
+        // nothing configured: the field is ignored, as OpenAI clients always send one
+        assertEquals(engine.modelPath, engine(null).model("anything"));
+    }
+
+    @Test
+    void recognizesUnreadableAudio() {
+        assertTrue(WhisperCppEngine.unreadableAudio("error: failed to read audio file 'x.bin'"));
+        assertTrue(WhisperCppEngine.unreadableAudio("error: failed to open 'x' as WAV file"));
+        assertEquals(false, WhisperCppEngine.unreadableAudio("whisper_init: failed to load model"));
+    }
+
+    @Test
+    @DisabledOnOs(OS.WINDOWS)
+    void transcribesThroughTheCliAndRejectsBadAudio() throws Exception {
+        Path cli = Files.writeString(dir.resolve("whisper-cli"), FAKE_CLI, StandardCharsets.UTF_8);
+        assertTrue(cli.toFile().setExecutable(true));
+        WhisperCppEngine engine = engine(null);
+        engine.binary = cli.toString();
+        var options = new TranscriptionEngine.Options("whisper-1", null, null, null, false);
+
+        var result = engine.transcribe(Files.writeString(dir.resolve("ok.wav"), "RIFF"), options);
+        assertEquals("Hallo Welt.", result.text());
+
+        Path bad = Files.writeString(dir.resolve("bad.wav"), "bad");
+        assertThrows(IllegalArgumentException.class, () -> engine.transcribe(bad, options));
+        Path empty = Files.createFile(dir.resolve("empty.wav"));
+        assertThrows(IllegalArgumentException.class, () -> engine.transcribe(empty, options));
+    }
+}
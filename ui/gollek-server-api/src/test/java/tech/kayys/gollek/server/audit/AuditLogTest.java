@@ -0,0 +1,83 @@
+package tech.kayys.gollek.server.audit;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNull;
+
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.security.AtRestCipher;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+class AuditLogTest {
+
+    private static AuditLog audit(String capture) {
+        AuditLog audit = new AuditLog();
+        audit.enabled = true;
+        audit.capture = capture;
+        audit.redactPatterns = Optional.of(List.of("[\\w.+-]+@[\\w-]+\\.[\\w.]+"));
+        audit.redactParameters = Optional.of(List.of("user"));
+        return audit;
+    }
+
+    private static InferenceRequest request() {
+        return InferenceRequest.builder()
+                .requestId("r1")
+                .model("m")
+                .apiKey("secret-key-1234")
+                .message(Message.user("Mail bob@example.com the report"))
+                .parameter("temperature", 0.2)
+                .parameter("user", "bob")
+                .build();
+    }
+
+    @Test
+    void hashesTextAndMasksKeysByDefault() {
+        Map<String, Object> entry = audit("hash").entry(request(), false, "Done.", 7, 2, "stop", 42, null);
+
+        assertEquals("r1", entry.get("request_id"));
+        assertEquals("****1234", entry.get("api_key"));
+        assertEquals(AuditLog.sha256("user: Mail bob@example.com the report\n"), entry.get("prompt_sha256"));
+        assertEquals(AuditLog.sha256("Done."), entry.get("response_sha256"));
+        assertFalse(entry.containsKey("prompt"));
+        assertEquals(Map.of("temperature", 0.2, "user", AuditLog.REDACTED), entry.get("parameters"));
+        assertEquals(7, entry.get("input_tokens"));
+        assertEquals(42L, entry.get("latency_ms"));
+        assertEquals("stop", entry.get("finish_reason"));
+    }
+
+    @Test
+    void fullCaptureRedactsPatterns() {
+        Map<String, Object> entry = audit("full").entry(request(), true, "Sent to bob@example.com today", 7, 4,
+                "stop", 10, null);
+
+        assertEquals(List.of(Map.of("role", "user", "content", "Mail [REDACTED] the report")), entry.get("prompt"));
+        assertEquals("Sent to [REDACTED] today", entry.get("response"));
+    }
+
+    @Test
+    void fileLinesAreSealedWithAStorageKey() {
+        AuditLog audit = audit("full");
+        assertEquals("{\"a\":1}", audit.fileLine("{\"a\":1}"));
+
+        audit.cipher = new AtRestCipher(new byte[32]);
+        String line = audit.fileLine("{\"prompt\":\"secret\"}");
+        assertFalse(line.contains("secret"));
+        assertEquals("{\"prompt\":\"secret\"}", audit.cipher.decryptToString(line));
+    }
+
+    @Test
+    void failuresRecordTheError() {
+        Map<String, Object> entry = audit("none").entry(request(), false, null, 0, 0, null, 5,
+                new IllegalStateException("model not loaded"));
+
+        assertNull(entry.get("finish_reason"));
+        assertFalse(entry.containsKey("prompt_sha256"));
+        assertEquals("IllegalStateException: model not loaded", entry.get("error"));
+    }
+}
@@ -0,0 +1,47 @@
+package tech.kayys.gollek.server.chat;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.util.List;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.Message;
+
+class HistoryBudgetTest {
+
+    private static final String LONG = "x".repeat(400); // ~100 tokens
+
+    private final List<Message> history = List.of(
+            Message.system("be brief"),
+            Message.user(LONG),
+            Message.assistant(LONG),
+            Message.user(LONG),
+            Message.user("latest"));
+
+    @Test
+    void withinBudgetIsUntouched() {
+        var result = HistoryBudget.apply(history, new HistoryBudget.Options(10_000, null, null), null);
+        assertEquals(history, result.messages());
+        assertNull(result.report());
+    }
+
+    @Test
+    void dropOldestKeepsSystemAndNewest() {
+        var result = HistoryBudget.apply(history, new HistoryBudget.Options(150, "drop-oldest", true), null);
+        assertEquals(Message.Role.SYSTEM, result.messages().get(0).getRole());
+        assertEquals("latest", result.messages().get(result.messages().size() - 1).getContent());
+        assertEquals(2, result.report().droppedMessages());
+        assertTrue(result.report().droppedTokens() > 0);
+    }
+
+    @Test
+    void summarizeReplacesDroppedMessages() {
+        var result = HistoryBudget.apply(history, new HistoryBudget.Options(150, "summarize_oldest", true),
+                dropped -> "they talked about x");
+        assertTrue(result.report().summarized());
+        assertTrue(result.messages().get(1).getContent().contains("they talked about x"));
+    }
+}
@@ -0,0 +1,64 @@
+package tech.kayys.gollek.server.chat;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertNull;
+
+import java.util.Arrays;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.openai.ChatCompletionRequest;
+import tech.kayys.gollek.spi.Message;
+
+class LanguageRoutingTest {
+
+    private static LanguageRouting routing(boolean enabled) {
+        LanguageRouting routing = new LanguageRouting();
+        routing.enabled = enabled;
+        routing.models = Optional.of(Map.of("de", "llama-de"));
+        routing.systemPrompts = Optional.of(Map.of("de", "Antworte auf Deutsch.", "fr", "Réponds en français."));
+        return routing;
+    }
+
+    private static ChatCompletionRequest request(String... userMessages) {
+        List<ChatCompletionRequest.ChatMessage> messages = Arrays.stream(userMessages)
+                .map(text -> new ChatCompletionRequest.ChatMessage("user", text, null))
+                .toList();
+        return ChatCompletionRequest.builder().model("m").messages(messages).build();
+    }
+
+    @Test
+    void detectsScriptsAndCommonWords() {
+        assertEquals(Optional.of("en"), LanguageRouting.detect("What is the capital of France?"));
+        assertEquals(Optional.of("de"), LanguageRouting.detect("Was ist die Hauptstadt von Frankreich und warum?"));
+        assertEquals(Optional.of("fr"), LanguageRouting.detect("Quelle est la capitale de la France ?"));
+        assertEquals(Optional.of("ja"), LanguageRouting.detect("東京はどこですか"));
+        assertEquals(Optional.of("zh"), LanguageRouting.detect("北京是中国的首都"));
+        assertEquals(Optional.of("ru"), LanguageRouting.detect("Где находится Москва?"));
+        assertEquals(Optional.empty(), LanguageRouting.detect("Hello"));
+        assertEquals(Optional.empty(), LanguageRouting.detect("1 + 1 = ?"));
+    }
+
+    @Test
+    void routesOnTheLastUserMessage() {
+        LanguageRouting.Decision decision = routing(true)
+                .decide(request("What is the capital of France?", "Und was ist die Hauptstadt von Deutschland?"));
+
+        assertEquals(new LanguageRouting.Decision("de", "llama-de", "Antworte auf Deutsch."), decision);
+        assertEquals(new LanguageRouting.Decision("fr", null, "Réponds en français."),
+                routing(true).decide(request("Quelle est la capitale de la France ?")));
+        assertNull(routing(false).decide(request("Was ist die Hauptstadt von Deutschland und warum?")));
+    }
+
+    @Test
+    void systemPromptGoesFirst() {
+        List<Message> messages = LanguageRouting.withSystemPrompt(List.of(Message.user("Hallo")), "Auf Deutsch.");
+
+        assertEquals(2, messages.size());
+        assertEquals(Message.Role.SYSTEM, messages.get(0).getRole());
+        assertEquals("Auf Deutsch.", messages.get(0).getContent());
+    }
+}
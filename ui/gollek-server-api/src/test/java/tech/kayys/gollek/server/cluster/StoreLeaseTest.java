@@ -0,0 +1,45 @@
+package tech.kayys.gollek.server.cluster;
+
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.time.Clock;
+import java.time.Duration;
+import java.time.Instant;
+import java.time.ZoneOffset;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.store.InMemoryStore;
+import tech.kayys.gollek.server.store.Store;
+
+class StoreLeaseTest {
+
+    private final Store store = new InMemoryStore();
+    private final Duration ttl = Duration.ofSeconds(15);
+
+    @Test
+    void onlyOneHolderUntilTheLeaseLapses() {
+        Instant t0 = Instant.parse("2026-01-01T00:00:00Z");
+        StoreLease a = new StoreLease(store, "leader", Clock.fixed(t0, ZoneOffset.UTC));
+        StoreLease b = new StoreLease(store, "leader", Clock.fixed(t0.plusSeconds(5), ZoneOffset.UTC));
+        StoreLease later = new StoreLease(store, "leader", Clock.fixed(t0.plusSeconds(20), ZoneOffset.UTC));
+
+        assertTrue(a.tryAcquire("a", ttl));
+        assertTrue(a.tryAcquire("a", ttl));
+        assertFalse(b.tryAcquire("b", ttl));
+        assertTrue(later.tryAcquire("b", ttl));
+        assertFalse(later.tryAcquire("a", ttl));
+    }
+
+    @Test
+    void releaseHandsTheLeaseOver() {
+        Clock clock = Clock.fixed(Instant.parse("2026-01-01T00:00:00Z"), ZoneOffset.UTC);
+        StoreLease lease = new StoreLease(store, "leader", clock);
+        assertTrue(lease.tryAcquire("a", ttl));
+        lease.release("b");
+        assertFalse(lease.tryAcquire("b", ttl));
+        lease.release("a");
+        assertTrue(lease.tryAcquire("b", ttl));
+    }
+}
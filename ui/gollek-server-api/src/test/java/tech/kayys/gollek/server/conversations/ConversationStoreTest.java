@@ -0,0 +1,113 @@
+package tech.kayys.gollek.server.conversations;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.Base64;
+import java.util.List;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import tech.kayys.gollek.server.openai.ChatCompletionRequest.ChatMessage;
+import tech.kayys.gollek.server.security.AtRestCipher;
+import tech.kayys.gollek.server.store.InMemoryStore;
+import tech.kayys.gollek.server.store.Store;
+
+class ConversationStoreTest {
+
+    private static final String KEY = Base64.getEncoder().encodeToString(new byte[32]);
+    private static final String LEGACY = "[{\"id\":\"conv-1\",\"title\":\"t\",\"system_prompt\":\"be brief\","
+            + "\"messages\":[{\"role\":\"user\",\"content\":\"hi\"},{\"role\":\"assistant\",\"content\":\"hello\"}],"
+            + "\"created_at\":1,\"updated_at\":2}]";
+
+    @TempDir
+    Path dir;
+
+    private ConversationStore conversations(Store store, String key) {
+        ConversationStore conversations = new ConversationStore();
+        conversations.store = store;
+        conversations.legacyFile = dir.resolve("conversations.json").toString();
+        conversations.encryptionKey = Optional.ofNullable(key);
+        conversations.encryptionKeyFile = Optional.empty();
+        conversations.init();
+        return conversations;
+    }
+
+    @Test
+    void legacyFileIsImportedOnce() throws Exception {
+        Path legacy = dir.resolve("conversations.json");
+        Files.writeString(legacy, LEGACY);
+        Store store = new InMemoryStore();
+
+        Conversation c = conversations(store, null).get("conv-1").orElseThrow();
+        assertEquals("be brief", c.systemPrompt());
+        assertEquals(List.of("hi", "hello"), c.messages().stream().map(ChatMessage::content).toList());
+        assertFalse(Files.exists(legacy));
+        assertTrue(Files.exists(dir.resolve("conversations.json.migrated")));
+        assertEquals(2, conversations(store, null).get("conv-1").orElseThrow().messages().size());
+    }
+
+    @Test
+    void encryptedLegacyFileWithoutKeyStopsStartup() throws Exception {
+        Path legacy = dir.resolve("conversations.json");
+        byte[] sealed = new AtRestCipher(new byte[32]).encrypt(LEGACY.getBytes(StandardCharsets.UTF_8));
+        Files.write(legacy, sealed);
+
+        assertThrows(IllegalStateException.class, () -> conversations(new InMemoryStore(), null));
+        assertTrue(Files.exists(legacy));
+        assertEquals(1, conversations(new InMemoryStore(), KEY).list().size());
+    }
+
+    @Test
+    void encryptedStoreNeedsItsKeyToStart() {
+        Store store = new InMemoryStore();
+        conversations(store, KEY).create("t", "m", "secret system prompt", null);
+        assertFalse(store.get("conversations", store.keys("conversations").get(0)).orElseThrow().contains("secret"));
+
+        assertThrows(IllegalStateException.class, () -> conversations(store, null));
+        String otherKey = Base64.getEncoder().encodeToString(new byte[16]);
+        assertThrows(IllegalStateException.class, () -> conversations(store, otherKey));
+        assertEquals(1, conversations(store, KEY).list().size());
+    }
+
+    @Test
+    void aStreamCutShortKeepsWhatTheClientWasSent() {
+        ConversationStore conversations = conversations(new InMemoryStore(), null);
+        String id = conversations.create("t", "m", null, null).id();
+        List<ChatMessage> turn = List.of(new ChatMessage("user", "tell me a story", null));
+
+        try (var streamed = conversations.streamedTurn(id, turn)) {
+            streamed.reply().append("Once upon");
+            // the client disconnects or generation fails before complete()
+        }
+
+        assertEquals(List.of("tell me a story", "Once upon"),
+                conversations.get(id).orElseThrow().messages().stream().map(ChatMessage::content).toList());
+    }
+
+    @Test
+    void aStreamThatSentNothingIsNotRecordedUnlessItCompleted() {
+        ConversationStore conversations = conversations(new InMemoryStore(), null);
+        String id = conversations.create("t", "m", null, null).id();
+        List<ChatMessage> turn = List.of(new ChatMessage("user", "hi", null));
+
+        conversations.streamedTurn(id, turn).close();
+        assertTrue(conversations.get(id).orElseThrow().messages().isEmpty());
+
+        try (var streamed = conversations.streamedTurn(id, turn)) {
+            streamed.complete();
+        }
+        assertEquals(List.of("hi", ""),
+                conversations.get(id).orElseThrow().messages().stream().map(ChatMessage::content).toList());
+
+        // streams outside a conversation record nothing
+        conversations.streamedTurn(null, turn).close();
+    }
+}
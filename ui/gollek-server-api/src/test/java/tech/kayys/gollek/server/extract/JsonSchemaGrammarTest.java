@@ -0,0 +1,95 @@
+package tech.kayys.gollek.server.extract;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import org.junit.jupiter.api.Test;
+
+class JsonSchemaGrammarTest {
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    private String grammar(String schema) throws Exception {
+        return JsonSchemaGrammar.fromSchema(mapper.readTree(schema));
+    }
+
+    /** The body of rule {@code name}, or null when the grammar has no such rule. */
+    private static String rule(String grammar, String name) {
+        for (String line : grammar.split("\n")) {
+            if (line.startsWith(name + " ::= ")) {
+                return line.substring(name.length() + 5);
+            }
+        }
+        return null;
+    }
+
+    @Test
+    void nestedObjectsGetTheirOwnRules() throws Exception {
+        String g = grammar("""
+                {"type": "object", "properties": {
+                  "name": {"type": "string"},
+                  "address": {"type": "object", "properties": {"city": {"type": "string"}}}}}
+                """);
+
+        assertEquals("ws root-value", rule(g, "root"));
+        assertEquals("\"{\" ws \"\\\"city\\\"\" ws \":\" ws string \"}\" ws", rule(g, "root-value-address"));
+        assertEquals("\"{\" ws \"\\\"name\\\"\" ws \":\" ws string \",\" ws \"\\\"address\\\"\" ws \":\" ws "
+                + "root-value-address \"}\" ws", rule(g, "root-value"));
+    }
+
+    @Test
+    void enumsAreAlternativesOfLiterals() throws Exception {
+        String g = grammar("""
+                {"type": "string", "enum": ["red", "green"]}
+                """);
+
+        assertEquals("(\"\\\"red\\\"\" | \"\\\"green\\\"\") ws", rule(g, "root-value"));
+    }
+
+    @Test
+    void arraysRepeatTheirItemRule() throws Exception {
+        String g = grammar("""
+                {"type": "array", "items": {"type": "integer"}}
+                """);
+
+        assertEquals("\"[\" ws ( integer ( \",\" ws integer )* )? \"]\" ws", rule(g, "root-value"));
+    }
+
+    @Test
+    void refsShareOneRule() throws Exception {
+        String g = grammar("""
+                {"type": "object",
+                 "properties": {"from": {"$ref": "#/$defs/point"}, "to": {"$ref": "#/$defs/point"}},
+                 "$defs": {"point": {"type": "object", "properties": {"x": {"type": "number"}}}}}
+                """);
+
+        assertEquals("\"{\" ws \"\\\"x\\\"\" ws \":\" ws number \"}\" ws", rule(g, "ref-point"));
+        assertEquals(2, rule(g, "root-value").split("ref-point", -1).length - 1);
+        assertEquals(1, g.split("\nref-point ::= ", -1).length - 1);
+    }
+
+    @Test
+    void recursiveRefsTerminate() throws Exception {
+        String g = grammar("""
+                {"$ref": "#/definitions/node",
+                 "definitions": {"node": {"type": "object", "properties": {
+                   "children": {"type": "array", "items": {"$ref": "#/definitions/node"}}}}}}
+                """);
+
+        assertEquals("ws ref-node", rule(g, "root"));
+        assertEquals("\"[\" ws ( ref-node ( \",\" ws ref-node )* )? \"]\" ws", rule(g, "ref-node-children"));
+        assertTrue(rule(g, "ref-node").contains("ref-node-children"));
+    }
+
+    @Test
+    void unresolvableRefsAcceptAnyValue() throws Exception {
+        String g = grammar("""
+                {"type": "object", "properties": {"x": {"$ref": "https://example.com/schema.json"}}}
+                """);
+
+        assertTrue(rule(g, "root-value").endsWith("ws \":\" ws value \"}\" ws"));
+        assertNull(rule(g, "ref-schema-json"));
+    }
+}
@@ -0,0 +1,51 @@
+package tech.kayys.gollek.server.grpc;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNotEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+
+import io.grpc.Status;
+import io.grpc.StatusRuntimeException;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.grpc.proto.ChatMessage;
+import tech.kayys.gollek.server.grpc.proto.CompletionRequest;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+class InferenceGrpcServiceTest {
+
+    private static CompletionRequest.Builder request() {
+        return CompletionRequest.newBuilder()
+                .setModel("gollek-demo")
+                .addMessages(ChatMessage.newBuilder().setRole("user").setContent("hi"));
+    }
+
+    @Test
+    void clientRequestIdIsALabelNotTheId() {
+        InferenceRequest first = InferenceGrpcService.toInferenceRequest(request().setRequestId("job-1").build(), false);
+        InferenceRequest second = InferenceGrpcService.toInferenceRequest(request().setRequestId("job-1").build(), true);
+        assertNotEquals("job-1", first.getRequestId());
+        assertNotEquals(first.getRequestId(), second.getRequestId());
+        assertEquals("job-1", first.getParameters().get(InferenceGrpcService.CLIENT_REQUEST_ID));
+    }
+
+    @Test
+    void requestsWithoutAnIdGetOne() {
+        InferenceRequest req = InferenceGrpcService.toInferenceRequest(request().build(), false);
+        assertFalse(req.getRequestId().isEmpty());
+        assertFalse(req.getParameters().containsKey(InferenceGrpcService.CLIENT_REQUEST_ID));
+    }
+
+    @Test
+    void rejectsEmptyMessagesAndUnknownRoles() {
+        StatusRuntimeException empty = assertThrows(StatusRuntimeException.class,
+                () -> InferenceGrpcService.toInferenceRequest(CompletionRequest.newBuilder().build(), false));
+        assertEquals(Status.Code.INVALID_ARGUMENT, empty.getStatus().getCode());
+        StatusRuntimeException role = assertThrows(StatusRuntimeException.class,
+                () -> InferenceGrpcService.toInferenceRequest(request()
+                        .addMessages(ChatMessage.newBuilder().setRole("narrator").setContent("x")).build(), false));
+        assertEquals(Status.Code.INVALID_ARGUMENT, role.getStatus().getCode());
+    }
+}
@@ -0,0 +1,69 @@
+package tech.kayys.gollek.server.jobs;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+
+import java.util.Base64;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.store.InMemoryStore;
+import tech.kayys.gollek.server.store.Store;
+
+class BackgroundJobManagerTest {
+
+    private static BackgroundJobManager manager(Store store) {
+        return manager(store, Optional.empty());
+    }
+
+    static BackgroundJobManager manager(Store store, Optional<String> encryptionKey) {
+        BackgroundJobManager manager = new BackgroundJobManager();
+        manager.store = store;
+        manager.encryptionKey = encryptionKey;
+        manager.encryptionKeyFile = Optional.empty();
+        manager.init();
+        return manager;
+    }
+
+    @Test
+    void statusChangesReachTheStore() {
+        Store store = new InMemoryStore();
+        JobRecord job = manager(store).register("job-1", "batch");
+        job.setStatus("RUNNING");
+        job.setResult("done");
+        job.setStatus("COMPLETED");
+
+        // a second manager on the same store sees the finished job without having run it
+        var info = manager(store).getJobInfo("job-1").orElseThrow();
+        assertEquals("COMPLETED", info.status());
+        assertEquals("done", info.result());
+        assertEquals("batch", info.type());
+    }
+
+    @Test
+    void unfinishedJobsFailAfterARestart() {
+        Store store = new InMemoryStore();
+        BackgroundJobManager first = manager(store);
+        first.register("job-1", "batch").setStatus("RUNNING");
+        first.register("job-2", "batch").setStatus("COMPLETED");
+
+        BackgroundJobManager restarted = manager(store);
+        assertEquals("FAILED", restarted.getJobInfo("job-1").orElseThrow().status());
+        assertEquals("Interrupted by a server restart", restarted.getJobInfo("job-1").orElseThrow().error());
+        assertEquals("COMPLETED", restarted.getJobInfo("job-2").orElseThrow().status());
+        assertEquals(2, restarted.listJobs().size());
+    }
+
+    @Test
+    void jobRecordsAreEncryptedWithAStorageKey() {
+        Store store = new InMemoryStore();
+        Optional<String> key = Optional.of(Base64.getEncoder().encodeToString(new byte[32]));
+        JobRecord job = manager(store, key).register("job-1", "batch");
+        job.setResult("the model's answer");
+        job.setStatus("COMPLETED");
+
+        assertFalse(store.get(BackgroundJobManager.NAMESPACE, "job-1").orElseThrow().contains("model's answer"));
+        assertEquals("the model's answer", manager(store, key).getJobInfo("job-1").orElseThrow().result());
+    }
+}
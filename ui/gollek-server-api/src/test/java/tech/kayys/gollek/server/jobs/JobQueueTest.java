@@ -0,0 +1,111 @@
+package tech.kayys.gollek.server.jobs;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.util.Base64;
+import java.util.HashSet;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+import java.util.Set;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.security.ApiKeyStore;
+import tech.kayys.gollek.server.store.InMemoryStore;
+import tech.kayys.gollek.server.store.Store;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+class JobQueueTest {
+
+    private static final String KEY = Base64.getEncoder().encodeToString(new byte[32]);
+
+    private final Set<String> allowedKeys = new HashSet<>(Set.of("community"));
+
+    private JobQueue queue(Store store, boolean spill) {
+        return queue(store, spill, Optional.empty());
+    }
+
+    private JobQueue queue(Store store, boolean spill, Optional<String> encryptionKey) {
+        JobQueue queue = new JobQueue();
+        queue.store = store;
+        queue.apiKeyStore = new ApiKeyStore() {
+            @Override
+            public Set<String> listKeys() {
+                return Set.copyOf(allowedKeys);
+            }
+        };
+        queue.encryptionKey = encryptionKey;
+        queue.encryptionKeyFile = Optional.empty();
+        queue.jobs = BackgroundJobManagerTest.manager(store, encryptionKey);
+        queue.maxQueue = 1;
+        queue.spillEnabled = spill;
+        queue.spillMax = 10;
+        queue.init();
+        return queue;
+    }
+
+    private static InferenceRequest request(String prompt) {
+        return InferenceRequest.builder().model("m").prompt(prompt).apiKey("community").build();
+    }
+
+    @Test
+    void rejectsWhenFullWithoutSpill() {
+        JobQueue queue = queue(new InMemoryStore(), false);
+
+        assertTrue(queue.submit(request("a")).isPresent());
+        assertTrue(queue.submit(request("b")).isEmpty());
+    }
+
+    @Test
+    void overflowIsSpilledAndReloadedInOrder() {
+        Store store = new InMemoryStore();
+        JobQueue first = queue(store, true);
+        first.submit(request("a"));
+        String b = first.submit(request("b")).orElseThrow();
+        String c = first.submit(request("c")).orElseThrow();
+        assertEquals(Map.of("memory", 1, "spilled", 2), first.depth());
+        assertEquals(2, store.keys(JobQueue.NAMESPACE).size());
+
+        // a restart reloads the spilled jobs, oldest first, into the new memory queue
+        JobQueue second = queue(store, true);
+        assertEquals(Map.of("memory", 1, "spilled", 1), second.depth());
+        assertEquals("PENDING", second.jobs.getJobInfo(b).orElseThrow().status());
+        assertEquals("PENDING", second.jobs.getJobInfo(c).orElseThrow().status());
+        List<String> left = store.keys(JobQueue.NAMESPACE);
+        assertEquals(1, left.size());
+        assertTrue(left.get(0).endsWith(c));
+    }
+
+    @Test
+    void spilledJobsAreEncryptedAndKeepOnlyAKeyHash() {
+        Store store = new InMemoryStore();
+        JobQueue first = queue(store, true, Optional.of(KEY));
+        first.submit(request("a"));
+        String b = first.submit(request("secret prompt")).orElseThrow();
+
+        String spilled = store.get(JobQueue.NAMESPACE, store.keys(JobQueue.NAMESPACE).get(0)).orElseThrow();
+        assertFalse(spilled.contains("secret prompt"));
+        assertFalse(spilled.contains("community"));
+
+        // reloaded with the same key, the job runs again under its API key
+        JobQueue second = queue(store, true, Optional.of(KEY));
+        assertEquals("PENDING", second.jobs.getJobInfo(b).orElseThrow().status());
+        assertEquals(Map.of("memory", 1, "spilled", 0), second.depth());
+    }
+
+    @Test
+    void spilledJobsFailOnceTheirKeyIsRevoked() {
+        Store store = new InMemoryStore();
+        JobQueue first = queue(store, true);
+        first.submit(request("a"));
+        String b = first.submit(request("b")).orElseThrow();
+        allowedKeys.remove("community");
+
+        JobQueue second = queue(store, true);
+        assertEquals("FAILED", second.jobs.getJobInfo(b).orElseThrow().status());
+        assertEquals("API key no longer allowed", second.jobs.getJobInfo(b).orElseThrow().error());
+    }
+}
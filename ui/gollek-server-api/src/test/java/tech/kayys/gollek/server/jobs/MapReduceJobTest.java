@@ -0,0 +1,79 @@
+package tech.kayys.gollek.server.jobs;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.lang.reflect.Proxy;
+import java.util.List;
+import java.util.concurrent.atomic.AtomicInteger;
+
+import org.junit.jupiter.api.AfterEach;
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.sdk.core.GollekSdk;
+import tech.kayys.gollek.server.WorkerPool;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+
+class MapReduceJobTest {
+
+    private final WorkerPool pool = new WorkerPool();
+
+    MapReduceJobTest() {
+        pool.threads = 8;
+        pool.maxQueue = 100;
+    }
+
+    @AfterEach
+    void shutdown() {
+        pool.shutdown();
+    }
+
+    /** An SDK whose completions echo the user message, tracking how many run at once. */
+    private static GollekSdk sdk(AtomicInteger running, AtomicInteger peak) {
+        return (GollekSdk) Proxy.newProxyInstance(GollekSdk.class.getClassLoader(), new Class<?>[] { GollekSdk.class },
+                (proxy, method, args) -> {
+                    if (!method.getName().equals("createCompletion")) {
+                        throw new UnsupportedOperationException(method.getName());
+                    }
+                    InferenceRequest request = (InferenceRequest) args[0];
+                    peak.accumulateAndGet(running.incrementAndGet(), Math::max);
+                    try {
+                        Thread.sleep(20);
+                    } finally {
+                        running.decrementAndGet();
+                    }
+                    String content = request.getMessages().get(1).getContent();
+                    return InferenceResponse.builder().requestId("r").content(content.length() + " chars").build();
+                });
+    }
+
+    @Test
+    void requestedConcurrencyIsClamped() {
+        assertEquals(4, MapReduceJob.parallelism(null, 8));
+        assertEquals(2, MapReduceJob.parallelism(1000, 2));
+        assertEquals(1, MapReduceJob.parallelism(0, 2));
+    }
+
+    @Test
+    void mapsChunksWithinTheLimitAndReduces() {
+        AtomicInteger running = new AtomicInteger();
+        AtomicInteger peak = new AtomicInteger();
+        String input = "x".repeat(1000);
+        JobRecord record = new JobRecord("job-1");
+        var spec = new MapReduceJob.Spec("m", input, "summarize", null, 100, 0, 1000, null);
+
+        new MapReduceJob(spec, record, sdk(running, peak), pool.limit(MapReduceJob.parallelism(1000, 2), 100)).run();
+
+        assertEquals("COMPLETED", record.getStatus());
+        assertTrue(peak.get() <= 2, "ran " + peak.get() + " chunks at once");
+        // ten chunks combined into one reduce prompt
+        assertTrue(record.getResult().endsWith("chars"));
+    }
+
+    @Test
+    void splitsOnParagraphs() {
+        String input = "a".repeat(60) + "\n\n" + "b".repeat(60);
+        assertEquals(List.of("a".repeat(60), "b".repeat(60)), MapReduceJob.split(input, 100, 0));
+    }
+}
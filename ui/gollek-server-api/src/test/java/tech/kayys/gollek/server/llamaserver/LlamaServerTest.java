@@ -0,0 +1,106 @@
+package tech.kayys.gollek.server.llamaserver;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+
+import java.util.List;
+import java.util.Map;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class LlamaServerTest {
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    @SuppressWarnings("unchecked")
+    private Map<String, Object> body(String json) throws Exception {
+        return mapper.readValue(json, Map.class);
+    }
+
+    @Test
+    void completionIsARawPromptWithTopLevelSampling() throws Exception {
+        var body = body("""
+                {"prompt": "Building a website can be done in", "n_predict": 128, "temperature": 0.1,
+                 "top_k": 40, "seed": -1, "min_p": 0.05, "stop": ["\\n"], "cache_prompt": true, "stream": true}
+                """);
+
+        var request = LlamaServer.toInferenceRequest(body, "m", "id", tokens -> "");
+
+        assertTrue(LlamaServer.isStream(body));
+        assertEquals(true, request.getParameters().get("raw"));
+        assertEquals("Building a website can be done in", request.getParameters().get("prompt"));
+        assertEquals(128, request.getMaxTokens());
+        assertEquals(40, request.getTopK());
+        assertNull(request.getParameters().get("seed"));
+        assertEquals(0.05, request.getParameters().get("min_p"));
+        assertEquals(List.of("\n"), request.getParameters().get("stop"));
+    }
+
+    @Test
+    void tokenPromptsAreDetokenized() throws Exception {
+        var request = LlamaServer.toInferenceRequest(body("{\"prompt\": [1, 2, 3]}"), "m", "id",
+                tokens -> "ids:" + tokens.length);
+
+        assertEquals("ids:3", request.getParameters().get("prompt"));
+        assertThrows(IllegalArgumentException.class, () -> LlamaServer.toInferenceRequest(
+                body("{\"prompt\": [\"a\", \"b\"]}"), "m", "id", tokens -> ""));
+    }
+
+    @Test
+    void infillUsesTheFirstFormatTheTokenizerKnows() throws Exception {
+        // a StarCoder-style vocabulary: only <fim_*> markers are single tokens
+        var fim = LlamaServer.fim(text -> text.startsWith("<fim_") ? new int[] { 1 } : new int[] { 1, 2 });
+        assertEquals("<fim_prefix>", fim.prefix());
+        assertNull(LlamaServer.fim(text -> new int[] { 1, 2 }));
+
+        var request = LlamaServer.toInfillRequest(body("""
+                {"input_prefix": "def add(a, b):\\n    ", "input_suffix": "\\n", "prompt": "return",
+                 "input_extra": [{"filename": "util.py", "text": "import math"}]}
+                """), "m", "id", fim);
+
+        assertEquals("import math\n<fim_prefix>def add(a, b):\n    <fim_suffix>\n<fim_middle>return",
+                request.getParameters().get("prompt"));
+    }
+
+    @Test
+    void resultCarriesCountsTimingsAndStopType() {
+        Map<String, Object> result = LlamaServer.result("m", "hi", LlamaServer.stopType(
+                InferenceResponse.FinishReason.LENGTH), 10, 4, 1_000_000, 3_000_000);
+
+        assertEquals(true, result.get("stop"));
+        assertEquals("limit", result.get("stop_type"));
+        assertEquals(4L, result.get("tokens_predicted"));
+        assertEquals(10L, result.get("tokens_evaluated"));
+        @SuppressWarnings("unchecked")
+        var timings = (Map<String, Object>) result.get("timings");
+        assertEquals(1.0, timings.get("prompt_ms"));
+        assertEquals(2000.0, timings.get("predicted_per_second"));
+        assertEquals("eos", LlamaServer.stopType("stop"));
+        assertFalse((Boolean) LlamaServer.chunk("x").get("stop"));
+    }
+
+    @Test
+    void slotsAreNumberedAcrossRunners() {
+        Map<String, Object> slot = Map.of("id", 0, "active", true, "request_id", "r1", "tokens", 12,
+                "capacity", 4096);
+        var runners = List.<Map<String, Object>>of(
+                Map.of("model", "a", "slots", List.of(slot, Map.of("id", 1, "active", false, "tokens", 0,
+                        "capacity", 4096))),
+                Map.of("model", "b", "slots", List.of(slot)));
+
+        var slots = LlamaServer.slots(runners, null);
+
+        assertEquals(List.of(0, 1, 2), slots.stream().map(s -> s.get("id")).toList());
+        assertEquals("r1", slots.get(0).get("id_task"));
+        assertEquals(-1, slots.get(1).get("id_task"));
+        assertEquals(1, LlamaServer.slots(runners, "b").size());
+        assertEquals(2, LlamaServer.props("a", LlamaServer.slots(runners, "a"), null, Map.of())
+                .get("total_slots"));
+    }
+}
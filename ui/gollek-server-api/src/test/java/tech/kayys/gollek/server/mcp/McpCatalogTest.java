@@ -0,0 +1,20 @@
+package tech.kayys.gollek.server.mcp;
+
+import org.junit.jupiter.api.Test;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+
+class McpCatalogTest {
+
+    @Test
+    void servingContextPrefersLoadedSlotCapacity() {
+        assertEquals(2048, McpCatalog.servingContext(32768L, 8192, 2048L));
+    }
+
+    @Test
+    void servingContextCapsConfiguredByTrainedLength() {
+        assertEquals(8192, McpCatalog.servingContext(32768L, 8192, null));
+        assertEquals(2048, McpCatalog.servingContext(2048L, 8192, null));
+        assertEquals(8192, McpCatalog.servingContext(null, 8192, null));
+    }
+}
@@ -0,0 +1,21 @@
+package tech.kayys.gollek.server.mcp;
+
+import org.junit.jupiter.api.Test;
+
+import java.util.Set;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+
+class McpResourceCatalogTest {
+
+    @Test
+    void modelIdsAreEncodedInUris() {
+        assertEquals("gollek://models/org%2Fllama-3", McpResourceCatalog.modelUri("org/llama-3"));
+    }
+
+    @Test
+    void changedIsTheSymmetricDifference() {
+        assertEquals(Set.of("a", "c"), McpResourceCatalog.changed(Set.of("a", "b"), Set.of("b", "c")));
+        assertEquals(Set.of(), McpResourceCatalog.changed(Set.of("a"), Set.of("a")));
+    }
+}
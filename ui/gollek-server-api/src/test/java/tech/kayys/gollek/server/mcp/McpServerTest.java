@@ -0,0 +1,184 @@
+package tech.kayys.gollek.server.mcp;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.inference.RequestCancellation;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class McpServerTest {
+
+    private final ObjectMapper mapper = new ObjectMapper();
+    private final McpServer server = new McpServer();
+
+    @Test
+    @SuppressWarnings("unchecked")
+    void initializeAdvertisesStreamingNotifications() throws Exception {
+        var response = server.handle(mapper.readTree("{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"initialize\"}"));
+
+        assertEquals(1, response.get("id"));
+        var result = (Map<String, Object>) response.get("result");
+        var capabilities = (Map<String, Object>) result.get("capabilities");
+        var streaming = (Map<String, Object>) ((Map<String, Object>) capabilities.get("experimental"))
+                .get("gollek/streaming");
+        assertEquals(McpServer.STREAM_NOTIFICATION, streaming.get("notification"));
+    }
+
+    @Test
+    void unknownMethodIsAnError() throws Exception {
+        var response = server.handle(mapper.readTree("{\"jsonrpc\":\"2.0\",\"id\":\"a\",\"method\":\"nope\"}"));
+
+        assertEquals("a", response.get("id"));
+        assertEquals(McpServer.METHOD_NOT_FOUND, ((Map<?, ?>) response.get("error")).get("code"));
+    }
+
+    @Test
+    void streamingIsOptInAndNotificationsHaveNoId() throws Exception {
+        assertTrue(server.isStreaming(mapper.readTree(
+                "{\"id\":1,\"method\":\"gollek/generate\",\"params\":{\"stream\":true}}")));
+        assertFalse(server.isStreaming(mapper.readTree("{\"id\":1,\"method\":\"gollek/generate\"}")));
+        assertTrue(McpServer.isNotification(mapper.readTree("{\"method\":\"notifications/initialized\"}")));
+
+        var notification = McpServer.notification(McpServer.STREAM_NOTIFICATION, Map.of("cursor", 0));
+        assertFalse(notification.containsKey("id"));
+    }
+
+    @Test
+    void buildsRequestFromPromptOrMessages() throws Exception {
+        var request = McpServer.toRequest(mapper.readTree("""
+                {"model": "m", "messages": [{"role": "system", "content": "be brief"}],
+                 "prompt": "hi", "max_tokens": 8}
+                """));
+
+        assertEquals("m", request.getModel());
+        assertEquals(2, request.getMessages().size());
+        assertEquals(8, request.getMaxTokens());
+        assertThrows(IllegalArgumentException.class, () -> McpServer.toRequest(mapper.readTree("{\"model\":\"m\"}")));
+    }
+
+    @Test
+    @SuppressWarnings("unchecked")
+    void toolCallStreamsProgressWhenTokenGiven() throws Exception {
+        server.tools = new McpTools();
+        server.tools.register(new McpTool() {
+            @Override
+            public String name() {
+                return "echo";
+            }
+
+            @Override
+            public String description() {
+                return "echoes in two parts";
+            }
+
+            @Override
+            public Map<String, Object> inputSchema() {
+                return Map.of("type", "object");
+            }
+
+            @Override
+            public List<Map<String, Object>> call(JsonNode arguments, Call call) throws Exception {
+                String text = arguments.path("text").asText();
+                call.progress(text.substring(0, 2));
+                call.progress(text.substring(2));
+                return List.of(McpTool.text(text));
+            }
+        });
+
+        var list = (Map<String, Object>) server.handle(mapper.readTree(
+                "{\"id\":1,\"method\":\"tools/list\"}")).get("result");
+        assertEquals("echo", ((List<Map<String, Object>>) list.get("tools")).get(0).get("name"));
+
+        var call = mapper.readTree("""
+                {"id": 2, "method": "tools/call",
+                 "params": {"name": "echo", "arguments": {"text": "hello"}, "_meta": {"progressToken": "p"}}}
+                """);
+        assertTrue(server.isStreaming(call));
+        List<Map<String, Object>> sent = new ArrayList<>();
+        server.stream(call, sent::add);
+
+        assertEquals(3, sent.size());
+        var first = (Map<String, Object>) sent.get(0).get("params");
+        assertEquals(McpServer.PROGRESS_NOTIFICATION, sent.get(0).get("method"));
+        assertEquals("p", first.get("progressToken"));
+        assertEquals("he", first.get("message"));
+        var result = (Map<String, Object>) sent.get(2).get("result");
+        assertEquals(false, result.get("isError"));
+
+        var unknown = server.handle(mapper.readTree("{\"id\":3,\"method\":\"tools/call\",\"params\":{\"name\":\"x\"}}"));
+        assertEquals(McpServer.INVALID_PARAMS, ((Map<?, ?>) unknown.get("error")).get("code"));
+    }
+
+    @Test
+    void toolFailuresAreResultsNotErrors() throws Exception {
+        McpTools tools = new McpTools();
+        McpTool failing = tools.webhook(new McpTools.WebhookSpec("down", null, null, "http://127.0.0.1:1/", null));
+
+        var result = tools.call(failing, mapper.createObjectNode(), "r1", McpTool.Progress.NONE);
+
+        assertEquals(true, result.get("isError"));
+    }
+
+    @Test
+    void cancelledNotificationCancelsTheSessionsInFlightCall() throws Exception {
+        List<String> requestIds = new ArrayList<>();
+        server.tools = new McpTools();
+        server.tools.register(new McpTool() {
+            @Override
+            public String name() {
+                return "slow";
+            }
+
+            @Override
+            public String description() {
+                return "cancels itself from another session, then its own";
+            }
+
+            @Override
+            public Map<String, Object> inputSchema() {
+                return Map.of("type", "object");
+            }
+
+            @Override
+            public List<Map<String, Object>> call(JsonNode arguments, Call call) throws Exception {
+                requestIds.add(call.requestId());
+                server.notify(mapper.readTree("""
+                        {"method": "notifications/cancelled", "params": {"requestId": 7}}
+                        """), "other");
+                assertFalse(RequestCancellation.isCancelled(call.requestId()));
+                server.notify(mapper.readTree("""
+                        {"method": "notifications/cancelled", "params": {"requestId": 7, "reason": "user"}}
+                        """), "s1");
+                assertTrue(RequestCancellation.isCancelled(call.requestId()));
+                return List.of(McpTool.text("stopped"));
+            }
+        });
+
+        var response = server.handle(mapper.readTree(
+                "{\"id\":7,\"method\":\"tools/call\",\"params\":{\"name\":\"slow\"}}"), "s1");
+
+        assertEquals(false, ((Map<?, ?>) response.get("result")).get("isError"));
+        RequestCancellation.clear(requestIds.get(0));
+        // finished calls are forgotten, so a late cancellation is a no-op
+        server.notify(mapper.readTree("{\"method\":\"notifications/cancelled\",\"params\":{\"requestId\":7}}"), "s1");
+        assertFalse(RequestCancellation.isCancelled(requestIds.get(0)));
+    }
+
+    @Test
+    void webhookResultPassesMcpContentThrough() {
+        McpTools tools = new McpTools();
+
+        assertEquals(List.of(Map.of("type", "text", "text", "a")),
+                tools.webhookContent("{\"content\":[{\"type\":\"text\",\"text\":\"a\"}]}"));
+        assertEquals(List.of(McpTool.text("plain")), tools.webhookContent("plain"));
+    }
+}
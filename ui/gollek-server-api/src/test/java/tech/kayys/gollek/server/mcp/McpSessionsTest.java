@@ -0,0 +1,59 @@
+package tech.kayys.gollek.server.mcp;
+
+import org.junit.jupiter.api.Test;
+
+import java.time.Duration;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class McpSessionsTest {
+
+    @Test
+    void updatesGoOnlyToSubscribedSessionsWithAStream() {
+        McpSessions sessions = new McpSessions();
+        String subscribed = sessions.open().orElseThrow();
+        String other = sessions.open().orElseThrow();
+        List<Map<String, Object>> received = new ArrayList<>();
+        List<Map<String, Object>> otherReceived = new ArrayList<>();
+        sessions.attach(subscribed, received::add);
+        sessions.attach(other, otherReceived::add);
+
+        assertTrue(sessions.subscribe(subscribed, "gollek://models/m"));
+        assertFalse(sessions.subscribe("nope", "gollek://models/m"));
+        sessions.resourceUpdated("gollek://models/m");
+
+        assertEquals(1, received.size());
+        assertEquals("notifications/resources/updated", received.get(0).get("method"));
+        assertEquals(Map.of("uri", "gollek://models/m"), received.get(0).get("params"));
+        assertTrue(otherReceived.isEmpty());
+
+        sessions.unsubscribe(subscribed, "gollek://models/m");
+        sessions.resourceUpdated("gollek://models/m");
+        assertEquals(1, received.size());
+    }
+
+    @Test
+    void limitRejectsNewSessionsUntilIdleOnesAreEvicted() {
+        McpSessions sessions = new McpSessions();
+        sessions.maxSessions = 2;
+        sessions.idleTimeout = Duration.ofMinutes(10);
+        long t0 = 1_000_000;
+
+        String idle = sessions.open(t0).orElseThrow();
+        String streaming = sessions.open(t0).orElseThrow();
+        sessions.attach(streaming, message -> {
+        });
+        assertTrue(sessions.open(t0 + 1000).isEmpty());
+
+        // past the timeout the idle session makes room; the one with a stream stays
+        assertTrue(sessions.open(t0 + Duration.ofMinutes(11).toMillis()).isPresent());
+        assertFalse(sessions.exists(idle));
+        assertTrue(sessions.exists(streaming));
+        assertEquals(2, sessions.size());
+    }
+}
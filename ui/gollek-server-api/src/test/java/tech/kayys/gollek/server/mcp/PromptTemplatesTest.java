@@ -0,0 +1,68 @@
+package tech.kayys.gollek.server.mcp;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.List;
+import java.util.Optional;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class PromptTemplatesTest {
+
+    @TempDir
+    Path dir;
+
+    private PromptTemplates templates() throws Exception {
+        Files.writeString(dir.resolve("summarize.j2"), "Summarize {{ text }}");
+        Files.writeString(dir.resolve("summarize@v2.j2"), "Summarize briefly: {{ text }}");
+        Files.writeString(dir.resolve("summarize@v10.j2"), "TL;DR {{ text }}");
+        Files.writeString(dir.resolve("translate@1.txt"), "Translate {{ text }}");
+        Files.writeString(dir.resolve("translate@2.txt"), "Translate to {{ language }}: {{ text }}");
+        PromptTemplates templates = new PromptTemplates();
+        templates.dir = Optional.of(dir.toString());
+        return templates;
+    }
+
+    @Test
+    void stableIsUnversionedFileThenHighestVersion() throws Exception {
+        PromptTemplates templates = templates();
+
+        assertEquals(List.of("summarize@default", "translate@2"),
+                templates.list().stream().map(PromptTemplates.Template::ref).toList());
+        assertEquals(List.of("v2", "v10", "default"),
+                templates.versions("summarize").stream().map(PromptTemplates.Template::version).toList());
+        assertEquals("v2", templates.get("summarize@v2").orElseThrow().version());
+        assertTrue(templates.select("summarize", "v9", null).isEmpty());
+    }
+
+    @Test
+    void rolloutPicksStableAndCanariesBySession() throws Exception {
+        PromptTemplates templates = templates();
+        Files.writeString(dir.resolve(PromptTemplates.ROLLOUT_FILE),
+                "{\"summarize\": {\"stable\": \"v2\", \"canary\": \"v10\", \"percent\": 30}}");
+
+        assertEquals("v2", templates.get("summarize").orElseThrow().version());
+        int canary = 0;
+        for (int i = 0; i < 1000; i++) {
+            String version = templates.select("summarize", null, "session-" + i).orElseThrow().version();
+            assertEquals(version, templates.select("summarize", null, "session-" + i).orElseThrow().version());
+            if (version.equals("v10")) {
+                canary++;
+            }
+        }
+        assertTrue(canary > 200 && canary < 400, "canary share " + canary);
+        assertEquals("default", templates.select("summarize", "default", "session-1").orElseThrow().version());
+    }
+
+    @Test
+    void canaryBucketsAreStablePerKey() {
+        assertFalse(PromptTemplates.inCanary("s", "p", 0));
+        assertTrue(PromptTemplates.inCanary("s", "p", 100));
+        assertEquals(PromptTemplates.inCanary("s", "p", 50), PromptTemplates.inCanary("s", "p", 50));
+    }
+}
@@ -0,0 +1,40 @@
+package tech.kayys.gollek.server.metrics;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+
+import java.util.Map;
+
+import org.junit.jupiter.api.Test;
+
+class ScalingSignalsTest {
+
+    @Test
+    void signalsAreNormalizedAndClipped() {
+        var load = new ScalingSignals.Load(4, 2, 2, 3000, 8000, 10_000, 0, 0, 50);
+
+        Map<String, Double> signals = ScalingSignals.signals(load, 5000, 100);
+
+        assertEquals(1.0, signals.get("queue"));
+        assertEquals(0.5, signals.get("saturation"));
+        assertEquals(0.375, signals.get("kv_cache"));
+        assertEquals(1.0, signals.get("wait"));
+    }
+
+    @Test
+    void admissionSaturationCountsWhenEnabled() {
+        var load = new ScalingSignals.Load(4, 1, 0, 0, 8000, 0, 4, 3, 0);
+
+        Map<String, Double> signals = ScalingSignals.signals(load, 5000, 100);
+
+        assertEquals(0.75, signals.get("saturation"));
+        assertEquals(0.0, signals.get("queue"));
+    }
+
+    @Test
+    void idleServerWithoutRunnersScoresZero() {
+        var load = new ScalingSignals.Load(0, 0, 0, 0, 0, 0, 0, 0, 0);
+
+        assertEquals(0.0, ScalingSignals.signals(load, 5000, 100).values().stream()
+                .mapToDouble(Double::doubleValue).max().orElse(0));
+    }
+}
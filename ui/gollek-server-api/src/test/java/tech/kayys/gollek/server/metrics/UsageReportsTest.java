@@ -0,0 +1,88 @@
+package tech.kayys.gollek.server.metrics;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.time.Clock;
+import java.time.Instant;
+import java.time.YearMonth;
+import java.time.ZoneOffset;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.store.InMemoryStore;
+import tech.kayys.gollek.server.store.Store;
+
+class UsageReportsTest {
+
+    private static final YearMonth SEPTEMBER = YearMonth.of(2026, 9);
+
+    private static UsageReports reports(Store store) {
+        UsageReports reports = new UsageReports();
+        reports.store = store;
+        reports.enabled = true;
+        reports.uploadUrl = Optional.empty();
+        reports.uploadToken = Optional.empty();
+        reports.clock = Clock.fixed(Instant.parse("2026-10-01T00:15:00Z"), ZoneOffset.UTC);
+        return reports;
+    }
+
+    private static UsageReports.Event event(String key, String model, int in, int out, long latencyMs) {
+        return new UsageReports.Event(UsageReports.tenant(key), "****" + key.substring(key.length() - 4), model, in,
+                out, latencyMs);
+    }
+
+    @Test
+    @SuppressWarnings("unchecked")
+    void summarizesTenantsWithTopModelsAndP95() {
+        List<UsageReports.Event> events = new ArrayList<>();
+        for (int i = 1; i <= 20; i++) {
+            events.add(event("key-alpha", i <= 15 ? "llama" : "qwen", 10, 5, i * 10L));
+        }
+        events.add(event("key-beta1", "llama", 1, 1, 7));
+
+        Map<String, Object> report = UsageReports.report(SEPTEMBER, events, Instant.EPOCH);
+
+        assertEquals(Map.of("requests", 21L, "input_tokens", 201L, "output_tokens", 101L, "total_tokens", 302L),
+                report.get("totals"));
+        var tenants = (List<Map<String, Object>>) report.get("tenants");
+        Map<String, Object> alpha = tenants.get(0);
+        assertEquals(UsageReports.tenant("key-alpha"), alpha.get("tenant"));
+        assertEquals("****lpha", alpha.get("key"));
+        assertEquals(20L, alpha.get("requests"));
+        assertEquals(190L, alpha.get("p95_latency_ms"));
+        var models = (List<Map<String, Object>>) alpha.get("top_models");
+        assertEquals(List.of("llama", "qwen"), models.stream().map(m -> m.get("model")).toList());
+        assertTrue(UsageReports.csv(report).contains(",20,200,100,300,190,llama:15;qwen:5\n"));
+    }
+
+    @Test
+    void finishedMonthsAreReportedAndTheirEventsDropped() {
+        Store store = new InMemoryStore();
+        UsageReports reports = reports(store);
+        reports.append(SEPTEMBER, event("key-alpha", "llama", 3, 4, 50));
+        reports.append(YearMonth.of(2026, 10), event("key-alpha", "llama", 1, 1, 5));
+
+        assertEquals(List.of(SEPTEMBER), reports.generateDue());
+
+        assertEquals(List.of("2026-09"), reports.months());
+        assertTrue(reports.report(SEPTEMBER, "csv").orElseThrow().startsWith("month,tenant,"));
+        assertEquals(List.of("2026-10"), store.lists(UsageReports.EVENTS));
+        // asking again keeps the report although the events are gone
+        var totals = (Map<?, ?>) reports.generate(SEPTEMBER).get("totals");
+        assertEquals(1L, ((Number) totals.get("requests")).longValue());
+    }
+
+    @Test
+    void tenantsAreStableHashes() {
+        assertEquals(UsageReports.tenant("secret"), UsageReports.tenant("secret"));
+        assertEquals(12, UsageReports.tenant("secret").length());
+        assertEquals(UsageReports.ANONYMOUS, UsageReports.tenant(null));
+        assertEquals(7L, UsageReports.percentile(List.of(7L), 0.95));
+        assertEquals(0L, UsageReports.percentile(List.of(), 0.95));
+    }
+}
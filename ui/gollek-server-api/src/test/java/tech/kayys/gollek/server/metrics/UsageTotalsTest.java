@@ -0,0 +1,64 @@
+package tech.kayys.gollek.server.metrics;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.store.InMemoryStore;
+import tech.kayys.gollek.server.store.Store;
+
+class UsageTotalsTest {
+
+    private static UsageTotals totals(Store store, boolean persist) {
+        UsageTotals totals = new UsageTotals();
+        totals.store = store;
+        totals.persist = persist;
+        totals.init();
+        return totals;
+    }
+
+    @Test
+    void countsSurviveARestart() {
+        Store store = new InMemoryStore();
+        UsageTotals first = totals(store, true);
+        first.record(10, 5);
+        first.record(3, -1);
+        first.flush();
+        // a second flush without new counts must not add them again
+        first.flush();
+
+        UsageTotals second = totals(store, true);
+        second.record(1, 1);
+
+        assertEquals(3L, second.snapshot().get("requests"));
+        assertEquals(14L, second.snapshot().get("input_tokens"));
+        assertEquals(6L, second.snapshot().get("output_tokens"));
+        assertEquals(20L, second.snapshot().get("total_tokens"));
+    }
+
+    @Test
+    void replicasMergeIntoOneTotal() {
+        Store store = new InMemoryStore();
+        UsageTotals a = totals(store, true);
+        UsageTotals b = totals(store, true);
+        a.record(1, 1);
+        b.record(2, 2);
+        a.flush();
+        b.flush();
+
+        assertEquals(2L, totals(store, true).snapshot().get("requests"));
+        assertEquals(3L, totals(store, true).snapshot().get("input_tokens"));
+    }
+
+    @Test
+    void nothingIsStoredWhenDisabled() {
+        Store store = new InMemoryStore();
+        UsageTotals totals = totals(store, false);
+        totals.record(1, 1);
+        totals.flush();
+
+        assertTrue(store.keys(UsageTotals.NAMESPACE).isEmpty());
+        assertEquals(1L, totals.snapshot().get("requests"));
+    }
+}
@@ -0,0 +1,111 @@
+package tech.kayys.gollek.server.models;
+
+import org.junit.jupiter.api.Test;
+
+import java.io.ByteArrayInputStream;
+import java.io.ByteArrayOutputStream;
+import java.nio.ByteBuffer;
+import java.nio.ByteOrder;
+import java.nio.charset.StandardCharsets;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertInstanceOf;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class GgufHeaderTest {
+
+    @Test
+    void readsMetadataAndCountsParameters() throws Exception {
+        Writer w = new Writer();
+        w.i32(0x46554747).i32(3).i64(2).i64(6);
+        w.string("general.architecture").i32(8).string("llama");
+        w.string("llama.context_length").i32(4).i32(8192);
+        w.string("general.file_type").i32(4).i32(15);
+        w.string("tokenizer.chat_template").i32(8).string("{% if tools %}{{ tools }}{% endif %}");
+        w.string("llama.attention.causal").i32(7).i8(1);
+        w.string("tokenizer.ggml.tokens").i32(9).i32(8).i64(100);
+        for (int i = 0; i < 100; i++) {
+            w.string("t" + i);
+        }
+        w.string("token_embd.weight").i32(2).i64(4096).i64(32000).i32(12).i64(0);
+        w.string("output_norm.weight").i32(1).i64(4096).i32(0).i64(0);
+
+        GgufHeader header = GgufHeader.read(new ByteArrayInputStream(w.bytes()));
+
+        assertEquals(3, header.version());
+        assertEquals(8192L, header.number("llama.context_length"));
+        assertInstanceOf(GgufHeader.SkippedArray.class, header.metadata().get("tokenizer.ggml.tokens"));
+        assertEquals(4096L * 32000 + 4096, header.parameterCount());
+
+        ModelCapabilities caps = ModelCapabilities.fromGguf(header, null);
+        assertTrue(caps.chat());
+        assertTrue(caps.toolCalling());
+        assertFalse(caps.embeddingsOnly());
+        assertEquals("Q4_K_M", caps.quantization());
+        assertEquals(8192L, caps.contextLength());
+    }
+
+    @Test
+    void rankPoolingMarksReranker() throws Exception {
+        Writer w = new Writer();
+        w.i32(0x46554747).i32(3).i64(0).i64(2);
+        w.string("general.architecture").i32(8).string("bert");
+        w.string("bert.pooling_type").i32(4).i32(4);
+
+        ModelCapabilities caps = ModelCapabilities.fromGguf(GgufHeader.read(new ByteArrayInputStream(w.bytes())), null);
+
+        assertTrue(caps.reranker());
+        assertTrue(caps.embeddingsOnly());
+        assertFalse(caps.chat());
+        assertTrue(caps.has("rerank"));
+    }
+
+    @Test
+    void estimatesKvCacheFromHyperParameters() throws Exception {
+        Writer w = new Writer();
+        w.i32(0x46554747).i32(3).i64(0).i64(6);
+        w.string("general.architecture").i32(8).string("llama");
+        w.string("llama.block_count").i32(4).i32(32);
+        w.string("llama.embedding_length").i32(4).i32(4096);
+        w.string("llama.attention.head_count").i32(4).i32(32);
+        w.string("llama.attention.head_count_kv").i32(4).i32(8);
+        w.string("llama.context_length").i32(4).i32(8192);
+
+        KvCacheEstimate kv = KvCacheEstimate.fromGguf(GgufHeader.read(new ByteArrayInputStream(w.bytes())), 4096);
+
+        assertEquals(128L * 1024, kv.bytesPerToken());
+        assertEquals(512L * 1024 * 1024, kv.bytes());
+        assertEquals(1024L * 1024 * 1024, kv.modelContextBytes());
+    }
+
+    private static final class Writer {
+        private final ByteArrayOutputStream out = new ByteArrayOutputStream();
+
+        Writer i8(int v) {
+            out.write(v);
+            return this;
+        }
+
+        Writer i32(int v) {
+            out.writeBytes(ByteBuffer.allocate(4).order(ByteOrder.LITTLE_ENDIAN).putInt(v).array());
+            return this;
+        }
+
+        Writer i64(long v) {
+            out.writeBytes(ByteBuffer.allocate(8).order(ByteOrder.LITTLE_ENDIAN).putLong(v).array());
+            return this;
+        }
+
+        Writer string(String s) {
+            byte[] b = s.getBytes(StandardCharsets.UTF_8);
+            i64(b.length);
+            out.writeBytes(b);
+            return this;
+        }
+
+        byte[] bytes() {
+            return out.toByteArray();
+        }
+    }
+}
@@ -0,0 +1,161 @@
+package tech.kayys.gollek.server.models;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import java.nio.file.Files;
+import java.nio.file.LinkOption;
+import java.nio.file.Path;
+import java.nio.file.attribute.FileTime;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTimeoutPreemptively;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class ModelCacheTest {
+
+    @TempDir
+    Path dir;
+
+    private ModelCache cache(long maxSize, String... pinned) {
+        ModelCache cache = new ModelCache();
+        cache.enabled = true;
+        cache.dir = dir.toString();
+        cache.maxSizeBytes = maxSize;
+        cache.pinned = Optional.of(List.of(pinned));
+        cache.readyModels = Optional.empty();
+        cache.prewarmModels = Optional.empty();
+        return cache;
+    }
+
+    @Test
+    void identicalFilesShareOneBlob() throws Exception {
+        Files.writeString(dir.resolve("qwen.gguf"), "weights");
+        Files.createDirectories(dir.resolve("alias"));
+        Files.writeString(dir.resolve("alias/qwen-chat.gguf"), "weights");
+        Files.writeString(dir.resolve("llama.gguf"), "other weights");
+        ModelCache cache = cache(0);
+
+        assertEquals(3, cache.adopt());
+        assertEquals(0, cache.adopt());
+
+        List<ModelCache.Entry> entries = cache.list();
+        assertEquals(2, entries.size());
+        ModelCache.Entry shared = entries.stream().filter(e -> e.aliases().size() == 2).findFirst().orElseThrow();
+        assertEquals("sha256-" + ModelCache.sha256(dir.resolve(ModelCache.BLOBS).resolve(shared.digest())),
+                shared.digest());
+        assertEquals("weights", Files.readString(dir.resolve("alias/qwen-chat.gguf")));
+    }
+
+    @Test
+    void gcDeletesOrphansAndEvictsUnpinnedOverCap() throws Exception {
+        Files.writeString(dir.resolve("big.gguf"), "0123456789");
+        Files.writeString(dir.resolve("keep.gguf"), "abcdefghij");
+        ModelCache cache = cache(15, "keep");
+        cache.adopt();
+
+        ModelCache.GcResult result = cache.gc();
+
+        assertEquals(1, result.evictedAliases());
+        assertEquals(1, result.deletedBlobs());
+        assertEquals(10, result.freedBytes());
+        assertFalse(Files.exists(dir.resolve("big.gguf"), LinkOption.NOFOLLOW_LINKS));
+        assertTrue(Files.exists(dir.resolve("keep.gguf")));
+        assertTrue(cache.list().getFirst().pinned());
+    }
+
+    @Test
+    void reportsAndDeletesPlainFilesWithoutTheContentStore() throws Exception {
+        FileTime longAgo = FileTime.from(Instant.now().minus(Duration.ofDays(60)));
+        Files.writeString(dir.resolve("old.gguf"), "old");
+        Files.writeString(dir.resolve("keep.gguf"), "keep");
+        Files.setLastModifiedTime(dir.resolve("old.gguf"), longAgo);
+        Files.setAttribute(dir.resolve("old.gguf"), "lastAccessTime", longAgo);
+        ModelCache cache = cache(0, "keep.gguf");
+        cache.enabled = false;
+
+        assertEquals(2, cache.files().size());
+        assertEquals(7, cache.usedBytes());
+        assertThrows(IllegalStateException.class, () -> cache.delete("keep.gguf"));
+        assertThrows(IllegalArgumentException.class, () -> cache.delete("../escape.gguf"));
+
+        assertEquals(List.of("old.gguf"), cache.prune(Duration.ofDays(30)));
+        assertEquals(List.of("keep.gguf"), cache.files().stream().map(ModelCache.FileUsage::path).toList());
+
+        cache.setMaxSizeBytes(1);
+        assertEquals(0, cache.gc().evictedAliases());
+    }
+
+    @Test
+    void evictsByUseInThisProcessNotByAccessTime() throws Exception {
+        FileTime longAgo = FileTime.from(Instant.now().minus(Duration.ofDays(60)));
+        for (String name : List.of("used.gguf", "idle.gguf")) {
+            Files.writeString(dir.resolve(name), "0123456789");
+            Files.setLastModifiedTime(dir.resolve(name), longAgo);
+        }
+        // e.g. read by a backup or by hashing; says nothing about use
+        Files.setAttribute(dir.resolve("idle.gguf"), "lastAccessTime", FileTime.from(Instant.now()));
+        ModelCache cache = cache(15);
+        cache.enabled = false;
+
+        cache.touch("used");
+        ModelCache.GcResult result = cache.gc();
+
+        assertEquals(1, result.evictedAliases());
+        assertTrue(Files.exists(dir.resolve("used.gguf")));
+        assertFalse(Files.exists(dir.resolve("idle.gguf")));
+    }
+
+    @Test
+    void loadedModelsAreNeverEvictedOrPruned() throws Exception {
+        FileTime longAgo = FileTime.from(Instant.now().minus(Duration.ofDays(60)));
+        Files.writeString(dir.resolve("loaded.gguf"), "0123456789");
+        Files.writeString(dir.resolve("copy.gguf"), "0123456789");
+        Files.writeString(dir.resolve("named.gguf"), "abcdefghij");
+        ModelCache cache = cache(1);
+        cache.adopt();
+        for (String name : List.of("loaded.gguf", "copy.gguf", "named.gguf")) {
+            Files.setLastModifiedTime(dir.resolve(name), longAgo);
+        }
+        // one model reported by its file, one only by its name
+        cache.modelBackends = new ModelBackends() {
+            @Override
+            public Snapshot snapshot() {
+                return new Snapshot(Map.of(), List.of(
+                        Map.of("model", "some-id", "path", dir.resolve("loaded.gguf").toString()),
+                        Map.of("model", "named")));
+            }
+        };
+
+        assertEquals(List.of(), cache.prune(Duration.ofDays(30)));
+        ModelCache.GcResult result = cache.gc();
+
+        assertEquals(0, result.evictedAliases());
+        assertEquals(2, cache.list().size());
+        // the alias sharing the loaded model's blob is kept with it
+        assertTrue(Files.exists(dir.resolve("copy.gguf")));
+    }
+
+    @Test
+    void startupAdoptsInTheBackground() throws Exception {
+        Files.writeString(dir.resolve("qwen.gguf"), "weights");
+        ModelCache cache = cache(0);
+
+        cache.onStart(null);
+
+        assertTimeoutPreemptively(Duration.ofSeconds(10), () -> {
+            while (cache.list().isEmpty()) {
+                Thread.sleep(20);
+            }
+        });
+        assertEquals(List.of("qwen.gguf"), cache.list().getFirst().aliases());
+        assertEquals("weights", Files.readString(dir.resolve("qwen.gguf")));
+    }
+}
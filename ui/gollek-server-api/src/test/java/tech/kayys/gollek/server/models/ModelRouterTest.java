@@ -0,0 +1,41 @@
+package tech.kayys.gollek.server.models;
+
+import org.junit.jupiter.api.Test;
+
+import java.util.List;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class ModelRouterTest {
+
+    private static ModelRouter.Candidate model(String id, boolean vision, long ctx, long params) {
+        return new ModelRouter.Candidate(id, new ModelCapabilities(true, vision, false, false, false,
+                ctx, "Q4_K_M", "llama", params, null));
+    }
+
+    private final List<ModelRouter.Candidate> models = List.of(
+            model("small", false, 4096, 1_000_000_000L),
+            model("large", false, 8192, 8_000_000_000L),
+            model("vision", true, 4096, 3_000_000_000L),
+            new ModelRouter.Candidate("embed", new ModelCapabilities(false, false, true, false, false,
+                    512L, null, "bert", 100_000_000_000L, null)));
+
+    @Test
+    void picksLargestEligibleModelAndSkipsEmbeddingModels() {
+        var selection = ModelRouter.choose(models, ModelRouter.Hints.NONE, 20).orElseThrow();
+        assertEquals("large", selection.selected());
+        assertEquals(4, selection.candidatesConsidered());
+    }
+
+    @Test
+    void appliesHints() {
+        assertEquals("vision", ModelRouter.choose(models,
+                new ModelRouter.Hints(true, null, null, null), 20).orElseThrow().selected());
+        // 8B at 20 ms per billion is ~160 ms/token, over budget; 3B vision model is ~60 ms
+        assertEquals("vision", ModelRouter.choose(models,
+                new ModelRouter.Hints(null, null, 100, null), 20).orElseThrow().selected());
+        assertTrue(ModelRouter.choose(models,
+                new ModelRouter.Hints(true, null, null, 8192), 20).isEmpty());
+    }
+}
@@ -0,0 +1,75 @@
+package tech.kayys.gollek.server.models;
+
+import static org.junit.jupiter.api.Assertions.assertArrayEquals;
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+
+import java.lang.reflect.Proxy;
+import java.util.List;
+import java.util.Optional;
+
+import jakarta.enterprise.inject.Instance;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.provider.LLMProvider;
+import tech.kayys.gollek.spi.provider.TokenizingProvider;
+
+class TokenizerServiceTest {
+
+    /** A provider for {@code model} that tokenizes to character codes when {@code tokenizing}. */
+    private static LLMProvider provider(String model, boolean tokenizing) {
+        Class<?>[] types = tokenizing
+                ? new Class<?>[] { LLMProvider.class, TokenizingProvider.class }
+                : new Class<?>[] { LLMProvider.class };
+        return (LLMProvider) Proxy.newProxyInstance(LLMProvider.class.getClassLoader(), types,
+                (proxy, method, args) -> switch (method.getName()) {
+                    case "supports" -> model.equals(args[0]);
+                    case "tokenize" -> ((String) args[1]).chars().toArray();
+                    case "detokenize" -> new String(((int[]) args[1]), 0, ((int[]) args[1]).length);
+                    case "tokenPiece" -> String.valueOf((char) (int) args[1]);
+                    case "toString" -> "provider(" + model + ")";
+                    default -> throw new UnsupportedOperationException(method.getName());
+                });
+    }
+
+    @SuppressWarnings("unchecked")
+    private static TokenizerService service(List<String> configured, LLMProvider... providers) {
+        TokenizerService service = new TokenizerService();
+        service.providers = (Instance<LLMProvider>) Proxy.newProxyInstance(Instance.class.getClassLoader(),
+                new Class<?>[] { Instance.class }, (proxy, method, args) -> {
+                    if (method.getName().equals("iterator")) {
+                        return List.of(providers).iterator();
+                    }
+                    throw new UnsupportedOperationException(method.getName());
+                });
+        service.configuredModels = Optional.ofNullable(configured);
+        return service;
+    }
+
+    @Test
+    void theProviderThatKnowsTheModelTokenizes() {
+        TokenizerService service = service(null, provider("other", true), provider("llama", true));
+
+        assertArrayEquals(new int[] { 'h', 'i' }, service.tokenize("llama", "hi", false));
+        assertEquals("hi", service.detokenize("llama", new int[] { 'h', 'i' }));
+        assertEquals("h", service.tokenPiece("llama", 'h'));
+    }
+
+    @Test
+    void requestsWithoutAModelUseTheFirstConfiguredOne() {
+        TokenizerService service = service(List.of("llama", "other"), provider("llama", true));
+
+        assertArrayEquals(new int[] { 'a' }, service.tokenize(null, "a", false));
+        assertThrows(IllegalArgumentException.class, () -> service(null, provider("llama", true))
+                .tokenize(" ", "a", false));
+    }
+
+    @Test
+    void unknownModelsAndProvidersWithoutTokenizersAreTold() {
+        assertThrows(IllegalArgumentException.class,
+                () -> service(null, provider("llama", true)).tokenize("mistral", "a", false));
+        assertThrows(UnsupportedOperationException.class,
+                () -> service(null, provider("llama", false)).tokenize("llama", "a", false));
+    }
+}
@@ -0,0 +1,99 @@
+package tech.kayys.gollek.server.ollama;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.models.ModelCapabilities;
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+
+import java.util.List;
+import java.util.Map;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class OllamaTest {
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    @Test
+    void generateMapsSystemPromptAndOptions() throws Exception {
+        var req = mapper.readValue("""
+                {"model": "llama3", "prompt": "Why is the sky blue?", "system": "Be brief.",
+                 "options": {"temperature": 0.2, "num_predict": 64, "seed": 7, "stop": ["\\n\\n"], "num_ctx": 8192}}
+                """, Ollama.GenerateRequest.class);
+
+        var request = Ollama.toInferenceRequest(req, "id");
+
+        assertTrue(req.isStream());
+        assertEquals(List.of(Message.Role.SYSTEM, Message.Role.USER),
+                request.getMessages().stream().map(Message::getRole).toList());
+        assertEquals(0.2, request.getTemperature(), 1e-9);
+        assertEquals(64, request.getMaxTokens());
+        assertEquals(7, request.getParameters().get("seed"));
+        assertEquals(List.of("\n\n"), request.getParameters().get("stop"));
+        assertNull(request.getParameters().get("num_ctx"));
+    }
+
+    @Test
+    void rawPromptsSkipTheTemplate() throws Exception {
+        var req = mapper.readValue("""
+                {"model": "m", "prompt": "<s>[INST] hi [/INST]", "raw": true, "stream": false,
+                 "options": {"num_predict": -1}}
+                """, Ollama.GenerateRequest.class);
+
+        var request = Ollama.toInferenceRequest(req, "id");
+
+        assertFalse(req.isStream());
+        assertEquals(true, request.getParameters().get("raw"));
+        assertEquals("<s>[INST] hi [/INST]", request.getParameters().get("prompt"));
+        assertNull(request.getParameters().get("max_tokens"));
+    }
+
+    @Test
+    void chatMapsRolesAndJsonFormat() throws Exception {
+        var req = mapper.readValue("""
+                {"model": "m", "format": "json",
+                 "messages": [{"role": "system", "content": "s"}, {"role": "user", "content": "u"}]}
+                """, Ollama.ChatRequest.class);
+
+        var request = Ollama.toInferenceRequest(req, "id");
+
+        assertEquals(2, request.getMessages().size());
+        assertEquals(true, request.getParameters().get("json_mode"));
+        assertThrows(IllegalArgumentException.class, () -> Ollama.toInferenceRequest(mapper.readValue("""
+                {"model": "m", "messages": [{"role": "narrator", "content": "x"}]}
+                """, Ollama.ChatRequest.class), "id"));
+        assertThrows(IllegalArgumentException.class, () -> Ollama.toInferenceRequest(mapper.readValue("""
+                {"model": "m", "format": "yaml", "messages": [{"role": "user", "content": "x"}]}
+                """, Ollama.ChatRequest.class), "id"));
+    }
+
+    @Test
+    void finalLinesCarryCountsAndReason() {
+        Map<String, Object> line = Ollama.done(Ollama.chatLine("m", "hi"),
+                Ollama.doneReason(InferenceResponse.FinishReason.LENGTH), 12, 3, 1_000);
+
+        assertEquals(Map.of("role", "assistant", "content", "hi"), line.get("message"));
+        assertEquals(true, line.get("done"));
+        assertEquals("length", line.get("done_reason"));
+        assertEquals(12L, line.get("prompt_eval_count"));
+        assertEquals(3L, line.get("eval_count"));
+    }
+
+    @Test
+    void capabilitiesUseOllamaNames() {
+        var vision = new ModelCapabilities(true, true, false, false, true, 8192L, "Q4_K_M", "llama", 8_000_000_000L,
+                "8B");
+        var embedder = new ModelCapabilities(false, false, true, false, false, 512L, "F16", "bert", 100_000_000L,
+                "100M");
+
+        assertEquals(List.of("completion", "vision", "tools"), Ollama.capabilities(vision));
+        assertEquals(List.of("embedding"), Ollama.capabilities(embedder));
+        assertEquals(List.of("completion"), Ollama.capabilities(null));
+    }
+}
@@ -0,0 +1,193 @@
+package tech.kayys.gollek.server.openai;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.spi.inference.InferenceResponse;
+import tech.kayys.gollek.spi.inference.StreamingInferenceChunk;
+
+import java.util.List;
+import java.util.Map;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+
+class ChatCompletionsTest {
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    private ChatCompletionRequest parse(String json) throws Exception {
+        return mapper.readValue(json, ChatCompletionRequest.class);
+    }
+
+    @Test
+    void copiesKeepEveryOtherField() throws Exception {
+        var req = parse("""
+                {"model": "auto", "messages": [{"role": "user", "content": "hi"}], "n": 2, "logprobs": true,
+                 "top_logprobs": 3, "inter_token_timeout_ms": 500, "conversation_id": "c1"}
+                """);
+
+        var copy = req.withModel("qwen");
+
+        assertEquals("qwen", copy.model());
+        assertEquals(req, copy.toBuilder().model("auto").build());
+        assertEquals(3, copy.logprobAlternatives());
+        assertEquals(500L, copy.interTokenTimeoutMs());
+    }
+
+    @Test
+    void forwardsLogitBias() throws Exception {
+        var req = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}],
+                 "logit_bias": {"50256": -100, "1234": 2.5}}
+                """);
+
+        var request = ChatCompletions.toInferenceRequest(req, "r1", ChatCompletions.toMessages(req));
+
+        assertEquals(Map.of("50256", -100.0, "1234", 2.5), request.getParameters().get("logit_bias"));
+    }
+
+    @Test
+    void rejectsLogitBiasOutsideOpenAiLimits() throws Exception {
+        var badKey = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "logit_bias": {"the": 1}}
+                """);
+        var badValue = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "logit_bias": {"7": 150}}
+                """);
+
+        assertThrows(IllegalArgumentException.class,
+                () -> ChatCompletions.toInferenceRequest(badKey, "r1", ChatCompletions.toMessages(badKey)));
+        assertThrows(IllegalArgumentException.class,
+                () -> ChatCompletions.toInferenceRequest(badValue, "r1", ChatCompletions.toMessages(badValue)));
+    }
+
+    @Test
+    void forwardsTypicalAndMirostatSettings() throws Exception {
+        var req = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}],
+                 "typical_p": 0.9, "mirostat": 2, "mirostat_tau": 4.0, "mirostat_eta": 0.2, "min_p": 0.1}
+                """);
+
+        var params = ChatCompletions.toInferenceRequest(req, "r1", ChatCompletions.toMessages(req)).getParameters();
+
+        assertEquals(0.9, params.get("typical_p"));
+        assertEquals(2, params.get("mirostat"));
+        assertEquals(4.0, params.get("mirostat_tau"));
+        assertEquals(0.2, params.get("mirostat_eta"));
+        assertEquals(0.1, params.get("min_p"));
+        var badMode = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "mirostat": 3}
+                """);
+        assertThrows(IllegalArgumentException.class,
+                () -> ChatCompletions.toInferenceRequest(badMode, "r1", ChatCompletions.toMessages(badMode)));
+    }
+
+    @Test
+    void forwardsContextOverflowStrategy() throws Exception {
+        var req = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}],
+                 "context_overflow": "shift", "n_keep": 64}
+                """);
+
+        var params = ChatCompletions.toInferenceRequest(req, "r1", ChatCompletions.toMessages(req)).getParameters();
+
+        assertEquals("shift", params.get("context_overflow"));
+        assertEquals(64, params.get("n_keep"));
+        var bad = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "context_overflow": "wrap"}
+                """);
+        assertThrows(IllegalArgumentException.class,
+                () -> ChatCompletions.toInferenceRequest(bad, "r1", ChatCompletions.toMessages(bad)));
+    }
+
+    @Test
+    void forwardsLogprobsInChatAndCompletionsStyle() throws Exception {
+        var chat = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "logprobs": true, "top_logprobs": 3}
+                """);
+        var count = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "logprobs": 5}
+                """);
+        var tooMany = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "logprobs": true, "top_logprobs": 21}
+                """);
+
+        assertEquals(3, ChatCompletions.toInferenceRequest(chat, "r1").getParameters().get("logprobs"));
+        assertEquals(5, ChatCompletions.toInferenceRequest(count, "r1").getParameters().get("logprobs"));
+        assertThrows(IllegalArgumentException.class, () -> ChatCompletions.toInferenceRequest(tooMany, "r1"));
+    }
+
+    @Test
+    void mapsLogprobsMetadataOntoChoices() {
+        var content = List.<Map<String, Object>>of(Map.of("token", "Hi", "logprob", -0.1, "top_logprobs", List.of()));
+        var response = InferenceResponse.builder().requestId("r1").model("m").content("Hi")
+                .metadata("logprobs", content).build();
+        var chunk = StreamingInferenceChunk.withMetadata("r1", 0, "Hi", Map.of("logprobs", content));
+
+        assertEquals(content, ChatCompletions.toChatCompletion("c1", "m", response).choices().get(0).logprobs().content());
+        assertEquals(content, ChatCompletions.toChunk("c1", "m", 0, chunk, true).choices().get(0).logprobs().content());
+        assertNull(ChatCompletions.toChunk("c1", "m", 0, StreamingInferenceChunk.of("r1", 1, "!"), false)
+                .choices().get(0).logprobs());
+    }
+
+    @Test
+    void verboseJsonAsksForTheReportAndLogprobs() throws Exception {
+        var verbose = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}],
+                 "response_format": {"type": "verbose_json"}}
+                """);
+        var withTop = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "logprobs": true, "top_logprobs": 2,
+                 "response_format": {"type": "verbose_json"}}
+                """);
+
+        var params = ChatCompletions.toInferenceRequest(verbose, "r1").getParameters();
+        assertEquals(true, params.get("verbose"));
+        assertEquals(0, params.get("logprobs"));
+        assertEquals(2, ChatCompletions.toInferenceRequest(withTop, "r1").getParameters().get("logprobs"));
+
+        Map<String, Object> report = Map.of("token_ids", List.of(9906), "timings", Map.of("total_ms", 12.5));
+        var response = InferenceResponse.builder().requestId("r1").model("m").content("Hi")
+                .metadata("verbose", report).build();
+        assertEquals(report, ChatCompletions.toChatCompletion("c1", "m", response).choices().get(0).verbose());
+        assertNull(ChatCompletions.toChunk("c1", "m", 0, StreamingInferenceChunk.of("r1", 1, "!"), false)
+                .choices().get(0).verbose());
+    }
+
+    @Test
+    void choicesGetTheirOwnRequestIdsAndSeeds() throws Exception {
+        var req = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "n": 3, "seed": 42}
+                """);
+
+        var requests = ChatCompletions.choiceRequests(ChatCompletions.toInferenceRequest(req, "r1"),
+                req.choices(ChatCompletions.MAX_CHOICES));
+
+        assertEquals(List.of("r1", "r1-1", "r1-2"), requests.stream().map(r -> r.getRequestId()).toList());
+        assertEquals(List.of(42, 43, 44), requests.stream().map(r -> r.getParameters().get("seed")).toList());
+        var none = parse("""
+                {"model": "m", "messages": [{"role": "user", "content": "hi"}], "n": 0}
+                """);
+        assertThrows(IllegalArgumentException.class, () -> none.choices(ChatCompletions.MAX_CHOICES));
+    }
+
+    @Test
+    void mergesChoicesAndCountsThePromptOnce() {
+        var a = InferenceResponse.builder().requestId("r1").model("m").content("A").inputTokens(10).outputTokens(3)
+                .build();
+        var b = InferenceResponse.builder().requestId("r1-1").model("m").content("B").inputTokens(10)
+                .outputTokens(5).finishReason(InferenceResponse.FinishReason.LENGTH).build();
+
+        var completion = ChatCompletions.toChatCompletion("c1", "m", List.of(a, b));
+
+        assertEquals(List.of(0, 1), completion.choices().stream().map(ChatCompletion.Choice::index).toList());
+        assertEquals("B", completion.choices().get(1).message().content());
+        assertEquals("length", completion.choices().get(1).finishReason());
+        assertEquals(10, completion.usage().promptTokens());
+        assertEquals(8, completion.usage().completionTokens());
+        assertEquals(1, ChatCompletions.toChunk("c1", "m", 0, StreamingInferenceChunk.of("r1-1", 0, "B"), true, 1)
+                .choices().get(0).index());
+    }
+}
@@ -0,0 +1,45 @@
+package tech.kayys.gollek.server.openai;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import org.junit.jupiter.api.Test;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+class ResponseFormatTest {
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    @Test
+    void jsonObjectAcceptsAnyObject() {
+        var format = new ResponseFormat("json_object", null, null);
+        assertTrue(format.validate("{\"a\": 1}").isEmpty());
+        assertTrue(format.validate("not json").get(0).startsWith("output is not valid JSON"));
+        assertEquals("$: expected object", format.validate("[1]").get(0));
+        assertTrue(format.grammar().startsWith("root ::= ws object-any"));
+    }
+
+    @Test
+    void jsonSchemaChecksTypesAndRequiredFields() throws Exception {
+        var schema = mapper.readTree("""
+                {"type": "object", "required": ["name", "age"],
+                 "properties": {"name": {"type": "string"}, "age": {"type": "integer"},
+                                "tags": {"type": "array", "items": {"enum": ["a", "b"]}}}}
+                """);
+        var format = new ResponseFormat("json_schema", null, new ResponseFormat.JsonSchemaSpec("person", schema, true));
+
+        assertTrue(format.validate("{\"name\": \"x\", \"age\": 3, \"tags\": [\"a\"]}").isEmpty());
+        var problems = format.validate("{\"name\": 1, \"tags\": [\"c\"]}");
+        assertEquals(3, problems.size());
+        assertTrue(problems.contains("$: missing required field age"));
+        assertTrue(problems.contains("$.name: expected string"));
+        assertTrue(problems.contains("$.tags[0]: value not in enum"));
+    }
+
+    @Test
+    void rejectsSchemaFormatWithoutSchema() {
+        assertThrows(IllegalArgumentException.class, () -> new ResponseFormat("json_schema", null, null).check());
+        assertThrows(IllegalArgumentException.class, () -> new ResponseFormat("yaml", null, null).check());
+    }
+}
@@ -0,0 +1,69 @@
+package tech.kayys.gollek.server.publish;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.io.ByteArrayOutputStream;
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.List;
+
+import org.junit.jupiter.api.Test;
+
+class TokenChannelsTest {
+
+    private static TokenChannels channels(boolean enabled) {
+        TokenChannels channels = new TokenChannels();
+        channels.enabled = enabled;
+        channels.channelPrefix = "gollek.";
+        return channels;
+    }
+
+    @Test
+    void parsesDestinationAndMode() {
+        TokenChannels channels = channels(true);
+
+        assertNull(channels.destination(null, "only"));
+        var tee = channels.destination("redis:gollek.chat.1", null);
+        assertEquals("redis", tee.transport());
+        assertEquals("gollek.chat.1", tee.channel());
+        assertFalse(tee.only());
+        var only = channels.destination("NATS:gollek.chat.>", "only");
+        assertEquals("nats", only.transport());
+        assertTrue(only.only());
+    }
+
+    @Test
+    void rejectsInvalidDestinations() {
+        assertThrows(IllegalArgumentException.class, () -> channels(false).destination("redis:gollek.a", null));
+        TokenChannels channels = channels(true);
+        assertThrows(IllegalArgumentException.class, () -> channels.destination("kafka:gollek.a", null));
+        assertThrows(IllegalArgumentException.class, () -> channels.destination("gollek.a", null));
+        assertThrows(IllegalArgumentException.class, () -> channels.destination("redis:other.a", null));
+        assertThrows(IllegalArgumentException.class, () -> channels.destination("nats:gollek. a", null));
+        assertThrows(IllegalArgumentException.class, () -> channels.destination("redis:gollek.a", "both"));
+    }
+
+    @Test
+    void teePublishesEachEventsDataOnce() throws Exception {
+        var sse = new ByteArrayOutputStream();
+        List<String> published = new ArrayList<>();
+        var tee = new TokenChannels.EventTee(sse, published::add);
+        String stream = "event: queued\ndata: {\"position\":1}\n\ndata: {\"delta\":\"hé\"}\n\ndata: [DONE]\n\n";
+        byte[] bytes = stream.getBytes(StandardCharsets.UTF_8);
+
+        // split inside the two-byte 'é' and inside an event delimiter
+        int cut = stream.substring(0, stream.indexOf('é')).getBytes(StandardCharsets.UTF_8).length + 1;
+        tee.write(Arrays.copyOfRange(bytes, 0, cut));
+        tee.write(Arrays.copyOfRange(bytes, cut, bytes.length - 1));
+        assertEquals(List.of("{\"position\":1}", "{\"delta\":\"hé\"}"), published);
+        tee.write(bytes[bytes.length - 1]);
+
+        assertEquals(List.of("{\"position\":1}", "{\"delta\":\"hé\"}", "[DONE]"), published);
+        assertEquals(stream, sse.toString(StandardCharsets.UTF_8));
+    }
+}
@@ -0,0 +1,50 @@
+package tech.kayys.gollek.server.replay;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertNotEquals;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.List;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+import tech.kayys.gollek.spi.Message;
+import tech.kayys.gollek.spi.inference.InferenceRequest;
+
+class FixtureStoreTest {
+
+    @TempDir
+    Path dir;
+
+    private static InferenceRequest request(String id, String prompt) {
+        return InferenceRequest.builder()
+                .requestId(id)
+                .model("demo")
+                .message(Message.user(prompt))
+                .temperature(0.0)
+                .build();
+    }
+
+    @Test
+    void keyIgnoresRequestIdButNotContent() {
+        FixtureStore store = new FixtureStore(dir.resolve("f.json"));
+        assertEquals(store.key(request("a", "hi"), false), store.key(request("b", "hi"), false));
+        assertNotEquals(store.key(request("a", "hi"), false), store.key(request("a", "bye"), false));
+        assertNotEquals(store.key(request("a", "hi"), false), store.key(request("a", "hi"), true));
+    }
+
+    @Test
+    void fixturesSurviveReload() {
+        Path file = dir.resolve("f.json");
+        FixtureStore store = new FixtureStore(file);
+        store.put("k", new FixtureStore.Fixture("hello", "demo", 1, 2, "STOP", List.of("hel", "lo")));
+
+        FixtureStore reloaded = new FixtureStore(file);
+        assertTrue(Files.exists(file));
+        assertEquals("hello", reloaded.get("k").content());
+        assertEquals(List.of("hel", "lo"), reloaded.get("k").chunks());
+    }
+}
@@ -0,0 +1,71 @@
+package tech.kayys.gollek.server.schedules;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.lang.reflect.Proxy;
+import java.util.Base64;
+import java.util.List;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+import io.quarkus.scheduler.Scheduler;
+import tech.kayys.gollek.server.store.InMemoryStore;
+import tech.kayys.gollek.server.store.Store;
+
+class TaskSchedulerTest {
+
+    private static TaskScheduler scheduler(String... allowedHosts) {
+        TaskScheduler scheduler = new TaskScheduler();
+        scheduler.webhookAllowedHosts = allowedHosts.length == 0 ? Optional.empty() : Optional.of(List.of(allowedHosts));
+        return scheduler;
+    }
+
+    @Test
+    void webhooksAreRefusedWithoutAnAllowList() {
+        assertFalse(scheduler().webhookAllowed("https://hooks.example.com/run"));
+    }
+
+    @Test
+    void webhooksMustTargetAnAllowedHost() {
+        TaskScheduler scheduler = scheduler("hooks.example.com", "*.internal.example.com");
+        assertTrue(scheduler.webhookAllowed("https://hooks.example.com/run"));
+        assertTrue(scheduler.webhookAllowed("http://ci.internal.example.com:8080/x"));
+        assertFalse(scheduler.webhookAllowed("http://169.254.169.254/latest/meta-data"));
+        assertFalse(scheduler.webhookAllowed("https://hooks.example.com.evil.test/run"));
+        assertFalse(scheduler.webhookAllowed("https://user@hooks.example.com/run"));
+        assertFalse(scheduler.webhookAllowed("file://hooks.example.com/etc/passwd"));
+        assertFalse(scheduler.webhookAllowed("not a url"));
+    }
+
+    /** A scheduler that accepts every job definition and never fires. */
+    private static Scheduler acceptingScheduler() {
+        Class<?>[] types = { Scheduler.class, Scheduler.JobDefinition.class };
+        return (Scheduler) Proxy.newProxyInstance(Scheduler.class.getClassLoader(), types,
+                (proxy, method, args) -> method.getReturnType().isInstance(proxy) ? proxy : null);
+    }
+
+    @Test
+    void tasksAndRunsAreEncryptedWithAStorageKey() {
+        Store store = new InMemoryStore();
+        TaskScheduler scheduler = scheduler("hooks.example.com");
+        scheduler.store = store;
+        scheduler.scheduler = acceptingScheduler();
+        scheduler.encryptionKey = Optional.of(Base64.getEncoder().encodeToString(new byte[32]));
+        scheduler.encryptionKeyFile = Optional.empty();
+        scheduler.history = 10;
+        scheduler.init();
+
+        ScheduledTask task = scheduler.create(new ScheduledTask(null, "nightly", null, "1h", "m", null,
+                "summarize the secret plan", null, null, null, 0, null));
+        scheduler.runNow(task.id());
+
+        String stored = store.get(TaskScheduler.NAMESPACE, task.id()).orElseThrow();
+        assertFalse(stored.contains("secret plan"));
+        assertFalse(store.range(TaskScheduler.NAMESPACE, task.id(), 0, -1).get(0).startsWith("{"));
+        assertEquals("summarize the secret plan", scheduler.get(task.id()).orElseThrow().prompt());
+        assertEquals(1, scheduler.results(task.id(), 10).size());
+    }
+}
@@ -0,0 +1,46 @@
+package tech.kayys.gollek.server.security;
+
+import static org.junit.jupiter.api.Assertions.assertArrayEquals;
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.nio.charset.StandardCharsets;
+
+import org.junit.jupiter.api.Test;
+
+class AtRestCipherTest {
+
+    private final AtRestCipher cipher = new AtRestCipher(new byte[32]);
+    private final byte[] plain = "{\"content\":\"secret prompt\"}".getBytes(StandardCharsets.UTF_8);
+
+    @Test
+    void roundTrips() {
+        byte[] sealed = cipher.encrypt(plain);
+        assertTrue(AtRestCipher.isEncrypted(sealed));
+        assertArrayEquals(plain, cipher.decrypt(sealed));
+    }
+
+    @Test
+    void plaintextPassesThrough() {
+        assertFalse(AtRestCipher.isEncrypted(plain));
+        assertArrayEquals(plain, cipher.decrypt(plain));
+    }
+
+    @Test
+    void stringsRoundTripAsBase64() {
+        String sealed = cipher.encryptToString("secret prompt");
+        assertFalse(sealed.contains("secret"));
+        assertEquals("secret prompt", cipher.decryptToString(sealed));
+        assertThrows(IllegalStateException.class, () -> cipher.decryptToString("{\"plain\":true}"));
+    }
+
+    @Test
+    void wrongKeyFails() {
+        byte[] other = new byte[32];
+        other[0] = 1;
+        byte[] sealed = cipher.encrypt(plain);
+        assertThrows(IllegalStateException.class, () -> new AtRestCipher(other).decrypt(sealed));
+    }
+}
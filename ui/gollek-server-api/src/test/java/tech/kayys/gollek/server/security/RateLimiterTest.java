@@ -0,0 +1,43 @@
+package tech.kayys.gollek.server.security;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.util.List;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+class RateLimiterTest {
+
+    private static RateLimiter limiter(String... trustedProxies) {
+        RateLimiter limiter = new RateLimiter();
+        limiter.trustedProxies = trustedProxies.length == 0 ? Optional.empty() : Optional.of(List.of(trustedProxies));
+        return limiter;
+    }
+
+    @Test
+    void forwardedForIsIgnoredFromUntrustedPeers() {
+        assertEquals("203.0.113.9", limiter().clientIp("203.0.113.9", "1.2.3.4"));
+        assertEquals("203.0.113.9", limiter("10.0.0.0/8").clientIp("203.0.113.9", "1.2.3.4"));
+    }
+
+    @Test
+    void trustedProxiesAreSkippedRightToLeft() {
+        RateLimiter limiter = limiter("10.0.0.0/8", "127.0.0.1");
+        // the left-most entry is whatever the client sent; the nearest untrusted hop is the client
+        assertEquals("198.51.100.7", limiter.clientIp("127.0.0.1", "6.6.6.6, 198.51.100.7, 10.1.2.3"));
+        assertEquals("127.0.0.1", limiter.clientIp("127.0.0.1", null));
+    }
+
+    @Test
+    void matchesAddressesAndCidrs() {
+        assertTrue(RateLimiter.inRange("10.20.30.40", "10.0.0.0/8"));
+        assertTrue(RateLimiter.inRange("192.168.1.130", "192.168.1.128/25"));
+        assertFalse(RateLimiter.inRange("192.168.1.127", "192.168.1.128/25"));
+        assertTrue(RateLimiter.inRange("::1", "::1"));
+        assertFalse(RateLimiter.inRange("::1", "127.0.0.1"));
+        assertFalse(RateLimiter.inRange("localhost", "127.0.0.1"));
+    }
+}
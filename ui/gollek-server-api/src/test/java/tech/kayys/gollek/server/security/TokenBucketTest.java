@@ -0,0 +1,30 @@
+package tech.kayys.gollek.server.security;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import org.junit.jupiter.api.Test;
+
+class TokenBucketTest {
+
+    private static final long SECOND = 1_000_000_000L;
+
+    @Test
+    void burstThenRefill() {
+        TokenBucket bucket = new TokenBucket(2, 3, 0);
+        assertEquals(0, bucket.tryAcquire(0));
+        assertEquals(0, bucket.tryAcquire(0));
+        assertEquals(0, bucket.tryAcquire(0));
+        long wait = bucket.tryAcquire(0);
+        assertEquals(SECOND / 2, wait);
+        assertEquals(0, bucket.tryAcquire(SECOND / 2));
+    }
+
+    @Test
+    void refillIsCappedAtBurst() {
+        TokenBucket bucket = new TokenBucket(100, 2, 0);
+        bucket.tryAcquire(0);
+        assertEquals(2, bucket.remaining(60 * SECOND));
+        assertTrue(bucket.isIdle(60 * SECOND));
+    }
+}
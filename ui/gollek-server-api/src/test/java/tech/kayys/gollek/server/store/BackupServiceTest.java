@@ -0,0 +1,100 @@
+package tech.kayys.gollek.server.store;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+
+import java.lang.reflect.Proxy;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.List;
+import java.util.Map;
+import java.util.Optional;
+
+import org.eclipse.microprofile.metrics.Counter;
+import org.eclipse.microprofile.metrics.MetricRegistry;
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+class BackupServiceTest {
+
+    @TempDir
+    Path dir;
+
+    private final Store store = new InMemoryStore();
+
+    static MetricRegistry registry() {
+        Counter counter = (Counter) Proxy.newProxyInstance(Counter.class.getClassLoader(),
+                new Class<?>[] { Counter.class }, (proxy, method, args) -> null);
+        return (MetricRegistry) Proxy.newProxyInstance(MetricRegistry.class.getClassLoader(),
+                new Class<?>[] { MetricRegistry.class },
+                (proxy, method, args) -> method.getReturnType() == Counter.class ? counter : null);
+    }
+
+    private BackupService backups(List<String> namespaces) {
+        BackupService backups = new BackupService();
+        backups.store = store;
+        backups.registry = registry();
+        backups.dir = dir.toString();
+        backups.namespaces = namespaces;
+        backups.keep = 7;
+        return backups;
+    }
+
+    @Test
+    void restoreReplacesNamespacesWithTheBackup() throws Exception {
+        store.put("conversations", "c1", "first");
+        store.append("usage-events", "2026-10", "e1");
+        store.append("usage-events", "2026-10", "e2");
+        BackupService backups = backups(List.of("conversations", "usage-events"));
+        String file = backups.backup().file();
+
+        store.put("conversations", "c1", "changed");
+        store.put("conversations", "c2", "added later");
+        store.deleteList("usage-events", "2026-10");
+        Map<String, Integer> restored = backups.restore(Path.of(file).getFileName().toString(), List.of());
+
+        assertEquals(Map.of("conversations", 1, "usage-events", 2), restored);
+        assertEquals(Optional.of("first"), store.get("conversations", "c1"));
+        assertEquals(List.of("c1"), store.keys("conversations"));
+        assertEquals(List.of("e1", "e2"), store.range("usage-events", "2026-10", 0, -1));
+    }
+
+    @Test
+    void restoreCanBeLimitedToSomeNamespaces() throws Exception {
+        store.put("conversations", "c1", "first");
+        store.put("metrics", "requests", "10");
+        BackupService backups = backups(List.of("conversations", "metrics"));
+        String file = backups.backup().file();
+
+        store.put("conversations", "c1", "changed");
+        store.put("metrics", "requests", "12");
+        backups.restore(file, List.of("metrics"));
+
+        assertEquals(Optional.of("changed"), store.get("conversations", "c1"));
+        assertEquals(Optional.of("10"), store.get("metrics", "requests"));
+    }
+
+    @Test
+    void onlyFilesInTheBackupDirectoryCanBeRestored() throws Exception {
+        Path outside = Files.writeString(dir.getParent().resolve("gollek-backup-elsewhere.json"), "{}");
+        try {
+            BackupService backups = backups(List.of("conversations"));
+            assertThrows(IllegalArgumentException.class, () -> backups.restore("../" + outside.getFileName(), List.of()));
+            assertThrows(IllegalArgumentException.class, () -> backups.restore("gollek-backup-missing.json", List.of()));
+        } finally {
+            Files.deleteIfExists(outside);
+        }
+    }
+
+    @Test
+    void backupsArePrunedToKeep() throws Exception {
+        BackupService backups = backups(List.of("conversations"));
+        backups.keep = 2;
+        for (int i = 0; i < 3; i++) {
+            Files.writeString(dir.resolve("gollek-backup-2020010" + i + "-000000.json"), "{}");
+        }
+        backups.backup();
+
+        assertEquals(2, backups.list().size());
+    }
+}
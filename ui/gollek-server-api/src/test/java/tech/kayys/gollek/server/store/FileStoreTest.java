@@ -0,0 +1,46 @@
+package tech.kayys.gollek.server.store;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.List;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+import org.junit.jupiter.api.io.TempDir;
+
+class FileStoreTest {
+
+    @TempDir
+    Path dir;
+
+    private FileStore store() {
+        FileStore store = new FileStore();
+        store.dir = dir.toString();
+        return store;
+    }
+
+    @Test
+    void namespacesSurviveAReload() {
+        FileStore first = store();
+        first.put("ns", "k", "v");
+        first.append("ns", "log", "a");
+
+        FileStore second = store();
+        assertEquals(Optional.of("v"), second.get("ns", "k"));
+        assertEquals(List.of("a"), second.range("ns", "log", 0, -1));
+    }
+
+    @Test
+    void unreadableFileIsNeverOverwritten() throws Exception {
+        Path file = dir.resolve("conversations.json");
+        Files.writeString(file, "[{\"id\":\"conv-1\"}]");
+
+        FileStore store = store();
+        assertThrows(IllegalStateException.class, () -> store.keys("conversations"));
+        assertThrows(IllegalStateException.class, () -> store.put("conversations", "k", "v"));
+        assertEquals("[{\"id\":\"conv-1\"}]", Files.readString(file));
+    }
+}
@@ -0,0 +1,58 @@
+package tech.kayys.gollek.server.store;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertFalse;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.util.List;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+class InMemoryStoreTest {
+
+    private final Store store = new InMemoryStore();
+
+    @Test
+    void keyValueIsNamespaced() {
+        store.put("a", "k", "1");
+        store.put("b", "k", "2");
+        assertEquals(Optional.of("1"), store.get("a", "k"));
+        assertEquals(List.of("k"), store.keys("b"));
+        assertTrue(store.delete("a", "k"));
+        assertFalse(store.delete("a", "k"));
+        assertEquals(Optional.of("2"), store.get("b", "k"));
+    }
+
+    @Test
+    void listsKeepInsertionOrderAndPage() {
+        for (String v : List.of("x", "y", "z")) {
+            store.append("ns", "log", v);
+        }
+        assertEquals(List.of("x", "y", "z"), store.range("ns", "log", 0, -1));
+        assertEquals(List.of("y"), store.range("ns", "log", 1, 1));
+        assertEquals(List.of(), store.range("ns", "log", 5, 2));
+        store.deleteList("ns", "log");
+        assertEquals(List.of(), store.range("ns", "log", 0, -1));
+    }
+
+    @Test
+    void trimKeepsTheNewestEntries() {
+        for (String v : List.of("a", "b", "c", "d")) {
+            store.append("ns", "log", v);
+        }
+        store.trim("ns", "log", 2);
+        assertEquals(List.of("c", "d"), store.range("ns", "log", 0, -1));
+        store.trim("ns", "log", 5);
+        assertEquals(List.of("c", "d"), store.range("ns", "log", 0, -1));
+    }
+
+    @Test
+    void compareAndSetOnlyWritesTheExpectedValue() {
+        assertTrue(store.compareAndSet("ns", "k", null, "1"));
+        assertFalse(store.compareAndSet("ns", "k", null, "2"));
+        assertFalse(store.compareAndSet("ns", "k", "0", "2"));
+        assertTrue(store.compareAndSet("ns", "k", "1", "2"));
+        assertEquals(Optional.of("2"), store.get("ns", "k"));
+    }
+}
@@ -0,0 +1,14 @@
+package tech.kayys.gollek.server.store;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+
+import org.junit.jupiter.api.Test;
+
+class RedisStoreTest {
+
+    @Test
+    void scanPatternsMatchNamespacesLiterally() {
+        assertEquals("gollek:plain:list:", RedisStore.globEscape("gollek:plain:list:"));
+        assertEquals("gollek:a\\*b\\?c\\[d\\]\\\\e:list:", RedisStore.globEscape("gollek:a*b?c[d]\\e:list:"));
+    }
+}
@@ -0,0 +1,60 @@
+package tech.kayys.gollek.server.store;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertNull;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import java.time.Duration;
+import java.time.Instant;
+import java.util.Optional;
+
+import org.junit.jupiter.api.Test;
+
+import tech.kayys.gollek.server.conversations.ConversationStore;
+
+class RetentionServiceTest {
+
+    /** Records the cutoff it is asked for and reports {@code deleted} conversations. */
+    static final class Conversations extends ConversationStore {
+        Instant cutoff;
+        int deleted;
+
+        @Override
+        public synchronized int deleteUpdatedBefore(Instant cutoff) {
+            this.cutoff = cutoff;
+            return deleted;
+        }
+    }
+
+    private static RetentionService retention(Conversations conversations, Duration maxAge) {
+        RetentionService retention = new RetentionService();
+        retention.conversations = conversations;
+        retention.registry = BackupServiceTest.registry();
+        retention.conversationsMaxAge = Optional.ofNullable(maxAge);
+        return retention;
+    }
+
+    @Test
+    void conversationsIdleLongerThanMaxAgeAreDeleted() {
+        Conversations conversations = new Conversations();
+        conversations.deleted = 3;
+        Instant before = Instant.now().minus(Duration.ofDays(30));
+
+        RetentionService.Result result = retention(conversations, Duration.ofDays(30)).enforce();
+
+        assertEquals(3, result.conversationsDeleted());
+        assertTrue(!conversations.cutoff.isBefore(before));
+        assertTrue(conversations.cutoff.isBefore(Instant.now().minus(Duration.ofDays(29))));
+    }
+
+    @Test
+    void nothingIsDeletedWithoutAPolicy() {
+        Conversations conversations = new Conversations();
+
+        RetentionService retention = retention(conversations, null);
+
+        assertEquals(0, retention.enforce().conversationsDeleted());
+        assertNull(conversations.cutoff);
+        assertNull(retention.policy().conversationsMaxAgeSeconds());
+    }
+}
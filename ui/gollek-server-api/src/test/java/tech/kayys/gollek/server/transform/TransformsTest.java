@@ -0,0 +1,74 @@
+package tech.kayys.gollek.server.transform;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+import static org.junit.jupiter.api.Assertions.assertTrue;
+
+import com.fasterxml.jackson.core.type.TypeReference;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.node.ObjectNode;
+import org.junit.jupiter.api.Test;
+
+import java.util.List;
+import java.util.Map;
+
+class TransformsTest {
+
+    private final ObjectMapper mapper = new ObjectMapper();
+
+    private Transforms load(String json) throws Exception {
+        Transforms transforms = new Transforms();
+        transforms.setRules(mapper.readValue(json, new TypeReference<List<Transforms.Rule>>() {
+        }));
+        return transforms;
+    }
+
+    @Test
+    void mapsALegacyClientsFieldsOnItsRouteOnly() throws Exception {
+        Transforms transforms = load("""
+                [{"route": "/v1/chat/completions", "headers": {"X-Client": "crm"},
+                  "request": [{"op": "rename", "field": "max_length", "to": "max_tokens"},
+                              {"op": "default", "field": "temperature", "value": 0.3},
+                              {"op": "split", "field": "stop", "separator": "|"},
+                              {"op": "drop", "field": "session"}],
+                  "response": [{"op": "rename", "field": "usage.total_tokens", "to": "usage.tokens"}]}]
+                """);
+        Map<String, String> crm = Map.of("X-Client", "crm");
+
+        var ops = transforms.requestOps("v1/chat/completions", crm::get);
+        ObjectNode body = (ObjectNode) mapper.readTree("""
+                {"max_length": 64, "stop": "END|###", "session": "s1", "messages": []}
+                """);
+        Transforms.apply(body, ops);
+
+        assertEquals(mapper.readTree("""
+                {"max_tokens": 64, "stop": ["END", "###"], "messages": [], "temperature": 0.3}
+                """), body);
+        assertTrue(transforms.requestOps("v1/chat/completions", h -> null).isEmpty());
+        assertTrue(transforms.requestOps("v1/completions", crm::get).isEmpty());
+
+        ObjectNode response = (ObjectNode) mapper.readTree("""
+                {"usage": {"total_tokens": 9, "prompt_tokens": 4}}
+                """);
+        Transforms.apply(response, transforms.responseOps("v1/chat/completions", crm::get));
+        assertEquals(mapper.readTree("""
+                {"usage": {"prompt_tokens": 4, "tokens": 9}}
+                """), response);
+    }
+
+    @Test
+    void renameKeepsAFieldTheClientAlsoSetAndRejectsMalformedSteps() throws Exception {
+        ObjectNode body = (ObjectNode) mapper.readTree("""
+                {"max_length": 64, "max_tokens": 10}
+                """);
+        Transforms.apply(body, List.of(new Transforms.Op("rename", "max_length", "max_tokens", null, null)));
+        assertEquals(10, body.get("max_tokens").asInt());
+
+        assertThrows(IllegalArgumentException.class, () -> load("""
+                [{"route": "v1/completions", "request": [{"op": "rename", "field": "a"}]}]
+                """));
+        assertThrows(IllegalArgumentException.class, () -> load("""
+                [{"route": "v1/completions", "request": [{"op": "uppercase", "field": "a"}]}]
+                """));
+    }
+}
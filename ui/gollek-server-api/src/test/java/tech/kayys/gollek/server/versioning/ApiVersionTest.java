@@ -0,0 +1,42 @@
+package tech.kayys.gollek.server.versioning;
+
+import static org.junit.jupiter.api.Assertions.assertEquals;
+import static org.junit.jupiter.api.Assertions.assertThrows;
+
+import org.junit.jupiter.api.Test;
+
+import java.util.Map;
+
+class ApiVersionTest {
+
+    @Test
+    void negotiatesFromPathAndHeader() {
+        assertEquals(1, ApiVersion.ofPath("v1/chat/completions"));
+        assertEquals(2, ApiVersion.ofPath("/v2/embeddings"));
+        assertEquals(0, ApiVersion.ofPath("health"));
+        assertEquals(0, ApiVersion.ofPath("v10/models"));
+
+        assertEquals(1, ApiVersion.parse(null, 1));
+        assertEquals(2, ApiVersion.parse("v2", 1));
+        assertEquals(2, ApiVersion.parse(" 2 ", 1));
+        assertThrows(IllegalArgumentException.class, () -> ApiVersion.parse("3", 1));
+        assertThrows(IllegalArgumentException.class, () -> ApiVersion.parse("latest", 1));
+    }
+
+    @Test
+    void v2ErrorsCarryTheTaxonomy() {
+        var body = ApiVersion.v2Error(503, Map.of("error", "Runner queue is full", "retry_after", 2));
+
+        assertEquals(Map.of("type", "overloaded_error", "code", "overloaded_error",
+                "message", "Runner queue is full", "retryable", true), body.get("error"));
+        assertEquals(2, body.get("retry_after"));
+        assertEquals(Map.of("type", "invalid_request_error", "code", "RUNTIME_007", "message", "bad", "retryable", false),
+                ApiVersion.v2Error(400, Map.of("error", "bad", "code", "RUNTIME_007")).get("error"));
+        assertEquals("server_error", ApiVersion.errorType(502));
+    }
+
+    @Test
+    void sunsetDatesAreHttpDates() {
+        assertEquals("Thu, 01 Jul 2027 00:00:00 GMT", ApiVersionFilter.httpDate("2027-07-01"));
+    }
+}